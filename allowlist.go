@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// parseIPAllowlist 解析 IP 或 CIDR 列表 (逗号、空白或换行分隔), 忽略无法识别的条目。
+func parseIPAllowlist(value string) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, entry := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' || r == '\n' || r == '\t' }) {
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		if addr, err := netip.ParseAddr(entry); err == nil {
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+		}
+	}
+	return prefixes
+}
+
+func normalizeIPAllowlist(value string) string {
+	prefixes := parseIPAllowlist(value)
+	entries := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		if prefix.IsSingleIP() {
+			entries = append(entries, prefix.Addr().String())
+			continue
+		}
+		entries = append(entries, prefix.String())
+	}
+	return strings.Join(entries, ",")
+}
+
+// remoteAddr 返回直接连接方的地址, 不读取 X-Forwarded-For: 该头可以被客户端伪造。
+func remoteAddr(r *http.Request) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// withIPAllowlist 在配置了 ip_allowlist 时拒绝列表之外的来源; 本机地址始终放行, 避免误配置后无法恢复。
+func (s *webServer) withIPAllowlist(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefixes := parseIPAllowlist(s.configSnapshot().IPAllowlist)
+		if len(prefixes) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		addr, ok := remoteAddr(r)
+		if ok && addr.IsLoopback() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if ok {
+			for _, prefix := range prefixes {
+				if prefix.Contains(addr) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+		}
+		logInfo("拒绝不在 IP 白名单中的请求: remote=%s path=%s", r.RemoteAddr, r.URL.Path)
+		writeError(w, http.StatusForbidden, errCodeIPNotAllowed, "来源地址不在 IP 白名单中")
+	})
+}
+
+// isLoopbackListen 判断监听地址是否只绑定本机; 主机部分为空 (如 ":8080") 表示监听全部网卡。
+func isLoopbackListen(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip, err := netip.ParseAddr(host)
+	return err == nil && ip.IsLoopback()
+}
+
+// checkListenSecurity 在启动前检查监听地址: 绑定非本机地址且未配置 server_users 时拒绝启动
+// (除非指定 --allow-insecure-listen), 已配置用户但未启用 HTTPS 时只输出警告, 便于放在反向代理之后。
+func checkListenSecurity(cfg *cliConfig) error {
+	if isLoopbackListen(cfg.ServeAddr) {
+		return nil
+	}
+	tls := strings.TrimSpace(cfg.TLSCert) != "" && strings.TrimSpace(cfg.TLSKey) != ""
+	if len(parseServerUsers(cfg.ServerUsers)) == 0 {
+		if !cfg.AllowInsecureListen {
+			return errors.New("监听地址 " + cfg.ServeAddr + " 不是本机地址, 但未配置 server_users, 任何能访问该端口的人都可以读取对话与 Token; " +
+				"请先配置用户, 或改为监听 127.0.0.1, 确认有其他防护时可指定 --allow-insecure-listen")
+		}
+		logInfo("警告: 监听非本机地址 %s 且未启用认证, 请确认已通过防火墙或 ip_allowlist 限制访问", cfg.ServeAddr)
+	}
+	if !tls {
+		logInfo("警告: 监听非本机地址 %s 但未启用 HTTPS, 用户 Token 将以明文传输; 可指定 --tls-cert/--tls-key 或放在反向代理之后", cfg.ServeAddr)
+	}
+	return nil
+}
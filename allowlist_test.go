@@ -0,0 +1,91 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNormalizeIPAllowlist(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "空配置", value: "", want: ""},
+		{name: "单个地址与网段", value: "192.168.1.10, 10.0.0.0/8", want: "192.168.1.10,10.0.0.0/8"},
+		{name: "网段按掩码对齐", value: "10.1.2.3/16", want: "10.1.0.0/16"},
+		{name: "空白与换行分隔", value: "203.0.113.5\n2001:db8::/32\t::1", want: "203.0.113.5,2001:db8::/32,::1"},
+		{name: "IPv4 映射地址", value: "::ffff:192.0.2.1", want: "192.0.2.1"},
+		{name: "忽略无法识别的条目", value: "example.com,300.1.1.1,10.0.0.0/40,198.51.100.7", want: "198.51.100.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeIPAllowlist(tt.value); got != tt.want {
+				t.Errorf("normalizeIPAllowlist(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithIPAllowlist(t *testing.T) {
+	tests := []struct {
+		name      string
+		allowlist string
+		remote    string
+		forwarded string
+		want      int
+	}{
+		{name: "未配置白名单", allowlist: "", remote: "203.0.113.9:5000", want: http.StatusNoContent},
+		{name: "网段内", allowlist: "10.0.0.0/8", remote: "10.20.30.40:5000", want: http.StatusNoContent},
+		{name: "单个地址", allowlist: "203.0.113.9", remote: "203.0.113.9:5000", want: http.StatusNoContent},
+		{name: "IPv4 映射的 IPv6 来源", allowlist: "203.0.113.0/24", remote: "[::ffff:203.0.113.9]:5000", want: http.StatusNoContent},
+		{name: "不在白名单中", allowlist: "10.0.0.0/8", remote: "203.0.113.9:5000", want: http.StatusForbidden},
+		{name: "本机始终放行", allowlist: "10.0.0.0/8", remote: "127.0.0.1:5000", want: http.StatusNoContent},
+		{name: "本机 IPv6 始终放行", allowlist: "10.0.0.0/8", remote: "[::1]:5000", want: http.StatusNoContent},
+		{name: "不信任 X-Forwarded-For", allowlist: "10.0.0.0/8", remote: "203.0.113.9:5000", forwarded: "10.0.0.1", want: http.StatusForbidden},
+		{name: "无法解析的来源", allowlist: "10.0.0.0/8", remote: "unknown", want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &webServer{cfg: &cliConfig{IPAllowlist: tt.allowlist}}
+			handler := s.withIPAllowlist(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+			r.RemoteAddr = tt.remote
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, r)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCheckListenSecurity(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     cliConfig
+		wantErr bool
+	}{
+		{name: "本机地址", cfg: cliConfig{ServeAddr: "127.0.0.1:8080"}},
+		{name: "localhost", cfg: cliConfig{ServeAddr: "localhost:8080"}},
+		{name: "本机 IPv6", cfg: cliConfig{ServeAddr: "[::1]:8080"}},
+		{name: "全部网卡且未配置用户", cfg: cliConfig{ServeAddr: ":8080"}, wantErr: true},
+		{name: "外部地址且未配置用户", cfg: cliConfig{ServeAddr: "0.0.0.0:8080"}, wantErr: true},
+		{name: "显式允许不安全监听", cfg: cliConfig{ServeAddr: ":8080", AllowInsecureListen: true}},
+		{name: "已配置用户", cfg: cliConfig{ServeAddr: ":8080", ServerUsers: "alice:admin:t"}},
+		{name: "用户配置无效", cfg: cliConfig{ServeAddr: ":8080", ServerUsers: "alice:root:t"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkListenSecurity(&tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("checkListenSecurity() err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
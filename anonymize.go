@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Devoty/openai-backup/anonymize"
+)
+
+// dumpAnonymizedConversation 拉取单个对话, 匿名化后以 JSON 写入 w, 供附在问题报告中。
+func dumpAnonymizedConversation(ctx context.Context, cfg *cliConfig, conversationID string, w io.Writer) error {
+	token := strings.TrimSpace(cfg.Token)
+	if token == "" {
+		return errChatGPTTokenMissing
+	}
+	conv, err := newChatGPTClient(cfg, token).Conversation(ctx, conversationID)
+	if err != nil {
+		return fmt.Errorf("获取对话 %s 详情失败: %w", conversationID, err)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(anonymize.Conversation(conv)); err != nil {
+		return fmt.Errorf("输出匿名化对话失败: %w", err)
+	}
+	logInfo("已输出匿名化对话: id=%s 节点数=%d", conversationID, len(conv.Mapping))
+	return nil
+}
@@ -0,0 +1,198 @@
+// Package anonymize 把对话详情中的私人内容替换为等长占位文本, 保留消息树结构与元数据形状,
+// 用于在问题报告中附带可复现的数据。
+package anonymize
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"unicode"
+
+	"github.com/Devoty/openai-backup/client"
+)
+
+const lorem = "loremipsumdolorsitametconsecteturadipiscingelitseddoeiusmodtemporincididuntutlaboreetdoloremagnaaliqua"
+
+// structuralKeys 的字符串值描述类型或状态, 不含用户内容, 原样保留以便复现解析与渲染逻辑。
+var structuralKeys = map[string]bool{
+	"content_type":  true,
+	"type":          true,
+	"role":          true,
+	"status":        true,
+	"recipient":     true,
+	"language":      true,
+	"format":        true,
+	"mime_type":     true,
+	"mimeType":      true,
+	"model_slug":    true,
+	"default_model": true,
+	"finish_type":   true,
+	"stop_reason":   true,
+	"message_type":  true,
+	"source":        true,
+}
+
+// idKeys 的字符串值是 ID, 替换为摘要以保留引用关系。
+var idKeys = map[string]bool{
+	"id":              true,
+	"message_id":      true,
+	"parent_id":       true,
+	"parent":          true,
+	"conversation_id": true,
+	"request_id":      true,
+	"gen_id":          true,
+	"file_id":         true,
+	"fileId":          true,
+	"current_node":    true,
+}
+
+// Conversation 返回匿名化后的对话副本, 不修改传入的对话:
+//   - 对话、节点与消息 ID 替换为摘要, 父子关系保持一致;
+//   - 标题与消息文本替换为等长的 lorem ipsum, 保留空白与 ASCII 标点 (Markdown、代码与公式结构);
+//   - 元数据保留键名与数值, 字符串按上述规则处理, 文件指针只替换 ID 部分。
+func Conversation(conv *client.Conversation) *client.Conversation {
+	out := &client.Conversation{
+		ID:         HashID(conv.ID),
+		Title:      Text(conv.Title),
+		CreateTime: conv.CreateTime,
+		UpdateTime: conv.UpdateTime,
+		Mapping:    make(map[string]client.Node, len(conv.Mapping)),
+	}
+	for key, node := range conv.Mapping {
+		anon := client.Node{
+			ID:       HashID(node.ID),
+			Parent:   HashID(node.Parent),
+			Metadata: scrubRaw(node.Metadata),
+		}
+		for _, child := range node.Children {
+			anon.Children = append(anon.Children, HashID(child))
+		}
+		if node.Message != nil {
+			anon.Message = message(node.Message)
+		}
+		out.Mapping[HashID(key)] = anon
+	}
+	return out
+}
+
+func message(msg *client.Message) *client.Message {
+	anon := *msg
+	anon.ID = HashID(msg.ID)
+	anon.Content = client.Content{
+		ContentType:      msg.Content.ContentType,
+		Text:             Text(msg.Content.Text),
+		UserProfile:      Text(msg.Content.UserProfile),
+		UserInstructions: Text(msg.Content.UserInstructions),
+	}
+	for _, part := range msg.Content.Parts {
+		anon.Content.Parts = append(anon.Content.Parts, scrubRaw(part))
+	}
+	anon.Metadata = scrubRaw(msg.Metadata)
+	anon.Extras = scrubRaw(msg.Extras)
+	anon.Attachments = scrubRaw(msg.Attachments)
+	return &anon
+}
+
+// HashID 把 ID 替换为稳定的摘要; 原 ID 为 UUID 时输出同样的 8-4-4-4-12 格式。
+func HashID(id string) string {
+	if id == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(id))
+	digest := hex.EncodeToString(sum[:])
+	if len(id) == 36 && strings.Count(id, "-") == 4 {
+		return digest[:8] + "-" + digest[8:12] + "-" + digest[12:16] + "-" + digest[16:20] + "-" + digest[20:32]
+	}
+	if prefix, _, ok := strings.Cut(id, "-"); ok && prefix != "" && len(prefix) <= 8 {
+		// 保留 file-、msg- 一类前缀, 便于识别 ID 类型。
+		return prefix + "-" + digest[:24]
+	}
+	return digest[:24]
+}
+
+// Text 把文本替换为等长 (按字符计) 的 lorem ipsum, 空白与 ASCII 标点保持原位, 大小写跟随原文;
+// 代码块的围栏行 (含语言标记) 原样保留。
+func Text(text string) string {
+	if text == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.Grow(len(text))
+	i := 0
+	for n, line := range strings.Split(text, "\n") {
+		if n > 0 {
+			b.WriteByte('\n')
+		}
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			b.WriteString(line)
+			continue
+		}
+		for _, r := range line {
+			if unicode.IsSpace(r) || r < unicode.MaxASCII && (unicode.IsPunct(r) || unicode.IsSymbol(r)) {
+				b.WriteRune(r)
+				continue
+			}
+			c := rune(lorem[i%len(lorem)])
+			i++
+			if unicode.IsUpper(r) {
+				c = unicode.ToUpper(c)
+			}
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// scrubRaw 匿名化任意 JSON, 解析失败时返回空值而不是原文, 避免泄露。
+func scrubRaw(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 {
+		return raw
+	}
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return json.RawMessage("null")
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(scrubValue("", value)); err != nil {
+		return json.RawMessage("null")
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n")
+}
+
+func scrubValue(key string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			out[k] = scrubValue(k, item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = scrubValue(key, item)
+		}
+		return out
+	case string:
+		return scrubString(key, v)
+	default:
+		return v
+	}
+}
+
+func scrubString(key, value string) string {
+	switch {
+	case structuralKeys[key]:
+		return value
+	case idKeys[key] || key == "children":
+		return HashID(value)
+	}
+	if scheme, rest, ok := strings.Cut(value, "://"); ok && (scheme == "file-service" || scheme == "sediment") {
+		return scheme + "://" + HashID(rest)
+	}
+	return Text(value)
+}
@@ -0,0 +1,128 @@
+package anonymize
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Devoty/openai-backup/client"
+)
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "空文本", text: "", want: ""},
+		{name: "保留标点与大小写", text: "Hello, World!", want: "Lorem, Ipsum!"},
+		{name: "非 ASCII 字符按字符替换", text: "你好 世界", want: "lo re"},
+		{name: "保留 Markdown 结构", text: "# Ab\n\n- [x](y)", want: "# Lo\n\n- [r](e)"},
+		{name: "保留代码围栏行", text: "```go\nfmt\n```", want: "```go\nlor\n```"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Text(tt.text); got != tt.want {
+				t.Errorf("Text(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHashID(t *testing.T) {
+	tests := []struct {
+		name  string
+		id    string
+		check func(got string) bool
+	}{
+		{name: "空 ID", id: "", check: func(got string) bool { return got == "" }},
+		{name: "UUID 保留格式", id: "6650f3a2-1b2c-4d5e-8f90-0123456789ab", check: func(got string) bool {
+			return len(got) == 36 && strings.Count(got, "-") == 4 && got != "6650f3a2-1b2c-4d5e-8f90-0123456789ab"
+		}},
+		{name: "保留短前缀", id: "file-AbCdEf123", check: func(got string) bool {
+			return strings.HasPrefix(got, "file-") && len(got) == len("file-")+24
+		}},
+		{name: "无前缀", id: "client_created_root", check: func(got string) bool { return len(got) == 24 && !strings.Contains(got, "-") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := HashID(tt.id)
+			if !tt.check(got) {
+				t.Errorf("HashID(%q) = %q", tt.id, got)
+			}
+			if HashID(tt.id) != got {
+				t.Errorf("HashID(%q) 结果不稳定", tt.id)
+			}
+		})
+	}
+}
+
+func TestScrubRaw(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{name: "空值", raw: "", want: ""},
+		{name: "无法解析时不输出原文", raw: `{"secret":`, want: "null"},
+		{name: "保留结构字段与数值", raw: `{"content_type":"text","count":3,"ok":true}`, want: `{"content_type":"text","count":3,"ok":true}`},
+		{name: "替换普通字符串", raw: `{"name":"Alice"}`, want: `{"name":"Lorem"}`},
+		{name: "文件指针只替换 ID", raw: `{"asset_pointer":"file-service://file-abc"}`, want: `{"asset_pointer":"file-service://` + HashID("file-abc") + `"}`},
+		{name: "数组中的 ID", raw: `{"children":["a","b"]}`, want: `{"children":["` + HashID("a") + `","` + HashID("b") + `"]}`},
+		{name: "不转义 HTML 字符", raw: `["<&>"]`, want: `["<&>"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(scrubRaw(json.RawMessage(tt.raw))); got != tt.want {
+				t.Errorf("scrubRaw(%s) = %s, want %s", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConversation(t *testing.T) {
+	part, _ := json.Marshal("我的密码是 hunter2")
+	conv := &client.Conversation{
+		ID:    "6650f3a2-1b2c-4d5e-8f90-0123456789ab",
+		Title: "Private plans",
+		Mapping: map[string]client.Node{
+			"root": {ID: "root", Children: []string{"m1"}},
+			"m1": {
+				ID:     "m1",
+				Parent: "root",
+				Message: &client.Message{
+					ID:       "m1",
+					Author:   client.Author{Role: "user"},
+					Content:  client.Content{ContentType: "text", Parts: []json.RawMessage{part}},
+					Metadata: json.RawMessage(`{"model_slug":"gpt-4o","parent_id":"root"}`),
+				},
+			},
+		},
+	}
+	anon := Conversation(conv)
+
+	if conv.Title != "Private plans" || conv.Mapping["m1"].Message.ID != "m1" {
+		t.Fatal("不应修改传入的对话")
+	}
+	if anon.ID == conv.ID || len(anon.ID) != len(conv.ID) || anon.Title != "Loremip sumdo" {
+		t.Errorf("ID = %q Title = %q", anon.ID, anon.Title)
+	}
+	root, ok := anon.Mapping[HashID("root")]
+	if !ok || len(root.Children) != 1 {
+		t.Fatalf("根节点缺失: %+v", anon.Mapping)
+	}
+	child, ok := anon.Mapping[root.Children[0]]
+	if !ok || child.Parent != root.ID || child.Message == nil || child.Message.ID != child.ID {
+		t.Fatalf("父子关系未保持: %+v", child)
+	}
+	msg := child.Message
+	if msg.Author.Role != "user" || msg.Content.ContentType != "text" {
+		t.Errorf("角色与内容类型应保留: %+v", msg)
+	}
+	if text := string(msg.Content.Parts[0]); strings.Contains(text, "hunter2") || strings.Contains(text, "密码") {
+		t.Errorf("消息正文未匿名化: %s", text)
+	}
+	if want := `{"model_slug":"gpt-4o","parent_id":"` + HashID("root") + `"}`; string(msg.Metadata) != want {
+		t.Errorf("Metadata = %s, want %s", msg.Metadata, want)
+	}
+}
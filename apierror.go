@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/Devoty/openai-backup/client"
+	"github.com/Devoty/openai-backup/diskspace"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+// 接口错误码, 前端与自动化脚本按 code 分支, 不再匹配 message 文本。
+const (
+	errCodeInvalidRequest    = "invalid_request"
+	errCodeNotFound          = "not_found"
+	errCodeMethodNotAllowed  = "method_not_allowed"
+	errCodeConflict          = "conflict"
+	errCodeUnsupportedFormat = "unsupported_format"
+	errCodeCanceled          = "canceled"
+	errCodeInternal          = "internal_error"
+
+	errCodeInsufficientStorage = "insufficient_storage"
+
+	errCodeUnauthorized = "unauthorized"
+	errCodeForbidden    = "forbidden"
+	errCodeIPNotAllowed = "ip_not_allowed"
+	errCodeConfigLocked = "config_locked"
+
+	errCodeHookDisabled     = "hook_disabled"
+	errCodeHookUnauthorized = "hook_unauthorized"
+
+	errCodeChatGPTTokenMissing = "chatgpt_token_missing"
+	errCodeChatGPTUnauthorized = "chatgpt_unauthorized"
+	errCodeChatGPTRateLimited  = "chatgpt_rate_limited"
+	errCodeChatGPTNotFound     = "chatgpt_not_found"
+	errCodeChatGPTForbidden    = "chatgpt_forbidden"
+	errCodeChatGPTUnavailable  = "chatgpt_unavailable"
+	errCodeChatGPTError        = "chatgpt_error"
+
+	errCodeTargetMisconfigured = "target_misconfigured"
+	errCodeTargetUnauthorized  = "target_unauthorized"
+	errCodeTargetRateLimited   = "target_rate_limited"
+	errCodeTargetUnavailable   = "target_unavailable"
+	errCodeTargetRejected      = "target_rejected"
+	errCodeTargetError         = "target_error"
+
+	errCodeGoogleAuthFailed = "google_auth_failed"
+)
+
+var errChatGPTTokenMissing = errors.New("缺少 OpenAI Token, 请先在配置页填写")
+
+// apiError 是接口返回的结构化错误: message 面向用户, detail 为底层错误原文。
+type apiError struct {
+	Status  int    `json:"-"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+func (e *apiError) Error() string {
+	if e.Detail == "" {
+		return e.Message
+	}
+	return e.Message + ": " + e.Detail
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeAPIError(w, &apiError{Status: status, Code: code, Message: message})
+}
+
+// writeErrorDetail 与 writeError 相同, 额外把 err 原文放入 detail。
+func writeErrorDetail(w http.ResponseWriter, status int, code, message string, err error) {
+	writeAPIError(w, &apiError{Status: status, Code: code, Message: message, Detail: errorDetail(err)})
+}
+
+func writeAPIError(w http.ResponseWriter, e *apiError) {
+	status := e.Status
+	if status < 400 {
+		status = http.StatusBadRequest
+	}
+	writeJSON(w, status, e)
+}
+
+func errorDetail(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// chatgptError 按 ChatGPT 接口的失败原因区分 Token 缺失/失效、限流与服务不可用。
+func chatgptError(message string, err error) *apiError {
+	e := &apiError{Status: http.StatusBadGateway, Code: errCodeChatGPTError, Message: message, Detail: errorDetail(err)}
+	var statusErr *client.StatusError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, errChatGPTTokenMissing):
+		e.Status, e.Code = http.StatusBadRequest, errCodeChatGPTTokenMissing
+	case errors.As(err, &statusErr):
+		switch {
+		case isConversationForbidden(statusErr):
+			e.Code = errCodeChatGPTForbidden
+		case statusErr.Status == http.StatusUnauthorized || statusErr.Status == http.StatusForbidden:
+			e.Code = errCodeChatGPTUnauthorized
+		case statusErr.Status == http.StatusTooManyRequests:
+			e.Code = errCodeChatGPTRateLimited
+		case statusErr.Status == http.StatusNotFound:
+			e.Code = errCodeChatGPTNotFound
+		case statusErr.Status >= 500:
+			e.Code = errCodeChatGPTUnavailable
+		}
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr):
+		e.Code = errCodeChatGPTUnavailable
+	}
+	return e
+}
+
+// targetError 按导出目标的失败原因区分鉴权失败、限流、服务不可用与请求被拒绝。
+func targetError(message string, err error) *apiError {
+	e := &apiError{Status: http.StatusBadGateway, Code: errCodeTargetError, Message: message, Detail: errorDetail(err)}
+	var statusErr *targets.StatusError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &statusErr):
+		switch {
+		case statusErr.Status == http.StatusUnauthorized || statusErr.Status == http.StatusForbidden:
+			e.Code = errCodeTargetUnauthorized
+		case statusErr.Status == http.StatusTooManyRequests:
+			e.Code = errCodeTargetRateLimited
+		case statusErr.Status >= 500:
+			e.Code = errCodeTargetUnavailable
+		default:
+			e.Code = errCodeTargetRejected
+		}
+	case errors.Is(err, errTargetUnavailable), errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr):
+		e.Code = errCodeTargetUnavailable
+	case errors.Is(err, diskspace.ErrInsufficient):
+		e.Status, e.Code = http.StatusInsufficientStorage, errCodeInsufficientStorage
+	}
+	return e
+}
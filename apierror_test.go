@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Devoty/openai-backup/client"
+	"github.com/Devoty/openai-backup/diskspace"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+func TestChatGPTError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "缺少 Token", err: fmt.Errorf("读取列表: %w", errChatGPTTokenMissing), wantStatus: http.StatusBadRequest, wantCode: errCodeChatGPTTokenMissing},
+		{name: "Token 失效", err: &client.StatusError{Status: http.StatusUnauthorized}, wantStatus: http.StatusBadGateway, wantCode: errCodeChatGPTUnauthorized},
+		{name: "无权访问对话", err: &client.StatusError{Action: client.ActionConversationDetail, Status: http.StatusForbidden, Body: `{"detail":"forbidden"}`}, wantStatus: http.StatusBadGateway, wantCode: errCodeChatGPTForbidden},
+		{name: "Cloudflare 拦截", err: &client.StatusError{Action: client.ActionConversationDetail, Status: http.StatusForbidden, Body: "<html>"}, wantStatus: http.StatusBadGateway, wantCode: errCodeChatGPTUnauthorized},
+		{name: "限流", err: &client.StatusError{Status: http.StatusTooManyRequests}, wantStatus: http.StatusBadGateway, wantCode: errCodeChatGPTRateLimited},
+		{name: "对话不存在", err: &client.StatusError{Status: http.StatusNotFound}, wantStatus: http.StatusBadGateway, wantCode: errCodeChatGPTNotFound},
+		{name: "服务不可用", err: &client.StatusError{Status: http.StatusServiceUnavailable}, wantStatus: http.StatusBadGateway, wantCode: errCodeChatGPTUnavailable},
+		{name: "超时", err: context.DeadlineExceeded, wantStatus: http.StatusBadGateway, wantCode: errCodeChatGPTUnavailable},
+		{name: "其他错误", err: errors.New("解析失败"), wantStatus: http.StatusBadGateway, wantCode: errCodeChatGPTError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := chatgptError("读取对话失败", tt.err)
+			if e.Status != tt.wantStatus || e.Code != tt.wantCode || e.Detail != tt.err.Error() {
+				t.Errorf("chatgptError() = %+v, want %d %s", e, tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestTargetError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "鉴权失败", err: &targets.StatusError{Status: http.StatusForbidden}, wantStatus: http.StatusBadGateway, wantCode: errCodeTargetUnauthorized},
+		{name: "限流", err: &targets.StatusError{Status: http.StatusTooManyRequests}, wantStatus: http.StatusBadGateway, wantCode: errCodeTargetRateLimited},
+		{name: "服务不可用", err: &targets.StatusError{Status: http.StatusInternalServerError}, wantStatus: http.StatusBadGateway, wantCode: errCodeTargetUnavailable},
+		{name: "请求被拒绝", err: &targets.StatusError{Status: http.StatusUnprocessableEntity}, wantStatus: http.StatusBadGateway, wantCode: errCodeTargetRejected},
+		{name: "熔断后放弃", err: fmt.Errorf("%w: timeout", errTargetUnavailable), wantStatus: http.StatusBadGateway, wantCode: errCodeTargetUnavailable},
+		{name: "磁盘空间不足", err: fmt.Errorf("写入报告: %w", diskspace.ErrInsufficient), wantStatus: http.StatusInsufficientStorage, wantCode: errCodeInsufficientStorage},
+		{name: "其他错误", err: errors.New("未知"), wantStatus: http.StatusBadGateway, wantCode: errCodeTargetError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := targetError("写入目标失败", tt.err)
+			if e.Status != tt.wantStatus || e.Code != tt.wantCode {
+				t.Errorf("targetError() = %+v, want %d %s", e, tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestWriteAPIError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        *apiError
+		wantStatus int
+		wantText   string
+	}{
+		{name: "没有 detail", err: &apiError{Status: http.StatusNotFound, Code: errCodeNotFound, Message: "对话不存在"}, wantStatus: http.StatusNotFound, wantText: "对话不存在"},
+		{name: "附带 detail", err: &apiError{Status: http.StatusBadGateway, Code: errCodeChatGPTError, Message: "读取失败", Detail: "EOF"}, wantStatus: http.StatusBadGateway, wantText: "读取失败: EOF"},
+		{name: "状态码无效时按 400 返回", err: &apiError{Code: errCodeInvalidRequest, Message: "参数错误"}, wantStatus: http.StatusBadRequest, wantText: "参数错误"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			writeAPIError(rec, tt.err)
+			var body map[string]string
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != tt.wantStatus || body["code"] != tt.err.Code || body["message"] != tt.err.Message || body["detail"] != tt.err.Detail {
+				t.Errorf("响应 = %d %v", rec.Code, body)
+			}
+			if tt.err.Error() != tt.wantText {
+				t.Errorf("Error() = %q, want %q", tt.err.Error(), tt.wantText)
+			}
+		})
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	s := &webServer{}
+	tests := []struct {
+		name    string
+		method  string
+		handler http.HandlerFunc
+	}{
+		{name: "对话列表只接受 GET", method: http.MethodPost, handler: s.handleConversationList},
+		{name: "合并对话只接受 POST", method: http.MethodGet, handler: s.handleConversationMerge},
+		{name: "批量操作只接受 POST", method: http.MethodPut, handler: s.handleBatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.handler(rec, httptest.NewRequest(tt.method, "/", nil))
+			var body map[string]string
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("响应不是 JSON: %q", rec.Body.String())
+			}
+			if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Content-Type") != "application/json" || body["code"] != errCodeMethodNotAllowed || body["message"] == "" {
+				t.Errorf("响应 = %d %s %v", rec.Code, rec.Header().Get("Content-Type"), body)
+			}
+		})
+	}
+}
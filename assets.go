@@ -0,0 +1,58 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"strings"
+
+	"github.com/Devoty/openai-backup/export"
+)
+
+// fetchAsset 使用当前配置下载 ChatGPT 文件, 供导出目标上传图片等场景使用。
+func (s *webServer) fetchAsset(ctx context.Context, pointer string) ([]byte, error) {
+	cfg := s.configSnapshot()
+	return s.downloadFile(ctx, newChatGPTClient(cfg, strings.TrimSpace(cfg.Token)), pointer)
+}
+
+// bundleConversationAssets 下载对话中指定类型的文件写入压缩包, 并回填相对路径。
+// 单个文件失败只记录日志, 导出内容中保留原始指针。
+func (s *webServer) bundleConversationAssets(ctx context.Context, archive *zip.Writer, conv *export.Conversation, kinds map[string]bool, written map[string]bool) {
+	cfg := s.configSnapshot()
+	chatgpt := newChatGPTClient(cfg, strings.TrimSpace(cfg.Token))
+	// 复制消息与文件列表, 避免修改详情缓存中的数据。
+	conv.Messages = append([]export.Message(nil), conv.Messages...)
+	for i := range conv.Messages {
+		msg := &conv.Messages[i]
+		if len(msg.Assets) == 0 {
+			continue
+		}
+		msg.Assets = append([]export.Asset(nil), msg.Assets...)
+		for j := range msg.Assets {
+			asset := &msg.Assets[j]
+			if !kinds[asset.Kind] {
+				continue
+			}
+			archivePath := export.AssetArchivePath(*asset)
+			if written[archivePath] {
+				asset.Path = archivePath
+				continue
+			}
+			data, err := s.downloadFile(ctx, chatgpt, asset.Pointer)
+			if err != nil {
+				logInfo("下载%s失败: conversation=%s pointer=%s err=%v", export.AssetKindLabel(asset.Kind), conv.ID, asset.Pointer, err)
+				continue
+			}
+			writer, err := archive.Create(archivePath)
+			if err != nil {
+				logInfo("写入%s失败: %v", export.AssetKindLabel(asset.Kind), err)
+				continue
+			}
+			if _, err := writer.Write(data); err != nil {
+				logInfo("写入%s失败: %v", export.AssetKindLabel(asset.Kind), err)
+				continue
+			}
+			written[archivePath] = true
+			asset.Path = archivePath
+		}
+	}
+}
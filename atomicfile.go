@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Devoty/openai-backup/atomicfile"
+)
+
+// fileWritesSchema 是写入中的临时文件日志: 写入前登记, 改名完成后删除。服务启动时仍在表中的记录
+// 说明上次进程在写入中途退出, 由 RecoverFileWrites 删除遗留的临时文件。写入方式见 atomicfile 包。
+const fileWritesSchema = `
+	CREATE TABLE IF NOT EXISTS file_writes (
+		temp_path TEXT PRIMARY KEY,
+		path TEXT NOT NULL,
+		started_at TIMESTAMP NOT NULL
+	);`
+
+// BeginFileWrite 登记写入中的临时文件, 实现 atomicfile.Journal。
+func (s *ConfigStore) BeginFileWrite(path, temp string) error {
+	if s == nil || s.config == nil {
+		return errors.New("配置存储未初始化")
+	}
+	_, err := s.config.writer.ExecContext(context.Background(), `
+		INSERT OR REPLACE INTO file_writes(temp_path, path, started_at) VALUES(?, ?, ?)
+	`, temp, path, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("记录写入中的文件失败: %w", err)
+	}
+	return nil
+}
+
+// EndFileWrite 删除临时文件的登记, 实现 atomicfile.Journal。
+func (s *ConfigStore) EndFileWrite(temp string) error {
+	if s == nil || s.config == nil {
+		return errors.New("配置存储未初始化")
+	}
+	if _, err := s.config.writer.ExecContext(context.Background(), `DELETE FROM file_writes WHERE temp_path = ?`, temp); err != nil {
+		return fmt.Errorf("清除写入记录失败: %w", err)
+	}
+	return nil
+}
+
+// PendingFileWrites 按开始时间列出仍未结束的写入, 实现 atomicfile.RecoveryJournal。
+func (s *ConfigStore) PendingFileWrites(ctx context.Context) ([]atomicfile.Pending, error) {
+	if s == nil || s.config == nil {
+		return nil, errors.New("配置存储未初始化")
+	}
+	rows, err := s.config.reader.QueryContext(ctx, `SELECT temp_path, path FROM file_writes ORDER BY started_at`)
+	if err != nil {
+		return nil, fmt.Errorf("读取写入记录失败: %w", err)
+	}
+	defer rows.Close()
+	var pending []atomicfile.Pending
+	for rows.Next() {
+		var write atomicfile.Pending
+		if err := rows.Scan(&write.Temp, &write.Path); err != nil {
+			return nil, fmt.Errorf("解析写入记录失败: %w", err)
+		}
+		pending = append(pending, write)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取写入记录失败: %w", err)
+	}
+	return pending, nil
+}
+
+// RecoverFileWrites 删除上次进程在写入中途退出时遗留的临时文件, 返回未完成写入的目标路径。
+func (s *ConfigStore) RecoverFileWrites(ctx context.Context) ([]string, error) {
+	return atomicfile.Recover(ctx, s)
+}
@@ -0,0 +1,148 @@
+// Package atomicfile 以临时文件加改名的方式写入文件: 内容与目录项都先落盘, 断电或进程中途退出后
+// 目标路径要么是旧内容要么是完整的新内容。写入中的临时文件登记在 Journal 中, 下次启动时由 Recover
+// 删除遗留的临时文件。主程序与写入本地目录的导出目标共用这一写入方式。
+package atomicfile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/Devoty/openai-backup/logging"
+)
+
+// Journal 记录写入中的临时文件: 写入前登记, 改名完成或放弃后删除。为 nil 时不记录。
+type Journal interface {
+	BeginFileWrite(path, temp string) error
+	EndFileWrite(temp string) error
+}
+
+// Pending 是 Journal 中仍未结束的一次写入。
+type Pending struct {
+	Temp string
+	Path string
+}
+
+// RecoveryJournal 是可以列出未结束写入的 Journal, 供 Recover 使用。
+type RecoveryJournal interface {
+	Journal
+	PendingFileWrites(ctx context.Context) ([]Pending, error)
+}
+
+// File 是写入目标文件时使用的临时文件, 位于同一目录, Commit 后才出现在目标路径上。
+type File struct {
+	*os.File
+	path    string
+	journal Journal
+}
+
+// Create 在 path 所在目录创建临时文件并登记到 journal。
+func Create(journal Journal, path string) (*File, error) {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	if journal != nil {
+		if err := journal.BeginFileWrite(path, file.Name()); err != nil {
+			file.Close()
+			os.Remove(file.Name())
+			return nil, err
+		}
+	}
+	return &File{File: file, path: path, journal: journal}, nil
+}
+
+// Commit 把临时文件落盘后改名为目标路径, 并同步目录, 断电后目标路径要么是旧内容要么是完整的新内容。
+func (f *File) Commit(perm os.FileMode) error {
+	if err := f.Sync(); err != nil {
+		f.Abort()
+		return fmt.Errorf("同步文件失败: %w", err)
+	}
+	if err := f.Chmod(perm); err != nil {
+		f.Abort()
+		return fmt.Errorf("设置文件权限失败: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		f.Abort()
+		return fmt.Errorf("关闭文件失败: %w", err)
+	}
+	if err := os.Rename(f.Name(), f.path); err != nil {
+		f.Abort()
+		return fmt.Errorf("重命名文件失败: %w", err)
+	}
+	syncDir(filepath.Dir(f.path))
+	f.end()
+	return nil
+}
+
+// Abort 删除临时文件, 目标路径保持不变。Commit 失败时已自动调用。
+func (f *File) Abort() {
+	f.Close()
+	os.Remove(f.Name())
+	f.end()
+}
+
+func (f *File) end() {
+	if f.journal == nil {
+		return
+	}
+	if err := f.journal.EndFileWrite(f.Name()); err != nil {
+		logging.Infof("清除写入记录失败: %v", err)
+	}
+}
+
+// WriteFile 以临时文件加改名的方式写入 path, 代替 os.WriteFile。
+func WriteFile(journal Journal, path string, data []byte, perm os.FileMode) error {
+	return WriteFrom(journal, path, perm, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// WriteFrom 与 WriteFile 相同, 内容由 write 写入。
+func WriteFrom(journal Journal, path string, perm os.FileMode, write func(io.Writer) error) error {
+	file, err := Create(journal, path)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Abort()
+		return err
+	}
+	return file.Commit(perm)
+}
+
+// Recover 删除上次进程在写入中途退出时遗留的临时文件, 返回未完成写入的目标路径。
+// 目标路径上的文件 (如有) 仍是写入前的完整内容。
+func Recover(ctx context.Context, journal RecoveryJournal) ([]string, error) {
+	pending, err := journal.PendingFileWrites(ctx)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(pending))
+	for _, write := range pending {
+		if err := os.Remove(write.Temp); err != nil && !errors.Is(err, os.ErrNotExist) {
+			logging.Infof("删除未完成的临时文件失败: %s err=%v", write.Temp, err)
+			continue
+		}
+		if err := journal.EndFileWrite(write.Temp); err != nil {
+			return nil, err
+		}
+		paths = append(paths, write.Path)
+		logging.Infof("上次退出时 %s 未写入完成, 已删除临时文件 %s", write.Path, write.Temp)
+	}
+	return paths, nil
+}
+
+// syncDir 同步目录项, 使改名在断电后仍然有效; 部分平台不支持对目录 fsync, 失败时忽略。
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
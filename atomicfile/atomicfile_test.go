@@ -0,0 +1,195 @@
+package atomicfile
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// memoryJournal 在内存中记录写入, beginErr 非空时拒绝登记。
+type memoryJournal struct {
+	pending  map[string]string
+	began    int
+	beginErr error
+}
+
+func newMemoryJournal() *memoryJournal {
+	return &memoryJournal{pending: make(map[string]string)}
+}
+
+func (j *memoryJournal) BeginFileWrite(path, temp string) error {
+	if j.beginErr != nil {
+		return j.beginErr
+	}
+	j.began++
+	j.pending[temp] = path
+	return nil
+}
+
+func (j *memoryJournal) EndFileWrite(temp string) error {
+	delete(j.pending, temp)
+	return nil
+}
+
+func (j *memoryJournal) PendingFileWrites(ctx context.Context) ([]Pending, error) {
+	var pending []Pending
+	for temp, path := range j.pending {
+		pending = append(pending, Pending{Temp: temp, Path: path})
+	}
+	return pending, nil
+}
+
+func TestWriteFrom(t *testing.T) {
+	errWrite := errors.New("写入中断")
+	errBegin := errors.New("日志不可用")
+	tests := []struct {
+		name     string
+		old      string
+		write    func(w io.Writer) error
+		beginErr error
+		wantErr  error
+		want     string
+	}{
+		{
+			name:  "新建文件",
+			write: func(w io.Writer) error { _, err := io.WriteString(w, "new"); return err },
+			want:  "new",
+		},
+		{
+			name:  "覆盖已有文件",
+			old:   "old",
+			write: func(w io.Writer) error { _, err := io.WriteString(w, "new"); return err },
+			want:  "new",
+		},
+		{
+			name: "写入失败时保留旧内容",
+			old:  "old",
+			write: func(w io.Writer) error {
+				io.WriteString(w, "partial")
+				return errWrite
+			},
+			wantErr: errWrite,
+			want:    "old",
+		},
+		{
+			name:     "登记失败时不写入",
+			old:      "old",
+			write:    func(w io.Writer) error { _, err := io.WriteString(w, "new"); return err },
+			beginErr: errBegin,
+			wantErr:  errBegin,
+			want:     "old",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "report.json")
+			if tt.old != "" {
+				if err := os.WriteFile(path, []byte(tt.old), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			journal := newMemoryJournal()
+			journal.beginErr = tt.beginErr
+			err := WriteFrom(journal, path, 0o644, tt.write)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("内容 = %q, want %q", data, tt.want)
+			}
+			if tt.wantErr == nil {
+				info, err := os.Stat(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if info.Mode().Perm() != 0o644 {
+					t.Errorf("权限 = %v, want 0644", info.Mode().Perm())
+				}
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				t.Errorf("目录中应只剩目标文件, 实际 %d 项", len(entries))
+			}
+			if len(journal.pending) != 0 {
+				t.Errorf("写入结束后日志中仍有 %d 条记录", len(journal.pending))
+			}
+			wantBegan := 1
+			if tt.beginErr != nil {
+				wantBegan = 0
+			}
+			if journal.began != wantBegan {
+				t.Errorf("登记次数 = %d, want %d", journal.began, wantBegan)
+			}
+		})
+	}
+}
+
+func TestWriteFileWithoutJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.md")
+	if err := WriteFile(nil, path, []byte("# a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "# a" {
+		t.Fatalf("内容 = %q, err=%v", data, err)
+	}
+	if err := WriteFile(nil, filepath.Join(t.TempDir(), "missing", "a.md"), []byte("x"), 0o644); err == nil {
+		t.Fatal("目录不存在时应返回错误")
+	}
+}
+
+func TestRecover(t *testing.T) {
+	dir := t.TempDir()
+	journal := newMemoryJournal()
+
+	// 模拟进程在写入中途退出: 临时文件已创建并登记, 但未改名。
+	interrupted := filepath.Join(dir, "a.json")
+	if err := os.WriteFile(interrupted, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := Create(journal, interrupted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString("partial")
+	file.Close()
+
+	// 临时文件已不存在的记录也应清除。
+	vanished := filepath.Join(dir, "b.json")
+	journal.pending[filepath.Join(dir, ".b.json.tmp-gone")] = vanished
+
+	paths, err := Recover(context.Background(), journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(paths)
+	if want := []string{interrupted, vanished}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("Recover() = %v, want %v", paths, want)
+	}
+	if _, err := os.Stat(file.Name()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("遗留的临时文件未删除: err=%v", err)
+	}
+	if data, _ := os.ReadFile(interrupted); string(data) != "old" {
+		t.Errorf("目标文件 = %q, want 写入前的内容", data)
+	}
+	if len(journal.pending) != 0 {
+		t.Errorf("恢复后日志中仍有 %d 条记录", len(journal.pending))
+	}
+
+	paths, err = Recover(context.Background(), journal)
+	if err != nil || len(paths) != 0 {
+		t.Errorf("再次恢复 = %v, err=%v, want 空", paths, err)
+	}
+}
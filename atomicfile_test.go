@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Devoty/openai-backup/atomicfile"
+)
+
+func TestConfigStoreRecoverFileWrites(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	dir := t.TempDir()
+
+	done := filepath.Join(dir, "done.json")
+	if err := atomicfile.WriteFile(store, done, []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	interrupted := filepath.Join(dir, "interrupted.json")
+	file, err := atomicfile.Create(store, interrupted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	pending, err := store.PendingFileWrites(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []atomicfile.Pending{{Temp: file.Name(), Path: interrupted}}; !reflect.DeepEqual(pending, want) {
+		t.Fatalf("PendingFileWrites() = %+v, want %+v", pending, want)
+	}
+
+	paths, err := store.RecoverFileWrites(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(paths, []string{interrupted}) {
+		t.Errorf("RecoverFileWrites() = %v, want [%s]", paths, interrupted)
+	}
+	if _, err := os.Stat(file.Name()); !os.IsNotExist(err) {
+		t.Errorf("遗留的临时文件未删除: err=%v", err)
+	}
+	if pending, err := store.PendingFileWrites(ctx); err != nil || len(pending) != 0 {
+		t.Errorf("恢复后仍有写入记录: %+v err=%v", pending, err)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"strings"
+
+	"github.com/Devoty/openai-backup/export"
+)
+
+// bundleConversationAttachments 下载仍可访问的上传文件写入压缩包, 已过期的文件只保留元数据。
+func (s *webServer) bundleConversationAttachments(ctx context.Context, archive *zip.Writer, conv *export.Conversation, written map[string]bool) {
+	if len(conv.Attachments) == 0 {
+		return
+	}
+	cfg := s.configSnapshot()
+	chatgpt := newChatGPTClient(cfg, strings.TrimSpace(cfg.Token))
+	conv.Attachments = append([]export.Attachment(nil), conv.Attachments...)
+	for i := range conv.Attachments {
+		att := &conv.Attachments[i]
+		archivePath := export.AttachmentArchivePath(*att)
+		if written[archivePath] {
+			att.Path = archivePath
+			continue
+		}
+		data, err := s.downloadFile(ctx, chatgpt, att.ID)
+		if err != nil {
+			logInfo("下载上传文件失败: conversation=%s file=%s err=%v", conv.ID, att.ID, err)
+			continue
+		}
+		writer, err := archive.Create(archivePath)
+		if err != nil {
+			logInfo("写入上传文件失败: %v", err)
+			continue
+		}
+		if _, err := writer.Write(data); err != nil {
+			logInfo("写入上传文件失败: %v", err)
+			continue
+		}
+		written[archivePath] = true
+		att.Path = archivePath
+	}
+}
+
+// bundleFullTexts 把截断前的消息全文写入压缩包, 并在消息末尾附上指向全文的链接, 见 max_message_chars。
+func bundleFullTexts(archive *zip.Writer, conv *export.Conversation) {
+	for i := range conv.Messages {
+		msg := &conv.Messages[i]
+		if msg.FullText == "" {
+			continue
+		}
+		archivePath := export.FullTextArchivePath(*conv, i)
+		writer, err := archive.Create(archivePath)
+		if err == nil {
+			_, err = writer.Write([]byte(msg.FullText))
+		}
+		msg.FullText = ""
+		if err != nil {
+			logInfo("写入消息全文失败: conversation=%s message=%d err=%v", conv.ID, i+1, err)
+			continue
+		}
+		msg.Assets = append(append([]export.Asset(nil), msg.Assets...), export.Asset{Kind: export.AssetFullText, Path: archivePath})
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// 用户角色, 权限逐级包含: viewer 只能浏览与预览归档, operator 额外可以执行导出与导入,
+// admin 额外可以修改配置、删除 ChatGPT 上的对话与维护数据库。
+const (
+	roleViewer   = "viewer"
+	roleOperator = "operator"
+	roleAdmin    = "admin"
+)
+
+var roleLevels = map[string]int{roleViewer: 1, roleOperator: 2, roleAdmin: 3}
+
+// serverUser 是 server_users 中的一项。通过 API Token 认证时 scopes 为该 Token 的权限范围,
+// 登录用户的 scopes 为 nil, 不受限制。
+type serverUser struct {
+	name   string
+	role   string
+	token  string
+	scopes []string
+}
+
+func (u serverUser) allows(role string) bool {
+	return roleLevels[u.role] >= roleLevels[role]
+}
+
+// permits 判断用户能否访问需要 role 的请求 r; API Token 还需授予对应的权限范围。
+func (u serverUser) permits(r *http.Request, role string) bool {
+	if !u.allows(role) {
+		return false
+	}
+	if u.scopes == nil {
+		return true
+	}
+	scope := requiredScope(r, role)
+	return scope != "" && hasTokenScope(u.scopes, scope)
+}
+
+// parseServerUsers 解析 "名称:角色:Token" 列表 (逗号或换行分隔), Token 可以包含冒号;
+// 角色无法识别或缺少 Token 的条目忽略, 同名用户以最后一项为准。
+func parseServerUsers(value string) []serverUser {
+	var users []serverUser
+	seen := make(map[string]int)
+	for _, entry := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '\n' }) {
+		parts := strings.SplitN(strings.TrimSpace(entry), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		user := serverUser{
+			name:  strings.TrimSpace(parts[0]),
+			role:  strings.ToLower(strings.TrimSpace(parts[1])),
+			token: strings.TrimSpace(parts[2]),
+		}
+		if user.name == "" || user.token == "" || roleLevels[user.role] == 0 {
+			continue
+		}
+		if idx, ok := seen[user.name]; ok {
+			users[idx] = user
+			continue
+		}
+		seen[user.name] = len(users)
+		users = append(users, user)
+	}
+	return users
+}
+
+func normalizeServerUsers(value string) string {
+	users := parseServerUsers(value)
+	entries := make([]string, 0, len(users))
+	for _, user := range users {
+		entries = append(entries, user.name+":"+user.role+":"+user.token)
+	}
+	return strings.Join(entries, "\n")
+}
+
+// authenticate 按 Authorization 头查找用户, 支持 Bearer Token 与 Basic (用户名 + Token 作为密码);
+// Basic 便于浏览器直接弹出登录框访问 Web 界面。
+func authenticate(users []serverUser, r *http.Request) (serverUser, bool) {
+	if name, password, ok := r.BasicAuth(); ok {
+		for _, user := range users {
+			if user.name == name && subtle.ConstantTimeCompare([]byte(password), []byte(user.token)) == 1 {
+				return user, true
+			}
+		}
+		return serverUser{}, false
+	}
+	auth := strings.TrimSpace(r.Header.Get("Authorization"))
+	if len(auth) <= 7 || !strings.EqualFold(auth[:7], "Bearer ") {
+		return serverUser{}, false
+	}
+	token := strings.TrimSpace(auth[7:])
+	for _, user := range users {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(user.token)) == 1 {
+			return user, true
+		}
+	}
+	return serverUser{}, false
+}
+
+// requiredRole 返回请求需要的最低角色, 空字符串表示不经过用户认证 (备份 Hook 使用独立的 API Key)。
+// 未列出的接口 GET 只需 viewer, 其余方法需要 operator。
+func requiredRole(r *http.Request) string {
+	path := r.URL.Path
+	switch {
+	case path == "/api/hooks/run-backup", path == "/manifest.webmanifest":
+		// 浏览器请求 manifest 时默认不带凭据, 其中也没有需要保护的内容。
+		return ""
+	case path == "/api/config" || strings.HasPrefix(path, "/api/config/"),
+		strings.HasPrefix(path, "/api/admin/"),
+		strings.HasPrefix(path, "/api/google/"),
+		path == "/api/conversations/delete",
+		strings.HasPrefix(path, "/api/projects/") && r.Method != http.MethodGet:
+		return roleAdmin
+	case path == "/api/conversations/export",
+		path == "/api/import",
+		path == "/api/takeout",
+		path == "/api/failures/retry",
+		path == "/export",
+		path == "/share",
+		strings.HasPrefix(path, "/api/debug/"):
+		return roleOperator
+	case path == "/api/batch",
+		path == "/api/tokens" || strings.HasPrefix(path, "/api/tokens/"):
+		// 批量接口逐条按对应 REST 接口检查, 见 runBatchOperation; Token 管理由处理函数按用户区分。
+		return roleViewer
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		return roleViewer
+	default:
+		return roleOperator
+	}
+}
+
+// requiredScope 返回 API Token 访问需要 role 的请求 r 时应具备的权限范围,
+// 空字符串表示 API Token 不能访问 (修改配置、管理 Token 等)。
+func requiredScope(r *http.Request, role string) string {
+	path := r.URL.Path
+	switch {
+	case path == "/api/tokens" || strings.HasPrefix(path, "/api/tokens/"):
+		return ""
+	case role == roleViewer:
+		return tokenScopeRead
+	case role == roleOperator:
+		return tokenScopeExport
+	case path == "/api/conversations/delete":
+		return tokenScopeDelete
+	default:
+		return ""
+	}
+}
+
+type authUserKey struct{}
+
+// authUserFromContext 返回当前请求的用户, 未启用认证时返回 false。
+func authUserFromContext(ctx context.Context) (serverUser, bool) {
+	user, ok := ctx.Value(authUserKey{}).(serverUser)
+	return user, ok
+}
+
+// checkRole 判断 r 的用户能否访问 r 对应的接口, 不能访问时写入 401/403 并返回 false。
+// 未配置 server_users 时不启用认证。
+func (s *webServer) checkRole(w http.ResponseWriter, r *http.Request) bool {
+	role := requiredRole(r)
+	if role == "" {
+		return true
+	}
+	if user, ok := authUserFromContext(r.Context()); ok {
+		if !user.allows(role) {
+			writeError(w, http.StatusForbidden, errCodeForbidden, "当前用户 ("+user.name+", "+user.role+") 无权执行该操作, 需要 "+role+" 角色")
+			return false
+		}
+		if !user.permits(r, role) {
+			if scope := requiredScope(r, role); scope != "" {
+				writeError(w, http.StatusForbidden, errCodeForbidden, "API Token 未授予该操作所需的 "+scope+" 权限")
+			} else {
+				writeError(w, http.StatusForbidden, errCodeForbidden, "该操作不能使用 API Token, 请以用户身份登录")
+			}
+			return false
+		}
+		return true
+	}
+	users := parseServerUsers(s.configSnapshot().ServerUsers)
+	if len(users) == 0 {
+		return true
+	}
+	w.Header().Set("WWW-Authenticate", `Basic realm="openai-backup", charset="UTF-8"`)
+	writeError(w, http.StatusUnauthorized, errCodeUnauthorized, "需要登录, 请提供有效的用户 Token")
+	return false
+}
+
+// withAuth 在配置了 server_users 时校验每个请求的用户与角色, 并把用户写入请求上下文。
+func (s *webServer) withAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requiredRole(r) == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		users := parseServerUsers(s.configSnapshot().ServerUsers)
+		if len(users) > 0 {
+			user, ok := authenticate(users, r)
+			if !ok {
+				user, ok = s.authenticateAPIToken(users, r)
+			}
+			if ok {
+				r = r.WithContext(context.WithValue(r.Context(), authUserKey{}, user))
+			}
+		}
+		if !s.checkRole(w, r) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
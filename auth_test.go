@@ -0,0 +1,171 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseServerUsers(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []serverUser
+	}{
+		{name: "空配置", value: "", want: nil},
+		{
+			name:  "逗号与换行分隔, 角色不区分大小写",
+			value: "alice:Admin:t1, bob:viewer:t2\ncarol:operator:t3",
+			want: []serverUser{
+				{name: "alice", role: roleAdmin, token: "t1"},
+				{name: "bob", role: roleViewer, token: "t2"},
+				{name: "carol", role: roleOperator, token: "t3"},
+			},
+		},
+		{
+			name:  "Token 可以包含冒号",
+			value: "alice:admin:a:b:c",
+			want:  []serverUser{{name: "alice", role: roleAdmin, token: "a:b:c"}},
+		},
+		{
+			name:  "忽略无效条目",
+			value: "alice:root:t1,bob:viewer:,:viewer:t3,carol:viewer,dave:viewer:t4",
+			want:  []serverUser{{name: "dave", role: roleViewer, token: "t4"}},
+		},
+		{
+			name:  "同名用户以最后一项为准",
+			value: "alice:viewer:t1,bob:viewer:t2,alice:admin:t3",
+			want: []serverUser{
+				{name: "alice", role: roleAdmin, token: "t3"},
+				{name: "bob", role: roleViewer, token: "t2"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseServerUsers(tt.value); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseServerUsers() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerUserAllows(t *testing.T) {
+	tests := []struct {
+		role string
+		need string
+		want bool
+	}{
+		{roleViewer, roleViewer, true},
+		{roleViewer, roleOperator, false},
+		{roleOperator, roleViewer, true},
+		{roleOperator, roleAdmin, false},
+		{roleAdmin, roleAdmin, true},
+		{"", roleViewer, false},
+	}
+	for _, tt := range tests {
+		if got := (serverUser{role: tt.role}).allows(tt.need); got != tt.want {
+			t.Errorf("%q.allows(%q) = %v, want %v", tt.role, tt.need, got, tt.want)
+		}
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	users := parseServerUsers("alice:admin:secret-a,bob:viewer:secret-b")
+	tests := []struct {
+		name   string
+		header func(r *http.Request)
+		want   string
+	}{
+		{name: "Bearer", header: func(r *http.Request) { r.Header.Set("Authorization", "Bearer secret-b") }, want: "bob"},
+		{name: "Bearer 不区分大小写", header: func(r *http.Request) { r.Header.Set("Authorization", "bearer  secret-a ") }, want: "alice"},
+		{name: "Basic", header: func(r *http.Request) { r.SetBasicAuth("alice", "secret-a") }, want: "alice"},
+		{name: "Basic 用户名与 Token 不匹配", header: func(r *http.Request) { r.SetBasicAuth("bob", "secret-a") }},
+		{name: "错误的 Token", header: func(r *http.Request) { r.Header.Set("Authorization", "Bearer wrong") }},
+		{name: "缺少 Authorization", header: func(r *http.Request) {}},
+		{name: "空 Bearer", header: func(r *http.Request) { r.Header.Set("Authorization", "Bearer ") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+			tt.header(r)
+			user, ok := authenticate(users, r)
+			if ok != (tt.want != "") || user.name != tt.want {
+				t.Errorf("authenticate() = %q, %v, want %q", user.name, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequiredRole(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{http.MethodPost, "/api/hooks/run-backup", ""},
+		{http.MethodGet, "/manifest.webmanifest", ""},
+		{http.MethodGet, "/api/config", roleAdmin},
+		{http.MethodPost, "/api/config/import", roleAdmin},
+		{http.MethodPost, "/api/admin/db", roleAdmin},
+		{http.MethodPost, "/api/conversations/delete", roleAdmin},
+		{http.MethodGet, "/api/projects/p1", roleViewer},
+		{http.MethodPost, "/api/projects/p1", roleAdmin},
+		{http.MethodPost, "/api/conversations/export", roleOperator},
+		{http.MethodPost, "/api/takeout", roleOperator},
+		{http.MethodGet, "/api/debug/skipped", roleOperator},
+		{http.MethodPost, "/api/batch", roleViewer},
+		{http.MethodPost, "/api/tokens/revoke", roleViewer},
+		{http.MethodGet, "/api/conversations", roleViewer},
+		{http.MethodHead, "/", roleViewer},
+		{http.MethodPost, "/api/unknown", roleOperator},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.path, nil)
+		if got := requiredRole(r); got != tt.want {
+			t.Errorf("requiredRole(%s %s) = %q, want %q", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestWithAuth(t *testing.T) {
+	tests := []struct {
+		name   string
+		users  string
+		method string
+		path   string
+		token  string
+		want   int
+	}{
+		{name: "未配置用户时不认证", users: "", method: http.MethodPost, path: "/api/conversations/delete", want: http.StatusNoContent},
+		{name: "缺少凭据", users: "v:viewer:tv", method: http.MethodGet, path: "/api/status", want: http.StatusUnauthorized},
+		{name: "错误的凭据", users: "v:viewer:tv", method: http.MethodGet, path: "/api/status", token: "bad", want: http.StatusUnauthorized},
+		{name: "viewer 浏览", users: "v:viewer:tv", method: http.MethodGet, path: "/api/status", token: "tv", want: http.StatusNoContent},
+		{name: "viewer 导出", users: "v:viewer:tv", method: http.MethodPost, path: "/api/conversations/export", token: "tv", want: http.StatusForbidden},
+		{name: "operator 导出", users: "o:operator:to", method: http.MethodPost, path: "/api/conversations/export", token: "to", want: http.StatusNoContent},
+		{name: "operator 修改配置", users: "o:operator:to", method: http.MethodPost, path: "/api/config", token: "to", want: http.StatusForbidden},
+		{name: "admin 删除对话", users: "a:admin:ta", method: http.MethodPost, path: "/api/conversations/delete", token: "ta", want: http.StatusNoContent},
+		{name: "备份 Hook 不经过用户认证", users: "v:viewer:tv", method: http.MethodPost, path: "/api/hooks/run-backup", want: http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &webServer{cfg: &cliConfig{ServerUsers: tt.users}}
+			handler := s.withAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, r)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+			if tt.want == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 响应缺少 WWW-Authenticate")
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const maxBatchOperations = 50
+
+// batchOperation 是批量接口中的单个操作, params 按操作类型解释。
+type batchOperation struct {
+	ID     string          `json:"id"`
+	Op     string          `json:"op"`
+	Params json.RawMessage `json:"params"`
+}
+
+type batchRequest struct {
+	Operations []batchOperation `json:"operations"`
+}
+
+type batchResult struct {
+	ID     string          `json:"id,omitempty"`
+	Op     string          `json:"op"`
+	OK     bool            `json:"ok"`
+	Status int             `json:"status"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *apiError       `json:"error,omitempty"`
+}
+
+type batchListParams struct {
+	Offset      int  `json:"offset"`
+	Limit       int  `json:"limit"`
+	Refresh     bool `json:"refresh"`
+	NotExported bool `json:"not_exported"`
+	Preview     bool `json:"preview"`
+}
+
+type batchDetailParams struct {
+	ID      string `json:"id"`
+	Refresh bool   `json:"refresh"`
+}
+
+// batchRecorder 收集单个操作的响应, 供批量接口汇总。
+type batchRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newBatchRecorder() *batchRecorder {
+	return &batchRecorder{header: make(http.Header)}
+}
+
+func (r *batchRecorder) Header() http.Header { return r.header }
+
+func (r *batchRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *batchRecorder) Write(data []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(data)
+}
+
+// handleBatch 处理 /api/batch, 按顺序执行多个操作并逐条返回结果, 单个操作失败不影响其余操作。
+func (s *webServer) handleBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+		return
+	}
+	var req batchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorDetail(w, http.StatusBadRequest, errCodeInvalidRequest, "请求体解析失败", err)
+		return
+	}
+	if len(req.Operations) == 0 {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "operations 不能为空")
+		return
+	}
+	if len(req.Operations) > maxBatchOperations {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, fmt.Sprintf("单次最多 %d 个操作", maxBatchOperations))
+		return
+	}
+
+	results := make([]batchResult, 0, len(req.Operations))
+	for _, op := range req.Operations {
+		if r.Context().Err() != nil {
+			results = append(results, batchResult{ID: op.ID, Op: op.Op, Status: http.StatusServiceUnavailable, Error: &apiError{Code: errCodeCanceled, Message: "请求已取消"}})
+			continue
+		}
+		results = append(results, s.runBatchOperation(r, op))
+	}
+	logInfo("批量接口执行完成: 操作数=%d", len(results))
+	writeJSON(w, http.StatusOK, map[string]interface{}{"results": results})
+}
+
+func (s *webServer) runBatchOperation(parent *http.Request, op batchOperation) batchResult {
+	result := batchResult{ID: op.ID, Op: op.Op}
+	sub, handler, err := s.buildBatchRequest(parent, op)
+	if err != nil {
+		result.Status = http.StatusBadRequest
+		result.Error = &apiError{Code: errCodeInvalidRequest, Message: err.Error()}
+		return result
+	}
+
+	rec := newBatchRecorder()
+	if s.checkRole(rec, sub) {
+		handler(rec, sub)
+	}
+	result.Status = rec.status
+	if result.Status == 0 {
+		result.Status = http.StatusOK
+	}
+	result.OK = result.Status >= 200 && result.Status < 300
+
+	body := bytes.TrimSpace(rec.body.Bytes())
+	if !json.Valid(body) {
+		if !result.OK {
+			result.Error = &apiError{Code: errCodeInvalidRequest, Message: string(body)}
+		}
+		return result
+	}
+	if !result.OK {
+		var apiErr apiError
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Code != "" {
+			result.Error = &apiErr
+			return result
+		}
+	}
+	result.Result = json.RawMessage(body)
+	return result
+}
+
+// buildBatchRequest 将批量操作翻译为对应 REST 接口的请求, 复用现有处理逻辑。
+func (s *webServer) buildBatchRequest(parent *http.Request, op batchOperation) (*http.Request, http.HandlerFunc, error) {
+	params := op.Params
+	if len(bytes.TrimSpace(params)) == 0 {
+		params = json.RawMessage("{}")
+	}
+	ctx := parent.Context()
+
+	switch strings.ToLower(strings.TrimSpace(op.Op)) {
+	case "list":
+		var p batchListParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, nil, fmt.Errorf("list 参数解析失败: %w", err)
+		}
+		query := url.Values{}
+		query.Set("offset", fmt.Sprintf("%d", p.Offset))
+		if p.Limit > 0 {
+			query.Set("limit", fmt.Sprintf("%d", p.Limit))
+		}
+		if p.Refresh {
+			query.Set("refresh", "1")
+		}
+		if p.NotExported {
+			query.Set("not_exported", "1")
+		}
+		if p.Preview {
+			query.Set("preview", "1")
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/api/conversations?"+query.Encode(), nil)
+		return req, s.handleConversationList, err
+	case "detail":
+		var p batchDetailParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, nil, fmt.Errorf("detail 参数解析失败: %w", err)
+		}
+		id := strings.TrimSpace(p.ID)
+		if id == "" || strings.Contains(id, "/") {
+			return nil, nil, fmt.Errorf("detail 缺少有效的对话 ID")
+		}
+		target := "/api/conversations/" + url.PathEscape(id)
+		if p.Refresh {
+			target += "?refresh=1"
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		if err == nil {
+			req.URL.Path = "/api/conversations/" + id
+		}
+		return req, s.handleConversationDetail, err
+	case "export":
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/api/import", bytes.NewReader(params))
+		return req, s.handleImport, err
+	case "delete":
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/api/conversations/delete", bytes.NewReader(params))
+		return req, s.handleDelete, err
+	default:
+		return nil, nil, fmt.Errorf("不支持的操作: %s", op.Op)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// blobsSchema 是按内容寻址的快照存储: 历史版本只保存 blob_hash, 内容相同的快照
+// (如内容改回旧版本、同一份本地副本多次另存) 共用一份数据。
+const blobsSchema = `
+	CREATE TABLE IF NOT EXISTS blobs (
+		hash TEXT PRIMARY KEY,
+		data BLOB NOT NULL,
+		size INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMP NOT NULL
+	);`
+
+// blobTables 是引用 blobs 的快照表, 旧版本写入的行 data 列保存完整内容、blob_hash 为空。
+var blobTables = []string{"conversation_versions", "local_conversation_versions"}
+
+// blobMigrateBatch 是迁移旧快照时每个事务处理的行数。
+const blobMigrateBatch = 200
+
+// blobHash 返回内容的 sha256 摘要, 作为 blobs 的主键。
+func blobHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// putBlob 在事务中保存内容并返回摘要, 已存在的内容不重复写入。
+func putBlob(ctx context.Context, tx *sql.Tx, data []byte) (string, error) {
+	hash := blobHash(data)
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO blobs(hash, data, size, created_at) VALUES(?, ?, ?, ?)
+		ON CONFLICT(hash) DO NOTHING
+	`, hash, data, len(data), time.Now().UTC()); err != nil {
+		return "", fmt.Errorf("保存快照内容失败: %w", err)
+	}
+	return hash, nil
+}
+
+// ensureBlobColumns 为旧版本的快照表补上 blob_hash 列, 并把行内保存的内容迁入 blobs。
+func (s *ConfigStore) ensureBlobColumns(ctx context.Context) error {
+	for _, table := range blobTables {
+		tx, err := s.archive.writer.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("开启事务失败: %w", err)
+		}
+		columns, err := tableColumns(ctx, tx, "main", table)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		hasHash := false
+		for _, name := range columns {
+			if name == "blob_hash" {
+				hasHash = true
+			}
+		}
+		if !hasHash {
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %s ADD COLUMN blob_hash TEXT NOT NULL DEFAULT ''`, table)); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("为 %s 添加 blob_hash 列失败: %w", table, err)
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("提交表结构变更失败: %w", err)
+		}
+		moved, err := s.migrateInlineSnapshots(ctx, table)
+		if err != nil {
+			return err
+		}
+		if moved > 0 {
+			logInfo("已将 %s 中的 %d 个快照迁入去重存储", table, moved)
+		}
+	}
+	return nil
+}
+
+// migrateInlineSnapshots 分批把 table 中行内保存的内容写入 blobs, 并清空原来的 data 列。
+func (s *ConfigStore) migrateInlineSnapshots(ctx context.Context, table string) (int, error) {
+	moved := 0
+	for {
+		n, err := s.migrateInlineBatch(ctx, table)
+		if err != nil {
+			return moved, err
+		}
+		moved += n
+		if n < blobMigrateBatch {
+			return moved, nil
+		}
+	}
+}
+
+func (s *ConfigStore) migrateInlineBatch(ctx context.Context, table string) (int, error) {
+	tx, err := s.archive.writer.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`SELECT id, data FROM %s WHERE blob_hash = '' LIMIT ?`, table), blobMigrateBatch)
+	if err != nil {
+		return 0, fmt.Errorf("读取 %s 失败: %w", table, err)
+	}
+	type inlineRow struct {
+		id   int64
+		data []byte
+	}
+	var pending []inlineRow
+	for rows.Next() {
+		var row inlineRow
+		if err := rows.Scan(&row.id, &row.data); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("读取 %s 失败: %w", table, err)
+		}
+		pending = append(pending, row)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("读取 %s 失败: %w", table, err)
+	}
+	for _, row := range pending {
+		hash, err := putBlob(ctx, tx, row.data)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`UPDATE %s SET blob_hash = ?, data = x'' WHERE id = ?`, table), hash, row.id); err != nil {
+			return 0, fmt.Errorf("更新 %s 失败: %w", table, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("提交快照迁移失败: %w", err)
+	}
+	return len(pending), nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPutBlob(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	tx, err := store.archive.writer.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	tests := []struct {
+		data      string
+		wantBlobs int
+	}{
+		{data: `{"id":"a"}`, wantBlobs: 1},
+		{data: `{"id":"a"}`, wantBlobs: 1},
+		{data: `{"id":"b"}`, wantBlobs: 2},
+		{data: ``, wantBlobs: 3},
+	}
+	for _, tt := range tests {
+		hash, err := putBlob(ctx, tx, []byte(tt.data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hash != blobHash([]byte(tt.data)) || len(hash) != 64 {
+			t.Errorf("putBlob(%q) = %s", tt.data, hash)
+		}
+		var count int
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM blobs`).Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		if count != tt.wantBlobs {
+			t.Errorf("putBlob(%q) 后 blobs = %d 行, want %d", tt.data, count, tt.wantBlobs)
+		}
+	}
+}
+
+func TestEnsureBlobColumns(t *testing.T) {
+	tests := []struct {
+		name      string
+		rows      int
+		distinct  int
+		wantBlobs int
+	}{
+		{name: "没有旧快照", rows: 0, distinct: 1, wantBlobs: 0},
+		{name: "相同内容共用一份", rows: 3, distinct: 2, wantBlobs: 2},
+		{name: "超过一批分多次迁移", rows: blobMigrateBatch + 5, distinct: blobMigrateBatch + 5, wantBlobs: blobMigrateBatch + 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newTestStore(t)
+			now := time.Now().UTC()
+			for i := 0; i < tt.rows; i++ {
+				data := fmt.Sprintf(`{"id":"c1","title":"版本 %d"}`, i%tt.distinct)
+				if _, err := store.archive.writer.ExecContext(ctx, `
+					INSERT INTO conversation_versions(conversation_id, content_hash, data, first_seen_at, last_seen_at)
+					VALUES('c1', ?, ?, ?, ?)
+				`, fmt.Sprint(i), data, now, now); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := store.ensureBlobColumns(ctx); err != nil {
+				t.Fatal(err)
+			}
+			var inline, blobs int
+			if err := store.archive.reader.QueryRowContext(ctx, `SELECT COUNT(*) FROM conversation_versions WHERE blob_hash = '' OR length(data) > 0`).Scan(&inline); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.archive.reader.QueryRowContext(ctx, `SELECT COUNT(*) FROM blobs`).Scan(&blobs); err != nil {
+				t.Fatal(err)
+			}
+			if inline != 0 || blobs != tt.wantBlobs {
+				t.Errorf("行内快照 = %d, blobs = %d, want 0, %d", inline, blobs, tt.wantBlobs)
+			}
+			if tt.rows == 0 {
+				return
+			}
+			versions, err := store.ListConversationVersions(ctx, "c1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, conv, err := store.ConversationVersion(ctx, "c1", versions[0].ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := fmt.Sprintf("版本 %d", (tt.rows-1)%tt.distinct); conv.Title != want {
+				t.Errorf("迁移后读取标题 = %q, want %q", conv.Title, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Devoty/openai-backup/targets"
+)
+
+const (
+	breakerFailureThreshold = 3
+	breakerRetryDelay       = 2 * time.Second
+	breakerBaseCooldown     = 15 * time.Second
+	breakerMaxCooldown      = 5 * time.Minute
+	breakerMaxTrips         = 5
+)
+
+const (
+	breakerStateClosed   = "closed"
+	breakerStateOpen     = "open"
+	breakerStateHalfOpen = "half_open"
+)
+
+// circuitBreaker 记录单个导出目标的健康状况: 连续失败达到阈值后熔断,
+// 冷却结束后放行一次探测请求, 成功即恢复, 失败则加倍冷却时间。
+type circuitBreaker struct {
+	target string
+
+	mu          sync.Mutex
+	state       string
+	failures    int
+	trips       int
+	lastError   string
+	lastFailure time.Time
+	openUntil   time.Time
+}
+
+type targetStatus struct {
+	Target      string `json:"target"`
+	Healthy     bool   `json:"healthy"`
+	State       string `json:"state"`
+	Failures    int    `json:"consecutive_failures"`
+	Trips       int    `json:"trips"`
+	LastError   string `json:"last_error,omitempty"`
+	LastFailure string `json:"last_failure_at,omitempty"`
+	OpenUntil   string `json:"open_until,omitempty"`
+}
+
+func newCircuitBreaker(target string) *circuitBreaker {
+	return &circuitBreaker{target: target, state: breakerStateClosed}
+}
+
+// run 执行一次目标写入, 对可重试的错误按熔断策略等待后重试, 直至成功、遇到不可重试错误或放弃。
+func (b *circuitBreaker) run(ctx context.Context, fn func(context.Context) (targets.Object, error)) (targets.Object, error) {
+	for {
+		if err := b.wait(ctx); err != nil {
+			return targets.Object{}, err
+		}
+		object, err := fn(ctx)
+		if err == nil {
+			b.recordSuccess()
+			return object, nil
+		}
+		if !isTransientTargetError(err) {
+			return targets.Object{}, err
+		}
+		retryIn, giveUp := b.recordFailure(err)
+		if giveUp {
+			return targets.Object{}, fmt.Errorf("%w: %v", errTargetUnavailable, err)
+		}
+		if retryIn > 0 {
+			if err := sleepContext(ctx, retryIn); err != nil {
+				return targets.Object{}, err
+			}
+		}
+	}
+}
+
+func (b *circuitBreaker) wait(ctx context.Context) error {
+	b.mu.Lock()
+	if b.state != breakerStateOpen {
+		b.mu.Unlock()
+		return nil
+	}
+	delay := time.Until(b.openUntil)
+	b.mu.Unlock()
+
+	if delay > 0 {
+		logInfo("导出目标 %s 处于熔断状态, 暂停 %s 后重试", b.target, delay.Round(time.Second))
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+
+	b.mu.Lock()
+	if b.state == breakerStateOpen {
+		b.state = breakerStateHalfOpen
+	}
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *circuitBreaker) recordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state != breakerStateClosed {
+		logInfo("导出目标 %s 已恢复, 继续执行任务", b.target)
+	}
+	b.state = breakerStateClosed
+	b.failures = 0
+	b.trips = 0
+	b.openUntil = time.Time{}
+}
+
+// recordFailure 返回下一次重试前需要等待的时间; 熔断次数超过上限时返回 giveUp。
+func (b *circuitBreaker) recordFailure(err error) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	b.failures++
+	b.lastError = err.Error()
+	b.lastFailure = now
+
+	if b.state != breakerStateHalfOpen && b.failures < breakerFailureThreshold {
+		return breakerRetryDelay * time.Duration(b.failures), false
+	}
+
+	b.trips++
+	cooldown := breakerBaseCooldown << (b.trips - 1)
+	if cooldown > breakerMaxCooldown || cooldown <= 0 {
+		cooldown = breakerMaxCooldown
+	}
+	b.state = breakerStateOpen
+	b.openUntil = now.Add(cooldown)
+	if b.trips > breakerMaxTrips {
+		logInfo("导出目标 %s 持续不可用, 已熔断 %d 次, 放弃本次任务: %v", b.target, b.trips-1, err)
+		return 0, true
+	}
+	logInfo("导出目标 %s 连续失败 %d 次, 熔断 %s: %v", b.target, b.failures, cooldown, err)
+	return 0, false
+}
+
+func (b *circuitBreaker) status() targetStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := targetStatus{
+		Target:    b.target,
+		Healthy:   b.state == breakerStateClosed,
+		State:     b.state,
+		Failures:  b.failures,
+		Trips:     b.trips,
+		LastError: b.lastError,
+	}
+	if !b.lastFailure.IsZero() {
+		status.LastFailure = b.lastFailure.Format(time.RFC3339)
+	}
+	if b.state == breakerStateOpen && !b.openUntil.IsZero() {
+		status.OpenUntil = b.openUntil.Format(time.RFC3339)
+	}
+	return status
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Devoty/openai-backup/targets"
+)
+
+func TestIsTransientTargetError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "成功", err: nil},
+		{name: "已取消", err: context.Canceled},
+		{name: "限流", err: &targets.StatusError{Status: http.StatusTooManyRequests}, want: true},
+		{name: "服务不可用", err: &targets.StatusError{Status: http.StatusBadGateway}, want: true},
+		{name: "请求被拒绝", err: &targets.StatusError{Status: http.StatusBadRequest}},
+		{name: "超时", err: context.DeadlineExceeded, want: true},
+		{name: "其他错误", err: errors.New("配置错误")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTransientTargetError(tt.err); got != tt.want {
+				t.Errorf("isTransientTargetError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerRecordFailure(t *testing.T) {
+	b := newCircuitBreaker("notion")
+	failure := &targets.StatusError{Action: "创建 Notion 页面", Status: http.StatusBadGateway, Message: "bad gateway"}
+	tests := []struct {
+		name       string
+		halfOpen   bool
+		wantRetry  time.Duration
+		wantGiveUp bool
+		wantState  string
+		wantOpen   time.Duration
+	}{
+		{name: "第 1 次失败", wantRetry: breakerRetryDelay, wantState: breakerStateClosed},
+		{name: "第 2 次失败", wantRetry: 2 * breakerRetryDelay, wantState: breakerStateClosed},
+		{name: "达到阈值后熔断", wantState: breakerStateOpen, wantOpen: breakerBaseCooldown},
+		{name: "探测失败时冷却加倍", halfOpen: true, wantState: breakerStateOpen, wantOpen: 2 * breakerBaseCooldown},
+		{name: "第 3 次熔断", halfOpen: true, wantState: breakerStateOpen, wantOpen: 4 * breakerBaseCooldown},
+		{name: "第 4 次熔断", halfOpen: true, wantState: breakerStateOpen, wantOpen: 8 * breakerBaseCooldown},
+		{name: "第 5 次熔断", halfOpen: true, wantState: breakerStateOpen, wantOpen: 16 * breakerBaseCooldown},
+		{name: "超过熔断次数后放弃, 冷却时间有上限", halfOpen: true, wantGiveUp: true, wantState: breakerStateOpen, wantOpen: breakerMaxCooldown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.halfOpen {
+				b.state = breakerStateHalfOpen
+			}
+			retry, giveUp := b.recordFailure(failure)
+			if retry != tt.wantRetry || giveUp != tt.wantGiveUp {
+				t.Errorf("recordFailure() = %s, %v, want %s, %v", retry, giveUp, tt.wantRetry, tt.wantGiveUp)
+			}
+			status := b.status()
+			if status.State != tt.wantState || status.Healthy != (tt.wantState == breakerStateClosed) || status.LastError != failure.Error() || status.LastFailure == "" {
+				t.Errorf("status() = %+v", status)
+			}
+			if tt.wantOpen > 0 {
+				if open := time.Until(b.openUntil); open > tt.wantOpen || open < tt.wantOpen-time.Second || status.OpenUntil == "" {
+					t.Errorf("冷却 %s, want %s", open, tt.wantOpen)
+				}
+			}
+		})
+	}
+
+	b.recordSuccess()
+	if status := b.status(); !status.Healthy || status.Failures != 0 || status.Trips != 0 || status.OpenUntil != "" {
+		t.Errorf("恢复后 status() = %+v", status)
+	}
+}
+
+func TestCircuitBreakerRun(t *testing.T) {
+	rejected := &targets.StatusError{Status: http.StatusBadRequest, Message: "invalid"}
+	tests := []struct {
+		name      string
+		results   []error
+		prepare   func(*circuitBreaker)
+		ctx       func() context.Context
+		wantErr   error
+		wantCalls int
+	}{
+		{name: "成功", results: []error{nil}, wantCalls: 1},
+		{name: "不可重试的错误直接返回", results: []error{rejected}, wantErr: rejected, wantCalls: 1},
+		{
+			name:    "熔断期间等待被取消",
+			results: []error{nil},
+			prepare: func(b *circuitBreaker) { b.state, b.openUntil = breakerStateOpen, time.Now().Add(time.Hour) },
+			ctx: func() context.Context {
+				ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+				t.Cleanup(cancel)
+				return ctx
+			},
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name:    "冷却结束后放行探测请求",
+			results: []error{nil},
+			prepare: func(b *circuitBreaker) {
+				b.state, b.trips, b.openUntil = breakerStateOpen, 2, time.Now().Add(-time.Second)
+			},
+			wantCalls: 1,
+		},
+		{
+			name:      "熔断次数用尽后放弃",
+			results:   []error{&targets.StatusError{Status: http.StatusServiceUnavailable}},
+			prepare:   func(b *circuitBreaker) { b.state, b.trips = breakerStateHalfOpen, breakerMaxTrips },
+			wantErr:   errTargetUnavailable,
+			wantCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newCircuitBreaker("notion")
+			if tt.prepare != nil {
+				tt.prepare(b)
+			}
+			ctx := context.Background()
+			if tt.ctx != nil {
+				ctx = tt.ctx()
+			}
+			calls := 0
+			obj, err := b.run(ctx, func(context.Context) (targets.Object, error) {
+				calls++
+				if err := tt.results[calls-1]; err != nil {
+					return targets.Object{}, err
+				}
+				return targets.Object{ID: "page-1"}, nil
+			})
+			if !errors.Is(err, tt.wantErr) || calls != tt.wantCalls {
+				t.Fatalf("run() err = %v, calls = %d, want %v, %d", err, calls, tt.wantErr, tt.wantCalls)
+			}
+			if err == nil && (obj.ID != "page-1" || !b.status().Healthy) {
+				t.Errorf("run() = %+v, status = %+v", obj, b.status())
+			}
+		})
+	}
+}
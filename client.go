@@ -1,166 +1,66 @@
 package main
 
 import (
-	"bytes"
-	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
-	"openai-backup/httpc"
 	"strings"
-)
-
-func fetchAllConversations(ctx context.Context, cfg *cliConfig, token string) ([]conversationMeta, error) {
-	// 拉取分页对话列表并拼接完整集合。
-	var result []conversationMeta
-	offset := cfg.InitialOffset
-
-	for {
-		logInfo("请求对话列表 offset=%d limit=%d", offset, cfg.PageSize)
-		page, err := fetchConversationPage(ctx, cfg, token, offset, cfg.PageSize)
-		if err != nil {
-			return nil, err
-		}
-
-		if len(page.Items) == 0 {
-			break
-		}
-
-		for _, item := range page.Items {
-			result = append(result, item)
-			if cfg.MaxConversations > 0 && len(result) >= cfg.MaxConversations {
-				return result, nil
-			}
-		}
-
-		if !page.HasMore {
-			logInfo("对话列表已读完, has_more=false")
-			break
-		}
-		nextOffset := offset + cfg.PageSize
-		if nextOffset <= offset {
-			break
-		}
-		offset = nextOffset
-	}
-
-	return result, nil
-}
-
-func fetchConversationPage(ctx context.Context, cfg *cliConfig, token string, offset, limit int) (*conversationListResponse, error) {
-	// 构造列表接口请求。
-	endpoint, err := url.Parse(cfg.BaseURL + "/conversations")
-	if err != nil {
-		return nil, err
-	}
-
-	query := endpoint.Query()
-	query.Set("offset", fmt.Sprintf("%d", offset))
-	query.Set("limit", fmt.Sprintf("%d", limit))
-	query.Set("order", cfg.Order)
-	if cfg.IncludeArchived {
-		query.Set("is_archived", "true")
-	} else {
-		query.Set("is_archived", "false")
-	}
-	query.Set("is_starred", "false")
-	endpoint.RawQuery = query.Encode()
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), nil)
-	if err != nil {
-		return nil, err
-	}
-
-	applyCommonHeaders(req, cfg, token)
+	"sync"
 
-	resp, err := httpc.Client().Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
-		return nil, fmt.Errorf("请求对话列表失败: %s - %s", resp.Status, strings.TrimSpace(string(body)))
-	}
+	"github.com/Devoty/openai-backup/client"
+)
 
-	var parsed conversationListResponse
-	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
-		return nil, fmt.Errorf("解析对话列表响应失败: %w", err)
-	}
+// defaultUARotationBatch 是轮换浏览器请求头时每组连续使用的请求数。
+const defaultUARotationBatch = 50
 
-	return &parsed, nil
+// uaRotation 缓存 ua_rotation 对应的轮换器, 各次创建的客户端共用, 轮换进度不因重新创建客户端而重置。
+var uaRotation struct {
+	mu      sync.Mutex
+	key     string
+	rotator *client.ProfileRotator
 }
 
-func fetchConversationDetail(ctx context.Context, cfg *cliConfig, token, conversationID string) (*conversationDetail, error) {
-	// 请求单个对话的详细消息结构。
-	endpoint := fmt.Sprintf("%s/conversation/%s", strings.TrimSuffix(cfg.BaseURL, "/"), url.PathEscape(conversationID))
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	applyCommonHeaders(req, cfg, token)
-
-	resp, err := httpc.Client().Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
-		return nil, fmt.Errorf("请求对话详情失败: %s - %s", resp.Status, strings.TrimSpace(string(body)))
-	}
-
-	var parsed conversationDetail
-	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
-		return nil, fmt.Errorf("解析对话详情响应失败: %w", err)
-	}
-
-	return &parsed, nil
+// newChatGPTClient 按当前配置创建 ChatGPT 接口客户端。
+func newChatGPTClient(cfg *cliConfig, token string) *client.Client {
+	return client.New(client.Options{BaseURL: cfg.BaseURL, Token: token, UserAgent: cfg.UserAgent, Profiles: profileRotator(cfg)})
 }
 
-func applyCommonHeaders(req *http.Request, cfg *cliConfig, token string) {
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Accept", "*/*")
-	req.Header.Set("User-Agent", cfg.UserAgent)
+// listOptions 返回配置中的列表排序与归档筛选, 分页参数由调用方补充。
+func listOptions(cfg *cliConfig) client.ListOptions {
+	return client.ListOptions{Order: cfg.Order, IncludeArchived: cfg.IncludeArchived}
 }
 
-func deleteConversation(ctx context.Context, cfg *cliConfig, token, conversationID string) error {
-	if strings.TrimSpace(conversationID) == "" {
-		return errors.New("缺少对话 ID")
-	}
-
-	endpoint := fmt.Sprintf("%s/conversation/%s", strings.TrimSuffix(cfg.BaseURL, "/"), url.PathEscape(conversationID))
-	payload := map[string]any{
-		"is_visible": false,
+// profileRotator 返回 ua_rotation 选中的浏览器请求头轮换器, 未开启时返回 nil (使用 user_agent)。
+func profileRotator(cfg *cliConfig) *client.ProfileRotator {
+	names := normalizeUARotation(cfg.UARotation)
+	if names == "" {
+		return nil
 	}
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("构造删除请求失败: %w", err)
+	batch := cfg.UARotationBatch
+	if batch <= 0 {
+		batch = defaultUARotationBatch
 	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, endpoint, bytes.NewReader(data))
-	if err != nil {
-		return err
+	key := fmt.Sprintf("%s/%d", names, batch)
+	uaRotation.mu.Lock()
+	defer uaRotation.mu.Unlock()
+	if uaRotation.key != key {
+		uaRotation.key = key
+		uaRotation.rotator = client.NewProfileRotator(client.ProfilesByName(strings.Split(names, ",")), batch)
 	}
-	applyCommonHeaders(req, cfg, token)
-	req.Header.Set("Content-Type", "application/json")
+	return uaRotation.rotator
+}
 
-	resp, err := httpc.Client().Do(req)
-	if err != nil {
-		return err
+// normalizeUARotation 把逗号或空白分隔的请求头名称收敛为内置名称列表, 含 all 时为 "all"; 忽略未知名称。
+func normalizeUARotation(value string) string {
+	fields := strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\t'
+	})
+	var names []string
+	for _, field := range fields {
+		if field == "all" {
+			return "all"
+		}
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
-		return fmt.Errorf("删除对话失败: %s - %s", resp.Status, strings.TrimSpace(string(body)))
+	for _, profile := range client.ProfilesByName(fields) {
+		names = append(names, profile.Name)
 	}
-
-	return nil
+	return strings.Join(names, ",")
 }
@@ -0,0 +1,354 @@
+// Package client 封装 ChatGPT 网页端 backend-api 的对话列表、详情、删除、项目信息与文件下载接口。
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Devoty/openai-backup/httpc"
+	"github.com/Devoty/openai-backup/logging"
+)
+
+const (
+	// DefaultBaseURL 与 DefaultUserAgent 是 Options 对应字段为空时的取值。
+	DefaultBaseURL   = "https://chatgpt.com/backend-api"
+	DefaultUserAgent = "openai-backup/0.1 (+https://github.com/)"
+
+	// MaxFileBytes 是单个文件下载的大小上限。
+	MaxFileBytes = 50 << 20
+)
+
+// Options 是创建 Client 所需的连接参数, 为空的字段使用默认值。
+type Options struct {
+	BaseURL   string
+	Token     string
+	UserAgent string
+	// Profiles 不为空时按轮换的浏览器请求头发送请求, 代替 UserAgent。
+	Profiles *ProfileRotator
+	// HTTPClient 为空时使用共享的限速客户端。
+	HTTPClient *http.Client
+}
+
+// ListOptions 控制对话列表的分页与筛选。
+type ListOptions struct {
+	Offset          int
+	Limit           int
+	Order           string
+	IncludeArchived bool
+	// Max 为 FetchAll 最多返回的条数, 0 表示不限制。
+	Max int
+}
+
+// ActionConversationDetail 是请求单个对话详情失败时 StatusError 的 Action。
+const ActionConversationDetail = "请求对话详情"
+
+// StatusError 表示 ChatGPT 接口返回了非成功状态码, 调用方可据 Status 区分 Token 失效与限流。
+type StatusError struct {
+	Action     string
+	Status     int
+	StatusText string
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("%s失败: %s", e.Action, e.StatusText)
+	}
+	return fmt.Sprintf("%s失败: %s - %s", e.Action, e.StatusText, e.Body)
+}
+
+func newStatusError(action string, resp *http.Response) *StatusError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return &StatusError{Action: action, Status: resp.StatusCode, StatusText: resp.Status, Body: strings.TrimSpace(string(body))}
+}
+
+// Client 访问 ChatGPT backend-api, 可在多个 goroutine 间共享。
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+	userAgent  string
+	profiles   *ProfileRotator
+}
+
+// New 按 opts 创建 Client。
+func New(opts Options) *Client {
+	baseURL := strings.TrimSuffix(strings.TrimSpace(opts.BaseURL), "/")
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	userAgent := strings.TrimSpace(opts.UserAgent)
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		httpClient = httpc.Client()
+	}
+	return &Client{
+		httpClient: httpClient,
+		baseURL:    baseURL,
+		token:      strings.TrimSpace(opts.Token),
+		userAgent:  userAgent,
+		profiles:   opts.Profiles,
+	}
+}
+
+// FetchAll 拉取分页对话列表并拼接完整集合。
+func (c *Client) FetchAll(ctx context.Context, opts ListOptions) ([]ConversationMeta, error) {
+	var result []ConversationMeta
+	offset := opts.Offset
+
+	for {
+		logging.Infof("请求对话列表 offset=%d limit=%d", offset, opts.Limit)
+		page, err := c.ListConversations(ctx, ListOptions{Offset: offset, Limit: opts.Limit, Order: opts.Order, IncludeArchived: opts.IncludeArchived})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(page.Items) == 0 {
+			break
+		}
+
+		for _, item := range page.Items {
+			result = append(result, item)
+			if opts.Max > 0 && len(result) >= opts.Max {
+				return result, nil
+			}
+		}
+
+		if !page.HasMore {
+			logging.Infof("对话列表已读完, has_more=false")
+			break
+		}
+		nextOffset := offset + opts.Limit
+		if nextOffset <= offset {
+			break
+		}
+		offset = nextOffset
+	}
+
+	return result, nil
+}
+
+// ListConversations 请求一页对话列表。
+func (c *Client) ListConversations(ctx context.Context, opts ListOptions) (*ConversationPage, error) {
+	endpoint, err := url.Parse(c.baseURL + "/conversations")
+	if err != nil {
+		return nil, err
+	}
+
+	query := endpoint.Query()
+	query.Set("offset", fmt.Sprintf("%d", opts.Offset))
+	query.Set("limit", fmt.Sprintf("%d", opts.Limit))
+	query.Set("order", opts.Order)
+	if opts.IncludeArchived {
+		query.Set("is_archived", "true")
+	} else {
+		query.Set("is_archived", "false")
+	}
+	query.Set("is_starred", "false")
+	endpoint.RawQuery = query.Encode()
+
+	req, err := c.newRequest(ctx, http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError("请求对话列表", resp)
+	}
+
+	var parsed ConversationPage
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("解析对话列表响应失败: %w", err)
+	}
+
+	return &parsed, nil
+}
+
+// Conversation 请求单个对话的详细消息结构。
+func (c *Client) Conversation(ctx context.Context, conversationID string) (*Conversation, error) {
+	endpoint := fmt.Sprintf("%s/conversation/%s", c.baseURL, url.PathEscape(conversationID))
+	req, err := c.newRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError(ActionConversationDetail, resp)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取对话详情响应失败: %w", err)
+	}
+	return decodeConversation(data)
+}
+
+// DeleteConversation 将对话设为不可见, 与网页端删除行为一致。
+func (c *Client) DeleteConversation(ctx context.Context, conversationID string) error {
+	if strings.TrimSpace(conversationID) == "" {
+		return errors.New("缺少对话 ID")
+	}
+
+	endpoint := fmt.Sprintf("%s/conversation/%s", c.baseURL, url.PathEscape(conversationID))
+	payload := map[string]any{
+		"is_visible": false,
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("构造删除请求失败: %w", err)
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPatch, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newStatusError("删除对话", resp)
+	}
+
+	return nil
+}
+
+// IsProjectID 判断 gizmo_id 是否为 ChatGPT 项目 (而非自定义 GPT)。
+func IsProjectID(gizmoID string) bool {
+	return strings.HasPrefix(strings.TrimSpace(gizmoID), "g-p-")
+}
+
+// ProjectName 返回项目或 GPT 的显示名称。
+func (c *Client) ProjectName(ctx context.Context, gizmoID string) (string, error) {
+	if strings.TrimSpace(gizmoID) == "" {
+		return "", errors.New("缺少项目 ID")
+	}
+	endpoint := fmt.Sprintf("%s/gizmos/%s", c.baseURL, url.PathEscape(gizmoID))
+	req, err := c.newRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", newStatusError("请求项目信息", resp)
+	}
+	var parsed struct {
+		Gizmo struct {
+			Display struct {
+				Name string `json:"name"`
+			} `json:"display"`
+		} `json:"gizmo"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return "", fmt.Errorf("解析项目信息失败: %w", err)
+	}
+	return strings.TrimSpace(parsed.Gizmo.Display.Name), nil
+}
+
+// FileID 从 sediment:// 或 file-service:// 形式的指针中取出文件 ID。
+func FileID(pointer string) string {
+	pointer = strings.TrimSpace(pointer)
+	if idx := strings.Index(pointer, "://"); idx >= 0 {
+		pointer = pointer[idx+3:]
+	}
+	return strings.Trim(pointer, "/")
+}
+
+// DownloadFile 通过文件接口换取下载地址并下载文件内容, pointer 可以是文件 ID 或资源指针。
+func (c *Client) DownloadFile(ctx context.Context, pointer string) ([]byte, error) {
+	fileID := FileID(pointer)
+	if fileID == "" {
+		return nil, errors.New("无效的文件指针")
+	}
+	endpoint := fmt.Sprintf("%s/files/%s/download", c.baseURL, url.PathEscape(fileID))
+	req, err := c.newRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError("请求文件下载地址", resp)
+	}
+	var parsed struct {
+		DownloadURL string `json:"download_url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("解析文件下载地址失败: %w", err)
+	}
+	if strings.TrimSpace(parsed.DownloadURL) == "" {
+		return nil, errors.New("文件下载地址为空")
+	}
+
+	// 下载地址为预签名链接, 不需要携带 ChatGPT 鉴权头。
+	fileReq, err := http.NewRequestWithContext(ctx, http.MethodGet, parsed.DownloadURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	fileResp, err := c.httpClient.Do(fileReq)
+	if err != nil {
+		return nil, err
+	}
+	defer fileResp.Body.Close()
+	if fileResp.StatusCode != http.StatusOK {
+		return nil, &StatusError{Action: "下载文件", Status: fileResp.StatusCode, StatusText: fileResp.Status}
+	}
+	data, err := io.ReadAll(io.LimitReader(fileResp.Body, MaxFileBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取文件内容失败: %w", err)
+	}
+	if len(data) > MaxFileBytes {
+		return nil, fmt.Errorf("文件超过 %d MB 上限", MaxFileBytes>>20)
+	}
+	return data, nil
+}
+
+func (c *Client) newRequest(ctx context.Context, method, endpoint string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "*/*")
+	if c.profiles != nil {
+		c.profiles.next().apply(req.Header)
+	} else {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	return req, nil
+}
@@ -0,0 +1,315 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestFileID(t *testing.T) {
+	tests := []struct {
+		pointer string
+		want    string
+	}{
+		{"", ""},
+		{"file-abc", "file-abc"},
+		{" file-service://file-abc ", "file-abc"},
+		{"sediment://file_123/", "file_123"},
+	}
+	for _, tt := range tests {
+		if got := FileID(tt.pointer); got != tt.want {
+			t.Errorf("FileID(%q) = %q, want %q", tt.pointer, got, tt.want)
+		}
+	}
+}
+
+func TestIsProjectID(t *testing.T) {
+	tests := []struct {
+		gizmoID string
+		want    bool
+	}{
+		{"", false},
+		{"g-p-6789abcdef", true},
+		{" g-p-1 ", true},
+		{"g-abc123", false},
+	}
+	for _, tt := range tests {
+		if got := IsProjectID(tt.gizmoID); got != tt.want {
+			t.Errorf("IsProjectID(%q) = %v, want %v", tt.gizmoID, got, tt.want)
+		}
+	}
+}
+
+func TestFlexFloat64(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    float64
+		wantErr bool
+	}{
+		{`null`, 0, false},
+		{`1709283600.5`, 1709283600.5, false},
+		{`"1709283600"`, 1709283600, false},
+		{`" "`, 0, false},
+		{`"2024-03-01T09:00:00Z"`, 1709283600, false},
+		{`"昨天"`, 0, true},
+		{`true`, 0, true},
+	}
+	for _, tt := range tests {
+		var got FlexFloat64
+		err := json.Unmarshal([]byte(tt.raw), &got)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Unmarshal(%s) err = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			continue
+		}
+		if got.Float64() != tt.want {
+			t.Errorf("Unmarshal(%s) = %v, want %v", tt.raw, got.Float64(), tt.want)
+		}
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		err  *StatusError
+		want string
+	}{
+		{&StatusError{Action: "删除对话", StatusText: "404 Not Found"}, "删除对话失败: 404 Not Found"},
+		{&StatusError{Action: "删除对话", StatusText: "401 Unauthorized", Body: `{"detail":"token expired"}`}, `删除对话失败: 401 Unauthorized - {"detail":"token expired"}`},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+// newTestClient 启动 handler 并返回连接到它的 Client, Token 为 tok。
+func newTestClient(t *testing.T, handler http.Handler) (*Client, *httptest.Server) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return New(Options{BaseURL: server.URL + "/backend-api/", Token: " tok ", HTTPClient: server.Client()}), server
+}
+
+func TestNew(t *testing.T) {
+	c := New(Options{})
+	if c.baseURL != DefaultBaseURL || c.userAgent != DefaultUserAgent || c.httpClient == nil {
+		t.Errorf("默认值 = %q %q", c.baseURL, c.userAgent)
+	}
+}
+
+func TestFetchAll(t *testing.T) {
+	const total = 5
+	tests := []struct {
+		name      string
+		opts      ListOptions
+		wantIDs   string
+		wantPages int
+	}{
+		{name: "读到 has_more 为 false", opts: ListOptions{Limit: 2, Order: "updated"}, wantIDs: "c0,c1,c2,c3,c4", wantPages: 3},
+		{name: "达到 Max 后停止", opts: ListOptions{Limit: 2, Order: "updated", Max: 3}, wantIDs: "c0,c1,c2", wantPages: 2},
+		{name: "从 Offset 开始", opts: ListOptions{Offset: 3, Limit: 10, Order: "created", IncludeArchived: true}, wantIDs: "c3,c4", wantPages: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var queries []string
+			c, _ := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/backend-api/conversations" || r.Header.Get("Authorization") != "Bearer tok" {
+					http.NotFound(w, r)
+					return
+				}
+				query := r.URL.Query()
+				queries = append(queries, r.URL.RawQuery)
+				offset, _ := strconv.Atoi(query.Get("offset"))
+				limit, _ := strconv.Atoi(query.Get("limit"))
+				var page ConversationPage
+				for i := offset; i < total && i < offset+limit; i++ {
+					page.Items = append(page.Items, ConversationMeta{ID: fmt.Sprintf("c%d", i)})
+				}
+				page.HasMore = offset+limit < total
+				json.NewEncoder(w).Encode(page)
+			}))
+			items, err := c.FetchAll(context.Background(), tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, item := range items {
+				ids = append(ids, item.ID)
+			}
+			if strings.Join(ids, ",") != tt.wantIDs || len(queries) != tt.wantPages {
+				t.Errorf("FetchAll() = %v (%d 页), want %s (%d 页)", ids, len(queries), tt.wantIDs, tt.wantPages)
+			}
+			archived := strconv.FormatBool(tt.opts.IncludeArchived)
+			if !strings.Contains(queries[0], "is_archived="+archived) || !strings.Contains(queries[0], "order="+tt.opts.Order) {
+				t.Errorf("查询参数 = %q", queries[0])
+			}
+		})
+	}
+}
+
+func TestConversation(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantTitle  string
+		wantStatus int
+	}{
+		{name: "解析详情", status: http.StatusOK, body: `{"id":"c/1","title":"标题","mapping":{}}`, wantTitle: "标题"},
+		{name: "对话不存在", status: http.StatusNotFound, body: `{"detail":"Can't load conversation"}`, wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path string
+			c, _ := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.EscapedPath()
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			}))
+			conv, err := c.Conversation(context.Background(), "c/1")
+			if path != "/backend-api/conversation/c%2F1" {
+				t.Errorf("请求路径 = %q", path)
+			}
+			if tt.wantStatus != 0 {
+				var statusErr *StatusError
+				if !errors.As(err, &statusErr) || statusErr.Status != tt.wantStatus || statusErr.Action != ActionConversationDetail || !strings.Contains(statusErr.Body, "Can't load") {
+					t.Fatalf("err = %v, want 状态码 %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if conv.Title != tt.wantTitle || string(conv.Raw) != tt.body {
+				t.Errorf("Conversation() = %+v", conv)
+			}
+		})
+	}
+}
+
+func TestDeleteConversation(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		status  int
+		wantReq bool
+		wantErr bool
+	}{
+		{name: "隐藏对话", id: "c1", status: http.StatusOK, wantReq: true},
+		{name: "缺少对话 ID", id: " ", wantErr: true},
+		{name: "接口拒绝", id: "c1", status: http.StatusForbidden, wantReq: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			c, _ := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				got = r.Method + " " + r.URL.Path + " " + string(body)
+				w.WriteHeader(tt.status)
+			}))
+			err := c.DeleteConversation(context.Background(), tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if want := `PATCH /backend-api/conversation/c1 {"is_visible":false}`; tt.wantReq && got != want {
+				t.Errorf("请求 = %q, want %q", got, want)
+			}
+			if !tt.wantReq && got != "" {
+				t.Errorf("不应发送请求: %q", got)
+			}
+		})
+	}
+}
+
+func TestProjectName(t *testing.T) {
+	tests := []struct {
+		name    string
+		gizmoID string
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{name: "项目名称", gizmoID: "g-p-1", body: `{"gizmo":{"display":{"name":" 旅行 "}}}`, want: "旅行"},
+		{name: "缺少项目 ID", gizmoID: "", wantErr: true},
+		{name: "响应不是 JSON", gizmoID: "g-p-1", body: "<html>", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/backend-api/gizmos/"+tt.gizmoID {
+					http.NotFound(w, r)
+					return
+				}
+				io.WriteString(w, tt.body)
+			}))
+			got, err := c.ProjectName(context.Background(), tt.gizmoID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ProjectName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDownloadFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		pointer     string
+		downloadURL string
+		fileStatus  int
+		want        string
+		wantErr     string
+	}{
+		{name: "通过预签名地址下载", pointer: "sediment://file-1", downloadURL: "/signed/file-1", want: "文件内容"},
+		{name: "无效指针", pointer: "sediment://", wantErr: "无效的文件指针"},
+		{name: "下载地址为空", pointer: "file-1", downloadURL: "", wantErr: "下载地址为空"},
+		{name: "预签名地址已过期", pointer: "file-1", downloadURL: "/signed/file-1", fileStatus: http.StatusForbidden, wantErr: "下载文件失败"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var signedAuth string
+			var server *httptest.Server
+			c, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/backend-api/files/file-1/download":
+					url := tt.downloadURL
+					if url != "" {
+						url = server.URL + url
+					}
+					json.NewEncoder(w).Encode(map[string]string{"download_url": url})
+				case "/signed/file-1":
+					signedAuth = r.Header.Get("Authorization")
+					if tt.fileStatus != 0 {
+						w.WriteHeader(tt.fileStatus)
+						return
+					}
+					io.WriteString(w, "文件内容")
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			data, err := c.DownloadFile(context.Background(), tt.pointer)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want 包含 %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want || signedAuth != "" {
+				t.Errorf("DownloadFile() = %q, 预签名请求 Authorization = %q", data, signedAuth)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SchemaDrift 描述一次按宽松模式解析的对话详情: 接口结构与已知格式不一致 (字段类型变化、新增结构等),
+// 严格解析失败后改为逐字段解析, 无法解析的字段被忽略, 其余内容照常导出。
+type SchemaDrift struct {
+	// Err 是严格解析时的错误。
+	Err string
+	// Dropped 列出无法解析而被忽略的字段路径, 如 mapping.<node>.message.content.parts。
+	Dropped []string
+	// Unknown 列出已知结构之外的字段路径, 这些字段保留在 Raw 中。
+	Unknown []string
+	// Raw 是接口返回的原始内容, 用于排查与反馈。
+	Raw []byte
+}
+
+// decodeConversation 解析对话详情。严格解析失败时以宽松模式重试一次, 并在结果上附带 SchemaDrift;
+// 宽松模式也无法解析 (如响应不是 JSON 对象) 时返回错误。
+func decodeConversation(data []byte) (*Conversation, error) {
+	var parsed Conversation
+	strictErr := json.Unmarshal(data, &parsed)
+	if strictErr == nil {
+		parsed.Raw = data
+		return &parsed, nil
+	}
+
+	var lenient Conversation
+	drift := &SchemaDrift{Err: strictErr.Error(), Raw: data}
+	if err := lenientDecode(data, reflect.ValueOf(&lenient).Elem(), "", drift); err != nil {
+		return nil, fmt.Errorf("解析对话详情响应失败: %w", strictErr)
+	}
+	sort.Strings(drift.Dropped)
+	sort.Strings(drift.Unknown)
+	lenient.Drift = drift
+	lenient.Raw = data
+	return &lenient, nil
+}
+
+// lenientDecode 把 data 逐字段解析到 v: 字段能整体解析时直接使用, 否则对结构体、map 与切片逐层展开,
+// 仍无法解析的字段记入 drift.Dropped 后跳过。只有 data 本身的外层形状不对时才返回错误。
+func lenientDecode(data []byte, v reflect.Value, path string, drift *SchemaDrift) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if isJSONNull(data) {
+			return nil
+		}
+		elem := reflect.New(v.Type().Elem())
+		if err := lenientDecode(data, elem.Elem(), path, drift); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	case reflect.Struct:
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return err
+		}
+		if setter, ok := v.Addr().Interface().(rawSetter); ok {
+			setter.setRaw(data)
+		} else if v.Addr().Type().Implements(unmarshalerType) {
+			return json.Unmarshal(data, v.Addr().Interface())
+		}
+		known := make(map[string]bool)
+		for i := 0; i < v.NumField(); i++ {
+			name := jsonFieldName(v.Type().Field(i))
+			if name == "" {
+				continue
+			}
+			known[name] = true
+			raw, ok := fields[name]
+			if !ok {
+				continue
+			}
+			decodeField(raw, v.Field(i), joinPath(path, name), drift)
+		}
+		for name := range fields {
+			if !known[name] {
+				drift.Unknown = append(drift.Unknown, joinPath(path, name))
+			}
+		}
+		return nil
+	case reflect.Map:
+		if isJSONNull(data) {
+			return nil
+		}
+		var entries map[string]json.RawMessage
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return err
+		}
+		result := reflect.MakeMapWithSize(v.Type(), len(entries))
+		for key, raw := range entries {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if !decodeField(raw, elem, joinPath(path, key), drift) {
+				continue
+			}
+			result.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), elem)
+		}
+		v.Set(result)
+		return nil
+	case reflect.Slice:
+		if v.Type() == rawMessageType || isJSONNull(data) {
+			return json.Unmarshal(data, v.Addr().Interface())
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		result := reflect.MakeSlice(v.Type(), 0, len(items))
+		for idx, raw := range items {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if !decodeField(raw, elem, fmt.Sprintf("%s[%d]", path, idx), drift) {
+				continue
+			}
+			result = reflect.Append(result, elem)
+		}
+		v.Set(result)
+		return nil
+	default:
+		return json.Unmarshal(data, v.Addr().Interface())
+	}
+}
+
+// decodeField 解析单个字段, 先整体解析, 失败后逐层展开; 仍然失败时记入 Dropped 并返回 false。
+// 整体解析成功时不会深入检查其中的未知字段。
+func decodeField(raw json.RawMessage, v reflect.Value, path string, drift *SchemaDrift) bool {
+	probe := reflect.New(v.Type())
+	if err := json.Unmarshal(raw, probe.Interface()); err == nil && !hasUnknownFields(raw, v.Type()) {
+		v.Set(probe.Elem())
+		return true
+	}
+	if err := lenientDecode(raw, v, path, drift); err != nil {
+		drift.Dropped = append(drift.Dropped, path)
+		return false
+	}
+	return true
+}
+
+// hasUnknownFields 判断 raw 顶层是否含有结构体 t 未声明的字段, 用于在宽松模式下记录新增字段。
+func hasUnknownFields(raw json.RawMessage, t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || (reflect.PointerTo(t).Implements(unmarshalerType) && !reflect.PointerTo(t).Implements(rawSetterType)) {
+		return false
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		delete(fields, jsonFieldName(t.Field(i)))
+	}
+	return len(fields) > 0
+}
+
+// rawSetter 由保留原始 JSON 的类型实现 (如 Content), 逐字段解析时同样保留原始内容。
+type rawSetter interface {
+	setRaw([]byte)
+}
+
+var (
+	unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	rawMessageType  = reflect.TypeOf(json.RawMessage(nil))
+	rawSetterType   = reflect.TypeOf((*rawSetter)(nil)).Elem()
+)
+
+func jsonFieldName(field reflect.StructField) string {
+	if field.PkgPath != "" {
+		return ""
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return ""
+	}
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name
+	}
+	return field.Name
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func isJSONNull(data []byte) bool {
+	return bytes.Equal(bytes.TrimSpace(data), []byte("null"))
+}
@@ -0,0 +1,110 @@
+package client
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeConversation(t *testing.T) {
+	tests := []struct {
+		name        string
+		raw         string
+		wantTitle   string
+		wantNodes   int
+		wantDrift   bool
+		wantDropped []string
+		wantUnknown []string
+		wantErr     bool
+	}{
+		{
+			name:      "结构与已知格式一致",
+			raw:       `{"id":"c1","title":"标题","new_field":1,"mapping":{"n1":{"id":"n1","message":{"id":"m1","content":{"content_type":"text","parts":["你好"]}}}}}`,
+			wantTitle: "标题",
+			wantNodes: 1,
+		},
+		{
+			name:        "字段类型变化时忽略该字段",
+			raw:         `{"id":"c1","title":"标题","mapping":{"n1":{"id":"n1","message":{"id":"m1","create_time":{"seconds":1},"content":{"content_type":"text","parts":["你好"]}}}}}`,
+			wantTitle:   "标题",
+			wantNodes:   1,
+			wantDrift:   true,
+			wantDropped: []string{"mapping.n1.message.create_time"},
+		},
+		{
+			name:        "无法解析的节点被跳过并记录新增字段",
+			raw:         `{"id":"c1","title":"标题","workspace":{"id":"w"},"mapping":{"n1":{"id":"n1","children":["n2"]},"n2":"broken"}}`,
+			wantTitle:   "标题",
+			wantNodes:   1,
+			wantDrift:   true,
+			wantDropped: []string{"mapping.n2"},
+			wantUnknown: []string{"workspace"},
+		},
+		{
+			name:        "标题类型变化",
+			raw:         `{"id":"c1","title":["标题"],"mapping":{}}`,
+			wantDrift:   true,
+			wantDropped: []string{"title"},
+		},
+		{name: "响应不是 JSON 对象", raw: `["c1"]`, wantErr: true},
+		{name: "响应不是 JSON", raw: `<html>`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conv, err := decodeConversation([]byte(tt.raw))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if conv.ID != "c1" || conv.Title != tt.wantTitle || len(conv.Mapping) != tt.wantNodes || string(conv.Raw) != tt.raw {
+				t.Errorf("decodeConversation() = %+v", conv)
+			}
+			if (conv.Drift != nil) != tt.wantDrift {
+				t.Fatalf("Drift = %+v, wantDrift %v", conv.Drift, tt.wantDrift)
+			}
+			if conv.Drift == nil {
+				return
+			}
+			if conv.Drift.Err == "" || string(conv.Drift.Raw) != tt.raw {
+				t.Errorf("Drift = %+v", conv.Drift)
+			}
+			if strings.Join(conv.Drift.Dropped, ",") != strings.Join(tt.wantDropped, ",") || strings.Join(conv.Drift.Unknown, ",") != strings.Join(tt.wantUnknown, ",") {
+				t.Errorf("Dropped = %v Unknown = %v, want %v %v", conv.Drift.Dropped, conv.Drift.Unknown, tt.wantDropped, tt.wantUnknown)
+			}
+		})
+	}
+}
+
+func TestDecodeConversationKeepsContentRaw(t *testing.T) {
+	raw := `{"id":"c1","title":1,"mapping":{"n1":{"id":"n1","message":{"id":"m1","content":{"content_type":"reasoning_recap","content":"思考了 5 秒"}}}}}`
+	conv, err := decodeConversation([]byte(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conv.Drift == nil {
+		t.Fatal("应按宽松模式解析")
+	}
+	content := conv.Mapping["n1"].Message.Content
+	if content.ContentType != "reasoning_recap" || !strings.Contains(string(content.Raw), "思考了 5 秒") {
+		t.Errorf("Content = %+v", content)
+	}
+}
+
+func TestJSONFieldName(t *testing.T) {
+	type sample struct {
+		Tagged   string `json:"tagged,omitempty"`
+		Plain    string
+		Skipped  string `json:"-"`
+		OnlyOpts string `json:",omitempty"`
+		private  string
+	}
+	want := []string{"tagged", "Plain", "", "OnlyOpts", ""}
+	typ := reflect.TypeOf(sample{})
+	for i := 0; i < typ.NumField(); i++ {
+		if got := jsonFieldName(typ.Field(i)); got != want[i] {
+			t.Errorf("jsonFieldName(%s) = %q, want %q", typ.Field(i).Name, got, want[i])
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// HeaderProfile 是一组浏览器请求头: User-Agent 与对应的 Client Hints。
+// Firefox 与 Safari 不发送 Client Hints, 对应字段为空。
+type HeaderProfile struct {
+	Name            string
+	UserAgent       string
+	SecChUA         string
+	SecChUAMobile   string
+	SecChUAPlatform string
+}
+
+// BrowserProfiles 是内置的常见桌面浏览器请求头, 按 Name 选用。
+var BrowserProfiles = []HeaderProfile{
+	{
+		Name:            "chrome-windows",
+		UserAgent:       "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Safari/537.36",
+		SecChUA:         `"Google Chrome";v="141", "Not?A_Brand";v="8", "Chromium";v="141"`,
+		SecChUAMobile:   "?0",
+		SecChUAPlatform: `"Windows"`,
+	},
+	{
+		Name:            "chrome-mac",
+		UserAgent:       "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Safari/537.36",
+		SecChUA:         `"Google Chrome";v="141", "Not?A_Brand";v="8", "Chromium";v="141"`,
+		SecChUAMobile:   "?0",
+		SecChUAPlatform: `"macOS"`,
+	},
+	{
+		Name:            "edge-windows",
+		UserAgent:       "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Safari/537.36 Edg/141.0.0.0",
+		SecChUA:         `"Microsoft Edge";v="141", "Not?A_Brand";v="8", "Chromium";v="141"`,
+		SecChUAMobile:   "?0",
+		SecChUAPlatform: `"Windows"`,
+	},
+	{
+		Name:      "firefox-mac",
+		UserAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10.15; rv:144.0) Gecko/20100101 Firefox/144.0",
+	},
+	{
+		Name:      "safari-mac",
+		UserAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/26.0 Safari/605.1.15",
+	},
+}
+
+// ProfilesByName 按名称选出内置请求头, "all" 表示全部; 忽略未知名称。
+func ProfilesByName(names []string) []HeaderProfile {
+	var profiles []HeaderProfile
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		for _, profile := range BrowserProfiles {
+			if (name == "all" || profile.Name == name) && !seen[profile.Name] {
+				seen[profile.Name] = true
+				profiles = append(profiles, profile)
+			}
+		}
+	}
+	return profiles
+}
+
+// ProfileRotator 在多组请求头之间轮换: 每组连续使用 batch 次请求后换到下一组, 同一批请求的请求头保持一致。
+// 可在多个 Client 之间共享。
+type ProfileRotator struct {
+	mu       sync.Mutex
+	profiles []HeaderProfile
+	batch    int
+	current  int
+	used     int
+}
+
+// NewProfileRotator 创建轮换器, 从随机一组开始; profiles 为空时返回 nil。batch 小于 1 时按 1 处理。
+func NewProfileRotator(profiles []HeaderProfile, batch int) *ProfileRotator {
+	if len(profiles) == 0 {
+		return nil
+	}
+	return &ProfileRotator{
+		profiles: append([]HeaderProfile(nil), profiles...),
+		batch:    max(batch, 1),
+		current:  rand.IntN(len(profiles)),
+	}
+}
+
+// next 返回本次请求使用的请求头。
+func (r *ProfileRotator) next() HeaderProfile {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.used >= r.batch {
+		r.used = 0
+		r.current = (r.current + 1) % len(r.profiles)
+	}
+	r.used++
+	return r.profiles[r.current]
+}
+
+// apply 写入 User-Agent 与 Client Hints。
+func (p HeaderProfile) apply(header http.Header) {
+	header.Set("User-Agent", p.UserAgent)
+	for name, value := range map[string]string{
+		"Sec-Ch-Ua":          p.SecChUA,
+		"Sec-Ch-Ua-Mobile":   p.SecChUAMobile,
+		"Sec-Ch-Ua-Platform": p.SecChUAPlatform,
+	} {
+		if value != "" {
+			header.Set(name, value)
+		}
+	}
+}
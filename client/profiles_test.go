@@ -0,0 +1,109 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestProfilesByName(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  string
+	}{
+		{nil, ""},
+		{[]string{"chrome-mac"}, "chrome-mac"},
+		{[]string{" Safari-Mac ", "unknown", "chrome-mac", "safari-mac"}, "safari-mac,chrome-mac"},
+		{[]string{"chrome-mac", "all"}, "chrome-mac,chrome-windows,edge-windows,firefox-mac,safari-mac"},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, profile := range ProfilesByName(tt.names) {
+			got = append(got, profile.Name)
+		}
+		if strings.Join(got, ",") != tt.want {
+			t.Errorf("ProfilesByName(%q) = %v, want %s", tt.names, got, tt.want)
+		}
+	}
+}
+
+func TestProfileRotator(t *testing.T) {
+	profiles := []HeaderProfile{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	tests := []struct {
+		name  string
+		batch int
+		want  string
+	}{
+		{name: "每次请求轮换", batch: 0, want: "a,b,c,a,b"},
+		{name: "每组连续使用 batch 次", batch: 2, want: "a,a,b,b,c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewProfileRotator(profiles, tt.batch)
+			// 固定起点, 便于比较顺序。
+			r.current = 0
+			var got []string
+			for i := 0; i < 5; i++ {
+				got = append(got, r.next().Name)
+			}
+			if strings.Join(got, ",") != tt.want {
+				t.Errorf("轮换顺序 = %v, want %s", got, tt.want)
+			}
+		})
+	}
+	if NewProfileRotator(nil, 1) != nil {
+		t.Error("没有请求头时应返回 nil")
+	}
+}
+
+func TestHeaderProfileApply(t *testing.T) {
+	tests := []struct {
+		name       string
+		profile    string
+		wantHints  bool
+		wantAgent  string
+		wantMobile string
+	}{
+		{name: "Chrome 发送 Client Hints", profile: "chrome-windows", wantHints: true, wantAgent: "Chrome/", wantMobile: "?0"},
+		{name: "Firefox 不发送 Client Hints", profile: "firefox-mac", wantAgent: "Firefox/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			ProfilesByName([]string{tt.profile})[0].apply(header)
+			if !strings.Contains(header.Get("User-Agent"), tt.wantAgent) || header.Get("Sec-Ch-Ua-Mobile") != tt.wantMobile {
+				t.Errorf("请求头 = %v", header)
+			}
+			if _, ok := header["Sec-Ch-Ua"]; ok != tt.wantHints {
+				t.Errorf("Sec-Ch-Ua 存在 = %v, want %v", ok, tt.wantHints)
+			}
+		})
+	}
+}
+
+func TestClientUsesProfiles(t *testing.T) {
+	edge := ProfilesByName([]string{"edge-windows"})
+	rotator := NewProfileRotator(edge, 1)
+	tests := []struct {
+		name      string
+		opts      Options
+		wantAgent string
+		wantHint  bool
+	}{
+		{name: "默认 User-Agent", opts: Options{}, wantAgent: DefaultUserAgent},
+		{name: "自定义 User-Agent", opts: Options{UserAgent: " my-agent "}, wantAgent: "my-agent"},
+		{name: "轮换浏览器请求头", opts: Options{UserAgent: "my-agent", Profiles: rotator}, wantAgent: edge[0].UserAgent, wantHint: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := New(tt.opts).newRequest(context.Background(), http.MethodGet, "https://chatgpt.com/backend-api/me", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if req.Header.Get("User-Agent") != tt.wantAgent || (req.Header.Get("Sec-Ch-Ua") != "") != tt.wantHint {
+				t.Errorf("请求头 = %v", req.Header)
+			}
+		})
+	}
+}
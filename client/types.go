@@ -0,0 +1,140 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FlexFloat64 兼容接口中以数字、数字字符串或 RFC3339 字符串表示的时间戳。
+type FlexFloat64 float64
+
+func (f *FlexFloat64) UnmarshalJSON(b []byte) error {
+	s := strings.TrimSpace(string(b))
+	if s == "" || s == "null" {
+		*f = 0
+		return nil
+	}
+
+	var num float64
+	if err := json.Unmarshal(b, &num); err == nil {
+		*f = FlexFloat64(num)
+		return nil
+	}
+
+	var str string
+	if err := json.Unmarshal(b, &str); err == nil {
+		str = strings.TrimSpace(str)
+		if str == "" {
+			*f = 0
+			return nil
+		}
+		if parsed, err := strconv.ParseFloat(str, 64); err == nil {
+			*f = FlexFloat64(parsed)
+			return nil
+		}
+		if t, err := time.Parse(time.RFC3339Nano, str); err == nil {
+			*f = FlexFloat64(float64(t.UnixNano()) / 1e9)
+			return nil
+		}
+		if t, err := time.Parse(time.RFC3339, str); err == nil {
+			*f = FlexFloat64(float64(t.UnixNano()) / 1e9)
+			return nil
+		}
+		return fmt.Errorf("无法解析字符串时间戳: %s", str)
+	}
+
+	return fmt.Errorf("无法解析数值: %s", s)
+}
+
+func (f FlexFloat64) Float64() float64 {
+	return float64(f)
+}
+
+// ConversationPage 是对话列表接口返回的一页结果。
+type ConversationPage struct {
+	Items   []ConversationMeta `json:"items"`
+	Total   int                `json:"total"`
+	Limit   int                `json:"limit"`
+	Offset  int                `json:"offset"`
+	HasMore bool               `json:"has_more"`
+}
+
+// ConversationMeta 是列表中的对话摘要。
+type ConversationMeta struct {
+	ID         string      `json:"id"`
+	Title      string      `json:"title"`
+	CreateTime FlexFloat64 `json:"create_time"`
+	UpdateTime FlexFloat64 `json:"update_time"`
+	// GizmoID 为对话所属的 GPT 或项目, 项目以 g-p- 开头, 见 IsProjectID。
+	GizmoID string `json:"gizmo_id,omitempty"`
+}
+
+// Conversation 是对话详情, Mapping 以节点 ID 为键保存整棵消息树。
+type Conversation struct {
+	ID         string          `json:"id"`
+	Title      string          `json:"title"`
+	CreateTime FlexFloat64     `json:"create_time"`
+	UpdateTime FlexFloat64     `json:"update_time"`
+	Mapping    map[string]Node `json:"mapping"`
+	GizmoID    string          `json:"gizmo_id,omitempty"`
+	// Drift 在接口结构变化、详情按宽松模式解析时非空。
+	Drift *SchemaDrift `json:"-"`
+	// Raw 是解析前的完整 JSON, 包含上面未列出的字段; 由多份内容合并而来时为空。
+	Raw json.RawMessage `json:"-"`
+}
+
+type Node struct {
+	ID       string          `json:"id"`
+	Message  *Message        `json:"message"`
+	Parent   string          `json:"parent"`
+	Children []string        `json:"children"`
+	Metadata json.RawMessage `json:"metadata"`
+}
+
+// Message 是消息树节点上的一条消息, Content.Parts 保留原始 JSON 由调用方按类型解析。
+type Message struct {
+	ID          string          `json:"id"`
+	Author      Author          `json:"author"`
+	CreateTime  FlexFloat64     `json:"create_time"`
+	UpdateTime  FlexFloat64     `json:"update_time"`
+	Content     Content         `json:"content"`
+	Metadata    json.RawMessage `json:"metadata"`
+	Status      string          `json:"status"`
+	EndTurn     *bool           `json:"end_turn"`
+	Weight      *float64        `json:"weight"`
+	Recipient   string          `json:"recipient"`
+	Role        string          `json:"role"`
+	Extras      json.RawMessage `json:"extra_metadata"`
+	Attachments json.RawMessage `json:"attachments"`
+}
+
+type Author struct {
+	Role string `json:"role"`
+	Name string `json:"name"`
+}
+
+type Content struct {
+	ContentType      string            `json:"content_type"`
+	Parts            []json.RawMessage `json:"parts"`
+	Text             string            `json:"text"`
+	UserProfile      string            `json:"user_profile"`
+	UserInstructions string            `json:"user_instructions"`
+	// Raw 保留解析前的完整内容, 供调用方读取上面未列出的字段 (如 reasoning_recap 的 content)。
+	Raw json.RawMessage `json:"-"`
+}
+
+func (c *Content) UnmarshalJSON(b []byte) error {
+	type plain Content
+	if err := json.Unmarshal(b, (*plain)(c)); err != nil {
+		return err
+	}
+	c.setRaw(b)
+	return nil
+}
+
+func (c *Content) setRaw(b []byte) {
+	c.Raw = append(json.RawMessage(nil), b...)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Devoty/openai-backup/export"
+)
+
+// maxCombineConversations 是一次合并的对话数上限。
+const maxCombineConversations = 10
+
+// combineRequest 是 POST /api/conversations/merge 的请求体。Mode 见 export.NormalizeCombineMode,
+// Format 为 markdown、html 或 plaintext; Title 为空时用各对话标题拼接。
+type combineRequest struct {
+	IDs    []string `json:"ids"`
+	Mode   string   `json:"mode"`
+	Format string   `json:"format"`
+	Title  string   `json:"title"`
+}
+
+// handleConversationMerge 处理 POST /api/conversations/merge: 把同一讨论分散在几个对话中的内容合并为一份文档直接返回。
+// interleaved 按消息时间交错, 每条消息标明来源对话; side_by_side 生成各对话并排显示的 HTML 页面。
+func (s *webServer) handleConversationMerge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+		return
+	}
+	var req combineRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorDetail(w, http.StatusBadRequest, errCodeInvalidRequest, "请求体解析失败", err)
+		return
+	}
+	items := exportItemsFromIDs(req.IDs)
+	if len(items) < 2 || len(items) > maxCombineConversations {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, fmt.Sprintf("请选择 2 到 %d 条对话", maxCombineConversations))
+		return
+	}
+	mode := export.NormalizeCombineMode(req.Mode)
+	if mode == "" {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "mode 只支持 interleaved 或 side_by_side")
+		return
+	}
+	format := strings.ToLower(strings.TrimSpace(req.Format))
+	switch format {
+	case "", "markdown", "md":
+		format = "markdown"
+	case "html", "plaintext":
+	default:
+		writeError(w, http.StatusBadRequest, errCodeUnsupportedFormat, fmt.Sprintf("不支持的格式: %s", req.Format))
+		return
+	}
+	if mode == export.CombineSideBySide && format != "html" {
+		writeError(w, http.StatusBadRequest, errCodeUnsupportedFormat, "并排显示只支持 html 格式")
+		return
+	}
+
+	cfg := s.configSnapshot()
+	convs := make([]export.Conversation, 0, len(items))
+	for _, item := range items {
+		conv, err := s.loadExportConversation(r.Context(), item.ID, false)
+		if err != nil {
+			writeAPIError(w, chatgptError(fmt.Sprintf("获取对话 %s 详情失败", item.ID), err))
+			return
+		}
+		convs = append(convs, s.conversationForTarget(titleFallbackZip, conv))
+	}
+
+	htmlOptions := export.HTMLOptions{Theme: cfg.HTMLTheme, CustomCSS: cfg.HTMLCustomCSS, MathMode: cfg.MathMode, RenderDiagrams: cfg.RenderDiagrams, RenderSVG: s.svgRenderer(cfg)}
+	var body, contentType, ext string
+	if mode == export.CombineSideBySide {
+		body, contentType, ext = export.RenderSideBySideHTML(convs, req.Title, cfg.OutputTimezone, htmlOptions), "text/html; charset=utf-8", ".html"
+	} else {
+		combined := export.CombineConversations(convs, req.Title)
+		switch format {
+		case "html":
+			body, contentType, ext = export.RenderHTML(combined, cfg.OutputTimezone, htmlOptions), "text/html; charset=utf-8", ".html"
+		case "plaintext":
+			body, contentType, ext = export.RenderPlainText(combined, cfg.OutputTimezone), "text/plain; charset=utf-8", ".txt"
+		default:
+			body, contentType, ext = export.RenderMarkdown(combined, cfg.OutputTimezone), "text/markdown; charset=utf-8", ".md"
+		}
+	}
+	logInfo("合并对话: 数量=%d 方式=%s 格式=%s", len(convs), mode, format)
+
+	filename := fmt.Sprintf("conversations-merged-%s%s", time.Now().Format("20060102-150405"), ext)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Cache-Control", "no-store")
+	if _, err := w.Write([]byte(body)); err != nil {
+		logInfo("输出合并文档失败: %v", err)
+	}
+}
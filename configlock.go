@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const (
+	// configLockFlag 表示字段由 --lock-config 锁定, 不能通过接口解除。
+	configLockFlag = "flag"
+	// configLockAPI 表示字段由 /api/admin/config-locks 锁定, 保存在配置项 config_locks 中。
+	configLockAPI = "api"
+)
+
+// configLock 是一个被锁定的配置项及锁定来源。
+type configLock struct {
+	Key    string `json:"key"`
+	Source string `json:"source"`
+}
+
+// lockableConfigKeys 返回可以锁定的配置项, 即持久化的全部配置键 (config_locks 本身除外)。
+func lockableConfigKeys() map[string]struct{} {
+	keys := make(map[string]struct{})
+	for key := range configPayloadToItems(ConfigPayload{}) {
+		if key != "config_locks" {
+			keys[key] = struct{}{}
+		}
+	}
+	return keys
+}
+
+// parseConfigLocks 解析以逗号或空白分隔的配置键列表, 去重并排序; unknown 为不存在的配置键。
+func parseConfigLocks(raw string) (keys, unknown []string) {
+	lockable := lockableConfigKeys()
+	seen := make(map[string]struct{})
+	for _, field := range strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	}) {
+		key := strings.ToLower(strings.TrimSpace(field))
+		if _, ok := seen[key]; ok || key == "" {
+			continue
+		}
+		seen[key] = struct{}{}
+		if _, ok := lockable[key]; !ok {
+			unknown = append(unknown, key)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, unknown
+}
+
+// normalizeConfigLocks 归一化配置项 config_locks, 丢弃不存在的配置键。
+func normalizeConfigLocks(raw string) string {
+	keys, _ := parseConfigLocks(raw)
+	return strings.Join(keys, ",")
+}
+
+// configLocks 返回当前锁定的配置项, 同时被两处锁定时记为 flag。
+func (s *webServer) configLocks() []configLock {
+	cfg := s.configSnapshot()
+	sources := make(map[string]string)
+	apiKeys, _ := parseConfigLocks(cfg.ConfigLocks)
+	for _, key := range apiKeys {
+		sources[key] = configLockAPI
+	}
+	flagKeys, _ := parseConfigLocks(cfg.LockConfig)
+	for _, key := range flagKeys {
+		sources[key] = configLockFlag
+	}
+	locks := make([]configLock, 0, len(sources))
+	for key, source := range sources {
+		locks = append(locks, configLock{Key: key, Source: source})
+	}
+	sort.Slice(locks, func(i, j int) bool { return locks[i].Key < locks[j].Key })
+	return locks
+}
+
+// keepLockedConfig 把 payload 中被锁定的配置项 (以及 config_locks 本身) 恢复为当前值,
+// 返回取值与当前值不同、因而被忽略的配置键。
+func (s *webServer) keepLockedConfig(payload *ConfigPayload) []string {
+	current := s.currentConfigPayload()
+	currentItems := configPayloadToItems(current)
+	payloadItems := configPayloadToItems(*payload)
+	var changed []string
+	for _, lock := range s.configLocks() {
+		if payloadItems[lock.Key].value != currentItems[lock.Key].value {
+			changed = append(changed, lock.Key)
+			applyConfigItem(payload, lock.Key, currentItems[lock.Key].value)
+		}
+	}
+	payload.ConfigLocks = current.ConfigLocks
+	return changed
+}
+
+// lockedConfigChanges 返回更新请求 body 中试图修改的已锁定配置项; 取值与当前值相同的字段不算修改,
+// 前端保存整页配置时会原样带上这些字段。
+func (s *webServer) lockedConfigChanges(body []byte) ([]string, error) {
+	locks := s.configLocks()
+	if len(locks) == 0 {
+		return nil, nil
+	}
+	current := s.currentConfigPayload()
+	candidate := current
+	if err := json.Unmarshal(body, &candidate); err != nil {
+		return nil, err
+	}
+	currentItems := configPayloadToItems(current)
+	candidateItems := configPayloadToItems(normalizeConfigImportPayload(candidate))
+	var changed []string
+	for _, lock := range locks {
+		if candidateItems[lock.Key].value != currentItems[lock.Key].value {
+			changed = append(changed, lock.Key)
+		}
+	}
+	return changed, nil
+}
+
+// handleConfigLocks 处理 /api/admin/config-locks: GET 返回锁定的配置项; PUT 以 {"fields": [...]}
+// 替换通过接口锁定的配置项。--lock-config 锁定的字段始终保持锁定。
+func (s *webServer) handleConfigLocks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var req struct {
+			Fields []string `json:"fields"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorDetail(w, http.StatusBadRequest, errCodeInvalidRequest, "请求体解析失败", err)
+			return
+		}
+		keys, unknown := parseConfigLocks(strings.Join(req.Fields, ","))
+		if len(unknown) > 0 {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, fmt.Sprintf("未知的配置项: %s", strings.Join(unknown, ", ")))
+			return
+		}
+		s.configMu.Lock()
+		s.cfg.ConfigLocks = strings.Join(keys, ",")
+		cfgCopy := *s.cfg
+		s.configMu.Unlock()
+		s.persistConfig(&cfgCopy)
+		logInfo("锁定配置项已更新: %s", firstNonEmpty(cfgCopy.ConfigLocks, "(无)"))
+	default:
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"locks": s.configLocks()})
+}
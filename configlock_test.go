@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseConfigLocks(t *testing.T) {
+	tests := []struct {
+		raw         string
+		wantKeys    []string
+		wantUnknown []string
+	}{
+		{raw: "", wantKeys: nil, wantUnknown: nil},
+		{raw: "Token, timezone\nlisten token", wantKeys: []string{"listen", "timezone", "token"}},
+		{raw: "token,config_locks,nope", wantKeys: []string{"token"}, wantUnknown: []string{"config_locks", "nope"}},
+	}
+	for _, tt := range tests {
+		keys, unknown := parseConfigLocks(tt.raw)
+		if !reflect.DeepEqual(keys, tt.wantKeys) || !reflect.DeepEqual(unknown, tt.wantUnknown) {
+			t.Errorf("parseConfigLocks(%q) = %v, %v, want %v, %v", tt.raw, keys, unknown, tt.wantKeys, tt.wantUnknown)
+		}
+	}
+	if got := normalizeConfigLocks(" token,nope, Listen"); got != "listen,token" {
+		t.Errorf("normalizeConfigLocks() = %q", got)
+	}
+}
+
+func TestConfigLocks(t *testing.T) {
+	s := &webServer{cfg: &cliConfig{LockConfig: "token", ConfigLocks: "timezone,token"}}
+	want := []configLock{{Key: "timezone", Source: configLockAPI}, {Key: "token", Source: configLockFlag}}
+	if got := s.configLocks(); !reflect.DeepEqual(got, want) {
+		t.Errorf("configLocks() = %+v, want %+v", got, want)
+	}
+}
+
+func TestKeepLockedConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		payload     ConfigPayload
+		wantChanged []string
+	}{
+		{name: "未修改锁定字段", payload: ConfigPayload{Token: "t-old", Timezone: "UTC", Listen: ":9000"}},
+		{name: "恢复被修改的锁定字段", payload: ConfigPayload{Token: "t-new", Timezone: "Asia/Shanghai", Listen: ":9000", ConfigLocks: ""}, wantChanged: []string{"timezone", "token"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &webServer{cfg: &cliConfig{Token: "t-old", OutputTimezone: "UTC", LockConfig: "token", ConfigLocks: "timezone"}}
+			payload := tt.payload
+			changed := s.keepLockedConfig(&payload)
+			if !reflect.DeepEqual(changed, tt.wantChanged) {
+				t.Errorf("keepLockedConfig() = %v, want %v", changed, tt.wantChanged)
+			}
+			if payload.Token != "t-old" || payload.Timezone != "UTC" || payload.Listen != ":9000" || payload.ConfigLocks != "timezone" {
+				t.Errorf("payload = %+v", payload)
+			}
+		})
+	}
+}
+
+func TestLockedConfigChanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		locks   string
+		body    string
+		want    []string
+		wantErr bool
+	}{
+		{name: "没有锁定字段", locks: "", body: `{"token":"t-new"}`},
+		{name: "修改锁定字段", locks: "token", body: `{"token":" t-new "}`, want: []string{"token"}},
+		{name: "原样带上锁定字段", locks: "token", body: `{"token":"t-old","listen":":9000"}`},
+		{name: "请求体无效", locks: "token", body: `{`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &webServer{cfg: &cliConfig{Token: "t-old", ConfigLocks: tt.locks}}
+			got, err := s.lockedConfigChanges([]byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lockedConfigChanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleConfigLocks(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+		wantLocks  string
+		wantKeys   []string
+	}{
+		{name: "读取锁定字段", method: http.MethodGet, wantStatus: http.StatusOK, wantLocks: "timezone", wantKeys: []string{"timezone", "token"}},
+		{name: "替换接口锁定的字段", method: http.MethodPut, body: `{"fields":["Listen","token"]}`, wantStatus: http.StatusOK, wantLocks: "listen,token", wantKeys: []string{"listen", "token"}},
+		{name: "清空接口锁定的字段", method: http.MethodPut, body: `{"fields":[]}`, wantStatus: http.StatusOK, wantLocks: "", wantKeys: []string{"token"}},
+		{name: "未知字段", method: http.MethodPut, body: `{"fields":["nope"]}`, wantStatus: http.StatusBadRequest, wantLocks: "timezone"},
+		{name: "请求体无效", method: http.MethodPut, body: `[`, wantStatus: http.StatusBadRequest, wantLocks: "timezone"},
+		{name: "不支持的方法", method: http.MethodDelete, wantStatus: http.StatusMethodNotAllowed, wantLocks: "timezone"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &webServer{cfg: &cliConfig{LockConfig: "token", ConfigLocks: "timezone"}}
+			rec := httptest.NewRecorder()
+			s.handleConfigLocks(rec, httptest.NewRequest(tt.method, "/api/admin/config-locks", strings.NewReader(tt.body)))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("状态码 = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if s.cfg.ConfigLocks != tt.wantLocks {
+				t.Errorf("config_locks = %q, want %q", s.cfg.ConfigLocks, tt.wantLocks)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			var resp struct {
+				Locks []configLock `json:"locks"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			var keys []string
+			for _, lock := range resp.Locks {
+				keys = append(keys, lock.Key)
+			}
+			if !reflect.DeepEqual(keys, tt.wantKeys) {
+				t.Errorf("locks = %v, want %v", keys, tt.wantKeys)
+			}
+		})
+	}
+}
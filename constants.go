@@ -1,8 +1,12 @@
 package main
 
+import (
+	"github.com/Devoty/openai-backup/client"
+)
+
 const (
-	defaultBaseURL          = "https://chatgpt.com/backend-api"
-	defaultUserAgent        = "openai-backup/0.1 (+https://github.com/)"
+	defaultBaseURL          = client.DefaultBaseURL
+	defaultUserAgent        = client.DefaultUserAgent
 	defaultConfigDBPath     = "config/app.db"
 	defaultListenAddr       = "127.0.0.1:8080"
 	defaultOrder            = "updated"
@@ -12,6 +16,17 @@ const (
 )
 
 const (
-	exportTargetAnytype = "anytype"
-	exportTargetNotion  = "notion"
+	exportTargetAnytype  = "anytype"
+	exportTargetNotion   = "notion"
+	exportTargetExec     = "exec"
+	exportTargetWebhook  = "webhook"
+	exportTargetAirtable = "airtable"
+	exportTargetGDrive   = "gdrive"
+	exportTargetTelegram = "telegram"
+	exportTargetReadwise = "readwise"
+	exportTargetMemos    = "memos"
+	exportTargetTrilium  = "trilium"
+	exportTargetOneNote  = "onenote"
+	exportTargetMarkdown = "markdown"
+	exportTargetJSON     = "json"
 )
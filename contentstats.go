@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Devoty/openai-backup/client"
+	"github.com/Devoty/openai-backup/export"
+)
+
+const (
+	defaultContentStatsSamples = 20
+	maxContentStatsSamples     = 100
+	// contentStatsExamples 是每种类型最多列出的示例对话数。
+	contentStatsExamples = 3
+)
+
+// contentStatsEntry 是一种 content_type、角色或元数据键的出现次数, Examples 为出现过的对话 ID。
+type contentStatsEntry struct {
+	Name     string   `json:"name"`
+	Count    int      `json:"count"`
+	Handled  *bool    `json:"handled,omitempty"`
+	Examples []string `json:"examples"`
+}
+
+// contentStats 统计抽样对话中消息结构的分布, 用于发现导出尚未专门处理的消息类型。
+type contentStats struct {
+	contentTypes map[string]*contentStatsEntry
+	partTypes    map[string]*contentStatsEntry
+	roles        map[string]*contentStatsEntry
+	recipients   map[string]*contentStatsEntry
+	metadataKeys map[string]*contentStatsEntry
+	messages     int
+}
+
+func newContentStats() *contentStats {
+	return &contentStats{
+		contentTypes: make(map[string]*contentStatsEntry),
+		partTypes:    make(map[string]*contentStatsEntry),
+		roles:        make(map[string]*contentStatsEntry),
+		recipients:   make(map[string]*contentStatsEntry),
+		metadataKeys: make(map[string]*contentStatsEntry),
+	}
+}
+
+func (c *contentStats) count(table map[string]*contentStatsEntry, name, conversationID string) {
+	entry, ok := table[name]
+	if !ok {
+		entry = &contentStatsEntry{Name: name, Examples: []string{}}
+		table[name] = entry
+	}
+	entry.Count++
+	if len(entry.Examples) < contentStatsExamples && (len(entry.Examples) == 0 || entry.Examples[len(entry.Examples)-1] != conversationID) {
+		entry.Examples = append(entry.Examples, conversationID)
+	}
+}
+
+// add 统计对话中所有节点的消息, 包括未选中的分支。
+func (c *contentStats) add(conv *client.Conversation) {
+	for _, node := range conv.Mapping {
+		msg := node.Message
+		if msg == nil {
+			continue
+		}
+		c.messages++
+		c.count(c.contentTypes, firstNonEmpty(msg.Content.ContentType, "(空)"), conv.ID)
+		c.count(c.roles, firstNonEmpty(msg.Author.Role, "(空)"), conv.ID)
+		if recipient := strings.TrimSpace(msg.Recipient); recipient != "" {
+			c.count(c.recipients, recipient, conv.ID)
+		}
+		for _, raw := range msg.Content.Parts {
+			var part struct {
+				ContentType string `json:"content_type"`
+			}
+			if err := json.Unmarshal(raw, &part); err == nil && part.ContentType != "" {
+				c.count(c.partTypes, part.ContentType, conv.ID)
+			}
+		}
+		var metadata map[string]json.RawMessage
+		if err := json.Unmarshal(msg.Metadata, &metadata); err == nil {
+			for key := range metadata {
+				c.count(c.metadataKeys, key, conv.ID)
+			}
+		}
+	}
+}
+
+// sortedContentStats 按出现次数从多到少排列, 次数相同时按名称排列。
+func sortedContentStats(table map[string]*contentStatsEntry) []contentStatsEntry {
+	out := make([]contentStatsEntry, 0, len(table))
+	for _, entry := range table {
+		out = append(out, *entry)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// fetchRawConversation 读取对话的原始结构: 优先使用导入的官方导出数据, 没有或已过期时拉取接口。
+func (s *webServer) fetchRawConversation(ctx context.Context, id string) (*client.Conversation, error) {
+	local, stale := s.localConversation(ctx, id)
+	if local != nil && !stale {
+		return local, nil
+	}
+	remote, err := s.fetchRemoteConversation(ctx, s.configSnapshot(), id)
+	if err != nil {
+		if local != nil {
+			return local, nil
+		}
+		return nil, err
+	}
+	return remote, nil
+}
+
+// handleContentStats 处理 GET /api/debug/content-types?count=20&mode=recent|random:
+// 抽样读取对话详情, 统计 content_type、作者角色、消息接收方与 metadata 键的分布, 标出没有专门处理的类型。
+// 也可以用 ?id=xxx 指定对话 (可重复)。
+func (s *webServer) handleContentStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+		return
+	}
+	query := r.URL.Query()
+	mode := strings.ToLower(strings.TrimSpace(query.Get("mode")))
+	switch mode {
+	case "":
+		mode = testSampleRecent
+	case testSampleRecent, testSampleRandom:
+	default:
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "mode 只支持 recent 或 random")
+		return
+	}
+	count := defaultContentStatsSamples
+	if value := strings.TrimSpace(query.Get("count")); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "count 必须为正整数")
+			return
+		}
+		count = n
+	}
+	if count > maxContentStatsSamples {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, fmt.Sprintf("最多统计 %d 条对话", maxContentStatsSamples))
+		return
+	}
+
+	var ids []string
+	for _, id := range query["id"] {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		items, err := s.sampleConversations(r.Context(), mode, count, query.Get("refresh") == "1")
+		if err != nil {
+			writeAPIError(w, chatgptError("读取对话列表失败", err))
+			return
+		}
+		for _, item := range items {
+			ids = append(ids, item.ID)
+		}
+	}
+	if len(ids) > maxContentStatsSamples {
+		ids = ids[:maxContentStatsSamples]
+	}
+
+	stats := newContentStats()
+	failed := []map[string]string{}
+	for _, id := range ids {
+		if err := r.Context().Err(); err != nil {
+			return
+		}
+		conv, err := s.fetchRawConversation(r.Context(), id)
+		if err != nil {
+			logInfo("统计消息类型时读取对话 %s 失败: %v", id, err)
+			failed = append(failed, map[string]string{"id": id, "error": err.Error()})
+			continue
+		}
+		stats.add(conv)
+	}
+
+	contentTypes := sortedContentStats(stats.contentTypes)
+	unhandled := []string{}
+	for i := range contentTypes {
+		handled := contentTypes[i].Name == "(空)" || export.HasContentHandler(contentTypes[i].Name)
+		contentTypes[i].Handled = &handled
+		if !handled {
+			unhandled = append(unhandled, contentTypes[i].Name)
+		}
+	}
+	logInfo("消息类型统计: 对话=%d 消息=%d 类型=%d 未处理=%d", len(ids)-len(failed), stats.messages, len(contentTypes), len(unhandled))
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"mode":          mode,
+		"conversations": len(ids) - len(failed),
+		"messages":      stats.messages,
+		"content_types": contentTypes,
+		"part_types":    sortedContentStats(stats.partTypes),
+		"roles":         sortedContentStats(stats.roles),
+		"recipients":    sortedContentStats(stats.recipients),
+		"metadata_keys": sortedContentStats(stats.metadataKeys),
+		"unhandled":     unhandled,
+		"failed":        failed,
+	})
+}
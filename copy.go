@@ -0,0 +1,31 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/Devoty/openai-backup/export"
+)
+
+// handleConversationText 处理 GET /api/conversations/{id}/markdown 与 /plaintext: 按导出设置渲染单个对话,
+// 直接返回正文, 供界面复制到剪贴板或在脚本中通过管道使用, 不需要配置导出目标。加 ?refresh=1 重新拉取详情。
+func (s *webServer) handleConversationText(w http.ResponseWriter, r *http.Request, id, format string) {
+	conv, err := s.loadExportConversation(r.Context(), id, r.URL.Query().Get("refresh") == "1")
+	if err != nil {
+		writeAPIError(w, chatgptError("获取对话详情失败", err))
+		return
+	}
+	cfg := s.configSnapshot()
+	conv = s.conversationForTarget(titleFallbackZip, conv)
+	var body string
+	if format == "markdown" {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		body = export.RenderMarkdown(conv, cfg.OutputTimezone)
+	} else {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		body = export.RenderPlainText(conv, cfg.OutputTimezone)
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	if _, err := w.Write([]byte(body)); err != nil {
+		logInfo("输出对话正文失败: %v", err)
+	}
+}
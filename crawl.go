@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Devoty/openai-backup/client"
+)
+
+const (
+	crawlStatusRunning = "running"
+	crawlStatusDone    = "done"
+
+	// crawlResumeMaxAge 之前中断的抓取超过该时长后不再续抓, 列表顺序已变化太多。
+	crawlResumeMaxAge = 24 * time.Hour
+)
+
+const crawlStateSchema = `
+	CREATE TABLE IF NOT EXISTS crawl_state (
+		crawl_key TEXT PRIMARY KEY,
+		status TEXT NOT NULL,
+		next_offset INTEGER NOT NULL DEFAULT 0,
+		total INTEGER NOT NULL DEFAULT 0,
+		started_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);`
+
+const crawlIDsSchema = `
+	CREATE TABLE IF NOT EXISTS crawl_ids (
+		crawl_key TEXT NOT NULL,
+		conversation_id TEXT NOT NULL,
+		PRIMARY KEY (crawl_key, conversation_id)
+	);`
+
+// crawlCheckpoint 是一次完整列表抓取的进度。Collected 为已收集的不重复对话数。
+type crawlCheckpoint struct {
+	Key        string
+	Status     string
+	NextOffset int
+	Total      int
+	Collected  int
+	StartedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// crawlKey 区分排序与归档筛选不同的抓取, 二者的分页互不通用。
+func crawlKey(opts client.ListOptions) string {
+	return fmt.Sprintf("conversations:order=%s:archived=%t", opts.Order, opts.IncludeArchived)
+}
+
+// LoadCrawlCheckpoint 读取抓取进度, 不存在时返回 nil。
+func (s *ConfigStore) LoadCrawlCheckpoint(ctx context.Context, key string) (*crawlCheckpoint, error) {
+	if s == nil || s.archive == nil {
+		return nil, errors.New("配置存储未初始化")
+	}
+	cp := &crawlCheckpoint{Key: key}
+	err := s.archive.reader.QueryRowContext(ctx, `
+		SELECT status, next_offset, total, started_at, updated_at,
+			(SELECT COUNT(*) FROM crawl_ids WHERE crawl_key = ?)
+		FROM crawl_state WHERE crawl_key = ?
+	`, key, key).Scan(&cp.Status, &cp.NextOffset, &cp.Total, &cp.StartedAt, &cp.UpdatedAt, &cp.Collected)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取抓取进度失败: %w", err)
+	}
+	return cp, nil
+}
+
+// StartCrawl 清空旧进度并从 offset 0 开始一次新的抓取。
+func (s *ConfigStore) StartCrawl(ctx context.Context, key string) error {
+	if s == nil || s.archive == nil {
+		return errors.New("配置存储未初始化")
+	}
+	tx, err := s.archive.writer.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `DELETE FROM crawl_ids WHERE crawl_key = ?`, key); err != nil {
+		return fmt.Errorf("清理抓取记录失败: %w", err)
+	}
+	now := time.Now().UTC()
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO crawl_state(crawl_key, status, next_offset, total, started_at, updated_at)
+		VALUES(?, ?, 0, 0, ?, ?)
+		ON CONFLICT(crawl_key) DO UPDATE SET
+			status=excluded.status,
+			next_offset=0,
+			total=0,
+			started_at=excluded.started_at,
+			updated_at=excluded.updated_at
+	`, key, crawlStatusRunning, now, now); err != nil {
+		return fmt.Errorf("写入抓取进度失败: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交抓取进度失败: %w", err)
+	}
+	return nil
+}
+
+// SaveCrawlPage 在同一事务中写入一页对话索引、记录已收集的 ID 并推进 offset,
+// 进程在任意时刻退出时进度与索引保持一致。
+func (s *ConfigStore) SaveCrawlPage(ctx context.Context, key string, metas []client.ConversationMeta, nextOffset, total int) error {
+	if s == nil || s.archive == nil {
+		return errors.New("配置存储未初始化")
+	}
+	tx, err := s.archive.writer.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+	if err := upsertConversationIndexTx(ctx, tx, metas); err != nil {
+		return err
+	}
+	for _, meta := range metas {
+		if strings.TrimSpace(meta.ID) == "" {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO crawl_ids(crawl_key, conversation_id) VALUES(?, ?) ON CONFLICT DO NOTHING`, key, meta.ID); err != nil {
+			return fmt.Errorf("记录抓取 ID 失败: %w", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE crawl_state SET next_offset = ?, total = ?, updated_at = ? WHERE crawl_key = ?`,
+		nextOffset, total, time.Now().UTC(), key); err != nil {
+		return fmt.Errorf("更新抓取进度失败: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交抓取进度失败: %w", err)
+	}
+	return nil
+}
+
+// FinishCrawl 标记抓取完成并清理收集的 ID, 下次抓取从头开始。同时删除索引中本次抓取没有出现
+// (写入时间早于抓取开始) 的对话, 已在上游删除或归档的对话不再出现在筛选结果中; 导入的官方导出数据保留。
+// 返回删除的条数。
+func (s *ConfigStore) FinishCrawl(ctx context.Context, key string) (int, error) {
+	if s == nil || s.archive == nil {
+		return 0, errors.New("配置存储未初始化")
+	}
+	tx, err := s.archive.writer.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+	res, err := tx.ExecContext(ctx, `
+		DELETE FROM conversation_index
+		WHERE indexed_at < (SELECT started_at FROM crawl_state WHERE crawl_key = ?)
+			AND id NOT IN (SELECT id FROM local_conversations)
+	`, key)
+	if err != nil {
+		return 0, fmt.Errorf("清理对话索引失败: %w", err)
+	}
+	removed, _ := res.RowsAffected()
+	if _, err := tx.ExecContext(ctx, `UPDATE crawl_state SET status = ?, updated_at = ? WHERE crawl_key = ?`, crawlStatusDone, time.Now().UTC(), key); err != nil {
+		return 0, fmt.Errorf("更新抓取进度失败: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM crawl_ids WHERE crawl_key = ?`, key); err != nil {
+		return 0, fmt.Errorf("清理抓取记录失败: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("提交抓取进度失败: %w", err)
+	}
+	return int(removed), nil
+}
+
+// crawlConversationIndex 完整拉取对话列表并写入索引, 每页提交一次进度, 完成后移除列表中已不存在的对话。
+// 上次抓取因重启或网络中断未完成且未过期时, 从保存的 offset 继续; 列表在中断期间新增对话
+// 导致的重复条目按 ID 去重。返回本次抓取收集到的对话数。
+func (s *webServer) crawlConversationIndex(ctx context.Context, cfg *cliConfig, token string) (int, error) {
+	s.crawlMu.Lock()
+	defer s.crawlMu.Unlock()
+
+	opts := listOptions(cfg)
+	opts.Limit = indexCrawlPageSize
+	key := crawlKey(opts)
+
+	cp, err := s.store.LoadCrawlCheckpoint(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	offset := 0
+	if cp != nil && cp.Status == crawlStatusRunning && time.Since(cp.UpdatedAt) < crawlResumeMaxAge {
+		offset = cp.NextOffset
+		logInfo("继续上次未完成的列表抓取: offset=%d 已收集=%d 开始于=%s", offset, cp.Collected, cp.StartedAt.Local().Format(time.DateTime))
+	} else if err := s.store.StartCrawl(ctx, key); err != nil {
+		return 0, err
+	}
+
+	api := newChatGPTClient(cfg, token)
+	for {
+		opts.Offset = offset
+		page, err := api.ListConversations(ctx, opts)
+		if err != nil {
+			return 0, fmt.Errorf("拉取对话列表失败 (offset=%d, 进度已保存): %w", offset, err)
+		}
+		next := offset + len(page.Items)
+		if err := s.store.SaveCrawlPage(ctx, key, page.Items, next, page.Total); err != nil {
+			return 0, err
+		}
+		if len(page.Items) == 0 || !page.HasMore {
+			break
+		}
+		offset = next
+	}
+
+	cp, err = s.store.LoadCrawlCheckpoint(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	// 抓取期间有更新的对话会移到列表前面, 可能被分页跳过; 清理索引前按更新时间补抓一遍。
+	if _, err := s.indexUpdatedSince(ctx, cfg, token, float64(cp.StartedAt.Unix())); err != nil {
+		return 0, err
+	}
+	removed, err := s.store.FinishCrawl(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if removed > 0 {
+		logInfo("对话索引中 %d 条对话已不在列表中, 已移除", removed)
+	}
+	return cp.Collected, nil
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Devoty/openai-backup/client"
+)
+
+// fakeConversationList 模拟分页的对话列表接口, failOffset 非负时该页返回 400。
+type fakeConversationList struct {
+	mu         sync.Mutex
+	total      int
+	failOffset int
+	offsets    []int
+}
+
+func (f *fakeConversationList) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	f.offsets = append(f.offsets, offset)
+	if offset == f.failOffset {
+		http.Error(w, `{"detail":"bad request"}`, http.StatusBadRequest)
+		return
+	}
+	page := client.ConversationPage{Total: f.total, Limit: limit, Offset: offset}
+	for i := offset; i < f.total && i < offset+limit; i++ {
+		page.Items = append(page.Items, client.ConversationMeta{ID: fmt.Sprintf("c%03d", i), Title: fmt.Sprintf("对话 %d", i), UpdateTime: client.FlexFloat64(1000 - i)})
+	}
+	page.HasMore = offset+len(page.Items) < f.total
+	json.NewEncoder(w).Encode(page)
+}
+
+func TestCrawlConversationIndex(t *testing.T) {
+	tests := []struct {
+		name string
+		// interruptAt 非负时先抓取一次并在该 offset 失败, 模拟中断。
+		interruptAt int
+		// staleResume 为 true 时把中断的进度改为超过 crawlResumeMaxAge 之前。
+		staleResume bool
+		// wantOffsets 末尾的 0 是完成后按更新时间补抓的一页。
+		wantOffsets []int
+	}{
+		{name: "完整抓取", interruptAt: -1, wantOffsets: []int{0, 100, 200, 0}},
+		{name: "中断后从保存的 offset 续抓", interruptAt: 200, wantOffsets: []int{200, 0}},
+		{name: "过期的进度从头开始", interruptAt: 100, staleResume: true, wantOffsets: []int{0, 100, 200, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			api := &fakeConversationList{total: 250, failOffset: tt.interruptAt}
+			srv := httptest.NewServer(api)
+			defer srv.Close()
+			cfg := &cliConfig{BaseURL: srv.URL, Order: "updated"}
+			s := &webServer{cfg: cfg, store: newTestStore(t)}
+			key := crawlKey(listOptions(cfg))
+
+			if tt.interruptAt >= 0 {
+				if _, err := s.crawlConversationIndex(ctx, cfg, "token"); err == nil {
+					t.Fatal("中断的抓取应返回错误")
+				}
+				cp, err := s.store.LoadCrawlCheckpoint(ctx, key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if cp.Status != crawlStatusRunning || cp.NextOffset != tt.interruptAt || cp.Collected != tt.interruptAt {
+					t.Fatalf("中断后的进度 = %+v", cp)
+				}
+				if tt.staleResume {
+					if _, err := s.store.archive.writer.ExecContext(ctx, `UPDATE crawl_state SET updated_at = ?`, time.Now().UTC().Add(-crawlResumeMaxAge-time.Hour)); err != nil {
+						t.Fatal(err)
+					}
+				}
+				api.mu.Lock()
+				api.failOffset, api.offsets = -1, nil
+				api.mu.Unlock()
+			}
+
+			count, err := s.crawlConversationIndex(ctx, cfg, "token")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count != api.total || !reflect.DeepEqual(api.offsets, tt.wantOffsets) {
+				t.Errorf("收集 %d 条, 请求 offset %v, want %d, %v", count, api.offsets, api.total, tt.wantOffsets)
+			}
+			cp, err := s.store.LoadCrawlCheckpoint(ctx, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cp.Status != crawlStatusDone || cp.Collected != 0 || cp.Total != api.total {
+				t.Errorf("完成后的进度 = %+v", cp)
+			}
+			metas, err := s.store.QueryConversationIndex(ctx, 0, 0, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(metas) != api.total || metas[0].ID != "c000" {
+				t.Errorf("索引 %d 条, 首条 %v", len(metas), metas[0])
+			}
+		})
+	}
+}
+
+func TestCrawlKey(t *testing.T) {
+	tests := []struct {
+		opts client.ListOptions
+		want string
+	}{
+		{client.ListOptions{Order: "updated"}, "conversations:order=updated:archived=false"},
+		{client.ListOptions{Order: "created", IncludeArchived: true, Offset: 100}, "conversations:order=created:archived=true"},
+	}
+	for _, tt := range tests {
+		if got := crawlKey(tt.opts); got != tt.want {
+			t.Errorf("crawlKey(%+v) = %q, want %q", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestRefreshConversationIndex(t *testing.T) {
+	ctx := context.Background()
+	api := &fakeConversationList{total: 250, failOffset: -1}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+	cfg := &cliConfig{BaseURL: srv.URL, Order: "updated", Token: "token"}
+	s := &webServer{cfg: cfg, store: newTestStore(t)}
+
+	// 列表中已不存在的对话在完整抓取后移除, 导入的官方导出数据保留。
+	if err := s.store.UpsertConversationIndex(ctx, []client.ConversationMeta{{ID: "gone", UpdateTime: 5000}}); err != nil {
+		t.Fatal(err)
+	}
+	local := client.ConversationMeta{ID: "local", UpdateTime: 1}
+	if err := s.store.SaveLocalConversations(ctx, []localConversation{{meta: local, data: []byte(`{"id":"local"}`)}}, "takeout"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		full        bool
+		wantOffsets []int
+		wantIndexed int
+	}{
+		{name: "首次完整抓取", wantOffsets: []int{0, 100, 200, 0}, wantIndexed: 251},
+		{name: "之后只增量拉取", wantOffsets: []int{0}, wantIndexed: 251},
+		{name: "refresh 强制完整抓取", full: true, wantOffsets: []int{0, 100, 200, 0}, wantIndexed: 251},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api.mu.Lock()
+			api.offsets = nil
+			api.mu.Unlock()
+			if _, err := s.refreshConversationIndex(ctx, tt.full); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(api.offsets, tt.wantOffsets) {
+				t.Errorf("请求 offset %v, want %v", api.offsets, tt.wantOffsets)
+			}
+			metas, err := s.store.QueryConversationIndex(ctx, 0, 0, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := make(map[string]bool, len(metas))
+			for _, meta := range metas {
+				ids[meta.ID] = true
+			}
+			if len(metas) != tt.wantIndexed || ids["gone"] || !ids["local"] {
+				t.Errorf("索引 %d 条 (gone=%t local=%t), want %d 条且只保留 local", len(metas), ids["gone"], ids["local"], tt.wantIndexed)
+			}
+		})
+	}
+}
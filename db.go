@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// sqliteBusyTimeoutMS 是等待其他连接释放锁的时长, 超过后才返回 SQLITE_BUSY。
+const sqliteBusyTimeoutMS = 5000
+
+// archiveTables 是从配置库拆分到归档库的表, 旧版本把它们与配置项放在同一个文件中。
+var archiveTables = []string{"failed_exports", "export_state", "conversation_index", "crawl_state", "crawl_ids"}
+
+// sqliteDB 是同一个 SQLite 文件上的两组连接: writer 只有一个连接, 所有写入与事务在此串行,
+// 事务以 BEGIN IMMEDIATE 开始, 避免读事务升级为写事务时互相等待; reader 是只读连接池,
+// WAL 模式下读取不阻塞写入, 也不被写入阻塞。
+type sqliteDB struct {
+	path   string
+	writer *sql.DB
+	reader *sql.DB
+}
+
+func openSQLite(path string) (*sqliteDB, error) {
+	base := fmt.Sprintf("file:%s?_pragma=foreign_keys(ON)&_pragma=busy_timeout(%d)", path, sqliteBusyTimeoutMS)
+	writer, err := sql.Open("sqlite", base+"&_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)&_txlock=immediate")
+	if err != nil {
+		return nil, err
+	}
+	writer.SetConnMaxLifetime(0)
+	writer.SetMaxOpenConns(1)
+	writer.SetMaxIdleConns(1)
+	// 先在写连接上切换到 WAL, 只读连接无法修改日志模式。
+	if err := writer.Ping(); err != nil {
+		writer.Close()
+		return nil, err
+	}
+
+	reader, err := sql.Open("sqlite", base+"&_pragma=query_only(ON)")
+	if err != nil {
+		writer.Close()
+		return nil, err
+	}
+	readers := runtime.NumCPU()
+	if readers < 4 {
+		readers = 4
+	}
+	reader.SetConnMaxLifetime(0)
+	reader.SetMaxOpenConns(readers)
+	reader.SetMaxIdleConns(readers)
+	return &sqliteDB{path: path, writer: writer, reader: reader}, nil
+}
+
+func (d *sqliteDB) Close() error {
+	if d == nil {
+		return nil
+	}
+	rerr := d.reader.Close()
+	if err := d.writer.Close(); err != nil {
+		return err
+	}
+	return rerr
+}
+
+// archiveDBPath 返回与配置库同目录的归档库路径, 例如 config/app.db 对应 config/app.archive.db。
+func archiveDBPath(configPath string) string {
+	ext := filepath.Ext(configPath)
+	return strings.TrimSuffix(configPath, ext) + ".archive" + ext
+}
+
+// migrateArchiveTables 把旧版本写在配置库中的归档表搬到归档库, 完成后从配置库删除。
+// 归档库中已有的同主键记录保留不动。
+func migrateArchiveTables(ctx context.Context, config *sqliteDB, archivePath string) error {
+	conn, err := config.writer.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var legacy []string
+	for _, table := range archiveTables {
+		var count int
+		if err := conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&count); err != nil {
+			return fmt.Errorf("检查旧版归档表失败: %w", err)
+		}
+		if count > 0 {
+			legacy = append(legacy, table)
+		}
+	}
+	if len(legacy) == 0 {
+		return nil
+	}
+
+	if _, err := conn.ExecContext(ctx, `ATTACH DATABASE ? AS archive`, archivePath); err != nil {
+		return fmt.Errorf("挂载归档库失败: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `DETACH DATABASE archive`)
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+	for _, table := range legacy {
+		// 只搬两边都有的列, 旧版本的表可能缺少后来新增的列。
+		columns, err := tableColumns(ctx, tx, "main", table)
+		if err != nil {
+			return err
+		}
+		current, err := tableColumns(ctx, tx, "archive", table)
+		if err != nil {
+			return err
+		}
+		known := make(map[string]bool, len(current))
+		for _, name := range current {
+			known[name] = true
+		}
+		var shared []string
+		for _, name := range columns {
+			if known[name] {
+				shared = append(shared, name)
+			}
+		}
+		list := strings.Join(shared, ", ")
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`INSERT OR IGNORE INTO archive.%s (%s) SELECT %s FROM main.%s`, table, list, list, table)); err != nil {
+			return fmt.Errorf("迁移归档表 %s 失败: %w", table, err)
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DROP TABLE main.%s`, table)); err != nil {
+			return fmt.Errorf("删除旧版归档表 %s 失败: %w", table, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交归档表迁移失败: %w", err)
+	}
+	logInfo("已将 %s 从配置库迁移到归档库: %s", strings.Join(legacy, ", "), archivePath)
+	return nil
+}
+
+func tableColumns(ctx context.Context, tx *sql.Tx, schema, table string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`SELECT name FROM pragma_table_info('%s', '%s')`, table, schema))
+	if err != nil {
+		return nil, fmt.Errorf("读取表结构 %s 失败: %w", table, err)
+	}
+	defer rows.Close()
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("读取表结构 %s 失败: %w", table, err)
+		}
+		columns = append(columns, name)
+	}
+	return columns, rows.Err()
+}
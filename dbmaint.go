@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Devoty/openai-backup/atomicfile"
+	"github.com/Devoty/openai-backup/diskspace"
+)
+
+const (
+	dbOpVacuum         = "vacuum"
+	dbOpIntegrityCheck = "integrity_check"
+	dbOpBackup         = "backup"
+
+	// dbBackupDirName 是备份文件所在目录, 位于配置库同级。
+	dbBackupDirName = "backups"
+)
+
+var errDBIntegrity = errors.New("数据库完整性检查未通过")
+
+// dbMaintenanceResult 是对单个 SQLite 文件执行维护操作的结果。
+type dbMaintenanceResult struct {
+	Database   string   `json:"database"`
+	Path       string   `json:"path"`
+	SizeBefore int64    `json:"size_before"`
+	SizeAfter  int64    `json:"size_after"`
+	OK         bool     `json:"ok"`
+	Problems   []string `json:"problems,omitempty"`
+	BackupPath string   `json:"backup_path,omitempty"`
+	DurationMS int64    `json:"duration_ms"`
+}
+
+func normalizeDBOperation(op string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(op)) {
+	case dbOpVacuum:
+		return dbOpVacuum, true
+	case dbOpIntegrityCheck, "check":
+		return dbOpIntegrityCheck, true
+	case dbOpBackup:
+		return dbOpBackup, true
+	default:
+		return "", false
+	}
+}
+
+type namedDB struct {
+	name string
+	db   *sqliteDB
+}
+
+// databases 按固定顺序返回配置库与归档库。
+func (s *ConfigStore) databases() []namedDB {
+	return []namedDB{{"config", s.config}, {"archive", s.archive}}
+}
+
+// Maintain 对配置库与归档库依次执行维护操作。操作都在写连接上执行, 与正在进行的写入串行,
+// 读取不受影响。integrity_check 发现问题时结果中 OK 为 false, 不返回错误。
+func (s *ConfigStore) Maintain(ctx context.Context, op string) ([]dbMaintenanceResult, error) {
+	if s == nil || s.config == nil || s.archive == nil {
+		return nil, errors.New("配置存储未初始化")
+	}
+	stamp := time.Now().Format("20060102-150405")
+	if op == dbOpBackup {
+		// 副本不大于数据库文件与 WAL 之和, 先检查备份目录所在磁盘, 避免只备份了一部分。
+		var need int64
+		for _, item := range s.databases() {
+			need += sqliteFileSize(item.db.path)
+		}
+		if err := diskspace.Check(filepath.Join(filepath.Dir(s.config.path), dbBackupDirName), need); err != nil {
+			return nil, err
+		}
+	}
+	var results []dbMaintenanceResult
+	for _, item := range s.databases() {
+		start := time.Now()
+		result := dbMaintenanceResult{Database: item.name, Path: item.db.path, SizeBefore: sqliteFileSize(item.db.path)}
+		var err error
+		switch op {
+		case dbOpVacuum:
+			_, err = item.db.writer.ExecContext(ctx, `VACUUM`)
+			if err == nil {
+				// 把 WAL 中的内容写回主文件, 文件大小才能反映 VACUUM 的效果。
+				_, err = item.db.writer.ExecContext(ctx, `PRAGMA wal_checkpoint(TRUNCATE)`)
+			}
+		case dbOpIntegrityCheck:
+			result.Problems, err = integrityCheck(ctx, item.db)
+		case dbOpBackup:
+			result.BackupPath, err = backupSQLite(ctx, s, item.db, stamp)
+		default:
+			return nil, fmt.Errorf("不支持的数据库操作: %s", op)
+		}
+		if err != nil {
+			return results, fmt.Errorf("%s 数据库执行 %s 失败: %w", item.name, op, err)
+		}
+		result.OK = len(result.Problems) == 0
+		result.SizeAfter = sqliteFileSize(item.db.path)
+		result.DurationMS = time.Since(start).Milliseconds()
+		logInfo("数据库维护: 操作=%s 数据库=%s 结果=%t 大小=%d→%d 耗时=%dms", op, item.name, result.OK, result.SizeBefore, result.SizeAfter, result.DurationMS)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func integrityCheck(ctx context.Context, db *sqliteDB) ([]string, error) {
+	rows, err := db.writer.QueryContext(ctx, `PRAGMA integrity_check`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var problems []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, err
+		}
+		if line != "ok" {
+			problems = append(problems, line)
+		}
+	}
+	return problems, rows.Err()
+}
+
+// backupSQLite 用 VACUUM INTO 在线生成一致的副本, 写入配置库同级的 backups/ 目录,
+// 文件名带时间戳, 例如 backups/app-20240101-120000.db。副本先写入临时文件, 完成后才改名。
+func backupSQLite(ctx context.Context, journal atomicfile.Journal, db *sqliteDB, stamp string) (string, error) {
+	dir := filepath.Join(filepath.Dir(db.path), dbBackupDirName)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("创建备份目录失败: %w", err)
+	}
+	base := filepath.Base(db.path)
+	ext := filepath.Ext(base)
+	target := filepath.Join(dir, strings.TrimSuffix(base, ext)+"-"+stamp+ext)
+	file, err := atomicfile.Create(journal, target)
+	if err != nil {
+		return "", fmt.Errorf("创建备份文件失败: %w", err)
+	}
+	if _, err := db.writer.ExecContext(ctx, `VACUUM INTO ?`, file.Name()); err != nil {
+		file.Abort()
+		return "", err
+	}
+	if err := file.Commit(0o644); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// sqliteFileSize 返回数据库文件与 WAL 文件的总大小。
+func sqliteFileSize(path string) int64 {
+	var total int64
+	for _, name := range []string{path, path + "-wal"} {
+		if info, err := os.Stat(name); err == nil {
+			total += info.Size()
+		}
+	}
+	return total
+}
+
+// dbFileInfo 是 GET /api/admin/db 返回的文件信息。
+type dbFileInfo struct {
+	Database string `json:"database"`
+	Path     string `json:"path"`
+	Size     int64  `json:"size"`
+}
+
+type dbMaintenanceRequest struct {
+	Operation string `json:"operation"`
+}
+
+// handleAdminDB 对本地 SQLite 文件执行维护: GET 返回文件路径与大小, POST 执行
+// vacuum、integrity_check 或 backup。
+func (s *webServer) handleAdminDB(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		var files []dbFileInfo
+		for _, item := range s.store.databases() {
+			files = append(files, dbFileInfo{Database: item.name, Path: item.db.path, Size: sqliteFileSize(item.db.path)})
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"databases": files})
+	case http.MethodPost:
+		var req dbMaintenanceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorDetail(w, http.StatusBadRequest, errCodeInvalidRequest, "请求体解析失败", err)
+			return
+		}
+		op, ok := normalizeDBOperation(req.Operation)
+		if !ok {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "operation 只能是 vacuum、integrity_check 或 backup")
+			return
+		}
+		results, err := s.store.Maintain(r.Context(), op)
+		if errors.Is(err, diskspace.ErrInsufficient) {
+			writeErrorDetail(w, http.StatusInsufficientStorage, errCodeInsufficientStorage, "磁盘空间不足, 无法备份数据库", err)
+			return
+		}
+		if err != nil {
+			writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "数据库维护失败", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"operation": op, "results": results})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+	}
+}
+
+// runDBMaintenance 是 --db-maintenance 的命令行入口, 结果以 JSON 写入 w;
+// 完整性检查未通过时返回错误, 进程以非零状态退出。
+func runDBMaintenance(ctx context.Context, cfg *cliConfig, operation string, w io.Writer) error {
+	op, ok := normalizeDBOperation(operation)
+	if !ok {
+		return fmt.Errorf("不支持的数据库操作: %s (可选 vacuum、integrity_check、backup)", operation)
+	}
+	store, err := Init(cfg.ConfigDBPath)
+	if err != nil {
+		return fmt.Errorf("初始化配置存储失败: %w", err)
+	}
+	defer store.Close()
+	results, err := store.Maintain(ctx, op)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(results); err != nil {
+		return fmt.Errorf("输出维护结果失败: %w", err)
+	}
+	for _, result := range results {
+		if !result.OK {
+			return errDBIntegrity
+		}
+	}
+	return nil
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Devoty/openai-backup/client"
+	"github.com/Devoty/openai-backup/httpc"
+)
+
+const (
+	// defaultDeleteInterval 是 delete_interval_ms 为 0 时两次删除请求之间的间隔。
+	defaultDeleteInterval = time.Second
+	// deleteJobTarget 是删除任务记录中的目标名。
+	deleteJobTarget = "chatgpt"
+)
+
+// pendingDeletesSchema 保存等待期内的删除, 服务重启后由 resumePendingDeletes 重新安排; 撤销或执行结束时删除记录。
+const pendingDeletesSchema = `
+	CREATE TABLE IF NOT EXISTS pending_deletes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		conversation_ids TEXT NOT NULL,
+		execute_at TIMESTAMP NOT NULL,
+		created_at TIMESTAMP NOT NULL
+	);`
+
+// pendingDelete 是一次等待执行的删除, ID 为 pending_deletes 中的行号, 未能保存时为 0。
+type pendingDelete struct {
+	ID        int64
+	IDs       []string
+	ExecuteAt time.Time
+}
+
+// handleDelete 处理 POST /api/conversations/delete: 在后台任务中逐条删除对话, 立即返回任务 ID,
+// 进度与逐条结果通过 /api/jobs/{id} 查询, 可用 /api/jobs/{id}/cancel 取消。配置了 delete_grace_minutes 时
+// 任务先等待该时长再开始删除, 期间取消即撤销本次删除; 等待中的删除保存在 pending_deletes 中, 重启后继续等待。请求体 wait 为 true 时直接删除并等待任务结束;
+// 配置了等待期时 wait 不生效, 同样返回等待中的任务, 撤销窗口不能被跳过。
+func (s *webServer) handleDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+		return
+	}
+	cfg := s.configSnapshot()
+	if strings.TrimSpace(cfg.Token) == "" {
+		writeError(w, http.StatusBadRequest, errCodeChatGPTTokenMissing, errChatGPTTokenMissing.Error())
+		return
+	}
+	var req deleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorDetail(w, http.StatusBadRequest, errCodeInvalidRequest, "请求体解析失败", err)
+		return
+	}
+	if len(req.IDs) == 0 {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "请选择至少一条对话")
+		return
+	}
+	seen := make(map[string]struct{})
+	var ids []string
+	for _, rawID := range req.IDs {
+		id := strings.TrimSpace(rawID)
+		if id == "" {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "没有有效的对话可删除")
+		return
+	}
+
+	job := s.jobs.start("delete", deleteJobTarget)
+	job.addPending(len(ids))
+	ctx, cancel := context.WithCancel(context.Background())
+	job.setCancel(cancel)
+	logInfo("Web 删除触发: 对话=%d 任务=%s", len(ids), job.ID)
+
+	grace := time.Duration(cfg.DeleteGraceMinutes) * time.Minute
+	if req.Wait && grace > 0 {
+		logInfo("已配置删除等待期, 忽略 wait: 任务=%s", job.ID)
+	}
+	if !req.Wait || grace > 0 {
+		response := map[string]interface{}{
+			"job_id": job.ID,
+			"total":  len(ids),
+			"status": jobStatusRunning,
+		}
+		if grace > 0 {
+			pending := pendingDelete{IDs: ids, ExecuteAt: time.Now().Add(grace)}
+			job.schedule(pending.ExecuteAt)
+			if rowID, err := s.store.SavePendingDelete(context.Background(), pending); err != nil {
+				logInfo("%v, 重启服务会放弃本次删除: 任务=%s", err, job.ID)
+			} else {
+				pending.ID = rowID
+			}
+			response["status"] = jobStatusPending
+			response["execute_at"] = pending.ExecuteAt
+			logInfo("删除任务等待执行: 任务=%s 执行时间=%s", job.ID, pending.ExecuteAt.In(s.locationSnapshot()).Format("2006-01-02 15:04:05"))
+			go s.runScheduledDelete(ctx, cancel, job, pending)
+		} else {
+			go func() {
+				defer cancel()
+				s.runDeleteJob(ctx, job, ids)
+			}()
+		}
+		writeJSON(w, http.StatusAccepted, response)
+		return
+	}
+
+	// 等待结果时, 请求断开同样取消任务。
+	stop := context.AfterFunc(r.Context(), cancel)
+	defer stop()
+	jobErr := s.runDeleteJob(ctx, job, ids)
+	cancel()
+
+	snapshot := job.snapshot()
+	deleted := []string{}
+	var failed, skipped []jobOutcome
+	for _, item := range snapshot.Outcomes {
+		switch item.Status {
+		case outcomeDeleted:
+			deleted = append(deleted, item.ConversationID)
+		case outcomeFailed:
+			failed = append(failed, item)
+		case outcomeSkipped:
+			skipped = append(skipped, item)
+		}
+	}
+	if len(deleted) == 0 && jobErr != nil && !errors.Is(jobErr, context.Canceled) {
+		writeAPIError(w, chatgptError("删除对话失败", jobErr))
+		return
+	}
+	response := map[string]interface{}{
+		"job_id":  job.ID,
+		"status":  snapshot.Status,
+		"deleted": deleted,
+		"count":   len(deleted),
+	}
+	if len(failed) > 0 {
+		response["failed"] = failed
+	}
+	if len(skipped) > 0 {
+		response["skipped"] = skipped
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// runScheduledDelete 等待到 pending.ExecuteAt 后执行删除, 等待期间取消即撤销本次删除。撤销或执行结束后
+// 删除持久化的记录; 执行中途服务退出时记录保留, 重启后重新执行, 已删除的对话记为跳过。
+func (s *webServer) runScheduledDelete(ctx context.Context, cancel context.CancelFunc, job *exportJob, pending pendingDelete) {
+	defer cancel()
+	defer func() {
+		if pending.ID == 0 {
+			return
+		}
+		if err := s.store.RemovePendingDelete(context.Background(), pending.ID); err != nil {
+			logInfo("%v", err)
+		}
+	}()
+	if err := sleepContext(ctx, time.Until(pending.ExecuteAt)); err != nil {
+		s.jobs.finish(job, err, s.locationSnapshot())
+		return
+	}
+	job.begin()
+	s.runDeleteJob(ctx, job, pending.IDs)
+}
+
+// resumePendingDeletes 为上次退出时仍在等待的删除重新创建任务, 任务 ID 与重启前不同;
+// 执行时间已过的删除立即开始。
+func (s *webServer) resumePendingDeletes(ctx context.Context) {
+	items, err := s.store.ListPendingDeletes(ctx)
+	if err != nil {
+		logInfo("%v", err)
+		return
+	}
+	for _, pending := range items {
+		job := s.jobs.start("delete", deleteJobTarget)
+		job.addPending(len(pending.IDs))
+		jobCtx, cancel := context.WithCancel(context.Background())
+		job.setCancel(cancel)
+		job.schedule(pending.ExecuteAt)
+		logInfo("恢复等待中的删除: 对话=%d 任务=%s 执行时间=%s", len(pending.IDs), job.ID, pending.ExecuteAt.In(s.locationSnapshot()).Format("2006-01-02 15:04:05"))
+		go s.runScheduledDelete(jobCtx, cancel, job, pending)
+	}
+}
+
+// SavePendingDelete 保存一次等待执行的删除, 返回记录的行号。
+func (s *ConfigStore) SavePendingDelete(ctx context.Context, pending pendingDelete) (int64, error) {
+	if s == nil || s.archive == nil {
+		return 0, errors.New("配置存储未初始化")
+	}
+	data, err := json.Marshal(pending.IDs)
+	if err != nil {
+		return 0, fmt.Errorf("序列化待删除对话失败: %w", err)
+	}
+	res, err := s.archive.writer.ExecContext(ctx, `
+		INSERT INTO pending_deletes(conversation_ids, execute_at, created_at) VALUES(?, ?, ?)
+	`, string(data), pending.ExecuteAt.UTC(), time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("保存等待中的删除失败: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("保存等待中的删除失败: %w", err)
+	}
+	return id, nil
+}
+
+// RemovePendingDelete 删除撤销或已执行的删除记录。
+func (s *ConfigStore) RemovePendingDelete(ctx context.Context, id int64) error {
+	if s == nil || s.archive == nil {
+		return errors.New("配置存储未初始化")
+	}
+	if _, err := s.archive.writer.ExecContext(ctx, `DELETE FROM pending_deletes WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("删除等待中的删除记录失败: %w", err)
+	}
+	return nil
+}
+
+// ListPendingDeletes 按执行时间列出等待中的删除。
+func (s *ConfigStore) ListPendingDeletes(ctx context.Context) ([]pendingDelete, error) {
+	if s == nil || s.archive == nil {
+		return nil, errors.New("配置存储未初始化")
+	}
+	rows, err := s.archive.reader.QueryContext(ctx, `
+		SELECT id, conversation_ids, execute_at FROM pending_deletes ORDER BY execute_at, id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("读取等待中的删除失败: %w", err)
+	}
+	return scanPendingDeletes(rows)
+}
+
+func scanPendingDeletes(rows *sql.Rows) ([]pendingDelete, error) {
+	defer rows.Close()
+	var items []pendingDelete
+	for rows.Next() {
+		var item pendingDelete
+		var ids string
+		if err := rows.Scan(&item.ID, &ids, &item.ExecuteAt); err != nil {
+			return nil, fmt.Errorf("解析等待中的删除失败: %w", err)
+		}
+		if err := json.Unmarshal([]byte(ids), &item.IDs); err != nil {
+			return nil, fmt.Errorf("解析待删除对话失败: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取等待中的删除失败: %w", err)
+	}
+	return items, nil
+}
+
+// runDeleteJob 按 delete_interval_ms 的间隔逐条删除对话并记录结果。对话不存在 (404) 时记为跳过,
+// 其他单条失败继续处理后续对话; Token 失效、请求被拦截或持续限流时中止任务, 返回该错误。
+func (s *webServer) runDeleteJob(ctx context.Context, job *exportJob, ids []string) error {
+	ctx = httpc.Background(ctx)
+	cfg := s.configSnapshot()
+	chatgpt := newChatGPTClient(cfg, strings.TrimSpace(cfg.Token))
+	interval := time.Duration(cfg.DeleteIntervalMs) * time.Millisecond
+	if interval <= 0 {
+		interval = defaultDeleteInterval
+	}
+
+	var (
+		jobErr  error
+		deleted int
+	)
+	for i, id := range ids {
+		if i > 0 {
+			if err := sleepContext(ctx, interval); err != nil {
+				jobErr = err
+				break
+			}
+		}
+		outcome := jobOutcome{ConversationID: id, Target: deleteJobTarget}
+		if meta, ok := s.lookupConversationMeta(id); ok {
+			outcome.Title = meta.Title
+		}
+		started := time.Now()
+		err := chatgpt.DeleteConversation(ctx, id)
+		outcome.DurationMs = time.Since(started).Milliseconds()
+		if ctx.Err() != nil {
+			jobErr = ctx.Err()
+			break
+		}
+		var statusErr *client.StatusError
+		switch {
+		case err == nil:
+			outcome.Status = outcomeDeleted
+			s.removeDetailCache(id)
+			deleted++
+		case errors.As(err, &statusErr) && statusErr.Status == http.StatusNotFound:
+			outcome.Status = outcomeSkipped
+			outcome.Error = "对话不存在或已被删除 (404)"
+		default:
+			outcome.Status = outcomeFailed
+			outcome.Error = err.Error()
+			if deleteAborts(err) {
+				jobErr = fmt.Errorf("删除对话 %s 失败: %w", id, err)
+			}
+		}
+		job.record(outcome)
+		job.advance(nil)
+		if jobErr != nil {
+			break
+		}
+	}
+
+	if deleted > 0 {
+		s.invalidateConversationCache()
+	}
+	s.jobs.finish(job, jobErr, s.locationSnapshot())
+	return jobErr
+}
+
+// deleteAborts 判断删除失败是否会影响后续所有请求: Token 失效 (401)、整体被拦截 (返回 HTML 页面的 403)
+// 或节流重试后仍被限流 (429)。
+func deleteAborts(err error) bool {
+	var statusErr *client.StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	switch statusErr.Status {
+	case http.StatusUnauthorized, http.StatusTooManyRequests:
+		return true
+	case http.StatusForbidden:
+		return !strings.HasPrefix(strings.TrimSpace(statusErr.Body), "{")
+	default:
+		return false
+	}
+}
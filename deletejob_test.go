@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandleDeleteAppliesGracePeriod(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "后台执行", body: `{"ids":["a","b"]}`},
+		{name: "等待结果", body: `{"ids":["a","b"],"wait":true}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &webServer{
+				cfg:      &cliConfig{Token: "token", DeleteGraceMinutes: 10},
+				location: time.UTC,
+				jobs:     newJobManager(t.TempDir(), nil),
+				store:    newTestStore(t),
+			}
+			rec := httptest.NewRecorder()
+			s.handleDelete(rec, httptest.NewRequest(http.MethodPost, "/api/conversations/delete", strings.NewReader(tt.body)))
+			if rec.Code != http.StatusAccepted {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusAccepted, rec.Body.String())
+			}
+			var resp struct {
+				JobID     string     `json:"job_id"`
+				Status    string     `json:"status"`
+				ExecuteAt *time.Time `json:"execute_at"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Status != jobStatusPending || resp.ExecuteAt == nil {
+				t.Fatalf("status = %q execute_at = %v, want pending", resp.Status, resp.ExecuteAt)
+			}
+			if wait := time.Until(*resp.ExecuteAt); wait < 9*time.Minute {
+				t.Errorf("等待期 = %s, want 约 10 分钟", wait)
+			}
+
+			saved, err := s.store.ListPendingDeletes(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(saved) != 1 || strings.Join(saved[0].IDs, ",") != "a,b" {
+				t.Fatalf("ListPendingDeletes() = %+v, want 一条 a,b", saved)
+			}
+
+			job, ok := s.jobs.get(resp.JobID)
+			if !ok || !job.requestCancel() {
+				t.Fatal("等待中的删除应可撤销")
+			}
+			waitPendingDeletesCleared(t, s, job)
+			if got := job.snapshot().Summary.Deleted; got != 0 {
+				t.Errorf("撤销后删除了 %d 条对话", got)
+			}
+		})
+	}
+}
+
+func TestResumePendingDeletes(t *testing.T) {
+	s := &webServer{
+		cfg:      &cliConfig{Token: "token"},
+		location: time.UTC,
+		jobs:     newJobManager(t.TempDir(), nil),
+		store:    newTestStore(t),
+	}
+	executeAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	if _, err := s.store.SavePendingDelete(context.Background(), pendingDelete{IDs: []string{"a", "b"}, ExecuteAt: executeAt}); err != nil {
+		t.Fatal(err)
+	}
+
+	s.resumePendingDeletes(context.Background())
+	if len(s.jobs.order) != 1 {
+		t.Fatalf("恢复了 %d 个任务, want 1", len(s.jobs.order))
+	}
+	job, _ := s.jobs.get(s.jobs.order[0])
+	snapshot := job.snapshot()
+	if snapshot.Status != jobStatusPending || snapshot.ExecuteAt == nil || !snapshot.ExecuteAt.Equal(executeAt) {
+		t.Fatalf("恢复的任务 status = %q execute_at = %v, want pending %v", snapshot.Status, snapshot.ExecuteAt, executeAt)
+	}
+	if !job.requestCancel() {
+		t.Fatal("恢复的删除应可撤销")
+	}
+	waitPendingDeletesCleared(t, s, job)
+}
+
+// waitPendingDeletesCleared 等待任务结束为已取消且等待中的删除记录被清除。
+func waitPendingDeletesCleared(t *testing.T, s *webServer, job *exportJob) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		saved, err := s.store.ListPendingDeletes(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.snapshot().Status == jobStatusCanceled && len(saved) == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("撤销后状态 = %q, 剩余记录 %d 条", job.snapshot().Status, len(saved))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestJobManagerKeepsUnfinishedJobs(t *testing.T) {
+	m := newJobManager(t.TempDir(), nil)
+	pending := m.start("delete", deleteJobTarget)
+	pending.schedule(time.Now().Add(time.Hour))
+	running := m.start("import", "notion")
+	var finished []*exportJob
+	for i := 0; i < maxRetainedJobs+10; i++ {
+		job := m.start("import", "notion")
+		m.finish(job, nil, time.UTC)
+		finished = append(finished, job)
+	}
+
+	for _, job := range []*exportJob{pending, running} {
+		if _, ok := m.get(job.ID); !ok {
+			t.Errorf("未结束的任务 %s 被丢弃", job.ID)
+		}
+	}
+	if _, ok := m.get(finished[0].ID); ok {
+		t.Error("最早结束的任务应被丢弃")
+	}
+	if _, ok := m.get(finished[len(finished)-1].ID); !ok {
+		t.Error("最近结束的任务应保留")
+	}
+	if len(m.order) != maxRetainedJobs || len(m.jobs) != maxRetainedJobs {
+		t.Errorf("保留任务数 order=%d jobs=%d, want %d", len(m.order), len(m.jobs), maxRetainedJobs)
+	}
+}
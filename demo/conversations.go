@@ -0,0 +1,233 @@
+package demo
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Devoty/openai-backup/client"
+)
+
+// turn 是示例对话中的一轮问答。
+type turn struct {
+	question string
+	answer   string
+}
+
+// topic 是示例对话模板, 按需附加生成图片、代码执行、个性化设置或重新生成的回答; project 为所属项目的 ID, 见 projects。
+type topic struct {
+	title       string
+	turns       []turn
+	image       string
+	code        string
+	context     bool
+	regenerated string
+	project     string
+}
+
+// projects 是示例对话所属的 ChatGPT 项目, 以 gizmo_id 为键。
+var projects = map[string]string{
+	"g-p-demo-backend": "后端学习",
+}
+
+var topics = []topic{
+	{
+		title:   "Go 并发模式入门",
+		project: "g-p-demo-backend",
+		turns: []turn{{
+			question: "用 Go 写一个带超时的 worker pool 示例",
+			answer:   "可以用带缓冲的 channel 分发任务, 再用 `context.WithTimeout` 控制整体超时:\n\n```go\nfunc run(ctx context.Context, jobs []int) {\n\tctx, cancel := context.WithTimeout(ctx, 2*time.Second)\n\tdefer cancel()\n\tch := make(chan int)\n\tvar wg sync.WaitGroup\n\tfor i := 0; i < 4; i++ {\n\t\twg.Add(1)\n\t\tgo func() {\n\t\t\tdefer wg.Done()\n\t\t\tfor job := range ch {\n\t\t\t\tprocess(ctx, job)\n\t\t\t}\n\t\t}()\n\t}\n\tfor _, job := range jobs {\n\t\tch <- job\n\t}\n\tclose(ch)\n\twg.Wait()\n}\n```\n\n每个 worker 从同一个 channel 读取任务, 关闭 channel 后循环自然结束。",
+		}, {
+			question: "如果某个任务 panic 了怎么办?",
+			answer:   "在 worker 内部用 `defer recover()` 捕获, 记录错误后继续处理下一个任务, 避免整个进程退出。",
+		}},
+	},
+	{
+		title: "二次方程求根公式",
+		turns: []turn{{
+			question: "推导一下 $ax^2+bx+c=0$ 的求根公式",
+			answer:   "先两边除以 $a$ 并配方:\n\n$$\\left(x+\\frac{b}{2a}\\right)^2=\\frac{b^2-4ac}{4a^2}$$\n\n开方后得到:\n\n$$x=\\frac{-b\\pm\\sqrt{b^2-4ac}}{2a}$$\n\n判别式 $\\Delta=b^2-4ac$ 决定实根的个数。",
+		}},
+	},
+	{
+		title: "画一个部署流程图",
+		turns: []turn{{
+			question: "用 mermaid 画出从提交代码到上线的流程",
+			answer:   "```mermaid\ngraph LR\n  A[提交代码] --> B[CI 构建]\n  B --> C{测试通过?}\n  C -- 是 --> D[部署预发]\n  C -- 否 --> A\n  D --> E[灰度发布]\n  E --> F[全量上线]\n```\n\n预发与灰度阶段都可以随时回滚。",
+		}},
+	},
+	{
+		title: "周末露营装备清单",
+		turns: []turn{{
+			question: "两个人周末去山里露营, 需要带什么?",
+			answer:   "基础装备:\n\n- 帐篷、防潮垫、睡袋 (按夜间最低温选择)\n- 头灯与备用电池\n- 炉头、气罐、套锅\n- 急救包与驱虫液\n\n食物按每人每天 2500 千卡准备, 另带 2 升饮用水。",
+		}},
+	},
+	{
+		title:   "SQL 窗口函数示例",
+		project: "g-p-demo-backend",
+		turns: []turn{{
+			question: "怎么查询每个部门薪资最高的前三名员工?",
+			answer:   "使用 `ROW_NUMBER()` 按部门分组排序:\n\n```sql\nSELECT *\nFROM (\n  SELECT name, dept, salary,\n         ROW_NUMBER() OVER (PARTITION BY dept ORDER BY salary DESC) AS rn\n  FROM employees\n) t\nWHERE rn <= 3;\n```\n\n如果需要并列名次, 把 `ROW_NUMBER` 换成 `DENSE_RANK`。",
+		}},
+	},
+	{
+		title: "生成一张水彩风格的猫",
+		turns: []turn{{
+			question: "画一只在窗边读书的猫, 水彩风格",
+			answer:   "已生成图片, 需要调整色调或构图可以继续告诉我。",
+		}},
+		image: "A watercolor cat reading a book by the window",
+	},
+	{
+		title: "Polish an English email",
+		turns: []turn{{
+			question: "Please polish: \"Hi team, I want to inform the meeting is delay to next week because many people is on vacation.\"",
+			answer:   "Hi team,\n\nI'd like to let you know that the meeting has been postponed to next week, as many people are on vacation.\n\nThanks for your understanding.",
+		}},
+	},
+	{
+		title: "数据分析: 月度销售趋势",
+		turns: []turn{{
+			question: "帮我看看这份销售数据的月度趋势",
+			answer:   "按月汇总后, 3 月到 6 月销售额持续增长, 7 月回落约 12%, 主要来自线下渠道下滑。",
+		}},
+		code: "import pandas as pd\ndf = pd.read_csv('sales.csv', parse_dates=['date'])\ndf.groupby(df['date'].dt.to_period('M'))['amount'].sum()",
+	},
+	{
+		title: "京都三日游行程",
+		turns: []turn{{
+			question: "帮我规划京都三天的行程, 喜欢寺庙和美食",
+			answer:   "第一天: 清水寺 → 二年坂三年坂 → 祇园晚餐。\n\n第二天: 伏见稻荷大社 (早上人少) → 锦市场午餐 → 河原町。\n\n第三天: 岚山竹林 → 天龙寺 → 嵯峨野小火车。",
+		}, {
+			question: "第二天下午下雨的话怎么调整?",
+			answer:   "可以改去京都国立博物馆或三十三间堂, 都是室内参观, 附近也有不少甜品店。",
+		}, {
+			question: "推荐几家锦市场的小吃",
+			answer:   "玉子烧、豆乳甜甜圈、章鱼小丸子和现烤海鲜串都很受欢迎, 建议中午前到达。",
+		}},
+	},
+	{
+		title: "解释 TCP 三次握手",
+		turns: []turn{{
+			question: "TCP 为什么需要三次握手?",
+			answer:   "```mermaid\nsequenceDiagram\n  客户端->>服务端: SYN seq=x\n  服务端->>客户端: SYN+ACK seq=y ack=x+1\n  客户端->>服务端: ACK ack=y+1\n```\n\n三次握手让双方都确认了自己与对方的收发能力, 同时交换初始序列号, 并防止历史连接请求被误当作新连接。",
+		}},
+	},
+	{
+		title: "给新产品起名字",
+		turns: []turn{{
+			question: "一款帮助备份聊天记录的工具, 起几个名字",
+			answer:   "1. 留声 (Echo Keeper)\n2. 拾语\n3. ChatVault\n4. 回音匣",
+		}},
+		regenerated: "可以考虑: 存言、对话档案馆、TalkSafe、记语。",
+	},
+	{
+		title: "带个性化设置的对话",
+		turns: []turn{{
+			question: "推荐一本入门的分布式系统书",
+			answer:   "推荐《数据密集型应用系统设计》(DDIA), 覆盖复制、分区、事务与一致性, 适合有后端经验的读者。",
+		}},
+		context: true,
+	},
+}
+
+// Conversations 生成 count 个示例对话, 超出模板数量时循环使用并在标题后追加序号。
+func Conversations(count int) []client.Conversation {
+	convs := make([]client.Conversation, 0, count)
+	for i := 0; i < count; i++ {
+		t := topics[i%len(topics)]
+		title := t.title
+		if round := i / len(topics); round > 0 {
+			title = fmt.Sprintf("%s (%d)", title, round+1)
+		}
+		created := baseTime.Add(time.Duration(i) * 9 * time.Hour)
+		convs = append(convs, buildConversation(fmt.Sprintf("demo-%04d", i), title, t, created))
+	}
+	return convs
+}
+
+// treeBuilder 依次追加节点, 维护父子关系。
+type treeBuilder struct {
+	mapping map[string]client.Node
+	last    string
+	clock   time.Time
+}
+
+func (b *treeBuilder) add(id, role, name, recipient string, content client.Content, metadata interface{}) {
+	b.addUnder(b.last, id, role, name, recipient, content, metadata)
+	b.last = id
+}
+
+func (b *treeBuilder) addUnder(parent, id, role, name, recipient string, content client.Content, metadata interface{}) {
+	b.clock = b.clock.Add(20 * time.Second)
+	meta, _ := json.Marshal(metadata)
+	if metadata == nil {
+		meta = []byte("{}")
+	}
+	b.mapping[id] = client.Node{
+		ID:     id,
+		Parent: parent,
+		Message: &client.Message{
+			ID:         id,
+			Author:     client.Author{Role: role, Name: name},
+			CreateTime: client.FlexFloat64(float64(b.clock.Unix())),
+			Content:    content,
+			Metadata:   meta,
+			Recipient:  recipient,
+		},
+		Metadata: json.RawMessage("{}"),
+	}
+	node := b.mapping[parent]
+	node.Children = append(node.Children, id)
+	b.mapping[parent] = node
+}
+
+func textContent(text string) client.Content {
+	part, _ := json.Marshal(text)
+	return client.Content{ContentType: "text", Parts: []json.RawMessage{part}}
+}
+
+func buildConversation(id, title string, t topic, created time.Time) client.Conversation {
+	b := &treeBuilder{
+		mapping: map[string]client.Node{"root": {ID: "root", Metadata: json.RawMessage("{}")}},
+		last:    "root",
+		clock:   created,
+	}
+	if t.context {
+		b.add("ctx", "user", "", "", client.Content{
+			ContentType:      "user_editable_context",
+			UserProfile:      "我是一名后端工程师, 主要使用 Go。",
+			UserInstructions: "回答尽量简洁, 给出可操作的建议。",
+		}, map[string]bool{"is_visually_hidden_from_conversation": true, "is_user_system_message": true})
+	}
+	for idx, item := range t.turns {
+		b.add(fmt.Sprintf("u%d", idx), "user", "", "", textContent(item.question), nil)
+		if idx == 0 && t.code != "" {
+			b.add("code", "assistant", "", "python", client.Content{ContentType: "code", Text: t.code}, map[string]string{"language": "python"})
+		}
+		if idx == len(t.turns)-1 && t.regenerated != "" {
+			// 模拟重新生成: 同一问题下挂两个回答分支。
+			b.addUnder(b.last, fmt.Sprintf("a%d-old", idx), "assistant", "", "", textContent(t.regenerated), nil)
+		}
+		b.add(fmt.Sprintf("a%d", idx), "assistant", "", "", textContent(item.answer), nil)
+	}
+	if t.image != "" {
+		pointer, _ := json.Marshal(map[string]interface{}{
+			"content_type":  "image_asset_pointer",
+			"asset_pointer": "file-service://file-" + id,
+			"width":         96,
+			"height":        96,
+			"metadata":      map[string]interface{}{"dalle": map[string]string{"prompt": t.image, "gen_id": id}},
+		})
+		b.add("img", "tool", "dalle.text2im", "", client.Content{ContentType: "multimodal_text", Parts: []json.RawMessage{pointer}}, nil)
+	}
+	return client.Conversation{
+		ID:         id,
+		Title:      title,
+		CreateTime: client.FlexFloat64(float64(created.Unix())),
+		UpdateTime: client.FlexFloat64(float64(b.clock.Unix())),
+		Mapping:    b.mapping,
+		GizmoID:    t.project,
+	}
+}
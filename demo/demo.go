@@ -0,0 +1,202 @@
+// Package demo 提供模拟 ChatGPT backend-api 的 HTTP 服务, 返回固定生成的示例对话,
+// 用于演示模式 (--demo) 以及不依赖真实 Token 的端到端测试。
+package demo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Devoty/openai-backup/client"
+	"github.com/Devoty/openai-backup/logging"
+)
+
+const (
+	// BasePath 是模拟接口的路径前缀, 与 ChatGPT 网页端一致。
+	BasePath = "/backend-api"
+	// Token 是演示模式使用的占位 Token, 服务端不做校验。
+	Token = "demo-token"
+	// DefaultCount 是默认生成的对话数量。
+	DefaultCount = 36
+
+	blobPath = "/demo-files/"
+)
+
+// baseTime 固定示例数据的起始时间, 保证每次生成的内容一致。
+var baseTime = time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+// Server 在内存中保存示例对话, 支持列表、详情、删除 (隐藏)、项目信息与文件下载。
+type Server struct {
+	mu            sync.Mutex
+	conversations []client.Conversation
+	hidden        map[string]bool
+}
+
+// New 生成 count 个示例对话, count <= 0 时使用 DefaultCount。
+func New(count int) *Server {
+	if count <= 0 {
+		count = DefaultCount
+	}
+	return &Server{conversations: Conversations(count), hidden: make(map[string]bool)}
+}
+
+// Start 在 127.0.0.1 的随机端口启动模拟服务, 返回可直接用作 base-url 的地址与关闭函数。
+func Start(count int) (string, func(context.Context) error, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, fmt.Errorf("启动演示服务失败: %w", err)
+	}
+	server := &http.Server{Handler: New(count), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logging.Infof("演示服务异常退出: %v", err)
+		}
+	}()
+	return "http://" + listener.Addr().String() + BasePath, server.Shutdown, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	switch {
+	case path == BasePath+"/conversations" && r.Method == http.MethodGet:
+		s.handleList(w, r)
+	case strings.HasPrefix(path, BasePath+"/conversation/"):
+		id := strings.TrimPrefix(path, BasePath+"/conversation/")
+		switch r.Method {
+		case http.MethodGet:
+			s.handleDetail(w, id)
+		case http.MethodPatch:
+			s.handleHide(w, id)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	case strings.HasPrefix(path, BasePath+"/gizmos/") && r.Method == http.MethodGet:
+		s.handleGizmo(w, strings.TrimPrefix(path, BasePath+"/gizmos/"))
+	case strings.HasPrefix(path, BasePath+"/files/") && strings.HasSuffix(path, "/download"):
+		fileID := strings.TrimSuffix(strings.TrimPrefix(path, BasePath+"/files/"), "/download")
+		writeJSON(w, http.StatusOK, map[string]string{
+			"status":       "success",
+			"download_url": "http://" + r.Host + blobPath + fileID,
+		})
+	case strings.HasPrefix(path, blobPath):
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(demoImage(strings.TrimPrefix(path, blobPath)))
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"detail": "Not Found"})
+	}
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	offset, _ := strconv.Atoi(query.Get("offset"))
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = 28
+	}
+
+	s.mu.Lock()
+	items := make([]client.ConversationMeta, 0, len(s.conversations))
+	for _, conv := range s.conversations {
+		if s.hidden[conv.ID] {
+			continue
+		}
+		items = append(items, client.ConversationMeta{ID: conv.ID, Title: conv.Title, CreateTime: conv.CreateTime, UpdateTime: conv.UpdateTime, GizmoID: conv.GizmoID})
+	}
+	s.mu.Unlock()
+
+	if query.Get("order") == "created" {
+		sort.SliceStable(items, func(i, j int) bool { return items[i].CreateTime > items[j].CreateTime })
+	} else {
+		sort.SliceStable(items, func(i, j int) bool { return items[i].UpdateTime > items[j].UpdateTime })
+	}
+	total := len(items)
+	end := offset + limit
+	if offset > total {
+		offset = total
+	}
+	if end > total {
+		end = total
+	}
+	writeJSON(w, http.StatusOK, client.ConversationPage{
+		Items:   items[offset:end],
+		Total:   total,
+		Limit:   limit,
+		Offset:  offset,
+		HasMore: end < total,
+	})
+}
+
+func (s *Server) handleDetail(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conv := range s.conversations {
+		if conv.ID == id && !s.hidden[id] {
+			writeJSON(w, http.StatusOK, conv)
+			return
+		}
+	}
+	writeJSON(w, http.StatusNotFound, map[string]string{"detail": "Can't load conversation " + id})
+}
+
+// handleGizmo 返回示例项目的信息, 只包含显示名称。
+func (s *Server) handleGizmo(w http.ResponseWriter, id string) {
+	name, ok := projects[id]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"detail": "Gizmo not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"gizmo": map[string]interface{}{"id": id, "display": map[string]string{"name": name}},
+	})
+}
+
+func (s *Server) handleHide(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conv := range s.conversations {
+		if conv.ID == id {
+			s.hidden[id] = true
+			writeJSON(w, http.StatusOK, map[string]bool{"success": true})
+			return
+		}
+	}
+	writeJSON(w, http.StatusNotFound, map[string]string{"detail": "Can't load conversation " + id})
+}
+
+func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(payload)
+}
+
+// demoImage 按文件 ID 生成一张渐变色 PNG, 代替真实的生成图片。
+func demoImage(fileID string) []byte {
+	var seed uint8
+	for _, b := range []byte(fileID) {
+		seed += b
+	}
+	img := image.NewRGBA(image.Rect(0, 0, 96, 96))
+	for y := 0; y < 96; y++ {
+		for x := 0; x < 96; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x*2) + seed, G: uint8(y * 2), B: 180 - seed/2, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
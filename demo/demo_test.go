@@ -0,0 +1,150 @@
+package demo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image/png"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Devoty/openai-backup/client"
+)
+
+func TestConversations(t *testing.T) {
+	tests := []struct {
+		count     int
+		wantTitle string
+	}{
+		{count: 1, wantTitle: topics[0].title},
+		{count: len(topics) + 1, wantTitle: topics[0].title + " (2)"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.count), func(t *testing.T) {
+			convs := Conversations(tt.count)
+			if len(convs) != tt.count {
+				t.Fatalf("生成 %d 个对话, want %d", len(convs), tt.count)
+			}
+			if last := convs[len(convs)-1]; last.Title != tt.wantTitle {
+				t.Errorf("最后一个对话标题 = %q, want %q", last.Title, tt.wantTitle)
+			}
+			seen := make(map[string]bool)
+			for _, conv := range convs {
+				if seen[conv.ID] {
+					t.Errorf("对话 ID 重复: %s", conv.ID)
+				}
+				seen[conv.ID] = true
+				if conv.UpdateTime <= conv.CreateTime {
+					t.Errorf("%s: update_time %v 不晚于 create_time %v", conv.ID, conv.UpdateTime, conv.CreateTime)
+				}
+				for id, node := range conv.Mapping {
+					for _, child := range node.Children {
+						if conv.Mapping[child].Parent != id {
+							t.Errorf("%s: 节点 %s 的父节点 = %q, want %q", conv.ID, child, conv.Mapping[child].Parent, id)
+						}
+					}
+					if id != "root" && node.Message == nil {
+						t.Errorf("%s: 节点 %s 没有消息", conv.ID, id)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestServer(t *testing.T) {
+	const count = 5
+	baseURL, shutdown, err := Start(count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { shutdown(context.Background()) })
+	c := client.New(client.Options{BaseURL: baseURL, Token: Token})
+	ctx := context.Background()
+
+	items, err := c.FetchAll(ctx, client.ListOptions{Limit: 2, Order: "updated"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != count {
+		t.Fatalf("列表 %d 项, want %d", len(items), count)
+	}
+	for i := 1; i < len(items); i++ {
+		if items[i].UpdateTime > items[i-1].UpdateTime {
+			t.Errorf("列表未按更新时间倒序: %s 在 %s 之后", items[i].ID, items[i-1].ID)
+		}
+	}
+
+	tests := []struct {
+		name string
+		run  func() error
+	}{
+		{
+			name: "读取详情",
+			run: func() error {
+				conv, err := c.Conversation(ctx, items[0].ID)
+				if err == nil && (conv.Title != items[0].Title || len(conv.Mapping) == 0 || conv.Drift != nil) {
+					err = fmt.Errorf("详情 = %+v", conv)
+				}
+				return err
+			},
+		},
+		{
+			name: "项目名称",
+			run: func() error {
+				name, err := c.ProjectName(ctx, "g-p-demo-backend")
+				if err == nil && name != projects["g-p-demo-backend"] {
+					err = fmt.Errorf("项目名称 = %q", name)
+				}
+				return err
+			},
+		},
+		{
+			name: "下载示例图片",
+			run: func() error {
+				data, err := c.DownloadFile(ctx, "file-service://file-demo-0000")
+				if err != nil {
+					return err
+				}
+				_, err = png.Decode(bytes.NewReader(data))
+				return err
+			},
+		},
+		{
+			name: "删除后不再出现在列表与详情中",
+			run: func() error {
+				if err := c.DeleteConversation(ctx, items[0].ID); err != nil {
+					return err
+				}
+				var statusErr *client.StatusError
+				if _, err := c.Conversation(ctx, items[0].ID); !errors.As(err, &statusErr) || statusErr.Status != http.StatusNotFound {
+					return fmt.Errorf("删除后读取详情 err = %v, want 404", err)
+				}
+				page, err := c.ListConversations(ctx, client.ListOptions{Limit: 10, Order: "created"})
+				if err == nil && (len(page.Items) != count-1 || page.Total != count-1) {
+					err = fmt.Errorf("删除后列表 %d 项 (total %d), want %d", len(page.Items), page.Total, count-1)
+				}
+				return err
+			},
+		},
+		{
+			name: "未知接口",
+			run: func() error {
+				var statusErr *client.StatusError
+				if _, err := c.ProjectName(ctx, "g-p-missing"); !errors.As(err, &statusErr) || !strings.Contains(statusErr.Body, "not found") {
+					return fmt.Errorf("err = %v, want 404", err)
+				}
+				return nil
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.run(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
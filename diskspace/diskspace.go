@@ -0,0 +1,38 @@
+// Package diskspace 在写入较大的输出前检查磁盘剩余空间, 避免写到一半才因磁盘写满而失败。
+// 主程序的压缩包、数据库备份、任务报告与导入的媒体文件, 以及写入本地目录的导出目标共用。
+package diskspace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Devoty/openai-backup/export"
+)
+
+// ErrInsufficient 表示写入前检查发现磁盘剩余空间不足以容纳预计的输出。
+var ErrInsufficient = errors.New("磁盘空间不足")
+
+// Margin 是检查磁盘空间时额外保留的余量, 避免把磁盘写满后数据库等其他写入失败。
+const Margin = 64 << 20
+
+// Check 检查 dir 所在文件系统能否再写入 need 字节 (另加 Margin), 不足时返回 ErrInsufficient。
+// dir 尚未创建时检查最近的上级目录; 无法读取可用空间时不检查。
+func Check(dir string, need int64) error {
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil
+		}
+		dir = parent
+	}
+	available, ok := free(dir)
+	if !ok || available >= need+Margin {
+		return nil
+	}
+	return fmt.Errorf("%w: %s 所在磁盘可用 %s, 预计需要 %s", ErrInsufficient, dir, export.FormatByteSize(available), export.FormatByteSize(need+Margin))
+}
@@ -0,0 +1,33 @@
+package diskspace
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	if _, ok := free(dir); !ok {
+		t.Skip("当前平台不读取可用空间")
+	}
+	tests := []struct {
+		name string
+		dir  string
+		need int64
+		want error
+	}{
+		{name: "空间充足", dir: dir, need: 1 << 10, want: nil},
+		{name: "目录尚未创建时检查上级目录", dir: filepath.Join(dir, "a", "b"), need: 1 << 10, want: nil},
+		{name: "空间不足", dir: dir, need: 1 << 62, want: ErrInsufficient},
+		{name: "目录尚未创建且空间不足", dir: filepath.Join(dir, "missing"), need: 1 << 62, want: ErrInsufficient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Check(tt.dir, tt.need)
+			if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Check() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
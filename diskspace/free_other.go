@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+
+package diskspace
+
+// free 在其他平台上不读取可用空间, 写入前不做检查。
+func free(path string) (int64, bool) {
+	return 0, false
+}
@@ -0,0 +1,19 @@
+//go:build linux || darwin
+
+package diskspace
+
+import (
+	"syscall"
+
+	"github.com/Devoty/openai-backup/logging"
+)
+
+// free 返回 path 所在文件系统中非特权用户可用的字节数。
+func free(path string) (int64, bool) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		logging.Infof("读取磁盘可用空间失败: path=%s err=%v", path, err)
+		return 0, false
+	}
+	return int64(stat.Bavail) * int64(stat.Bsize), true
+}
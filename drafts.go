@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// notionDraftsSchema 记录创建在 Notion 草稿父级下、尚未移动到最终父级的页面。
+const notionDraftsSchema = `
+	CREATE TABLE IF NOT EXISTS notion_drafts (
+		conversation_id TEXT PRIMARY KEY,
+		page_id TEXT NOT NULL,
+		url TEXT NOT NULL DEFAULT '',
+		title TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL
+	);`
+
+// notionDraft 是一条待审阅的 Notion 草稿页面。
+type notionDraft struct {
+	ConversationID string    `json:"conversation_id"`
+	PageID         string    `json:"page_id"`
+	URL            string    `json:"url"`
+	Title          string    `json:"title"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type notionPromoteRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// notionPromoteFailure 是移动失败的草稿及原因。
+type notionPromoteFailure struct {
+	ConversationID string `json:"conversation_id"`
+	Error          string `json:"error"`
+}
+
+// RecordNotionDraft 写入或覆盖对话的草稿页面, 重新导出时以新页面为准。
+func (s *ConfigStore) RecordNotionDraft(ctx context.Context, draft notionDraft) error {
+	if s == nil || s.archive == nil {
+		return errors.New("配置存储未初始化")
+	}
+	_, err := s.archive.writer.ExecContext(ctx, `
+		INSERT INTO notion_drafts(conversation_id, page_id, url, title, created_at)
+		VALUES(?, ?, ?, ?, ?)
+		ON CONFLICT(conversation_id) DO UPDATE SET
+			page_id=excluded.page_id,
+			url=excluded.url,
+			title=excluded.title,
+			created_at=excluded.created_at
+	`, draft.ConversationID, draft.PageID, draft.URL, draft.Title, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("写入 Notion 草稿记录失败: %w", err)
+	}
+	return nil
+}
+
+// ListNotionDrafts 按创建时间倒序列出待审阅的草稿页面。
+func (s *ConfigStore) ListNotionDrafts(ctx context.Context) ([]notionDraft, error) {
+	if s == nil || s.archive == nil {
+		return nil, errors.New("配置存储未初始化")
+	}
+	rows, err := s.archive.reader.QueryContext(ctx, `
+		SELECT conversation_id, page_id, url, title, created_at FROM notion_drafts ORDER BY created_at DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("读取 Notion 草稿记录失败: %w", err)
+	}
+	defer rows.Close()
+	var items []notionDraft
+	for rows.Next() {
+		var item notionDraft
+		if err := rows.Scan(&item.ConversationID, &item.PageID, &item.URL, &item.Title, &item.CreatedAt); err != nil {
+			return nil, fmt.Errorf("解析 Notion 草稿记录失败: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取 Notion 草稿记录失败: %w", err)
+	}
+	return items, nil
+}
+
+// DeleteNotionDraft 删除对话的草稿记录。
+func (s *ConfigStore) DeleteNotionDraft(ctx context.Context, conversationID string) error {
+	if s == nil || s.archive == nil {
+		return errors.New("配置存储未初始化")
+	}
+	if _, err := s.archive.writer.ExecContext(ctx, `DELETE FROM notion_drafts WHERE conversation_id = ?`, conversationID); err != nil {
+		return fmt.Errorf("删除 Notion 草稿记录失败: %w", err)
+	}
+	return nil
+}
+
+// notionDraftsEnabled 判断导出到 Notion 的页面是否先写入草稿父级。
+func (s *webServer) notionDraftsEnabled() bool {
+	client, err := s.resolveNotionClient()
+	return err == nil && client.Drafts()
+}
+
+// handleNotionDrafts 处理 GET /api/notion/drafts: 列出待审阅的草稿页面。
+func (s *webServer) handleNotionDrafts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+		return
+	}
+	items, err := s.store.ListNotionDrafts(r.Context())
+	if err != nil {
+		writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "读取 Notion 草稿记录失败", err)
+		return
+	}
+	if items == nil {
+		items = []notionDraft{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"items":   items,
+		"count":   len(items),
+		"enabled": s.notionDraftsEnabled(),
+	})
+}
+
+// handleNotionPromote 处理 POST /api/notion/promote: 把审阅过的草稿页面移动到最终父级,
+// 成功后更新导出状态中的页面链接并删除草稿记录。
+func (s *webServer) handleNotionPromote(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+		return
+	}
+	var req notionPromoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorDetail(w, http.StatusBadRequest, errCodeInvalidRequest, "请求体解析失败", err)
+		return
+	}
+	if len(req.IDs) == 0 {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "请选择至少一个草稿")
+		return
+	}
+	exporter, err := s.resolveNotionClient()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errCodeTargetMisconfigured, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	drafts, err := s.store.ListNotionDrafts(ctx)
+	if err != nil {
+		writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "读取 Notion 草稿记录失败", err)
+		return
+	}
+	byID := make(map[string]notionDraft, len(drafts))
+	for _, draft := range drafts {
+		byID[draft.ConversationID] = draft
+	}
+
+	promoted := []exportResult{}
+	failed := []notionPromoteFailure{}
+	for _, id := range req.IDs {
+		id = strings.TrimSpace(id)
+		draft, ok := byID[id]
+		if !ok {
+			failed = append(failed, notionPromoteFailure{ConversationID: id, Error: "没有该对话的草稿记录"})
+			continue
+		}
+		started := time.Now()
+		object, err := exporter.Promote(ctx, draft.PageID)
+		if err != nil {
+			logInfo("移动 Notion 草稿失败: conversation=%s page=%s err=%v", id, draft.PageID, err)
+			failed = append(failed, notionPromoteFailure{ConversationID: id, Error: err.Error()})
+			continue
+		}
+		if err := s.store.DeleteNotionDraft(ctx, id); err != nil {
+			logInfo("删除 Notion 草稿记录失败: conversation=%s err=%v", id, err)
+		}
+		state := exportState{ConversationID: id, Target: exportTargetNotion, ObjectID: object.ID, URL: object.URL}
+		if err := s.store.RecordExportState(ctx, state); err != nil {
+			logInfo("记录导出状态失败: conversation=%s err=%v", id, err)
+		}
+		promoted = append(promoted, exportResult{ConversationID: id, Title: draft.Title, ObjectID: object.ID, URL: object.URL, Duration: time.Since(started)})
+	}
+	logInfo("Notion 草稿移动到最终父级: 选中=%d 成功=%d 失败=%d", len(req.IDs), len(promoted), len(failed))
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"promoted": promoted,
+		"failed":   failed,
+	})
+}
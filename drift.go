@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Devoty/openai-backup/atomicfile"
+	"github.com/Devoty/openai-backup/client"
+)
+
+// quarantineDirName 是保存结构变化时原始响应的目录, 与 reports 同在配置数据库所在目录下。
+const quarantineDirName = "quarantine"
+
+// schemaDrift 是一次接口结构变化的记录: 对话详情严格解析失败, 改用宽松模式解析后继续导出。
+type schemaDrift struct {
+	ConversationID string    `json:"id"`
+	Title          string    `json:"title"`
+	Error          string    `json:"error"`
+	Dropped        []string  `json:"dropped,omitempty"`
+	Unknown        []string  `json:"unknown,omitempty"`
+	Payload        string    `json:"payload,omitempty"`
+	CapturedAt     time.Time `json:"captured_at"`
+}
+
+// captureSchemaDrift 把原始响应写入隔离目录并暂存记录, 由导出任务取出写入报告。
+// 原始响应可能包含对话内容, 只保存在本地, 反馈问题前请自行检查。
+func (s *webServer) captureSchemaDrift(id string, detail *client.Conversation) {
+	drift := schemaDrift{
+		ConversationID: firstNonEmpty(detail.ID, id),
+		Title:          detail.Title,
+		Error:          detail.Drift.Err,
+		Dropped:        detail.Drift.Dropped,
+		Unknown:        detail.Drift.Unknown,
+		CapturedAt:     time.Now(),
+	}
+	path, err := s.writeQuarantine(drift.ConversationID, drift.CapturedAt, detail.Drift.Raw)
+	if err != nil {
+		logInfo("保存对话 %s 的原始响应失败: %v", drift.ConversationID, err)
+	} else {
+		drift.Payload = path
+	}
+	logInfo("对话 %s 的详情结构与已知格式不一致, 已按宽松模式解析: error=%s 忽略字段=%s 新增字段=%s 原始响应=%s",
+		drift.ConversationID, drift.Error, joinOrDash(drift.Dropped), joinOrDash(drift.Unknown), firstNonEmpty(drift.Payload, "-"))
+
+	s.conflictMu.Lock()
+	if s.schemaDrifts == nil {
+		s.schemaDrifts = make(map[string]schemaDrift)
+	}
+	s.schemaDrifts[drift.ConversationID] = drift
+	s.conflictMu.Unlock()
+}
+
+// writeQuarantine 保存原始响应, 文件名为 <对话 ID>-<时间>.json, 返回文件路径。
+func (s *webServer) writeQuarantine(id string, at time.Time, raw []byte) (string, error) {
+	dir := filepath.Join(filepath.Dir(s.configSnapshot().ConfigDBPath), quarantineDirName)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("创建隔离目录失败: %w", err)
+	}
+	name := fmt.Sprintf("%s-%s.json", quarantineName(id), at.Format("20060102-150405"))
+	path := filepath.Join(dir, name)
+	if err := atomicfile.WriteFile(s.store, path, raw, 0o600); err != nil {
+		return "", fmt.Errorf("写入原始响应失败: %w", err)
+	}
+	return path, nil
+}
+
+// takeSchemaDrift 取出并清除对话最近一次的结构变化记录。
+func (s *webServer) takeSchemaDrift(id string) (schemaDrift, bool) {
+	s.conflictMu.Lock()
+	defer s.conflictMu.Unlock()
+	drift, ok := s.schemaDrifts[id]
+	delete(s.schemaDrifts, id)
+	return drift, ok
+}
+
+func (j *exportJob) recordSchemaDrift(drift schemaDrift) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.SchemaDrifts = append(j.SchemaDrifts, drift)
+}
+
+func (j *exportJob) schemaDrifts() []schemaDrift {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]schemaDrift(nil), j.SchemaDrifts...)
+}
+
+func renderSchemaDriftsMarkdown(items []schemaDrift) string {
+	if len(items) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("\n## 接口结构变化 (%d)\n\n", len(items)))
+	b.WriteString("以下对话的详情与已知格式不一致, 已按宽松模式解析后导出, 被忽略的字段不会出现在导出内容中。反馈问题时请附上原始响应文件 (可能包含对话内容, 请先检查)。\n\n")
+	b.WriteString("| 对话 ID | 标题 | 解析错误 | 忽略字段 | 新增字段 | 原始响应 |\n")
+	b.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+	for _, item := range items {
+		b.WriteString(fmt.Sprintf("| `%s` | %s | %s | %s | %s | %s |\n",
+			item.ConversationID,
+			firstNonEmpty(escapeMarkdownTableCell(item.Title), "-"),
+			escapeMarkdownTableCell(item.Error),
+			escapeMarkdownTableCell(joinOrDash(item.Dropped)),
+			escapeMarkdownTableCell(joinOrDash(item.Unknown)),
+			firstNonEmpty(escapeMarkdownTableCell(item.Payload), "-"),
+		))
+	}
+	return b.String()
+}
+
+// quarantineName 只保留对话 ID 中可以安全用作文件名的字符。
+func quarantineName(id string) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+			return r
+		}
+		return '_'
+	}, id)
+	return firstNonEmpty(name, "conversation")
+}
+
+func joinOrDash(values []string) string {
+	if len(values) == 0 {
+		return "-"
+	}
+	return strings.Join(values, ", ")
+}
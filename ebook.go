@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Devoty/openai-backup/export"
+)
+
+// exportEPUB 把选中的对话打包为一本 EPUB 电子书直接返回, 每个对话一章, 图片一并写入书中。
+// 由 /api/conversations/export 在 format 为 epub 时调用, 书的内容超过暂存阈值后写入临时目录。
+func (s *webServer) exportEPUB(w http.ResponseWriter, r *http.Request, req exportRequest, theme string) {
+	ctx := r.Context()
+	started := time.Now().UTC()
+	cfg := s.configSnapshot()
+	items := exportItemsFromIDs(req.IDs)
+	if len(items) == 0 {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "没有有效的对话可导出")
+		return
+	}
+
+	title := req.Title
+	buf := newSpillBuffer(spillThresholdBytes(cfg))
+	defer buf.Close()
+	var book *export.EPUBWriter
+	for _, item := range items {
+		conv, err := s.fetchExportConversation(ctx, item.ID)
+		if err != nil {
+			writeAPIError(w, chatgptError(fmt.Sprintf("获取对话 %s 详情失败", item.ID), err))
+			return
+		}
+		s.annotateConversationChanges(ctx, &conv, started)
+		s.recordConversationVersion(ctx, conv)
+		conv = s.conversationForTarget(titleFallbackZip, conv)
+		if book == nil {
+			// 只选了一个对话时用对话标题作书名。
+			if title == "" && len(items) == 1 {
+				title = conv.Title
+			}
+			if title == "" {
+				title = fmt.Sprintf("ChatGPT 对话 (%d 篇)", len(items))
+			}
+			book, err = export.NewEPUBWriter(buf, title, cfg.OutputTimezone, export.HTMLOptions{Theme: theme, CustomCSS: cfg.HTMLCustomCSS, MathMode: cfg.MathMode})
+			if err != nil {
+				writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "创建电子书失败", err)
+				return
+			}
+		}
+		s.bundleEPUBImages(ctx, book, &conv)
+		if err := book.AddChapter(conv); err != nil {
+			book.Close()
+			writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, fmt.Sprintf("写入对话 %s 失败", conv.ID), err)
+			return
+		}
+	}
+	if err := book.Close(); err != nil {
+		writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "生成电子书失败", err)
+		return
+	}
+
+	logInfo("Web 导出电子书: 选中=%d 章节=%d 大小=%d", len(req.IDs), book.Chapters(), buf.Size())
+
+	filename := fmt.Sprintf("conversations-%s.epub", time.Now().Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/epub+zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Length", strconv.FormatInt(buf.Size(), 10))
+	if _, err := buf.WriteTo(w); err != nil {
+		logInfo("写入电子书失败: %v", err)
+	}
+}
+
+// bundleEPUBImages 下载对话中的图片写入电子书并回填路径。语音与上传文件多数阅读器无法打开, 不写入书中;
+// 单个图片失败只记录日志, 章节中保留原始指针。
+func (s *webServer) bundleEPUBImages(ctx context.Context, book *export.EPUBWriter, conv *export.Conversation) {
+	// 复制消息列表, 避免修改详情缓存中的数据。
+	conv.Messages = append([]export.Message(nil), conv.Messages...)
+	for i := range conv.Messages {
+		msg := &conv.Messages[i]
+		if len(msg.Assets) == 0 {
+			continue
+		}
+		msg.Assets = append([]export.Asset(nil), msg.Assets...)
+		for j := range msg.Assets {
+			asset := &msg.Assets[j]
+			if asset.Kind != export.AssetImage || asset.Pointer == "" {
+				continue
+			}
+			archivePath := export.AssetArchivePath(*asset)
+			if !book.HasResource(archivePath) {
+				data, err := s.fetchAsset(ctx, asset.Pointer)
+				if err != nil {
+					logInfo("下载图片失败: conversation=%s pointer=%s err=%v", conv.ID, asset.Pointer, err)
+					continue
+				}
+				if err := book.AddResource(archivePath, data); err != nil {
+					logInfo("写入图片失败: %v", err)
+					continue
+				}
+			}
+			asset.Path = archivePath
+		}
+	}
+}
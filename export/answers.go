@@ -0,0 +1,69 @@
+package export
+
+import "strings"
+
+// 导出内容范围, 见 ApplyExportMode。
+const (
+	// ExportModeFull 导出完整的对话记录, 为默认方式。
+	ExportModeFull = "full"
+	// ExportModeAnswers 只保留助手的回答, 适合整理成参考文档。
+	ExportModeAnswers = "answers"
+	// ExportModeAnswersQuoted 只保留回答, 并在每个问题的第一条回答前引用一行问题。
+	ExportModeAnswersQuoted = "answers_quoted"
+)
+
+// maxQuestionQuoteRunes 是回答前引用问题的最大长度, 超出部分以省略号代替。
+const maxQuestionQuoteRunes = 120
+
+// NormalizeExportMode 规范化导出内容范围, 无法识别时返回 ExportModeFull。
+func NormalizeExportMode(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case ExportModeAnswers, "answer_only", "answers_only":
+		return ExportModeAnswers
+	case ExportModeAnswersQuoted, "quoted":
+		return ExportModeAnswersQuoted
+	default:
+		return ExportModeFull
+	}
+}
+
+// ApplyExportMode 按 mode 返回对话副本: 只保留回答时删除用户与其他角色的消息,
+// 已删除消息 (Removed) 同样只保留回答; 不修改传入的对话。
+func ApplyExportMode(conv Conversation, mode string) Conversation {
+	mode = NormalizeExportMode(mode)
+	if mode == ExportModeFull {
+		return conv
+	}
+	conv.Messages = answerMessages(conv.Messages, mode == ExportModeAnswersQuoted)
+	conv.Removed = answerMessages(conv.Removed, false)
+	return conv
+}
+
+func answerMessages(msgs []Message, quote bool) []Message {
+	var (
+		out      []Message
+		question string
+	)
+	for _, msg := range msgs {
+		switch strings.ToLower(msg.Role) {
+		case "user":
+			question = questionQuote(msg.Text)
+		case "assistant":
+			if quote && question != "" {
+				msg.Text = "> " + question + "\n\n" + msg.Text
+				question = ""
+			}
+			out = append(out, msg)
+		}
+	}
+	return out
+}
+
+// questionQuote 把问题压缩为一行: 去掉 Markdown 标记并合并空白, 过长时截断。
+func questionQuote(text string) string {
+	line := strings.Join(strings.Fields(stripMarkdownMarks(text)), " ")
+	if runes := []rune(line); len(runes) > maxQuestionQuoteRunes {
+		line = string(runes[:maxQuestionQuoteRunes]) + "…"
+	}
+	return line
+}
@@ -0,0 +1,89 @@
+package export
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeExportMode(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ExportModeFull},
+		{"unknown", ExportModeFull},
+		{" Answers_Only ", ExportModeAnswers},
+		{"quoted", ExportModeAnswersQuoted},
+	}
+	for _, tt := range tests {
+		if got := NormalizeExportMode(tt.value); got != tt.want {
+			t.Errorf("NormalizeExportMode(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestApplyExportMode(t *testing.T) {
+	conv := Conversation{
+		Messages: []Message{
+			{Role: "system", Text: "指令"},
+			{Role: "user", Text: "## 如何 *配置*\n  nginx?"},
+			{Role: "assistant", Text: "第一步"},
+			{Role: "tool", Text: "结果"},
+			{Role: "assistant", Text: "第二步"},
+			{Role: "user", Text: strings.Repeat("长", maxQuestionQuoteRunes+1)},
+			{Role: "Assistant", Text: "好的"},
+		},
+		Removed: []Message{{Role: "user", Text: "旧问题"}, {Role: "assistant", Text: "旧回答"}},
+	}
+	tests := []struct {
+		name        string
+		mode        string
+		want        []string
+		wantRemoved []string
+	}{
+		{
+			name:        "完整对话",
+			mode:        "",
+			want:        []string{"指令", "## 如何 *配置*\n  nginx?", "第一步", "结果", "第二步", strings.Repeat("长", maxQuestionQuoteRunes+1), "好的"},
+			wantRemoved: []string{"旧问题", "旧回答"},
+		},
+		{
+			name:        "只保留回答",
+			mode:        ExportModeAnswers,
+			want:        []string{"第一步", "第二步", "好的"},
+			wantRemoved: []string{"旧回答"},
+		},
+		{
+			name: "回答前引用问题",
+			mode: ExportModeAnswersQuoted,
+			want: []string{
+				"> 如何 配置 nginx?\n\n第一步",
+				"第二步",
+				"> " + strings.Repeat("长", maxQuestionQuoteRunes) + "…\n\n好的",
+			},
+			wantRemoved: []string{"旧回答"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ApplyExportMode(conv, tt.mode)
+			if texts := messageTexts(got.Messages); strings.Join(texts, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("Messages = %q, want %q", texts, tt.want)
+			}
+			if texts := messageTexts(got.Removed); strings.Join(texts, "|") != strings.Join(tt.wantRemoved, "|") {
+				t.Errorf("Removed = %q, want %q", texts, tt.wantRemoved)
+			}
+		})
+	}
+	if conv.Messages[2].Text != "第一步" || len(conv.Messages) != 7 {
+		t.Errorf("传入的对话被修改: %+v", conv.Messages)
+	}
+}
+
+func messageTexts(msgs []Message) []string {
+	var texts []string
+	for _, msg := range msgs {
+		texts = append(texts, msg.Text)
+	}
+	return texts
+}
@@ -0,0 +1,149 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	archiveIndexJSONName = "index.json"
+	archiveIndexCSVName  = "conversations.csv"
+)
+
+// IndexEntry 描述导出目录中的一个对话文件, 供 Dataview/脚本等下游工具建立视图。
+type IndexEntry struct {
+	ID           string   `json:"id"`
+	Title        string   `json:"title"`
+	CreatedAt    string   `json:"created_at"`
+	UpdatedAt    string   `json:"updated_at"`
+	MessageCount int      `json:"message_count"`
+	Tags         []string `json:"tags"`
+	Path         string   `json:"path"`
+}
+
+type archiveIndex struct {
+	GeneratedAt   string       `json:"generated_at"`
+	Count         int          `json:"count"`
+	Conversations []IndexEntry `json:"conversations"`
+}
+
+// NewIndexEntry 生成对话在索引中的条目, path 为对话文件在导出包中的相对路径。
+func NewIndexEntry(conv Conversation, path string) IndexEntry {
+	tags := conv.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return IndexEntry{
+		ID:           conv.ID,
+		Title:        firstNonEmpty(conv.Title, "(未命名对话)"),
+		CreatedAt:    formatIndexTime(conv.CreateTime),
+		UpdatedAt:    formatIndexTime(conv.UpdateTime),
+		MessageCount: len(conv.Messages),
+		Tags:         tags,
+		Path:         path,
+	}
+}
+
+// formatIndexTime 统一输出 RFC3339 (UTC), 方便下游按日期解析。
+func formatIndexTime(value float64) string {
+	if value <= 0 {
+		return ""
+	}
+	sec := int64(value)
+	nsec := int64((value - float64(sec)) * 1e9)
+	return time.Unix(sec, nsec).UTC().Format(time.RFC3339)
+}
+
+// MarshalIndex 输出 index.json 的内容。
+func MarshalIndex(entries []IndexEntry) ([]byte, error) {
+	index := archiveIndex{
+		GeneratedAt:   time.Now().UTC().Format(time.RFC3339),
+		Count:         len(entries),
+		Conversations: entries,
+	}
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("序列化索引失败: %w", err)
+	}
+	return data, nil
+}
+
+// ParseIndex 读取 MarshalIndex 输出的 index.json, 返回其中的对话条目。
+func ParseIndex(data []byte) ([]IndexEntry, error) {
+	var index archiveIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("解析索引失败: %w", err)
+	}
+	return index.Conversations, nil
+}
+
+// RenderIndexMarkdown 输出列出全部对话的 Markdown 表格, 标题链接到对话文件, 时间按 timezone 显示。
+func RenderIndexMarkdown(entries []IndexEntry, timezone string) string {
+	loc := ResolveLocation(timezone)
+	formatTime := func(value string) string {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return "-"
+		}
+		return parsed.In(loc).Format("2006-01-02 15:04")
+	}
+	cell := strings.NewReplacer("|", "\\|", "\n", " ", "[", "\\[", "]", "\\]")
+
+	var b strings.Builder
+	b.WriteString("# ChatGPT 对话索引\n\n")
+	b.WriteString(fmt.Sprintf("共 %d 个对话，更新于 %s。\n\n", len(entries), time.Now().In(loc).Format("2006-01-02 15:04")))
+	b.WriteString("| 标题 | 创建时间 | 最近更新 | 消息数 | 标签 |\n| --- | --- | --- | --- | --- |\n")
+	for _, entry := range entries {
+		b.WriteString(fmt.Sprintf("| [%s](<%s>) | %s | %s | %d | %s |\n",
+			cell.Replace(entry.Title), entry.Path, formatTime(entry.CreatedAt), formatTime(entry.UpdatedAt), entry.MessageCount, cell.Replace(strings.Join(entry.Tags, ", "))))
+	}
+	return b.String()
+}
+
+// WriteArchiveIndex 在压缩包根目录写入 index.json 与 conversations.csv。
+func WriteArchiveIndex(archive *zip.Writer, entries []IndexEntry) error {
+	data, err := MarshalIndex(entries)
+	if err != nil {
+		return err
+	}
+	writer, err := archive.Create(archiveIndexJSONName)
+	if err != nil {
+		return fmt.Errorf("创建 %s 失败: %w", archiveIndexJSONName, err)
+	}
+	if _, err := writer.Write(data); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", archiveIndexJSONName, err)
+	}
+
+	writer, err = archive.Create(archiveIndexCSVName)
+	if err != nil {
+		return fmt.Errorf("创建 %s 失败: %w", archiveIndexCSVName, err)
+	}
+	csvWriter := csv.NewWriter(writer)
+	if err := csvWriter.Write([]string{"id", "title", "created_at", "updated_at", "message_count", "tags", "path"}); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", archiveIndexCSVName, err)
+	}
+	for _, entry := range entries {
+		record := []string{
+			entry.ID,
+			entry.Title,
+			entry.CreatedAt,
+			entry.UpdatedAt,
+			strconv.Itoa(entry.MessageCount),
+			strings.Join(entry.Tags, ";"),
+			entry.Path,
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return fmt.Errorf("写入 %s 失败: %w", archiveIndexCSVName, err)
+		}
+	}
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", archiveIndexCSVName, err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/Devoty/openai-backup/client"
+)
+
+const (
+	AssetAudio = "audio"
+	AssetImage = "image"
+	// AssetFullText 是被截断消息的全文, 由导出压缩包写为文本文件, 见 TruncateMessages。
+	AssetFullText = "full_text"
+
+	assetsDirName = "assets"
+)
+
+// Asset 是消息中引用的文件资源 (语音、图片等), Path 为写入导出包后的相对路径。
+type Asset struct {
+	Kind    string `json:"kind"`
+	Pointer string `json:"pointer"`
+	Format  string `json:"format,omitempty"`
+	Prompt  string `json:"prompt,omitempty"`
+	Path    string `json:"path,omitempty"`
+}
+
+type contentPart struct {
+	ContentType       string `json:"content_type"`
+	Text              string `json:"text"`
+	AssetPointer      string `json:"asset_pointer"`
+	Format            string `json:"format"`
+	AudioAssetPointer *struct {
+		AssetPointer string `json:"asset_pointer"`
+		Format       string `json:"format"`
+	} `json:"audio_asset_pointer"`
+	Metadata *struct {
+		Dalle *struct {
+			Prompt string `json:"prompt"`
+		} `json:"dalle"`
+	} `json:"metadata"`
+}
+
+func decodeContentPart(raw json.RawMessage) (contentPart, bool) {
+	var part contentPart
+	if err := json.Unmarshal(raw, &part); err != nil {
+		return contentPart{}, false
+	}
+	return part, part.ContentType != ""
+}
+
+func hasAssetKind(assets []Asset, kind string) bool {
+	for _, asset := range assets {
+		if asset.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// isAssetPointerPart 判断 part 是否只是文件引用, 这类 part 不应作为文本输出。
+func isAssetPointerPart(part contentPart) bool {
+	return strings.HasSuffix(part.ContentType, "asset_pointer")
+}
+
+// parseMessageAssets 提取消息 parts 中的语音与图片文件引用, 图片附带 DALL·E 生成提示词。
+func parseMessageAssets(content client.Content) []Asset {
+	var assets []Asset
+	for _, raw := range content.Parts {
+		part, ok := decodeContentPart(raw)
+		if !ok {
+			continue
+		}
+		switch {
+		case part.ContentType == "audio_asset_pointer" && part.AssetPointer != "":
+			assets = append(assets, Asset{Kind: AssetAudio, Pointer: part.AssetPointer, Format: part.Format})
+		case part.AudioAssetPointer != nil && part.AudioAssetPointer.AssetPointer != "":
+			assets = append(assets, Asset{Kind: AssetAudio, Pointer: part.AudioAssetPointer.AssetPointer, Format: part.AudioAssetPointer.Format})
+		case part.ContentType == "image_asset_pointer" && part.AssetPointer != "":
+			asset := Asset{Kind: AssetImage, Pointer: part.AssetPointer, Format: "png"}
+			if part.Metadata != nil && part.Metadata.Dalle != nil {
+				asset.Prompt = strings.TrimSpace(part.Metadata.Dalle.Prompt)
+			}
+			assets = append(assets, asset)
+		}
+	}
+	return assets
+}
+
+// AssetArchivePath 返回文件写入导出包时的相对路径 (assets/<文件 ID>.<格式>)。
+func AssetArchivePath(asset Asset) string {
+	name := sanitizeFilenamePart(client.FileID(asset.Pointer))
+	if name == "" {
+		name = "asset"
+	}
+	if format := sanitizeFilenamePart(asset.Format); format != "" {
+		name += "." + format
+	}
+	return path.Join(assetsDirName, name)
+}
+
+// AssetKindLabel 返回文件类型的中文名称。
+func AssetKindLabel(kind string) string {
+	switch kind {
+	case AssetAudio:
+		return "语音"
+	case AssetImage:
+		return "图片"
+	case AssetFullText:
+		return "全文"
+	default:
+		return "附件"
+	}
+}
+
+// assetHref 返回文件链接: Path 为带协议的地址 (如上传到导出目标后的对象链接) 时原样使用并返回 true,
+// 否则按相对路径转义。
+func assetHref(p string) (string, bool) {
+	if u, err := url.Parse(p); err == nil && u.Scheme != "" {
+		return p, true
+	}
+	return (&url.URL{Path: p}).EscapedPath(), false
+}
+
+// renderAssetsMarkdown 列出消息附带的文件, 已下载或已上传的给出链接, 否则保留原始指针。
+func renderAssetsMarkdown(assets []Asset) string {
+	if len(assets) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for _, asset := range assets {
+		label := AssetKindLabel(asset.Kind)
+		link, absolute := assetHref(asset.Path)
+		switch {
+		case asset.Path != "" && asset.Kind == AssetImage && !absolute:
+			b.WriteString(fmt.Sprintf("- %s: ![%s](%s)\n", label, escapeMarkdownLinkText(firstNonEmpty(asset.Prompt, path.Base(asset.Path))), link))
+		case absolute:
+			b.WriteString(fmt.Sprintf("- %s: [%s](%s)\n", label, path.Base(AssetArchivePath(asset)), link))
+		case asset.Path != "":
+			b.WriteString(fmt.Sprintf("- %s: [%s](%s)\n", label, path.Base(asset.Path), link))
+		default:
+			b.WriteString(fmt.Sprintf("- %s: `%s`\n", label, asset.Pointer))
+		}
+		if asset.Prompt != "" {
+			b.WriteString(fmt.Sprintf("  - 提示词: %s\n", strings.ReplaceAll(asset.Prompt, "\n", " ")))
+		}
+	}
+	return b.String()
+}
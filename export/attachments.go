@@ -0,0 +1,149 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"path"
+	"strings"
+)
+
+const attachmentsDirName = "attachments"
+
+// Attachment 是用户在对话中上传的文件, Path 为下载到导出包后的相对路径。
+type Attachment struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Size     int64  `json:"size,omitempty"`
+	MimeType string `json:"mime_type,omitempty"`
+	Path     string `json:"path,omitempty"`
+}
+
+// parseMessageAttachments 读取消息 metadata.attachments 中的上传文件信息。
+func parseMessageAttachments(metadata json.RawMessage) []Attachment {
+	if len(metadata) == 0 {
+		return nil
+	}
+	var meta struct {
+		Attachments []struct {
+			ID       string  `json:"id"`
+			Name     string  `json:"name"`
+			Size     float64 `json:"size"`
+			MimeType string  `json:"mime_type"`
+		} `json:"attachments"`
+	}
+	if err := json.Unmarshal(metadata, &meta); err != nil {
+		return nil
+	}
+	attachments := make([]Attachment, 0, len(meta.Attachments))
+	for _, item := range meta.Attachments {
+		if strings.TrimSpace(item.ID) == "" {
+			continue
+		}
+		attachments = append(attachments, Attachment{
+			ID:       item.ID,
+			Name:     firstNonEmpty(strings.TrimSpace(item.Name), item.ID),
+			Size:     int64(item.Size),
+			MimeType: strings.TrimSpace(item.MimeType),
+		})
+	}
+	return attachments
+}
+
+// mergeAttachments 按文件 ID 去重, 同一文件在多条消息中引用时只记录一次。
+func mergeAttachments(existing, more []Attachment) []Attachment {
+	for _, item := range more {
+		duplicate := false
+		for _, prev := range existing {
+			if prev.ID == item.ID {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			existing = append(existing, item)
+		}
+	}
+	return existing
+}
+
+// AttachmentArchivePath 返回上传文件写入导出包时的相对路径 (attachments/<文件 ID>-<文件名>)。
+func AttachmentArchivePath(att Attachment) string {
+	name := sanitizeFilenamePart(att.Name)
+	id := sanitizeFilenamePart(att.ID)
+	if name == "" || name == id {
+		return path.Join(attachmentsDirName, firstNonEmpty(id, "attachment"))
+	}
+	return path.Join(attachmentsDirName, id+"-"+trimFilename(name, 120))
+}
+
+// AttachmentDetail 返回文件类型与大小的说明, 例如 "application/pdf, 1.2 MB"。
+func AttachmentDetail(att Attachment) string {
+	var parts []string
+	if att.MimeType != "" {
+		parts = append(parts, att.MimeType)
+	}
+	if att.Size > 0 {
+		parts = append(parts, FormatByteSize(att.Size))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// FormatByteSize 把字节数格式化为 B、KB、MB 或 GB, 保留一位小数。
+func FormatByteSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value := float64(size)
+	for _, suffix := range []string{"KB", "MB", "GB"} {
+		value /= unit
+		if value < unit || suffix == "GB" {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+	}
+	return fmt.Sprintf("%d B", size)
+}
+
+// renderAttachmentsMarkdown 输出文档头部的上传文件列表, 已下载的给出相对链接。
+func renderAttachmentsMarkdown(attachments []Attachment) string {
+	if len(attachments) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("- 上传文件:\n")
+	for _, att := range attachments {
+		name := att.Name
+		if att.Path != "" {
+			link, _ := assetHref(att.Path)
+			name = fmt.Sprintf("[%s](%s)", escapeMarkdownLinkText(att.Name), link)
+		}
+		if detail := AttachmentDetail(att); detail != "" {
+			b.WriteString(fmt.Sprintf("  - %s (%s)\n", name, detail))
+		} else {
+			b.WriteString(fmt.Sprintf("  - %s\n", name))
+		}
+	}
+	return b.String()
+}
+
+func renderAttachmentsHTML(attachments []Attachment) string {
+	if len(attachments) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("<li>上传文件:\n<ul>\n")
+	for _, att := range attachments {
+		name := html.EscapeString(att.Name)
+		if att.Path != "" {
+			name = fmt.Sprintf("<a href=\"%s\">%s</a>", html.EscapeString((&url.URL{Path: att.Path}).EscapedPath()), name)
+		}
+		if detail := AttachmentDetail(att); detail != "" {
+			name += " (" + html.EscapeString(detail) + ")"
+		}
+		b.WriteString("<li>" + name + "</li>\n")
+	}
+	b.WriteString("</ul>\n</li>\n")
+	return b.String()
+}
@@ -0,0 +1,91 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/Devoty/openai-backup/client"
+	"github.com/Devoty/openai-backup/demo"
+)
+
+// benchmarkDetails 返回演示模式的示例对话, 覆盖代码、公式、Mermaid、图片与代码执行等内容。
+func benchmarkDetails() []client.Conversation {
+	return demo.Conversations(demo.DefaultCount)
+}
+
+// longConversation 把示例对话的全部消息首尾相连, 模拟大任务中常见的长对话。
+func longConversation(details []client.Conversation) *client.Conversation {
+	long := &client.Conversation{
+		ID:      "bench-long",
+		Title:   "长对话",
+		Mapping: map[string]client.Node{"root": {ID: "root", Metadata: json.RawMessage("{}")}},
+	}
+	parent := "root"
+	for i, detail := range details {
+		for j, msg := range Build(client.ConversationMeta{}, &detail).Messages {
+			id := fmt.Sprintf("m%d-%d", i, j)
+			part, _ := json.Marshal(msg.Text)
+			long.Mapping[id] = client.Node{
+				ID:     id,
+				Parent: parent,
+				Message: &client.Message{
+					ID:         id,
+					Author:     client.Author{Role: msg.Role},
+					CreateTime: client.FlexFloat64(msg.CreateTime),
+					Content:    client.Content{ContentType: "text", Parts: []json.RawMessage{part}},
+					Metadata:   json.RawMessage("{}"),
+				},
+			}
+			node := long.Mapping[parent]
+			node.Children = append(node.Children, id)
+			long.Mapping[parent] = node
+			parent = id
+		}
+	}
+	return long
+}
+
+func BenchmarkBuild(b *testing.B) {
+	details := benchmarkDetails()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := range details {
+			Build(client.ConversationMeta{}, &details[j])
+		}
+	}
+}
+
+func BenchmarkBuildLong(b *testing.B) {
+	long := longConversation(benchmarkDetails())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Build(client.ConversationMeta{}, long)
+	}
+}
+
+func BenchmarkRenderMarkdown(b *testing.B) {
+	details := benchmarkDetails()
+	convs := make([]Conversation, 0, len(details))
+	for j := range details {
+		convs = append(convs, Build(client.ConversationMeta{}, &details[j]))
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, conv := range convs {
+			RenderMarkdown(conv, "UTC")
+		}
+	}
+}
+
+func BenchmarkRenderMarkdownLong(b *testing.B) {
+	conv := Build(client.ConversationMeta{}, longConversation(benchmarkDetails()))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		RenderMarkdown(conv, "UTC")
+	}
+}
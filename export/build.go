@@ -0,0 +1,274 @@
+package export
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Devoty/openai-backup/client"
+	"github.com/Devoty/openai-backup/logging"
+)
+
+var structuredTagPattern = regexp.MustCompile(".*?")
+
+// Build 将接口返回的对话详情规整为导出模型: 按时间排序消息, 过滤工具调用等内部消息,
+// 并提取上下文、引用、文件与上传附件信息。
+func Build(meta client.ConversationMeta, detail *client.Conversation) Conversation {
+	export := Conversation{
+		ID:         firstNonEmpty(detail.ID, meta.ID),
+		Title:      firstNonEmpty(detail.Title, meta.Title),
+		CreateTime: chooseTime(detail.CreateTime.Float64(), meta.CreateTime.Float64()),
+		UpdateTime: chooseTime(detail.UpdateTime.Float64(), meta.UpdateTime.Float64()),
+		Raw:        detail.Raw,
+	}
+
+	for _, node := range detail.Mapping {
+		if node.Message == nil {
+			continue
+		}
+		msg := node.Message
+		if entries, ok := extractContextEntries(msg); ok {
+			export.Context = append(export.Context, entries...)
+			continue
+		}
+		export.Attachments = mergeAttachments(export.Attachments, parseMessageAttachments(msg.Metadata))
+		text := renderMessageContent(msg.Content)
+		assets := parseMessageAssets(msg.Content)
+		// 图片生成结果由 tool 消息返回, 含图片时保留。
+		if reason := skipReason(msg, text); reason != "" && !hasAssetKind(assets, AssetImage) {
+			export.Skipped = append(export.Skipped, newSkippedMessage(msg, reason, text))
+			continue
+		}
+		role := chooseRole(msg)
+		if strings.EqualFold(role, "tool") {
+			// 图片生成工具的结果归入助手回复展示。
+			role = "assistant"
+		}
+		normalized := normalizeContent(text)
+		if len(assets) == 0 && (normalized == "" || strings.TrimSpace(normalized) == "\"\"") {
+			if strings.EqualFold(role, "system") || strings.EqualFold(role, "assistant") {
+				logging.Infof("过滤空SYSTEM消息 node=%s", node.ID)
+			}
+			continue
+		}
+		export.Messages = append(export.Messages, Message{
+			Role:       role,
+			CreateTime: msg.CreateTime.Float64(),
+			UpdateTime: msg.UpdateTime.Float64(),
+			Text:       normalized,
+			References: gatherReferences(msg.Metadata),
+			Assets:     assets,
+		})
+	}
+
+	sort.Slice(export.Messages, func(i, j int) bool {
+		a := export.Messages[i].CreateTime
+		b := export.Messages[j].CreateTime
+		if a == 0 || b == 0 {
+			return export.Messages[i].Text < export.Messages[j].Text
+		}
+		return a < b
+	})
+
+	return export
+}
+
+// extractContextEntries 识别自定义指令/项目提示词消息, 返回其中的文本。
+// 第二个返回值表示该消息属于上下文消息, 不应作为普通消息导出。
+func extractContextEntries(msg *client.Message) ([]ContextEntry, bool) {
+	var meta struct {
+		IsUserSystemMessage bool `json:"is_user_system_message"`
+		UserContext         *struct {
+			AboutUser  string `json:"about_user_message"`
+			AboutModel string `json:"about_model_message"`
+		} `json:"user_context_message_data"`
+	}
+	if len(msg.Metadata) > 0 {
+		_ = json.Unmarshal(msg.Metadata, &meta)
+	}
+	if msg.Content.ContentType != "user_editable_context" && !meta.IsUserSystemMessage {
+		return nil, false
+	}
+
+	var entries []ContextEntry
+	add := func(label, text string) {
+		if text = strings.TrimSpace(text); text != "" {
+			entries = append(entries, ContextEntry{Label: label, Text: text})
+		}
+	}
+	add("关于用户", msg.Content.UserProfile)
+	add("回复要求", msg.Content.UserInstructions)
+	if len(entries) == 0 && meta.UserContext != nil {
+		add("关于用户", meta.UserContext.AboutUser)
+		add("回复要求", meta.UserContext.AboutModel)
+	}
+	if len(entries) == 0 {
+		add("系统提示词", renderMessageContent(msg.Content))
+	}
+	return entries, true
+}
+
+func chooseRole(msg *client.Message) string {
+	if msg.Author.Role != "" {
+		return msg.Author.Role
+	}
+	if msg.Role != "" {
+		return msg.Role
+	}
+	return "unknown"
+}
+
+func normalizeContent(input string) string {
+	if input == "" {
+		return ""
+	}
+	clean := strings.TrimSpace(input)
+	if clean == "" {
+		return ""
+	}
+	clean = strings.ReplaceAll(clean, "\u200B", "")
+	clean = strings.ReplaceAll(clean, "\uFEFF", "")
+	clean = strings.TrimSpace(clean)
+	clean = structuredTagPattern.ReplaceAllString(clean, "")
+	return clean
+}
+
+type messageMetadata struct {
+	ContentReferences []contentReference  `json:"content_references"`
+	SearchGroups      []searchResultGroup `json:"search_result_groups"`
+	Citations         []citationRef       `json:"citations"`
+}
+
+type contentReference struct {
+	SafeURLs []string       `json:"safe_urls"`
+	Items    []contentEntry `json:"items"`
+	Type     string         `json:"type"`
+}
+
+type contentEntry struct {
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	Snippet     string `json:"snippet"`
+	Attribution string `json:"attribution"`
+}
+
+type searchResultGroup struct {
+	Domain  string        `json:"domain"`
+	Entries []searchEntry `json:"entries"`
+}
+
+type searchEntry struct {
+	URL         string `json:"url"`
+	Title       string `json:"title"`
+	Snippet     string `json:"snippet"`
+	Attribution string `json:"attribution"`
+}
+
+type citationRef struct {
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	Attribution string `json:"attribution"`
+}
+
+func gatherReferences(raw json.RawMessage) []Reference {
+	if len(raw) == 0 {
+		return nil
+	}
+	var meta messageMetadata
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		return nil
+	}
+
+	seen := make(map[string]Reference)
+
+	add := func(rawURL, title, source string) {
+		u := strings.TrimSpace(rawURL)
+		if u == "" {
+			return
+		}
+		if _, ok := seen[u]; ok {
+			return
+		}
+		if title = strings.TrimSpace(title); title == "" {
+			title = fallbackTitle(u)
+		}
+		if source = strings.TrimSpace(source); source == "" {
+			source = hostFromURL(u)
+		}
+		seen[u] = Reference{Title: title, URL: u, Source: source}
+	}
+
+	for _, ref := range meta.ContentReferences {
+		for _, item := range ref.Items {
+			add(item.URL, item.Title, item.Attribution)
+		}
+		for _, rawURL := range ref.SafeURLs {
+			add(rawURL, "", ref.Type)
+		}
+	}
+
+	for _, group := range meta.SearchGroups {
+		for _, entry := range group.Entries {
+			source := entry.Attribution
+			if source == "" {
+				source = group.Domain
+			}
+			add(entry.URL, entry.Title, source)
+		}
+	}
+
+	for _, c := range meta.Citations {
+		add(c.URL, c.Title, c.Attribution)
+	}
+
+	if len(seen) == 0 {
+		return nil
+	}
+
+	refs := make([]Reference, 0, len(seen))
+	for _, item := range seen {
+		refs = append(refs, item)
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Source == refs[j].Source {
+			return refs[i].Title < refs[j].Title
+		}
+		return refs[i].Source < refs[j].Source
+	})
+	return refs
+}
+
+func fallbackTitle(rawURL string) string {
+	if host := hostFromURL(rawURL); host != "" {
+		return host
+	}
+	return rawURL
+}
+
+func hostFromURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return parsed.Host
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func chooseTime(values ...float64) float64 {
+	for _, v := range values {
+		if v > 0 {
+			return v
+		}
+	}
+	return 0
+}
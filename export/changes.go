@@ -0,0 +1,133 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+)
+
+// 消息相对上一次备份的变化, 见 AnnotateChanges。
+const (
+	ChangeAdded  = "added"
+	ChangeEdited = "edited"
+)
+
+// changeLabels 是变化标记在导出文档中的显示文本。
+var changeLabels = map[string]string{
+	ChangeAdded:  "新增",
+	ChangeEdited: "已修改",
+}
+
+// messageKey 以角色与创建时间识别同一条消息; 缺少创建时间时退回到同角色内的序号。
+func messageKey(msg Message, seq int) string {
+	role := strings.ToLower(msg.Role)
+	if msg.CreateTime > 0 {
+		return role + "@" + strconv.FormatFloat(msg.CreateTime, 'f', -1, 64)
+	}
+	return role + "#" + strconv.Itoa(seq)
+}
+
+func messageKeys(msgs []Message) []string {
+	keys := make([]string, len(msgs))
+	seq := make(map[string]int)
+	for i, msg := range msgs {
+		role := strings.ToLower(msg.Role)
+		keys[i] = messageKey(msg, seq[role])
+		seq[role]++
+	}
+	return keys
+}
+
+// AnnotateChanges 对比 previous (上一次备份的内容) 标记 conv 中新增与修改过的消息,
+// 只存在于 previous 中的消息记入 conv.Removed。
+func AnnotateChanges(conv *Conversation, previous Conversation) {
+	prevKeys := messageKeys(previous.Messages)
+	prevByKey := make(map[string]Message, len(prevKeys))
+	for i, key := range prevKeys {
+		prevByKey[key] = previous.Messages[i]
+	}
+	seen := make(map[string]bool, len(conv.Messages))
+	for i, key := range messageKeys(conv.Messages) {
+		seen[key] = true
+		prev, ok := prevByKey[key]
+		switch {
+		case !ok:
+			conv.Messages[i].Change = ChangeAdded
+		case prev.Text != conv.Messages[i].Text:
+			conv.Messages[i].Change = ChangeEdited
+		default:
+			conv.Messages[i].Change = ""
+		}
+	}
+	conv.Removed = nil
+	for i, key := range prevKeys {
+		if !seen[key] {
+			removed := previous.Messages[i]
+			removed.Change = ""
+			conv.Removed = append(conv.Removed, removed)
+		}
+	}
+}
+
+// ClearChanges 返回去掉变化标记的副本, 不修改传入的对话。
+func ClearChanges(conv Conversation) Conversation {
+	conv.Removed = nil
+	msgs := make([]Message, len(conv.Messages))
+	for i, msg := range conv.Messages {
+		msg.Change = ""
+		msgs[i] = msg
+	}
+	conv.Messages = msgs
+	return conv
+}
+
+// HasChanges 判断对话是否带有变化标记。
+func HasChanges(conv Conversation) bool {
+	if len(conv.Removed) > 0 {
+		return true
+	}
+	for _, msg := range conv.Messages {
+		if msg.Change != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func changeLabel(change string) string {
+	return changeLabels[change]
+}
+
+// renderRemovedMarkdown 输出"已删除的消息"小节。
+func renderRemovedMarkdown(removed []Message, tf TimeFormatter) string {
+	if len(removed) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("## 已删除的消息\n\n")
+	for _, msg := range removed {
+		b.WriteString(fmt.Sprintf("### %s · %s\n\n", strings.ToUpper(firstNonEmpty(msg.Role, "unknown")), tf.Format(msg.CreateTime)))
+		b.WriteString(blockquote("user", msg.Text))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// renderRemovedHTML 输出"已删除的消息"小节的 HTML。
+func renderRemovedHTML(removed []Message, tf TimeFormatter, opts HTMLOptions) string {
+	if len(removed) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("<section class=\"removed\">\n<h2>已删除的消息</h2>\n")
+	for _, msg := range removed {
+		role := strings.ToLower(firstNonEmpty(msg.Role, "unknown"))
+		b.WriteString(fmt.Sprintf("<article class=\"message %s change-removed\">\n", html.EscapeString(role)))
+		b.WriteString(fmt.Sprintf("<h2>%s · %s</h2>\n", html.EscapeString(strings.ToUpper(role)), tf.Format(msg.CreateTime)))
+		b.WriteString(renderTextHTML(firstNonEmpty(msg.Text, "(空内容)"), opts))
+		b.WriteString("</article>\n")
+	}
+	b.WriteString("</section>\n")
+	return b.String()
+}
@@ -0,0 +1,103 @@
+package export
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnnotateChanges(t *testing.T) {
+	previous := Conversation{Messages: []Message{
+		{Role: "user", Text: "问题", CreateTime: 1},
+		{Role: "assistant", Text: "旧回答", CreateTime: 2},
+		{Role: "user", Text: "追问", CreateTime: 3},
+		{Role: "tool", Text: "结果"},
+	}}
+	tests := []struct {
+		name        string
+		messages    []Message
+		wantChanges []string
+		wantRemoved []string
+		wantChanged bool
+	}{
+		{
+			name:        "没有变化",
+			messages:    append([]Message(nil), previous.Messages...),
+			wantChanges: []string{"", "", "", ""},
+		},
+		{
+			name: "新增、修改与删除",
+			messages: []Message{
+				{Role: "user", Text: "问题", CreateTime: 1},
+				{Role: "assistant", Text: "新回答", CreateTime: 2},
+				{Role: "tool", Text: "结果"},
+				{Role: "assistant", Text: "补充", CreateTime: 4, Change: ChangeEdited},
+			},
+			wantChanges: []string{"", ChangeEdited, "", ChangeAdded},
+			wantRemoved: []string{"追问"},
+			wantChanged: true,
+		},
+		{
+			name:        "没有时间的消息按同角色序号对应",
+			messages:    []Message{{Role: "TOOL", Text: "新结果"}, {Role: "tool", Text: "第二个"}},
+			wantChanges: []string{ChangeEdited, ChangeAdded},
+			wantRemoved: []string{"问题", "旧回答", "追问"},
+			wantChanged: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conv := Conversation{Messages: tt.messages, Removed: []Message{{Text: "上次的结果"}}}
+			AnnotateChanges(&conv, previous)
+			var changes, removed []string
+			for _, msg := range conv.Messages {
+				changes = append(changes, msg.Change)
+			}
+			for _, msg := range conv.Removed {
+				removed = append(removed, msg.Text)
+			}
+			if !reflect.DeepEqual(changes, tt.wantChanges) || !reflect.DeepEqual(removed, tt.wantRemoved) {
+				t.Errorf("changes = %q removed = %q, want %q %q", changes, removed, tt.wantChanges, tt.wantRemoved)
+			}
+			if HasChanges(conv) != tt.wantChanged {
+				t.Errorf("HasChanges() = %v, want %v", HasChanges(conv), tt.wantChanged)
+			}
+		})
+	}
+}
+
+func TestClearChanges(t *testing.T) {
+	conv := Conversation{
+		Messages: []Message{{Role: "user", Text: "问题", Change: ChangeAdded}},
+		Removed:  []Message{{Role: "assistant", Text: "旧回答"}},
+	}
+	cleared := ClearChanges(conv)
+	if HasChanges(cleared) {
+		t.Errorf("ClearChanges() = %+v", cleared)
+	}
+	if !HasChanges(conv) || conv.Messages[0].Change != ChangeAdded {
+		t.Errorf("传入的对话被修改: %+v", conv)
+	}
+}
+
+func TestRenderRemovedMarkdown(t *testing.T) {
+	tf := TimeFormatter{}
+	tests := []struct {
+		name    string
+		removed []Message
+		want    string
+	}{
+		{name: "没有删除的消息", removed: nil, want: ""},
+		{
+			name:    "列出删除的消息",
+			removed: []Message{{Text: "旧回答"}},
+			want:    "## 已删除的消息\n\n### UNKNOWN · -\n\n" + blockquote("user", "旧回答") + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderRemovedMarkdown(tt.removed, tf); got != tt.want {
+				t.Errorf("renderRemovedMarkdown() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
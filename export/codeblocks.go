@@ -0,0 +1,53 @@
+package export
+
+import (
+	"strings"
+)
+
+// TextBlock 是按围栏代码块拆分后的一段内容, Code 为 true 时 Text 为代码正文。
+type TextBlock struct {
+	Text string
+	Code bool
+	Lang string
+}
+
+// SplitFencedBlocks 把文本拆为普通段落与 ``` 围栏代码块, 未闭合的代码块延续到文本末尾。
+func SplitFencedBlocks(text string) []TextBlock {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	var blocks []TextBlock
+	var prose []string
+	flushProse := func() {
+		if joined := strings.Trim(strings.Join(prose, "\n"), "\n"); joined != "" {
+			blocks = append(blocks, TextBlock{Text: joined})
+		}
+		prose = nil
+	}
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(trimmed, "```") {
+			prose = append(prose, lines[i])
+			continue
+		}
+		flushProse()
+		lang := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(trimmed, "```")))
+		var code []string
+		for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+			code = append(code, lines[i])
+		}
+		blocks = append(blocks, TextBlock{Text: strings.Join(code, "\n"), Code: true, Lang: lang})
+	}
+	flushProse()
+	return blocks
+}
+
+// DiagramType 返回代码块语言对应的图表类型, 非图表语言返回空字符串。
+func DiagramType(lang string) string {
+	switch strings.ToLower(strings.TrimSpace(lang)) {
+	case "mermaid", "mmd":
+		return "mermaid"
+	case "plantuml", "puml", "uml":
+		return "plantuml"
+	default:
+		return ""
+	}
+}
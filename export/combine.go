@@ -0,0 +1,114 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+)
+
+// 合并多个对话时的排列方式: 按消息时间交错为一个对话, 或在 HTML 页面中并排显示。
+const (
+	CombineInterleaved = "interleaved"
+	CombineSideBySide  = "side_by_side"
+)
+
+// NormalizeCombineMode 规范化合并方式, 未知值返回空字符串。
+func NormalizeCombineMode(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", CombineInterleaved:
+		return CombineInterleaved
+	case CombineSideBySide, "side-by-side", "sidebyside":
+		return CombineSideBySide
+	default:
+		return ""
+	}
+}
+
+// CombineConversations 把多个对话的消息按时间交错合并为一个对话, 用于同一讨论分散在几个对话中时生成一份归档文档。
+// 每条消息的 Source 为来源对话的标题, 上下文开头列出各来源对话; title 为空时用各标题拼接。
+func CombineConversations(convs []Conversation, title string) Conversation {
+	type keyed struct {
+		msg Message
+		at  float64
+	}
+	var (
+		combined Conversation
+		ids      []string
+		titles   []string
+		sources  strings.Builder
+		messages []keyed
+		tags     = make(map[string]bool)
+	)
+	for _, conv := range convs {
+		name := firstNonEmpty(conv.Title, conv.ID)
+		ids = append(ids, conv.ID)
+		titles = append(titles, name)
+		sources.WriteString(fmt.Sprintf("- %s (`%s`)\n", name, conv.ID))
+		if combined.CreateTime == 0 || (conv.CreateTime > 0 && conv.CreateTime < combined.CreateTime) {
+			combined.CreateTime = conv.CreateTime
+		}
+		combined.UpdateTime = max(combined.UpdateTime, conv.UpdateTime)
+		for _, tag := range conv.Tags {
+			if !tags[tag] {
+				tags[tag] = true
+				combined.Tags = append(combined.Tags, tag)
+			}
+		}
+		for _, entry := range conv.Context {
+			combined.Context = append(combined.Context, ContextEntry{Label: fmt.Sprintf("%s (%s)", entry.Label, name), Text: entry.Text})
+		}
+		combined.Attachments = append(combined.Attachments, conv.Attachments...)
+		// 没有时间的消息沿用同一对话中上一条消息的时间, 保持在原来的位置附近。
+		at := conv.CreateTime
+		for _, msg := range conv.Messages {
+			if msg.CreateTime > 0 {
+				at = msg.CreateTime
+			}
+			msg.Source = name
+			messages = append(messages, keyed{msg: msg, at: at})
+		}
+	}
+	sort.SliceStable(messages, func(i, j int) bool { return messages[i].at < messages[j].at })
+
+	combined.ID = strings.Join(ids, "+")
+	combined.Title = firstNonEmpty(strings.TrimSpace(title), strings.Join(titles, " + "))
+	combined.Context = append([]ContextEntry{{Label: "合并自", Text: strings.TrimSpace(sources.String())}}, combined.Context...)
+	combined.Messages = make([]Message, 0, len(messages))
+	for _, item := range messages {
+		combined.Messages = append(combined.Messages, item.msg)
+	}
+	if len(convs) > 0 {
+		first := convs[0]
+		combined.Layout, combined.ShowStats, combined.TimeFormat, combined.SecondTimezone = first.Layout, first.ShowStats, first.TimeFormat, first.SecondTimezone
+	}
+	return combined
+}
+
+// RenderSideBySideHTML 输出把多个对话并排显示的 HTML 页面, 每列为一个对话的完整内容, 便于对照阅读。
+func RenderSideBySideHTML(convs []Conversation, title, timezone string, opts HTMLOptions) string {
+	if strings.TrimSpace(title) == "" {
+		titles := make([]string, 0, len(convs))
+		for _, conv := range convs {
+			titles = append(titles, firstNonEmpty(conv.Title, conv.ID))
+		}
+		title = strings.Join(titles, " | ")
+	}
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html lang=\"zh-CN\">\n<head>\n<meta charset=\"utf-8\">\n")
+	b.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+	b.WriteString(fmt.Sprintf("<title>%s</title>\n", html.EscapeString(title)))
+	b.WriteString("<style>")
+	b.WriteString(htmlStylesheet(opts.Theme, opts.CustomCSS))
+	b.WriteString(fmt.Sprintf("body.side-by-side { max-width: none; }\n.columns { display: grid; grid-template-columns: repeat(%d, minmax(0, 1fr)); gap: 24px; align-items: start; }\n", max(len(convs), 1)))
+	b.WriteString("@media (max-width: 800px) { .columns { grid-template-columns: 1fr; } }\n")
+	b.WriteString("</style>\n</head>\n")
+	b.WriteString(fmt.Sprintf("<body class=\"theme-%s side-by-side\">\n<div class=\"columns\">\n", NormalizeHTMLTheme(opts.Theme)))
+	for _, conv := range convs {
+		b.WriteString("<div class=\"column\">\n")
+		b.WriteString(RenderHTMLFragment(conv, timezone, opts))
+		b.WriteString("</div>\n")
+	}
+	b.WriteString("</div>\n</body>\n</html>\n")
+	return b.String()
+}
@@ -0,0 +1,97 @@
+package export
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeCombineMode(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", CombineInterleaved},
+		{" Interleaved ", CombineInterleaved},
+		{"side-by-side", CombineSideBySide},
+		{"SideBySide", CombineSideBySide},
+		{"stacked", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeCombineMode(tt.value); got != tt.want {
+			t.Errorf("NormalizeCombineMode(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestCombineConversations(t *testing.T) {
+	a := Conversation{
+		ID: "a", Title: "部署", CreateTime: 10, UpdateTime: 40, Tags: []string{"ops", "nginx"}, TimeFormat: "zh",
+		Context: []ContextEntry{{Label: "自定义指令", Text: "简洁"}},
+		Messages: []Message{
+			{Role: "user", Text: "a1", CreateTime: 11},
+			{Role: "assistant", Text: "a2"},
+			{Role: "user", Text: "a3", CreateTime: 30},
+		},
+	}
+	b := Conversation{
+		ID: "b", CreateTime: 5, UpdateTime: 20, Tags: []string{"nginx"},
+		Messages: []Message{{Role: "user", Text: "b1", CreateTime: 20}},
+	}
+	tests := []struct {
+		name      string
+		title     string
+		wantTitle string
+	}{
+		{name: "默认标题", title: " ", wantTitle: "部署 + b"},
+		{name: "指定标题", title: "合并", wantTitle: "合并"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CombineConversations([]Conversation{a, b}, tt.title)
+			if got.ID != "a+b" || got.Title != tt.wantTitle || got.CreateTime != 5 || got.UpdateTime != 40 || got.TimeFormat != "zh" {
+				t.Errorf("CombineConversations() = %+v", got)
+			}
+			if !reflect.DeepEqual(got.Tags, []string{"ops", "nginx"}) {
+				t.Errorf("Tags = %v", got.Tags)
+			}
+			var order []string
+			for _, msg := range got.Messages {
+				order = append(order, msg.Text+"@"+msg.Source)
+			}
+			if want := "a1@部署,a2@部署,b1@b,a3@部署"; strings.Join(order, ",") != want {
+				t.Errorf("消息顺序 = %v, want %s", order, want)
+			}
+			wantContext := []ContextEntry{
+				{Label: "合并自", Text: "- 部署 (`a`)\n- b (`b`)"},
+				{Label: "自定义指令 (部署)", Text: "简洁"},
+			}
+			if !reflect.DeepEqual(got.Context, wantContext) {
+				t.Errorf("Context = %+v, want %+v", got.Context, wantContext)
+			}
+		})
+	}
+	if a.Messages[0].Source != "" {
+		t.Errorf("传入的对话被修改: %+v", a.Messages[0])
+	}
+}
+
+func TestRenderSideBySideHTML(t *testing.T) {
+	convs := []Conversation{
+		{ID: "a", Title: "<部署>", Messages: []Message{{Role: "user", Text: "a1"}}},
+		{ID: "b", Messages: []Message{{Role: "user", Text: "b1"}}},
+	}
+	page := RenderSideBySideHTML(convs, "", "UTC", HTMLOptions{})
+	for _, want := range []string{
+		"<title>&lt;部署&gt; | b</title>",
+		"repeat(2, minmax(0, 1fr))",
+		"side-by-side\">",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("页面缺少 %q", want)
+		}
+	}
+	if got := strings.Count(page, "<div class=\"column\">"); got != len(convs) {
+		t.Errorf("列数 = %d, want %d", got, len(convs))
+	}
+}
@@ -0,0 +1,240 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/Devoty/openai-backup/client"
+)
+
+// ContentHandler 把某种 content_type 的消息内容转换为导出的正文, 返回空字符串表示没有可导出的文字。
+type ContentHandler func(content client.Content) string
+
+var (
+	contentMu       sync.RWMutex
+	contentHandlers = map[string]ContentHandler{
+		"":                        renderPartsContent,
+		"text":                    renderPartsContent,
+		"multimodal_text":         renderPartsContent,
+		"code":                    renderPartsContent,
+		"execution_output":        renderPartsContent,
+		"user_editable_context":   renderPartsContent,
+		"reasoning_recap":         renderReasoningRecap,
+		"thoughts":                renderThoughts,
+		"model_editable_context":  renderModelContext,
+		"tether_browsing_display": renderBrowsingDisplay,
+		"tether_quote":            renderTetherQuote,
+		"system_error":            renderSystemError,
+	}
+)
+
+// RegisterContentHandler 注册或替换某种 content_type 的处理函数, handler 为 nil 时恢复默认处理。
+// 作为库引用时可用于支持新出现的类型。
+func RegisterContentHandler(contentType string, handler ContentHandler) {
+	contentMu.Lock()
+	defer contentMu.Unlock()
+	if handler == nil {
+		delete(contentHandlers, contentType)
+		return
+	}
+	contentHandlers[contentType] = handler
+}
+
+// HasContentHandler 报告 content_type 是否有专门的处理函数, 没有时按 renderUnknownContent 只提取文字。
+func HasContentHandler(contentType string) bool {
+	contentMu.RLock()
+	defer contentMu.RUnlock()
+	_, ok := contentHandlers[contentType]
+	return ok
+}
+
+// renderMessageContent 按 content_type 选择处理函数; 未登记的类型使用 renderUnknownContent,
+// 新出现的类型只会丢失格式, 不会把原始 JSON 写进导出内容。
+func renderMessageContent(content client.Content) string {
+	contentMu.RLock()
+	handler, ok := contentHandlers[content.ContentType]
+	contentMu.RUnlock()
+	if !ok {
+		handler = renderUnknownContent
+	}
+	return strings.TrimSpace(handler(content))
+}
+
+// renderPartsContent 将 message.content 的 text 与 parts 解析为纯文本输出。
+func renderPartsContent(content client.Content) string {
+	var segments []string
+
+	if trimmed := strings.TrimSpace(content.Text); trimmed != "" {
+		segments = append(segments, trimmed)
+	}
+
+	for _, raw := range content.Parts {
+		if part, ok := decodeContentPart(raw); ok && isAssetPointerPart(part) {
+			continue
+		}
+		var str string
+		if err := json.Unmarshal(raw, &str); err == nil {
+			if str = strings.TrimSpace(str); str != "" {
+				segments = append(segments, str)
+			}
+			continue
+		}
+		// 对象形式的片段只取其中的文字, 不输出原始 JSON。
+		segments = append(segments, nestedTexts(raw)...)
+	}
+
+	return strings.TrimSpace(strings.Join(segments, "\n\n"))
+}
+
+// renderUnknownContent 是未登记类型的默认处理: 先按 text/parts 解析, 没有结果时递归提取
+// 内容中所有 text 字段。
+func renderUnknownContent(content client.Content) string {
+	if text := renderPartsContent(content); text != "" {
+		return text
+	}
+	return strings.Join(nestedTexts(content.Raw), "\n\n")
+}
+
+// reasoning_recap 是推理过程的摘要, 如 "Thought for 12 seconds"。
+func renderReasoningRecap(content client.Content) string {
+	var recap struct {
+		Content string `json:"content"`
+	}
+	_ = json.Unmarshal(content.Raw, &recap)
+	return recap.Content
+}
+
+// thoughts 是推理过程, 每段包含摘要与正文。
+func renderThoughts(content client.Content) string {
+	var payload struct {
+		Thoughts []struct {
+			Summary string `json:"summary"`
+			Content string `json:"content"`
+		} `json:"thoughts"`
+	}
+	_ = json.Unmarshal(content.Raw, &payload)
+	var segments []string
+	for _, thought := range payload.Thoughts {
+		summary := strings.TrimSpace(thought.Summary)
+		body := strings.TrimSpace(thought.Content)
+		switch {
+		case summary != "" && body != "":
+			segments = append(segments, "**"+summary+"**\n\n"+body)
+		case summary != "" || body != "":
+			segments = append(segments, summary+body)
+		}
+	}
+	return strings.Join(segments, "\n\n")
+}
+
+// model_editable_context 是模型记忆 (Memory) 的快照。
+func renderModelContext(content client.Content) string {
+	var payload struct {
+		ModelSetContext string `json:"model_set_context"`
+	}
+	_ = json.Unmarshal(content.Raw, &payload)
+	return payload.ModelSetContext
+}
+
+// tether_browsing_display 是网页浏览的结果摘要。
+func renderBrowsingDisplay(content client.Content) string {
+	var payload struct {
+		Result  string `json:"result"`
+		Summary string `json:"summary"`
+	}
+	_ = json.Unmarshal(content.Raw, &payload)
+	return firstNonEmpty(strings.TrimSpace(payload.Summary), payload.Result)
+}
+
+// tether_quote 是浏览时引用的网页片段。
+func renderTetherQuote(content client.Content) string {
+	var payload struct {
+		Title string `json:"title"`
+		URL   string `json:"url"`
+		Text  string `json:"text"`
+	}
+	_ = json.Unmarshal(content.Raw, &payload)
+	text := strings.TrimSpace(firstNonEmpty(payload.Text, content.Text))
+	if text == "" {
+		return ""
+	}
+	source := firstNonEmpty(strings.TrimSpace(payload.Title), payload.URL)
+	if source == "" {
+		return text
+	}
+	if payload.URL != "" {
+		source = "[" + source + "](" + payload.URL + ")"
+	}
+	return text + "\n\n— " + source
+}
+
+// system_error 是工具调用失败等系统错误, 保留错误名称。
+func renderSystemError(content client.Content) string {
+	var payload struct {
+		Name string `json:"name"`
+		Text string `json:"text"`
+	}
+	_ = json.Unmarshal(content.Raw, &payload)
+	text := strings.TrimSpace(firstNonEmpty(payload.Text, content.Text))
+	if payload.Name == "" || text == "" {
+		return text
+	}
+	return payload.Name + ": " + text
+}
+
+// nestedTexts 按出现顺序返回 raw 中任意层级名为 text 的字符串字段。
+func nestedTexts(raw json.RawMessage) []string {
+	if len(raw) == 0 {
+		return nil
+	}
+	type frame struct {
+		object    bool
+		expectKey bool
+		key       string
+	}
+	var (
+		texts []string
+		stack []*frame
+	)
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	// valueDone 在对象中读完一个值后切换回读取键名。
+	valueDone := func() {
+		if len(stack) > 0 && stack[len(stack)-1].object {
+			stack[len(stack)-1].expectKey = true
+		}
+	}
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return texts
+		}
+		switch v := tok.(type) {
+		case json.Delim:
+			switch v {
+			case '{', '[':
+				stack = append(stack, &frame{object: v == '{', expectKey: v == '{'})
+			default:
+				if len(stack) > 0 {
+					stack = stack[:len(stack)-1]
+				}
+				valueDone()
+			}
+		case string:
+			if len(stack) > 0 && stack[len(stack)-1].object && stack[len(stack)-1].expectKey {
+				stack[len(stack)-1].key = v
+				stack[len(stack)-1].expectKey = false
+				continue
+			}
+			if len(stack) > 0 && stack[len(stack)-1].key == "text" {
+				if text := strings.TrimSpace(v); text != "" {
+					texts = append(texts, text)
+				}
+			}
+			valueDone()
+		default:
+			valueDone()
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package export
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Devoty/openai-backup/client"
+)
+
+func TestRenderMessageContent(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{name: "文本片段", raw: `{"content_type":"text","parts":[" 你好 ","","世界"]}`, want: "你好\n\n世界"},
+		{
+			name: "跳过图片引用并提取对象中的文字",
+			raw:  `{"content_type":"multimodal_text","parts":[{"content_type":"image_asset_pointer","asset_pointer":"file-service://f1"},{"content_type":"audio_transcription","text":"语音转写"},"说明"]}`,
+			want: "语音转写\n\n说明",
+		},
+		{name: "代码", raw: `{"content_type":"code","text":"print(1)\n"}`, want: "print(1)"},
+		{name: "推理摘要", raw: `{"content_type":"reasoning_recap","content":"思考了 5 秒"}`, want: "思考了 5 秒"},
+		{
+			name: "推理过程",
+			raw:  `{"content_type":"thoughts","thoughts":[{"summary":"分析","content":"先看日志"},{"summary":"","content":"再看配置"},{"summary":" ","content":""}]}`,
+			want: "**分析**\n\n先看日志\n\n再看配置",
+		},
+		{name: "模型记忆", raw: `{"content_type":"model_editable_context","model_set_context":"用户使用 Go"}`, want: "用户使用 Go"},
+		{name: "浏览摘要优先", raw: `{"content_type":"tether_browsing_display","result":"全文","summary":" 摘要 "}`, want: "摘要"},
+		{
+			name: "网页引用附带来源链接",
+			raw:  `{"content_type":"tether_quote","title":"nginx docs","url":"https://nginx.org","text":"配置说明"}`,
+			want: "配置说明\n\n— [nginx docs](https://nginx.org)",
+		},
+		{name: "网页引用没有来源", raw: `{"content_type":"tether_quote","text":"配置说明"}`, want: "配置说明"},
+		{name: "系统错误", raw: `{"content_type":"system_error","name":"TimeoutError","text":"超时"}`, want: "TimeoutError: 超时"},
+		{
+			name: "未知类型递归提取文字",
+			raw:  `{"content_type":"future_widget","items":[{"text":"第一段","meta":{"text":"第二段","n":1}},{"title":"text"}],"text_extra":"x"}`,
+			want: "第一段\n\n第二段",
+		},
+		{name: "未知类型没有文字", raw: `{"content_type":"future_widget","items":[1,2]}`, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var content client.Content
+			if err := json.Unmarshal([]byte(tt.raw), &content); err != nil {
+				t.Fatal(err)
+			}
+			if got := renderMessageContent(content); got != tt.want {
+				t.Errorf("renderMessageContent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterContentHandler(t *testing.T) {
+	const contentType = "test_widget"
+	t.Cleanup(func() { RegisterContentHandler(contentType, nil) })
+	content := client.Content{ContentType: contentType, Text: "原文"}
+	tests := []struct {
+		name    string
+		handler ContentHandler
+		want    string
+		wantHas bool
+	}{
+		{name: "注册处理函数", handler: func(c client.Content) string { return strings.ToUpper(c.ContentType) }, want: "TEST_WIDGET", wantHas: true},
+		{name: "传入 nil 时恢复默认处理", handler: nil, want: "原文"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			RegisterContentHandler(contentType, tt.handler)
+			if HasContentHandler(contentType) != tt.wantHas {
+				t.Errorf("HasContentHandler() = %v, want %v", HasContentHandler(contentType), tt.wantHas)
+			}
+			if got := renderMessageContent(content); got != tt.want {
+				t.Errorf("renderMessageContent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package export
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	epubPackagePath    = "content.opf"
+	epubStylePath      = "style.css"
+	epubNavPath        = "nav.xhtml"
+	epubNCXPath        = "toc.ncx"
+	epubChapterPattern = "chapter-%03d.xhtml"
+)
+
+// xhtmlVoidPattern 匹配 HTML 渲染结果中未自闭合的空元素, XHTML 要求写成 <br/>。
+// 正文中的尖括号都已转义, 只会匹配到标签。
+var xhtmlVoidPattern = regexp.MustCompile(`<(br|hr|img|meta|source|wbr)((?:\s[^<>]*?)?)\s*/?>`)
+
+type epubChapter struct {
+	path  string
+	title string
+	// sections 是按 message_layout 分组后的各组标题, 在目录中列在章节之下。
+	sections []epubSection
+}
+
+type epubSection struct {
+	anchor string
+	title  string
+}
+
+type epubResource struct {
+	path      string
+	mediaType string
+}
+
+// EPUBWriter 把多个对话写为一本 EPUB 3 电子书, 每个对话一章, 正文复用 HTML 导出的渲染结果。
+// 章节与资源按添加顺序流式写入 w, Close 时写入目录与包描述文件。
+type EPUBWriter struct {
+	archive   *zip.Writer
+	title     string
+	timezone  string
+	opts      HTMLOptions
+	ids       []string
+	chapters  []epubChapter
+	resources []epubResource
+	written   map[string]bool
+}
+
+// NewEPUBWriter 创建 EPUBWriter 并写入 mimetype 与 META-INF/container.xml。
+// 电子书用于离线阅读, 公式与图表不使用远程图片渲染。
+func NewEPUBWriter(w io.Writer, title, timezone string, opts HTMLOptions) (*EPUBWriter, error) {
+	if NormalizeMathMode(opts.MathMode) == MathImage {
+		opts.MathMode = MathRaw
+	}
+	opts.RenderDiagrams = false
+	opts.QRCode = ""
+	e := &EPUBWriter{
+		archive:  zip.NewWriter(w),
+		title:    firstNonEmpty(strings.TrimSpace(title), "ChatGPT 对话"),
+		timezone: timezone,
+		opts:     opts,
+		written:  make(map[string]bool),
+	}
+	// mimetype 必须是第一个文件且不压缩。
+	writer, err := e.archive.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(writer, "application/epub+zip"); err != nil {
+		return nil, err
+	}
+	container := `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+<rootfiles>
+<rootfile full-path="` + epubPackagePath + `" media-type="application/oebps-package+xml"/>
+</rootfiles>
+</container>
+`
+	if err := e.writeFile("META-INF/container.xml", container); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// HasResource 返回 name 是否已经写入电子书。
+func (e *EPUBWriter) HasResource(name string) bool {
+	return e.written[name]
+}
+
+// AddResource 写入章节引用的文件 (如图片), name 为相对电子书根目录的路径; 重复写入同一路径时忽略。
+func (e *EPUBWriter) AddResource(name string, data []byte) error {
+	if e.written[name] {
+		return nil
+	}
+	mediaType := mime.TypeByExtension(path.Ext(name))
+	if mediaType == "" {
+		mediaType = http.DetectContentType(data)
+	}
+	if i := strings.Index(mediaType, ";"); i >= 0 {
+		mediaType = strings.TrimSpace(mediaType[:i])
+	}
+	writer, err := e.archive.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return err
+	}
+	e.written[name] = true
+	e.resources = append(e.resources, epubResource{path: name, mediaType: mediaType})
+	return nil
+}
+
+// AddChapter 把对话渲染为一章。资源路径应已指向 AddResource 写入的文件, 未下载的资源保留原始指针。
+func (e *EPUBWriter) AddChapter(conv Conversation) error {
+	name := fmt.Sprintf(epubChapterPattern, len(e.chapters)+1)
+	title := firstNonEmpty(conv.Title, "(未命名对话)")
+	var b strings.Builder
+	b.WriteString(epubXHTMLHeader(title))
+	b.WriteString(fmt.Sprintf("<body class=\"theme-%s\">\n", NormalizeHTMLTheme(e.opts.Theme)))
+	b.WriteString(RenderXHTMLFragment(conv, e.timezone, e.opts))
+	b.WriteString("</body>\n</html>\n")
+	if err := e.writeFile(name, b.String()); err != nil {
+		return err
+	}
+	chapter := epubChapter{path: name, title: title}
+	for idx, group := range conv.GroupMessages(NewTimeFormatter(conv, ResolveLocation(e.timezone))) {
+		if group.Heading != "" {
+			chapter.sections = append(chapter.sections, epubSection{anchor: groupAnchor(idx), title: group.Heading})
+		}
+	}
+	e.chapters = append(e.chapters, chapter)
+	e.ids = append(e.ids, conv.ID)
+	return nil
+}
+
+// Chapters 返回已写入的章节数。
+func (e *EPUBWriter) Chapters() int {
+	return len(e.chapters)
+}
+
+// Close 写入样式、目录 (nav.xhtml 与兼容 EPUB 2 阅读器的 toc.ncx) 和包描述文件, 并结束压缩包。
+func (e *EPUBWriter) Close() error {
+	if err := e.writeFile(epubStylePath, htmlStylesheet(e.opts.Theme, e.opts.CustomCSS)); err != nil {
+		e.archive.Close()
+		return err
+	}
+	identifier := e.identifier()
+	for _, file := range []struct{ name, content string }{
+		{epubNavPath, e.renderNav()},
+		{epubNCXPath, e.renderNCX(identifier)},
+		{epubPackagePath, e.renderPackage(identifier)},
+	} {
+		if err := e.writeFile(file.name, file.content); err != nil {
+			e.archive.Close()
+			return err
+		}
+	}
+	return e.archive.Close()
+}
+
+func (e *EPUBWriter) writeFile(name, content string) error {
+	writer, err := e.archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(writer, content)
+	return err
+}
+
+// identifier 由对话 ID 生成稳定的 urn:uuid, 重新导出同一批对话时阅读器把它识别为同一本书。
+func (e *EPUBWriter) identifier() string {
+	ids := append([]string(nil), e.ids...)
+	sort.Strings(ids)
+	sum := sha1.Sum([]byte(strings.Join(ids, "\n")))
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+func (e *EPUBWriter) renderNav() string {
+	var b strings.Builder
+	b.WriteString(epubXHTMLHeader("目录"))
+	b.WriteString("<body>\n<nav epub:type=\"toc\" id=\"toc\">\n<h1>目录</h1>\n<ol>\n")
+	for _, chapter := range e.chapters {
+		b.WriteString(fmt.Sprintf("<li><a href=\"%s\">%s</a>", chapter.path, xmlText(chapter.title)))
+		if len(chapter.sections) > 0 {
+			b.WriteString("\n<ol>\n")
+			for _, section := range chapter.sections {
+				b.WriteString(fmt.Sprintf("<li><a href=\"%s#%s\">%s</a></li>\n", chapter.path, section.anchor, xmlText(section.title)))
+			}
+			b.WriteString("</ol>\n")
+		}
+		b.WriteString("</li>\n")
+	}
+	b.WriteString("</ol>\n</nav>\n</body>\n</html>\n")
+	return b.String()
+}
+
+func (e *EPUBWriter) renderNCX(identifier string) string {
+	var b strings.Builder
+	b.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	b.WriteString("<ncx xmlns=\"http://www.daisy.org/z3986/2005/ncx/\" version=\"2005-1\">\n<head>\n")
+	b.WriteString(fmt.Sprintf("<meta name=\"dtb:uid\" content=\"%s\"/>\n</head>\n", identifier))
+	b.WriteString(fmt.Sprintf("<docTitle><text>%s</text></docTitle>\n<navMap>\n", xmlText(e.title)))
+	order := 0
+	for i, chapter := range e.chapters {
+		order++
+		b.WriteString(fmt.Sprintf("<navPoint id=\"nav-%d\" playOrder=\"%d\"><navLabel><text>%s</text></navLabel><content src=\"%s\"/>", i+1, order, xmlText(chapter.title), chapter.path))
+		for j, section := range chapter.sections {
+			order++
+			b.WriteString(fmt.Sprintf("\n<navPoint id=\"nav-%d-%d\" playOrder=\"%d\"><navLabel><text>%s</text></navLabel><content src=\"%s#%s\"/></navPoint>", i+1, j+1, order, xmlText(section.title), chapter.path, section.anchor))
+		}
+		b.WriteString("</navPoint>\n")
+	}
+	b.WriteString("</navMap>\n</ncx>\n")
+	return b.String()
+}
+
+func (e *EPUBWriter) renderPackage(identifier string) string {
+	var b strings.Builder
+	b.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	b.WriteString("<package xmlns=\"http://www.idpf.org/2007/opf\" version=\"3.0\" unique-identifier=\"book-id\" xml:lang=\"zh-CN\">\n")
+	b.WriteString("<metadata xmlns:dc=\"http://purl.org/dc/elements/1.1/\">\n")
+	b.WriteString(fmt.Sprintf("<dc:identifier id=\"book-id\">%s</dc:identifier>\n", identifier))
+	b.WriteString(fmt.Sprintf("<dc:title>%s</dc:title>\n", xmlText(e.title)))
+	b.WriteString("<dc:language>zh-CN</dc:language>\n")
+	b.WriteString(fmt.Sprintf("<meta property=\"dcterms:modified\">%s</meta>\n", time.Now().UTC().Format("2006-01-02T15:04:05Z")))
+	b.WriteString("</metadata>\n<manifest>\n")
+	b.WriteString(fmt.Sprintf("<item id=\"nav\" href=\"%s\" media-type=\"application/xhtml+xml\" properties=\"nav\"/>\n", epubNavPath))
+	b.WriteString(fmt.Sprintf("<item id=\"ncx\" href=\"%s\" media-type=\"application/x-dtbncx+xml\"/>\n", epubNCXPath))
+	b.WriteString(fmt.Sprintf("<item id=\"style\" href=\"%s\" media-type=\"text/css\"/>\n", epubStylePath))
+	for i, chapter := range e.chapters {
+		b.WriteString(fmt.Sprintf("<item id=\"chapter-%d\" href=\"%s\" media-type=\"application/xhtml+xml\"/>\n", i+1, chapter.path))
+	}
+	for i, resource := range e.resources {
+		href := html.EscapeString(resource.path)
+		b.WriteString(fmt.Sprintf("<item id=\"res-%d\" href=\"%s\" media-type=\"%s\"/>\n", i+1, href, html.EscapeString(resource.mediaType)))
+	}
+	b.WriteString("</manifest>\n<spine toc=\"ncx\">\n")
+	for i := range e.chapters {
+		b.WriteString(fmt.Sprintf("<itemref idref=\"chapter-%d\"/>\n", i+1))
+	}
+	b.WriteString("</spine>\n</package>\n")
+	return b.String()
+}
+
+func epubXHTMLHeader(title string) string {
+	return "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<!DOCTYPE html>\n" +
+		"<html xmlns=\"http://www.w3.org/1999/xhtml\" xmlns:epub=\"http://www.idpf.org/2007/ops\" lang=\"zh-CN\" xml:lang=\"zh-CN\">\n" +
+		"<head>\n<meta charset=\"utf-8\"/>\n" +
+		fmt.Sprintf("<title>%s</title>\n", xmlText(title)) +
+		fmt.Sprintf("<link rel=\"stylesheet\" type=\"text/css\" href=\"%s\"/>\n</head>\n", epubStylePath)
+}
+
+// RenderXHTMLFragment 输出 RenderHTMLFragment 的 XHTML 形式, 用于 EPUB 章节与要求 XHTML 的目标。
+func RenderXHTMLFragment(conv Conversation, timezone string, opts HTMLOptions) string {
+	return xhtmlFragment(RenderHTMLFragment(conv, timezone, opts))
+}
+
+// xhtmlFragment 把 HTML 渲染结果转换为合法的 XHTML: 空元素自闭合, 布尔属性补全取值,
+// 并去掉 XML 不允许出现的控制字符。
+func xhtmlFragment(fragment string) string {
+	fragment = stripXMLInvalid(fragment)
+	fragment = xhtmlVoidPattern.ReplaceAllString(fragment, "<$1$2/>")
+	return strings.ReplaceAll(fragment, "<audio controls ", "<audio controls=\"controls\" ")
+}
+
+func xmlText(text string) string {
+	return html.EscapeString(stripXMLInvalid(text))
+}
+
+func stripXMLInvalid(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' || (r >= 0x20 && r != 0xFFFE && r != 0xFFFF) {
+			return r
+		}
+		return -1
+	}, text)
+}
@@ -0,0 +1,150 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestXHTMLFragment(t *testing.T) {
+	tests := []struct {
+		fragment string
+		want     string
+	}{
+		{"<p>a<br>b</p>", "<p>a<br/>b</p>"},
+		{"<hr />", "<hr />"},
+		{`<img src="a.png" alt="图">`, `<img src="a.png" alt="图"/>`},
+		{`<audio controls src="a.mp3"></audio>`, `<audio controls="controls" src="a.mp3"></audio>`},
+		{"<p>&lt;br&gt;\x00\x1b</p>", "<p>&lt;br&gt;</p>"},
+	}
+	for _, tt := range tests {
+		if got := xhtmlFragment(tt.fragment); got != tt.want {
+			t.Errorf("xhtmlFragment(%q) = %q, want %q", tt.fragment, got, tt.want)
+		}
+	}
+}
+
+func TestEPUBWriter(t *testing.T) {
+	day := func(d int) float64 { return float64(time.Date(2024, 3, d, 9, 0, 0, 0, time.UTC).Unix()) }
+	convs := []Conversation{
+		{ID: "a", Title: "部署 <nginx>", Layout: LayoutByDay, Messages: []Message{
+			{Role: "user", Text: "第一天\x00", CreateTime: day(1)},
+			{Role: "assistant", Text: "第二天", CreateTime: day(2)},
+		}},
+		{ID: "b", Messages: []Message{{Role: "user", Text: "你好"}}},
+	}
+	tests := []struct {
+		name         string
+		title        string
+		wantTitle    string
+		wantSections int
+	}{
+		{name: "默认书名", title: " ", wantTitle: "ChatGPT 对话", wantSections: 2},
+		{name: "指定书名", title: "运维笔记", wantTitle: "运维笔记", wantSections: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			e, err := NewEPUBWriter(&buf, tt.title, "UTC", HTMLOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, conv := range convs {
+				if err := e.AddChapter(conv); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for i := 0; i < 2; i++ {
+				if err := e.AddResource("attachments/a.png", []byte("\x89PNG\r\n\x1a\n")); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if e.Chapters() != len(convs) || !e.HasResource("attachments/a.png") || len(e.resources) != 1 {
+				t.Fatalf("Chapters() = %d resources = %+v", e.Chapters(), e.resources)
+			}
+			if err := e.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			files := readEPUB(t, buf.Bytes())
+			if files["mimetype"] != "application/epub+zip" {
+				t.Errorf("mimetype = %q", files["mimetype"])
+			}
+			for _, name := range []string{"META-INF/container.xml", epubPackagePath, epubNavPath, epubNCXPath, epubStylePath, "chapter-001.xhtml", "chapter-002.xhtml"} {
+				content, ok := files[name]
+				if !ok {
+					t.Errorf("缺少 %s", name)
+					continue
+				}
+				if strings.HasSuffix(name, ".css") {
+					continue
+				}
+				if err := xml.Unmarshal([]byte(content), new(struct{})); err != nil {
+					t.Errorf("%s 不是合法的 XML: %v", name, err)
+				}
+			}
+			opf := files[epubPackagePath]
+			if !strings.Contains(opf, "<dc:title>"+tt.wantTitle+"</dc:title>") || !strings.Contains(opf, `href="attachments/a.png" media-type="image/png"`) {
+				t.Errorf("content.opf = %s", opf)
+			}
+			nav := files[epubNavPath]
+			if got := strings.Count(nav, `href="chapter-001.xhtml#group-`); got != tt.wantSections {
+				t.Errorf("目录中的分组 = %d, want %d: %s", got, tt.wantSections, nav)
+			}
+			if !strings.Contains(nav, "部署 &lt;nginx&gt;") || !strings.Contains(nav, "(未命名对话)") {
+				t.Errorf("nav.xhtml = %s", nav)
+			}
+		})
+	}
+}
+
+func TestEPUBIdentifier(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []string
+		same bool
+	}{
+		{name: "与对话顺序无关", a: []string{"a", "b"}, b: []string{"b", "a"}, same: true},
+		{name: "对话不同", a: []string{"a", "b"}, b: []string{"a", "c"}, same: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, second := (&EPUBWriter{ids: tt.a}).identifier(), (&EPUBWriter{ids: tt.b}).identifier()
+			if (first == second) != tt.same {
+				t.Errorf("identifier() = %s, %s, same %v", first, second, tt.same)
+			}
+			if !strings.HasPrefix(first, "urn:uuid:") || first[len("urn:uuid:")+14] != '5' {
+				t.Errorf("identifier() = %s, want UUID v5", first)
+			}
+		})
+	}
+}
+
+func readEPUB(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first := reader.File[0]; first.Name != "mimetype" || first.Method != zip.Store {
+		t.Errorf("第一个文件 = %s (method %d), want 未压缩的 mimetype", first.Name, first.Method)
+	}
+	files := make(map[string]string)
+	for _, file := range reader.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[file.Name] = string(content)
+	}
+	return files
+}
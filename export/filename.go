@@ -0,0 +1,193 @@
+package export
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// 按创建日期划分目录的方式, 见 FilenameOptions.Hierarchy。
+const (
+	// HierarchyFlat 所有文件放在同一目录, 为默认方式。
+	HierarchyFlat = "flat"
+	// HierarchyMonth 按创建时间放入 YYYY/MM/ 目录。
+	HierarchyMonth = "month"
+	// HierarchyDay 按创建时间放入 YYYY/MM/DD/ 目录。
+	HierarchyDay = "day"
+)
+
+// undatedDir 是缺少创建时间的对话所在的目录。
+const undatedDir = "日期未知"
+
+// NormalizeHierarchy 规范化目录划分方式, 接受 "YYYY/MM" 与 "YYYY/MM/DD" 的写法, 无法识别时返回 HierarchyFlat。
+func NormalizeHierarchy(value string) string {
+	switch strings.ToLower(strings.Trim(strings.TrimSpace(value), "/")) {
+	case HierarchyMonth, "yyyy/mm":
+		return HierarchyMonth
+	case HierarchyDay, "yyyy/mm/dd":
+		return HierarchyDay
+	default:
+		return HierarchyFlat
+	}
+}
+
+var filenameReplacer = strings.NewReplacer(
+	"/", "-",
+	"\\", "-",
+	":", "-",
+	"*", "-",
+	"?", "-",
+	"\"", "",
+	"<", "(",
+	">", ")",
+	"|", "-",
+	"\n", " ",
+	"\r", " ",
+	"\t", " ",
+)
+
+// FilenameOptions 是生成文件名的兼容性选项。
+type FilenameOptions struct {
+	// StripEmoji 去掉标题中的 emoji, 部分文件系统与同步工具无法处理这些字符。
+	StripEmoji bool
+	// Hierarchy 按创建日期把文件放入子目录 (见 NormalizeHierarchy), Location 为计算日期的时区, 为空时使用本地时区。
+	Hierarchy string
+	Location  *time.Location
+}
+
+// hierarchyDir 返回对话按 opts.Hierarchy 所在的目录, 平铺时为空。
+func hierarchyDir(conv Conversation, opts FilenameOptions) string {
+	hierarchy := NormalizeHierarchy(opts.Hierarchy)
+	if hierarchy == HierarchyFlat {
+		return ""
+	}
+	if conv.CreateTime <= 0 {
+		return undatedDir
+	}
+	sec := int64(conv.CreateTime)
+	layout := "2006/01"
+	if hierarchy == HierarchyDay {
+		layout = "2006/01/02"
+	}
+	loc := opts.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	return time.Unix(sec, 0).In(loc).Format(layout)
+}
+
+// ConversationFilename 生成 "<标题>-<对话 ID>.md" 形式的文件名, used 用于为重名文件追加序号。
+func ConversationFilename(conv Conversation, used map[string]int) string {
+	return ConversationFilenameWith(conv, used, FilenameOptions{})
+}
+
+// ConversationFilenameWith 与 ConversationFilename 相同, 额外按 opts 处理标题。
+func ConversationFilenameWith(conv Conversation, used map[string]int, opts FilenameOptions) string {
+	title := conv.Title
+	if opts.StripEmoji {
+		title = StripEmoji(title)
+	}
+	title = sanitizeFilenamePart(firstNonEmpty(title, "对话"))
+	idPart := sanitizeFilenamePart(conv.ID)
+	base := strings.TrimSpace(title)
+	if idPart != "" {
+		base = strings.TrimSpace(base + "-" + idPart)
+	}
+	if base == "" {
+		base = "conversation"
+	}
+	base = trimFilename(base, 120)
+	if base == "" {
+		base = "conversation"
+	}
+
+	if dir := hierarchyDir(conv, opts); dir != "" {
+		base = dir + "/" + base
+	}
+	// 属于项目的对话放在以项目命名的目录下, 日期目录在其之内。
+	if project := trimFilename(sanitizeFilenamePart(conv.Project), 60); project != "" {
+		base = project + "/" + base
+	}
+	name := base + ".md"
+	if used == nil {
+		return name
+	}
+	if _, ok := used[name]; !ok {
+		used[name] = 1
+		return name
+	}
+	index := used[name]
+	for {
+		index++
+		candidate := fmt.Sprintf("%s-%d.md", base, index)
+		if _, exists := used[candidate]; !exists {
+			used[name] = index
+			used[candidate] = 1
+			return candidate
+		}
+	}
+}
+
+func sanitizeFilenamePart(input string) string {
+	trimmed := strings.TrimSpace(input)
+	if trimmed == "" {
+		return ""
+	}
+	trimmed = filenameReplacer.Replace(trimmed)
+	trimmed = strings.Join(strings.Fields(trimmed), " ")
+	trimmed = strings.Trim(trimmed, ".-_ ")
+	return trimmed
+}
+
+func trimFilename(input string, maxRunes int) string {
+	if maxRunes <= 0 {
+		return ""
+	}
+	runes := []rune(input)
+	if len(runes) <= maxRunes {
+		return input
+	}
+	return strings.TrimSpace(string(runes[:maxRunes]))
+}
+
+// RelativePath 返回从 dir 目录指向 target 的相对路径, 两者都是导出包内以 "/" 分隔的路径。
+func RelativePath(dir, target string) string {
+	dir = path.Clean(dir)
+	if dir == "." || target == "" {
+		return target
+	}
+	from := strings.Split(dir, "/")
+	to := strings.Split(path.Clean(target), "/")
+	common := 0
+	for common < len(from) && common < len(to)-1 && from[common] == to[common] {
+		common++
+	}
+	parts := make([]string, 0, len(from)-common+len(to)-common)
+	for range from[common:] {
+		parts = append(parts, "..")
+	}
+	return path.Join(append(parts, to[common:]...)...)
+}
+
+// RebasePaths 把对话中指向导出包内文件的路径 (资源、上传文件与相关对话) 改为相对 filename 所在目录,
+// 用于按日期划分目录后文件之间仍能互相跳转。
+func RebasePaths(conv *Conversation, filename string) {
+	dir := path.Dir(filename)
+	if dir == "." {
+		return
+	}
+	for _, msgs := range [][]Message{conv.Messages, conv.Removed} {
+		for i := range msgs {
+			for j := range msgs[i].Assets {
+				msgs[i].Assets[j].Path = RelativePath(dir, msgs[i].Assets[j].Path)
+			}
+		}
+	}
+	for i := range conv.Attachments {
+		conv.Attachments[i].Path = RelativePath(dir, conv.Attachments[i].Path)
+	}
+	for i := range conv.Related {
+		conv.Related[i].Path = RelativePath(dir, conv.Related[i].Path)
+	}
+}
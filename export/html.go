@@ -0,0 +1,399 @@
+package export
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+
+	"github.com/Devoty/openai-backup/logging"
+)
+
+const (
+	HTMLThemeLight = "light"
+	HTMLThemeDark  = "dark"
+	HTMLThemePrint = "print"
+)
+
+const htmlBaseCSS = `
+body { max-width: 860px; margin: 0 auto; padding: 2rem 1.25rem; font: 15px/1.65 -apple-system, "Segoe UI", "PingFang SC", "Microsoft YaHei", sans-serif; }
+header.meta { font-size: 0.9em; margin-bottom: 2rem; }
+header.meta ul { list-style: none; padding: 0; }
+article.message { margin: 1.5rem 0; padding: 1rem 1.25rem; border-radius: 8px; }
+article.message h2, article.message h3 { font-size: 0.95em; margin: 0 0 0.75rem; text-transform: uppercase; letter-spacing: 0.03em; }
+pre { padding: 0.75rem 1rem; border-radius: 6px; overflow-x: auto; }
+code { font-family: "SFMono-Regular", Consolas, "Liberation Mono", monospace; font-size: 0.92em; }
+.references { font-size: 0.9em; }
+article.message .change { font-size: 0.85em; padding: 0 0.4em; margin-left: 0.3em; border-radius: 4px; text-transform: none; }
+article.message.change-added { border-left: 4px solid #2da44e; }
+article.message.change-edited { border-left: 4px solid #bf8700; }
+article.message.change-removed { border-left: 4px solid #cf222e; opacity: 0.75; }
+figure.qrcode { float: right; margin: 0 0 1rem 1rem; text-align: center; }
+figure.qrcode svg { display: block; margin: 0 auto; }
+figure.qrcode figcaption { max-width: 160px; font-size: 0.75em; word-break: break-all; }
+section.group > h2 { font-size: 1.1em; margin: 2rem 0 0.5rem; padding-bottom: 0.25rem; border-bottom: 1px solid #d0d7de; }
+hr.topic-divider { margin: 3rem 0 1rem; border: none; border-top: 3px double #d0d7de; }
+nav.toc { font-size: 0.9em; margin-bottom: 2rem; }
+nav.toc ul { padding-left: 1.25rem; margin: 0.25rem 0; }
+`
+
+var htmlThemeCSS = map[string]string{
+	HTMLThemeLight: `
+body { background: #ffffff; color: #1f2328; }
+header.meta { color: #59636e; }
+article.message { background: #f6f8fa; }
+article.message.user { background: #eef4ff; }
+pre { background: #eaeef2; }
+a { color: #0969da; }
+`,
+	HTMLThemeDark: `
+body { background: #0d1117; color: #e6edf3; }
+header.meta { color: #9198a1; }
+article.message { background: #161b22; }
+article.message.user { background: #1c2a3f; }
+pre { background: #010409; }
+a { color: #4493f8; }
+`,
+	HTMLThemePrint: `
+body { background: #ffffff; color: #000000; max-width: none; font-family: Georgia, "Songti SC", "SimSun", serif; }
+article.message { border: 1px solid #999999; page-break-inside: avoid; }
+pre { border: 1px solid #cccccc; white-space: pre-wrap; }
+a { color: #000000; }
+@media print { body { padding: 0; } a::after { content: " (" attr(href) ")"; font-size: 0.85em; } nav.toc a::after { content: none; } }
+`,
+}
+
+// NormalizeHTMLTheme 将主题名收敛为内置主题之一, 未知值回落到 light。
+func NormalizeHTMLTheme(value string) string {
+	theme := strings.ToLower(strings.TrimSpace(value))
+	if _, ok := htmlThemeCSS[theme]; ok {
+		return theme
+	}
+	return HTMLThemeLight
+}
+
+// htmlStylesheet 组合基础样式、主题样式与用户自定义 CSS, 自定义部分最后生效。
+func htmlStylesheet(theme, customCSS string) string {
+	var b strings.Builder
+	b.WriteString(htmlBaseCSS)
+	b.WriteString(htmlThemeCSS[NormalizeHTMLTheme(theme)])
+	if css := strings.TrimSpace(customCSS); css != "" {
+		// 防止自定义内容提前闭合 style 标签。
+		b.WriteString(strings.ReplaceAll(css, "</", "<\\/"))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// HTMLOptions 是 HTML 导出的外观与内容处理选项。
+type HTMLOptions struct {
+	Theme     string
+	CustomCSS string
+	MathMode  string
+	// RenderDiagrams 为 true 时 mermaid/plantuml 代码块经 RenderSVG 渲染为 SVG 图片, 源码折叠保留。
+	RenderDiagrams bool
+	// QRCode 非空时页头嵌入二维码, 取值见 NormalizeQRCodeMode; ArchivePath 为对话在压缩包中的路径。
+	QRCode      string
+	ArchivePath string
+	// RenderSVG 在导出时渲染公式与图表, 结果以 data URI 嵌入页面; 为空或渲染失败时保留源码。
+	RenderSVG SVGRenderer
+}
+
+// SVGRenderer 把公式 (kind 为 SVGMath) 或图表渲染为 SVG。
+type SVGRenderer func(kind, source string) ([]byte, error)
+
+// RenderHTML 输出单个对话的独立 HTML 页面, 内联样式, 可直接用浏览器打印为 PDF。
+func RenderHTML(conv Conversation, timezone string, opts HTMLOptions) string {
+	theme := opts.Theme
+	title := firstNonEmpty(conv.Title, "(未命名对话)")
+
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html lang=\"zh-CN\">\n<head>\n<meta charset=\"utf-8\">\n")
+	b.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+	b.WriteString(fmt.Sprintf("<title>%s</title>\n", html.EscapeString(title)))
+	b.WriteString("<style>")
+	b.WriteString(htmlStylesheet(theme, opts.CustomCSS))
+	b.WriteString("</style>\n</head>\n")
+	b.WriteString(fmt.Sprintf("<body class=\"theme-%s\">\n", NormalizeHTMLTheme(theme)))
+	b.WriteString(RenderHTMLFragment(conv, timezone, opts))
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
+
+// RenderHTMLFragment 输出对话正文的 HTML 片段, 不含文档头与样式, 供自带编辑器的笔记应用导入。
+func RenderHTMLFragment(conv Conversation, timezone string, opts HTMLOptions) string {
+	loc := ResolveLocation(timezone)
+	title := firstNonEmpty(conv.Title, "(未命名对话)")
+
+	var b strings.Builder
+	b.WriteString("<header class=\"meta\">\n")
+	b.WriteString(renderQRCodeHTML(conv, opts))
+	b.WriteString(fmt.Sprintf("<h1>%s</h1>\n<ul>\n", html.EscapeString(title)))
+	b.WriteString(fmt.Sprintf("<li>对话ID: <code>%s</code></li>\n", html.EscapeString(conv.ID)))
+	if link := ConversationURL(conv.ID); link != "" {
+		b.WriteString(fmt.Sprintf("<li>原始对话: <a href=\"%s\">%s</a></li>\n", link, link))
+	}
+	tf := NewTimeFormatter(conv, loc)
+	b.WriteString(fmt.Sprintf("<li>创建时间: %s</li>\n", tf.Format(conv.CreateTime)))
+	b.WriteString(fmt.Sprintf("<li>最近更新: %s</li>\n", tf.Format(conv.UpdateTime)))
+	if conv.ShowStats {
+		b.WriteString(fmt.Sprintf("<li>统计: %s</li>\n", conversationStatsSummary(conv)))
+	}
+	b.WriteString(renderAttachmentsHTML(conv.Attachments))
+	b.WriteString("</ul>\n</header>\n")
+
+	if len(conv.Context) > 0 {
+		b.WriteString("<section class=\"context\">\n<h2>上下文</h2>\n")
+		for _, entry := range conv.Context {
+			b.WriteString(fmt.Sprintf("<h3>%s</h3>\n", html.EscapeString(entry.Label)))
+			b.WriteString(renderTextHTML(entry.Text, opts))
+		}
+		b.WriteString("</section>\n")
+	}
+
+	groups := groupMessages(conv.Messages, conv.Layout, tf)
+	toc := conv.WantsTOC()
+	if toc {
+		b.WriteString(renderTOCHTML(tocEntries(groups, tf)))
+	}
+	for idx, group := range groups {
+		tag := "h2"
+		if group.Divider {
+			b.WriteString("<hr class=\"topic-divider\">\n")
+		}
+		if group.Heading != "" {
+			// 分组锚点供目录与 EPUB 的章节导航跳转。
+			b.WriteString(fmt.Sprintf("<section class=\"group\" id=\"%s\">\n<h2>%s</h2>\n", groupAnchor(idx), html.EscapeString(group.Heading)))
+			tag = "h3"
+		}
+		for offset, msg := range group.Messages {
+			num := group.Start + offset + 1
+			anchor := ""
+			if toc {
+				anchor = messageAnchor(num)
+			}
+			renderMessageHTML(&b, num, msg, tag, anchor, tf, opts, conv.ShowStats)
+		}
+		if group.Heading != "" {
+			b.WriteString("</section>\n")
+		}
+	}
+
+	b.WriteString(renderRemovedHTML(conv.Removed, tf, opts))
+	if len(conv.Related) > 0 {
+		b.WriteString("<section class=\"related\">\n<h2>相关对话</h2>\n<ul>\n")
+		for _, item := range conv.Related {
+			label := html.EscapeString(firstNonEmpty(item.Title, item.ID))
+			href := item.URL
+			if item.Path != "" {
+				href = (&url.URL{Path: item.Path}).EscapedPath()
+			}
+			if href != "" {
+				b.WriteString(fmt.Sprintf("<li><a href=\"%s\">%s</a></li>\n", html.EscapeString(href), label))
+			} else {
+				b.WriteString(fmt.Sprintf("<li>%s (<code>%s</code>)</li>\n", label, html.EscapeString(item.ID)))
+			}
+		}
+		b.WriteString("</ul>\n</section>\n")
+	}
+	return b.String()
+}
+
+// renderMessageHTML 输出一条消息的 <article>, num 为全文中的序号, tag 为标题标签 (h2 或分组时的 h3),
+// anchor 不为空时作为 <article> 的 id 供目录跳转, showStats 为 true 时标题带上词数。
+func renderMessageHTML(b *strings.Builder, num int, msg Message, tag, anchor string, tf TimeFormatter, opts HTMLOptions, showStats bool) {
+	role := strings.ToLower(firstNonEmpty(msg.Role, "unknown"))
+	class, badge := role, ""
+	if change := changeLabel(msg.Change); change != "" {
+		class += " change-" + msg.Change
+		badge = fmt.Sprintf(" <span class=\"change\">%s</span>", change)
+	}
+	id := ""
+	if anchor != "" {
+		id = fmt.Sprintf(" id=\"%s\"", anchor)
+	}
+	b.WriteString(fmt.Sprintf("<article class=\"message %s\"%s>\n", html.EscapeString(class), id))
+	source := ""
+	if msg.Source != "" {
+		source = " · 来自 " + html.EscapeString(msg.Source)
+	}
+	b.WriteString(fmt.Sprintf("<%s>%d. %s · %s%s%s%s</%s>\n", tag, num, html.EscapeString(strings.ToUpper(role)), tf.Format(msg.CreateTime), messageStatsSuffix(msg, showStats), source, badge, tag))
+	if msg.Text != "" || len(msg.Assets) == 0 {
+		b.WriteString(renderTextHTML(firstNonEmpty(msg.Text, "(空内容)"), opts))
+	}
+	b.WriteString(renderAssetsHTML(msg.Assets))
+	if len(msg.References) > 0 {
+		b.WriteString("<ul class=\"references\">\n")
+		for _, ref := range msg.References {
+			label := firstNonEmpty(strings.TrimSpace(ref.Title), ref.URL)
+			b.WriteString(fmt.Sprintf("<li><a href=\"%s\">%s</a>", html.EscapeString(ref.URL), html.EscapeString(label)))
+			if source := strings.TrimSpace(ref.Source); source != "" {
+				b.WriteString(" · " + html.EscapeString(source))
+			}
+			b.WriteString("</li>\n")
+		}
+		b.WriteString("</ul>\n")
+	}
+	b.WriteString("</article>\n")
+}
+
+// renderTextHTML 将消息文本转为 HTML: 围栏代码块保持原样, 其余按空行分段。
+// 按选项把公式与图表预渲染为图片。
+func renderTextHTML(text string, opts HTMLOptions) string {
+	renderMath := NormalizeMathMode(opts.MathMode) == MathImage && opts.RenderSVG != nil
+	var b strings.Builder
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) == 0 {
+			return
+		}
+		escaped := make([]string, 0, len(paragraph))
+		for _, line := range paragraph {
+			if renderMath {
+				escaped = append(escaped, renderInlineMathHTML(line, opts.RenderSVG))
+			} else {
+				escaped = append(escaped, html.EscapeString(line))
+			}
+		}
+		b.WriteString("<p>" + strings.Join(escaped, "<br>\n") + "</p>\n")
+		paragraph = nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			flush()
+			lang := strings.TrimSpace(strings.TrimPrefix(trimmed, "```"))
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, lines[i])
+			}
+			class := ""
+			if lang != "" {
+				class = fmt.Sprintf(" class=\"language-%s\"", html.EscapeString(lang))
+			}
+			source := fmt.Sprintf("<pre><code%s>%s</code></pre>", class, html.EscapeString(strings.Join(code, "\n")))
+			if kind := DiagramType(lang); opts.RenderDiagrams && opts.RenderSVG != nil && kind != "" {
+				svg, err := opts.RenderSVG(kind, strings.Join(code, "\n"))
+				if err == nil {
+					b.WriteString(fmt.Sprintf("<figure class=\"diagram\"><img src=\"%s\" alt=\"%s 图表\"></figure>\n", svgDataURI(svg), kind))
+					b.WriteString(fmt.Sprintf("<details><summary>%s 源码</summary>%s</details>\n", kind, source))
+					continue
+				}
+				logging.Infof("%s 图表渲染失败, 保留源码: %v", kind, err)
+			}
+			b.WriteString(source + "\n")
+			continue
+		}
+		if renderMath && (strings.HasPrefix(trimmed, "$$") || strings.HasPrefix(trimmed, `\[`)) {
+			closer := "$$"
+			if strings.HasPrefix(trimmed, `\[`) {
+				closer = `\]`
+			}
+			end := -1
+			for j := i; j < len(lines); j++ {
+				candidate := strings.TrimSpace(lines[j])
+				if j == i {
+					// 起止符号长度相同, 跳过起始符号后再判断是否在同一行闭合。
+					candidate = candidate[len(closer):]
+				}
+				if strings.HasSuffix(candidate, closer) {
+					end = j
+					break
+				}
+			}
+			if end >= 0 {
+				if expr, ok := BlockMathExpression(strings.Join(lines[i:end+1], "\n")); ok {
+					flush()
+					b.WriteString(fmt.Sprintf("<p class=\"math\">%s</p>\n", mathImageHTML(expr, opts.RenderSVG)))
+					i = end
+					continue
+				}
+			}
+		}
+		if level := markdownHeadingLevel(trimmed); level > 0 {
+			flush()
+			// 消息内标题降级输出, 避免与页面结构标题冲突。
+			tag := fmt.Sprintf("h%d", min(level+2, 6))
+			b.WriteString(fmt.Sprintf("<%s>%s</%s>\n", tag, html.EscapeString(strings.TrimSpace(trimmed[level:])), tag))
+			continue
+		}
+		if trimmed == "" {
+			flush()
+			continue
+		}
+		paragraph = append(paragraph, line)
+	}
+	flush()
+	return b.String()
+}
+
+func renderInlineMathHTML(line string, render SVGRenderer) string {
+	var b strings.Builder
+	for _, span := range SplitInlineMath(line) {
+		if span.Math {
+			b.WriteString(mathImageHTML(span.Text, render))
+		} else {
+			b.WriteString(html.EscapeString(span.Text))
+		}
+	}
+	return b.String()
+}
+
+// mathImageHTML 把公式渲染为内嵌的 SVG 图片, 渲染失败时以代码样式输出表达式。
+func mathImageHTML(expr string, render SVGRenderer) string {
+	escaped := html.EscapeString(expr)
+	svg, err := render(SVGMath, expr)
+	if err != nil {
+		logging.Infof("公式渲染失败, 保留原文: %v", err)
+		return fmt.Sprintf("<code class=\"math\">%s</code>", escaped)
+	}
+	return fmt.Sprintf("<img class=\"math\" src=\"%s\" alt=\"%s\" title=\"%s\">", svgDataURI(svg), escaped, escaped)
+}
+
+// svgDataURI 把 SVG 编码为可直接用作图片地址的 data URI。
+func svgDataURI(svg []byte) string {
+	return "data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString(svg)
+}
+
+func markdownHeadingLevel(line string) int {
+	level := 0
+	for level < len(line) && level < 6 && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level >= len(line) || line[level] != ' ' {
+		return 0
+	}
+	return level
+}
+
+func renderAssetsHTML(assets []Asset) string {
+	var b strings.Builder
+	for _, asset := range assets {
+		label := html.EscapeString(AssetKindLabel(asset.Kind))
+		if asset.Path == "" {
+			b.WriteString(fmt.Sprintf("<p class=\"asset\">%s: <code>%s</code></p>\n", label, html.EscapeString(asset.Pointer)))
+			if asset.Prompt != "" {
+				b.WriteString(fmt.Sprintf("<p class=\"asset\">提示词: %s</p>\n", html.EscapeString(asset.Prompt)))
+			}
+			continue
+		}
+		src := html.EscapeString((&url.URL{Path: asset.Path}).EscapedPath())
+		switch asset.Kind {
+		case AssetAudio:
+			b.WriteString(fmt.Sprintf("<p class=\"asset\"><audio controls src=\"%s\"></audio></p>\n", src))
+		case AssetImage:
+			b.WriteString(fmt.Sprintf("<figure class=\"asset\"><img src=\"%s\" alt=\"%s\" style=\"max-width:100%%\">", src, html.EscapeString(asset.Prompt)))
+			if asset.Prompt != "" {
+				b.WriteString(fmt.Sprintf("<figcaption>提示词: %s</figcaption>", html.EscapeString(asset.Prompt)))
+			}
+			b.WriteString("</figure>\n")
+		default:
+			b.WriteString(fmt.Sprintf("<p class=\"asset\"><a href=\"%s\">%s</a></p>\n", src, label))
+		}
+	}
+	return b.String()
+}
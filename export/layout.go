@@ -0,0 +1,191 @@
+package export
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 消息在导出文档中的排列方式。
+const (
+	// LayoutChronological 按时间顺序逐条输出, 为默认方式。
+	LayoutChronological = "chronological"
+	// LayoutByDay 按消息日期分组, 每组前输出日期标题。
+	LayoutByDay = "by_day"
+	// LayoutQA 把用户提问与随后的回答合为一节。
+	LayoutQA = "qa"
+	// LayoutTopics 在话题转换处分节, 见 topicGroups。
+	LayoutTopics = "topics"
+)
+
+const (
+	// topicGap 是用户消息与上一条消息间隔达到该时长时视为换了话题。
+	topicGap = 3 * time.Hour
+	// topicMinMessages 是按回答中的标题分节时, 当前一节至少已有的消息数, 避免每个带标题的回答都单独成节。
+	topicMinMessages = 4
+	// topicTitleLength 是以用户提问作为话题标题时保留的字符数。
+	topicTitleLength = 40
+)
+
+// topicHeadingPattern 匹配回答开头的一级或二级 Markdown 标题。
+var topicHeadingPattern = regexp.MustCompile(`^#{1,2}\s+(.+?)\s*#*$`)
+
+// NormalizeLayout 规范化排列方式, 无法识别时返回 LayoutChronological。
+func NormalizeLayout(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case LayoutByDay, "day", "daily":
+		return LayoutByDay
+	case LayoutQA, "q&a", "pairs":
+		return LayoutQA
+	case LayoutTopics, "topic", "sections":
+		return LayoutTopics
+	default:
+		return LayoutChronological
+	}
+}
+
+// MessageGroup 是排列后的一组消息, Heading 为空时消息不分组, 直接作为二级标题输出。
+type MessageGroup struct {
+	Heading string
+	// Divider 为 true 时在组前输出分隔线, 用于标出话题转换。
+	Divider bool
+	// Start 为组内第一条消息在 conv.Messages 中的下标, 消息序号按全文连续编号。
+	Start    int
+	Messages []Message
+}
+
+// GroupMessages 按对话的 Layout 把消息分组, 与 Markdown/HTML 导出的分节一致, 供自行构建页面结构的
+// 目标 (如 Notion) 使用。分组标题中的时间按 tf 输出。
+func (c Conversation) GroupMessages(tf TimeFormatter) []MessageGroup {
+	return groupMessages(c.Messages, c.Layout, tf)
+}
+
+// groupMessages 按 layout 把消息分组, 组内与组间都保持原有顺序。
+func groupMessages(msgs []Message, layout string, tf TimeFormatter) []MessageGroup {
+	switch NormalizeLayout(layout) {
+	case LayoutByDay:
+		var groups []MessageGroup
+		for idx, msg := range msgs {
+			day := messageDay(msg, tf.loc)
+			if len(groups) == 0 || groups[len(groups)-1].Heading != day {
+				groups = append(groups, MessageGroup{Heading: day, Start: idx})
+			}
+			groups[len(groups)-1].Messages = append(groups[len(groups)-1].Messages, msg)
+		}
+		return groups
+	case LayoutQA:
+		var groups []MessageGroup
+		for idx, msg := range msgs {
+			if len(groups) == 0 || strings.EqualFold(msg.Role, "user") {
+				groups = append(groups, MessageGroup{Start: idx})
+			}
+			groups[len(groups)-1].Messages = append(groups[len(groups)-1].Messages, msg)
+		}
+		questions := 0
+		for i := range groups {
+			first := groups[i].Messages[0]
+			if !strings.EqualFold(first.Role, "user") {
+				// 第一条用户消息之前的消息 (如 GPTs 的开场白) 单独成节。
+				groups[i].Heading = "对话开头 · " + tf.Format(first.CreateTime)
+				continue
+			}
+			questions++
+			groups[i].Heading = fmt.Sprintf("问答 %d · %s", questions, tf.Format(first.CreateTime))
+		}
+		return groups
+	case LayoutTopics:
+		return topicGroups(msgs)
+	default:
+		return []MessageGroup{{Messages: msgs}}
+	}
+}
+
+// topicGroups 在话题转换处把消息分节: 用户消息距上一条消息超过 topicGap, 或当前一节已有
+// topicMinMessages 条消息且随后的回答以一级、二级标题开头时开始新的一节。标题取回答开头的标题,
+// 没有时取用户提问的第一行。
+func topicGroups(msgs []Message) []MessageGroup {
+	var (
+		groups []MessageGroup
+		last   float64
+	)
+	for idx, msg := range msgs {
+		if len(groups) == 0 || topicStarts(msgs, idx, last, len(groups[len(groups)-1].Messages)) {
+			groups = append(groups, MessageGroup{Start: idx, Divider: len(groups) > 0})
+		}
+		groups[len(groups)-1].Messages = append(groups[len(groups)-1].Messages, msg)
+		if msg.CreateTime > 0 {
+			last = msg.CreateTime
+		}
+	}
+	for i := range groups {
+		groups[i].Heading = fmt.Sprintf("话题 %d · %s", i+1, topicTitle(groups[i].Messages))
+	}
+	return groups
+}
+
+// topicStarts 判断第 idx 条消息是否开始新的话题, last 为之前最后一条消息的时间, current 为当前一节的消息数。
+func topicStarts(msgs []Message, idx int, last float64, current int) bool {
+	msg := msgs[idx]
+	if !strings.EqualFold(msg.Role, "user") {
+		return false
+	}
+	if last > 0 && msg.CreateTime > 0 && msg.CreateTime-last >= topicGap.Seconds() {
+		return true
+	}
+	if current < topicMinMessages {
+		return false
+	}
+	_, ok := replyHeading(msgs[idx+1:])
+	return ok
+}
+
+// replyHeading 返回提问之后第一条回答开头的标题, 遇到下一条用户消息时停止。
+func replyHeading(msgs []Message) (string, bool) {
+	for _, msg := range msgs {
+		if strings.EqualFold(msg.Role, "user") {
+			return "", false
+		}
+		if strings.EqualFold(msg.Role, "assistant") && strings.TrimSpace(msg.Text) != "" {
+			first, _, _ := strings.Cut(strings.TrimSpace(msg.Text), "\n")
+			match := topicHeadingPattern.FindStringSubmatch(strings.TrimSpace(first))
+			if match == nil {
+				return "", false
+			}
+			title := strings.TrimSpace(strings.Trim(match[1], "*_"))
+			return title, title != ""
+		}
+	}
+	return "", false
+}
+
+// topicTitle 返回一节的标题: 第一条提问的回答以标题开头时使用该标题, 否则取提问的第一行。
+func topicTitle(msgs []Message) string {
+	for idx, msg := range msgs {
+		if !strings.EqualFold(msg.Role, "user") {
+			continue
+		}
+		if title, ok := replyHeading(msgs[idx+1:]); ok {
+			return title
+		}
+		first, _, _ := strings.Cut(strings.TrimSpace(msg.Text), "\n")
+		runes := []rune(strings.TrimSpace(first))
+		if len(runes) > topicTitleLength {
+			return string(runes[:topicTitleLength]) + "…"
+		}
+		if len(runes) > 0 {
+			return string(runes)
+		}
+		break
+	}
+	return "对话开头"
+}
+
+// messageDay 返回消息所在的日期, 缺少创建时间时归入"日期未知"。
+func messageDay(msg Message, loc *time.Location) string {
+	stamp := FormatTimestamp(msg.CreateTime, loc)
+	if stamp == "-" {
+		return "日期未知"
+	}
+	return stamp[:len("2006-01-02")]
+}
@@ -0,0 +1,139 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// topicConversation 包含三段提问: 第二段距上一条消息超过 topicGap, 第三段的回答以标题开头且当前一节
+// 已有足够的消息, 应分为三个话题。
+func topicConversation() Conversation {
+	start := float64(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC).Unix())
+	later := start + 4*time.Hour.Seconds()
+	return Conversation{
+		ID:     "conv-topics",
+		Title:  "话题",
+		Layout: LayoutTopics,
+		Messages: []Message{
+			{Role: "user", Text: "如何配置 Nginx?\n细节如下", CreateTime: start},
+			{Role: "assistant", Text: "编辑 nginx.conf。", CreateTime: start + 60},
+			{Role: "user", Text: "Go 的切片如何扩容?", CreateTime: later},
+			{Role: "assistant", Text: "按容量翻倍。", CreateTime: later + 60},
+			{Role: "user", Text: "再问一个", CreateTime: later + 120},
+			{Role: "assistant", Text: "好的。", CreateTime: later + 180},
+			{Role: "user", Text: "介绍一下 Rust", CreateTime: later + 240},
+			{Role: "assistant", Text: "## Rust 简介\n\n一门系统语言。", CreateTime: later + 300},
+		},
+	}
+}
+
+func TestGroupMessagesTopics(t *testing.T) {
+	conv := topicConversation()
+	groups := conv.GroupMessages(NewTimeFormatter(conv, time.UTC))
+	want := []struct {
+		heading string
+		divider bool
+		start   int
+		count   int
+	}{
+		{"话题 1 · 如何配置 Nginx?", false, 0, 2},
+		{"话题 2 · Go 的切片如何扩容?", true, 2, 4},
+		{"话题 3 · Rust 简介", true, 6, 2},
+	}
+	if len(groups) != len(want) {
+		t.Fatalf("分组数 = %d, want %d", len(groups), len(want))
+	}
+	for i, w := range want {
+		g := groups[i]
+		if g.Heading != w.heading || g.Divider != w.divider || g.Start != w.start || len(g.Messages) != w.count {
+			t.Errorf("第 %d 组 = {%q %v %d %d}, want {%q %v %d %d}", i+1, g.Heading, g.Divider, g.Start, len(g.Messages), w.heading, w.divider, w.start, w.count)
+		}
+	}
+}
+
+func TestNormalizeLayout(t *testing.T) {
+	tests := map[string]string{
+		"":         LayoutChronological,
+		"unknown":  LayoutChronological,
+		"Daily":    LayoutByDay,
+		"q&a":      LayoutQA,
+		" topic ":  LayoutTopics,
+		"sections": LayoutTopics,
+	}
+	for input, want := range tests {
+		if got := NormalizeLayout(input); got != want {
+			t.Errorf("NormalizeLayout(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+// 每种渲染结果都应包含三个话题标题与两处分隔。
+func TestRenderersHonorTopics(t *testing.T) {
+	conv := topicConversation()
+	tests := []struct {
+		name    string
+		render  func() string
+		divider string
+	}{
+		{"markdown", func() string { return RenderMarkdown(conv, "UTC") }, "\n---\n"},
+		{"html", func() string { return RenderHTMLFragment(conv, "UTC", HTMLOptions{}) }, `<hr class="topic-divider">`},
+		{"xhtml", func() string { return RenderXHTMLFragment(conv, "UTC", HTMLOptions{}) }, `<hr class="topic-divider"/>`},
+		{"plaintext", func() string { return RenderPlainText(conv, "UTC") }, "\n----"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := tt.render()
+			for _, heading := range []string{"话题 1 · 如何配置 Nginx?", "话题 2 · Go 的切片如何扩容?", "话题 3 · Rust 简介"} {
+				if !strings.Contains(out, heading) {
+					t.Errorf("缺少标题 %q", heading)
+				}
+			}
+			if got := strings.Count(out, tt.divider); got != 2 {
+				t.Errorf("分隔 %q 出现 %d 次, want 2", tt.divider, got)
+			}
+		})
+	}
+}
+
+func TestEPUBNavListsGroups(t *testing.T) {
+	var buf bytes.Buffer
+	book, err := NewEPUBWriter(&buf, "书", "UTC", HTMLOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := book.AddChapter(topicConversation()); err != nil {
+		t.Fatal(err)
+	}
+	if err := book.Close(); err != nil {
+		t.Fatal(err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	for _, file := range archive.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		files[file.Name] = string(data)
+	}
+	for _, name := range []string{epubNavPath, epubNCXPath} {
+		for i := 1; i <= 3; i++ {
+			href := "chapter-001.xhtml#" + groupAnchor(i-1)
+			if !strings.Contains(files[name], href) {
+				t.Errorf("%s 缺少 %s", name, href)
+			}
+		}
+	}
+	if !strings.Contains(files["chapter-001.xhtml"], `id="group-3"`) {
+		t.Error("章节缺少分组锚点")
+	}
+}
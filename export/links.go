@@ -0,0 +1,163 @@
+package export
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// minLinkTitleRunes 过短的标题容易误匹配, 不参与按标题引用的检测。
+const minLinkTitleRunes = 4
+
+// maxQuotedPhraseRunes 超过该长度的引号内容不会是标题, QuotedPhrases 不收集。
+const maxQuotedPhraseRunes = 200
+
+// conversationURLPattern 匹配 ChatGPT 对话链接 (含 GPTs 下的对话), 捕获对话 ID。
+var conversationURLPattern = regexp.MustCompile(`https?://(?:chat\.openai\.com|chatgpt\.com)/(?:g/[^/\s]+/)?c/([0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})`)
+
+// conversationIDPattern 匹配 ChatGPT 对话 ID, 合并文档、演示数据等不对应网页端对话的 ID 不生成链接。
+var conversationIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ConversationURL 返回对话在 ChatGPT 网页端的地址, 对话删除前可以从归档跳回原对话; id 不是 ChatGPT 对话 ID 时返回空字符串。
+func ConversationURL(id string) string {
+	if !conversationIDPattern.MatchString(id) {
+		return ""
+	}
+	return "https://chatgpt.com/c/" + strings.ToLower(id)
+}
+
+// RelatedConversation 是导出文档中"相关对话"的一项: Path 用于本地文件间跳转, URL 指向目标平台对象。
+type RelatedConversation struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Path  string `json:"path,omitempty"`
+	URL   string `json:"url,omitempty"`
+}
+
+// MentionedConversationIDs 提取对话正文中出现的 ChatGPT 对话链接。
+func MentionedConversationIDs(conv Conversation) []string {
+	seen := make(map[string]struct{})
+	var ids []string
+	for _, msg := range conv.Messages {
+		for _, match := range conversationURLPattern.FindAllStringSubmatch(msg.Text, -1) {
+			id := strings.ToLower(match[1])
+			if id == strings.ToLower(conv.ID) {
+				continue
+			}
+			if _, ok := seen[id]; ok {
+				continue
+			}
+			seen[id] = struct{}{}
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// MentionsQuotedTitle 判断正文中是否以引号形式引用了另一条对话的标题。
+func MentionsQuotedTitle(conv Conversation, title string) bool {
+	title = strings.TrimSpace(title)
+	if utf8.RuneCountInString(title) < minLinkTitleRunes {
+		return false
+	}
+	for _, msg := range conv.Messages {
+		for _, pair := range quotePairs {
+			if strings.Contains(msg.Text, pair[0]+title+pair[1]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// quotePairs 是 MentionsQuotedTitle 识别的引号。
+var quotePairs = [][2]string{{`"`, `"`}, {"“", "”"}, {"「", "」"}, {"《", "》"}}
+
+// QuotedPhrases 提取正文中引号内的短语, 供流式导出时在不保留正文的情况下判断
+// 已处理的对话是否引用了后续对话的标题。ASCII 引号按出现顺序两两配对。
+func QuotedPhrases(conv Conversation) map[string]struct{} {
+	phrases := make(map[string]struct{})
+	for _, msg := range conv.Messages {
+		for _, pair := range quotePairs {
+			rest := msg.Text
+			for {
+				start := strings.Index(rest, pair[0])
+				if start < 0 {
+					break
+				}
+				rest = rest[start+len(pair[0]):]
+				end := strings.Index(rest, pair[1])
+				if end < 0 {
+					break
+				}
+				if phrase := strings.TrimSpace(rest[:end]); utf8.RuneCountInString(phrase) >= minLinkTitleRunes && utf8.RuneCountInString(phrase) <= maxQuotedPhraseRunes {
+					phrases[phrase] = struct{}{}
+				}
+				rest = rest[end+len(pair[1]):]
+			}
+		}
+	}
+	return phrases
+}
+
+func addRelated(conv *Conversation, other Conversation) {
+	for _, item := range conv.Related {
+		if item.ID == other.ID {
+			return
+		}
+	}
+	conv.Related = append(conv.Related, RelatedConversation{ID: other.ID, Title: firstNonEmpty(other.Title, other.ID)})
+}
+
+// LinkConversations 检测同一批对话之间的相互引用, 并在双方都记录关联。
+func LinkConversations(convs []Conversation) {
+	byID := make(map[string]int, len(convs))
+	for idx, conv := range convs {
+		byID[strings.ToLower(conv.ID)] = idx
+	}
+	for i := range convs {
+		for _, id := range MentionedConversationIDs(convs[i]) {
+			if j, ok := byID[id]; ok && j != i {
+				addRelated(&convs[i], convs[j])
+				addRelated(&convs[j], convs[i])
+			}
+		}
+		for j := range convs {
+			if i == j {
+				continue
+			}
+			if MentionsQuotedTitle(convs[i], convs[j].Title) {
+				addRelated(&convs[i], convs[j])
+				addRelated(&convs[j], convs[i])
+			}
+		}
+	}
+}
+
+// renderRelatedMarkdown 输出"相关对话"小节, 本地路径优先于平台链接。
+func renderRelatedMarkdown(related []RelatedConversation) string {
+	if len(related) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("## 相关对话\n\n")
+	for _, item := range related {
+		title := escapeMarkdownLinkText(firstNonEmpty(item.Title, item.ID))
+		switch {
+		case item.Path != "":
+			b.WriteString(fmt.Sprintf("- [%s](%s)\n", title, (&url.URL{Path: item.Path}).EscapedPath()))
+		case item.URL != "":
+			b.WriteString(fmt.Sprintf("- [%s](%s)\n", title, item.URL))
+		default:
+			b.WriteString(fmt.Sprintf("- %s (`%s`)\n", title, item.ID))
+		}
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+func escapeMarkdownLinkText(input string) string {
+	return strings.NewReplacer("[", "\\[", "]", "\\]").Replace(input)
+}
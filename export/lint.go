@@ -0,0 +1,115 @@
+package export
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// 导出内容检查的规则名, 用于任务报告。
+const (
+	LintEmptyBody       = "empty_body"
+	LintCitationMarker  = "citation_marker"
+	LintLongLine        = "long_line"
+	LintControlChar     = "control_char"
+	LintInvalidUTF8     = "invalid_utf8"
+	LintReplacementChar = "replacement_char"
+	LintPrivateUse      = "private_use"
+)
+
+// DefaultLintMaxLine 是单行字符数的默认上限, 超过时多半是粘贴的压缩代码或数据, 在目标中难以阅读。
+const DefaultLintMaxLine = 5000
+
+const lintSampleRunes = 40
+
+// citationPattern 匹配 ChatGPT 回答中未被替换的引用标记: 私有区字符包裹的 cite 标记、
+// 旧版的 【4:0†source】 以及丢失私有区字符后残留的 citeturn0search1。
+var citationPattern = regexp.MustCompile(`\x{E200}[^\x{E201}]*\x{E201}|【[^】\n]{0,40}†[^】\n]{0,40}】|cite(?:\x{E202})?turn\d+[a-z]+\d+`)
+
+// LintOptions 控制导出内容检查。MaxLine 为 0 时使用 DefaultLintMaxLine;
+// RejectControl 表示目标接口不接受控制字符 (如 NUL)。
+type LintOptions struct {
+	MaxLine       int
+	RejectControl bool
+}
+
+// LintWarning 是导出内容中可能的问题。Message 为消息下标 (从 0 开始), -1 表示整个对话。
+type LintWarning struct {
+	Rule    string `json:"rule"`
+	Message int    `json:"message"`
+	Detail  string `json:"detail"`
+}
+
+// Lint 检查即将写入目标的对话, 返回可能导致内容缺失或显示异常的问题; 不修改对话。
+// 同一条消息的同一规则只报告一次。
+func Lint(conv Conversation, opts LintOptions) []LintWarning {
+	maxLine := opts.MaxLine
+	if maxLine <= 0 {
+		maxLine = DefaultLintMaxLine
+	}
+	var warnings []LintWarning
+	empty := 0
+	for idx, msg := range conv.Messages {
+		add := func(rule, detail string) {
+			warnings = append(warnings, LintWarning{Rule: rule, Message: idx, Detail: detail})
+		}
+		if strings.TrimSpace(msg.Text) == "" {
+			empty++
+			if len(msg.Assets) == 0 {
+				add(LintEmptyBody, fmt.Sprintf("%s 消息没有内容", msg.Role))
+			}
+			continue
+		}
+		valid := utf8.ValidString(msg.Text)
+		if !valid {
+			add(LintInvalidUTF8, "包含无效的 UTF-8 字节")
+		}
+		if matches := citationPattern.FindAllString(msg.Text, -1); len(matches) > 0 {
+			add(LintCitationMarker, fmt.Sprintf("%d 处未替换的引用标记, 如 %q", len(matches), lintSample(matches[0])))
+		}
+		var control, replacement, private int
+		for _, r := range citationPattern.ReplaceAllString(msg.Text, "") {
+			switch {
+			case r == utf8.RuneError:
+				// 无效字节同样解码为 RuneError, 已由 invalid_utf8 报告。
+				if valid {
+					replacement++
+				}
+			case r >= 0xE000 && r <= 0xF8FF:
+				private++
+			case (r < 0x20 && r != '\t' && r != '\n' && r != '\r') || r == 0x7F:
+				control++
+			}
+		}
+		if replacement > 0 {
+			add(LintReplacementChar, fmt.Sprintf("%d 个替换字符 (U+FFFD), 原文可能已损坏", replacement))
+		}
+		if private > 0 {
+			add(LintPrivateUse, fmt.Sprintf("%d 个私有区字符, 目标中可能显示为方框", private))
+		}
+		if control > 0 && opts.RejectControl {
+			add(LintControlChar, fmt.Sprintf("%d 个控制字符, 目标可能拒绝写入", control))
+		}
+		longest, line := 0, 0
+		for i, text := range strings.Split(msg.Text, "\n") {
+			if n := utf8.RuneCountInString(text); n > longest {
+				longest, line = n, i+1
+			}
+		}
+		if longest > maxLine {
+			add(LintLongLine, fmt.Sprintf("第 %d 行有 %d 个字符", line, longest))
+		}
+	}
+	if len(conv.Messages) > 0 && empty == len(conv.Messages) {
+		warnings = append(warnings, LintWarning{Rule: LintEmptyBody, Message: -1, Detail: "所有消息都没有文字内容"})
+	}
+	return warnings
+}
+
+func lintSample(text string) string {
+	if runes := []rune(text); len(runes) > lintSampleRunes {
+		return string(runes[:lintSampleRunes]) + "…"
+	}
+	return text
+}
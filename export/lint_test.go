@@ -0,0 +1,87 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name string
+		msgs []Message
+		opts LintOptions
+		want []string
+	}{
+		{name: "没有问题", msgs: []Message{{Role: "user", Text: "你好\n\tworld"}}},
+		{name: "没有消息", msgs: nil},
+		{
+			name: "所有消息都没有内容",
+			msgs: []Message{{Role: "user", Text: " "}, {Role: "assistant", Text: "", Assets: []Asset{{}}}},
+			want: []string{"0:" + LintEmptyBody, "-1:" + LintEmptyBody},
+		},
+		{
+			name: "未替换的引用标记只报告一次",
+			msgs: []Message{{Role: "assistant", Text: "答案citeturn0search1 与 【4:0†source】"}},
+			want: []string{"0:" + LintCitationMarker},
+		},
+		{
+			name: "残留的 citeturn 标记",
+			msgs: []Message{{Role: "assistant", Text: "见 citeturn0search1"}},
+			want: []string{"0:" + LintCitationMarker},
+		},
+		{
+			name: "无效字节与私有区字符",
+			msgs: []Message{{Role: "user", Text: "ok"}, {Role: "assistant", Text: "a\xffb"}},
+			want: []string{"1:" + LintInvalidUTF8, "1:" + LintPrivateUse},
+		},
+		{
+			name: "替换字符",
+			msgs: []Message{{Role: "assistant", Text: "乱码\uFFFD"}},
+			want: []string{"0:" + LintReplacementChar},
+		},
+		{
+			name: "控制字符仅在目标拒绝时报告",
+			msgs: []Message{{Role: "assistant", Text: "a\x00b"}},
+			want: nil,
+		},
+		{
+			name: "目标拒绝控制字符",
+			msgs: []Message{{Role: "assistant", Text: "a\x00b\r\n"}},
+			opts: LintOptions{RejectControl: true},
+			want: []string{"0:" + LintControlChar},
+		},
+		{
+			name: "超长行",
+			msgs: []Message{{Role: "assistant", Text: "ok\n" + strings.Repeat("x", 11)}},
+			opts: LintOptions{MaxLine: 10},
+			want: []string{"0:" + LintLongLine},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, w := range Lint(Conversation{Messages: tt.msgs}, tt.opts) {
+				if w.Detail == "" {
+					t.Errorf("%s 缺少说明", w.Rule)
+				}
+				got = append(got, fmt.Sprintf("%d:%s", w.Message, w.Rule))
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Lint() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintDetail(t *testing.T) {
+	text := "ok\n" + strings.Repeat("x", DefaultLintMaxLine+1)
+	warnings := Lint(Conversation{Messages: []Message{{Role: "assistant", Text: text}}}, LintOptions{})
+	if len(warnings) != 1 || warnings[0].Detail != "第 2 行有 5001 个字符" {
+		t.Errorf("Lint() = %+v", warnings)
+	}
+	sample := lintSample(strings.Repeat("引", lintSampleRunes+1))
+	if sample != strings.Repeat("引", lintSampleRunes)+"…" {
+		t.Errorf("lintSample() = %q", sample)
+	}
+}
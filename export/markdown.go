@@ -0,0 +1,170 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Devoty/openai-backup/logging"
+)
+
+// RenderMarkdown 拼装单个对话的 Markdown 内容, 时间按 timezone 输出。
+func RenderMarkdown(conv Conversation, timezone string) string {
+	var b strings.Builder
+
+	loc := ResolveLocation(timezone)
+	title := conv.Title
+	if title == "" {
+		title = "(未命名对话)"
+	}
+
+	b.WriteString(fmt.Sprintf("# %s\n\n", escapeMarkdownHeading(title)))
+	b.WriteString(fmt.Sprintf("- 对话ID: `%s`\n", conv.ID))
+	if link := ConversationURL(conv.ID); link != "" {
+		b.WriteString(fmt.Sprintf("- 原始对话: <%s>\n", link))
+	}
+	tf := NewTimeFormatter(conv, loc)
+	b.WriteString(fmt.Sprintf("- 创建时间: %s\n", tf.Format(conv.CreateTime)))
+	b.WriteString(fmt.Sprintf("- 最近更新: %s\n", tf.Format(conv.UpdateTime)))
+	if conv.ShowStats {
+		b.WriteString(fmt.Sprintf("- 统计: %s\n", conversationStatsSummary(conv)))
+	}
+	b.WriteString(renderAttachmentsMarkdown(conv.Attachments))
+	b.WriteString("\n")
+
+	if len(conv.Context) > 0 {
+		b.WriteString("## 上下文\n\n")
+		for _, entry := range conv.Context {
+			b.WriteString(fmt.Sprintf("**%s**\n\n%s\n\n", entry.Label, entry.Text))
+		}
+	}
+
+	groups := groupMessages(conv.Messages, conv.Layout, tf)
+	toc := conv.WantsTOC()
+	if toc {
+		b.WriteString(renderTOCMarkdown(tocEntries(groups, tf)))
+	}
+	for idx, group := range groups {
+		level := "##"
+		if group.Divider {
+			b.WriteString("---\n\n")
+		}
+		if group.Heading != "" {
+			if toc {
+				b.WriteString(fmt.Sprintf("<a id=\"%s\"></a>\n\n", groupAnchor(idx)))
+			}
+			b.WriteString("## " + escapeMarkdownHeading(group.Heading) + "\n\n")
+			level = "###"
+		}
+		for offset, msg := range group.Messages {
+			num := group.Start + offset + 1
+			if toc {
+				b.WriteString(fmt.Sprintf("<a id=\"%s\"></a>\n\n", messageAnchor(num)))
+			}
+			renderMessageMarkdown(&b, num, msg, level, tf, conv.ShowStats)
+		}
+	}
+	b.WriteString(renderRemovedMarkdown(conv.Removed, tf))
+	b.WriteString(renderRelatedMarkdown(conv.Related))
+
+	return b.String()
+}
+
+// renderMessageMarkdown 输出一条消息, num 为全文中的序号, level 为标题级别 ("##" 或分组时的 "###"),
+// showStats 为 true 时标题带上词数。
+func renderMessageMarkdown(b *strings.Builder, num int, msg Message, level string, tf TimeFormatter, showStats bool) {
+	label := strings.ToUpper(msg.Role)
+	if label == "" {
+		label = "UNKNOWN"
+	}
+	heading := fmt.Sprintf("%d. %s · %s%s", num, label, tf.Format(msg.CreateTime), messageStatsSuffix(msg, showStats))
+	if msg.Source != "" {
+		heading += " · 来自 " + msg.Source
+	}
+	if change := changeLabel(msg.Change); change != "" {
+		heading += fmt.Sprintf(" · [%s]", change)
+	}
+	b.WriteString(level + " " + heading + "\n\n")
+	if msg.Text != "" || len(msg.Assets) == 0 {
+		b.WriteString(blockquote(msg.Role, msg.Text))
+		if len(msg.Assets) > 0 {
+			b.WriteString("\n")
+		}
+	}
+	b.WriteString(renderAssetsMarkdown(msg.Assets))
+	if len(msg.References) > 0 {
+		b.WriteString("引用:\n")
+		for _, ref := range msg.References {
+			title := strings.TrimSpace(ref.Title)
+			if title == "" {
+				title = ref.URL
+			}
+			source := strings.TrimSpace(ref.Source)
+			if source != "" {
+				b.WriteString(fmt.Sprintf("- [%s](%s) · %s\n", title, ref.URL, source))
+			} else {
+				b.WriteString(fmt.Sprintf("- [%s](%s)\n", title, ref.URL))
+			}
+		}
+		b.WriteString("\n")
+	} else {
+		b.WriteString("\n")
+	}
+}
+
+func blockquote(role, text string) string {
+	isUser := strings.EqualFold(role, "user")
+	if text == "" {
+		if isUser {
+			return "> (空内容)\n"
+		}
+		return "(空内容)\n"
+	}
+
+	if !isUser {
+		return text + "\n"
+	}
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = "> " + line
+		if line == "" {
+			lines[i] = ">"
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// FormatTimestamp 把秒级时间戳格式化为 "2006-01-02 15:04:05", 无效值输出 "-"。
+func FormatTimestamp(value float64, loc *time.Location) string {
+	if value <= 0 {
+		return "-"
+	}
+	return FormatTimestampAs(value, loc, TimeFormatDefault)
+}
+
+// ResolveLocation 解析时区名称, 支持 utc、local 与 IANA 时区, 无法识别时回落到本地时区。
+func ResolveLocation(name string) *time.Location {
+	switch strings.ToLower(name) {
+	case "utc":
+		return time.UTC
+	case "local", "":
+		return time.Local
+	default:
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			logging.Infof("未能识别时区 %q, 使用本地时区", name)
+			return time.Local
+		}
+		return loc
+	}
+}
+
+func escapeMarkdownHeading(input string) string {
+	trimmed := strings.TrimSpace(input)
+	if trimmed == "" {
+		return trimmed
+	}
+	trimmed = strings.ReplaceAll(trimmed, "\n", " ")
+	return trimmed
+}
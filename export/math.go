@@ -0,0 +1,121 @@
+package export
+
+import (
+	"strings"
+)
+
+const (
+	// MathRaw 保留原始 LaTeX 文本。
+	MathRaw = "raw"
+	// MathEquation 在 Notion 中转换为公式块与行内公式。
+	MathEquation = "equation"
+	// MathImage 在 Notion 中同样使用公式, HTML 导出额外在导出时经 HTMLOptions.RenderSVG
+	// 把公式渲染为 SVG 图片嵌入页面。
+	MathImage = "image"
+)
+
+// SVGMath 是 SVGRenderer 渲染公式时的 kind, 图表的 kind 为 DiagramType 的返回值。
+const SVGMath = "math"
+
+// NormalizeMathMode 将公式处理方式收敛为 raw、equation、image 之一, 未知值回落到 raw。
+func NormalizeMathMode(value string) string {
+	switch mode := strings.ToLower(strings.TrimSpace(value)); mode {
+	case MathEquation, MathImage:
+		return mode
+	default:
+		return MathRaw
+	}
+}
+
+// MathSpan 是一段普通文本或行内公式, Math 为 true 时 Text 为去掉定界符的表达式。
+type MathSpan struct {
+	Text string
+	Math bool
+}
+
+// BlockMathExpression 判断整段文本是否为独立公式 ($$...$$ 或 \[...\]), 返回其中的表达式。
+func BlockMathExpression(segment string) (string, bool) {
+	trimmed := strings.TrimSpace(segment)
+	for _, pair := range [][2]string{{"$$", "$$"}, {`\[`, `\]`}} {
+		if len(trimmed) <= len(pair[0])+len(pair[1]) || !strings.HasPrefix(trimmed, pair[0]) || !strings.HasSuffix(trimmed, pair[1]) {
+			continue
+		}
+		expr := strings.TrimSpace(trimmed[len(pair[0]) : len(trimmed)-len(pair[1])])
+		if expr == "" || strings.Contains(expr, pair[0]) {
+			continue
+		}
+		return expr, true
+	}
+	return "", false
+}
+
+// SplitInlineMath 按 \(...\) 与 $...$ 拆分行内公式。为避免把金额等误判为公式,
+// 单个 $ 要求起始后与结束前都不是空白, 且结束符后不紧跟数字。
+func SplitInlineMath(text string) []MathSpan {
+	var spans []MathSpan
+	var plain strings.Builder
+	flushPlain := func() {
+		if plain.Len() > 0 {
+			spans = append(spans, MathSpan{Text: plain.String()})
+			plain.Reset()
+		}
+	}
+
+	for i := 0; i < len(text); {
+		if strings.HasPrefix(text[i:], `\(`) {
+			if end := strings.Index(text[i+2:], `\)`); end > 0 {
+				flushPlain()
+				spans = append(spans, MathSpan{Text: strings.TrimSpace(text[i+2 : i+2+end]), Math: true})
+				i += 2 + end + 2
+				continue
+			}
+		}
+		if text[i] == '\\' && i+1 < len(text) {
+			plain.WriteString(text[i : i+2])
+			i += 2
+			continue
+		}
+		if text[i] == '$' {
+			if end, ok := inlineDollarEnd(text, i); ok {
+				flushPlain()
+				spans = append(spans, MathSpan{Text: text[i+1 : end], Math: true})
+				i = end + 1
+				continue
+			}
+		}
+		plain.WriteByte(text[i])
+		i++
+	}
+	flushPlain()
+	return spans
+}
+
+func inlineDollarEnd(text string, start int) (int, bool) {
+	if start+1 >= len(text) || text[start+1] == '$' || isMathSpace(text[start+1]) {
+		return 0, false
+	}
+	if start > 0 && text[start-1] == '$' {
+		return 0, false
+	}
+	for j := start + 1; j < len(text); j++ {
+		switch text[j] {
+		case '\\':
+			j++
+		case '\n':
+			return 0, false
+		case '$':
+			if isMathSpace(text[j-1]) {
+				return 0, false
+			}
+			if j+1 < len(text) && (text[j+1] == '$' || (text[j+1] >= '0' && text[j+1] <= '9')) {
+				return 0, false
+			}
+			return j, true
+		}
+	}
+	return 0, false
+}
+
+func isMathSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
@@ -0,0 +1,65 @@
+// Package export 把 ChatGPT 对话转换为与导出目标无关的模型, 并渲染为 Markdown、HTML 等格式。
+package export
+
+import "encoding/json"
+
+// Conversation 是单个对话的导出模型, 各导出目标与渲染器都以它为输入。
+type Conversation struct {
+	ID          string                `json:"id"`
+	Title       string                `json:"title"`
+	CreateTime  float64               `json:"create_time"`
+	UpdateTime  float64               `json:"update_time"`
+	Tags        []string              `json:"tags,omitempty"`
+	Context     []ContextEntry        `json:"context,omitempty"`
+	Attachments []Attachment          `json:"attachments,omitempty"`
+	Messages    []Message             `json:"messages"`
+	Related     []RelatedConversation `json:"related,omitempty"`
+	Skipped     []SkippedMessage      `json:"skipped,omitempty"`
+	// Project 是对话所属 ChatGPT 项目在目标中的分类名 (Notion 选项、压缩包子目录等), 见 README 的项目归类。
+	Project string `json:"project,omitempty"`
+	// Removed 是上一次备份中存在、当前已删除的消息, 仅在标记变化时填充, 见 AnnotateChanges。
+	Removed []Message `json:"removed,omitempty"`
+	// Layout 是渲染 Markdown/HTML 时消息的排列方式 (见 NormalizeLayout), 为空时按时间顺序。
+	// 与 ShowStats 一样随 JSON 保存, 导出压缩包暂存对话后读回时不会丢失。
+	Layout string `json:"layout,omitempty"`
+	// ShowStats 为 true 时渲染的文档头部与消息标题带上词数与阅读时间, 见 TextStats。
+	ShowStats bool `json:"show_stats,omitempty"`
+	// TimeFormat 是导出文档中时间的格式 (见 NormalizeTimeFormat), 为空时使用默认格式。
+	TimeFormat string `json:"time_format,omitempty"`
+	// SecondTimezone 不为空时, 导出的时间在括号中附上该时区的时间, 如 "2024-03-14 20:00:00 (2024-03-14 12:00:00 UTC)"。
+	SecondTimezone string `json:"second_timezone,omitempty"`
+	// TOCMinMessages 大于 0 且消息数达到该值时, Markdown/HTML 文档开头生成链接到各条消息的目录, 见 WantsTOC。
+	TOCMinMessages int `json:"toc_min_messages,omitempty"`
+	// Raw 是对话详情接口 (或导入的官方导出数据) 的原始 JSON, 供 json 目标无损保存。
+	// 不随 JSON 序列化, 避免外部命令、Webhook 等收到重复内容。
+	Raw json.RawMessage `json:"-"`
+}
+
+// Message 是导出的一条消息, Text 为规整后的正文。
+type Message struct {
+	Role       string      `json:"role"`
+	CreateTime float64     `json:"create_time"`
+	UpdateTime float64     `json:"update_time"`
+	Text       string      `json:"text"`
+	References []Reference `json:"references,omitempty"`
+	Assets     []Asset     `json:"assets,omitempty"`
+	// Change 是相对上一次备份的变化 (ChangeAdded / ChangeEdited), 未标记时为空。
+	Change string `json:"change,omitempty"`
+	// Source 是合并多个对话时消息来源对话的标题, 见 CombineConversations。
+	Source string `json:"source,omitempty"`
+	// FullText 是截断前的原文, 只在 TruncateMessages 要求保留时设置。
+	FullText string `json:"full_text,omitempty"`
+}
+
+// ContextEntry 是对话附带的自定义指令或项目系统提示词。
+type ContextEntry struct {
+	Label string `json:"label"`
+	Text  string `json:"text"`
+}
+
+// Reference 是消息引用的网页来源。
+type Reference struct {
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+	Source string `json:"source"`
+}
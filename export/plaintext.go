@@ -0,0 +1,59 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RenderPlainText 输出单个对话的纯文本, 用于复制到剪贴板或通过管道交给其他程序。
+// 只去掉 RenderMarkdown 添加的标题与引用块标记, 消息正文按原样输出。
+func RenderPlainText(conv Conversation, timezone string) string {
+	var b strings.Builder
+
+	tf := NewTimeFormatter(conv, ResolveLocation(timezone))
+	b.WriteString(firstNonEmpty(conv.Title, "(未命名对话)") + "\n")
+	b.WriteString(fmt.Sprintf("对话ID: %s\n", conv.ID))
+	if link := ConversationURL(conv.ID); link != "" {
+		b.WriteString(fmt.Sprintf("原始对话: %s\n", link))
+	}
+	b.WriteString(fmt.Sprintf("创建时间: %s\n", tf.Format(conv.CreateTime)))
+	b.WriteString(fmt.Sprintf("最近更新: %s\n", tf.Format(conv.UpdateTime)))
+	if conv.ShowStats {
+		b.WriteString(fmt.Sprintf("统计: %s\n", conversationStatsSummary(conv)))
+	}
+
+	for _, entry := range conv.Context {
+		b.WriteString(fmt.Sprintf("\n%s:\n%s\n", entry.Label, strings.TrimSpace(entry.Text)))
+	}
+
+	for _, group := range groupMessages(conv.Messages, conv.Layout, tf) {
+		if group.Divider {
+			b.WriteString("\n" + strings.Repeat("-", 40) + "\n")
+		}
+		if group.Heading != "" {
+			b.WriteString(fmt.Sprintf("\n== %s ==\n", group.Heading))
+		}
+		for offset, msg := range group.Messages {
+			label := strings.ToUpper(firstNonEmpty(msg.Role, "unknown"))
+			heading := fmt.Sprintf("[%d] %s · %s", group.Start+offset+1, label, tf.Format(msg.CreateTime))
+			if msg.Source != "" {
+				heading += " · 来自 " + msg.Source
+			}
+			b.WriteString("\n" + heading + "\n")
+			if text := strings.TrimSpace(msg.Text); text != "" {
+				b.WriteString(text + "\n")
+			}
+			for _, asset := range msg.Assets {
+				b.WriteString(fmt.Sprintf("(%s: %s)\n", AssetKindLabel(asset.Kind), firstNonEmpty(asset.Prompt, asset.Path, asset.Pointer)))
+			}
+			for _, ref := range msg.References {
+				if title := strings.TrimSpace(ref.Title); title != "" && title != ref.URL {
+					b.WriteString(fmt.Sprintf("引用: %s %s\n", title, ref.URL))
+				} else {
+					b.WriteString(fmt.Sprintf("引用: %s\n", ref.URL))
+				}
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,68 @@
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderPlainText(t *testing.T) {
+	created := float64(time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC).Unix())
+	base := Conversation{
+		ID:         "0196a1b2-c3d4-7e5f-8a9b-0c1d2e3f4a5b",
+		Title:      "部署",
+		CreateTime: created,
+		UpdateTime: created,
+		Context:    []ContextEntry{{Label: "自定义指令", Text: "  简洁回答 \n"}},
+		Messages: []Message{
+			{Role: "user", Text: "## 如何配置 **nginx**?", CreateTime: created},
+			{
+				Role:       "assistant",
+				Text:       " 见文档 ",
+				Source:     "旧对话",
+				Assets:     []Asset{{Kind: AssetImage, Prompt: "架构图"}},
+				References: []Reference{{Title: "nginx docs", URL: "https://nginx.org"}, {Title: "https://example.com", URL: "https://example.com"}},
+			},
+		},
+	}
+	untitled := base
+	untitled.ID, untitled.Title, untitled.Context, untitled.Messages = "c1", "", nil, nil
+	withStats := base
+	withStats.ShowStats = true
+	tests := []struct {
+		name string
+		conv Conversation
+		want string
+	}{
+		{
+			name: "完整内容",
+			conv: base,
+			want: "部署\n" +
+				"对话ID: 0196a1b2-c3d4-7e5f-8a9b-0c1d2e3f4a5b\n" +
+				"原始对话: https://chatgpt.com/c/0196a1b2-c3d4-7e5f-8a9b-0c1d2e3f4a5b\n" +
+				"创建时间: 2024-03-01 09:30:00\n" +
+				"最近更新: 2024-03-01 09:30:00\n" +
+				"\n自定义指令:\n简洁回答\n" +
+				"\n[1] USER · 2024-03-01 09:30:00\n## 如何配置 **nginx**?\n" +
+				"\n[2] ASSISTANT · - · 来自 旧对话\n见文档\n" +
+				"(图片: 架构图)\n" +
+				"引用: nginx docs https://nginx.org\n" +
+				"引用: https://example.com\n",
+		},
+		{
+			name: "没有标题与消息",
+			conv: untitled,
+			want: "(未命名对话)\n对话ID: c1\n创建时间: 2024-03-01 09:30:00\n最近更新: 2024-03-01 09:30:00\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RenderPlainText(tt.conv, "UTC"); got != tt.want {
+				t.Errorf("RenderPlainText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if got := RenderPlainText(withStats, "UTC"); !strings.Contains(got, "统计: "+conversationStatsSummary(withStats)+"\n") {
+		t.Errorf("RenderPlainText() 缺少统计行: %q", got)
+	}
+}
@@ -0,0 +1,450 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/Devoty/openai-backup/logging"
+)
+
+// 嵌入二维码的内容: 原始 ChatGPT 对话地址, 或对话在导出压缩包中的路径。
+const (
+	QRCodeOff  = ""
+	QRCodeURL  = "url"
+	QRCodePath = "path"
+)
+
+// NormalizeQRCodeMode 规范化二维码内容设置, 未知值视为关闭。
+func NormalizeQRCodeMode(value string) string {
+	switch mode := strings.ToLower(strings.TrimSpace(value)); mode {
+	case QRCodeURL, QRCodePath:
+		return mode
+	default:
+		return QRCodeOff
+	}
+}
+
+// qrCodeContent 返回二维码编码的文本: path 模式下为压缩包中的路径, 不在压缩包中时回落到原始对话地址。
+func qrCodeContent(conv Conversation, opts HTMLOptions) string {
+	switch NormalizeQRCodeMode(opts.QRCode) {
+	case QRCodePath:
+		if path := strings.TrimSpace(opts.ArchivePath); path != "" {
+			return path
+		}
+		return ConversationURL(conv.ID)
+	case QRCodeURL:
+		return ConversationURL(conv.ID)
+	default:
+		return ""
+	}
+}
+
+// renderQRCodeHTML 输出页头中的二维码, 内容过长无法编码时只记录日志, 不影响导出。
+func renderQRCodeHTML(conv Conversation, opts HTMLOptions) string {
+	content := qrCodeContent(conv, opts)
+	if content == "" {
+		return ""
+	}
+	code, err := EncodeQRCode(content)
+	if err != nil {
+		logging.Infof("对话 %s 生成二维码失败: %v", conv.ID, err)
+		return ""
+	}
+	return fmt.Sprintf("<figure class=\"qrcode\">%s<figcaption>%s</figcaption></figure>\n", code.SVG(3), html.EscapeString(content))
+}
+
+// QRCode 是编码完成的二维码矩阵, Modules[y][x] 为 true 表示深色模块。
+type QRCode struct {
+	Version int
+	Modules [][]bool
+}
+
+// qrVersionM 是纠错等级 M 下各版本的分块参数: 每块纠错码字数, 以及两组的块数与每块数据码字数。
+var qrVersionM = [...]struct{ ecPerBlock, blocks1, data1, blocks2, data2 int }{
+	{10, 1, 16, 0, 0}, {16, 1, 28, 0, 0}, {26, 1, 44, 0, 0}, {18, 2, 32, 0, 0}, {24, 2, 43, 0, 0},
+	{16, 4, 27, 0, 0}, {18, 4, 31, 0, 0}, {22, 2, 38, 2, 39}, {22, 3, 36, 2, 37}, {26, 4, 43, 1, 44},
+	{30, 1, 50, 4, 51}, {22, 6, 36, 2, 37}, {22, 8, 37, 1, 38}, {24, 4, 40, 5, 41}, {24, 5, 41, 5, 42},
+	{28, 7, 45, 3, 46}, {28, 10, 46, 1, 47}, {26, 9, 43, 4, 44}, {26, 3, 44, 11, 45}, {26, 3, 41, 13, 42},
+}
+
+// EncodeQRCode 以字节模式、纠错等级 M 编码文本, 选择能容纳内容的最小版本 (最大为 20, 约 660 字节)。
+func EncodeQRCode(text string) (QRCode, error) {
+	data := []byte(text)
+	version := 0
+	for v := 1; v <= len(qrVersionM); v++ {
+		p := qrVersionM[v-1]
+		capacity := (p.blocks1*p.data1 + p.blocks2*p.data2) * 8
+		if 4+qrCountBits(v)+len(data)*8 <= capacity {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return QRCode{}, fmt.Errorf("内容过长 (%d 字节), 无法编码为二维码", len(data))
+	}
+
+	p := qrVersionM[version-1]
+	capacity := (p.blocks1*p.data1 + p.blocks2*p.data2) * 8
+	var bits qrBits
+	bits.append(0b0100, 4)
+	bits.append(len(data), qrCountBits(version))
+	for _, c := range data {
+		bits.append(int(c), 8)
+	}
+	bits.append(0, min(4, capacity-len(bits)))
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+	codewords := make([]byte, len(bits)/8)
+	for i, bit := range bits {
+		if bit {
+			codewords[i/8] |= 1 << (7 - i%8)
+		}
+	}
+
+	q := newQRMatrix(version)
+	q.drawFunctionPatterns()
+	q.drawCodewords(qrInterleave(codewords, p.ecPerBlock, p.blocks1, p.data1, p.blocks2, p.data2))
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormatBits(mask)
+		if penalty := q.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		q.applyMask(mask)
+	}
+	q.applyMask(best)
+	q.drawFormatBits(best)
+	return QRCode{Version: version, Modules: q.modules}, nil
+}
+
+// SVG 输出带 4 个模块静区的 SVG 图形, scale 为每个模块的像素大小。
+func (c QRCode) SVG(scale int) string {
+	size := len(c.Modules) + 8
+	var path strings.Builder
+	for y, row := range c.Modules {
+		for x, dark := range row {
+			if dark {
+				path.WriteString(fmt.Sprintf("M%d,%dh1v1h-1z", x+4, y+4))
+			}
+		}
+	}
+	return fmt.Sprintf("<svg xmlns=\"http://www.w3.org/2000/svg\" viewBox=\"0 0 %d %d\" width=\"%d\" height=\"%d\" shape-rendering=\"crispEdges\" role=\"img\" aria-label=\"二维码\"><rect width=\"%d\" height=\"%d\" fill=\"#ffffff\"/><path d=\"%s\" fill=\"#000000\"/></svg>",
+		size, size, size*scale, size*scale, size, size, path.String())
+}
+
+func qrCountBits(version int) int {
+	if version < 10 {
+		return 8
+	}
+	return 16
+}
+
+type qrBits []bool
+
+func (b *qrBits) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*b = append(*b, (value>>i)&1 == 1)
+	}
+}
+
+// qrInterleave 把数据分块并计算各块的 Reed-Solomon 纠错码字, 按规范交错排列。
+func qrInterleave(data []byte, ecLen, blocks1, data1, blocks2, data2 int) []byte {
+	divisor := qrReedSolomonDivisor(ecLen)
+	var dataBlocks, ecBlocks [][]byte
+	offset := 0
+	for i := 0; i < blocks1+blocks2; i++ {
+		n := data1
+		if i >= blocks1 {
+			n = data2
+		}
+		block := data[offset : offset+n]
+		offset += n
+		dataBlocks = append(dataBlocks, block)
+		ecBlocks = append(ecBlocks, qrReedSolomonRemainder(block, divisor))
+	}
+	result := make([]byte, 0, len(data)+ecLen*len(dataBlocks))
+	for i := 0; i < max(data1, data2); i++ {
+		for _, block := range dataBlocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < ecLen; i++ {
+		for _, block := range ecBlocks {
+			result = append(result, block[i])
+		}
+	}
+	return result
+}
+
+func qrReedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = qrMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = qrMultiply(root, 0x02)
+	}
+	return result
+}
+
+func qrReedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, coef := range divisor {
+			result[i] ^= qrMultiply(coef, factor)
+		}
+	}
+	return result
+}
+
+// qrMultiply 是 GF(2^8) 上以 x^8+x^4+x^3+x^2+1 为模的乘法。
+func qrMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+type qrMatrix struct {
+	version  int
+	size     int
+	modules  [][]bool
+	function [][]bool
+}
+
+func newQRMatrix(version int) *qrMatrix {
+	size := version*4 + 17
+	q := &qrMatrix{version: version, size: size}
+	q.modules = make([][]bool, size)
+	q.function = make([][]bool, size)
+	for i := range q.modules {
+		q.modules[i] = make([]bool, size)
+		q.function[i] = make([]bool, size)
+	}
+	return q
+}
+
+func (q *qrMatrix) set(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.function[y][x] = true
+}
+
+// drawFunctionPatterns 绘制定位、时序、校正图形, 并为格式与版本信息预留位置。
+func (q *qrMatrix) drawFunctionPatterns() {
+	for i := 0; i < q.size; i++ {
+		q.set(6, i, i%2 == 0)
+		q.set(i, 6, i%2 == 0)
+	}
+	for _, center := range [][2]int{{3, 3}, {q.size - 4, 3}, {3, q.size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := center[0]+dx, center[1]+dy
+				if x >= 0 && x < q.size && y >= 0 && y < q.size {
+					dist := max(abs(dx), abs(dy))
+					q.set(x, y, dist != 2 && dist != 4)
+				}
+			}
+		}
+	}
+	positions := q.alignmentPositions()
+	last := len(positions) - 1
+	for i, cx := range positions {
+		for j, cy := range positions {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					q.set(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+	q.drawFormatBits(0)
+	if q.version >= 7 {
+		rem := q.version
+		for i := 0; i < 12; i++ {
+			rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+		}
+		bits := q.version<<12 | rem
+		for i := 0; i < 18; i++ {
+			dark := (bits>>i)&1 == 1
+			a, b := q.size-11+i%3, i/3
+			q.set(a, b, dark)
+			q.set(b, a, dark)
+		}
+	}
+}
+
+func (q *qrMatrix) alignmentPositions() []int {
+	if q.version == 1 {
+		return nil
+	}
+	count := q.version/7 + 2
+	step := (q.version*4 + count*2 + 1) / (count*2 - 2) * 2
+	result := make([]int, count)
+	result[0] = 6
+	for i, pos := count-1, q.size-7; i >= 1; i, pos = i-1, pos-step {
+		result[i] = pos
+	}
+	return result
+}
+
+// drawFormatBits 写入纠错等级 M 与掩码编号的格式信息, 两处各一份。
+func (q *qrMatrix) drawFormatBits(mask int) {
+	data := mask // 纠错等级 M 的指示位为 00
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return (bits>>i)&1 == 1 }
+	for i := 0; i <= 5; i++ {
+		q.set(8, i, bit(i))
+	}
+	q.set(8, 7, bit(6))
+	q.set(8, 8, bit(7))
+	q.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.set(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		q.set(q.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.set(8, q.size-15+i, bit(i))
+	}
+	q.set(8, q.size-8, true)
+}
+
+// drawCodewords 按之字形从右下角开始放置码字, 跳过功能图形。
+func (q *qrMatrix) drawCodewords(data []byte) {
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < q.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = q.size - 1 - vert
+				}
+				if !q.function[y][x] && i < len(data)*8 {
+					q.modules[y][x] = (data[i>>3]>>(7-(i&7)))&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask 对数据区域应用掩码, 再次调用可撤销。
+func (q *qrMatrix) applyMask(mask int) {
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !q.function[y][x] {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty 按规范的四条规则计算掩码评分, 分数越低越容易识别。
+func (q *qrMatrix) penalty() int {
+	result := 0
+	at := func(x, y int, vertical bool) bool {
+		if vertical {
+			return q.modules[x][y]
+		}
+		return q.modules[y][x]
+	}
+	finderA := []bool{true, false, true, true, true, false, true, false, false, false, false}
+	finderB := []bool{false, false, false, false, true, false, true, true, true, false, true}
+	for _, vertical := range []bool{false, true} {
+		for y := 0; y < q.size; y++ {
+			run := 1
+			for x := 1; x <= q.size; x++ {
+				if x < q.size && at(x, y, vertical) == at(x-1, y, vertical) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					result += 3 + run - 5
+				}
+				run = 1
+			}
+			for x := 0; x+len(finderA) <= q.size; x++ {
+				matchA, matchB := true, true
+				for k := range finderA {
+					value := at(x+k, y, vertical)
+					matchA = matchA && value == finderA[k]
+					matchB = matchB && value == finderB[k]
+				}
+				if matchA || matchB {
+					result += 40
+				}
+			}
+		}
+	}
+	dark := 0
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if q.modules[y][x] {
+				dark++
+			}
+			if x+1 < q.size && y+1 < q.size {
+				c := q.modules[y][x]
+				if c == q.modules[y][x+1] && c == q.modules[y+1][x] && c == q.modules[y+1][x+1] {
+					result += 3
+				}
+			}
+		}
+	}
+	total := q.size * q.size
+	result += (abs(dark*20-total*10)+total-1)/total*10 - 10
+	return result
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,187 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestEncodeQRCodeVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		length  int
+		version int
+	}{
+		{"空内容", 0, 1},
+		{"版本 1 上限", 14, 1},
+		{"超出版本 1", 15, 2},
+		{"版本 2 上限", 26, 2},
+		{"版本 9 上限", 180, 9},
+		{"版本 10 起计数字段为 16 位", 181, 10},
+		{"版本 20 上限", 666, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := EncodeQRCode(strings.Repeat("a", tt.length))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if code.Version != tt.version {
+				t.Errorf("Version = %d, want %d", code.Version, tt.version)
+			}
+			size := tt.version*4 + 17
+			if len(code.Modules) != size {
+				t.Fatalf("行数 = %d, want %d", len(code.Modules), size)
+			}
+			for y, row := range code.Modules {
+				if len(row) != size {
+					t.Fatalf("第 %d 行宽度 = %d, want %d", y, len(row), size)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeQRCodeTooLong(t *testing.T) {
+	if _, err := EncodeQRCode(strings.Repeat("a", 667)); err == nil || !strings.Contains(err.Error(), "667 字节") {
+		t.Fatalf("err = %v, want 内容过长", err)
+	}
+}
+
+func TestEncodeQRCodeFunctionPatterns(t *testing.T) {
+	for _, text := range []string{"hi", "https://chatgpt.com/c/6650f3a2-1b2c-4d5e-8f90-0123456789ab", strings.Repeat("中文", 60)} {
+		code, err := EncodeQRCode(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		size := len(code.Modules)
+		// 三个角的定位图形: 7x7 外框深色, 内圈浅色, 中心 3x3 深色, 外侧一圈分隔符浅色。
+		for _, corner := range [][2]int{{0, 0}, {size - 7, 0}, {0, size - 7}} {
+			for dy := -1; dy <= 7; dy++ {
+				for dx := -1; dx <= 7; dx++ {
+					x, y := corner[0]+dx, corner[1]+dy
+					if x < 0 || y < 0 || x >= size || y >= size {
+						continue
+					}
+					dist := max(abs(dx-3), abs(dy-3))
+					if want := dist != 2 && dist != 4; code.Modules[y][x] != want {
+						t.Fatalf("%q: 定位图形 (%d,%d) = %v, want %v", text, x, y, code.Modules[y][x], want)
+					}
+				}
+			}
+		}
+		// 时序图形在第 6 行与第 6 列深浅交替。
+		for i := 8; i < size-8; i++ {
+			if code.Modules[6][i] != (i%2 == 0) || code.Modules[i][6] != (i%2 == 0) {
+				t.Fatalf("%q: 时序图形第 %d 个模块错误", text, i)
+			}
+		}
+		if !code.Modules[size-8][8] {
+			t.Fatalf("%q: 固定深色模块缺失", text)
+		}
+		if level, _ := qrFormatInfo(code); level != 0 {
+			t.Fatalf("%q: 纠错等级指示位 = %02b, want 00 (M)", text, level)
+		}
+	}
+}
+
+// qrFormatInfo 读取左上角的格式信息, 去掉掩码后返回纠错等级指示位与掩码编号, 并校验与另一份一致。
+func qrFormatInfo(code QRCode) (level, mask int) {
+	size := len(code.Modules)
+	var first, second int
+	get := func(x, y int) int {
+		if code.Modules[y][x] {
+			return 1
+		}
+		return 0
+	}
+	for i := 0; i <= 5; i++ {
+		first |= get(8, i) << i
+	}
+	first |= get(8, 7)<<6 | get(8, 8)<<7 | get(7, 8)<<8
+	for i := 9; i < 15; i++ {
+		first |= get(14-i, 8) << i
+	}
+	for i := 0; i < 8; i++ {
+		second |= get(size-1-i, 8) << i
+	}
+	for i := 8; i < 15; i++ {
+		second |= get(8, size-15+i) << i
+	}
+	if first != second {
+		return -1, -1
+	}
+	data := (first ^ 0x5412) >> 10
+	return data >> 3, data & 7
+}
+
+func TestQRInterleave(t *testing.T) {
+	// ISO/IEC 18004 附录中 "01234567" 的版本 1-M 示例: 16 个数据码字与对应的 10 个纠错码字。
+	data := []byte{0x10, 0x20, 0x0C, 0x56, 0x61, 0x80, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11}
+	want := append(append([]byte{}, data...), 0xA5, 0x24, 0xD4, 0xC1, 0xED, 0x36, 0xC7, 0x87, 0x2C, 0x55)
+	if got := qrInterleave(data, 10, 1, 16, 0, 0); !bytes.Equal(got, want) {
+		t.Fatalf("qrInterleave() = % X\nwant % X", got, want)
+	}
+
+	// 多块时按列交错: 先取各块的第 1 个数据码字, 较长的块最后补上多出的码字。
+	data = make([]byte, 2*38+2*39)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	got := qrInterleave(data, 22, 2, 38, 2, 39)
+	if len(got) != len(data)+4*22 {
+		t.Fatalf("码字数 = %d, want %d", len(got), len(data)+4*22)
+	}
+	if head := got[:4]; !bytes.Equal(head, []byte{0, 38, 76, 115}) {
+		t.Errorf("前 4 个码字 = %v, want [0 38 76 115]", head)
+	}
+	if tail := got[len(data)-2 : len(data)]; !bytes.Equal(tail, []byte{114, 153}) {
+		t.Errorf("数据部分末尾 = %v, want [114 153]", tail)
+	}
+}
+
+func TestQRCodeSVG(t *testing.T) {
+	code, err := EncodeQRCode("hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	svg := code.SVG(3)
+	for _, want := range []string{`viewBox="0 0 29 29"`, `width="87"`, `height="87"`, `M4,4h1v1h-1z`} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("SVG 缺少 %s", want)
+		}
+	}
+	dark := 0
+	for _, row := range code.Modules {
+		for _, m := range row {
+			if m {
+				dark++
+			}
+		}
+	}
+	if got := strings.Count(svg, "h1v1h-1z"); got != dark {
+		t.Errorf("SVG 中的深色模块 = %d, want %d", got, dark)
+	}
+}
+
+func TestNormalizeQRCodeMode(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", QRCodeOff},
+		{"url", QRCodeURL},
+		{" PATH ", QRCodePath},
+		{"Url", QRCodeURL},
+		{"off", QRCodeOff},
+		{"link", QRCodeOff},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q", tt.value), func(t *testing.T) {
+			if got := NormalizeQRCodeMode(tt.value); got != tt.want {
+				t.Errorf("NormalizeQRCodeMode(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
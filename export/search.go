@@ -0,0 +1,93 @@
+package export
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SearchSnippet 是一处匹配及其前后文, 三段拼接即为摘录; 前后文被截断时带省略号。
+type SearchSnippet struct {
+	Before string `json:"before"`
+	Match  string `json:"match"`
+	After  string `json:"after"`
+}
+
+// MessageMatch 是一条包含关键词的消息, Index 为消息在 Conversation.Messages 中的下标。
+type MessageMatch struct {
+	Index    int             `json:"index"`
+	Role     string          `json:"role"`
+	Count    int             `json:"count"`
+	Snippets []SearchSnippet `json:"snippets"`
+}
+
+// SearchMessages 在消息正文中查找 query (忽略大小写, 连续空白视为一个空格), 按消息顺序返回匹配的消息。
+// 每条消息最多返回 maxSnippets 段摘录, 每段在匹配前后各保留约 contextRunes 个字符; Count 为全部匹配数。
+func SearchMessages(conv Conversation, query string, contextRunes, maxSnippets int) []MessageMatch {
+	needle := []rune(strings.Map(unicode.ToLower, strings.Join(strings.Fields(query), " ")))
+	if len(needle) == 0 {
+		return nil
+	}
+	var matches []MessageMatch
+	for idx, msg := range conv.Messages {
+		text := []rune(strings.Join(strings.Fields(msg.Text), " "))
+		// unicode.ToLower 逐字符转换, 转换前后字符数一致, 匹配位置可以直接用于原文。
+		lower := []rune(strings.Map(unicode.ToLower, string(text)))
+		match := MessageMatch{Index: idx, Role: msg.Role}
+		for pos := 0; pos+len(needle) <= len(lower); {
+			found := indexRunes(lower[pos:], needle)
+			if found < 0 {
+				break
+			}
+			start := pos + found
+			end := start + len(needle)
+			match.Count++
+			if len(match.Snippets) < maxSnippets {
+				match.Snippets = append(match.Snippets, snippetAround(text, start, end, contextRunes))
+			}
+			pos = end
+		}
+		if match.Count > 0 {
+			matches = append(matches, match)
+		}
+	}
+	return matches
+}
+
+func indexRunes(haystack, needle []rune) int {
+	for i := 0; i+len(needle) <= len(haystack); i++ {
+		ok := true
+		for j, r := range needle {
+			if haystack[i+j] != r {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return i
+		}
+	}
+	return -1
+}
+
+func snippetAround(text []rune, start, end, context int) SearchSnippet {
+	from := start - context
+	if from < 0 {
+		from = 0
+	}
+	to := end + context
+	if to > len(text) {
+		to = len(text)
+	}
+	snippet := SearchSnippet{
+		Before: string(text[from:start]),
+		Match:  string(text[start:end]),
+		After:  string(text[end:to]),
+	}
+	if from > 0 {
+		snippet.Before = "…" + snippet.Before
+	}
+	if to < len(text) {
+		snippet.After += "…"
+	}
+	return snippet
+}
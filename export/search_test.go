@@ -0,0 +1,50 @@
+package export
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSearchMessages(t *testing.T) {
+	conv := Conversation{Messages: []Message{
+		{Role: "user", Text: "How do I   configure\nNginx?"},
+		{Role: "assistant", Text: "先安装 nginx, 再修改 nginx.conf, 最后重启 NGINX。"},
+		{Role: "user", Text: "谢谢"},
+	}}
+	tests := []struct {
+		name        string
+		query       string
+		context     int
+		maxSnippets int
+		want        []MessageMatch
+	}{
+		{name: "空查询", query: "  ", context: 5, maxSnippets: 3},
+		{name: "没有匹配", query: "apache", context: 5, maxSnippets: 3},
+		{
+			name:        "连续空白视为一个空格",
+			query:       "CONFIGURE  nginx",
+			context:     3,
+			maxSnippets: 3,
+			want: []MessageMatch{{Index: 0, Role: "user", Count: 1, Snippets: []SearchSnippet{
+				{Before: "… I ", Match: "configure Nginx", After: "?"},
+			}}},
+		},
+		{
+			name:        "摘录数受限但计数包含全部匹配",
+			query:       "nginx",
+			context:     2,
+			maxSnippets: 1,
+			want: []MessageMatch{
+				{Index: 0, Role: "user", Count: 1, Snippets: []SearchSnippet{{Before: "…e ", Match: "Nginx", After: "?"}}},
+				{Index: 1, Role: "assistant", Count: 3, Snippets: []SearchSnippet{{Before: "…装 ", Match: "nginx", After: ", …"}}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SearchMessages(conv, tt.query, tt.context, tt.maxSnippets); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SearchMessages(%q) = %+v, want %+v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
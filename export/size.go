@@ -0,0 +1,49 @@
+package export
+
+// 估算导出大小的经验值: 每条消息的角色标题、时间等附加内容, HTML 页面的样式表与脚本,
+// 以及大小未知的图片、语音的平均大小。
+const (
+	estimatedMessageOverhead = 256
+	estimatedHTMLOverhead    = 32 << 10
+	estimatedAssetSize       = 1 << 20
+)
+
+// SizeOptions 描述导出文件的内容, 用于 EstimateSize。
+type SizeOptions struct {
+	// HTML 表示渲染为 HTML, 否则为 Markdown。
+	HTML bool
+	// Assets 为打包到导出文件中的资源类型 (AssetAudio、AssetImage)。
+	Assets map[string]bool
+	// Attachments 表示同时打包用户上传的文件。
+	Attachments bool
+}
+
+// EstimateSize 按消息正文长度估算对话导出后的字节数 (压缩前), 用于写入前检查磁盘空间。
+// HTML 的正文按 1.25 倍计算转义与标签; 上传文件按记录的大小计入, 图片与语音按平均大小计入。
+func EstimateSize(conv Conversation, opts SizeOptions) int64 {
+	size := int64(len(conv.Title)) + estimatedMessageOverhead
+	if opts.HTML {
+		size += estimatedHTMLOverhead
+	}
+	for _, msg := range conv.Messages {
+		text := int64(len(msg.Text))
+		if opts.HTML {
+			text += text / 4
+		}
+		size += text + estimatedMessageOverhead
+		for _, ref := range msg.References {
+			size += int64(len(ref.Title) + len(ref.URL))
+		}
+		for _, asset := range msg.Assets {
+			if opts.Assets[asset.Kind] {
+				size += estimatedAssetSize
+			}
+		}
+	}
+	if opts.Attachments {
+		for _, att := range conv.Attachments {
+			size += att.Size
+		}
+	}
+	return size
+}
@@ -0,0 +1,93 @@
+package export
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/Devoty/openai-backup/client"
+)
+
+// 消息过滤原因, 用于导出报告与调试接口。
+const (
+	SkipReasonTool                 = "工具消息"
+	SkipReasonHiddenSystem         = "隐藏的系统消息"
+	SkipReasonSystemPrompt         = "系统提示指令"
+	SkipReasonCodeToRecipient      = "发送给工具的代码"
+	SkipReasonSearchCall           = "搜索调用"
+	SkipReasonSearchClassification = "搜索分类结果"
+	SkipReasonReasoning            = "推理过程"
+)
+
+const skippedPreviewRunes = 80
+
+// SkippedMessage 记录被过滤规则排除的消息, 便于核对备份是否遗漏重要内容。
+type SkippedMessage struct {
+	MessageID string `json:"message_id"`
+	Role      string `json:"role"`
+	Recipient string `json:"recipient,omitempty"`
+	Reason    string `json:"reason"`
+	Preview   string `json:"preview,omitempty"`
+}
+
+func newSkippedMessage(msg *client.Message, reason, text string) SkippedMessage {
+	preview := strings.Join(strings.Fields(text), " ")
+	if runes := []rune(preview); len(runes) > skippedPreviewRunes {
+		preview = string(runes[:skippedPreviewRunes]) + "…"
+	}
+	return SkippedMessage{
+		MessageID: msg.ID,
+		Role:      chooseRole(msg),
+		Recipient: msg.Recipient,
+		Reason:    reason,
+		Preview:   preview,
+	}
+}
+
+// skipReason 返回消息被过滤的原因, 空字符串表示应当导出。
+func skipReason(msg *client.Message, rendered string) string {
+	role := strings.ToLower(chooseRole(msg))
+	trimmed := strings.TrimSpace(rendered)
+
+	if strings.EqualFold(role, "tool") {
+		return SkipReasonTool
+	}
+
+	var meta struct {
+		IsHidden bool   `json:"is_visually_hidden_from_conversation"`
+		Command  string `json:"command"`
+	}
+	if len(msg.Metadata) > 0 {
+		_ = json.Unmarshal(msg.Metadata, &meta)
+	}
+	if meta.IsHidden && role == "system" {
+		return SkipReasonHiddenSystem
+	}
+	if role == "system" && strings.EqualFold(meta.Command, "prompt") {
+		return SkipReasonSystemPrompt
+	}
+
+	// 推理过程与其摘要不属于回答正文, 记入报告以便核对。
+	if msg.Content.ContentType == "thoughts" || msg.Content.ContentType == "reasoning_recap" {
+		return SkipReasonReasoning
+	}
+
+	if msg.Content.ContentType == "code" && strings.EqualFold(role, "assistant") {
+		if msg.Recipient != "" && !strings.EqualFold(msg.Recipient, "all") {
+			return SkipReasonCodeToRecipient
+		}
+		lower := strings.ToLower(trimmed)
+		if strings.HasPrefix(lower, "search(") || strings.Contains(lower, " search(") {
+			return SkipReasonSearchCall
+		}
+		if len(msg.Metadata) > 0 {
+			var metaMap map[string]any
+			if err := json.Unmarshal(msg.Metadata, &metaMap); err == nil {
+				if _, ok := metaMap["sonic_classification_result"]; ok {
+					return SkipReasonSearchClassification
+				}
+			}
+		}
+	}
+
+	return ""
+}
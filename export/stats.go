@@ -0,0 +1,93 @@
+package export
+
+import (
+	"fmt"
+	"math"
+	"unicode"
+)
+
+// 阅读速度: 按空白分隔的词每分钟 230 个, 中日韩文字每分钟 400 字。
+const (
+	wordsPerMinute     = 230
+	wideRunesPerMinute = 400
+)
+
+// Stats 是消息或对话正文的统计。Words 中每个中日韩文字计为一个词, Characters 不含空白。
+type Stats struct {
+	Words          int `json:"words"`
+	Characters     int `json:"characters"`
+	ReadingSeconds int `json:"reading_seconds"`
+}
+
+// TextStats 统计一段文本的词数、字符数与预计阅读时间。
+func TextStats(text string) Stats {
+	var (
+		stats       Stats
+		words, wide int
+		inWord      bool
+	)
+	for _, r := range text {
+		switch {
+		case unicode.IsSpace(r):
+			inWord = false
+			continue
+		case isWideRune(r):
+			wide++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				words++
+			}
+			inWord = true
+		default:
+			inWord = false
+		}
+		stats.Characters++
+	}
+	stats.Words = words + wide
+	minutes := float64(words)/wordsPerMinute + float64(wide)/wideRunesPerMinute
+	stats.ReadingSeconds = int(math.Ceil(minutes * 60))
+	return stats
+}
+
+// Add 累加另一项统计。
+func (s Stats) Add(other Stats) Stats {
+	return Stats{
+		Words:          s.Words + other.Words,
+		Characters:     s.Characters + other.Characters,
+		ReadingSeconds: s.ReadingSeconds + other.ReadingSeconds,
+	}
+}
+
+// ConversationStats 汇总全部消息的统计, 同时返回每条消息的统计 (与 conv.Messages 一一对应)。
+func ConversationStats(conv Conversation) (Stats, []Stats) {
+	var total Stats
+	perMessage := make([]Stats, len(conv.Messages))
+	for i, msg := range conv.Messages {
+		perMessage[i] = TextStats(msg.Text)
+		total = total.Add(perMessage[i])
+	}
+	return total, perMessage
+}
+
+// FormatReadingTime 把阅读秒数格式化为"约 N 分钟", 不足一分钟时输出"不到 1 分钟"。
+func FormatReadingTime(seconds int) string {
+	if seconds < 60 {
+		return "不到 1 分钟"
+	}
+	return fmt.Sprintf("约 %d 分钟", (seconds+30)/60)
+}
+
+// conversationStatsSummary 是导出文档头部的统计行, 如"12 条消息 · 1200 词 · 3400 字符 · 约 5 分钟"。
+func conversationStatsSummary(conv Conversation) string {
+	total, _ := ConversationStats(conv)
+	return fmt.Sprintf("%d 条消息 · %d 词 · %d 字符 · %s", len(conv.Messages), total.Words, total.Characters, FormatReadingTime(total.ReadingSeconds))
+}
+
+// messageStatsSuffix 是追加在消息标题后的统计, 未开启统计时为空。
+func messageStatsSuffix(msg Message, show bool) string {
+	if !show {
+		return ""
+	}
+	return fmt.Sprintf(" · %d 词", TextStats(msg.Text).Words)
+}
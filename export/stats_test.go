@@ -0,0 +1,61 @@
+package export
+
+import "testing"
+
+func TestTextStats(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want Stats
+	}{
+		{name: "空文本", text: "", want: Stats{}},
+		{name: "英文按空白与标点分词", text: "Hello, world! v2.0", want: Stats{Words: 4, Characters: 16, ReadingSeconds: 2}},
+		{name: "中文每个字计为一个词", text: "你好 世界", want: Stats{Words: 4, Characters: 4, ReadingSeconds: 1}},
+		{name: "中英混排", text: "学习Go语言", want: Stats{Words: 5, Characters: 6, ReadingSeconds: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TextStats(tt.text); got != tt.want {
+				t.Errorf("TextStats(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConversationStats(t *testing.T) {
+	conv := Conversation{Messages: []Message{{Role: "user", Text: "one two"}, {Role: "assistant", Text: "三个字"}}}
+	total, perMessage := ConversationStats(conv)
+	if len(perMessage) != 2 || perMessage[0].Words != 2 || perMessage[1].Words != 3 {
+		t.Fatalf("perMessage = %+v", perMessage)
+	}
+	if total != perMessage[0].Add(perMessage[1]) || total.Characters != 9 {
+		t.Errorf("total = %+v", total)
+	}
+	if got, want := conversationStatsSummary(conv), "2 条消息 · 5 词 · 9 字符 · 不到 1 分钟"; got != want {
+		t.Errorf("conversationStatsSummary() = %q, want %q", got, want)
+	}
+	if got := messageStatsSuffix(conv.Messages[0], false); got != "" {
+		t.Errorf("未开启统计时 messageStatsSuffix() = %q", got)
+	}
+	if got := messageStatsSuffix(conv.Messages[1], true); got != " · 3 词" {
+		t.Errorf("messageStatsSuffix() = %q", got)
+	}
+}
+
+func TestFormatReadingTime(t *testing.T) {
+	tests := []struct {
+		seconds int
+		want    string
+	}{
+		{0, "不到 1 分钟"},
+		{59, "不到 1 分钟"},
+		{60, "约 1 分钟"},
+		{89, "约 1 分钟"},
+		{90, "约 2 分钟"},
+	}
+	for _, tt := range tests {
+		if got := FormatReadingTime(tt.seconds); got != tt.want {
+			t.Errorf("FormatReadingTime(%d) = %q, want %q", tt.seconds, got, tt.want)
+		}
+	}
+}
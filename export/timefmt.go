@@ -0,0 +1,149 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Devoty/openai-backup/logging"
+)
+
+// 预置的时间格式, 也可以直接使用 Go 的时间布局 (需包含年份 2006)。
+const (
+	TimeFormatDefault = "default"
+	TimeFormatISO8601 = "iso8601"
+	TimeFormatZH      = "zh"
+	TimeFormatUS      = "us"
+	TimeFormatEU      = "eu"
+)
+
+const defaultTimeLayout = "2006-01-02 15:04:05"
+
+var timeFormatLayouts = map[string]string{
+	TimeFormatDefault: defaultTimeLayout,
+	TimeFormatISO8601: time.RFC3339,
+	TimeFormatZH:      "2006年1月2日 15:04",
+	TimeFormatUS:      "Jan 2, 2006 3:04 PM",
+	TimeFormatEU:      "02.01.2006 15:04",
+}
+
+// NormalizeTimeFormat 返回预置格式名或自定义布局, 无法识别时返回 TimeFormatDefault。
+func NormalizeTimeFormat(value string) string {
+	value = strings.TrimSpace(value)
+	key := strings.ToLower(value)
+	switch key {
+	case "iso", "rfc3339", "iso-8601":
+		return TimeFormatISO8601
+	}
+	if _, ok := timeFormatLayouts[key]; ok {
+		return key
+	}
+	if strings.Contains(value, "2006") {
+		return value
+	}
+	return TimeFormatDefault
+}
+
+// TimeLayout 返回格式对应的 Go 时间布局。
+func TimeLayout(format string) string {
+	format = NormalizeTimeFormat(format)
+	if layout, ok := timeFormatLayouts[format]; ok {
+		return layout
+	}
+	return format
+}
+
+// FormatTimestampAs 按 format (见 NormalizeTimeFormat) 格式化秒级时间戳, 无效值输出 "-"。
+func FormatTimestampAs(value float64, loc *time.Location, format string) string {
+	if value <= 0 {
+		return "-"
+	}
+	sec := int64(value)
+	nsec := int64((value - float64(sec)) * 1e9)
+	return FormatTimeAs(time.Unix(sec, nsec), loc, format)
+}
+
+// FormatTimeAs 按 format 格式化时间, 零值输出 "-"。
+func FormatTimeAs(t time.Time, loc *time.Location, format string) string {
+	if t.IsZero() {
+		return "-"
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	return t.In(loc).Format(TimeLayout(format))
+}
+
+// LoadSecondLocation 解析第二时区名称, 为空时返回 nil (不附上第二时区)。与 ResolveLocation 不同,
+// 无法识别的名称返回错误而不是退回本地时区, 供保存配置时校验。
+func LoadSecondLocation(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	switch strings.ToLower(name) {
+	case "":
+		return nil, nil
+	case "utc":
+		return time.UTC, nil
+	case "local":
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("无法识别的时区 %q", name)
+	}
+	return loc, nil
+}
+
+// TimeFormatter 是渲染一个对话时使用的时区与时间格式, second 不为空时在括号中附上第二时区的时间。
+// 由 NewTimeFormatter 为每个对话创建一次, 各导出目标用它输出与 Markdown/HTML 一致的时间。
+type TimeFormatter struct {
+	loc    *time.Location
+	second *time.Location
+	format string
+}
+
+// NewTimeFormatter 按对话的 TimeFormat 与 SecondTimezone 创建格式化器, 第二时区只在这里解析一次。
+// 第二时区无法识别时记录日志并不再附上 (保存配置时已校验, 只有手工写入的旧配置会走到这里)。
+func NewTimeFormatter(conv Conversation, loc *time.Location) TimeFormatter {
+	tf := TimeFormatter{loc: loc, format: conv.TimeFormat}
+	second, err := LoadSecondLocation(conv.SecondTimezone)
+	if err != nil {
+		logging.Infof("忽略第二时区: %v", err)
+		return tf
+	}
+	if second != nil && (loc == nil || second.String() != loc.String()) {
+		tf.second = second
+	}
+	return tf
+}
+
+// Format 按格式化器的时区与格式输出时间戳, 无效值输出 "-"。
+func (f TimeFormatter) Format(value float64) string {
+	out := FormatTimestampAs(value, f.loc, f.format)
+	if f.second == nil || out == "-" {
+		return out
+	}
+	return fmt.Sprintf("%s (%s %s)", out, FormatTimestampAs(value, f.second, f.format), f.second)
+}
+
+// FormatRelative 把时间戳描述为相对 now 的时间, 如"3 天前"; 无效值输出 "-", 晚于 now 的时间视为"刚刚"。
+func FormatRelative(value float64, now time.Time) string {
+	if value <= 0 {
+		return "-"
+	}
+	sec := int64(value)
+	d := now.Sub(time.Unix(sec, int64((value-float64(sec))*1e9)))
+	switch {
+	case d < time.Minute:
+		return "刚刚"
+	case d < time.Hour:
+		return fmt.Sprintf("%d 分钟前", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%d 小时前", int(d/time.Hour))
+	case d < 30*24*time.Hour:
+		return fmt.Sprintf("%d 天前", int(d/(24*time.Hour)))
+	case d < 365*24*time.Hour:
+		return fmt.Sprintf("%d 个月前", int(d/(30*24*time.Hour)))
+	default:
+		return fmt.Sprintf("%d 年前", int(d/(365*24*time.Hour)))
+	}
+}
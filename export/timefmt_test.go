@@ -0,0 +1,129 @@
+package export
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNormalizeTimeFormat(t *testing.T) {
+	tests := []struct {
+		value      string
+		want       string
+		wantLayout string
+	}{
+		{"", TimeFormatDefault, defaultTimeLayout},
+		{" ZH ", TimeFormatZH, "2006年1月2日 15:04"},
+		{"RFC3339", TimeFormatISO8601, time.RFC3339},
+		{"2006/01/02", "2006/01/02", "2006/01/02"},
+		{"15:04", TimeFormatDefault, defaultTimeLayout},
+	}
+	for _, tt := range tests {
+		if got := NormalizeTimeFormat(tt.value); got != tt.want {
+			t.Errorf("NormalizeTimeFormat(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+		if got := TimeLayout(tt.value); got != tt.wantLayout {
+			t.Errorf("TimeLayout(%q) = %q, want %q", tt.value, got, tt.wantLayout)
+		}
+	}
+}
+
+func TestFormatTimestampAs(t *testing.T) {
+	ts := float64(time.Date(2024, 3, 1, 13, 5, 9, 0, time.UTC).Unix())
+	loc := time.FixedZone("UTC+8", 8*3600)
+	tests := []struct {
+		name   string
+		value  float64
+		format string
+		want   string
+	}{
+		{name: "默认格式", value: ts, format: "", want: "2024-03-01 21:05:09"},
+		{name: "ISO 8601", value: ts, format: "iso8601", want: "2024-03-01T21:05:09+08:00"},
+		{name: "美式格式", value: ts, format: "us", want: "Mar 1, 2024 9:05 PM"},
+		{name: "欧式格式", value: ts, format: "eu", want: "01.03.2024 21:05"},
+		{name: "自定义布局", value: ts + 0.5, format: "2006-01-02T15:04:05.0", want: "2024-03-01T21:05:09.5"},
+		{name: "无效时间", value: 0, format: "zh", want: "-"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatTimestampAs(tt.value, loc, tt.format); got != tt.want {
+				t.Errorf("FormatTimestampAs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSecondLocation(t *testing.T) {
+	tests := []struct {
+		name     string
+		wantZone string
+		wantErr  bool
+	}{
+		{name: "", wantZone: ""},
+		{name: " utc ", wantZone: "UTC"},
+		{name: "Asia/Tokyo", wantZone: "Asia/Tokyo"},
+		{name: "Mars/Olympus", wantErr: true},
+	}
+	for _, tt := range tests {
+		loc, err := LoadSecondLocation(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("LoadSecondLocation(%q) err = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		var zone string
+		if loc != nil {
+			zone = loc.String()
+		}
+		if zone != tt.wantZone {
+			t.Errorf("LoadSecondLocation(%q) = %q, want %q", tt.name, zone, tt.wantZone)
+		}
+	}
+}
+
+func TestTimeFormatter(t *testing.T) {
+	ts := float64(time.Date(2024, 3, 1, 13, 5, 0, 0, time.UTC).Unix())
+	loc := time.FixedZone("UTC+8", 8*3600)
+	tests := []struct {
+		name string
+		conv Conversation
+		loc  *time.Location
+		want string
+	}{
+		{name: "单一时区", conv: Conversation{TimeFormat: "zh"}, loc: loc, want: "2024年3月1日 21:05"},
+		{name: "附上第二时区", conv: Conversation{TimeFormat: "zh", SecondTimezone: "UTC"}, loc: loc, want: "2024年3月1日 21:05 (2024年3月1日 13:05 UTC)"},
+		{name: "第二时区与主时区相同", conv: Conversation{TimeFormat: "zh", SecondTimezone: "utc"}, loc: time.UTC, want: "2024年3月1日 13:05"},
+		{name: "无法识别的第二时区被忽略", conv: Conversation{TimeFormat: "zh", SecondTimezone: "Mars/Olympus"}, loc: loc, want: "2024年3月1日 21:05"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewTimeFormatter(tt.conv, tt.loc).Format(ts); got != tt.want {
+				t.Errorf("Format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if got := NewTimeFormatter(Conversation{SecondTimezone: "UTC"}, loc).Format(0); got != "-" {
+		t.Errorf("Format(0) = %q, want -", got)
+	}
+}
+
+func TestFormatRelative(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) float64 { return float64(now.Add(-d).Unix()) }
+	tests := []struct {
+		value float64
+		want  string
+	}{
+		{0, "-"},
+		{ago(-time.Hour), "刚刚"},
+		{ago(30 * time.Second), "刚刚"},
+		{ago(5 * time.Minute), "5 分钟前"},
+		{ago(3 * time.Hour), "3 小时前"},
+		{ago(3 * 24 * time.Hour), "3 天前"},
+		{ago(65 * 24 * time.Hour), "2 个月前"},
+		{ago(800 * 24 * time.Hour), "2 年前"},
+	}
+	for _, tt := range tests {
+		if got := FormatRelative(tt.value, now); got != tt.want {
+			t.Errorf("FormatRelative(%v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package export
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// 未命名对话的标题生成方式, 见 FallbackTitle。
+const (
+	TitleFallbackNone         = "none"
+	TitleFallbackFirstMessage = "first_message"
+	TitleFallbackDate         = "date"
+
+	// DefaultTitleFallbackWords 是 first_message 未指定词数时截取的词数。
+	DefaultTitleFallbackWords = 8
+	maxTitleFallbackWords     = 50
+)
+
+// TitleFallback 描述未命名对话的标题生成方式; Words 只对 first_message 生效。
+type TitleFallback struct {
+	Mode  string
+	Words int
+}
+
+// ParseTitleFallback 解析 "none"、"date" 或 "first_message[:词数]", 无法识别时返回 none。
+func ParseTitleFallback(value string) TitleFallback {
+	mode, count, _ := strings.Cut(strings.ToLower(strings.TrimSpace(value)), ":")
+	switch strings.TrimSpace(mode) {
+	case TitleFallbackFirstMessage:
+		words, err := strconv.Atoi(strings.TrimSpace(count))
+		if err != nil || words <= 0 {
+			words = DefaultTitleFallbackWords
+		}
+		if words > maxTitleFallbackWords {
+			words = maxTitleFallbackWords
+		}
+		return TitleFallback{Mode: TitleFallbackFirstMessage, Words: words}
+	case TitleFallbackDate:
+		return TitleFallback{Mode: TitleFallbackDate}
+	default:
+		return TitleFallback{Mode: TitleFallbackNone}
+	}
+}
+
+func (f TitleFallback) String() string {
+	if f.Mode == TitleFallbackFirstMessage {
+		return fmt.Sprintf("%s:%d", f.Mode, f.Words)
+	}
+	return f.Mode
+}
+
+// FallbackTitle 为没有标题的对话生成标题, 对话已有标题或无法生成时返回空字符串。
+func FallbackTitle(conv Conversation, f TitleFallback, loc *time.Location) string {
+	if strings.TrimSpace(conv.Title) != "" {
+		return ""
+	}
+	switch f.Mode {
+	case TitleFallbackFirstMessage:
+		for _, msg := range conv.Messages {
+			if strings.EqualFold(msg.Role, "user") && strings.TrimSpace(msg.Text) != "" {
+				return leadingWords(msg.Text, f.Words)
+			}
+		}
+	case TitleFallbackDate:
+		if ts := chooseTime(conv.CreateTime, conv.UpdateTime); ts > 0 {
+			return "对话 " + time.Unix(int64(ts), 0).In(loc).Format("2006-01-02 15:04")
+		}
+	}
+	return ""
+}
+
+// leadingWords 截取前 limit 个词: 按空白分词, 中日韩文字每个字计为一个词, 标点不计; 截断时追加省略号。
+func leadingWords(text string, limit int) string {
+	text = strings.Join(strings.Fields(stripMarkdownMarks(text)), " ")
+	count, inWord := 0, false
+	for i, r := range text {
+		switch {
+		case unicode.IsSpace(r):
+			inWord = false
+			continue
+		case unicode.IsPunct(r):
+			continue
+		case isWideRune(r):
+			inWord = false
+		case inWord:
+			continue
+		default:
+			inWord = true
+		}
+		if count == limit {
+			return strings.TrimSpace(text[:i]) + "…"
+		}
+		count++
+	}
+	return text
+}
+
+// stripMarkdownMarks 去掉标题中无意义的 Markdown 标记字符。
+func stripMarkdownMarks(text string) string {
+	return strings.NewReplacer("#", " ", "*", " ", "`", " ", ">", " ", "_", " ").Replace(text)
+}
+
+func isWideRune(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// TitlePlaceholder 是标题模板中代表原标题的占位符, 见 DecorateTitle。
+const TitlePlaceholder = "{{title}}"
+
+// NormalizeTitleTemplate 规范化标题模板: 没有 {{title}} 的模板视为前缀, 改写为 "前缀 {{title}}"。
+func NormalizeTitleTemplate(template string) string {
+	template = strings.TrimSpace(template)
+	if template == "" || strings.Contains(template, TitlePlaceholder) {
+		return template
+	}
+	return template + " " + TitlePlaceholder
+}
+
+// DecorateTitle 按模板修饰标题, 使导出的页面在共享的数据库中与原有笔记区分开。模板中的 {{title}} 替换为标题,
+// {{date}}、{{updated}} 为创建与更新日期 (YYYY-MM-DD, 按 loc 计算), {{id}} 为对话 ID。
+// 标题或模板为空时返回原标题。
+func DecorateTitle(conv Conversation, template string, loc *time.Location) string {
+	template = NormalizeTitleTemplate(template)
+	if template == "" || strings.TrimSpace(conv.Title) == "" {
+		return conv.Title
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	day := func(ts float64) string {
+		if ts <= 0 {
+			return ""
+		}
+		return time.Unix(int64(ts), 0).In(loc).Format("2006-01-02")
+	}
+	replacer := strings.NewReplacer(
+		TitlePlaceholder, conv.Title,
+		"{{date}}", day(chooseTime(conv.CreateTime, conv.UpdateTime)),
+		"{{updated}}", day(chooseTime(conv.UpdateTime, conv.CreateTime)),
+		"{{id}}", conv.ID,
+	)
+	return strings.TrimSpace(replacer.Replace(template))
+}
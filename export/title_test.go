@@ -0,0 +1,90 @@
+package export
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTitleFallback(t *testing.T) {
+	tests := []struct {
+		value string
+		want  TitleFallback
+	}{
+		{"", TitleFallback{Mode: TitleFallbackNone}},
+		{"unknown", TitleFallback{Mode: TitleFallbackNone}},
+		{" DATE ", TitleFallback{Mode: TitleFallbackDate}},
+		{"first_message", TitleFallback{Mode: TitleFallbackFirstMessage, Words: DefaultTitleFallbackWords}},
+		{"first_message: 5", TitleFallback{Mode: TitleFallbackFirstMessage, Words: 5}},
+		{"first_message:0", TitleFallback{Mode: TitleFallbackFirstMessage, Words: DefaultTitleFallbackWords}},
+		{"first_message:999", TitleFallback{Mode: TitleFallbackFirstMessage, Words: maxTitleFallbackWords}},
+	}
+	for _, tt := range tests {
+		if got := ParseTitleFallback(tt.value); got != tt.want {
+			t.Errorf("ParseTitleFallback(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+	if got := ParseTitleFallback("first_message:3").String(); got != "first_message:3" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestFallbackTitle(t *testing.T) {
+	created := float64(time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC).Unix())
+	question := []Message{
+		{Role: "assistant", Text: "有什么可以帮你?"},
+		{Role: "user", Text: " "},
+		{Role: "user", Text: "## How do I *configure* `nginx` for HTTPS redirects?"},
+	}
+	tests := []struct {
+		name     string
+		conv     Conversation
+		fallback string
+		want     string
+	}{
+		{name: "已有标题", conv: Conversation{Title: "标题", Messages: question}, fallback: "first_message", want: ""},
+		{name: "不生成标题", conv: Conversation{Messages: question}, fallback: "none", want: ""},
+		{name: "首条提问的前几个词", conv: Conversation{Messages: question}, fallback: "first_message:4", want: "How do I configure…"},
+		{name: "提问不足词数", conv: Conversation{Messages: question}, fallback: "first_message:20", want: "How do I configure nginx for HTTPS redirects?"},
+		{name: "中文每个字计为一个词", conv: Conversation{Messages: []Message{{Role: "user", Text: "如何学习 Go 语言?"}}}, fallback: "first_message:3", want: "如何学…"},
+		{name: "没有提问", conv: Conversation{Messages: question[:2]}, fallback: "first_message", want: ""},
+		{name: "创建日期", conv: Conversation{CreateTime: created}, fallback: "date", want: "对话 2024-03-01 17:30"},
+		{name: "没有时间", conv: Conversation{}, fallback: "date", want: ""},
+	}
+	loc := time.FixedZone("UTC+8", 8*3600)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FallbackTitle(tt.conv, ParseTitleFallback(tt.fallback), loc); got != tt.want {
+				t.Errorf("FallbackTitle() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecorateTitle(t *testing.T) {
+	conv := Conversation{
+		ID:         "c1",
+		Title:      "周报",
+		CreateTime: float64(time.Date(2024, 3, 1, 20, 0, 0, 0, time.UTC).Unix()),
+		UpdateTime: float64(time.Date(2024, 3, 5, 8, 0, 0, 0, time.UTC).Unix()),
+	}
+	tests := []struct {
+		name     string
+		conv     Conversation
+		template string
+		want     string
+	}{
+		{name: "没有模板", conv: conv, template: " ", want: "周报"},
+		{name: "模板视为前缀", conv: conv, template: "[ChatGPT]", want: "[ChatGPT] 周报"},
+		{name: "日期按时区计算", conv: conv, template: "{{date}} {{title}} ({{updated}}, {{id}})", want: "2024-03-02 周报 (2024-03-05, c1)"},
+		{name: "缺少时间", conv: Conversation{ID: "c1", Title: "周报"}, template: "{{title}} {{date}}", want: "周报"},
+		{name: "标题为空", conv: Conversation{ID: "c1"}, template: "[ChatGPT]", want: ""},
+	}
+	loc := time.FixedZone("UTC+8", 8*3600)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DecorateTitle(tt.conv, tt.template, loc); got != tt.want {
+				t.Errorf("DecorateTitle(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// tocLabelLength 是目录中每条消息摘录的最大字符数。
+const tocLabelLength = 40
+
+// tocEntry 是目录中的一项, Level 为 1 时缩进在分组标题之下。
+type tocEntry struct {
+	Anchor string
+	Label  string
+	Level  int
+}
+
+// WantsTOC 报告渲染时是否在文档开头生成目录: 设置了 TOCMinMessages 且消息数达到该值。
+func (c Conversation) WantsTOC() bool {
+	return c.TOCMinMessages > 0 && len(c.Messages) >= c.TOCMinMessages
+}
+
+// messageAnchor 返回第 num 条消息 (从 1 开始) 标题的锚点。
+func messageAnchor(num int) string {
+	return fmt.Sprintf("msg-%d", num)
+}
+
+// groupAnchor 返回第 idx 个分组 (从 0 开始) 标题的锚点。
+func groupAnchor(idx int) string {
+	return fmt.Sprintf("group-%d", idx+1)
+}
+
+// tocEntries 按分组生成目录: 有分组标题时先列标题, 组内消息缩进一级。
+// 消息条目为 "序号. 角色 · 正文第一行", 没有正文时用消息时间。
+func tocEntries(groups []MessageGroup, tf TimeFormatter) []tocEntry {
+	var entries []tocEntry
+	for idx, group := range groups {
+		level := 0
+		if group.Heading != "" {
+			entries = append(entries, tocEntry{Anchor: groupAnchor(idx), Label: group.Heading})
+			level = 1
+		}
+		for offset, msg := range group.Messages {
+			num := group.Start + offset + 1
+			entries = append(entries, tocEntry{
+				Anchor: messageAnchor(num),
+				Label:  fmt.Sprintf("%d. %s · %s", num, strings.ToUpper(firstNonEmpty(msg.Role, "unknown")), tocExcerpt(msg, tf)),
+				Level:  level,
+			})
+		}
+	}
+	return entries
+}
+
+func tocExcerpt(msg Message, tf TimeFormatter) string {
+	for _, line := range strings.Split(msg.Text, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#>*-` "))
+		if line == "" {
+			continue
+		}
+		if runes := []rune(line); len(runes) > tocLabelLength {
+			return string(runes[:tocLabelLength]) + "…"
+		}
+		return line
+	}
+	return tf.Format(msg.CreateTime)
+}
+
+// renderTOCMarkdown 输出 Markdown 目录, 链接指向消息标题前的 <a id> 锚点。
+func renderTOCMarkdown(entries []tocEntry) string {
+	var b strings.Builder
+	b.WriteString("## 目录\n\n")
+	for _, entry := range entries {
+		b.WriteString(fmt.Sprintf("%s- [%s](#%s)\n", strings.Repeat("  ", entry.Level), escapeMarkdownLinkText(entry.Label), entry.Anchor))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+func renderTOCHTML(entries []tocEntry) string {
+	var b strings.Builder
+	b.WriteString("<nav class=\"toc\">\n<h2>目录</h2>\n<ul>\n")
+	for i, entry := range entries {
+		b.WriteString(fmt.Sprintf("<li><a href=\"#%s\">%s</a>", entry.Anchor, html.EscapeString(entry.Label)))
+		next := 0
+		if i+1 < len(entries) {
+			next = entries[i+1].Level
+		}
+		switch {
+		case next > entry.Level:
+			b.WriteString("\n<ul>\n")
+			continue
+		case next < entry.Level:
+			b.WriteString("</li>\n</ul>\n")
+		}
+		b.WriteString("</li>\n")
+	}
+	b.WriteString("</ul>\n</nav>\n")
+	return b.String()
+}
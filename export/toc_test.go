@@ -0,0 +1,104 @@
+package export
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWantsTOC(t *testing.T) {
+	msgs := make([]Message, 5)
+	tests := []struct {
+		min  int
+		want bool
+	}{
+		{0, false},
+		{5, true},
+		{6, false},
+	}
+	for _, tt := range tests {
+		if got := (Conversation{Messages: msgs, TOCMinMessages: tt.min}).WantsTOC(); got != tt.want {
+			t.Errorf("WantsTOC(%d) = %v, want %v", tt.min, got, tt.want)
+		}
+	}
+}
+
+func TestTOCEntries(t *testing.T) {
+	created := float64(time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC).Unix())
+	tf := TimeFormatter{loc: time.UTC}
+	tests := []struct {
+		name   string
+		groups []MessageGroup
+		want   []tocEntry
+	}{
+		{
+			name: "没有分组标题",
+			groups: []MessageGroup{{Messages: []Message{
+				{Role: "user", Text: "\n## 如何配置 [nginx]?\n正文"},
+				{Role: "", Text: "", CreateTime: created},
+			}}},
+			want: []tocEntry{
+				{Anchor: "msg-1", Label: "1. USER · 如何配置 [nginx]?"},
+				{Anchor: "msg-2", Label: "2. UNKNOWN · 2024-03-01 09:30:00"},
+			},
+		},
+		{
+			name: "组内消息缩进并连续编号",
+			groups: []MessageGroup{
+				{Heading: "部署", Messages: []Message{{Role: "user", Text: strings.Repeat("长", 45)}}},
+				{Heading: "监控", Start: 1, Messages: []Message{{Role: "assistant", Text: "> 引用"}}},
+			},
+			want: []tocEntry{
+				{Anchor: "group-1", Label: "部署"},
+				{Anchor: "msg-1", Label: "1. USER · " + strings.Repeat("长", tocLabelLength) + "…", Level: 1},
+				{Anchor: "group-2", Label: "监控"},
+				{Anchor: "msg-2", Label: "2. ASSISTANT · 引用", Level: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tocEntries(tt.groups, tf); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tocEntries() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderTOC(t *testing.T) {
+	entries := []tocEntry{
+		{Anchor: "group-1", Label: "部署"},
+		{Anchor: "msg-1", Label: "1. USER · [a] <b>", Level: 1},
+		{Anchor: "msg-2", Label: "2. ASSISTANT · 完成", Level: 1},
+		{Anchor: "msg-3", Label: "3. USER · 谢谢"},
+	}
+	tests := []struct {
+		name   string
+		render func([]tocEntry) string
+		want   string
+	}{
+		{
+			name:   "Markdown",
+			render: renderTOCMarkdown,
+			want:   "## 目录\n\n- [部署](#group-1)\n  - [1. USER · \\[a\\] <b>](#msg-1)\n  - [2. ASSISTANT · 完成](#msg-2)\n- [3. USER · 谢谢](#msg-3)\n\n",
+		},
+		{
+			name:   "HTML",
+			render: renderTOCHTML,
+			want: "<nav class=\"toc\">\n<h2>目录</h2>\n<ul>\n" +
+				"<li><a href=\"#group-1\">部署</a>\n<ul>\n" +
+				"<li><a href=\"#msg-1\">1. USER · [a] &lt;b&gt;</a></li>\n" +
+				"<li><a href=\"#msg-2\">2. ASSISTANT · 完成</a></li>\n</ul>\n</li>\n" +
+				"<li><a href=\"#msg-3\">3. USER · 谢谢</a></li>\n" +
+				"</ul>\n</nav>\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.render(entries); got != tt.want {
+				t.Errorf("render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
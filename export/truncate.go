@@ -0,0 +1,114 @@
+package export
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// truncateHeadShare 是截断时保留开头部分所占的比例, 其余留给结尾: 日志等内容的结尾往往是错误信息。
+const truncateHeadShare = 2.0 / 3
+
+// truncateLineSlack 是截断位置为了落在行尾最多回退的比例, 超出时直接在字符处截断。
+const truncateLineSlack = 0.2
+
+// TruncateMessages 把超过 maxChars 个字符的消息截为开头与结尾两部分, 中间以省略说明代替;
+// keepFull 为 true 时在 Message.FullText 中保留原文 (已删除消息除外), 供导出压缩包写为附件。maxChars 不大于 0 时不截断。
+func TruncateMessages(conv Conversation, maxChars int, keepFull bool) Conversation {
+	if maxChars <= 0 {
+		return conv
+	}
+	truncate := func(msgs []Message, keepFull bool) []Message {
+		var out []Message
+		for i, msg := range msgs {
+			text, ok := TruncateText(msg.Text, maxChars)
+			if !ok {
+				continue
+			}
+			if out == nil {
+				out = append([]Message(nil), msgs...)
+			}
+			if keepFull {
+				out[i].FullText = msg.Text
+			}
+			out[i].Text = text
+		}
+		if out == nil {
+			return msgs
+		}
+		return out
+	}
+	conv.Messages = truncate(conv.Messages, keepFull)
+	conv.Removed = truncate(conv.Removed, false)
+	return conv
+}
+
+// TruncateText 在 text 超过 maxChars 个字符时保留开头与结尾, 截断位置尽量落在行尾,
+// 被截断的代码块会补上围栏, 中间插入省略的字符数与行数; 返回值的第二项表示是否截断。
+func TruncateText(text string, maxChars int) (string, bool) {
+	runes := []rune(text)
+	if maxChars <= 0 || len(runes) <= maxChars {
+		return text, false
+	}
+	headLen := int(float64(maxChars) * truncateHeadShare)
+	tailLen := maxChars - headLen
+	head := runes[:headLen]
+	if cut := lastIndexRune(head, '\n'); cut >= 0 && float64(headLen-cut) <= float64(headLen)*truncateLineSlack {
+		head = head[:cut]
+	}
+	tail := runes[len(runes)-tailLen:]
+	if cut := indexRune(tail, '\n'); cut >= 0 && float64(cut) <= float64(tailLen)*truncateLineSlack {
+		tail = tail[cut+1:]
+	}
+	omitted := runes[len(head) : len(runes)-len(tail)]
+
+	headText := strings.TrimRight(string(head), "\n")
+	tailText := strings.TrimLeft(string(tail), "\n")
+	var b strings.Builder
+	b.WriteString(headText)
+	// 截断位置在代码块内时, 先闭合开头部分的代码块, 结尾部分再重新打开, 避免后续内容都被当作代码。
+	if countFences(headText)%2 == 1 {
+		b.WriteString("\n```")
+	}
+	b.WriteString(fmt.Sprintf("\n\n…… 已省略 %d 个字符（%d 行） ……\n\n", len(omitted), strings.Count(strings.Trim(string(omitted), "\n"), "\n")+1))
+	if countFences(headText+"\n"+string(omitted))%2 == 1 {
+		b.WriteString("```\n")
+	}
+	b.WriteString(tailText)
+	return b.String(), true
+}
+
+// FullTextArchivePath 返回第 index 条 (从 0 开始) 消息全文写入导出包时的相对路径。
+func FullTextArchivePath(conv Conversation, index int) string {
+	id := firstNonEmpty(sanitizeFilenamePart(conv.ID), "conversation")
+	return path.Join(attachmentsDirName, fmt.Sprintf("%s-message-%d.txt", id, index+1))
+}
+
+// countFences 统计以 ``` 开头的行数, 奇数表示文本结束时仍在代码块内。
+func countFences(text string) int {
+	count := 0
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			count++
+		}
+	}
+	return count
+}
+
+func indexRune(runes []rune, r rune) int {
+	for i, c := range runes {
+		if c == r {
+			return i
+		}
+	}
+	return -1
+}
+
+func lastIndexRune(runes []rune, r rune) int {
+	for i := len(runes) - 1; i >= 0; i-- {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,110 @@
+package export
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTruncateText(t *testing.T) {
+	code := "intro\n```go\n" + strings.Repeat("x := 1\n", 10) + "```\nend"
+	tests := []struct {
+		name         string
+		text         string
+		maxChars     int
+		want         string
+		wantContains []string
+		wantOK       bool
+	}{
+		{name: "未超过上限", text: "短消息", maxChars: 3, want: "短消息"},
+		{name: "上限为 0 时不截断", text: "短消息", maxChars: 0, want: "短消息"},
+		{
+			name:     "保留开头与结尾",
+			text:     strings.Repeat("a", 30),
+			maxChars: 9,
+			want:     "aaaaaa\n\n…… 已省略 21 个字符（1 行） ……\n\naaa",
+			wantOK:   true,
+		},
+		{
+			name:     "截断位置落在行尾",
+			text:     "aaaa\nbbbb\ncccc\ndddd\neeee",
+			maxChars: 15,
+			want:     "aaaa\nbbbb\n\n…… 已省略 11 个字符（2 行） ……\n\neeee",
+			wantOK:   true,
+		},
+		{
+			name:         "代码块内截断时补上围栏",
+			text:         code,
+			maxChars:     30,
+			wantContains: []string{"intro\n```go\n", "\n```\n\n…… 已省略", "……\n\n```\n", "\n```\nend"},
+			wantOK:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := TruncateText(tt.text, tt.maxChars)
+			if ok != tt.wantOK {
+				t.Fatalf("TruncateText() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if tt.wantContains == nil && got != tt.want {
+				t.Errorf("TruncateText() = %q, want %q", got, tt.want)
+			}
+			for _, part := range tt.wantContains {
+				if !strings.Contains(got, part) {
+					t.Errorf("TruncateText() = %q, 缺少 %q", got, part)
+				}
+			}
+			if countFences(got)%2 != 0 {
+				t.Errorf("TruncateText() = %q, 代码块未闭合", got)
+			}
+		})
+	}
+}
+
+func TestTruncateMessages(t *testing.T) {
+	long := strings.Repeat("a", 30)
+	conv := Conversation{
+		Messages: []Message{{Role: "user", Text: "短"}, {Role: "assistant", Text: long}},
+		Removed:  []Message{{Role: "assistant", Text: long}},
+	}
+	tests := []struct {
+		name     string
+		maxChars int
+		keepFull bool
+		wantFull string
+		wantCut  bool
+	}{
+		{name: "不限制长度", maxChars: 0},
+		{name: "截断但不保留原文", maxChars: 9, wantCut: true},
+		{name: "截断并保留原文", maxChars: 9, keepFull: true, wantFull: long, wantCut: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TruncateMessages(conv, tt.maxChars, tt.keepFull)
+			if got.Messages[0].Text != "短" || (got.Messages[1].Text != long) == !tt.wantCut || got.Messages[1].FullText != tt.wantFull {
+				t.Errorf("Messages = %+v", got.Messages)
+			}
+			if (got.Removed[0].Text != long) == !tt.wantCut || got.Removed[0].FullText != "" {
+				t.Errorf("已删除消息 = %+v", got.Removed)
+			}
+			if conv.Messages[1].Text != long || conv.Messages[1].FullText != "" || conv.Removed[0].Text != long {
+				t.Errorf("传入的对话被修改: %+v", conv)
+			}
+		})
+	}
+}
+
+func TestFullTextArchivePath(t *testing.T) {
+	tests := []struct {
+		id    string
+		index int
+		want  string
+	}{
+		{"c1", 0, "attachments/c1-message-1.txt"},
+		{"", 2, "attachments/conversation-message-3.txt"},
+	}
+	for _, tt := range tests {
+		if got := FullTextArchivePath(Conversation{ID: tt.id}, tt.index); got != tt.want {
+			t.Errorf("FullTextArchivePath(%q, %d) = %q, want %q", tt.id, tt.index, got, tt.want)
+		}
+	}
+}
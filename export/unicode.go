@@ -0,0 +1,141 @@
+package export
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// UnicodeOptions 控制写入目标前的文本规范化, 用于兼容对罕见字符处理不佳的目标与文件系统。
+type UnicodeOptions struct {
+	// NFC 把文本转为 NFC 组合形式, 避免同一字符在不同目标中显示或搜索不一致。
+	NFC bool
+	// StripControl 删除换行与制表符以外的控制字符、非字符码位与孤立的变体选择符。
+	StripControl bool
+}
+
+// Enabled 判断是否需要处理。
+func (o UnicodeOptions) Enabled() bool {
+	return o.NFC || o.StripControl
+}
+
+// NormalizeText 按选项规范化文本, 同时去掉无效的 UTF-8 字节。
+func NormalizeText(text string, opts UnicodeOptions) string {
+	if text == "" || !opts.Enabled() {
+		return text
+	}
+	text = strings.ToValidUTF8(text, "")
+	if opts.StripControl {
+		text = stripControl(text)
+	}
+	if opts.NFC {
+		text = norm.NFC.String(text)
+	}
+	return text
+}
+
+// NormalizeConversation 返回规范化后的副本, 不修改传入的对话。
+func NormalizeConversation(conv Conversation, opts UnicodeOptions) Conversation {
+	if !opts.Enabled() {
+		return conv
+	}
+	conv.Title = NormalizeText(conv.Title, opts)
+	conv.Messages = normalizeMessages(conv.Messages, opts)
+	conv.Removed = normalizeMessages(conv.Removed, opts)
+	if conv.Context != nil {
+		entries := make([]ContextEntry, len(conv.Context))
+		for i, entry := range conv.Context {
+			entries[i] = ContextEntry{Label: NormalizeText(entry.Label, opts), Text: NormalizeText(entry.Text, opts)}
+		}
+		conv.Context = entries
+	}
+	if conv.Attachments != nil {
+		attachments := make([]Attachment, len(conv.Attachments))
+		for i, att := range conv.Attachments {
+			att.Name = NormalizeText(att.Name, opts)
+			attachments[i] = att
+		}
+		conv.Attachments = attachments
+	}
+	if conv.Related != nil {
+		related := make([]RelatedConversation, len(conv.Related))
+		for i, item := range conv.Related {
+			item.Title = NormalizeText(item.Title, opts)
+			related[i] = item
+		}
+		conv.Related = related
+	}
+	return conv
+}
+
+func normalizeMessages(msgs []Message, opts UnicodeOptions) []Message {
+	if msgs == nil {
+		return nil
+	}
+	out := make([]Message, len(msgs))
+	for i, msg := range msgs {
+		msg.Text = NormalizeText(msg.Text, opts)
+		if msg.References != nil {
+			refs := make([]Reference, len(msg.References))
+			for j, ref := range msg.References {
+				ref.Title = NormalizeText(ref.Title, opts)
+				ref.Source = NormalizeText(ref.Source, opts)
+				refs[j] = ref
+			}
+			msg.References = refs
+		}
+		out[i] = msg
+	}
+	return out
+}
+
+func stripControl(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	var prev rune = -1
+	for _, r := range text {
+		switch {
+		case r == '\n' || r == '\t':
+		case unicode.IsControl(r), isNoncharacter(r):
+			continue
+		case isVariationSelector(r) && (prev < 0 || unicode.IsSpace(prev) || unicode.IsControl(prev)):
+			// 前面没有基础字符的变体选择符会在部分目标中显示为方框。
+			continue
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+	return b.String()
+}
+
+func isNoncharacter(r rune) bool {
+	return r >= 0xFDD0 && r <= 0xFDEF || r&0xFFFE == 0xFFFE
+}
+
+func isVariationSelector(r rune) bool {
+	return r >= 0xFE00 && r <= 0xFE0F || r >= 0xE0100 && r <= 0xE01EF
+}
+
+// isEmojiRune 判断字符是否属于 emoji 及其组合用的连接符、变体选择符、肤色与标签字符。
+func isEmojiRune(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF, // 麻将牌、扑克、表情、符号与象形文字、交通、补充符号、国旗区域指示符
+		r >= 0x2600 && r <= 0x27BF,   // 杂项符号与装饰符号
+		r >= 0x2B00 && r <= 0x2BFF,   // 杂项符号与箭头
+		r >= 0xE0020 && r <= 0xE007F, // 标签字符 (地区旗帜)
+		r == 0x200D, r == 0x20E3:
+		return true
+	}
+	return isVariationSelector(r)
+}
+
+// StripEmoji 删除文本中的 emoji, 用于生成兼容性更好的文件名。
+func StripEmoji(text string) string {
+	return strings.Map(func(r rune) rune {
+		if isEmojiRune(r) {
+			return -1
+		}
+		return r
+	}, text)
+}
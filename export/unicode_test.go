@@ -0,0 +1,63 @@
+package export
+
+import "testing"
+
+func TestNormalizeText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		opts UnicodeOptions
+		want string
+	}{
+		{name: "未启用时原样返回", text: "e\u0301\x00", opts: UnicodeOptions{}, want: "e\u0301\x00"},
+		{name: "转为 NFC", text: "cafe\u0301", opts: UnicodeOptions{NFC: true}, want: "caf\u00e9"},
+		{name: "去掉无效 UTF-8", text: "a\xffb", opts: UnicodeOptions{NFC: true}, want: "ab"},
+		{name: "保留换行与制表符", text: "a\n\tb\x00\x1bc\r", opts: UnicodeOptions{StripControl: true}, want: "a\n\tbc"},
+		{name: "删除非字符码位", text: "a\uFDD0b\U0001FFFE", opts: UnicodeOptions{StripControl: true}, want: "ab"},
+		{name: "删除孤立的变体选择符", text: "\uFE0F开头 \uFE0F❤\uFE0F", opts: UnicodeOptions{StripControl: true}, want: "开头 ❤\uFE0F"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeText(tt.text, tt.opts); got != tt.want {
+				t.Errorf("NormalizeText(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeConversation(t *testing.T) {
+	conv := Conversation{
+		Title:       "cafe\u0301",
+		Messages:    []Message{{Role: "user", Text: "a\x00b", References: []Reference{{Title: "x\x07", Source: "s"}}}},
+		Context:     []ContextEntry{{Label: "l\x00", Text: "t\x00"}},
+		Attachments: []Attachment{{Name: "n\x00.png"}},
+		Related:     []RelatedConversation{{Title: "r\x00"}},
+	}
+	got := NormalizeConversation(conv, UnicodeOptions{NFC: true, StripControl: true})
+	if got.Title != "caf\u00e9" || got.Messages[0].Text != "ab" || got.Messages[0].References[0].Title != "x" ||
+		got.Context[0].Label != "l" || got.Context[0].Text != "t" || got.Attachments[0].Name != "n.png" || got.Related[0].Title != "r" {
+		t.Errorf("NormalizeConversation() = %+v", got)
+	}
+	if conv.Messages[0].Text != "a\x00b" || conv.Messages[0].References[0].Title != "x\x07" ||
+		conv.Context[0].Label != "l\x00" || conv.Attachments[0].Name != "n\x00.png" || conv.Related[0].Title != "r\x00" {
+		t.Errorf("传入的对话被修改: %+v", conv)
+	}
+}
+
+func TestStripEmoji(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"周报 📊", "周报 "},
+		{"家庭👨‍👩‍👧计划", "家庭计划"},
+		{"1\uFE0F\u20E3 第一步", "1 第一步"},
+		{"☀️ 天气 🇨🇳", " 天气 "},
+		{"普通标题 (v2)", "普通标题 (v2)"},
+	}
+	for _, tt := range tests {
+		if got := StripEmoji(tt.text); got != tt.want {
+			t.Errorf("StripEmoji(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
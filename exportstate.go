@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Devoty/openai-backup/client"
+)
+
+const exportStateSchema = `
+	CREATE TABLE IF NOT EXISTS export_state (
+		conversation_id TEXT NOT NULL,
+		target TEXT NOT NULL,
+		object_id TEXT NOT NULL DEFAULT '',
+		url TEXT NOT NULL DEFAULT '',
+		exported_at TIMESTAMP NOT NULL,
+		PRIMARY KEY(conversation_id, target)
+	);`
+
+// exportState 记录某条对话在某个目标上的最近一次成功导出。
+type exportState struct {
+	ConversationID string
+	Target         string
+	ObjectID       string
+	URL            string
+	ExportedAt     time.Time
+}
+
+// conversationExportStatus 汇总单条对话在所有目标上的导出情况。
+type conversationExportStatus struct {
+	LastExportedAt time.Time
+	Targets        []string
+}
+
+// RecordExportState 写入或覆盖对话在目标上的导出状态。
+func (s *ConfigStore) RecordExportState(ctx context.Context, state exportState) error {
+	if s == nil || s.archive == nil {
+		return errors.New("配置存储未初始化")
+	}
+	if state.ExportedAt.IsZero() {
+		state.ExportedAt = time.Now()
+	}
+	_, err := s.archive.writer.ExecContext(ctx, `
+		INSERT INTO export_state(conversation_id, target, object_id, url, exported_at)
+		VALUES(?, ?, ?, ?, ?)
+		ON CONFLICT(conversation_id, target) DO UPDATE SET
+			object_id=excluded.object_id,
+			url=excluded.url,
+			exported_at=excluded.exported_at
+	`, state.ConversationID, state.Target, state.ObjectID, state.URL, state.ExportedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("写入导出状态失败: %w", err)
+	}
+	return nil
+}
+
+// ExportStatusByConversation 按对话 ID 批量查询导出状态, 未导出的对话不会出现在结果中。
+func (s *ConfigStore) ExportStatusByConversation(ctx context.Context, ids []string) (map[string]conversationExportStatus, error) {
+	if s == nil || s.archive == nil {
+		return nil, errors.New("配置存储未初始化")
+	}
+	result := make(map[string]conversationExportStatus, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+	args := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	placeholders := strings.TrimRight(strings.Repeat("?,", len(ids)), ",")
+	rows, err := s.archive.reader.QueryContext(ctx, `SELECT conversation_id, target, exported_at FROM export_state WHERE conversation_id IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("读取导出状态失败: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			id, target string
+			exportedAt time.Time
+		)
+		if err := rows.Scan(&id, &target, &exportedAt); err != nil {
+			return nil, fmt.Errorf("解析导出状态失败: %w", err)
+		}
+		status := result[id]
+		status.Targets = append(status.Targets, target)
+		if exportedAt.After(status.LastExportedAt) {
+			status.LastExportedAt = exportedAt
+		}
+		result[id] = status
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取导出状态失败: %w", err)
+	}
+	for id, status := range result {
+		sort.Strings(status.Targets)
+		result[id] = status
+	}
+	return result, nil
+}
+
+// exportStatusLookup 查询列表页对应的导出状态, 失败时仅记录日志, 不影响列表返回。
+func (s *webServer) exportStatusLookup(ctx context.Context, metas []client.ConversationMeta) map[string]conversationExportStatus {
+	if s.store == nil || len(metas) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(metas))
+	for _, meta := range metas {
+		ids = append(ids, meta.ID)
+	}
+	statuses, err := s.store.ExportStatusByConversation(ctx, ids)
+	if err != nil {
+		logInfo("查询导出状态失败: %v", err)
+		return nil
+	}
+	return statuses
+}
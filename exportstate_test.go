@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Devoty/openai-backup/client"
+)
+
+func newTestStore(t *testing.T) *ConfigStore {
+	t.Helper()
+	store, err := Init(filepath.Join(t.TempDir(), "app.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestExportStatusByConversation(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	base := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	for _, state := range []exportState{
+		{ConversationID: "c1", Target: "notion", ObjectID: "p1", ExportedAt: base},
+		{ConversationID: "c1", Target: "markdown", ExportedAt: base.Add(time.Hour)},
+		{ConversationID: "c2", Target: "notion", ExportedAt: base},
+		// 再次导出时覆盖同一目标的记录。
+		{ConversationID: "c2", Target: "notion", ObjectID: "p2", ExportedAt: base.Add(2 * time.Hour)},
+	} {
+		if err := store.RecordExportState(ctx, state); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name string
+		ids  []string
+		want map[string]conversationExportStatus
+	}{
+		{name: "没有对话", ids: nil, want: map[string]conversationExportStatus{}},
+		{
+			name: "多个目标取最近一次",
+			ids:  []string{"c1", "c2", "c3"},
+			want: map[string]conversationExportStatus{
+				"c1": {LastExportedAt: base.Add(time.Hour), Targets: []string{"markdown", "notion"}},
+				"c2": {LastExportedAt: base.Add(2 * time.Hour), Targets: []string{"notion"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.ExportStatusByConversation(ctx, tt.ids)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ExportStatusByConversation() = %v, want %v", got, tt.want)
+			}
+			for id, want := range tt.want {
+				if !got[id].LastExportedAt.Equal(want.LastExportedAt) || !reflect.DeepEqual(got[id].Targets, want.Targets) {
+					t.Errorf("%s: %+v, want %+v", id, got[id], want)
+				}
+			}
+		})
+	}
+
+	s := &webServer{store: store}
+	if got := s.exportStatusLookup(ctx, []client.ConversationMeta{{ID: "c2"}}); len(got) != 1 || got["c2"].Targets[0] != "notion" {
+		t.Errorf("exportStatusLookup() = %v", got)
+	}
+	if got := (&webServer{}).exportStatusLookup(ctx, []client.ConversationMeta{{ID: "c2"}}); got != nil {
+		t.Errorf("没有存储时 exportStatusLookup() = %v", got)
+	}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Devoty/openai-backup/targets"
+)
+
+const failedExportsSchema = `
+	CREATE TABLE IF NOT EXISTS failed_exports (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		conversation_id TEXT NOT NULL,
+		title TEXT NOT NULL DEFAULT '',
+		target TEXT NOT NULL,
+		error TEXT NOT NULL,
+		retry_count INTEGER NOT NULL DEFAULT 0,
+		payload_ref TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		UNIQUE(conversation_id, target)
+	);`
+
+type failedExport struct {
+	ID             int64     `json:"id"`
+	ConversationID string    `json:"conversation_id"`
+	Title          string    `json:"title"`
+	Target         string    `json:"target"`
+	Error          string    `json:"error"`
+	RetryCount     int       `json:"retry_count"`
+	PayloadRef     string    `json:"payload_ref"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type failureRetryRequest struct {
+	IDs []int64 `json:"ids"`
+}
+
+// conversationPayloadRef 描述重试时重新获取对话内容的来源。
+func conversationPayloadRef(conversationID string) string {
+	return "chatgpt:conversation/" + conversationID
+}
+
+// RecordFailedExport 写入或更新失败记录, 重复失败时累加重试次数。
+func (s *ConfigStore) RecordFailedExport(ctx context.Context, conversationID, title, target, message string) error {
+	if s == nil || s.archive == nil {
+		return errors.New("配置存储未初始化")
+	}
+	now := time.Now().UTC()
+	_, err := s.archive.writer.ExecContext(ctx, `
+		INSERT INTO failed_exports(conversation_id, title, target, error, retry_count, payload_ref, created_at, updated_at)
+		VALUES(?, ?, ?, ?, 0, ?, ?, ?)
+		ON CONFLICT(conversation_id, target) DO UPDATE SET
+			title=CASE WHEN excluded.title != '' THEN excluded.title ELSE failed_exports.title END,
+			error=excluded.error,
+			retry_count=failed_exports.retry_count + 1,
+			updated_at=excluded.updated_at
+	`, conversationID, title, target, message, conversationPayloadRef(conversationID), now, now)
+	if err != nil {
+		return fmt.Errorf("写入失败记录失败: %w", err)
+	}
+	return nil
+}
+
+// ClearFailedExport 在导出成功后移除对应的失败记录。
+func (s *ConfigStore) ClearFailedExport(ctx context.Context, conversationID, target string) error {
+	if s == nil || s.archive == nil {
+		return errors.New("配置存储未初始化")
+	}
+	if _, err := s.archive.writer.ExecContext(ctx, `DELETE FROM failed_exports WHERE conversation_id = ? AND target = ?`, conversationID, target); err != nil {
+		return fmt.Errorf("清理失败记录失败: %w", err)
+	}
+	return nil
+}
+
+// ListFailedExports 返回失败记录, target 为空时返回全部。
+func (s *ConfigStore) ListFailedExports(ctx context.Context, target string) ([]failedExport, error) {
+	if s == nil || s.archive == nil {
+		return nil, errors.New("配置存储未初始化")
+	}
+	query := `SELECT id, conversation_id, title, target, error, retry_count, payload_ref, created_at, updated_at FROM failed_exports`
+	var args []interface{}
+	if target != "" {
+		query += ` WHERE target = ?`
+		args = append(args, target)
+	}
+	query += ` ORDER BY updated_at DESC`
+	return s.queryFailedExports(ctx, query, args...)
+}
+
+func (s *ConfigStore) FailedExportsByID(ctx context.Context, ids []int64) ([]failedExport, error) {
+	if s == nil || s.archive == nil {
+		return nil, errors.New("配置存储未初始化")
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	args := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	placeholders := strings.TrimRight(strings.Repeat("?,", len(ids)), ",")
+	query := `SELECT id, conversation_id, title, target, error, retry_count, payload_ref, created_at, updated_at FROM failed_exports WHERE id IN (` + placeholders + `) ORDER BY id`
+	return s.queryFailedExports(ctx, query, args...)
+}
+
+func (s *ConfigStore) queryFailedExports(ctx context.Context, query string, args ...interface{}) ([]failedExport, error) {
+	rows, err := s.archive.reader.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("读取失败记录失败: %w", err)
+	}
+	defer rows.Close()
+	var items []failedExport
+	for rows.Next() {
+		var item failedExport
+		if err := rows.Scan(&item.ID, &item.ConversationID, &item.Title, &item.Target, &item.Error, &item.RetryCount, &item.PayloadRef, &item.CreatedAt, &item.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("解析失败记录失败: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取失败记录失败: %w", err)
+	}
+	return items, nil
+}
+
+// recordSyncResult 将一次同步的结果落库: 成功的清除旧失败记录并更新导出状态, 失败的写入失败队列。
+func (s *webServer) recordSyncResult(target string, result syncResult) {
+	if s.store == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	drafts := target == exportTargetNotion && len(result.Exported) > 0 && s.notionDraftsEnabled()
+	for _, item := range result.Exported {
+		if err := s.store.ClearFailedExport(ctx, item.ConversationID, target); err != nil {
+			logInfo("清理失败记录失败: conversation=%s err=%v", item.ConversationID, err)
+		}
+		state := exportState{ConversationID: item.ConversationID, Target: target, ObjectID: item.ObjectID, URL: item.URL}
+		if err := s.store.RecordExportState(ctx, state); err != nil {
+			logInfo("记录导出状态失败: conversation=%s err=%v", item.ConversationID, err)
+		}
+		if drafts {
+			draft := notionDraft{ConversationID: item.ConversationID, PageID: item.ObjectID, URL: item.URL, Title: item.Title}
+			if err := s.store.RecordNotionDraft(ctx, draft); err != nil {
+				logInfo("记录 Notion 草稿失败: conversation=%s err=%v", item.ConversationID, err)
+			}
+		}
+	}
+	// 已删除或无权访问的对话重试也不会成功, 从失败队列中移除。
+	for _, item := range result.Unavailable {
+		if err := s.store.ClearFailedExport(ctx, item.ConversationID, target); err != nil {
+			logInfo("清理失败记录失败: conversation=%s err=%v", item.ConversationID, err)
+		}
+	}
+	for _, item := range result.Failed {
+		if err := s.store.RecordFailedExport(ctx, item.ConversationID, item.Title, target, item.Error); err != nil {
+			logInfo("记录导出失败失败: conversation=%s err=%v", item.ConversationID, err)
+		}
+	}
+}
+
+func (s *webServer) handleFailures(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+		return
+	}
+	target := strings.TrimSpace(r.URL.Query().Get("target"))
+	items, err := s.store.ListFailedExports(r.Context(), target)
+	if err != nil {
+		writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "读取失败记录失败", err)
+		return
+	}
+	if items == nil {
+		items = []failedExport{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"items": items,
+		"count": len(items),
+	})
+}
+
+func (s *webServer) handleFailureRetry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+		return
+	}
+	var req failureRetryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorDetail(w, http.StatusBadRequest, errCodeInvalidRequest, "请求体解析失败", err)
+		return
+	}
+	if len(req.IDs) == 0 {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "请选择至少一条失败记录")
+		return
+	}
+
+	ctx := r.Context()
+	items, err := s.store.FailedExportsByID(ctx, req.IDs)
+	if err != nil {
+		writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "读取失败记录失败", err)
+		return
+	}
+	if len(items) == 0 {
+		writeError(w, http.StatusNotFound, errCodeNotFound, "未找到对应的失败记录")
+		return
+	}
+
+	cfg := s.configSnapshot()
+	byTarget := make(map[string][]failedExport)
+	var order []string
+	for _, item := range items {
+		if _, ok := byTarget[item.Target]; !ok {
+			order = append(order, item.Target)
+		}
+		byTarget[item.Target] = append(byTarget[item.Target], item)
+	}
+
+	exporters := make(map[string]targets.Exporter, len(order))
+	labels := make(map[string]string, len(order))
+	for _, target := range order {
+		exporter, label, err := s.resolveExporter(target)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, errCodeTargetMisconfigured, err.Error())
+			return
+		}
+		exporters[target] = exporter
+		labels[target] = label
+	}
+
+	// 同一对话可能在多个目标上失败, 只拉取一次, 暂存到其余目标用完为止。
+	uses := make(map[string]int, len(items))
+	for _, item := range items {
+		uses[item.ConversationID]++
+	}
+	store := newSpillStore(spillThresholdBytes(cfg))
+	defer store.Close()
+	fetch := s.sharedFetcher(store, uses)
+
+	var (
+		exported    []exportResult
+		failed      []syncFailure
+		unavailable []unavailableConversation
+		jobErr      error
+	)
+	job := s.jobs.start("retry", strings.Join(order, ","))
+	for _, target := range order {
+		exporter, label := exporters[target], labels[target]
+		items := make([]exportItem, 0, len(byTarget[target]))
+		for _, item := range byTarget[target] {
+			items = append(items, exportItem{ID: item.ConversationID, Title: item.Title})
+		}
+		result, syncErr := s.syncConversations(ctx, job, target, label, exporter, fetch, items, cfg.OutputTimezone)
+		s.recordSyncResult(target, result)
+		job.recordSync(target, result)
+		exported = append(exported, result.Exported...)
+		failed = append(failed, result.Failed...)
+		unavailable = append(unavailable, result.Unavailable...)
+		if syncErr != nil {
+			logInfo("重试导出 %s 中止: %v", label, syncErr)
+			jobErr = syncErr
+			break
+		}
+	}
+	s.jobs.finish(job, jobErr, s.locationSnapshot())
+
+	logInfo("失败记录重试: 选中=%d 成功=%d 失败=%d", len(items), len(exported), len(failed))
+	if exported == nil {
+		exported = []exportResult{}
+	}
+	if failed == nil {
+		failed = []syncFailure{}
+	}
+	response := map[string]interface{}{
+		"exported": exported,
+		"failed":   failed,
+		"job_id":   job.ID,
+	}
+	if len(unavailable) > 0 {
+		response["unavailable"] = unavailable
+	}
+	writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultFeedEntries = 50
+	maxFeedEntries     = 200
+)
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID       string       `xml:"id"`
+	Title    string       `xml:"title"`
+	Updated  string       `xml:"updated"`
+	Links    []atomLink   `xml:"link,omitempty"`
+	Category atomCategory `xml:"category"`
+	Summary  string       `xml:"summary"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// exportedEntry 是最近一次成功导出的记录, 标题来自本地对话索引。
+type exportedEntry struct {
+	exportState
+	Title string
+}
+
+// RecentExportStates 按导出时间倒序返回最近的导出记录。
+func (s *ConfigStore) RecentExportStates(ctx context.Context, limit int) ([]exportedEntry, error) {
+	if s == nil || s.archive == nil {
+		return nil, errors.New("配置存储未初始化")
+	}
+	rows, err := s.archive.reader.QueryContext(ctx, `
+		SELECT es.conversation_id, es.target, es.object_id, es.url, es.exported_at, COALESCE(ci.title, '')
+		FROM export_state es
+		LEFT JOIN conversation_index ci ON ci.id = es.conversation_id
+		ORDER BY es.exported_at DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("读取导出记录失败: %w", err)
+	}
+	defer rows.Close()
+	var items []exportedEntry
+	for rows.Next() {
+		var item exportedEntry
+		if err := rows.Scan(&item.ConversationID, &item.Target, &item.ObjectID, &item.URL, &item.ExportedAt, &item.Title); err != nil {
+			return nil, fmt.Errorf("解析导出记录失败: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取导出记录失败: %w", err)
+	}
+	return items, nil
+}
+
+// handleFeed 输出 /feed.xml: 最近导出成功与失败的对话, 便于在阅读器中跟踪备份情况。
+func (s *webServer) handleFeed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+		return
+	}
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultFeedEntries
+	}
+	if limit > maxFeedEntries {
+		limit = maxFeedEntries
+	}
+
+	ctx := r.Context()
+	exported, err := s.store.RecentExportStates(ctx, limit)
+	if err != nil {
+		writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "读取导出记录失败", err)
+		return
+	}
+	failed, err := s.store.ListFailedExports(ctx, "")
+	if err != nil {
+		writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "读取失败记录失败", err)
+		return
+	}
+
+	type feedItem struct {
+		at    time.Time
+		entry atomEntry
+	}
+	items := make([]feedItem, 0, len(exported)+len(failed))
+	for _, item := range exported {
+		title := firstNonEmpty(item.Title, item.ConversationID)
+		entry := atomEntry{
+			ID:       fmt.Sprintf("urn:openai-backup:export:%s:%s:%d", item.Target, item.ConversationID, item.ExportedAt.Unix()),
+			Title:    fmt.Sprintf("已备份: %s", title),
+			Updated:  item.ExportedAt.UTC().Format(time.RFC3339),
+			Category: atomCategory{Term: "exported"},
+			Summary:  fmt.Sprintf("对话 %s 已导出到 %s, 对象 %s", item.ConversationID, item.Target, firstNonEmpty(item.ObjectID, "-")),
+		}
+		if snippet := s.previewSnippet(item.ConversationID); snippet != "" {
+			entry.Summary += "\n\n" + snippet
+		}
+		if item.URL != "" {
+			entry.Links = []atomLink{{Href: item.URL}}
+		}
+		items = append(items, feedItem{at: item.ExportedAt, entry: entry})
+	}
+	for _, item := range failed {
+		items = append(items, feedItem{at: item.UpdatedAt, entry: atomEntry{
+			ID:       fmt.Sprintf("urn:openai-backup:failure:%d:%d", item.ID, item.RetryCount),
+			Title:    fmt.Sprintf("导出失败: %s", firstNonEmpty(item.Title, item.ConversationID)),
+			Updated:  item.UpdatedAt.UTC().Format(time.RFC3339),
+			Category: atomCategory{Term: "failed"},
+			Summary:  fmt.Sprintf("对话 %s 导出到 %s 失败 (重试 %d 次): %s", item.ConversationID, item.Target, item.RetryCount, item.Error),
+		}})
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].at.After(items[j].at) })
+	if len(items) > limit {
+		items = items[:limit]
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	feed := atomFeed{
+		ID:      "urn:openai-backup:feed",
+		Title:   "openai-backup 备份记录",
+		Updated: time.Now().UTC().Format(time.RFC3339),
+		Links:   []atomLink{{Href: fmt.Sprintf("%s://%s/feed.xml", scheme, r.Host), Rel: "self"}},
+	}
+	if len(items) > 0 {
+		feed.Updated = items[0].at.UTC().Format(time.RFC3339)
+	}
+	for _, item := range items {
+		feed.Entries = append(feed.Entries, item.entry)
+	}
+
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "生成订阅源失败", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if _, err := w.Write(append([]byte(xml.Header), data...)); err != nil {
+		logInfo("输出订阅源失败: %v", err)
+	}
+}
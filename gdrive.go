@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/targets/gdrive"
+)
+
+const (
+	googleAuthPending    = "pending"
+	googleAuthAuthorized = "authorized"
+	googleAuthFailed     = "failed"
+)
+
+// googleDeviceAuth 记录进行中的 Google 设备授权, 同一时间只保留一个流程。
+type googleDeviceAuth struct {
+	mu              sync.Mutex
+	state           string
+	userCode        string
+	verificationURL string
+	expiresAt       time.Time
+	err             string
+	cancel          context.CancelFunc
+}
+
+type googleAuthStatus struct {
+	State           string `json:"state"`
+	Authorized      bool   `json:"authorized"`
+	UserCode        string `json:"user_code,omitempty"`
+	VerificationURL string `json:"verification_url,omitempty"`
+	ExpiresAt       string `json:"expires_at,omitempty"`
+	Error           string `json:"error,omitempty"`
+}
+
+func (s *webServer) resolveGoogleDriveClient() (*gdrive.Client, error) {
+	cfg := s.configSnapshot()
+	s.gdriveClientMu.Lock()
+	defer s.gdriveClientMu.Unlock()
+	if s.gdriveClient != nil {
+		return s.gdriveClient, nil
+	}
+	exporter, err := gdrive.New(gdrive.Config{
+		OAuth:        googleOAuthConfig(cfg),
+		RefreshToken: cfg.GoogleRefreshToken,
+		FolderID:     cfg.GoogleFolderID,
+		Mode:         cfg.GoogleDriveMode,
+		HTML:         export.HTMLOptions{Theme: export.HTMLThemeLight, MathMode: cfg.MathMode, RenderDiagrams: cfg.RenderDiagrams, RenderSVG: s.svgRenderer(cfg)},
+		Filename:     filenameOptions(cfg),
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.gdriveClient = exporter
+	return exporter, nil
+}
+
+func googleOAuthConfig(cfg *cliConfig) gdrive.OAuthConfig {
+	return gdrive.OAuthConfig{ClientID: cfg.GoogleClientID, ClientSecret: cfg.GoogleClientSecret}
+}
+
+// handleGoogleDeviceAuth 处理 Google Drive 授权:
+// POST /api/google/device 发起设备授权并返回验证码, 后台轮询直至用户完成授权;
+// GET 返回当前授权状态。
+func (s *webServer) handleGoogleDeviceAuth(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.googleAuthStatus())
+	case http.MethodPost:
+		if err := s.startGoogleDeviceAuth(r.Context()); err != nil {
+			writeErrorDetail(w, http.StatusBadGateway, errCodeGoogleAuthFailed, "发起 Google 授权失败", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, s.googleAuthStatus())
+	default:
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+	}
+}
+
+func (s *webServer) startGoogleDeviceAuth(ctx context.Context) error {
+	oauth := googleOAuthConfig(s.configSnapshot())
+	code, err := gdrive.RequestDeviceCode(ctx, oauth)
+	if err != nil {
+		return fmt.Errorf("发起 Google 授权失败: %w", err)
+	}
+
+	pollCtx, cancel := context.WithTimeout(context.Background(), time.Duration(code.ExpiresIn)*time.Second+time.Minute)
+	auth := &s.googleAuth
+	auth.mu.Lock()
+	if auth.cancel != nil {
+		auth.cancel()
+	}
+	auth.state = googleAuthPending
+	auth.userCode = code.UserCode
+	auth.verificationURL = code.VerificationURL
+	auth.expiresAt = time.Now().Add(time.Duration(code.ExpiresIn) * time.Second)
+	auth.err = ""
+	auth.cancel = cancel
+	auth.mu.Unlock()
+
+	logInfo("等待 Google 授权: 请打开 %s 并输入 %s", code.VerificationURL, code.UserCode)
+	go func() {
+		defer cancel()
+		refreshToken, err := gdrive.PollRefreshToken(pollCtx, oauth, code)
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		if err == nil {
+			_, err = s.updateConfig(configUpdate{GoogleRefreshToken: &refreshToken})
+		}
+
+		auth.mu.Lock()
+		defer auth.mu.Unlock()
+		if auth.userCode != code.UserCode {
+			return
+		}
+		auth.cancel = nil
+		if err != nil {
+			logInfo("Google 授权失败: %v", err)
+			auth.state = googleAuthFailed
+			auth.err = err.Error()
+			return
+		}
+		logInfo("Google Drive 授权成功")
+		auth.state = googleAuthAuthorized
+	}()
+	return nil
+}
+
+func (s *webServer) googleAuthStatus() googleAuthStatus {
+	cfg := s.configSnapshot()
+	auth := &s.googleAuth
+	auth.mu.Lock()
+	defer auth.mu.Unlock()
+	status := googleAuthStatus{
+		State:      auth.state,
+		Authorized: cfg.GoogleRefreshToken != "",
+		Error:      auth.err,
+	}
+	if auth.state == googleAuthPending {
+		status.UserCode = auth.userCode
+		status.VerificationURL = auth.verificationURL
+		status.ExpiresAt = auth.expiresAt.Format(time.RFC3339)
+	}
+	return status
+}
@@ -1,9 +1,10 @@
-module openai-backup
+module github.com/Devoty/openai-backup
 
 go 1.24.0
 
 require (
 	golang.org/x/crypto v0.43.0
+	golang.org/x/text v0.30.0
 	modernc.org/sqlite v1.39.1
 )
 
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Devoty/openai-backup/httpc"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+// hookAPIKeyFromRequest 支持 X-API-Key 头或 Authorization: Bearer 两种传法。
+func hookAPIKeyFromRequest(r *http.Request) string {
+	if key := strings.TrimSpace(r.Header.Get("X-API-Key")); key != "" {
+		return key
+	}
+	auth := strings.TrimSpace(r.Header.Get("Authorization"))
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
+
+// handleHookRunBackup 处理 POST /api/hooks/run-backup:
+// 校验 API Key 后在后台把所有未导出到默认目标的对话导出, 立即返回任务 ID。
+func (s *webServer) handleHookRunBackup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+		return
+	}
+	cfg := s.configSnapshot()
+	expected := strings.TrimSpace(cfg.HookAPIKey)
+	if expected == "" {
+		writeError(w, http.StatusForbidden, errCodeHookDisabled, "未配置 hook_api_key, webhook 已禁用")
+		return
+	}
+	provided := hookAPIKeyFromRequest(r)
+	if subtle.ConstantTimeCompare([]byte(provided), []byte(expected)) != 1 {
+		writeError(w, http.StatusUnauthorized, errCodeHookUnauthorized, "API Key 无效")
+		return
+	}
+
+	target := normalizeExportTarget(cfg.ExportTarget)
+	exporter, label, err := s.resolveExporter(target)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errCodeTargetMisconfigured, err.Error())
+		return
+	}
+	if !s.hookBackupRunning.CompareAndSwap(false, true) {
+		writeError(w, http.StatusConflict, errCodeConflict, "已有备份任务在执行中")
+		return
+	}
+
+	job := s.jobs.start("hook", target)
+	logInfo("Webhook 触发备份: 目标=%s 任务=%s", target, job.ID)
+	go func() {
+		defer s.hookBackupRunning.Store(false)
+		s.runHookBackup(context.Background(), job, target, exporter, label, cfg.HookLimit)
+	}()
+
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{
+		"job_id": job.ID,
+		"target": target,
+		"status": jobStatusRunning,
+	})
+}
+
+func (s *webServer) runHookBackup(ctx context.Context, job *exportJob, target string, exporter targets.Exporter, label string, limit int) {
+	ctx = httpc.Background(ctx)
+	cfg := s.configSnapshot()
+	ids, err := s.resolveImportFilter(ctx, importFilter{NotExported: true}, limit, target)
+	if err != nil {
+		s.jobs.finish(job, fmt.Errorf("解析待备份对话失败: %w", err), s.locationSnapshot())
+		return
+	}
+
+	result, syncErr := s.syncConversations(ctx, job, target, label, exporter, s.fetchExportConversation, exportItemsFromIDs(ids), cfg.OutputTimezone)
+	s.recordSyncResult(target, result)
+	job.recordSync(target, result)
+	s.jobs.finish(job, syncErr, s.locationSnapshot())
+}
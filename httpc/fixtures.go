@@ -0,0 +1,361 @@
+package httpc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	fixtureRecord = "record"
+	fixtureReplay = "replay"
+)
+
+var (
+	fixtureMode string
+	fixtureDir  string
+)
+
+// RecordFixtures 把之后所有经由 Client 的请求与响应写入 dir, 需在首次调用 Client 之前设置。
+func RecordFixtures(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("创建录制目录失败: %w", err)
+	}
+	fixtureMode, fixtureDir = fixtureRecord, dir
+	return nil
+}
+
+// ReplayFixtures 改为从 dir 中读取录制的响应, 不再访问网络, 需在首次调用 Client 之前设置。
+func ReplayFixtures(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("读取回放目录失败: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("回放路径不是目录: %s", dir)
+	}
+	fixtureMode, fixtureDir = fixtureReplay, dir
+	return nil
+}
+
+// fixture 是一次录制的 HTTP 往返。请求只保存方法、地址与正文, 不保存请求头; 地址、正文与响应头中的
+// 密钥在写入前打码, 见 redactURL、redactBody。
+type fixture struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	RequestBody string      `json:"request_body,omitempty"`
+	Status      int         `json:"status"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        string      `json:"body"`
+	// BodyBase64 为 true 时 Body 为 base64 编码的二进制内容。
+	BodyBase64 bool `json:"body_base64,omitempty"`
+}
+
+// secretQueryKeys 在保存的地址中打码, 匹配用的摘要仍基于原始地址。
+var secretQueryKeys = []string{"key", "token", "access_token", "api_key", "apikey", "signature", "sig"}
+
+var botTokenPattern = regexp.MustCompile(`/bot[0-9]+:[A-Za-z0-9_-]+/`)
+
+// secretBodyFields 在保存的表单与 JSON 正文中打码, 比较时忽略大小写、下划线与连字符,
+// 例如 refresh_token 与 refreshToken 都会匹配。
+var secretBodyFields = map[string]bool{
+	"token":         true,
+	"accesstoken":   true,
+	"refreshtoken":  true,
+	"idtoken":       true,
+	"sessiontoken":  true,
+	"clientsecret":  true,
+	"secret":        true,
+	"password":      true,
+	"apikey":        true,
+	"devicecode":    true,
+	"authorization": true,
+	"cookie":        true,
+}
+
+// skippedResponseHeaders 不写入录制文件。
+var skippedResponseHeaders = []string{"Set-Cookie", "Date", "Content-Length"}
+
+// secretHeaders 写入录制文件时打码。
+var secretHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+// fixtureKey 由方法、地址与正文计算摘要。multipart 正文的分隔符每次随机, 只按方法与地址匹配。
+func fixtureKey(method, rawURL, contentType string, body []byte) string {
+	sum := sha256.New()
+	sum.Write([]byte(method + " " + rawURL))
+	if mediaType, _, _ := mime.ParseMediaType(contentType); !strings.HasPrefix(mediaType, "multipart/") {
+		sum.Write([]byte{0})
+		sum.Write(body)
+	}
+	return hex.EncodeToString(sum.Sum(nil))[:16]
+}
+
+func fixtureFilename(method string, u *url.URL, key string) string {
+	slug := strings.Trim(u.Host+u.Path, "/")
+	slug = botTokenPattern.ReplaceAllString("/"+slug+"/", "/bot/")
+	slug = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '.' {
+			return r
+		}
+		return '_'
+	}, strings.Trim(slug, "/"))
+	if len(slug) > 80 {
+		slug = slug[:80]
+	}
+	return fmt.Sprintf("%s-%s-%s.json", strings.ToLower(method), slug, key)
+}
+
+func redactURL(u *url.URL) string {
+	clone := *u
+	query := clone.Query()
+	for name := range query {
+		for _, secret := range secretQueryKeys {
+			if strings.EqualFold(name, secret) {
+				query.Set(name, "***")
+			}
+		}
+	}
+	clone.RawQuery = query.Encode()
+	return botTokenPattern.ReplaceAllString(clone.String(), "/bot***/")
+}
+
+func isSecretField(name string) bool {
+	name = strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(name))
+	return secretBodyFields[name]
+}
+
+// redactBody 对表单与 JSON 正文中的密钥字段打码, 其他正文原样返回。没有需要打码的字段时
+// 返回原始内容, 不改变字段顺序与格式。
+func redactBody(contentType string, data []byte) []byte {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		form, err := url.ParseQuery(string(data))
+		if err != nil {
+			return data
+		}
+		changed := false
+		for name := range form {
+			if isSecretField(name) {
+				form.Set(name, "***")
+				changed = true
+			}
+		}
+		if !changed {
+			return data
+		}
+		return []byte(form.Encode())
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") || json.Valid(data):
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil || !redactJSON(value) {
+			return data
+		}
+		redacted, err := json.Marshal(value)
+		if err != nil {
+			return data
+		}
+		return redacted
+	}
+	return data
+}
+
+// redactJSON 就地替换密钥字段的值, 返回是否有字段被打码。
+func redactJSON(value interface{}) bool {
+	changed := false
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, item := range v {
+			if isSecretField(name) {
+				if _, isObject := item.(map[string]interface{}); !isObject && item != nil {
+					v[name] = "***"
+					changed = true
+					continue
+				}
+			}
+			if redactJSON(item) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if redactJSON(item) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+func encodeBody(data []byte) (string, bool) {
+	if utf8.Valid(data) {
+		return string(data), false
+	}
+	return base64.StdEncoding.EncodeToString(data), true
+}
+
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(data)), nil }
+	return data, nil
+}
+
+// recordingTransport 转发请求并把完整往返写入录制目录, 同一请求重复出现时保留最新一次。
+type recordingTransport struct {
+	base http.RoundTripper
+	dir  string
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	key := fixtureKey(req.Method, req.URL.String(), req.Header.Get("Content-Type"), reqBody)
+	item := fixture{
+		Method: req.Method,
+		URL:    redactURL(req.URL),
+		Status: resp.StatusCode,
+		Header: resp.Header.Clone(),
+	}
+	for _, name := range skippedResponseHeaders {
+		item.Header.Del(name)
+	}
+	for _, name := range secretHeaders {
+		if item.Header.Get(name) != "" {
+			item.Header.Set(name, "***")
+		}
+	}
+	if len(reqBody) > 0 {
+		item.RequestBody, _ = encodeBody(redactBody(req.Header.Get("Content-Type"), reqBody))
+	}
+	item.Body, item.BodyBase64 = encodeBody(redactBody(resp.Header.Get("Content-Type"), respBody))
+	if err := writeFixture(filepath.Join(t.dir, fixtureFilename(req.Method, req.URL, key)), item); err != nil {
+		Logf("写入录制文件失败: %s %s err=%v", req.Method, item.URL, err)
+	}
+	return resp, nil
+}
+
+func writeFixture(path string, item fixture) error {
+	data, err := json.MarshalIndent(item, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// replayTransport 按请求摘要返回录制的响应; 找不到完全一致的请求时退回同方法同地址的录制。
+type replayTransport struct {
+	dir string
+
+	once    sync.Once
+	loadErr error
+	byKey   map[string]fixture
+	byRoute map[string]fixture
+}
+
+func (t *replayTransport) load() {
+	t.byKey = make(map[string]fixture)
+	t.byRoute = make(map[string]fixture)
+	entries, err := os.ReadDir(t.dir)
+	if err != nil {
+		t.loadErr = fmt.Errorf("读取回放目录失败: %w", err)
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(t.dir, entry.Name()))
+		if err != nil {
+			t.loadErr = fmt.Errorf("读取录制文件失败: %w", err)
+			return
+		}
+		var item fixture
+		if err := json.Unmarshal(data, &item); err != nil {
+			Logf("跳过无法解析的录制文件: %s err=%v", entry.Name(), err)
+			continue
+		}
+		// 文件名末段即录制时的请求摘要, 地址中的密钥已打码, 不能重新计算。
+		name := strings.TrimSuffix(entry.Name(), ".json")
+		t.byKey[name[strings.LastIndex(name, "-")+1:]] = item
+		t.byRoute[item.Method+" "+item.URL] = item
+	}
+	Logf("已加载 %d 个录制文件: %s", len(t.byKey), t.dir)
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.once.Do(t.load)
+	if t.loadErr != nil {
+		return nil, t.loadErr
+	}
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	item, ok := t.byKey[fixtureKey(req.Method, req.URL.String(), req.Header.Get("Content-Type"), reqBody)]
+	if !ok {
+		item, ok = t.byRoute[req.Method+" "+redactURL(req.URL)]
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %s", ErrFixtureNotFound, req.Method, redactURL(req.URL))
+	}
+	body := []byte(item.Body)
+	if item.BodyBase64 {
+		if body, err = base64.StdEncoding.DecodeString(item.Body); err != nil {
+			return nil, fmt.Errorf("解码录制内容失败: %w", err)
+		}
+	}
+	header := item.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", item.Status, http.StatusText(item.Status)),
+		StatusCode:    item.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// ErrFixtureNotFound 表示回放模式下没有与请求匹配的录制。
+var ErrFixtureNotFound = errors.New("没有匹配的录制响应")
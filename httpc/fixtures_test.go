@@ -0,0 +1,141 @@
+package httpc
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{
+			name:        "表单中的密钥",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "client_id=app&client_secret=s3cret&grant_type=refresh_token&refresh_token=r1",
+			want:        "client_id=app&client_secret=%2A%2A%2A&grant_type=refresh_token&refresh_token=%2A%2A%2A",
+		},
+		{
+			name:        "没有密钥的表单保持原样",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "b=2&a=1",
+			want:        "b=2&a=1",
+		},
+		{
+			name:        "JSON 响应中的令牌",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"access_token":"a1","expires_in":3600,"refresh_token":"r2","token_type":"Bearer"}`,
+			want:        `{"access_token":"***","expires_in":3600,"refresh_token":"***","token_type":"Bearer"}`,
+		},
+		{
+			name:        "嵌套对象与驼峰字段",
+			contentType: "application/json",
+			body:        `{"user":{"name":"x","password":"p"},"items":[{"accessToken":"t"}]}`,
+			want:        `{"items":[{"accessToken":"***"}],"user":{"name":"x","password":"***"}}`,
+		},
+		{
+			name:        "未声明类型的 JSON",
+			contentType: "",
+			body:        `{"api_key":"k","query":"q"}`,
+			want:        `{"api_key":"***","query":"q"}`,
+		},
+		{
+			name:        "没有密钥的 JSON 保持原样",
+			contentType: "application/json",
+			body:        "{\n  \"b\": 1, \"a\": 2.50\n}",
+			want:        "{\n  \"b\": 1, \"a\": 2.50\n}",
+		},
+		{
+			name:        "其他正文保持原样",
+			contentType: "text/plain",
+			body:        "token=abc",
+			want:        "token=abc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(redactBody(tt.contentType, []byte(tt.body))); got != tt.want {
+				t.Errorf("redactBody() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"https://example.com/search?q=go&token=abc", "https://example.com/search?q=go&token=%2A%2A%2A"},
+		{"https://example.com/search?API_KEY=abc", "https://example.com/search?API_KEY=%2A%2A%2A"},
+		{"https://api.telegram.org/bot123:ABC-def/sendMessage", "https://api.telegram.org/bot***/sendMessage"},
+		{"https://example.com/v1/pages?page_size=10", "https://example.com/v1/pages?page_size=10"},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse(tt.raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := redactURL(u); got != tt.want {
+			t.Errorf("redactURL(%s) = %s, want %s", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestRecordingTransportRedacts(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Authorization", "Bearer upstream")
+		io.WriteString(w, `{"access_token":"acc-123","refresh_token":"rotated"}`)
+	}))
+	defer upstream.Close()
+
+	dir := t.TempDir()
+	transport := &recordingTransport{base: http.DefaultTransport, dir: dir}
+	form := "client_secret=s3cret&refresh_token=old-rt&grant_type=refresh_token"
+	req, err := http.NewRequest(http.MethodPost, upstream.URL+"/token", strings.NewReader(form))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "acc-123") {
+		t.Fatalf("调用方收到的响应不应打码: %s", body)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("录制文件数量 = %d, err=%v", len(files), err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"s3cret", "old-rt", "acc-123", "rotated", "upstream"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("录制文件包含密钥 %q: %s", secret, data)
+		}
+	}
+	var item fixture
+	if err := json.Unmarshal(data, &item); err != nil {
+		t.Fatal(err)
+	}
+	if item.Header.Get("Authorization") != "***" {
+		t.Errorf("Authorization = %q, want ***", item.Header.Get("Authorization"))
+	}
+}
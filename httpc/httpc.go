@@ -6,6 +6,10 @@ import (
 	"time"
 )
 
+// requestTimeout 是单次请求 (含读取响应) 的时限。时限由 throttledTransport 对每次尝试分别计算,
+// 限速等待与 Retry-After 等待不计入, 整体时长由调用方的 context 控制。
+const requestTimeout = 60 * time.Second
+
 var (
 	client *http.Client
 	once   sync.Once
@@ -13,13 +17,19 @@ var (
 
 func Client() *http.Client {
 	once.Do(func() {
+		var transport http.RoundTripper = newThrottledTransport(&http.Transport{
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 100,
+			IdleConnTimeout:     90 * time.Second,
+		}, requestTimeout)
+		switch fixtureMode {
+		case fixtureRecord:
+			transport = &recordingTransport{base: transport, dir: fixtureDir}
+		case fixtureReplay:
+			transport = &replayTransport{dir: fixtureDir}
+		}
 		client = &http.Client{
-			Timeout: 60 * time.Second,
-			Transport: &http.Transport{
-				MaxIdleConns:        100,
-				MaxIdleConnsPerHost: 100,
-				IdleConnTimeout:     90 * time.Second,
-			},
+			Transport: transport,
 		}
 	})
 	return client
@@ -0,0 +1,33 @@
+package httpc
+
+import "context"
+
+// Priority 是请求在节流排队时的优先级。
+type Priority int
+
+const (
+	// PriorityInteractive 是默认优先级, 用于界面上的列表、预览等即时请求。
+	PriorityInteractive Priority = iota
+	// PriorityBackground 用于导出任务等后台请求, 上游限速时让出请求间隔给交互请求。
+	PriorityBackground
+)
+
+type priorityKey struct{}
+
+// WithPriority 返回携带请求优先级的 context。
+func WithPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// Background 把 ctx 标记为后台请求。
+func Background(ctx context.Context) context.Context {
+	return WithPriority(ctx, PriorityBackground)
+}
+
+// PriorityOf 返回 ctx 中的请求优先级, 未设置时为 PriorityInteractive。
+func PriorityOf(ctx context.Context) Priority {
+	if priority, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return priority
+	}
+	return PriorityInteractive
+}
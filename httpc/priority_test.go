@@ -0,0 +1,66 @@
+package httpc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPriorityOf(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want Priority
+	}{
+		{name: "默认为交互请求", ctx: context.Background(), want: PriorityInteractive},
+		{name: "后台请求", ctx: Background(context.Background()), want: PriorityBackground},
+		{name: "重新标记为交互请求", ctx: WithPriority(Background(context.Background()), PriorityInteractive), want: PriorityInteractive},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PriorityOf(tt.ctx); got != tt.want {
+				t.Errorf("PriorityOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// 限速期间后到的交互请求应先于已在等待的后台请求取得请求间隔。
+func TestHostThrottleInteractiveFirst(t *testing.T) {
+	now := time.Now()
+	throttle := &hostThrottle{host: "example.com", interval: 200 * time.Millisecond, next: now.Add(200 * time.Millisecond), lastSlowdown: now}
+	var (
+		mu    sync.Mutex
+		order []string
+		wg    sync.WaitGroup
+	)
+	run := func(name string, ctx context.Context) {
+		defer wg.Done()
+		if err := throttle.wait(ctx); err != nil {
+			t.Errorf("%s: %v", name, err)
+			return
+		}
+		mu.Lock()
+		order = append(order, name)
+		mu.Unlock()
+	}
+	wg.Add(2)
+	go run("background", Background(context.Background()))
+	time.Sleep(20 * time.Millisecond)
+	go run("interactive", context.Background())
+	wg.Wait()
+	if len(order) != 2 || order[0] != "interactive" {
+		t.Errorf("取得请求间隔的顺序 = %v, want interactive 在前", order)
+	}
+}
+
+func TestHostThrottleWaitCanceled(t *testing.T) {
+	throttle := &hostThrottle{host: "example.com", interval: time.Minute, next: time.Now().Add(time.Minute), lastSlowdown: time.Now()}
+	ctx, cancel := context.WithTimeout(Background(context.Background()), 20*time.Millisecond)
+	defer cancel()
+	if err := throttle.wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+}
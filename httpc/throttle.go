@@ -0,0 +1,273 @@
+package httpc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	basePaceInterval   = 500 * time.Millisecond
+	maxPaceInterval    = 30 * time.Second
+	paceQuietPeriod    = time.Minute
+	maxThrottleRetries = 3
+	maxRetryAfter      = 2 * time.Minute
+)
+
+// Logf 输出节流状态变化, 由调用方注入具体的日志实现。
+var Logf = func(format string, args ...interface{}) {}
+
+// hostThrottle 按主机维护请求间隔: 遇到 429/5xx 时加倍, 平稳一段时间后逐步减半直至不限速。
+// 限速期间交互请求优先: 有交互请求在等待时, 后台请求不占用下一个请求间隔。
+type hostThrottle struct {
+	host string
+
+	mu           sync.Mutex
+	interval     time.Duration
+	next         time.Time
+	lastSlowdown time.Time
+	lastRelax    time.Time
+	// interactive 是正在等待请求间隔的交互请求数。
+	interactive int
+	// released 在交互请求取得请求间隔或放弃等待时关闭, 唤醒让路的后台请求重新排队。
+	released chan struct{}
+}
+
+func (t *hostThrottle) wait(ctx context.Context) error {
+	priority := PriorityOf(ctx)
+	waiting := false
+	defer func() {
+		if waiting {
+			t.mu.Lock()
+			t.interactive--
+			t.releaseLocked()
+			t.mu.Unlock()
+		}
+	}()
+	for {
+		t.mu.Lock()
+		now := time.Now()
+		t.relaxLocked(now)
+		yield := priority == PriorityBackground && t.interactive > 0
+		if !yield && !t.next.After(now) {
+			t.next = now.Add(t.interval)
+			t.mu.Unlock()
+			return nil
+		}
+		if priority == PriorityInteractive && !waiting {
+			waiting = true
+			t.interactive++
+		}
+		delay := t.next.Sub(now)
+		var released chan struct{}
+		if yield {
+			if t.released == nil {
+				t.released = make(chan struct{})
+			}
+			released = t.released
+			if delay < t.interval {
+				delay = t.interval
+			}
+		}
+		t.mu.Unlock()
+
+		// 后台请求让路时, 在交互请求取得间隔后或一个间隔之后重新排队。
+		if err := sleepCtx(ctx, delay, released); err != nil {
+			return err
+		}
+	}
+}
+
+// releaseLocked 唤醒等待交互请求让路的后台请求。
+func (t *hostThrottle) releaseLocked() {
+	if t.released != nil {
+		close(t.released)
+		t.released = nil
+	}
+}
+
+// sleepCtx 等待 delay、ctx 取消或 wake 关闭; wake 为 nil 时只等待前两者。
+func sleepCtx(ctx context.Context, delay time.Duration, wake <-chan struct{}) error {
+	if delay <= 0 {
+		delay = time.Millisecond
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+	case <-wake:
+	}
+	return nil
+}
+
+func (t *hostThrottle) slowDown(status int, retryAfter time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	if t.interval <= 0 {
+		t.interval = basePaceInterval
+	} else {
+		t.interval *= 2
+	}
+	if t.interval > maxPaceInterval {
+		t.interval = maxPaceInterval
+	}
+	wait := t.interval
+	if retryAfter > maxRetryAfter {
+		retryAfter = maxRetryAfter
+	}
+	if retryAfter > wait {
+		wait = retryAfter
+	}
+	if next := now.Add(wait); next.After(t.next) {
+		t.next = next
+	}
+	t.lastSlowdown = now
+	t.lastRelax = now
+	Logf("上游返回 %d, 降低请求速率: host=%s 间隔=%s", status, t.host, t.interval)
+}
+
+func (t *hostThrottle) relaxLocked(now time.Time) {
+	if t.interval <= 0 {
+		return
+	}
+	if now.Sub(t.lastSlowdown) < paceQuietPeriod || now.Sub(t.lastRelax) < paceQuietPeriod {
+		return
+	}
+	t.interval /= 2
+	if t.interval < basePaceInterval {
+		t.interval = 0
+	}
+	t.lastRelax = now
+	Logf("上游已平稳, 恢复请求速率: host=%s 间隔=%s", t.host, t.interval)
+}
+
+// throttledTransport 按主机限速, 遇到 429/503 时等待后重试。timeout 限制每次尝试 (含读取响应),
+// 放在重试循环之内, 否则 Retry-After 等待会耗尽整个请求的时限。
+type throttledTransport struct {
+	base    http.RoundTripper
+	timeout time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*hostThrottle
+}
+
+func newThrottledTransport(base http.RoundTripper, timeout time.Duration) *throttledTransport {
+	return &throttledTransport{
+		base:    base,
+		timeout: timeout,
+		hosts:   make(map[string]*hostThrottle),
+	}
+}
+
+func (t *throttledTransport) forHost(host string) *hostThrottle {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	throttle, ok := t.hosts[host]
+	if !ok {
+		throttle = &hostThrottle{host: host}
+		t.hosts[host] = throttle
+	}
+	return throttle
+}
+
+func (t *throttledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	throttle := t.forHost(req.URL.Host)
+	current := req
+	for attempt := 0; ; attempt++ {
+		if err := throttle.wait(req.Context()); err != nil {
+			return nil, err
+		}
+		resp, cancel, err := t.attempt(current)
+		if err != nil {
+			return nil, err
+		}
+		countUpload(current)
+		if !isThrottleStatus(resp.StatusCode) {
+			return resp, nil
+		}
+		throttle.slowDown(resp.StatusCode, parseRetryAfter(resp.Header.Get("Retry-After")))
+		// 仅 429/503 代表请求未被处理, 其余 5xx 只降速不重试, 避免重复写入。
+		if attempt >= maxThrottleRetries || !isRetryableThrottleStatus(resp.StatusCode) {
+			return resp, nil
+		}
+		next, ok := rewindRequest(req)
+		if !ok {
+			return resp, nil
+		}
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+		resp.Body.Close()
+		cancel()
+		current = next
+	}
+}
+
+// attempt 在 timeout 时限内发送一次请求。时限持续到响应正文关闭, 返回的 cancel 供提前放弃响应时释放。
+func (t *throttledTransport) attempt(req *http.Request) (*http.Response, context.CancelFunc, error) {
+	if t.timeout <= 0 {
+		resp, err := t.base.RoundTrip(req)
+		return resp, func() {}, err
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, cancel, nil
+}
+
+// cancelOnClose 在响应正文关闭时释放单次请求的 context。
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+func isThrottleStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+func isRetryableThrottleStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+}
+
+func rewindRequest(req *http.Request) (*http.Request, bool) {
+	clone := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return clone, true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	clone.Body = body
+	return clone, true
+}
+
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
@@ -0,0 +1,32 @@
+package httpc
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+)
+
+// UploadCounter 累计经 Client 发出的请求体字节数, 见 CountUploads。
+type UploadCounter struct {
+	bytes atomic.Int64
+}
+
+// Bytes 返回目前累计的字节数。
+func (c *UploadCounter) Bytes() int64 {
+	return c.bytes.Load()
+}
+
+type uploadCounterKey struct{}
+
+// CountUploads 返回携带计数器的 context: 用它发出的请求在收到响应后把请求体大小计入计数器,
+// 限流重试的请求重复计入。流式请求体 (ContentLength 未知) 不计。
+func CountUploads(ctx context.Context) (context.Context, *UploadCounter) {
+	counter := &UploadCounter{}
+	return context.WithValue(ctx, uploadCounterKey{}, counter), counter
+}
+
+func countUpload(req *http.Request) {
+	if counter, ok := req.Context().Value(uploadCounterKey{}).(*UploadCounter); ok && req.ContentLength > 0 {
+		counter.bytes.Add(req.ContentLength)
+	}
+}
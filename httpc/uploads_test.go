@@ -0,0 +1,49 @@
+package httpc
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCountUploads(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: newThrottledTransport(http.DefaultTransport, time.Minute)}
+
+	tests := []struct {
+		name string
+		body func() io.Reader
+		want int64
+	}{
+		{name: "没有请求体", body: func() io.Reader { return nil }, want: 0},
+		{name: "已知长度的请求体", body: func() io.Reader { return strings.NewReader("hello") }, want: 5},
+		{name: "流式请求体不计", body: func() io.Reader { return io.MultiReader(bytes.NewReader([]byte("hello"))) }, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, counter := CountUploads(context.Background())
+			for i := 0; i < 2; i++ {
+				req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, tt.body())
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp, err := client.Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp.Body.Close()
+			}
+			if got := counter.Bytes(); got != 2*tt.want {
+				t.Errorf("Bytes() = %d, want %d", got, 2*tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Devoty/openai-backup/client"
+)
+
+const (
+	defaultSelectorLimit = 500
+	maxSelectorLimit     = 5000
+	indexCrawlPageSize   = 100
+)
+
+const conversationIndexSchema = `
+	CREATE TABLE IF NOT EXISTS conversation_index (
+		id TEXT PRIMARY KEY,
+		title TEXT NOT NULL DEFAULT '',
+		create_time REAL NOT NULL DEFAULT 0,
+		update_time REAL NOT NULL DEFAULT 0,
+		indexed_at TIMESTAMP NOT NULL
+	);`
+
+// importFilter 描述批量导入时的对话筛选条件, 各条件之间为 AND 关系。
+type importFilter struct {
+	UpdatedAfter  string `json:"updated_after"`
+	UpdatedBefore string `json:"updated_before"`
+	TitleRegex    string `json:"title_regex"`
+	NotExported   bool   `json:"not_exported"`
+	// Refresh 为 true 时筛选前完整抓取一遍对话列表, 否则只拉取索引中最新对话之后有更新的对话。
+	Refresh bool `json:"refresh"`
+}
+
+// UpsertConversationIndex 将列表接口返回的对话元数据写入本地索引。
+func (s *ConfigStore) UpsertConversationIndex(ctx context.Context, metas []client.ConversationMeta) error {
+	if s == nil || s.archive == nil {
+		return errors.New("配置存储未初始化")
+	}
+	if len(metas) == 0 {
+		return nil
+	}
+	tx, err := s.archive.writer.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+	if err := upsertConversationIndexTx(ctx, tx, metas); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交对话索引失败: %w", err)
+	}
+	return nil
+}
+
+// upsertConversationIndexTx 在调用方的事务中写入对话索引。
+func upsertConversationIndexTx(ctx context.Context, tx *sql.Tx, metas []client.ConversationMeta) error {
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO conversation_index(id, title, create_time, update_time, indexed_at)
+		VALUES(?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			title=excluded.title,
+			create_time=excluded.create_time,
+			update_time=excluded.update_time,
+			indexed_at=excluded.indexed_at
+	`)
+	if err != nil {
+		return fmt.Errorf("准备索引写入失败: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC()
+	for _, meta := range metas {
+		if strings.TrimSpace(meta.ID) == "" {
+			continue
+		}
+		if _, err := stmt.ExecContext(ctx, meta.ID, meta.Title, meta.CreateTime.Float64(), meta.UpdateTime.Float64(), now); err != nil {
+			return fmt.Errorf("写入对话索引失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// QueryConversationIndex 按更新时间与导出状态筛选索引中的对话, 结果按更新时间倒序。
+// notExportedTarget 非空时排除已成功导出到该目标的对话。
+func (s *ConfigStore) QueryConversationIndex(ctx context.Context, updatedAfter, updatedBefore float64, notExportedTarget string) ([]client.ConversationMeta, error) {
+	if s == nil || s.archive == nil {
+		return nil, errors.New("配置存储未初始化")
+	}
+	query := `SELECT id, title, create_time, update_time FROM conversation_index ci WHERE 1=1`
+	var args []interface{}
+	if updatedAfter > 0 {
+		query += ` AND update_time >= ?`
+		args = append(args, updatedAfter)
+	}
+	if updatedBefore > 0 {
+		query += ` AND update_time < ?`
+		args = append(args, updatedBefore)
+	}
+	if notExportedTarget != "" {
+		query += ` AND NOT EXISTS (SELECT 1 FROM export_state es WHERE es.conversation_id = ci.id AND es.target = ?)`
+		args = append(args, notExportedTarget)
+	}
+	query += ` ORDER BY update_time DESC`
+
+	rows, err := s.archive.reader.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询对话索引失败: %w", err)
+	}
+	defer rows.Close()
+	var items []client.ConversationMeta
+	for rows.Next() {
+		var (
+			meta                   client.ConversationMeta
+			createTime, updateTime float64
+		)
+		if err := rows.Scan(&meta.ID, &meta.Title, &createTime, &updateTime); err != nil {
+			return nil, fmt.Errorf("解析对话索引失败: %w", err)
+		}
+		meta.CreateTime = client.FlexFloat64(createTime)
+		meta.UpdateTime = client.FlexFloat64(updateTime)
+		items = append(items, meta)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("查询对话索引失败: %w", err)
+	}
+	return items, nil
+}
+
+// LatestIndexUpdateTime 返回索引中最新的更新时间, 索引为空时返回 0。
+func (s *ConfigStore) LatestIndexUpdateTime(ctx context.Context) (float64, error) {
+	if s == nil || s.archive == nil {
+		return 0, errors.New("配置存储未初始化")
+	}
+	var latest float64
+	if err := s.archive.reader.QueryRowContext(ctx, `SELECT COALESCE(MAX(update_time), 0) FROM conversation_index`).Scan(&latest); err != nil {
+		return 0, fmt.Errorf("查询对话索引失败: %w", err)
+	}
+	return latest, nil
+}
+
+// indexConversations 将一页列表结果写入索引, 失败时仅记录日志。
+func (s *webServer) indexConversations(ctx context.Context, metas []client.ConversationMeta) {
+	if s.store == nil {
+		return
+	}
+	if err := s.store.UpsertConversationIndex(ctx, metas); err != nil {
+		logInfo("更新对话索引失败: %v", err)
+	}
+}
+
+// refreshConversationIndex 更新本地索引。已完成过完整抓取时只按更新时间拉取索引中最新对话之后有更新的对话;
+// full 为 true、从未完整抓取或上次抓取未完成时完整抓取一遍, 中断后可续抓, 见 crawlConversationIndex。
+func (s *webServer) refreshConversationIndex(ctx context.Context, full bool) (int, error) {
+	cfg := s.configSnapshot()
+	token := strings.TrimSpace(cfg.Token)
+	if token == "" {
+		return 0, errChatGPTTokenMissing
+	}
+	if !full {
+		cp, err := s.store.LoadCrawlCheckpoint(ctx, crawlKey(listOptions(cfg)))
+		if err != nil {
+			return 0, err
+		}
+		if cp != nil && cp.Status == crawlStatusDone {
+			latest, err := s.store.LatestIndexUpdateTime(ctx)
+			if err != nil {
+				return 0, err
+			}
+			count, err := s.indexUpdatedSince(ctx, cfg, token, latest)
+			if err != nil {
+				return 0, err
+			}
+			logInfo("对话索引已增量更新: %d 条", count)
+			return count, nil
+		}
+	}
+	count, err := s.crawlConversationIndex(ctx, cfg, token)
+	if err != nil {
+		return 0, err
+	}
+	logInfo("对话索引已刷新: %d 条", count)
+	if diverged, err := s.store.CountArchiveDivergence(ctx); err != nil {
+		logInfo("%v", err)
+	} else if diverged > 0 {
+		logInfo("%d 条对话在导入官方导出数据后又有更新, 导出时按 %s 策略与本地归档合并", diverged, normalizeArchiveMerge(cfg.ArchiveMerge))
+	}
+	return count, nil
+}
+
+// indexUpdatedSince 按更新时间倒序拉取对话列表并写入索引, 遇到更新时间早于 since 的对话时停止,
+// 返回写入的对话数。
+func (s *webServer) indexUpdatedSince(ctx context.Context, cfg *cliConfig, token string, since float64) (int, error) {
+	opts := listOptions(cfg)
+	opts.Order = defaultOrder
+	opts.Limit = indexCrawlPageSize
+	api := newChatGPTClient(cfg, token)
+	count := 0
+	for {
+		page, err := api.ListConversations(ctx, opts)
+		if err != nil {
+			return count, fmt.Errorf("拉取对话列表失败 (offset=%d): %w", opts.Offset, err)
+		}
+		items := page.Items
+		for i, meta := range items {
+			if meta.UpdateTime.Float64() < since {
+				items = items[:i]
+				break
+			}
+		}
+		if err := s.store.UpsertConversationIndex(ctx, items); err != nil {
+			return count, err
+		}
+		count += len(items)
+		if len(items) < len(page.Items) || len(page.Items) == 0 || !page.HasMore {
+			return count, nil
+		}
+		opts.Offset += len(page.Items)
+	}
+}
+
+// parseFilterTime 支持 RFC3339 与 "2006-01-02[ 15:04:05]" 形式, 后者按配置时区解析。
+func parseFilterTime(value string, loc *time.Location) (float64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return float64(t.Unix()), nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return float64(t.Unix()), nil
+		}
+	}
+	return 0, fmt.Errorf("无法解析时间: %s", value)
+}
+
+// resolveImportFilter 更新索引后按筛选条件返回待导入的对话 ID, filter.Refresh 为 true 时完整抓取一遍对话列表。
+func (s *webServer) resolveImportFilter(ctx context.Context, filter importFilter, limit int, target string) ([]string, error) {
+	loc := s.locationSnapshot()
+	after, err := parseFilterTime(filter.UpdatedAfter, loc)
+	if err != nil {
+		return nil, fmt.Errorf("updated_after 格式错误: %w", err)
+	}
+	before, err := parseFilterTime(filter.UpdatedBefore, loc)
+	if err != nil {
+		return nil, fmt.Errorf("updated_before 格式错误: %w", err)
+	}
+	var titlePattern *regexp.Regexp
+	if strings.TrimSpace(filter.TitleRegex) != "" {
+		titlePattern, err = regexp.Compile(filter.TitleRegex)
+		if err != nil {
+			return nil, fmt.Errorf("title_regex 无效: %w", err)
+		}
+	}
+	if limit <= 0 {
+		limit = defaultSelectorLimit
+	}
+	if limit > maxSelectorLimit {
+		limit = maxSelectorLimit
+	}
+
+	if _, err := s.refreshConversationIndex(ctx, filter.Refresh); err != nil {
+		// 未配置 Token 但导入过官方导出数据时, 直接按本地索引筛选。
+		local, countErr := s.store.CountLocalConversations(ctx)
+		if !errors.Is(err, errChatGPTTokenMissing) || countErr != nil || local == 0 {
+			return nil, err
+		}
+		logInfo("未配置 Token, 按本地索引筛选 (已导入 %d 条对话)", local)
+	}
+	notExportedTarget := ""
+	if filter.NotExported {
+		notExportedTarget = target
+	}
+	metas, err := s.store.QueryConversationIndex(ctx, after, before, notExportedTarget)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(metas))
+	for _, meta := range metas {
+		if titlePattern != nil && !titlePattern.MatchString(meta.Title) {
+			continue
+		}
+		ids = append(ids, meta.ID)
+		if len(ids) >= limit {
+			break
+		}
+	}
+	return ids, nil
+}
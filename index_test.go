@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Devoty/openai-backup/client"
+)
+
+func TestParseFilterTime(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: " "},
+		{value: "2024-03-01T08:00:00Z", want: time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)},
+		{value: "2024-03-01 08:00:00", want: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{value: "2024-03-01", want: time.Date(2024, 2, 29, 16, 0, 0, 0, time.UTC)},
+		{value: "03/01/2024", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseFilterTime(tt.value, loc)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseFilterTime(%q) err = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		want := 0.0
+		if !tt.want.IsZero() {
+			want = float64(tt.want.Unix())
+		}
+		if got != want {
+			t.Errorf("parseFilterTime(%q) = %v, want %v", tt.value, got, want)
+		}
+	}
+}
+
+func TestResolveImportFilter(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	day := func(d int) client.FlexFloat64 {
+		return client.FlexFloat64(time.Date(2024, 3, d, 12, 0, 0, 0, time.UTC).Unix())
+	}
+	var local []localConversation
+	for _, meta := range []client.ConversationMeta{
+		{ID: "c1", Title: "周报 第 1 周", UpdateTime: day(1)},
+		{ID: "c2", Title: "旅行计划", UpdateTime: day(2)},
+		{ID: "c3", Title: "周报 第 2 周", UpdateTime: day(8)},
+		{ID: "c4", Title: "周报 第 3 周", UpdateTime: day(15)},
+	} {
+		local = append(local, localConversation{meta: meta, data: []byte(`{"id":"` + meta.ID + `"}`)})
+	}
+	if err := store.SaveLocalConversations(ctx, local, "takeout"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.RecordExportState(ctx, exportState{ConversationID: "c4", Target: "notion"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		filter  importFilter
+		limit   int
+		want    []string
+		wantErr bool
+	}{
+		{name: "没有条件时按更新时间倒序", want: []string{"c4", "c3", "c2", "c1"}},
+		{name: "更新时间范围", filter: importFilter{UpdatedAfter: "2024-03-02", UpdatedBefore: "2024-03-15"}, want: []string{"c3", "c2"}},
+		{name: "标题正则", filter: importFilter{TitleRegex: "^周报"}, want: []string{"c4", "c3", "c1"}},
+		{name: "排除已导出到目标的对话", filter: importFilter{TitleRegex: "周报", NotExported: true}, want: []string{"c3", "c1"}},
+		{name: "数量上限", limit: 2, want: []string{"c4", "c3"}},
+		{name: "时间格式错误", filter: importFilter{UpdatedAfter: "昨天"}, wantErr: true},
+		{name: "正则无效", filter: importFilter{TitleRegex: "("}, wantErr: true},
+	}
+	s := &webServer{cfg: &cliConfig{}, store: store, location: time.UTC}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.resolveImportFilter(ctx, tt.filter, tt.limit, "notion")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolveImportFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	empty := &webServer{cfg: &cliConfig{}, store: newTestStore(t), location: time.UTC}
+	if _, err := empty.resolveImportFilter(ctx, importFilter{}, 0, "notion"); !errors.Is(err, errChatGPTTokenMissing) {
+		t.Errorf("未配置 Token 且没有本地数据时 err = %v, want errChatGPTTokenMissing", err)
+	}
+}
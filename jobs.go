@@ -0,0 +1,482 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Devoty/openai-backup/atomicfile"
+	"github.com/Devoty/openai-backup/diskspace"
+)
+
+const maxRetainedJobs = 100
+
+const (
+	jobStatusPending   = "pending"
+	jobStatusRunning   = "running"
+	jobStatusCompleted = "completed"
+	jobStatusFailed    = "failed"
+	jobStatusCanceled  = "canceled"
+)
+
+const (
+	outcomeExported = "exported"
+	outcomeFailed   = "failed"
+	outcomeSkipped  = "skipped"
+	outcomeDeleted  = "deleted"
+)
+
+var jobIDPattern = regexp.MustCompile(`^[0-9a-zA-Z-]+$`)
+
+type jobOutcome struct {
+	ConversationID string `json:"conversation_id"`
+	Title          string `json:"title"`
+	Target         string `json:"target"`
+	Status         string `json:"status"`
+	Error          string `json:"error,omitempty"`
+	ObjectID       string `json:"object_id,omitempty"`
+	URL            string `json:"url,omitempty"`
+	DurationMs     int64  `json:"duration_ms"`
+}
+
+type jobSummary struct {
+	Exported int `json:"exported"`
+	Failed   int `json:"failed"`
+	Skipped  int `json:"skipped"`
+	Deleted  int `json:"deleted,omitempty"`
+}
+
+// exportJob 记录一次导入/重试任务的执行过程, 结束后写出报告文件。
+type exportJob struct {
+	mu sync.Mutex
+
+	ID         string     `json:"id"`
+	Kind       string     `json:"kind"`
+	Target     string     `json:"target"`
+	Status     string     `json:"status"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	// ExecuteAt 是等待期结束、任务开始执行的时间, 仅在状态为 pending 时有意义。
+	ExecuteAt *time.Time   `json:"execute_at,omitempty"`
+	Summary   jobSummary   `json:"summary"`
+	Progress  *jobProgress `json:"progress,omitempty"`
+	Outcomes  []jobOutcome `json:"outcomes"`
+	// SkippedMessages 列出被过滤规则排除的消息, 用于核对备份完整性。
+	SkippedMessages []jobSkippedMessage `json:"skipped_messages,omitempty"`
+	// ArchiveConflicts 列出本地归档与接口内容不一致的对话及合并结果。
+	ArchiveConflicts []archiveConflict `json:"archive_conflicts,omitempty"`
+	// Substitutions 列出被导出目标拒绝、改用纯文本写入的区块。
+	Substitutions []jobSubstitution `json:"substitutions,omitempty"`
+	// SchemaDrifts 列出详情结构与已知格式不一致、按宽松模式解析的对话。
+	SchemaDrifts []schemaDrift `json:"schema_drifts,omitempty"`
+	// LintWarnings 列出导出内容检查发现的可能问题, 见 export.Lint。
+	LintWarnings []jobLintWarning `json:"lint_warnings,omitempty"`
+
+	// cancel 非空时任务可以通过 POST /api/jobs/{id}/cancel 取消, 见 setCancel。
+	cancel context.CancelFunc
+}
+
+type jobManager struct {
+	dir string
+	// journal 记录写入中的报告文件, 见 atomicfile.WriteFile。
+	journal atomicfile.Journal
+
+	mu    sync.RWMutex
+	jobs  map[string]*exportJob
+	order []string
+}
+
+func newJobManager(dir string, journal atomicfile.Journal) *jobManager {
+	return &jobManager{
+		dir:     dir,
+		journal: journal,
+		jobs:    make(map[string]*exportJob),
+	}
+}
+
+func newJobID() string {
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return time.Now().Format("20060102-150405.000000")
+	}
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(buf)
+}
+
+func (m *jobManager) start(kind, target string) *exportJob {
+	job := &exportJob{
+		ID:        newJobID(),
+		Kind:      kind,
+		Target:    target,
+		Status:    jobStatusRunning,
+		StartedAt: time.Now(),
+		Outcomes:  []jobOutcome{},
+	}
+	m.mu.Lock()
+	m.jobs[job.ID] = job
+	m.order = append(m.order, job.ID)
+	m.evictLocked()
+	m.mu.Unlock()
+	logInfo("任务开始: id=%s 类型=%s 目标=%s", job.ID, kind, target)
+	return job
+}
+
+// evictLocked 在任务数超过 maxRetainedJobs 时丢弃最早结束的任务。等待中与执行中的任务始终保留,
+// 否则等待期内的删除无法再查询或撤销; 调用方需持有 m.mu。
+func (m *jobManager) evictLocked() {
+	for len(m.order) > maxRetainedJobs {
+		evicted := -1
+		for i, id := range m.order {
+			if m.jobs[id].finished() {
+				evicted = i
+				break
+			}
+		}
+		if evicted < 0 {
+			return
+		}
+		delete(m.jobs, m.order[evicted])
+		m.order = append(m.order[:evicted], m.order[evicted+1:]...)
+	}
+}
+
+func (m *jobManager) get(id string) (*exportJob, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	job, ok := m.jobs[id]
+	return job, ok
+}
+
+func (j *exportJob) record(outcome jobOutcome) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	switch outcome.Status {
+	case outcomeExported:
+		j.Summary.Exported++
+	case outcomeFailed:
+		j.Summary.Failed++
+	case outcomeSkipped:
+		j.Summary.Skipped++
+	case outcomeDeleted:
+		j.Summary.Deleted++
+	}
+	j.Outcomes = append(j.Outcomes, outcome)
+}
+
+// recordSync 将同步结果逐条写入任务记录。
+func (j *exportJob) recordSync(target string, result syncResult) {
+	for _, item := range result.Exported {
+		j.record(jobOutcome{
+			ConversationID: item.ConversationID,
+			Title:          item.Title,
+			Target:         target,
+			Status:         outcomeExported,
+			ObjectID:       item.ObjectID,
+			URL:            item.URL,
+			DurationMs:     item.Duration.Milliseconds(),
+		})
+	}
+	for _, item := range result.Failed {
+		j.record(jobOutcome{
+			ConversationID: item.ConversationID,
+			Title:          item.Title,
+			Target:         target,
+			Status:         outcomeFailed,
+			Error:          item.Error,
+			DurationMs:     item.Duration.Milliseconds(),
+		})
+	}
+}
+
+// setCancel 登记取消任务的函数, 任务结束时清除。
+func (j *exportJob) setCancel(cancel context.CancelFunc) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.cancel = cancel
+}
+
+// schedule 把任务标记为等待执行, 到 at 时由调用方调用 begin 开始执行; 等待期间同样可以取消。
+func (j *exportJob) schedule(at time.Time) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.Status = jobStatusPending
+	j.ExecuteAt = &at
+}
+
+// finished 判断任务是否已结束。
+func (j *exportJob) finished() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.FinishedAt != nil
+}
+
+// begin 把等待中的任务标记为执行中。
+func (j *exportJob) begin() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.Status = jobStatusRunning
+}
+
+// requestCancel 取消等待中或仍在执行的任务, 任务不支持取消或已结束时返回 false。
+func (j *exportJob) requestCancel() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.cancel == nil || (j.Status != jobStatusRunning && j.Status != jobStatusPending) {
+		return false
+	}
+	j.cancel()
+	return true
+}
+
+// snapshot 返回任务的只读副本, 供序列化使用。
+func (j *exportJob) snapshot() *exportJob {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return &exportJob{
+		ID:         j.ID,
+		Kind:       j.Kind,
+		Target:     j.Target,
+		Status:     j.Status,
+		StartedAt:  j.StartedAt,
+		FinishedAt: j.FinishedAt,
+		Error:      j.Error,
+		ExecuteAt:  j.ExecuteAt,
+		Summary:    j.Summary,
+		Progress:   j.Progress.estimate(time.Now()),
+		Outcomes:   append([]jobOutcome(nil), j.Outcomes...),
+
+		SkippedMessages:  append([]jobSkippedMessage(nil), j.SkippedMessages...),
+		ArchiveConflicts: append([]archiveConflict(nil), j.ArchiveConflicts...),
+		Substitutions:    append([]jobSubstitution(nil), j.Substitutions...),
+		SchemaDrifts:     append([]schemaDrift(nil), j.SchemaDrifts...),
+		LintWarnings:     append([]jobLintWarning(nil), j.LintWarnings...),
+	}
+}
+
+// finish 标记任务结束并写出 JSON 与 Markdown 报告。
+func (m *jobManager) finish(job *exportJob, jobErr error, loc *time.Location) {
+	job.mu.Lock()
+	now := time.Now()
+	job.FinishedAt = &now
+	job.Status = jobStatusCompleted
+	switch {
+	case errors.Is(jobErr, context.Canceled):
+		job.Status = jobStatusCanceled
+		job.Error = "任务已取消"
+	case jobErr != nil:
+		job.Status = jobStatusFailed
+		job.Error = jobErr.Error()
+	}
+	job.cancel = nil
+	job.mu.Unlock()
+
+	snapshot := job.snapshot()
+	logInfo("任务结束: id=%s 状态=%s 成功=%d 失败=%d 跳过=%d", snapshot.ID, snapshot.Status, snapshot.Summary.Exported, snapshot.Summary.Failed, snapshot.Summary.Skipped)
+	if err := m.writeReport(snapshot, loc); err != nil {
+		logInfo("写入任务报告失败: id=%s err=%v", snapshot.ID, err)
+	}
+}
+
+func (m *jobManager) reportPath(id, ext string) string {
+	return filepath.Join(m.dir, id+ext)
+}
+
+func (m *jobManager) writeReport(job *exportJob, loc *time.Location) error {
+	if strings.TrimSpace(m.dir) == "" {
+		return nil
+	}
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return fmt.Errorf("创建报告目录失败: %w", err)
+	}
+	data, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化任务报告失败: %w", err)
+	}
+	markdown := renderJobReportMarkdown(job, loc)
+	if err := diskspace.Check(m.dir, int64(len(data)+len(markdown))); err != nil {
+		return err
+	}
+	if err := atomicfile.WriteFile(m.journal, m.reportPath(job.ID, ".json"), data, 0o644); err != nil {
+		return fmt.Errorf("写入 JSON 报告失败: %w", err)
+	}
+	if err := atomicfile.WriteFile(m.journal, m.reportPath(job.ID, ".md"), []byte(markdown), 0o644); err != nil {
+		return fmt.Errorf("写入 Markdown 报告失败: %w", err)
+	}
+	return nil
+}
+
+func renderJobReportMarkdown(job *exportJob, loc *time.Location) string {
+	var b strings.Builder
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.In(loc).Format("2006-01-02 15:04:05")
+	}
+
+	b.WriteString(fmt.Sprintf("# 导出任务报告 %s\n\n", job.ID))
+	b.WriteString(fmt.Sprintf("- 类型: %s\n", job.Kind))
+	b.WriteString(fmt.Sprintf("- 目标: %s\n", firstNonEmpty(job.Target, "-")))
+	b.WriteString(fmt.Sprintf("- 状态: %s\n", job.Status))
+	b.WriteString(fmt.Sprintf("- 开始时间: %s\n", formatTime(job.StartedAt)))
+	if job.ExecuteAt != nil {
+		b.WriteString(fmt.Sprintf("- 计划执行: %s\n", formatTime(*job.ExecuteAt)))
+	}
+	if job.FinishedAt != nil {
+		b.WriteString(fmt.Sprintf("- 结束时间: %s\n", formatTime(*job.FinishedAt)))
+		b.WriteString(fmt.Sprintf("- 总耗时: %s\n", job.FinishedAt.Sub(job.StartedAt).Round(time.Millisecond)))
+	}
+	if job.Summary.Deleted > 0 {
+		b.WriteString(fmt.Sprintf("- 删除: %d / 失败: %d / 跳过: %d\n", job.Summary.Deleted, job.Summary.Failed, job.Summary.Skipped))
+	} else {
+		b.WriteString(fmt.Sprintf("- 成功: %d / 失败: %d / 跳过: %d\n", job.Summary.Exported, job.Summary.Failed, job.Summary.Skipped))
+	}
+	if job.Error != "" {
+		b.WriteString(fmt.Sprintf("- 错误: %s\n", job.Error))
+	}
+	b.WriteString("\n")
+
+	if len(job.Outcomes) == 0 {
+		b.WriteString("(无对话记录)\n")
+		b.WriteString(renderSkippedMessagesMarkdown(job.SkippedMessages))
+		b.WriteString(renderArchiveConflictsMarkdown(job.ArchiveConflicts))
+		b.WriteString(renderSubstitutionsMarkdown(job.Substitutions))
+		b.WriteString(renderSchemaDriftsMarkdown(job.SchemaDrifts))
+		b.WriteString(renderLintWarningsMarkdown(job.LintWarnings))
+		return b.String()
+	}
+
+	b.WriteString("| 对话 ID | 标题 | 目标 | 结果 | 耗时 | 目标对象 | 错误 |\n")
+	b.WriteString("| --- | --- | --- | --- | --- | --- | --- |\n")
+	for _, item := range job.Outcomes {
+		object := item.ObjectID
+		if item.URL != "" {
+			object = fmt.Sprintf("[%s](%s)", firstNonEmpty(item.ObjectID, "链接"), item.URL)
+		}
+		b.WriteString(fmt.Sprintf("| `%s` | %s | %s | %s | %dms | %s | %s |\n",
+			item.ConversationID,
+			escapeMarkdownTableCell(item.Title),
+			item.Target,
+			item.Status,
+			item.DurationMs,
+			firstNonEmpty(object, "-"),
+			firstNonEmpty(escapeMarkdownTableCell(item.Error), "-"),
+		))
+	}
+	b.WriteString(renderSkippedMessagesMarkdown(job.SkippedMessages))
+	b.WriteString(renderArchiveConflictsMarkdown(job.ArchiveConflicts))
+	b.WriteString(renderSubstitutionsMarkdown(job.Substitutions))
+	b.WriteString(renderSchemaDriftsMarkdown(job.SchemaDrifts))
+	b.WriteString(renderLintWarningsMarkdown(job.LintWarnings))
+	return b.String()
+}
+
+func escapeMarkdownTableCell(input string) string {
+	input = strings.ReplaceAll(input, "\n", " ")
+	return strings.ReplaceAll(input, "|", "\\|")
+}
+
+// handleJobs 处理 /api/jobs/{id}、/api/jobs/{id}/report 与 POST /api/jobs/{id}/cancel。
+func (s *webServer) handleJobs(w http.ResponseWriter, r *http.Request) {
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/jobs/"), "/")
+	parts := strings.Split(rest, "/")
+	if !jobIDPattern.MatchString(parts[0]) {
+		http.NotFound(w, r)
+		return
+	}
+	if len(parts) == 2 && parts[1] == "cancel" {
+		s.handleJobCancel(w, r, parts[0])
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+		return
+	}
+	switch {
+	case len(parts) == 1:
+		job, ok := s.jobs.get(parts[0])
+		if !ok {
+			writeError(w, http.StatusNotFound, errCodeNotFound, "未找到任务")
+			return
+		}
+		writeJSON(w, http.StatusOK, job.snapshot())
+	case len(parts) == 2 && parts[1] == "report":
+		s.serveJobReport(w, r, parts[0])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// handleJobCancel 取消执行中的任务; 任务在处理完当前对话后停止, 已完成的部分保留在报告中。
+func (s *webServer) handleJobCancel(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+		return
+	}
+	job, ok := s.jobs.get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, errCodeNotFound, "未找到任务")
+		return
+	}
+	if !job.requestCancel() {
+		writeError(w, http.StatusConflict, errCodeConflict, "任务已结束或不支持取消")
+		return
+	}
+	logInfo("任务取消: id=%s", id)
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{"job_id": id, "status": "canceling"})
+}
+
+func (s *webServer) serveJobReport(w http.ResponseWriter, r *http.Request, id string) {
+	format := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format")))
+	if format == "" {
+		format = "json"
+	}
+
+	if job, ok := s.jobs.get(id); ok {
+		snapshot := job.snapshot()
+		if snapshot.Status == jobStatusRunning || snapshot.Status == jobStatusPending {
+			writeError(w, http.StatusConflict, errCodeConflict, "任务仍在执行中, 报告尚未生成")
+			return
+		}
+	}
+
+	var (
+		ext         string
+		contentType string
+	)
+	switch format {
+	case "json":
+		ext, contentType = ".json", "application/json"
+	case "md", "markdown":
+		ext, contentType = ".md", "text/markdown; charset=utf-8"
+	default:
+		writeError(w, http.StatusBadRequest, errCodeUnsupportedFormat, fmt.Sprintf("不支持的报告格式: %s", format))
+		return
+	}
+
+	data, err := os.ReadFile(s.jobs.reportPath(id, ext))
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, errCodeNotFound, "未找到任务报告")
+			return
+		}
+		writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "读取任务报告失败", err)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "job-"+id+ext))
+	w.Header().Set("Cache-Control", "no-store")
+	if _, err := w.Write(data); err != nil {
+		logInfo("输出任务报告失败: %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Devoty/openai-backup/export"
+)
+
+// conversationLinker 在逐条导出时为对话补充"相关对话": 同一任务中已处理的对话,
+// 以及此前已导出到同一目标的对话 (通过链接或带引号的标题引用)。本任务中已写入目标的对话
+// 无法再追加指向后续对话的链接, 只有后处理的一方记录关联。
+type conversationLinker struct {
+	exported []exportedEntry
+	byID     map[string]exportedEntry
+	// processed 只保存已处理对话的 ID、标题与引用摘要, 不保留正文。
+	processed []linkSummary
+}
+
+// linkSummary 是判断对话间引用关系所需的最少信息: 正文中的对话链接与引号内短语。
+type linkSummary struct {
+	id       string
+	title    string
+	mentions map[string]struct{}
+	quoted   map[string]struct{}
+}
+
+func newLinkSummary(conv export.Conversation) linkSummary {
+	mentions := make(map[string]struct{})
+	for _, id := range export.MentionedConversationIDs(conv) {
+		mentions[id] = struct{}{}
+	}
+	return linkSummary{id: conv.ID, title: conv.Title, mentions: mentions, quoted: export.QuotedPhrases(conv)}
+}
+
+// relates 判断两条对话是否通过链接或带引号的标题互相引用, 与 export.LinkConversations 的规则一致。
+func (a linkSummary) relates(b linkSummary) bool {
+	if strings.EqualFold(a.id, b.id) {
+		return false
+	}
+	_, aMentionsB := a.mentions[strings.ToLower(b.id)]
+	_, bMentionsA := b.mentions[strings.ToLower(a.id)]
+	_, aQuotesB := a.quoted[strings.TrimSpace(b.title)]
+	_, bQuotesA := b.quoted[strings.TrimSpace(a.title)]
+	return aMentionsB || bMentionsA || aQuotesB || bQuotesA
+}
+
+func (s *webServer) newConversationLinker(ctx context.Context, target string) *conversationLinker {
+	linker := &conversationLinker{byID: make(map[string]exportedEntry)}
+	if s.store == nil {
+		return linker
+	}
+	exported, err := s.store.ExportedConversations(ctx, target)
+	if err != nil {
+		logInfo("查询已导出对话失败: %v", err)
+		return linker
+	}
+	linker.exported = exported
+	for _, entry := range exported {
+		linker.byID[strings.ToLower(entry.ConversationID)] = entry
+	}
+	return linker
+}
+
+// link 为 conv 补充相关对话, 并记录其引用摘要供后续对话使用。
+func (l *conversationLinker) link(conv *export.Conversation) {
+	summary := newLinkSummary(*conv)
+	linked := make(map[string]struct{}, len(conv.Related))
+	for _, item := range conv.Related {
+		linked[strings.ToLower(item.ID)] = struct{}{}
+	}
+	add := func(id, title string) {
+		key := strings.ToLower(id)
+		if _, ok := linked[key]; ok || key == strings.ToLower(conv.ID) {
+			return
+		}
+		linked[key] = struct{}{}
+		conv.Related = append(conv.Related, export.RelatedConversation{ID: id, Title: firstNonEmpty(title, id), URL: l.byID[key].URL})
+	}
+
+	for _, prev := range l.processed {
+		if summary.relates(prev) {
+			add(prev.id, prev.title)
+		}
+	}
+	for id := range summary.mentions {
+		if entry, ok := l.byID[id]; ok {
+			add(entry.ConversationID, entry.Title)
+		}
+	}
+	for _, entry := range l.exported {
+		if entry.Title != "" && export.MentionsQuotedTitle(*conv, entry.Title) {
+			add(entry.ConversationID, entry.Title)
+		}
+	}
+	l.processed = append(l.processed, summary)
+}
+
+// exportedTo 记录本任务中刚导出的对话地址, 后续引用它的对话可直接链接到目标平台。
+func (l *conversationLinker) exportedTo(conv export.Conversation, object exportResult) {
+	l.byID[strings.ToLower(conv.ID)] = exportedEntry{
+		exportState: exportState{ConversationID: conv.ID, ObjectID: object.ObjectID, URL: object.URL},
+		Title:       conv.Title,
+	}
+}
+
+// ExportedConversations 返回已导出到指定目标的全部对话, 标题来自本地对话索引。
+func (s *ConfigStore) ExportedConversations(ctx context.Context, target string) ([]exportedEntry, error) {
+	if s == nil || s.archive == nil {
+		return nil, errors.New("配置存储未初始化")
+	}
+	rows, err := s.archive.reader.QueryContext(ctx, `
+		SELECT es.conversation_id, es.target, es.object_id, es.url, es.exported_at, COALESCE(ci.title, '')
+		FROM export_state es
+		LEFT JOIN conversation_index ci ON ci.id = es.conversation_id
+		WHERE es.target = ?
+	`, target)
+	if err != nil {
+		return nil, fmt.Errorf("读取导出状态失败: %w", err)
+	}
+	defer rows.Close()
+	var items []exportedEntry
+	for rows.Next() {
+		var item exportedEntry
+		if err := rows.Scan(&item.ConversationID, &item.Target, &item.ObjectID, &item.URL, &item.ExportedAt, &item.Title); err != nil {
+			return nil, fmt.Errorf("解析导出状态失败: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取导出状态失败: %w", err)
+	}
+	return items, nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Devoty/openai-backup/client"
+	"github.com/Devoty/openai-backup/export"
+)
+
+const (
+	linkID1 = "00000000-0000-4000-8000-000000000001"
+	linkID2 = "00000000-0000-4000-8000-000000000002"
+	linkID3 = "00000000-0000-4000-8000-000000000003"
+	linkID4 = "00000000-0000-4000-8000-000000000004"
+)
+
+func TestConversationLinker(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	if err := store.UpsertConversationIndex(ctx, []client.ConversationMeta{{ID: linkID1, Title: "部署指南 Kubernetes"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.RecordExportState(ctx, exportState{ConversationID: linkID1, Target: "notion", URL: "https://notion.so/p1"}); err != nil {
+		t.Fatal(err)
+	}
+	s := &webServer{store: store}
+	linker := s.newConversationLinker(ctx, "notion")
+
+	// 依次处理的对话, 前一条导出后记录其地址。
+	tests := []struct {
+		name string
+		conv export.Conversation
+		want []export.RelatedConversation
+	}{
+		{
+			name: "链接到此前已导出的对话",
+			conv: export.Conversation{ID: linkID2, Title: "集群排障", Messages: []export.Message{{Text: "参考 https://chatgpt.com/c/" + linkID1 + " 的步骤"}}},
+			want: []export.RelatedConversation{{ID: linkID1, Title: "部署指南 Kubernetes", URL: "https://notion.so/p1"}},
+		},
+		{
+			name: "引用本任务已处理的对话与已导出对话的标题",
+			conv: export.Conversation{ID: linkID3, Title: "复盘", Messages: []export.Message{{Text: "见 https://chatgpt.com/c/" + linkID2 + ", 以及“部署指南 Kubernetes”和「性能调优笔记」"}}},
+			want: []export.RelatedConversation{
+				{ID: linkID2, Title: "集群排障", URL: "https://notion.so/p2"},
+				{ID: linkID1, Title: "部署指南 Kubernetes", URL: "https://notion.so/p1"},
+			},
+		},
+		{
+			name: "被已处理的对话以标题引用",
+			conv: export.Conversation{ID: linkID4, Title: "性能调优笔记", Messages: []export.Message{{Text: "没有引用"}}},
+			want: []export.RelatedConversation{{ID: linkID3, Title: "复盘", URL: "https://notion.so/p3"}},
+		},
+		{
+			name: "不链接到自身, 已有的关联不重复",
+			conv: export.Conversation{ID: linkID1, Title: "部署指南 Kubernetes", Related: []export.RelatedConversation{{ID: linkID2, Title: "集群排障"}}, Messages: []export.Message{{Text: "https://chatgpt.com/c/" + linkID1}}},
+			want: []export.RelatedConversation{
+				{ID: linkID2, Title: "集群排障"},
+				{ID: linkID3, Title: "复盘", URL: "https://notion.so/p3"},
+			},
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conv := tt.conv
+			linker.link(&conv)
+			if !reflect.DeepEqual(conv.Related, tt.want) {
+				t.Errorf("相关对话 = %+v, want %+v", conv.Related, tt.want)
+			}
+			linker.exportedTo(conv, exportResult{URL: fmt.Sprintf("https://notion.so/p%d", i+2)})
+		})
+	}
+}
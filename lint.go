@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Devoty/openai-backup/export"
+)
+
+type jobLintWarning struct {
+	ConversationID string `json:"conversation_id"`
+	Title          string `json:"title"`
+	Target         string `json:"target"`
+	export.LintWarning
+}
+
+// lintOptions 返回目标对应的内容检查选项: 通过 JSON 接口写入的目标通常拒绝控制字符,
+// 以文件写入的目标 (Google Drive、Markdown 目录、JSON 目录、外部命令、Webhook) 不检查。
+func lintOptions(target string) export.LintOptions {
+	switch target {
+	case exportTargetGDrive, exportTargetMarkdown, exportTargetJSON, exportTargetExec, exportTargetWebhook:
+		return export.LintOptions{}
+	default:
+		return export.LintOptions{RejectControl: true}
+	}
+}
+
+// recordLintWarnings 检查即将写入目标的对话, 问题记入任务报告; 只提示, 不阻止导出。
+func (j *exportJob) recordLintWarnings(conv export.Conversation, target string) {
+	warnings := export.Lint(conv, lintOptions(target))
+	if len(warnings) == 0 {
+		return
+	}
+	logInfo("对话 %s 导出内容检查发现 %d 个问题", conv.ID, len(warnings))
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, item := range warnings {
+		j.LintWarnings = append(j.LintWarnings, jobLintWarning{ConversationID: conv.ID, Title: conv.Title, Target: target, LintWarning: item})
+	}
+}
+
+func (j *exportJob) lintWarnings() []jobLintWarning {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]jobLintWarning(nil), j.LintWarnings...)
+}
+
+func renderLintWarningsMarkdown(items []jobLintWarning) string {
+	if len(items) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("\n## 内容检查警告 (%d)\n\n", len(items)))
+	b.WriteString("| 对话 ID | 标题 | 目标 | 消息 | 规则 | 说明 |\n")
+	b.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+	for _, item := range items {
+		message := "-"
+		if item.Message >= 0 {
+			message = fmt.Sprintf("%d", item.Message+1)
+		}
+		b.WriteString(fmt.Sprintf("| `%s` | %s | %s | %s | %s | %s |\n",
+			item.ConversationID,
+			firstNonEmpty(escapeMarkdownTableCell(item.Title), "-"),
+			item.Target,
+			message,
+			item.Rule,
+			escapeMarkdownTableCell(item.Detail),
+		))
+	}
+	return b.String()
+}
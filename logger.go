@@ -5,6 +5,8 @@ import (
 	"log"
 	"os"
 	"strings"
+
+	"github.com/Devoty/openai-backup/logging"
 )
 
 var (
@@ -23,6 +25,7 @@ func setupLogger(path string) (io.Closer, error) {
 	}
 	multi := io.MultiWriter(file, os.Stderr)
 	logger = log.New(multi, "", log.LstdFlags)
+	logging.SetLogger(logger)
 	logInfo("日志初始化完成, 输出文件=%s", path)
 	return file, nil
 }
@@ -0,0 +1,27 @@
+// Package logging 是各子包共用的日志出口, 默认丢弃输出, 由调用方通过 SetLogger 接入。
+package logging
+
+import (
+	"io"
+	"log"
+	"sync/atomic"
+)
+
+var current atomic.Pointer[log.Logger]
+
+func init() {
+	current.Store(log.New(io.Discard, "", log.LstdFlags))
+}
+
+// SetLogger 替换日志输出, 传入 nil 时恢复为丢弃。
+func SetLogger(logger *log.Logger) {
+	if logger == nil {
+		logger = log.New(io.Discard, "", log.LstdFlags)
+	}
+	current.Store(logger)
+}
+
+// Infof 按 Printf 格式输出一行日志。
+func Infof(format string, args ...interface{}) {
+	current.Load().Printf(format, args...)
+}
@@ -0,0 +1,30 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"testing"
+)
+
+func TestSetLogger(t *testing.T) {
+	t.Cleanup(func() { SetLogger(nil) })
+	var buf bytes.Buffer
+	tests := []struct {
+		name   string
+		logger *log.Logger
+		want   string
+	}{
+		{name: "接入日志输出", logger: log.New(&buf, "", 0), want: "已完成 3 个对话\n"},
+		{name: "传入 nil 时恢复为丢弃", logger: nil, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			SetLogger(tt.logger)
+			Infof("已完成 %d 个对话", 3)
+			if buf.String() != tt.want {
+				t.Errorf("输出 = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
@@ -7,9 +7,14 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
 	"syscall"
 	"time"
+
+	"github.com/Devoty/openai-backup/demo"
+	"github.com/Devoty/openai-backup/httpc"
+	"github.com/Devoty/openai-backup/targets/command"
 )
 
 func main() {
@@ -34,11 +39,40 @@ func runApp(cfg *cliConfig) error {
 		return fmt.Errorf("初始化日志失败: %w", err)
 	}
 	defer logCloser.Close()
+	httpc.Logf = logInfo
+	if err := setupFixtures(cfg); err != nil {
+		return err
+	}
+	cleanupSpillRoot()
+	defer removeSpillRoot()
+	if op := strings.TrimSpace(cfg.DBMaintenance); op != "" {
+		return runDBMaintenance(context.Background(), cfg, op, os.Stdout)
+	}
+	if path := strings.TrimSpace(cfg.ImportTakeout); path != "" {
+		return runTakeoutImport(context.Background(), cfg, path, os.Stdout)
+	}
+	if addr := strings.TrimSpace(cfg.PprofListen); addr != "" {
+		stopPprof, err := startPprof(addr)
+		if err != nil {
+			return fmt.Errorf("启动性能分析接口失败: %w", err)
+		}
+		defer stopPprof()
+	}
+	if cfg.Demo {
+		stopDemo, err := startDemo(cfg)
+		if err != nil {
+			return err
+		}
+		defer stopDemo()
+	}
 
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
 	cfg.BaseURL = ensureBaseURL(cfg.BaseURL)
+	if id := strings.TrimSpace(cfg.DumpAnonymized); id != "" {
+		return dumpAnonymizedConversation(ctx, cfg, id, os.Stdout)
+	}
 	cfg.ExportTarget = normalizeExportTarget(cfg.ExportTarget)
 	cfg.Order = normalizeOrder(cfg.Order)
 	cfg.PageSize = clampPageSize(cfg.PageSize)
@@ -55,6 +89,43 @@ func runApp(cfg *cliConfig) error {
 	return nil
 }
 
+// startDemo 启动内置的模拟 ChatGPT 服务, 并把接口地址与 Token 指向它。
+func startDemo(cfg *cliConfig) (func(), error) {
+	baseURL, shutdown, err := demo.Start(demo.DefaultCount)
+	if err != nil {
+		return nil, err
+	}
+	cfg.BaseURL = baseURL
+	cfg.Token = demo.Token
+	logInfo("演示模式已开启, 使用 %d 个示例对话, 模拟接口地址=%s, 配置文件=%s", demo.DefaultCount, baseURL, cfg.ConfigDBPath)
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdown(ctx)
+	}, nil
+}
+
+// setupFixtures 按参数开启 HTTP 录制或回放, 必须在创建任何上游客户端之前调用。
+func setupFixtures(cfg *cliConfig) error {
+	record := strings.TrimSpace(cfg.RecordFixtures)
+	replay := strings.TrimSpace(cfg.ReplayFixtures)
+	switch {
+	case record != "" && replay != "":
+		return errors.New("--record-fixtures 与 --replay-fixtures 不能同时使用")
+	case record != "":
+		if err := httpc.RecordFixtures(record); err != nil {
+			return err
+		}
+		logInfo("HTTP 录制已开启, 录制文件写入 %s (包含对话内容, 分享前请检查)", record)
+	case replay != "":
+		if err := httpc.ReplayFixtures(replay); err != nil {
+			return err
+		}
+		logInfo("HTTP 回放已开启, 从 %s 读取响应, 不访问网络", replay)
+	}
+	return nil
+}
+
 type cliConfig struct {
 	BaseURL             string
 	OutputPath          string
@@ -81,6 +152,111 @@ type cliConfig struct {
 	ExportTarget        string
 	ConfigDBPath        string
 	ServeAddr           string
+	HookAPIKey          string
+	HookLimit           int
+	HTMLTheme           string
+	HTMLCustomCSS       string
+	IncludeContext      bool
+	DownloadAudio       bool
+	DownloadAttachments bool
+	MathMode            string
+	RenderDiagrams      bool
+	ExecCommand         string
+	RecordFixtures      string
+	ReplayFixtures      string
+	Demo                bool
+	DumpAnonymized      string
+	ExecTimeout         int
+	WebhookURL          string
+	WebhookHeaders      string
+	WebhookTemplate     string
+	WebhookIDPath       string
+	WebhookURLPath      string
+	AirtableBaseURL     string
+	AirtableToken       string
+	AirtableBaseID      string
+	AirtableTable       string
+	AirtableFields      string
+	AirtableBodyMode    string
+	GoogleClientID      string
+	GoogleClientSecret  string
+	GoogleRefreshToken  string
+	GoogleFolderID      string
+	GoogleDriveMode     string
+	TelegramBaseURL     string
+	TelegramBotToken    string
+	TelegramChatID      string
+	TelegramMode        string
+	ReadwiseBaseURL     string
+	ReadwiseToken       string
+	ReadwiseTags        string
+	MemosBaseURL        string
+	MemosToken          string
+	MemosVisibility     string
+	MemosTags           string
+	TriliumBaseURL      string
+	TriliumToken        string
+	TriliumParentNoteID string
+	SpillThresholdMB    int
+	PprofListen         string
+	WebDist             string
+	TLSCert             string
+	TLSKey              string
+	AllowInsecureListen bool
+	DBMaintenance       string
+	ImportTakeout       string
+	ArchiveMerge        string
+	AnnotateChanges     bool
+	TitleFallback       string
+	TargetTitleFallback string
+	UnicodeNormalize    bool
+	FilenameStripEmoji  bool
+	NotionChunkMode     string
+	ServerUsers         string
+	IPAllowlist         string
+	MessageLayout       string
+	ExportMode          string
+	ExportStats         bool
+	TimeFormat          string
+	RelativeTimes       bool
+	SecondTimezone      string
+	FileHierarchy       string
+	NotionDraftParentID string
+	NotionDraftType     string
+	ProjectTags         bool
+	NotionProjectField  string
+	TitleTemplate       string
+	TargetTitleTemplate string
+	QueueWindow         string
+	UARotation          string
+	UARotationBatch     int
+	UpdateCheck         bool
+	NotionURLField      string
+	HTMLQRCode          string
+	MaxMessageChars     int
+	TruncateKeepFull    bool
+	DeleteIntervalMs    int
+	DeleteGraceMinutes  int
+	ConfigLocks         string
+	RawOutputPath       string
+	RawFormat           string
+	NotionUploadAudio   bool
+	NotionUploadFiles   bool
+	AnytypeUploadFiles  bool
+	AnytypeFilesField   string
+	TOCMinMessages      int
+	OneNoteTenant       string
+	OneNoteClientID     string
+	OneNoteClientSecret string
+	OneNoteRefreshToken string
+	OneNoteToken        string
+	OneNoteNotebook     string
+	OneNoteSection      string
+	OneNoteBaseURL      string
+	MathRenderURL       string
+	DiagramRenderURL    string
+	// LockConfig 是 --lock-config 指定的锁定配置项, 不持久化, 见 configlock.go。
+	LockConfig string
 }
 
 func parseFlags() (*cliConfig, map[string]struct{}, error) {
@@ -90,13 +266,29 @@ func parseFlags() (*cliConfig, map[string]struct{}, error) {
 	flag.StringVar(&cfg.ServeAddr, "listen", defaultListenAddr, "Web 界面监听地址")
 
 	flag.StringVar(&cfg.BaseURL, "base-url", defaultBaseURL, "ChatGPT 接口基础地址")
-	flag.StringVar(&cfg.ExportTarget, "target", exportTargetAnytype, "导出目标: anytype 或 notion")
+	flag.StringVar(&cfg.ExportTarget, "target", exportTargetAnytype, "导出目标: anytype、notion、airtable、gdrive、telegram、readwise、memos、trilium、onenote、markdown、json、exec 或 webhook")
 	flag.StringVar(&cfg.Order, "order", defaultOrder, "对话排序: updated 或 created")
 	flag.IntVar(&cfg.PageSize, "page-size", defaultPageSize, "每次拉取的对话数量, 1-100")
 	flag.IntVar(&cfg.MaxConversations, "max", defaultMaxConversations, "最多导出多少条对话, 0 表示不限制")
 	flag.IntVar(&cfg.InitialOffset, "offset", defaultInitialOffset, "从第几条开始拉取对话")
 	flag.BoolVar(&cfg.IncludeArchived, "include-archived", false, "是否包含归档对话")
+	flag.BoolVar(&cfg.IncludeContext, "include-context", false, "导出时附带自定义指令/系统提示词")
 	flag.StringVar(&cfg.Token, "token", "", "OpenAI Bearer Token")
+	flag.StringVar(&cfg.ExecCommand, "exec-command", "", "exec 目标执行的外部命令, 对话 JSON 写入其标准输入")
+	flag.IntVar(&cfg.ExecTimeout, "exec-timeout", int(command.DefaultTimeout/time.Second), "exec 目标单个对话的超时秒数")
+	flag.StringVar(&cfg.RecordFixtures, "record-fixtures", "", "把所有上游 HTTP 请求与响应录制到该目录, 用于离线开发与问题复现")
+	flag.StringVar(&cfg.ReplayFixtures, "replay-fixtures", "", "从该目录回放录制的 HTTP 响应, 不访问网络")
+	flag.StringVar(&cfg.DumpAnonymized, "dump-anonymized", "", "拉取指定 ID 的对话, 匿名化后以 JSON 输出到标准输出并退出, 用于附在问题报告中")
+	flag.IntVar(&cfg.SpillThresholdMB, "spill-threshold-mb", defaultSpillThresholdMB, "单个任务在内存中保留中间结果的上限 (MB), 超出部分写入临时目录")
+	flag.StringVar(&cfg.TLSCert, "tls-cert", "", "HTTPS 证书文件 (PEM), 与 --tls-key 同时指定时 Web 界面使用 HTTPS")
+	flag.StringVar(&cfg.TLSKey, "tls-key", "", "HTTPS 私钥文件 (PEM)")
+	flag.BoolVar(&cfg.AllowInsecureListen, "allow-insecure-listen", false, "允许在未配置 server_users 时监听非本机地址 (仅在有其他防护时使用)")
+	flag.StringVar(&cfg.PprofListen, "pprof-listen", "", "调试用: 在该地址提供 net/http/pprof 性能分析接口, 例如 127.0.0.1:6060; 留空不开启")
+	flag.StringVar(&cfg.LockConfig, "lock-config", "", "锁定的配置项 (逗号分隔的配置键, 如 base_url,token), Web 界面与接口不能修改")
+	flag.StringVar(&cfg.WebDist, "web-dist", "", "从该目录提供前端页面 (需包含 index.html), 替代内置的 web/dist; 留空使用内置页面")
+	flag.StringVar(&cfg.DBMaintenance, "db-maintenance", "", "对本地 SQLite 文件执行维护后退出: vacuum、integrity_check 或 backup")
+	flag.StringVar(&cfg.ImportTakeout, "import-takeout", "", "导入 ChatGPT 官方导出数据压缩包 (conversations.json 与媒体文件) 到本地归档后退出")
+	flag.BoolVar(&cfg.Demo, "demo", false, "演示模式: 使用内置的示例对话, 无需 Token; 未指定 --config-db 时使用独立的临时配置")
 
 	flag.StringVar(&cfg.OutputTimezone, "timezone", "", "输出时区, 例如 UTC 或 Asia/Shanghai")
 	flag.StringVar(&cfg.LogPath, "log-file", "", "日志文件路径")
@@ -112,6 +304,13 @@ func parseFlags() (*cliConfig, map[string]struct{}, error) {
 	if cfg.ConfigDBPath == "" {
 		cfg.ConfigDBPath = defaultConfigDBPath
 	}
+	if _, ok := usedFlags["config-db"]; cfg.Demo && !ok {
+		// 演示模式不读写真实配置, 避免示例地址与 Token 覆盖用户设置。
+		cfg.ConfigDBPath = filepath.Join(os.TempDir(), "openai-backup-demo", "app.db")
+	}
+	if _, unknown := parseConfigLocks(cfg.LockConfig); len(unknown) > 0 {
+		return nil, nil, fmt.Errorf("--lock-config 包含未知的配置项: %s", strings.Join(unknown, ", "))
+	}
 
 	return cfg, usedFlags, nil
 }
@@ -196,6 +395,10 @@ func applyPersistedConfig(cfg *cliConfig, payload ConfigPayload, usedFlags map[s
 	applyPersistedString(usedFlags, "notion-parent-type", &cfg.NotionParentType, payload.NotionParentType)
 	applyPersistedString(usedFlags, "notion-parent-id", &cfg.NotionParentID, payload.NotionParentID)
 	applyPersistedString(usedFlags, "notion-title-property", &cfg.NotionTitleProperty, payload.NotionTitleProperty)
+	applyPersistedString(usedFlags, "hook-api-key", &cfg.HookAPIKey, payload.HookAPIKey)
+	applyPersistedInt(usedFlags, "hook-limit", &cfg.HookLimit, payload.HookLimit)
+	applyPersistedInt(usedFlags, "spill-threshold-mb", &cfg.SpillThresholdMB, payload.SpillThresholdMB)
+	applyPersistedBool(usedFlags, "include-context", &cfg.IncludeContext, payload.IncludeContext)
 }
 
 func applyPersistedString(usedFlags map[string]struct{}, flagName string, dst *string, value string) {
@@ -251,6 +454,9 @@ func applyEnvFallback(cfg *cliConfig, usedFlags map[string]struct{}) {
 	applyEnvString(usedFlags, "notion-parent-type", &cfg.NotionParentType, "NOTION_PARENT_TYPE")
 	applyEnvString(usedFlags, "notion-parent-id", &cfg.NotionParentID, "NOTION_PARENT_ID")
 	applyEnvString(usedFlags, "notion-title-property", &cfg.NotionTitleProperty, "NOTION_TITLE_PROPERTY")
+
+	applyEnvString(usedFlags, "hook-api-key", &cfg.HookAPIKey, "BACKUP_HOOK_API_KEY")
+	applyEnvString(usedFlags, "exec-command", &cfg.ExecCommand, "BACKUP_EXEC_COMMAND")
 }
 
 func applyEnvString(usedFlags map[string]struct{}, flagName string, dst *string, envKeys ...string) {
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Devoty/openai-backup/client"
+)
+
+// 本地归档 (官方导出数据) 与接口内容不一致时的合并策略。
+const (
+	// archiveMergeNewer 使用更新时间较新的一方, 默认策略。
+	archiveMergeNewer = "newer"
+	// archiveMergeUnion 以较新的一方为准, 补上只存在于另一方的消息 (如在网页上删除的消息)。
+	archiveMergeUnion = "union"
+	// archiveMergeVersions 使用较新的一方, 并把本地旧内容另存为历史版本, 本地副本更新为接口内容。
+	archiveMergeVersions = "versions"
+)
+
+func normalizeArchiveMerge(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case archiveMergeUnion:
+		return archiveMergeUnion
+	case archiveMergeVersions:
+		return archiveMergeVersions
+	default:
+		return archiveMergeNewer
+	}
+}
+
+const localVersionsSchema = `
+	CREATE TABLE IF NOT EXISTS local_conversation_versions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		conversation_id TEXT NOT NULL,
+		update_time REAL NOT NULL DEFAULT 0,
+		data BLOB NOT NULL,
+		blob_hash TEXT NOT NULL DEFAULT '',
+		source TEXT NOT NULL DEFAULT '',
+		archived_at TIMESTAMP NOT NULL
+	);`
+
+const localVersionsIndex = `CREATE INDEX IF NOT EXISTS idx_local_versions_conversation ON local_conversation_versions(conversation_id, update_time)`
+
+// archiveConflict 描述一次本地归档与接口内容不一致的处理结果, 写入任务报告。
+type archiveConflict struct {
+	ConversationID   string  `json:"conversation_id"`
+	Title            string  `json:"title"`
+	Policy           string  `json:"policy"`
+	LocalUpdateTime  float64 `json:"local_update_time"`
+	RemoteUpdateTime float64 `json:"remote_update_time"`
+	OnlyLocal        int     `json:"only_local"`
+	OnlyRemote       int     `json:"only_remote"`
+	Resolution       string  `json:"resolution"`
+}
+
+// ArchiveLocalVersion 把本地副本另存为历史版本, 再用 conv 覆盖本地副本, 二者在同一事务中完成。
+func (s *ConfigStore) ArchiveLocalVersion(ctx context.Context, conv *client.Conversation, source string) error {
+	if s == nil || s.archive == nil {
+		return fmt.Errorf("配置存储未初始化")
+	}
+	data, err := json.Marshal(conv)
+	if err != nil {
+		return fmt.Errorf("序列化对话失败: %w", err)
+	}
+	tx, err := s.archive.writer.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+	now := time.Now().UTC()
+	var (
+		updateTime float64
+		previous   []byte
+		prevSource string
+	)
+	err = tx.QueryRowContext(ctx, `SELECT update_time, data, source FROM local_conversations WHERE id = ?`, conv.ID).Scan(&updateTime, &previous, &prevSource)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("读取本地副本失败: %w", err)
+	}
+	if err == nil {
+		blob, err := putBlob(ctx, tx, previous)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO local_conversation_versions(conversation_id, update_time, data, blob_hash, source, archived_at)
+			VALUES(?, ?, x'', ?, ?, ?)
+		`, conv.ID, updateTime, blob, prevSource, now); err != nil {
+			return fmt.Errorf("保存历史版本失败: %w", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE local_conversations SET title = ?, create_time = ?, update_time = ?, data = ?, source = ?, imported_at = ?
+		WHERE id = ?
+	`, conv.Title, conv.CreateTime.Float64(), conv.UpdateTime.Float64(), data, source, now, conv.ID); err != nil {
+		return fmt.Errorf("更新本地副本失败: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交历史版本失败: %w", err)
+	}
+	return nil
+}
+
+// CountArchiveDivergence 统计对话索引中比本地副本更新的对话数, 即下次导出时需要合并的对话。
+func (s *ConfigStore) CountArchiveDivergence(ctx context.Context) (int, error) {
+	if s == nil || s.archive == nil {
+		return 0, fmt.Errorf("配置存储未初始化")
+	}
+	var count int
+	err := s.archive.reader.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM local_conversations l JOIN conversation_index i ON i.id = l.id
+		WHERE i.update_time > l.update_time
+	`).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("统计本地归档差异失败: %w", err)
+	}
+	return count, nil
+}
+
+// mergeArchiveConversation 按配置的策略合并本地副本与接口内容, 并记录冲突供任务报告使用。
+func (s *webServer) mergeArchiveConversation(ctx context.Context, policy string, local, remote *client.Conversation) *client.Conversation {
+	onlyLocal, onlyRemote := messageNodeDiff(local, remote), messageNodeDiff(remote, local)
+	conflict := archiveConflict{
+		ConversationID:   remote.ID,
+		Title:            firstNonEmpty(remote.Title, local.Title),
+		Policy:           policy,
+		LocalUpdateTime:  local.UpdateTime.Float64(),
+		RemoteUpdateTime: remote.UpdateTime.Float64(),
+		OnlyLocal:        len(onlyLocal),
+		OnlyRemote:       len(onlyRemote),
+	}
+	newer, older, newerLabel := remote, local, "接口"
+	if local.UpdateTime.Float64() > remote.UpdateTime.Float64() {
+		newer, older, newerLabel = local, remote, "本地归档"
+	}
+
+	result := newer
+	switch policy {
+	case archiveMergeUnion:
+		result = unionConversation(newer, older)
+		conflict.Resolution = fmt.Sprintf("以%s为准, 补入另一方独有的 %d 条消息", newerLabel, len(messageNodeDiff(older, newer)))
+	case archiveMergeVersions:
+		conflict.Resolution = "使用" + newerLabel + "内容"
+		if newer == remote {
+			if err := s.store.ArchiveLocalVersion(ctx, remote, "api"); err != nil {
+				logInfo("保存本地历史版本失败: conversation=%s err=%v", remote.ID, err)
+			} else {
+				conflict.Resolution += ", 本地旧内容已另存为历史版本"
+			}
+		}
+	default:
+		conflict.Resolution = "使用" + newerLabel + "内容"
+	}
+	logInfo("本地归档与接口内容不一致: conversation=%s 策略=%s 仅本地=%d 仅接口=%d 处理=%s", conflict.ConversationID, policy, conflict.OnlyLocal, conflict.OnlyRemote, conflict.Resolution)
+
+	s.conflictMu.Lock()
+	if s.archiveConflicts == nil {
+		s.archiveConflicts = make(map[string]archiveConflict)
+	}
+	s.archiveConflicts[conflict.ConversationID] = conflict
+	s.conflictMu.Unlock()
+	return result
+}
+
+// takeArchiveConflict 取出并清除对话最近一次的合并记录。
+func (s *webServer) takeArchiveConflict(id string) (archiveConflict, bool) {
+	s.conflictMu.Lock()
+	defer s.conflictMu.Unlock()
+	conflict, ok := s.archiveConflicts[id]
+	delete(s.archiveConflicts, id)
+	return conflict, ok
+}
+
+// messageNodeDiff 返回 a 中有消息而 b 中没有的节点 ID。
+func messageNodeDiff(a, b *client.Conversation) []string {
+	var ids []string
+	for id, node := range a.Mapping {
+		if node.Message == nil {
+			continue
+		}
+		if other, ok := b.Mapping[id]; !ok || other.Message == nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// unionConversation 复制 base, 补入 extra 中独有的节点; 父节点存在时同时补上子节点关系。
+func unionConversation(base, extra *client.Conversation) *client.Conversation {
+	merged := *base
+	merged.Raw = nil
+	merged.Mapping = make(map[string]client.Node, len(base.Mapping))
+	for id, node := range base.Mapping {
+		node.Children = append([]string(nil), node.Children...)
+		merged.Mapping[id] = node
+	}
+	for id, node := range extra.Mapping {
+		if _, ok := merged.Mapping[id]; ok {
+			continue
+		}
+		merged.Mapping[id] = node
+	}
+	for id, node := range extra.Mapping {
+		parent, ok := merged.Mapping[node.Parent]
+		if !ok || node.Parent == "" {
+			continue
+		}
+		if !containsString(parent.Children, id) {
+			parent.Children = append(parent.Children, id)
+			merged.Mapping[node.Parent] = parent
+		}
+	}
+	return &merged
+}
+
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
+
+func (j *exportJob) recordArchiveConflict(conflict archiveConflict) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.ArchiveConflicts = append(j.ArchiveConflicts, conflict)
+}
+
+func renderArchiveConflictsMarkdown(items []archiveConflict) string {
+	if len(items) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("\n## 本地归档与接口不一致 (%d)\n\n", len(items)))
+	b.WriteString("| 对话 ID | 标题 | 策略 | 仅本地 | 仅接口 | 处理 |\n")
+	b.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+	for _, item := range items {
+		b.WriteString(fmt.Sprintf("| `%s` | %s | %s | %d | %d | %s |\n",
+			item.ConversationID,
+			firstNonEmpty(escapeMarkdownTableCell(item.Title), "-"),
+			item.Policy,
+			item.OnlyLocal,
+			item.OnlyRemote,
+			escapeMarkdownTableCell(item.Resolution),
+		))
+	}
+	return b.String()
+}
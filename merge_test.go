@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Devoty/openai-backup/client"
+)
+
+// mergeConversation 构造一条从 root 开始依次连接 nodes 的对话。
+func mergeConversation(id string, updateTime float64, nodes ...string) *client.Conversation {
+	conv := &client.Conversation{ID: id, Title: "标题", UpdateTime: client.FlexFloat64(updateTime), Mapping: map[string]client.Node{"root": {ID: "root"}}}
+	parent := "root"
+	for _, node := range nodes {
+		p := conv.Mapping[parent]
+		p.Children = append(p.Children, node)
+		conv.Mapping[parent] = p
+		conv.Mapping[node] = client.Node{ID: node, Parent: parent, Message: &client.Message{ID: node}}
+		parent = node
+	}
+	return conv
+}
+
+func mappingIDs(conv *client.Conversation) []string {
+	var ids []string
+	for id := range conv.Mapping {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestNormalizeArchiveMerge(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", archiveMergeNewer},
+		{"unknown", archiveMergeNewer},
+		{" Union ", archiveMergeUnion},
+		{"VERSIONS", archiveMergeVersions},
+	}
+	for _, tt := range tests {
+		if got := normalizeArchiveMerge(tt.value); got != tt.want {
+			t.Errorf("normalizeArchiveMerge(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestUnionConversation(t *testing.T) {
+	base := mergeConversation("c1", 2, "a", "b")
+	extra := mergeConversation("c1", 1, "a", "c")
+	extra.Mapping["d"] = client.Node{ID: "d", Parent: "missing", Message: &client.Message{ID: "d"}}
+	if got := messageNodeDiff(extra, base); !reflect.DeepEqual(got, []string{"c", "d"}) {
+		t.Errorf("messageNodeDiff() = %v", got)
+	}
+	merged := unionConversation(base, extra)
+	if got := mappingIDs(merged); !reflect.DeepEqual(got, []string{"a", "b", "c", "d", "root"}) {
+		t.Errorf("合并后的节点 = %v", got)
+	}
+	if got := merged.Mapping["a"].Children; !reflect.DeepEqual(got, []string{"b", "c"}) {
+		t.Errorf("a 的子节点 = %v, want [b c]", got)
+	}
+	if got := base.Mapping["a"].Children; !reflect.DeepEqual(got, []string{"b"}) {
+		t.Errorf("合并修改了 base: a 的子节点 = %v", got)
+	}
+}
+
+func TestMergeArchiveConversation(t *testing.T) {
+	tests := []struct {
+		name           string
+		policy         string
+		localUpdate    float64
+		remoteUpdate   float64
+		wantNodes      []string
+		wantResolution string
+		wantVersions   int
+	}{
+		{name: "接口较新", policy: archiveMergeNewer, localUpdate: 1, remoteUpdate: 2, wantNodes: []string{"a", "r", "root"}, wantResolution: "使用接口内容"},
+		{name: "本地较新", policy: archiveMergeNewer, localUpdate: 3, remoteUpdate: 2, wantNodes: []string{"a", "l", "root"}, wantResolution: "使用本地归档内容"},
+		{name: "合并两方消息", policy: archiveMergeUnion, localUpdate: 1, remoteUpdate: 2, wantNodes: []string{"a", "l", "r", "root"}, wantResolution: "以接口为准, 补入另一方独有的 1 条消息"},
+		{name: "保存本地历史版本", policy: archiveMergeVersions, localUpdate: 1, remoteUpdate: 2, wantNodes: []string{"a", "r", "root"}, wantResolution: "使用接口内容, 本地旧内容已另存为历史版本", wantVersions: 1},
+		{name: "本地较新时不保存版本", policy: archiveMergeVersions, localUpdate: 3, remoteUpdate: 2, wantNodes: []string{"a", "l", "root"}, wantResolution: "使用本地归档内容"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newTestStore(t)
+			local := mergeConversation("c1", tt.localUpdate, "a", "l")
+			data, err := json.Marshal(local)
+			if err != nil {
+				t.Fatal(err)
+			}
+			meta := client.ConversationMeta{ID: "c1", Title: "标题", UpdateTime: local.UpdateTime}
+			if err := store.SaveLocalConversations(ctx, []localConversation{{meta: meta, data: data}}, "takeout"); err != nil {
+				t.Fatal(err)
+			}
+			remote := mergeConversation("c1", tt.remoteUpdate, "a", "r")
+			s := &webServer{cfg: &cliConfig{}, store: store}
+
+			result := s.mergeArchiveConversation(ctx, tt.policy, local, remote)
+			if got := mappingIDs(result); !reflect.DeepEqual(got, tt.wantNodes) {
+				t.Errorf("合并结果节点 = %v, want %v", got, tt.wantNodes)
+			}
+			conflict, ok := s.takeArchiveConflict("c1")
+			if !ok || conflict.Resolution != tt.wantResolution || conflict.OnlyLocal != 1 || conflict.OnlyRemote != 1 || conflict.Policy != tt.policy {
+				t.Errorf("合并记录 = %+v, %v", conflict, ok)
+			}
+			if _, ok := s.takeArchiveConflict("c1"); ok {
+				t.Error("合并记录取出后应清除")
+			}
+			var versions int
+			if err := store.archive.reader.QueryRowContext(ctx, `SELECT COUNT(*) FROM local_conversation_versions WHERE conversation_id = 'c1'`).Scan(&versions); err != nil {
+				t.Fatal(err)
+			}
+			if versions != tt.wantVersions {
+				t.Errorf("历史版本 = %d, want %d", versions, tt.wantVersions)
+			}
+			saved, _, err := store.LocalConversation(ctx, "c1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, hasRemote := saved.Mapping["r"]
+			if hasRemote != (tt.wantVersions > 0) {
+				t.Errorf("本地副本节点 = %v", mappingIDs(saved))
+			}
+		})
+	}
+}
+
+func TestRenderArchiveConflictsMarkdown(t *testing.T) {
+	if got := renderArchiveConflictsMarkdown(nil); got != "" {
+		t.Errorf("没有冲突时输出 = %q", got)
+	}
+	got := renderArchiveConflictsMarkdown([]archiveConflict{
+		{ConversationID: "c1", Title: "a|b", Policy: archiveMergeUnion, OnlyLocal: 1, OnlyRemote: 2, Resolution: "合并"},
+		{ConversationID: "c2", Policy: archiveMergeNewer, Resolution: "使用接口内容"},
+	})
+	for _, want := range []string{
+		"## 本地归档与接口不一致 (2)",
+		"| `c1` | a\\|b | union | 1 | 2 | 合并 |",
+		"| `c2` | - | newer | 0 | 0 | 使用接口内容 |",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("报告缺少 %q:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+)
+
+// playgroundEndpoint 是调试页面中的一个接口表单。Body 为请求体示例, File 表示以 multipart 上传文件。
+type playgroundEndpoint struct {
+	Method  string
+	Path    string
+	Summary string
+	Body    string
+	File    bool
+}
+
+// playgroundEndpoints 列出调试页面中的接口, 路径中的 {id} 等占位符需在页面上替换。
+var playgroundEndpoints = []playgroundEndpoint{
+	{Method: http.MethodGet, Path: "/api/config", Summary: "读取当前配置"},
+	{Method: http.MethodPost, Path: "/api/config", Summary: "保存配置, 只需包含要修改的字段", Body: `{"time_format": "iso8601"}`},
+	{Method: http.MethodGet, Path: "/api/config/export", Summary: "导出配置 (不含密钥)"},
+	{Method: http.MethodPost, Path: "/api/config/import", Summary: "导入配置, 请求体为 /api/config/export 的输出", Body: `{}`},
+	{Method: http.MethodGet, Path: "/api/conversations?offset=0&limit=20&preview=1", Summary: "对话列表, 可加 refresh=1、not_exported=1"},
+	{Method: http.MethodGet, Path: "/api/conversations/{id}", Summary: "对话详情, 可加 ?refresh=1"},
+	{Method: http.MethodGet, Path: "/api/conversations/{id}/versions", Summary: "对话的历史版本"},
+	{Method: http.MethodGet, Path: "/api/conversations/{id}/search?q=channel", Summary: "在对话中搜索, 返回匹配的消息下标与摘录"},
+	{Method: http.MethodGet, Path: "/api/conversations/{id}/markdown", Summary: "对话的 Markdown 正文 (text/markdown)"},
+	{Method: http.MethodGet, Path: "/api/conversations/{id}/plaintext", Summary: "对话的纯文本正文 (text/plain)"},
+	{Method: http.MethodPost, Path: "/api/conversations/export", Summary: "导出为压缩包, format 为 markdown 或 html", Body: `{"ids": ["{id}"], "format": "markdown"}`},
+	{Method: http.MethodPost, Path: "/api/conversations/merge", Summary: "合并多个对话为一份文档, mode 为 interleaved 或 side_by_side (仅 html)", Body: `{"ids": ["{id}", "{id}"], "mode": "interleaved", "format": "markdown", "title": ""}`},
+	{Method: http.MethodPost, Path: "/api/conversations/delete", Summary: "删除对话 (在 ChatGPT 中隐藏)", Body: `{"ids": ["{id}"], "wait": false}`},
+	{Method: http.MethodPost, Path: "/api/import", Summary: "导出到目标, target 留空使用默认目标; items 可单独指定标题、标签与目标", Body: `{"ids": ["{id}"], "target": "", "items": []}`},
+	{Method: http.MethodPost, Path: "/api/import/test", Summary: "测试导出: 抽取几条对话导出到目标, 标题带测试标记且不记录导出状态", Body: `{"count": 3, "mode": "recent", "target": ""}`},
+	{Method: http.MethodGet, Path: "/api/queue?status=pending", Summary: "导出队列条目与时间段状态"},
+	{Method: http.MethodPost, Path: "/api/queue", Summary: "提交到导出队列, 在 queue_window 的时间段内执行; 请求体与 /api/import 相同", Body: `{"ids": ["{id}"], "target": ""}`},
+	{Method: http.MethodPost, Path: "/api/queue/cancel", Summary: "取消仍在等待的队列条目", Body: `{"ids": [1]}`},
+	{Method: http.MethodGet, Path: "/api/targets/status", Summary: "各导出目标的熔断状态"},
+	{Method: http.MethodGet, Path: "/api/status", Summary: "服务健康状态汇总, 可加 ?refresh=1 重新检查 ChatGPT Token"},
+	{Method: http.MethodGet, Path: "/api/version", Summary: "版本与构建信息, 开启 update_check 时附带最新发布, 可加 ?refresh=1"},
+	{Method: http.MethodGet, Path: "/api/notion/drafts", Summary: "待审阅的 Notion 草稿页面"},
+	{Method: http.MethodPost, Path: "/api/notion/promote", Summary: "把审阅过的草稿移动到最终父级", Body: `{"ids": ["{id}"]}`},
+	{Method: http.MethodGet, Path: "/api/projects/mappings", Summary: "ChatGPT 项目到各目标分类的映射"},
+	{Method: http.MethodPost, Path: "/api/projects/mappings", Summary: "保存项目映射, 留空的列使用项目名称", Body: `{"project": "项目名称", "notion_select": "", "anytype_tag": "", "directory": ""}`},
+	{Method: http.MethodPost, Path: "/api/projects/mappings/delete", Summary: "删除项目映射", Body: `{"projects": ["项目名称"]}`},
+	{Method: http.MethodGet, Path: "/api/failures", Summary: "导出失败记录, 可加 ?target=notion"},
+	{Method: http.MethodPost, Path: "/api/failures/retry", Summary: "重试失败记录", Body: `{"ids": [1]}`},
+	{Method: http.MethodGet, Path: "/api/jobs/{job_id}", Summary: "任务状态与进度"},
+	{Method: http.MethodGet, Path: "/api/jobs/{job_id}/report?format=markdown", Summary: "任务报告, format 为 json 或 markdown"},
+	{Method: http.MethodPost, Path: "/api/jobs/{job_id}/cancel", Summary: "取消等待中或进行中的删除任务"},
+	{Method: http.MethodPost, Path: "/api/batch", Summary: "批量操作: list、detail、export、delete", Body: `{"operations": [{"id": "1", "op": "list", "params": {"limit": 5}}]}`},
+	{Method: http.MethodPost, Path: "/api/hooks/run-backup", Summary: "触发备份, 在上方 Authorization 中填写 hook_api_key"},
+	{Method: http.MethodGet, Path: "/api/debug/skipped?id={id}", Summary: "对话中被过滤的消息"},
+	{Method: http.MethodGet, Path: "/api/debug/content-types?count=20&mode=recent", Summary: "抽样统计消息的 content_type、角色与 metadata 键, 标出未专门处理的类型"},
+	{Method: http.MethodGet, Path: "/api/admin/db", Summary: "数据库文件路径与大小"},
+	{Method: http.MethodPost, Path: "/api/admin/db", Summary: "数据库维护: vacuum、integrity_check 或 backup", Body: `{"operation": "integrity_check"}`},
+	{Method: http.MethodGet, Path: "/api/admin/config-locks", Summary: "锁定的配置项及来源"},
+	{Method: http.MethodPut, Path: "/api/admin/config-locks", Summary: "替换通过接口锁定的配置项", Body: `{"fields": ["base_url", "token"]}`},
+	{Method: http.MethodPost, Path: "/api/takeout", Summary: "上传 ChatGPT 官方导出数据压缩包", File: true},
+	{Method: http.MethodGet, Path: "/api/google/device", Summary: "Google 设备授权进度"},
+	{Method: http.MethodPost, Path: "/api/google/device", Summary: "开始 Google 设备授权"},
+	{Method: http.MethodGet, Path: "/api/tokens", Summary: "个人 API Token 列表"},
+	{Method: http.MethodPost, Path: "/api/tokens", Summary: "创建 API Token, 明文只返回一次", Body: `{"name": "playground", "scopes": ["read"]}`},
+	{Method: http.MethodPost, Path: "/api/tokens/revoke", Summary: "撤销 API Token", Body: `{"id": 1}`},
+	{Method: http.MethodGet, Path: "/feed.xml?limit=20", Summary: "最近备份的 Atom 订阅源"},
+	{Method: http.MethodGet, Path: "/export?cid={id}&target=", Summary: "快速导出确认页, 确认后导出单个对话并跳转到目标页面"},
+}
+
+var playgroundTemplate = template.Must(template.New("playground").Parse(`<!doctype html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>openai-backup 接口调试</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", "PingFang SC", sans-serif; margin: 0 auto; max-width: 960px; padding: 16px; color: #1f2328; }
+h1 { font-size: 20px; }
+.settings { display: flex; gap: 8px; align-items: center; margin-bottom: 16px; flex-wrap: wrap; }
+.settings input { flex: 1; min-width: 240px; }
+details { border: 1px solid #d0d7de; border-radius: 6px; margin-bottom: 8px; padding: 8px 12px; }
+summary { cursor: pointer; }
+.method { display: inline-block; width: 48px; font-weight: 600; font-family: monospace; }
+.method.GET { color: #0969da; } .method.POST { color: #1a7f37; }
+.path { font-family: monospace; }
+.summary { color: #57606a; margin-left: 8px; }
+form { margin-top: 8px; display: grid; gap: 6px; }
+input, textarea { font-family: monospace; font-size: 13px; padding: 4px 6px; }
+textarea { min-height: 72px; }
+pre { background: #f6f8fa; padding: 8px; overflow: auto; max-height: 420px; margin: 0; }
+.status { font-size: 13px; color: #57606a; }
+</style>
+</head>
+<body>
+<h1>openai-backup 接口调试</h1>
+<p>不依赖前端页面直接调用后端接口。请求使用当前浏览器的登录状态; 也可以在下方填写 Authorization 请求头 (如 <code>Bearer obk_...</code>)。</p>
+<div class="settings">
+<label for="auth">Authorization</label>
+<input id="auth" placeholder="留空不发送">
+</div>
+{{range .}}
+<details>
+<summary><span class="method {{.Method}}">{{.Method}}</span><span class="path">{{.Path}}</span><span class="summary">{{.Summary}}</span></summary>
+<form data-method="{{.Method}}"{{if .File}} data-file="1"{{end}}>
+<input name="path" value="{{.Path}}">
+{{if .File}}<input type="file" name="file">{{else if .Body}}<textarea name="body">{{.Body}}</textarea>{{end}}
+<div><button type="submit">发送</button> <span class="status"></span></div>
+<pre hidden></pre>
+</form>
+</details>
+{{end}}
+<script>
+document.querySelectorAll("form").forEach(function (form) {
+  form.addEventListener("submit", async function (event) {
+    event.preventDefault();
+    var status = form.querySelector(".status");
+    var out = form.querySelector("pre");
+    var options = { method: form.dataset.method, headers: {}, credentials: "same-origin", redirect: "manual" };
+    var auth = document.getElementById("auth").value.trim();
+    if (auth) options.headers["Authorization"] = auth;
+    if (form.dataset.file) {
+      var file = form.elements.file.files[0];
+      if (!file) { status.textContent = "请选择文件"; return; }
+      var data = new FormData();
+      data.append("file", file);
+      options.body = data;
+    } else if (form.elements.body) {
+      options.headers["Content-Type"] = "application/json";
+      options.body = form.elements.body.value;
+    }
+    status.textContent = "请求中...";
+    var started = performance.now();
+    try {
+      var resp = await fetch(form.elements.path.value, options);
+      var elapsed = Math.round(performance.now() - started);
+      var type = resp.headers.get("Content-Type") || "";
+      status.textContent = (resp.type === "opaqueredirect" ? "跳转" : resp.status + " " + resp.statusText) + " · " + elapsed + "ms · " + (type || "无内容类型");
+      out.hidden = false;
+      out.textContent = "";
+      if (type.indexOf("json") >= 0) {
+        var text = await resp.text();
+        try { out.textContent = JSON.stringify(JSON.parse(text), null, 2); } catch (e) { out.textContent = text; }
+      } else if (type.indexOf("text") === 0 || type.indexOf("xml") >= 0) {
+        out.textContent = await resp.text();
+      } else if (resp.type !== "opaqueredirect") {
+        var blob = await resp.blob();
+        var link = document.createElement("a");
+        link.href = URL.createObjectURL(blob);
+        link.download = "response";
+        link.textContent = "下载响应 (" + blob.size + " 字节)";
+        out.appendChild(link);
+      }
+    } catch (err) {
+      status.textContent = "请求失败: " + err;
+    }
+  });
+});
+</script>
+</body>
+</html>
+`))
+
+// handlePlayground 处理 GET /api/playground: 内置的接口调试页面, 列出各接口并提供表单直接调用,
+// 用于没有前端构建或排查前端问题时操作后端。
+func (s *webServer) handlePlayground(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+		return
+	}
+	var buf bytes.Buffer
+	if err := playgroundTemplate.Execute(&buf, playgroundEndpoints); err != nil {
+		writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "生成调试页面失败", err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		logInfo("输出调试页面失败: %v", err)
+	}
+}
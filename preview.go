@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/Devoty/openai-backup/client"
+	"github.com/Devoty/openai-backup/export"
+)
+
+const (
+	previewSnippetRunes = 120
+	previewConcurrency  = 4
+)
+
+// previewCacheEntry 以对话更新时间作为版本, 对话未变化时片段长期有效。
+type previewCacheEntry struct {
+	snippet    string
+	updateTime float64
+}
+
+// conversationSnippet 取第一条用户消息并压缩为单行摘要。
+func conversationSnippet(conv export.Conversation) string {
+	for _, msg := range conv.Messages {
+		if !strings.EqualFold(msg.Role, "user") {
+			continue
+		}
+		text := strings.Join(strings.Fields(msg.Text), " ")
+		if text == "" {
+			continue
+		}
+		if utf8.RuneCountInString(text) > previewSnippetRunes {
+			runes := []rune(text)
+			text = string(runes[:previewSnippetRunes]) + "…"
+		}
+		return text
+	}
+	return ""
+}
+
+func (s *webServer) cachedPreview(meta client.ConversationMeta) (string, bool) {
+	s.previewMu.RLock()
+	defer s.previewMu.RUnlock()
+	entry, ok := s.previewCache[meta.ID]
+	if !ok || entry.updateTime != meta.UpdateTime.Float64() {
+		return "", false
+	}
+	return entry.snippet, true
+}
+
+// previewSnippet 返回已缓存的摘要, 不触发上游请求。
+func (s *webServer) previewSnippet(id string) string {
+	s.previewMu.RLock()
+	defer s.previewMu.RUnlock()
+	return s.previewCache[id].snippet
+}
+
+// loadPreviews 为列表页补充内容摘要, 未命中缓存的对话并发拉取详情。
+// 单条获取失败时跳过该条, 不影响列表返回。
+func (s *webServer) loadPreviews(ctx context.Context, metas []client.ConversationMeta) map[string]string {
+	result := make(map[string]string, len(metas))
+	var pending []client.ConversationMeta
+	for _, meta := range metas {
+		if snippet, ok := s.cachedPreview(meta); ok {
+			result[meta.ID] = snippet
+			continue
+		}
+		pending = append(pending, meta)
+	}
+	if len(pending) == 0 {
+		return result
+	}
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, previewConcurrency)
+	)
+	for _, meta := range pending {
+		wg.Add(1)
+		go func(meta client.ConversationMeta) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			conv, err := s.loadExportConversation(ctx, meta.ID, false)
+			if err != nil {
+				logInfo("获取对话摘要失败: conversation=%s err=%v", meta.ID, err)
+				return
+			}
+			snippet := conversationSnippet(conv)
+			s.previewMu.Lock()
+			s.previewCache[meta.ID] = previewCacheEntry{snippet: snippet, updateTime: meta.UpdateTime.Float64()}
+			s.previewMu.Unlock()
+			mu.Lock()
+			result[meta.ID] = snippet
+			mu.Unlock()
+		}(meta)
+	}
+	wg.Wait()
+	return result
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Devoty/openai-backup/client"
+	"github.com/Devoty/openai-backup/export"
+)
+
+func TestConversationSnippet(t *testing.T) {
+	long := strings.Repeat("长", previewSnippetRunes+5)
+	tests := []struct {
+		name     string
+		messages []export.Message
+		want     string
+	}{
+		{name: "没有消息", want: ""},
+		{name: "跳过助手消息与空白提问", messages: []export.Message{{Role: "assistant", Text: "你好"}, {Role: "user", Text: " \n "}, {Role: "User", Text: "第一行\n\n  第二行 "}}, want: "第一行 第二行"},
+		{name: "按字符截断", messages: []export.Message{{Role: "user", Text: long}}, want: long[:len("长")*previewSnippetRunes] + "…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := conversationSnippet(export.Conversation{Messages: tt.messages}); got != tt.want {
+				t.Errorf("conversationSnippet() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCachedPreview(t *testing.T) {
+	s := &webServer{previewCache: map[string]previewCacheEntry{"c1": {snippet: "摘要", updateTime: 100}}}
+	tests := []struct {
+		name   string
+		meta   client.ConversationMeta
+		want   string
+		wantOK bool
+	}{
+		{name: "对话未变化", meta: client.ConversationMeta{ID: "c1", UpdateTime: 100}, want: "摘要", wantOK: true},
+		{name: "对话已更新", meta: client.ConversationMeta{ID: "c1", UpdateTime: 200}},
+		{name: "没有缓存", meta: client.ConversationMeta{ID: "c2", UpdateTime: 100}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := s.cachedPreview(tt.meta)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("cachedPreview() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+	if got := s.loadPreviews(context.Background(), []client.ConversationMeta{{ID: "c1", UpdateTime: 100}}); got["c1"] != "摘要" || len(got) != 1 {
+		t.Errorf("loadPreviews() = %v", got)
+	}
+	if got := s.previewSnippet("c1"); got != "摘要" {
+		t.Errorf("previewSnippet() = %q", got)
+	}
+}
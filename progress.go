@@ -0,0 +1,84 @@
+package main
+
+import (
+	"time"
+
+	"github.com/Devoty/openai-backup/export"
+)
+
+// progressBytesPerMessage 把正文字节数折算为消息数, 用于估算单个对话的处理量。
+const progressBytesPerMessage = 2048
+
+// jobProgress 是任务进度。对话拉取详情后才知道消息数与正文大小, 按此加权:
+// 已处理的对话计实际权重, 未处理的按已处理对话的平均权重估算, 大小悬殊的对话混在一起时
+// 百分比与剩余时间仍然可信。
+type jobProgress struct {
+	Total      int     `json:"total"`
+	Done       int     `json:"done"`
+	Messages   int     `json:"messages"`
+	Percent    float64 `json:"percent"`
+	ETASeconds int64   `json:"eta_seconds,omitempty"`
+
+	started time.Time
+	weight  float64
+	known   int
+}
+
+// conversationWeight 估算对话的处理量: 每个对话固定 1, 每条消息 1, 正文每 2 KB 再计 1。
+func conversationWeight(conv export.Conversation) float64 {
+	size := 0
+	for _, msg := range conv.Messages {
+		size += len(msg.Text)
+	}
+	return 1 + float64(len(conv.Messages)) + float64(size)/progressBytesPerMessage
+}
+
+// addPending 登记即将处理的对话数, 多目标任务每个目标各登记一次。
+func (j *exportJob) addPending(count int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.Progress == nil {
+		j.Progress = &jobProgress{started: time.Now()}
+	}
+	j.Progress.Total += count
+}
+
+// advance 记录一个对话处理完毕; conv 为 nil 表示未能拉取详情, 按平均权重计。
+func (j *exportJob) advance(conv *export.Conversation) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	p := j.Progress
+	if p == nil {
+		return
+	}
+	p.Done++
+	if conv != nil {
+		p.weight += conversationWeight(*conv)
+		p.known++
+		p.Messages += len(conv.Messages)
+	}
+}
+
+// estimate 返回带百分比与剩余时间的进度副本。
+func (p *jobProgress) estimate(now time.Time) *jobProgress {
+	if p == nil {
+		return nil
+	}
+	out := *p
+	if p.Total <= 0 {
+		return &out
+	}
+	avg := 1.0
+	if p.known > 0 {
+		avg = p.weight / float64(p.known)
+	}
+	done := p.weight + float64(p.Done-p.known)*avg
+	remaining := float64(p.Total-p.Done) * avg
+	if done+remaining > 0 {
+		out.Percent = float64(int(done/(done+remaining)*1000)) / 10
+	}
+	if elapsed := now.Sub(p.started); done > 0 && remaining > 0 {
+		out.ETASeconds = int64((elapsed.Seconds() / done * remaining) + 0.5)
+	}
+	return &out
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Devoty/openai-backup/export"
+)
+
+func TestConversationWeight(t *testing.T) {
+	tests := []struct {
+		name string
+		conv export.Conversation
+		want float64
+	}{
+		{name: "空对话", conv: export.Conversation{}, want: 1},
+		{name: "每条消息计 1", conv: export.Conversation{Messages: []export.Message{{Text: "a"}, {Text: "b"}}}, want: 3 + 2.0/progressBytesPerMessage},
+		{name: "正文每 2 KB 计 1", conv: export.Conversation{Messages: []export.Message{{Text: strings.Repeat("x", 2*progressBytesPerMessage)}}}, want: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := conversationWeight(tt.conv); got != tt.want {
+				t.Errorf("conversationWeight() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobProgressEstimate(t *testing.T) {
+	// 权重为 4: 对话 1 + 消息 2 + 正文 2 KB。
+	heavy := &export.Conversation{Messages: []export.Message{
+		{Text: strings.Repeat("x", progressBytesPerMessage/2)},
+		{Text: strings.Repeat("y", progressBytesPerMessage/2)},
+	}}
+	tests := []struct {
+		name        string
+		pending     []int
+		done        []*export.Conversation
+		wantPercent float64
+		wantETA     int64
+	}{
+		{name: "尚未开始", pending: []int{4}, wantPercent: 0, wantETA: 0},
+		{name: "未拉到详情的对话按平均权重计", pending: []int{4}, done: []*export.Conversation{heavy, nil}, wantPercent: 50, wantETA: 10},
+		{name: "没有已知权重时每个对话计 1", pending: []int{2, 2}, done: []*export.Conversation{nil}, wantPercent: 25, wantETA: 30},
+		{name: "全部完成", pending: []int{2}, done: []*export.Conversation{heavy, heavy}, wantPercent: 100, wantETA: 0},
+		{name: "没有对话", pending: []int{0}, wantPercent: 0, wantETA: 0},
+	}
+	now := time.Now()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := &exportJob{}
+			for _, count := range tt.pending {
+				j.addPending(count)
+			}
+			j.Progress.started = now.Add(-10 * time.Second)
+			messages := 0
+			for _, conv := range tt.done {
+				j.advance(conv)
+				if conv != nil {
+					messages += len(conv.Messages)
+				}
+			}
+			got := j.Progress.estimate(now)
+			if got.Percent != tt.wantPercent || got.ETASeconds != tt.wantETA {
+				t.Errorf("estimate() = %.1f%% ETA %ds, want %.1f%% ETA %ds", got.Percent, got.ETASeconds, tt.wantPercent, tt.wantETA)
+			}
+			if got.Done != len(tt.done) || got.Messages != messages {
+				t.Errorf("done = %d, messages = %d, want %d, %d", got.Done, got.Messages, len(tt.done), messages)
+			}
+		})
+	}
+	var p *jobProgress
+	if p.estimate(now) != nil {
+		t.Error("nil 进度应返回 nil")
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Devoty/openai-backup/client"
+	"github.com/Devoty/openai-backup/export"
+)
+
+// projectMappingsSchema 保存 ChatGPT 项目到各目标分类的映射, 某一列为空时该目标直接使用项目名称。
+const projectMappingsSchema = `
+	CREATE TABLE IF NOT EXISTS project_mappings (
+		project TEXT PRIMARY KEY,
+		notion_select TEXT NOT NULL DEFAULT '',
+		anytype_tag TEXT NOT NULL DEFAULT '',
+		directory TEXT NOT NULL DEFAULT '',
+		updated_at TIMESTAMP NOT NULL
+	);`
+
+// projectMapping 是一个项目在各目标中的分类: Notion 选择属性的选项、Anytype 标签与导出压缩包中的子目录。
+type projectMapping struct {
+	Project      string    `json:"project"`
+	NotionSelect string    `json:"notion_select"`
+	AnytypeTag   string    `json:"anytype_tag"`
+	Directory    string    `json:"directory"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type projectMappingDeleteRequest struct {
+	Projects []string `json:"projects"`
+}
+
+// SaveProjectMapping 写入或覆盖项目的映射。
+func (s *ConfigStore) SaveProjectMapping(ctx context.Context, mapping projectMapping) error {
+	if s == nil || s.config == nil {
+		return errors.New("配置存储未初始化")
+	}
+	_, err := s.config.writer.ExecContext(ctx, `
+		INSERT INTO project_mappings(project, notion_select, anytype_tag, directory, updated_at)
+		VALUES(?, ?, ?, ?, ?)
+		ON CONFLICT(project) DO UPDATE SET
+			notion_select=excluded.notion_select,
+			anytype_tag=excluded.anytype_tag,
+			directory=excluded.directory,
+			updated_at=excluded.updated_at
+	`, mapping.Project, mapping.NotionSelect, mapping.AnytypeTag, mapping.Directory, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("写入项目映射失败: %w", err)
+	}
+	return nil
+}
+
+// ListProjectMappings 按项目名称列出全部映射。
+func (s *ConfigStore) ListProjectMappings(ctx context.Context) ([]projectMapping, error) {
+	if s == nil || s.config == nil {
+		return nil, errors.New("配置存储未初始化")
+	}
+	rows, err := s.config.reader.QueryContext(ctx, `
+		SELECT project, notion_select, anytype_tag, directory, updated_at FROM project_mappings ORDER BY project
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("读取项目映射失败: %w", err)
+	}
+	defer rows.Close()
+	var items []projectMapping
+	for rows.Next() {
+		var item projectMapping
+		if err := rows.Scan(&item.Project, &item.NotionSelect, &item.AnytypeTag, &item.Directory, &item.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("解析项目映射失败: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取项目映射失败: %w", err)
+	}
+	return items, nil
+}
+
+// DeleteProjectMapping 删除项目的映射, 之后该项目在各目标中使用项目名称。
+func (s *ConfigStore) DeleteProjectMapping(ctx context.Context, project string) error {
+	if s == nil || s.config == nil {
+		return errors.New("配置存储未初始化")
+	}
+	if _, err := s.config.writer.ExecContext(ctx, `DELETE FROM project_mappings WHERE project = ?`, project); err != nil {
+		return fmt.Errorf("删除项目映射失败: %w", err)
+	}
+	return nil
+}
+
+// projectName 返回 gizmo_id 对应的项目名称, 自定义 GPT 与查询失败时返回空字符串。名称按 ID 缓存,
+// 同一项目只请求一次。
+func (s *webServer) projectName(ctx context.Context, cfg *cliConfig, gizmoID string) string {
+	if !client.IsProjectID(gizmoID) {
+		return ""
+	}
+	s.projectMu.Lock()
+	name, ok := s.projectNames[gizmoID]
+	s.projectMu.Unlock()
+	if ok {
+		return name
+	}
+	token := strings.TrimSpace(cfg.Token)
+	if token == "" {
+		return ""
+	}
+	name, err := newChatGPTClient(cfg, token).ProjectName(ctx, gizmoID)
+	if err != nil {
+		logInfo("读取 ChatGPT 项目 %s 的名称失败: %v", gizmoID, err)
+		return ""
+	}
+	s.projectMu.Lock()
+	s.projectNames[gizmoID] = name
+	s.projectMu.Unlock()
+	return name
+}
+
+// projectMapping 返回项目的映射, 映射表在首次使用与修改后重新读取。
+func (s *webServer) projectMapping(project string) projectMapping {
+	s.projectMu.Lock()
+	defer s.projectMu.Unlock()
+	if s.projectMappings == nil {
+		items, err := s.store.ListProjectMappings(context.Background())
+		if err != nil {
+			logInfo("读取项目映射失败: %v", err)
+			return projectMapping{}
+		}
+		s.projectMappings = make(map[string]projectMapping, len(items))
+		for _, item := range items {
+			s.projectMappings[item.Project] = item
+		}
+	}
+	return s.projectMappings[project]
+}
+
+func (s *webServer) invalidateProjectMappings() {
+	s.projectMu.Lock()
+	s.projectMappings = nil
+	s.projectMu.Unlock()
+}
+
+// applyProjectMapping 把对话所属的项目换成目标中的分类: Notion 为选择属性的选项, Anytype 为标签,
+// 导出压缩包与 Markdown 目录为子目录; 映射表中对应列为空时使用项目名称。其他目标不使用项目分类。
+func (s *webServer) applyProjectMapping(target string, conv export.Conversation) export.Conversation {
+	project := conv.Project
+	conv.Project = ""
+	if project == "" {
+		return conv
+	}
+	mapping := s.projectMapping(project)
+	switch target {
+	case exportTargetNotion:
+		conv.Project = firstNonEmpty(strings.TrimSpace(mapping.NotionSelect), project)
+	case exportTargetAnytype:
+		conv.Tags = append(append([]string(nil), conv.Tags...), firstNonEmpty(strings.TrimSpace(mapping.AnytypeTag), project))
+	case titleFallbackZip, exportTargetMarkdown:
+		conv.Project = firstNonEmpty(strings.TrimSpace(mapping.Directory), project)
+	}
+	return conv
+}
+
+// handleProjectMappings 处理 /api/projects/mappings: GET 列出项目映射, POST 写入一条映射。
+func (s *webServer) handleProjectMappings(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		items, err := s.store.ListProjectMappings(r.Context())
+		if err != nil {
+			writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "读取项目映射失败", err)
+			return
+		}
+		if items == nil {
+			items = []projectMapping{}
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"items":   items,
+			"enabled": s.configSnapshot().ProjectTags,
+		})
+	case http.MethodPost:
+		var input projectMapping
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			writeErrorDetail(w, http.StatusBadRequest, errCodeInvalidRequest, "请求体解析失败", err)
+			return
+		}
+		input.Project = strings.TrimSpace(input.Project)
+		if input.Project == "" {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "缺少项目名称")
+			return
+		}
+		input.NotionSelect = strings.TrimSpace(input.NotionSelect)
+		input.AnytypeTag = strings.TrimSpace(input.AnytypeTag)
+		input.Directory = strings.TrimSpace(input.Directory)
+		if err := s.store.SaveProjectMapping(r.Context(), input); err != nil {
+			writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "保存项目映射失败", err)
+			return
+		}
+		s.invalidateProjectMappings()
+		logInfo("保存项目映射: project=%s notion=%q anytype=%q directory=%q", input.Project, input.NotionSelect, input.AnytypeTag, input.Directory)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"saved": input.Project})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+	}
+}
+
+// handleProjectMappingDelete 处理 POST /api/projects/mappings/delete: 删除项目映射。
+func (s *webServer) handleProjectMappingDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+		return
+	}
+	var req projectMappingDeleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorDetail(w, http.StatusBadRequest, errCodeInvalidRequest, "请求体解析失败", err)
+		return
+	}
+	deleted := 0
+	for _, project := range req.Projects {
+		if project = strings.TrimSpace(project); project == "" {
+			continue
+		}
+		if err := s.store.DeleteProjectMapping(r.Context(), project); err != nil {
+			writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "删除项目映射失败", err)
+			return
+		}
+		deleted++
+	}
+	s.invalidateProjectMappings()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"deleted": deleted})
+}
@@ -0,0 +1,628 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exportQueueSchema 保存提交到导出队列、等待在允许的时间段内执行的对话。
+const exportQueueSchema = `
+	CREATE TABLE IF NOT EXISTS export_queue (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		conversation_id TEXT NOT NULL,
+		target TEXT NOT NULL,
+		title TEXT NOT NULL DEFAULT '',
+		tags TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL,
+		job_id TEXT NOT NULL DEFAULT '',
+		url TEXT NOT NULL DEFAULT '',
+		error TEXT NOT NULL DEFAULT '',
+		submitted_at TIMESTAMP NOT NULL,
+		finished_at TIMESTAMP
+	);`
+
+const exportQueueIndex = `CREATE INDEX IF NOT EXISTS idx_export_queue_status ON export_queue(status, id);`
+
+const (
+	queueStatusPending = "pending"
+	queueStatusRunning = "running"
+	queueStatusDone    = "done"
+	queueStatusSkipped = "skipped"
+	queueStatusFailed  = "failed"
+)
+
+const (
+	// queuePollInterval 是队列检查时间段与待执行条目的间隔, 提交新条目时会立即检查一次。
+	queuePollInterval = 30 * time.Second
+	// queueRetryDelay 是任务中止 (如 ChatGPT Token 失效) 后到下一次尝试的间隔, 期间条目保持待执行。
+	queueRetryDelay = 10 * time.Minute
+	queueBatchSize  = 20
+	// queueRetention 是已结束条目的保留时间。
+	queueRetention    = 30 * 24 * time.Hour
+	defaultQueueLimit = 200
+	maxQueueListLimit = 1000
+)
+
+// queueEntry 是导出队列中的一条对话, Title 与 Tags 为提交时单独指定的标题与标签。
+type queueEntry struct {
+	ID             int64      `json:"id"`
+	ConversationID string     `json:"conversation_id"`
+	Target         string     `json:"target"`
+	Title          string     `json:"title,omitempty"`
+	Tags           []string   `json:"tags,omitempty"`
+	Status         string     `json:"status"`
+	JobID          string     `json:"job_id,omitempty"`
+	URL            string     `json:"url,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	SubmittedAt    time.Time  `json:"submitted_at"`
+	FinishedAt     *time.Time `json:"finished_at,omitempty"`
+}
+
+type queueCancelRequest struct {
+	IDs []int64 `json:"ids"`
+}
+
+// queueWindow 是一天中允许执行队列的时间段, 以分钟表示; Start 大于 End 时跨越午夜。
+type queueWindow struct {
+	Start int
+	End   int
+}
+
+// parseQueueWindow 解析逗号分隔的 "HH:MM-HH:MM" 时间段列表, 忽略格式错误的项。
+func parseQueueWindow(value string) []queueWindow {
+	var windows []queueWindow
+	for _, part := range strings.Split(value, ",") {
+		from, to, ok := strings.Cut(strings.TrimSpace(part), "-")
+		if !ok {
+			continue
+		}
+		start, ok1 := parseClockMinutes(from)
+		end, ok2 := parseClockMinutes(to)
+		if !ok1 || !ok2 || start == end {
+			continue
+		}
+		windows = append(windows, queueWindow{Start: start, End: end})
+	}
+	return windows
+}
+
+func parseClockMinutes(value string) (int, bool) {
+	hour, minute, ok := strings.Cut(strings.TrimSpace(value), ":")
+	if !ok {
+		return 0, false
+	}
+	h, err1 := strconv.Atoi(hour)
+	m, err2 := strconv.Atoi(minute)
+	if err1 != nil || err2 != nil || h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, false
+	}
+	return h*60 + m, true
+}
+
+func normalizeQueueWindow(value string) string {
+	windows := parseQueueWindow(value)
+	parts := make([]string, 0, len(windows))
+	for _, window := range windows {
+		parts = append(parts, fmt.Sprintf("%02d:%02d-%02d:%02d", window.Start/60, window.Start%60, window.End/60, window.End%60))
+	}
+	return strings.Join(parts, ",")
+}
+
+// queueWindowOpen 判断 now 是否在任一时间段内, 未配置时间段时始终允许。
+func queueWindowOpen(windows []queueWindow, now time.Time) bool {
+	if len(windows) == 0 {
+		return true
+	}
+	minute := now.Hour()*60 + now.Minute()
+	for _, window := range windows {
+		if window.Start < window.End && minute >= window.Start && minute < window.End {
+			return true
+		}
+		if window.Start > window.End && (minute >= window.Start || minute < window.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// nextQueueWindow 返回 now 之后最近一个时间段的开始时间, 未配置时间段时返回零值。
+func nextQueueWindow(windows []queueWindow, now time.Time) time.Time {
+	var next time.Time
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for _, window := range windows {
+		start := midnight.Add(time.Duration(window.Start) * time.Minute)
+		if !start.After(now) {
+			start = start.AddDate(0, 0, 1)
+		}
+		if next.IsZero() || start.Before(next) {
+			next = start
+		}
+	}
+	return next
+}
+
+// EnqueueExports 把对话加入导出队列。同一目标中已在等待的对话只更新标题与标签, 不重复加入。
+func (s *ConfigStore) EnqueueExports(ctx context.Context, target string, items []exportItem) (int, error) {
+	if s == nil || s.archive == nil {
+		return 0, errors.New("配置存储未初始化")
+	}
+	tx, err := s.archive.writer.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("写入导出队列失败: %w", err)
+	}
+	defer tx.Rollback()
+	now := time.Now().UTC()
+	added := 0
+	for _, item := range items {
+		tags := ""
+		if len(item.Tags) > 0 {
+			data, err := json.Marshal(item.Tags)
+			if err != nil {
+				return 0, fmt.Errorf("序列化标签失败: %w", err)
+			}
+			tags = string(data)
+		}
+		res, err := tx.ExecContext(ctx, `
+			UPDATE export_queue SET title = ?, tags = ? WHERE conversation_id = ? AND target = ? AND status = ?
+		`, item.CustomTitle, tags, item.ID, target, queueStatusPending)
+		if err != nil {
+			return 0, fmt.Errorf("写入导出队列失败: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO export_queue(conversation_id, target, title, tags, status, submitted_at)
+			VALUES(?, ?, ?, ?, ?, ?)
+		`, item.ID, target, item.CustomTitle, tags, queueStatusPending, now); err != nil {
+			return 0, fmt.Errorf("写入导出队列失败: %w", err)
+		}
+		added++
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("写入导出队列失败: %w", err)
+	}
+	return added, nil
+}
+
+// ClaimQueueEntries 取出最早提交的至多 limit 条待执行条目并标记为执行中。
+func (s *ConfigStore) ClaimQueueEntries(ctx context.Context, limit int) ([]queueEntry, error) {
+	if s == nil || s.archive == nil {
+		return nil, errors.New("配置存储未初始化")
+	}
+	tx, err := s.archive.writer.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("读取导出队列失败: %w", err)
+	}
+	defer tx.Rollback()
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, conversation_id, target, title, tags, status, job_id, url, error, submitted_at, finished_at
+		FROM export_queue WHERE status = ? ORDER BY id LIMIT ?
+	`, queueStatusPending, limit)
+	if err != nil {
+		return nil, fmt.Errorf("读取导出队列失败: %w", err)
+	}
+	entries, err := scanQueueEntries(rows)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		if _, err := tx.ExecContext(ctx, `UPDATE export_queue SET status = ? WHERE id = ?`, queueStatusRunning, entries[i].ID); err != nil {
+			return nil, fmt.Errorf("更新导出队列失败: %w", err)
+		}
+		entries[i].Status = queueStatusRunning
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("更新导出队列失败: %w", err)
+	}
+	return entries, nil
+}
+
+// FinishQueueEntry 更新条目的状态; 状态为待执行时清空任务 ID, 下次重新执行。
+func (s *ConfigStore) FinishQueueEntry(ctx context.Context, id int64, status, jobID, url, message string) error {
+	if s == nil || s.archive == nil {
+		return errors.New("配置存储未初始化")
+	}
+	var finished interface{}
+	if status == queueStatusPending {
+		jobID = ""
+	} else {
+		finished = time.Now().UTC()
+	}
+	if _, err := s.archive.writer.ExecContext(ctx, `
+		UPDATE export_queue SET status = ?, job_id = ?, url = ?, error = ?, finished_at = ? WHERE id = ?
+	`, status, jobID, url, message, finished, id); err != nil {
+		return fmt.Errorf("更新导出队列失败: %w", err)
+	}
+	return nil
+}
+
+// ResetRunningQueueEntries 把上次退出时仍在执行的条目恢复为待执行。
+func (s *ConfigStore) ResetRunningQueueEntries(ctx context.Context) (int64, error) {
+	if s == nil || s.archive == nil {
+		return 0, errors.New("配置存储未初始化")
+	}
+	res, err := s.archive.writer.ExecContext(ctx, `
+		UPDATE export_queue SET status = ?, job_id = '' WHERE status = ?
+	`, queueStatusPending, queueStatusRunning)
+	if err != nil {
+		return 0, fmt.Errorf("恢复导出队列失败: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
+
+// PruneQueueEntries 删除结束时间早于 before 的条目。
+func (s *ConfigStore) PruneQueueEntries(ctx context.Context, before time.Time) error {
+	if s == nil || s.archive == nil {
+		return errors.New("配置存储未初始化")
+	}
+	if _, err := s.archive.writer.ExecContext(ctx, `
+		DELETE FROM export_queue WHERE finished_at IS NOT NULL AND finished_at < ?
+	`, before.UTC()); err != nil {
+		return fmt.Errorf("清理导出队列失败: %w", err)
+	}
+	return nil
+}
+
+// CancelQueueEntries 删除仍在等待的条目, 返回删除的条数; 执行中与已结束的条目不受影响。
+func (s *ConfigStore) CancelQueueEntries(ctx context.Context, ids []int64) (int, error) {
+	if s == nil || s.archive == nil {
+		return 0, errors.New("配置存储未初始化")
+	}
+	cancelled := 0
+	for _, id := range ids {
+		res, err := s.archive.writer.ExecContext(ctx, `DELETE FROM export_queue WHERE id = ? AND status = ?`, id, queueStatusPending)
+		if err != nil {
+			return cancelled, fmt.Errorf("取消导出队列条目失败: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			cancelled++
+		}
+	}
+	return cancelled, nil
+}
+
+// ListQueueEntries 按提交顺序倒序列出条目, status 为空时列出全部。
+func (s *ConfigStore) ListQueueEntries(ctx context.Context, status string, limit int) ([]queueEntry, error) {
+	if s == nil || s.archive == nil {
+		return nil, errors.New("配置存储未初始化")
+	}
+	query := `
+		SELECT id, conversation_id, target, title, tags, status, job_id, url, error, submitted_at, finished_at
+		FROM export_queue`
+	args := []interface{}{}
+	if status != "" {
+		query += ` WHERE status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+	rows, err := s.archive.reader.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("读取导出队列失败: %w", err)
+	}
+	return scanQueueEntries(rows)
+}
+
+// CountQueueEntries 按状态统计条目数。
+func (s *ConfigStore) CountQueueEntries(ctx context.Context) (map[string]int, error) {
+	if s == nil || s.archive == nil {
+		return nil, errors.New("配置存储未初始化")
+	}
+	rows, err := s.archive.reader.QueryContext(ctx, `SELECT status, COUNT(*) FROM export_queue GROUP BY status`)
+	if err != nil {
+		return nil, fmt.Errorf("统计导出队列失败: %w", err)
+	}
+	defer rows.Close()
+	counts := map[string]int{}
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("解析导出队列统计失败: %w", err)
+		}
+		counts[status] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("统计导出队列失败: %w", err)
+	}
+	return counts, nil
+}
+
+func scanQueueEntries(rows *sql.Rows) ([]queueEntry, error) {
+	defer rows.Close()
+	var entries []queueEntry
+	for rows.Next() {
+		var entry queueEntry
+		var tags string
+		if err := rows.Scan(&entry.ID, &entry.ConversationID, &entry.Target, &entry.Title, &tags, &entry.Status, &entry.JobID, &entry.URL, &entry.Error, &entry.SubmittedAt, &entry.FinishedAt); err != nil {
+			return nil, fmt.Errorf("解析导出队列失败: %w", err)
+		}
+		if tags != "" {
+			if err := json.Unmarshal([]byte(tags), &entry.Tags); err != nil {
+				return nil, fmt.Errorf("解析导出队列标签失败: %w", err)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取导出队列失败: %w", err)
+	}
+	return entries, nil
+}
+
+// wakeExportQueue 通知队列立即检查一次, 不阻塞。
+func (s *webServer) wakeExportQueue() {
+	select {
+	case s.queueWake <- struct{}{}:
+	default:
+	}
+}
+
+// runExportQueue 在后台执行导出队列, 直到 ctx 取消。每隔 queuePollInterval 或收到新提交时检查一次,
+// 当前时间不在 queue_window 的时间段内时条目保持等待。
+func (s *webServer) runExportQueue(ctx context.Context) {
+	if n, err := s.store.ResetRunningQueueEntries(ctx); err != nil {
+		logInfo("%v", err)
+	} else if n > 0 {
+		logInfo("导出队列: %d 条上次未完成的条目恢复为待执行", n)
+	}
+	for {
+		wait := queuePollInterval
+		if err := s.drainExportQueue(ctx); err != nil && ctx.Err() == nil {
+			logInfo("导出队列暂停 %s: %v", queueRetryDelay, err)
+			wait = queueRetryDelay
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-s.queueWake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// drainExportQueue 在时间段内分批执行待执行条目, 每批一个 queue 任务; 离开时间段或队列为空时返回。
+// 任务中止时未执行的条目恢复为待执行并返回错误, 由调用方推迟下一次尝试。
+func (s *webServer) drainExportQueue(ctx context.Context) error {
+	if err := s.store.PruneQueueEntries(ctx, time.Now().Add(-queueRetention)); err != nil {
+		logInfo("%v", err)
+	}
+	for ctx.Err() == nil {
+		cfg := s.configSnapshot()
+		if !queueWindowOpen(parseQueueWindow(cfg.QueueWindow), time.Now().In(s.locationSnapshot())) {
+			return nil
+		}
+		entries, err := s.store.ClaimQueueEntries(ctx, queueBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+		if err := s.runQueueBatch(ctx, cfg, entries); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// runQueueBatch 按目标分组执行一批条目并写回各条目的结果。
+func (s *webServer) runQueueBatch(ctx context.Context, cfg *cliConfig, entries []queueEntry) error {
+	var order []string
+	groups := make(map[string][]queueEntry)
+	for _, entry := range entries {
+		if _, ok := groups[entry.Target]; !ok {
+			order = append(order, entry.Target)
+		}
+		groups[entry.Target] = append(groups[entry.Target], entry)
+	}
+	logInfo("导出队列执行: 条目=%d 目标=%s", len(entries), strings.Join(order, ","))
+	job := s.jobs.start("queue", strings.Join(order, ","))
+	finish := func(entry queueEntry, status, url, message string) {
+		if err := s.store.FinishQueueEntry(context.Background(), entry.ID, status, job.ID, url, message); err != nil {
+			logInfo("%v", err)
+		}
+	}
+
+	var batchErr error
+	for _, name := range order {
+		group := groups[name]
+		if batchErr != nil {
+			for _, entry := range group {
+				finish(entry, queueStatusPending, "", "")
+			}
+			continue
+		}
+		exporter, label, err := s.resolveExporter(name)
+		if err != nil {
+			for _, entry := range group {
+				finish(entry, queueStatusFailed, "", err.Error())
+			}
+			continue
+		}
+		items := make([]exportItem, 0, len(group))
+		byConversation := make(map[string]queueEntry, len(group))
+		for _, entry := range group {
+			items = append(items, exportItem{ID: entry.ConversationID, CustomTitle: entry.Title, Tags: entry.Tags})
+			byConversation[entry.ConversationID] = entry
+		}
+		result, err := s.syncConversations(ctx, job, name, label, exporter, s.fetchExportConversation, items, cfg.OutputTimezone)
+
+		// 中止后未执行的对话与服务退出时被打断的对话留在队列中, 不记入失败记录。
+		executed := result.Failed[:0:0]
+		for _, item := range result.Failed {
+			if err != nil && (ctx.Err() != nil || item.Error == syncAbortedReason) {
+				finish(byConversation[item.ConversationID], queueStatusPending, "", "")
+				continue
+			}
+			executed = append(executed, item)
+			finish(byConversation[item.ConversationID], queueStatusFailed, "", item.Error)
+		}
+		result.Failed = executed
+		s.recordSyncResult(name, result)
+		job.recordSync(name, result)
+		for _, item := range result.Exported {
+			finish(byConversation[item.ConversationID], queueStatusDone, item.URL, "")
+		}
+		for _, id := range result.Skipped {
+			finish(byConversation[id], queueStatusSkipped, "", "没有可导出的消息")
+		}
+		for _, item := range result.Unavailable {
+			finish(byConversation[item.ConversationID], queueStatusSkipped, "", item.Reason)
+		}
+		if err != nil {
+			logInfo("导出队列执行 %s 中止: %v", label, err)
+			batchErr = err
+		}
+	}
+	s.jobs.finish(job, batchErr, s.locationSnapshot())
+	return batchErr
+}
+
+// handleQueue 处理 /api/queue: GET 列出队列条目与时间段状态, POST 提交对话 (请求体与 /api/import 相同),
+// 在 queue_window 的时间段内由后台执行。
+func (s *webServer) handleQueue(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.listQueue(w, r)
+	case http.MethodPost:
+		s.submitQueue(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+	}
+}
+
+func (s *webServer) listQueue(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	status := strings.TrimSpace(query.Get("status"))
+	limit := defaultQueueLimit
+	if raw := strings.TrimSpace(query.Get("limit")); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value <= 0 {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "limit 必须为正整数")
+			return
+		}
+		limit = min(value, maxQueueListLimit)
+	}
+	items, err := s.store.ListQueueEntries(r.Context(), status, limit)
+	if err != nil {
+		writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "读取导出队列失败", err)
+		return
+	}
+	counts, err := s.store.CountQueueEntries(r.Context())
+	if err != nil {
+		writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "统计导出队列失败", err)
+		return
+	}
+	if items == nil {
+		items = []queueEntry{}
+	}
+	writeJSON(w, http.StatusOK, s.queueWindowStatus(map[string]interface{}{
+		"items":  items,
+		"counts": counts,
+	}))
+}
+
+func (s *webServer) submitQueue(w http.ResponseWriter, r *http.Request) {
+	var req importRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorDetail(w, http.StatusBadRequest, errCodeInvalidRequest, "请求体解析失败", err)
+		return
+	}
+	ctx := r.Context()
+	target := strings.TrimSpace(req.Target)
+	if target == "" {
+		target = s.configSnapshot().ExportTarget
+	}
+	target = normalizeExportTarget(target)
+	if len(req.IDs) == 0 && len(req.Items) == 0 && req.Filter != nil {
+		ids, err := s.resolveImportFilter(ctx, *req.Filter, req.Limit, target)
+		if err != nil {
+			writeErrorDetail(w, http.StatusBadRequest, errCodeInvalidRequest, "解析筛选条件失败", err)
+			return
+		}
+		if len(ids) == 0 {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "没有符合筛选条件的对话")
+			return
+		}
+		req.IDs = ids
+	}
+	order, groups := groupImportItems(req.IDs, req.Items, target)
+	if len(order) == 0 {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "请选择至少一条对话")
+		return
+	}
+	// 提交时检查目标配置, 避免到时间段内才发现无法执行。
+	for _, name := range order {
+		if _, _, err := s.resolveExporter(name); err != nil {
+			writeError(w, http.StatusBadRequest, errCodeTargetMisconfigured, err.Error())
+			return
+		}
+	}
+	queued, total := 0, 0
+	for _, name := range order {
+		added, err := s.store.EnqueueExports(ctx, name, groups[name])
+		if err != nil {
+			writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "写入导出队列失败", err)
+			return
+		}
+		queued += added
+		total += len(groups[name])
+	}
+	logInfo("导出队列提交: 选中=%d 新增=%d 目标=%s", total, queued, strings.Join(order, ","))
+	s.wakeExportQueue()
+	writeJSON(w, http.StatusAccepted, s.queueWindowStatus(map[string]interface{}{
+		"queued":  queued,
+		"updated": total - queued,
+		"target":  strings.Join(order, ","),
+	}))
+}
+
+// queueWindowStatus 在响应中加入时间段配置、当前是否在时间段内以及下一个时间段的开始时间。
+func (s *webServer) queueWindowStatus(response map[string]interface{}) map[string]interface{} {
+	cfg := s.configSnapshot()
+	windows := parseQueueWindow(cfg.QueueWindow)
+	now := time.Now().In(s.locationSnapshot())
+	response["window"] = cfg.QueueWindow
+	response["open"] = queueWindowOpen(windows, now)
+	if next := nextQueueWindow(windows, now); !next.IsZero() && !queueWindowOpen(windows, now) {
+		response["next_window"] = next
+	}
+	return response
+}
+
+// handleQueueCancel 处理 POST /api/queue/cancel: 取消仍在等待的条目。
+func (s *webServer) handleQueueCancel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+		return
+	}
+	var req queueCancelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorDetail(w, http.StatusBadRequest, errCodeInvalidRequest, "请求体解析失败", err)
+		return
+	}
+	if len(req.IDs) == 0 {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "请选择至少一个条目")
+		return
+	}
+	cancelled, err := s.store.CancelQueueEntries(r.Context(), req.IDs)
+	if err != nil {
+		writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "取消导出队列条目失败", err)
+		return
+	}
+	logInfo("导出队列取消: 选中=%d 取消=%d", len(req.IDs), cancelled)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"cancelled": cancelled})
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseQueueWindow(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []queueWindow
+		norm  string
+	}{
+		{value: "", want: nil, norm: ""},
+		{value: "1:30-6:00", want: []queueWindow{{Start: 90, End: 360}}, norm: "01:30-06:00"},
+		{value: " 22:00-07:00 , 12:00-13:00", want: []queueWindow{{Start: 1320, End: 420}, {Start: 720, End: 780}}, norm: "22:00-07:00,12:00-13:00"},
+		{value: "20:00-24:00", want: []queueWindow{{Start: 1200, End: 1440}}, norm: "20:00-24:00"},
+		{value: "08:00-08:00,24:01-02:00,1:60-2:00,9-10,abc,03:00-04:00", want: []queueWindow{{Start: 180, End: 240}}, norm: "03:00-04:00"},
+	}
+	for _, tt := range tests {
+		if got := parseQueueWindow(tt.value); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseQueueWindow(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+		if got := normalizeQueueWindow(tt.value); got != tt.norm {
+			t.Errorf("normalizeQueueWindow(%q) = %q, want %q", tt.value, got, tt.norm)
+		}
+	}
+}
+
+func TestQueueWindowOpen(t *testing.T) {
+	day := func(hour, minute int) time.Time {
+		return time.Date(2024, 3, 1, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name     string
+		window   string
+		now      time.Time
+		wantOpen bool
+		wantNext time.Time
+	}{
+		{name: "未配置时始终允许", window: "", now: day(12, 0), wantOpen: true},
+		{name: "时间段内", window: "01:00-06:00", now: day(1, 0), wantOpen: true, wantNext: day(1, 0).AddDate(0, 0, 1)},
+		{name: "结束时间不含在内", window: "01:00-06:00", now: day(6, 0), wantNext: day(1, 0).AddDate(0, 0, 1)},
+		{name: "跨越午夜的前半段", window: "22:00-07:00", now: day(23, 30), wantOpen: true, wantNext: day(22, 0).AddDate(0, 0, 1)},
+		{name: "跨越午夜的后半段", window: "22:00-07:00", now: day(6, 59), wantOpen: true, wantNext: day(22, 0)},
+		{name: "多个时间段取最近的开始时间", window: "22:00-07:00,12:00-13:00", now: day(9, 0), wantNext: day(12, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			windows := parseQueueWindow(tt.window)
+			if got := queueWindowOpen(windows, tt.now); got != tt.wantOpen {
+				t.Errorf("queueWindowOpen() = %v, want %v", got, tt.wantOpen)
+			}
+			if got := nextQueueWindow(windows, tt.now); !got.Equal(tt.wantNext) {
+				t.Errorf("nextQueueWindow() = %v, want %v", got, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestExportQueueStore(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	added, err := store.EnqueueExports(ctx, "notion", []exportItem{{ID: "c1"}, {ID: "c2", Tags: []string{"周报"}}, {ID: "c3"}})
+	if err != nil || added != 3 {
+		t.Fatalf("EnqueueExports() = %d, %v", added, err)
+	}
+	// 等待中的对话只更新标题与标签。
+	added, err = store.EnqueueExports(ctx, "notion", []exportItem{{ID: "c1", CustomTitle: "新标题"}})
+	if err != nil || added != 0 {
+		t.Fatalf("重复加入 EnqueueExports() = %d, %v", added, err)
+	}
+
+	claimed, err := store.ClaimQueueEntries(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claimed) != 2 || claimed[0].ConversationID != "c1" || claimed[0].Title != "新标题" || !reflect.DeepEqual(claimed[1].Tags, []string{"周报"}) {
+		t.Fatalf("ClaimQueueEntries() = %+v", claimed)
+	}
+	if err := store.FinishQueueEntry(ctx, claimed[0].ID, queueStatusDone, "job-1", "https://notion.so/p1", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		run  func() error
+		want map[string]int
+	}{
+		{name: "领取后", run: func() error { return nil }, want: map[string]int{queueStatusDone: 1, queueStatusRunning: 1, queueStatusPending: 1}},
+		{name: "重启后恢复执行中的条目", run: func() error { _, err := store.ResetRunningQueueEntries(ctx); return err }, want: map[string]int{queueStatusDone: 1, queueStatusPending: 2}},
+		{
+			name: "只取消等待中的条目",
+			run: func() error {
+				n, err := store.CancelQueueEntries(ctx, []int64{claimed[0].ID, claimed[1].ID, 999})
+				if err == nil && n != 1 {
+					t.Errorf("取消 %d 条, want 1", n)
+				}
+				return err
+			},
+			want: map[string]int{queueStatusDone: 1, queueStatusPending: 1},
+		},
+		{name: "清理已结束的条目", run: func() error { return store.PruneQueueEntries(ctx, time.Now().Add(time.Minute)) }, want: map[string]int{queueStatusPending: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.run(); err != nil {
+				t.Fatal(err)
+			}
+			counts, err := store.CountQueueEntries(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(counts, tt.want) {
+				t.Errorf("CountQueueEntries() = %v, want %v", counts, tt.want)
+			}
+		})
+	}
+	entries, err := store.ListQueueEntries(ctx, queueStatusPending, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].ConversationID != "c3" {
+		t.Errorf("ListQueueEntries() = %+v", entries)
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// quickExportTokenTTL 是确认页中令牌的有效期, 过期后需重新打开链接。
+const quickExportTokenTTL = 10 * time.Minute
+
+// quickExportConversationID 从 cid 参数取对话 ID, 也接受完整的 ChatGPT 对话链接,
+// 书签脚本可以直接传入 location.href。
+func quickExportConversationID(value string) string {
+	value = strings.TrimSpace(value)
+	if idx := strings.LastIndex(value, "/c/"); idx >= 0 {
+		value = value[idx+len("/c/"):]
+		if end := strings.IndexAny(value, "/?#"); end >= 0 {
+			value = value[:end]
+		}
+	}
+	if strings.ContainsAny(value, "/?# ") {
+		return ""
+	}
+	return value
+}
+
+// handleQuickExport 处理 /export?cid=<对话 ID 或链接>&target=<目标>, 供书签脚本或快捷指令一键发送正在查看的对话。
+// GET 只返回确认页, 不触发导出; 确认页以 POST 提交带签名令牌的表单后才导出, 成功时跳转到目标中创建的页面,
+// 目标未返回链接时以 JSON 返回导出结果。其他网站无法读取确认页, 也就拿不到令牌, 不能借用登录状态触发导出。
+func (s *webServer) handleQuickExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeErrorDetail(w, http.StatusBadRequest, errCodeInvalidRequest, "解析请求失败", err)
+		return
+	}
+	id := quickExportConversationID(r.Form.Get("cid"))
+	if id == "" {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "缺少有效的对话 ID (cid)")
+		return
+	}
+	target := strings.TrimSpace(r.Form.Get("target"))
+	if r.Method == http.MethodGet {
+		s.renderQuickExportConfirm(w, id, target)
+		return
+	}
+	if !s.validQuickExportToken(r.PostForm.Get("token"), id, target, time.Now()) {
+		writeError(w, http.StatusForbidden, errCodeForbidden, "确认已失效或无效, 请重新打开导出链接")
+		return
+	}
+	s.runQuickExport(w, r, id, target)
+}
+
+// quickExportSecret 返回签名确认令牌的密钥, 每次启动随机生成, 重启后旧的确认页失效。
+func (s *webServer) quickExportSecret() []byte {
+	s.quickExportKeyOnce.Do(func() {
+		s.quickExportKey = make([]byte, 32)
+		if _, err := rand.Read(s.quickExportKey); err != nil {
+			panic(fmt.Sprintf("生成快速导出密钥失败: %v", err))
+		}
+	})
+	return s.quickExportKey
+}
+
+// quickExportToken 为对话与目标生成确认令牌, 格式为 "签发时间.签名"。
+func (s *webServer) quickExportToken(id, target string, issued time.Time) string {
+	ts := strconv.FormatInt(issued.Unix(), 10)
+	return ts + "." + s.quickExportSignature(id, target, ts)
+}
+
+func (s *webServer) quickExportSignature(id, target, ts string) string {
+	mac := hmac.New(sha256.New, s.quickExportSecret())
+	mac.Write([]byte(id + "\n" + target + "\n" + ts))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// validQuickExportToken 校验令牌的签名与有效期, 令牌只对签发时的对话与目标有效。
+func (s *webServer) validQuickExportToken(token, id, target string, now time.Time) bool {
+	ts, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return false
+	}
+	age := now.Sub(time.Unix(unix, 0))
+	if age < -time.Minute || age > quickExportTokenTTL {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(s.quickExportSignature(id, target, ts)))
+}
+
+var quickExportConfirmTemplate = template.Must(template.New("quick-export").Parse(`<!doctype html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>确认导出对话</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", "PingFang SC", sans-serif; margin: 0 auto; max-width: 480px; padding: 24px 16px; color: #1f2328; }
+h1 { font-size: 20px; }
+code { font-size: 13px; word-break: break-all; }
+button { font-size: 16px; padding: 8px 20px; }
+</style>
+</head>
+<body>
+<h1>确认导出对话</h1>
+<p>对话: <code>{{.ID}}</code></p>
+<p>目标: {{if .Target}}<code>{{.Target}}</code>{{else}}默认导出目标{{end}}</p>
+<form method="post" action="/export">
+<input type="hidden" name="cid" value="{{.ID}}">
+<input type="hidden" name="target" value="{{.Target}}">
+<input type="hidden" name="token" value="{{.Token}}">
+<button type="submit" autofocus>导出</button>
+</form>
+</body>
+</html>
+`))
+
+// renderQuickExportConfirm 输出确认页, 表单以 POST 提交到 /export。页面禁止被嵌入框架, 防止其他网站诱导点击。
+func (s *webServer) renderQuickExportConfirm(w http.ResponseWriter, id, target string) {
+	var buf bytes.Buffer
+	data := map[string]string{"ID": id, "Target": target, "Token": s.quickExportToken(id, target, time.Now())}
+	if err := quickExportConfirmTemplate.Execute(&buf, data); err != nil {
+		writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "生成确认页失败", err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Content-Security-Policy", "frame-ancestors 'none'")
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		logInfo("输出确认页失败: %v", err)
+	}
+}
+
+// runQuickExport 导出单个对话并跳转到创建的页面, target 为空时使用默认目标。调用方需先确认请求来自确认页。
+func (s *webServer) runQuickExport(w http.ResponseWriter, r *http.Request, id, target string) {
+	ctx := r.Context()
+	cfg := s.configSnapshot()
+	target = strings.TrimSpace(target)
+	if target == "" {
+		target = cfg.ExportTarget
+	}
+	target = normalizeExportTarget(target)
+	exporter, targetLabel, err := s.resolveExporter(target)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errCodeTargetMisconfigured, err.Error())
+		return
+	}
+
+	logInfo("快速导出触发: conversation=%s 目标=%s", id, target)
+	job := s.jobs.start("quick_export", target)
+	result, syncErr := s.syncConversations(ctx, job, target, targetLabel, exporter, s.fetchExportConversation, []exportItem{{ID: id}}, cfg.OutputTimezone)
+	s.recordSyncResult(target, result)
+	job.recordSync(target, result)
+	s.jobs.finish(job, syncErr, s.locationSnapshot())
+	if len(result.Failed) > 0 {
+		if first := result.Failed[0]; first.fetch {
+			writeAPIError(w, chatgptError(fmt.Sprintf("获取对话 %s 详情失败", first.ConversationID), first.err))
+		} else {
+			writeAPIError(w, targetError(fmt.Sprintf("导出到 %s 失败", targetLabel), first.err))
+		}
+		return
+	}
+	if len(result.Unavailable) > 0 {
+		first := result.Unavailable[0]
+		writeError(w, unavailableStatus(first), first.Code, first.Reason)
+		return
+	}
+	if len(result.Exported) == 0 {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "该对话没有可导出的消息")
+		return
+	}
+	exported := result.Exported[0]
+	if exported.URL != "" {
+		http.Redirect(w, r, exported.URL, http.StatusSeeOther)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"target": target,
+		"job_id": job.ID,
+		"result": exported,
+	})
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQuickExportRequiresConfirmation(t *testing.T) {
+	s := &webServer{cfg: &cliConfig{}, location: time.UTC, jobs: newJobManager(t.TempDir(), nil)}
+	valid := s.quickExportToken("c1", "notion", time.Now())
+	form := func(cid, target, token string) string {
+		return url.Values{"cid": {cid}, "target": {target}, "token": {token}}.Encode()
+	}
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{name: "GET 只返回确认页", method: http.MethodGet, path: "/export?cid=c1&target=notion", status: http.StatusOK},
+		{name: "分享链接返回确认页", method: http.MethodGet, path: "/share?text=" + url.QueryEscape("看看 https://chatgpt.com/c/c1"), status: http.StatusOK},
+		{name: "其他网站提交的分享表单只返回确认页", method: http.MethodPost, path: "/share", body: "url=" + url.QueryEscape("https://chatgpt.com/c/c1"), status: http.StatusOK},
+		{name: "缺少令牌", method: http.MethodPost, path: "/export", body: form("c1", "notion", ""), status: http.StatusForbidden},
+		{name: "令牌对应其他对话", method: http.MethodPost, path: "/export", body: form("c2", "notion", valid), status: http.StatusForbidden},
+		{name: "令牌对应其他目标", method: http.MethodPost, path: "/export", body: form("c1", "markdown", valid), status: http.StatusForbidden},
+		{name: "令牌已过期", method: http.MethodPost, path: "/export", body: form("c1", "notion", s.quickExportToken("c1", "notion", time.Now().Add(-quickExportTokenTTL-time.Minute))), status: http.StatusForbidden},
+		// 令牌有效时进入导出, 测试环境未配置 Notion, 返回目标配置错误。
+		{name: "令牌有效", method: http.MethodPost, path: "/export", body: form("c1", "notion", valid), status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			rec := httptest.NewRecorder()
+			s.routes().ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status == http.StatusOK {
+				if !strings.Contains(rec.Body.String(), `name="token"`) || rec.Header().Get("X-Frame-Options") != "DENY" {
+					t.Errorf("确认页缺少令牌或允许嵌入框架: %s", rec.Body.String())
+				}
+			}
+			if tt.status == http.StatusBadRequest && !strings.Contains(rec.Body.String(), errCodeTargetMisconfigured) {
+				t.Errorf("令牌有效时应进入导出: %s", rec.Body.String())
+			}
+		})
+	}
+}
@@ -7,6 +7,6 @@ REPO_ROOT="$(cd "${SCRIPT_DIR}/.." && pwd)"
 cd "${REPO_ROOT}"
 
 mkdir -p bin
-go build -o bin/openai-backup ./...
+go build -ldflags "-X main.version=${VERSION:-dev}" -o bin/openai-backup ./...
 
 echo "Go 后端已编译到 ${REPO_ROOT}/bin/openai-backup"
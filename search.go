@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Devoty/openai-backup/export"
+)
+
+const (
+	// searchContextRunes 是搜索摘录中匹配前后各保留的字符数。
+	searchContextRunes = 40
+	// searchSnippetsPerMessage 是每条消息最多返回的摘录数。
+	searchSnippetsPerMessage = 3
+	// defaultSearchLimit 与 maxSearchLimit 是单次搜索返回的消息数默认值与上限。
+	defaultSearchLimit = 50
+	maxSearchLimit     = 500
+)
+
+// conversationSearchMatch 是搜索结果中的一条消息, Index 与详情接口 messages 的下标一致。
+type conversationSearchMatch struct {
+	export.MessageMatch
+	Timestamp string `json:"timestamp"`
+}
+
+// handleConversationSearch 处理 GET /api/conversations/{id}/search?q=: 在对话详情 (优先使用缓存与本地归档)
+// 中查找关键词, 返回匹配的消息下标与摘录, 界面可据此跳转到长对话中的相关位置。
+func (s *webServer) handleConversationSearch(w http.ResponseWriter, r *http.Request, id string) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "缺少搜索关键词 q")
+		return
+	}
+	limit := defaultSearchLimit
+	if raw := strings.TrimSpace(r.URL.Query().Get("limit")); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value <= 0 {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "limit 必须为正整数")
+			return
+		}
+		limit = min(value, maxSearchLimit)
+	}
+
+	conv, err := s.loadExportConversation(r.Context(), id, false)
+	if err != nil {
+		writeAPIError(w, chatgptError("获取对话详情失败", err))
+		return
+	}
+	matches := export.SearchMessages(conv, query, searchContextRunes, searchSnippetsPerMessage)
+	total := 0
+	for _, match := range matches {
+		total += match.Count
+	}
+	results := make([]conversationSearchMatch, 0, min(len(matches), limit))
+	for _, match := range matches {
+		if len(results) == limit {
+			break
+		}
+		results = append(results, conversationSearchMatch{
+			MessageMatch: match,
+			Timestamp:    s.formatMessageTimestamp(conv.Messages[match.Index]),
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"conversation_id": conv.ID,
+		"query":           query,
+		"messages":        len(conv.Messages),
+		"matched":         len(matches),
+		"total":           total,
+		"truncated":       len(matches) > len(results),
+		"results":         results,
+	})
+}
@@ -2,18 +2,39 @@ package main
 
 import (
 	"archive/zip"
-	"bytes"
 	"context"
 	"embed"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/Devoty/openai-backup/client"
+	"github.com/Devoty/openai-backup/diskspace"
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/targets"
+	"github.com/Devoty/openai-backup/targets/airtable"
+	"github.com/Devoty/openai-backup/targets/anytype"
+	"github.com/Devoty/openai-backup/targets/command"
+	"github.com/Devoty/openai-backup/targets/gdrive"
+	"github.com/Devoty/openai-backup/targets/markdown"
+	"github.com/Devoty/openai-backup/targets/memos"
+	"github.com/Devoty/openai-backup/targets/notion"
+	"github.com/Devoty/openai-backup/targets/onenote"
+	"github.com/Devoty/openai-backup/targets/rawjson"
+	"github.com/Devoty/openai-backup/targets/readwise"
+	"github.com/Devoty/openai-backup/targets/telegram"
+	"github.com/Devoty/openai-backup/targets/trilium"
+	"github.com/Devoty/openai-backup/targets/webhook"
 )
 
 const (
@@ -22,12 +43,12 @@ const (
 )
 
 type detailCacheEntry struct {
-	export  exportConversation
+	conv    export.Conversation
 	fetched time.Time
 }
 
 type conversationPageCacheEntry struct {
-	data    *conversationListResponse
+	data    *client.ConversationPage
 	fetched time.Time
 }
 
@@ -36,12 +57,12 @@ type convPageKey struct {
 	limit  int
 }
 
-func cloneConversationPage(src *conversationListResponse) *conversationListResponse {
+func cloneConversationPage(src *client.ConversationPage) *client.ConversationPage {
 	if src == nil {
 		return nil
 	}
 	copy := *src
-	copy.Items = append([]conversationMeta(nil), src.Items...)
+	copy.Items = append([]client.ConversationMeta(nil), src.Items...)
 	return &copy
 }
 
@@ -49,6 +70,8 @@ type webServer struct {
 	cfg      *cliConfig
 	location *time.Location
 	store    *ConfigStore
+	// dist 是前端页面的文件系统, 默认为内置的 web/dist, 可由 --web-dist 替换。
+	dist fs.FS
 
 	configMu sync.RWMutex
 
@@ -58,11 +81,61 @@ type webServer struct {
 	detailMu    sync.RWMutex
 	detailCache map[string]detailCacheEntry
 
+	previewMu    sync.RWMutex
+	previewCache map[string]previewCacheEntry
+
 	anyClientMu sync.Mutex
-	anyClient   *anytypeClient
+	anyClient   *anytype.Client
 
 	notionClientMu sync.Mutex
-	notionClient   *notionClient
+	notionClient   *notion.Client
+
+	gdriveClientMu sync.Mutex
+	gdriveClient   *gdrive.Client
+	googleAuth     googleDeviceAuth
+
+	onenoteClientMu sync.Mutex
+	onenoteClient   *onenote.Client
+
+	// svgCache 缓存公式与图表的渲染结果, 见 svgrender.go。
+	svgCache svgCache
+
+	breakerMu sync.Mutex
+	breakers  map[string]*circuitBreaker
+
+	// crawlMu 保证同一时间只有一个完整列表抓取在写进度。
+	crawlMu sync.Mutex
+
+	// archiveConflicts 暂存最近一次拉取时本地归档与接口内容的合并结果, 由导出任务取出写入报告。
+	conflictMu       sync.Mutex
+	archiveConflicts map[string]archiveConflict
+	// schemaDrifts 暂存按宽松模式解析的对话详情记录, 与 archiveConflicts 共用 conflictMu。
+	schemaDrifts map[string]schemaDrift
+
+	// projectNames 缓存 ChatGPT 项目 ID 对应的名称, projectMappings 缓存项目映射表 (nil 表示需要重新读取)。
+	projectMu       sync.Mutex
+	projectNames    map[string]string
+	projectMappings map[string]projectMapping
+
+	// quickExportKey 签名快速导出确认页中的令牌, 见 quickExportSecret。
+	quickExportKeyOnce sync.Once
+	quickExportKey     []byte
+
+	// hookBackupRunning 保证同一时间只有一个 webhook 触发的备份任务在执行。
+	hookBackupRunning atomic.Bool
+
+	// queueWake 通知导出队列有新提交, 见 runExportQueue。
+	queueWake chan struct{}
+
+	// chatgptProbe 缓存 /api/status 的 ChatGPT 鉴权检查结果, 见 chatgptAuthStatus。
+	statusMu     sync.Mutex
+	chatgptProbe *chatgptStatus
+
+	// updateCheck 缓存新版本检查结果, updateMu 在检查期间持有, 见 checkForUpdate。
+	updateMu    sync.Mutex
+	updateCheck *updateStatus
+
+	jobs *jobManager
 }
 
 type ConfigPayload struct {
@@ -104,6 +177,97 @@ type ConfigPayload struct {
 	NotionParentType    string `json:"notion_parent_type"`
 	NotionParentID      string `json:"notion_parent_id"`
 	NotionTitleProperty string `json:"notion_title_property"`
+	HookAPIKey          string `json:"hook_api_key"`
+	HookLimit           int    `json:"hook_limit"`
+	HTMLTheme           string `json:"html_theme"`
+	HTMLCustomCSS       string `json:"html_custom_css"`
+	IncludeContext      bool   `json:"include_context"`
+	DownloadAudio       bool   `json:"download_audio"`
+	DownloadAttachments bool   `json:"download_attachments"`
+	MathMode            string `json:"math_mode"`
+	RenderDiagrams      bool   `json:"render_diagrams"`
+	WebhookURL          string `json:"webhook_url"`
+	WebhookHeaders      string `json:"webhook_headers"`
+	WebhookTemplate     string `json:"webhook_template"`
+	WebhookIDPath       string `json:"webhook_id_path"`
+	WebhookURLPath      string `json:"webhook_url_path"`
+	AirtableBaseURL     string `json:"airtable_base_url"`
+	AirtableToken       string `json:"airtable_token"`
+	AirtableBaseID      string `json:"airtable_base_id"`
+	AirtableTable       string `json:"airtable_table"`
+	AirtableFields      string `json:"airtable_fields"`
+	AirtableBodyMode    string `json:"airtable_body_mode"`
+	GoogleClientID      string `json:"google_client_id"`
+	GoogleClientSecret  string `json:"google_client_secret"`
+	GoogleRefreshToken  string `json:"google_refresh_token"`
+	GoogleFolderID      string `json:"google_folder_id"`
+	GoogleDriveMode     string `json:"google_drive_mode"`
+	TelegramBaseURL     string `json:"telegram_base_url"`
+	TelegramBotToken    string `json:"telegram_bot_token"`
+	TelegramChatID      string `json:"telegram_chat_id"`
+	TelegramMode        string `json:"telegram_mode"`
+	ReadwiseBaseURL     string `json:"readwise_base_url"`
+	ReadwiseToken       string `json:"readwise_token"`
+	ReadwiseTags        string `json:"readwise_tags"`
+	MemosBaseURL        string `json:"memos_base_url"`
+	MemosToken          string `json:"memos_token"`
+	MemosVisibility     string `json:"memos_visibility"`
+	MemosTags           string `json:"memos_tags"`
+	TriliumBaseURL      string `json:"trilium_base_url"`
+	TriliumToken        string `json:"trilium_token"`
+	TriliumParentNoteID string `json:"trilium_parent_note_id"`
+	SpillThresholdMB    int    `json:"spill_threshold_mb"`
+	ArchiveMerge        string `json:"archive_merge"`
+	AnnotateChanges     bool   `json:"annotate_changes"`
+	TitleFallback       string `json:"title_fallback"`
+	TargetTitleFallback string `json:"target_title_fallback"`
+	UnicodeNormalize    bool   `json:"unicode_normalize"`
+	FilenameStripEmoji  bool   `json:"filename_strip_emoji"`
+	NotionChunkMode     string `json:"notion_chunk_mode"`
+	ServerUsers         string `json:"server_users"`
+	IPAllowlist         string `json:"ip_allowlist"`
+	MessageLayout       string `json:"message_layout"`
+	ExportMode          string `json:"export_mode"`
+	ExportStats         bool   `json:"export_stats"`
+	TimeFormat          string `json:"time_format"`
+	RelativeTimes       bool   `json:"relative_times"`
+	SecondTimezone      string `json:"second_timezone"`
+	FileHierarchy       string `json:"file_hierarchy"`
+	NotionDraftParentID string `json:"notion_draft_parent_id"`
+	NotionDraftType     string `json:"notion_draft_type"`
+	ProjectTags         bool   `json:"project_tags"`
+	NotionProjectField  string `json:"notion_project_field"`
+	TitleTemplate       string `json:"title_template"`
+	TargetTitleTemplate string `json:"target_title_template"`
+	QueueWindow         string `json:"queue_window"`
+	UARotation          string `json:"ua_rotation"`
+	UARotationBatch     int    `json:"ua_rotation_batch"`
+	UpdateCheck         bool   `json:"update_check"`
+	NotionURLField      string `json:"notion_url_field"`
+	HTMLQRCode          string `json:"html_qr_code"`
+	MaxMessageChars     int    `json:"max_message_chars"`
+	TruncateKeepFull    bool   `json:"truncate_keep_full"`
+	DeleteIntervalMs    int    `json:"delete_interval_ms"`
+	DeleteGraceMinutes  int    `json:"delete_grace_minutes"`
+	ConfigLocks         string `json:"config_locks"`
+	OutputPath          string `json:"output_path"`
+	RawOutputPath       string `json:"raw_output_path"`
+	RawFormat           string `json:"raw_format"`
+	NotionUploadAudio   bool   `json:"notion_upload_audio"`
+	NotionUploadFiles   bool   `json:"notion_upload_files"`
+	AnytypeUploadFiles  bool   `json:"anytype_upload_files"`
+	AnytypeFilesField   string `json:"anytype_files_property"`
+	TOCMinMessages      int    `json:"toc_min_messages"`
+	OneNoteTenant       string `json:"onenote_tenant"`
+	OneNoteClientID     string `json:"onenote_client_id"`
+	OneNoteClientSecret string `json:"onenote_client_secret"`
+	OneNoteRefreshToken string `json:"onenote_refresh_token"`
+	OneNoteToken        string `json:"onenote_token"`
+	OneNoteNotebook     string `json:"onenote_notebook"`
+	OneNoteSection      string `json:"onenote_section"`
+	OneNoteBaseURL      string `json:"onenote_base_url"`
+	MathRenderURL       string `json:"math_render_url"`
+	DiagramRenderURL    string `json:"diagram_render_url"`
 }
 
 type configUpdate struct {
@@ -145,6 +309,96 @@ type configUpdate struct {
 	NotionParentType    *string `json:"notion_parent_type"`
 	NotionParentID      *string `json:"notion_parent_id"`
 	NotionTitleProperty *string `json:"notion_title_property"`
+	HookAPIKey          *string `json:"hook_api_key"`
+	HookLimit           *int    `json:"hook_limit"`
+	HTMLTheme           *string `json:"html_theme"`
+	HTMLCustomCSS       *string `json:"html_custom_css"`
+	IncludeContext      *bool   `json:"include_context"`
+	DownloadAudio       *bool   `json:"download_audio"`
+	DownloadAttachments *bool   `json:"download_attachments"`
+	MathMode            *string `json:"math_mode"`
+	RenderDiagrams      *bool   `json:"render_diagrams"`
+	WebhookURL          *string `json:"webhook_url"`
+	WebhookHeaders      *string `json:"webhook_headers"`
+	WebhookTemplate     *string `json:"webhook_template"`
+	WebhookIDPath       *string `json:"webhook_id_path"`
+	WebhookURLPath      *string `json:"webhook_url_path"`
+	AirtableBaseURL     *string `json:"airtable_base_url"`
+	AirtableToken       *string `json:"airtable_token"`
+	AirtableBaseID      *string `json:"airtable_base_id"`
+	AirtableTable       *string `json:"airtable_table"`
+	AirtableFields      *string `json:"airtable_fields"`
+	AirtableBodyMode    *string `json:"airtable_body_mode"`
+	GoogleClientID      *string `json:"google_client_id"`
+	GoogleClientSecret  *string `json:"google_client_secret"`
+	GoogleRefreshToken  *string `json:"google_refresh_token"`
+	GoogleFolderID      *string `json:"google_folder_id"`
+	GoogleDriveMode     *string `json:"google_drive_mode"`
+	TelegramBaseURL     *string `json:"telegram_base_url"`
+	TelegramBotToken    *string `json:"telegram_bot_token"`
+	TelegramChatID      *string `json:"telegram_chat_id"`
+	TelegramMode        *string `json:"telegram_mode"`
+	ReadwiseBaseURL     *string `json:"readwise_base_url"`
+	ReadwiseToken       *string `json:"readwise_token"`
+	ReadwiseTags        *string `json:"readwise_tags"`
+	MemosBaseURL        *string `json:"memos_base_url"`
+	MemosToken          *string `json:"memos_token"`
+	MemosVisibility     *string `json:"memos_visibility"`
+	MemosTags           *string `json:"memos_tags"`
+	TriliumBaseURL      *string `json:"trilium_base_url"`
+	TriliumToken        *string `json:"trilium_token"`
+	TriliumParentNoteID *string `json:"trilium_parent_note_id"`
+	SpillThresholdMB    *int    `json:"spill_threshold_mb"`
+	ArchiveMerge        *string `json:"archive_merge"`
+	AnnotateChanges     *bool   `json:"annotate_changes"`
+	TitleFallback       *string `json:"title_fallback"`
+	TargetTitleFallback *string `json:"target_title_fallback"`
+	UnicodeNormalize    *bool   `json:"unicode_normalize"`
+	FilenameStripEmoji  *bool   `json:"filename_strip_emoji"`
+	NotionChunkMode     *string `json:"notion_chunk_mode"`
+	ServerUsers         *string `json:"server_users"`
+	IPAllowlist         *string `json:"ip_allowlist"`
+	MessageLayout       *string `json:"message_layout"`
+	ExportMode          *string `json:"export_mode"`
+	ExportStats         *bool   `json:"export_stats"`
+	TimeFormat          *string `json:"time_format"`
+	RelativeTimes       *bool   `json:"relative_times"`
+	SecondTimezone      *string `json:"second_timezone"`
+	FileHierarchy       *string `json:"file_hierarchy"`
+	NotionDraftParentID *string `json:"notion_draft_parent_id"`
+	NotionDraftType     *string `json:"notion_draft_type"`
+	ProjectTags         *bool   `json:"project_tags"`
+	NotionProjectField  *string `json:"notion_project_field"`
+	TitleTemplate       *string `json:"title_template"`
+	TargetTitleTemplate *string `json:"target_title_template"`
+	QueueWindow         *string `json:"queue_window"`
+	UARotation          *string `json:"ua_rotation"`
+	UARotationBatch     *int    `json:"ua_rotation_batch"`
+	UpdateCheck         *bool   `json:"update_check"`
+	NotionURLField      *string `json:"notion_url_field"`
+	HTMLQRCode          *string `json:"html_qr_code"`
+	MaxMessageChars     *int    `json:"max_message_chars"`
+	TruncateKeepFull    *bool   `json:"truncate_keep_full"`
+	DeleteIntervalMs    *int    `json:"delete_interval_ms"`
+	DeleteGraceMinutes  *int    `json:"delete_grace_minutes"`
+	OutputPath          *string `json:"output_path"`
+	RawOutputPath       *string `json:"raw_output_path"`
+	RawFormat           *string `json:"raw_format"`
+	NotionUploadAudio   *bool   `json:"notion_upload_audio"`
+	NotionUploadFiles   *bool   `json:"notion_upload_files"`
+	AnytypeUploadFiles  *bool   `json:"anytype_upload_files"`
+	AnytypeFilesField   *string `json:"anytype_files_property"`
+	TOCMinMessages      *int    `json:"toc_min_messages"`
+	OneNoteTenant       *string `json:"onenote_tenant"`
+	OneNoteClientID     *string `json:"onenote_client_id"`
+	OneNoteClientSecret *string `json:"onenote_client_secret"`
+	OneNoteRefreshToken *string `json:"onenote_refresh_token"`
+	OneNoteToken        *string `json:"onenote_token"`
+	OneNoteNotebook     *string `json:"onenote_notebook"`
+	OneNoteSection      *string `json:"onenote_section"`
+	OneNoteBaseURL      *string `json:"onenote_base_url"`
+	MathRenderURL       *string `json:"math_render_url"`
+	DiagramRenderURL    *string `json:"diagram_render_url"`
 }
 
 //go:embed web/dist/*
@@ -160,6 +414,27 @@ func init() {
 	}
 }
 
+// loadWebDist 返回前端页面所在的文件系统: dir 为空时使用内置的 web/dist, 否则使用该目录,
+// 便于在不更换后端的情况下运行自定义或更新的前端构建。
+func loadWebDist(dir string) (fs.FS, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return distFS, nil
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取前端目录失败: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("前端目录不是目录: %s", dir)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "index.html")); err != nil {
+		return nil, fmt.Errorf("前端目录缺少 index.html: %s", dir)
+	}
+	logInfo("使用外部前端目录: %s", dir)
+	return os.DirFS(dir), nil
+}
+
 func runWebServer(ctx context.Context, cfg *cliConfig) error {
 	app, err := newWebServer(cfg)
 	if err != nil {
@@ -170,15 +445,32 @@ func runWebServer(ctx context.Context, cfg *cliConfig) error {
 			logInfo("关闭配置存储失败: %v", cerr)
 		}
 	}()
+	if err := checkListenSecurity(app.cfg); err != nil {
+		return err
+	}
+	certFile, keyFile := strings.TrimSpace(app.cfg.TLSCert), strings.TrimSpace(app.cfg.TLSKey)
+	if (certFile == "") != (keyFile == "") {
+		return errors.New("--tls-cert 与 --tls-key 需要同时指定")
+	}
 	server := &http.Server{
 		Addr:    app.cfg.ServeAddr,
 		Handler: app.routes(),
 	}
 
+	app.resumePendingDeletes(ctx)
+	go app.runExportQueue(ctx)
+
 	errCh := make(chan error, 1)
 	go func() {
-		logInfo("Web 界面已启动, 访问地址: http://%s", app.cfg.ServeAddr)
-		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		var err error
+		if certFile != "" {
+			logInfo("Web 界面已启动, 访问地址: https://%s", app.cfg.ServeAddr)
+			err = server.ListenAndServeTLS(certFile, keyFile)
+		} else {
+			logInfo("Web 界面已启动, 访问地址: http://%s", app.cfg.ServeAddr)
+			err = server.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			errCh <- err
 		}
 	}()
@@ -211,23 +503,41 @@ func newWebServer(cfg *cliConfig) (*webServer, error) {
 	if strings.TrimSpace(cfgCopy.UserAgent) == "" {
 		cfgCopy.UserAgent = defaultUserAgent
 	}
-	loc := resolveLocation(cfgCopy.OutputTimezone)
+	loc := export.ResolveLocation(cfgCopy.OutputTimezone)
+
+	dist, err := loadWebDist(cfgCopy.WebDist)
+	if err != nil {
+		return nil, err
+	}
 
 	store, err := Init(cfgCopy.ConfigDBPath)
 	if err != nil {
 		return nil, fmt.Errorf("初始化配置存储失败: %w", err)
 	}
+	if _, err := store.RecoverFileWrites(ctx); err != nil {
+		logInfo("清理未完成的文件写入失败: %v", err)
+	}
 
 	app := &webServer{
-		cfg:         &cfgCopy,
-		location:    loc,
-		store:       store,
-		pageCache:   make(map[convPageKey]conversationPageCacheEntry),
-		detailCache: make(map[string]detailCacheEntry),
+		cfg:          &cfgCopy,
+		location:     loc,
+		store:        store,
+		dist:         dist,
+		pageCache:    make(map[convPageKey]conversationPageCacheEntry),
+		detailCache:  make(map[string]detailCacheEntry),
+		previewCache: make(map[string]previewCacheEntry),
+		breakers:     make(map[string]*circuitBreaker),
+		projectNames: make(map[string]string),
+		queueWake:    make(chan struct{}, 1),
+		jobs:         newJobManager(filepath.Join(filepath.Dir(cfgCopy.ConfigDBPath), "reports"), store),
 	}
 
 	if payload, err := store.LoadConfig(ctx); err == nil {
 		applyConfigPayload(app.cfg, payload)
+		if cfgCopy.Demo {
+			// 演示模式的模拟接口端口每次启动都不同, 不使用持久化的地址与 Token。
+			app.cfg.BaseURL, app.cfg.Token = cfg.BaseURL, cfg.Token
+		}
 	} else if !errors.Is(err, errConfigNotFound) {
 		store.Close()
 		return nil, fmt.Errorf("加载持久化配置失败: %w", err)
@@ -238,7 +548,7 @@ func newWebServer(cfg *cliConfig) (*webServer, error) {
 
 func (s *webServer) routes() http.Handler {
 	mux := http.NewServeMux()
-	staticServer := http.FileServer(http.FS(distFS))
+	staticServer := http.FileServer(http.FS(s.dist))
 	mux.Handle("/assets/", staticServer)
 	mux.Handle("/favicon.ico", staticServer)
 	mux.HandleFunc("/api/config/export", s.handleConfigExport)
@@ -247,10 +557,40 @@ func (s *webServer) routes() http.Handler {
 	mux.HandleFunc("/api/conversations", s.handleConversationList)
 	mux.HandleFunc("/api/conversations/export", s.handleConversationExport)
 	mux.HandleFunc("/api/conversations/delete", s.handleDelete)
+	mux.HandleFunc("/api/conversations/merge", s.handleConversationMerge)
 	mux.HandleFunc("/api/conversations/", s.handleConversationDetail)
 	mux.HandleFunc("/api/import", s.handleImport)
+	mux.HandleFunc("/api/import/test", s.handleTestExport)
+	mux.HandleFunc("/api/queue", s.handleQueue)
+	mux.HandleFunc("/api/queue/cancel", s.handleQueueCancel)
+	mux.HandleFunc("/api/targets/status", s.handleTargetStatus)
+	mux.HandleFunc("/api/stats/targets", s.handleTargetStats)
+	mux.HandleFunc("/api/status", s.handleStatus)
+	mux.HandleFunc("/api/version", s.handleVersion)
+	mux.HandleFunc("/api/notion/drafts", s.handleNotionDrafts)
+	mux.HandleFunc("/api/notion/promote", s.handleNotionPromote)
+	mux.HandleFunc("/api/projects/mappings", s.handleProjectMappings)
+	mux.HandleFunc("/api/projects/mappings/delete", s.handleProjectMappingDelete)
+	mux.HandleFunc("/api/failures", s.handleFailures)
+	mux.HandleFunc("/api/failures/retry", s.handleFailureRetry)
+	mux.HandleFunc("/api/jobs/", s.handleJobs)
+	mux.HandleFunc("/api/batch", s.handleBatch)
+	mux.HandleFunc("/api/hooks/run-backup", s.handleHookRunBackup)
+	mux.HandleFunc("/api/debug/skipped", s.handleSkippedMessages)
+	mux.HandleFunc("/api/debug/content-types", s.handleContentStats)
+	mux.HandleFunc("/api/playground", s.handlePlayground)
+	mux.HandleFunc("/api/admin/db", s.handleAdminDB)
+	mux.HandleFunc("/api/admin/config-locks", s.handleConfigLocks)
+	mux.HandleFunc("/api/takeout", s.handleTakeout)
+	mux.HandleFunc("/api/google/device", s.handleGoogleDeviceAuth)
+	mux.HandleFunc("/api/tokens", s.handleTokens)
+	mux.HandleFunc("/api/tokens/revoke", s.handleTokenRevoke)
+	mux.HandleFunc("/feed.xml", s.handleFeed)
+	mux.HandleFunc("/export", s.handleQuickExport)
+	mux.HandleFunc("/share", s.handleShare)
+	mux.HandleFunc("/manifest.webmanifest", s.handleManifest)
 	mux.HandleFunc("/", s.serveIndex)
-	return mux
+	return s.withIPAllowlist(s.withAuth(mux))
 }
 
 func (s *webServer) Close() error {
@@ -272,32 +612,46 @@ func (s *webServer) handleConfig(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusOK, payload)
 	case http.MethodPost:
 		defer r.Body.Close()
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeErrorDetail(w, http.StatusBadRequest, errCodeInvalidRequest, "读取请求体失败", err)
+			return
+		}
 		var input configUpdate
-		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-			writeError(w, http.StatusBadRequest, fmt.Sprintf("解析配置失败: %v", err))
+		if err := json.Unmarshal(body, &input); err != nil {
+			writeErrorDetail(w, http.StatusBadRequest, errCodeInvalidRequest, "解析配置失败", err)
+			return
+		}
+		locked, err := s.lockedConfigChanges(body)
+		if err != nil {
+			writeErrorDetail(w, http.StatusBadRequest, errCodeInvalidRequest, "解析配置失败", err)
+			return
+		}
+		if len(locked) > 0 {
+			writeError(w, http.StatusForbidden, errCodeConfigLocked, fmt.Sprintf("以下配置项已锁定, 不能修改: %s", strings.Join(locked, ", ")))
 			return
 		}
 		payload, err := s.updateConfig(input)
 		if err != nil {
-			writeError(w, http.StatusBadRequest, err.Error())
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
 			return
 		}
 		writeJSON(w, http.StatusOK, payload)
 	default:
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
 	}
 }
 
 func (s *webServer) handleConfigExport(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
 		return
 	}
 	filename := fmt.Sprintf("openai-backup-config-%s.json", time.Now().Format("20060102-150405"))
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
 	payload, err := s.prepareConfigExport()
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, fmt.Sprintf("导出配置失败: %v", err))
+		writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "导出配置失败", err)
 		return
 	}
 	writeJSON(w, http.StatusOK, payload)
@@ -305,16 +659,23 @@ func (s *webServer) handleConfigExport(w http.ResponseWriter, r *http.Request) {
 
 func (s *webServer) handleConfigImport(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
 		return
 	}
 	defer r.Body.Close()
 	var payload ConfigPayload
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		writeError(w, http.StatusBadRequest, fmt.Sprintf("解析配置失败: %v", err))
+		writeErrorDetail(w, http.StatusBadRequest, errCodeInvalidRequest, "解析配置失败", err)
 		return
 	}
 	normalized := normalizeConfigImportPayload(payload)
+	if locked := s.keepLockedConfig(&normalized); len(locked) > 0 {
+		logInfo("导入配置时保留已锁定的配置项: %s", strings.Join(locked, ", "))
+	}
+	if _, err := export.LoadSecondLocation(normalized.SecondTimezone); err != nil {
+		writeErrorDetail(w, http.StatusBadRequest, errCodeInvalidRequest, "second_timezone 无效", err)
+		return
+	}
 	response := s.replaceConfig(normalized)
 	writeJSON(w, http.StatusOK, response)
 }
@@ -353,6 +714,97 @@ func configToPayload(cfg *cliConfig) ConfigPayload {
 		NotionParentType:    sanitizeNotionParentType(cfg.NotionParentType),
 		NotionParentID:      strings.TrimSpace(cfg.NotionParentID),
 		NotionTitleProperty: strings.TrimSpace(cfg.NotionTitleProperty),
+		HookAPIKey:          strings.TrimSpace(cfg.HookAPIKey),
+		HookLimit:           nonNegative(cfg.HookLimit),
+		HTMLTheme:           export.NormalizeHTMLTheme(cfg.HTMLTheme),
+		HTMLCustomCSS:       strings.TrimSpace(cfg.HTMLCustomCSS),
+		IncludeContext:      cfg.IncludeContext,
+		DownloadAudio:       cfg.DownloadAudio,
+		DownloadAttachments: cfg.DownloadAttachments,
+		MathMode:            export.NormalizeMathMode(cfg.MathMode),
+		RenderDiagrams:      cfg.RenderDiagrams,
+		WebhookURL:          strings.TrimSpace(cfg.WebhookURL),
+		WebhookHeaders:      strings.TrimSpace(cfg.WebhookHeaders),
+		WebhookTemplate:     strings.TrimSpace(cfg.WebhookTemplate),
+		WebhookIDPath:       strings.TrimSpace(cfg.WebhookIDPath),
+		WebhookURLPath:      strings.TrimSpace(cfg.WebhookURLPath),
+		AirtableBaseURL:     strings.TrimSpace(cfg.AirtableBaseURL),
+		AirtableToken:       strings.TrimSpace(cfg.AirtableToken),
+		AirtableBaseID:      strings.TrimSpace(cfg.AirtableBaseID),
+		AirtableTable:       strings.TrimSpace(cfg.AirtableTable),
+		AirtableFields:      strings.TrimSpace(cfg.AirtableFields),
+		AirtableBodyMode:    airtable.NormalizeBodyMode(cfg.AirtableBodyMode),
+		GoogleClientID:      strings.TrimSpace(cfg.GoogleClientID),
+		GoogleClientSecret:  strings.TrimSpace(cfg.GoogleClientSecret),
+		GoogleRefreshToken:  strings.TrimSpace(cfg.GoogleRefreshToken),
+		GoogleFolderID:      strings.TrimSpace(cfg.GoogleFolderID),
+		GoogleDriveMode:     gdrive.NormalizeMode(cfg.GoogleDriveMode),
+		TelegramBaseURL:     strings.TrimSpace(cfg.TelegramBaseURL),
+		TelegramBotToken:    strings.TrimSpace(cfg.TelegramBotToken),
+		TelegramChatID:      strings.TrimSpace(cfg.TelegramChatID),
+		TelegramMode:        telegram.NormalizeMode(cfg.TelegramMode),
+		ReadwiseBaseURL:     strings.TrimSpace(cfg.ReadwiseBaseURL),
+		ReadwiseToken:       strings.TrimSpace(cfg.ReadwiseToken),
+		ReadwiseTags:        strings.TrimSpace(cfg.ReadwiseTags),
+		MemosBaseURL:        strings.TrimSpace(cfg.MemosBaseURL),
+		MemosToken:          strings.TrimSpace(cfg.MemosToken),
+		MemosVisibility:     memos.NormalizeVisibility(cfg.MemosVisibility),
+		MemosTags:           strings.TrimSpace(cfg.MemosTags),
+		TriliumBaseURL:      strings.TrimSpace(cfg.TriliumBaseURL),
+		TriliumToken:        strings.TrimSpace(cfg.TriliumToken),
+		TriliumParentNoteID: strings.TrimSpace(cfg.TriliumParentNoteID),
+		SpillThresholdMB:    nonNegative(cfg.SpillThresholdMB),
+		ArchiveMerge:        normalizeArchiveMerge(cfg.ArchiveMerge),
+		AnnotateChanges:     cfg.AnnotateChanges,
+		TitleFallback:       normalizeTitleFallback(cfg.TitleFallback),
+		TargetTitleFallback: normalizeTargetTitleFallback(cfg.TargetTitleFallback),
+		UnicodeNormalize:    cfg.UnicodeNormalize,
+		FilenameStripEmoji:  cfg.FilenameStripEmoji,
+		NotionChunkMode:     targets.NormalizeChunkMode(cfg.NotionChunkMode),
+		ServerUsers:         normalizeServerUsers(cfg.ServerUsers),
+		IPAllowlist:         normalizeIPAllowlist(cfg.IPAllowlist),
+		MessageLayout:       export.NormalizeLayout(cfg.MessageLayout),
+		ExportMode:          export.NormalizeExportMode(cfg.ExportMode),
+		ExportStats:         cfg.ExportStats,
+		TimeFormat:          export.NormalizeTimeFormat(cfg.TimeFormat),
+		RelativeTimes:       cfg.RelativeTimes,
+		SecondTimezone:      strings.TrimSpace(cfg.SecondTimezone),
+		FileHierarchy:       export.NormalizeHierarchy(cfg.FileHierarchy),
+		NotionDraftParentID: strings.TrimSpace(cfg.NotionDraftParentID),
+		NotionDraftType:     sanitizeNotionParentType(cfg.NotionDraftType),
+		ProjectTags:         cfg.ProjectTags,
+		NotionProjectField:  strings.TrimSpace(cfg.NotionProjectField),
+		TitleTemplate:       normalizeTitleTemplate(cfg.TitleTemplate),
+		TargetTitleTemplate: normalizeTargetTitleTemplate(cfg.TargetTitleTemplate),
+		QueueWindow:         normalizeQueueWindow(cfg.QueueWindow),
+		UARotation:          normalizeUARotation(cfg.UARotation),
+		UARotationBatch:     nonNegative(cfg.UARotationBatch),
+		UpdateCheck:         cfg.UpdateCheck,
+		NotionURLField:      strings.TrimSpace(cfg.NotionURLField),
+		HTMLQRCode:          export.NormalizeQRCodeMode(cfg.HTMLQRCode),
+		MaxMessageChars:     nonNegative(cfg.MaxMessageChars),
+		TruncateKeepFull:    cfg.TruncateKeepFull,
+		DeleteIntervalMs:    nonNegative(cfg.DeleteIntervalMs),
+		DeleteGraceMinutes:  nonNegative(cfg.DeleteGraceMinutes),
+		ConfigLocks:         normalizeConfigLocks(cfg.ConfigLocks),
+		OutputPath:          strings.TrimSpace(cfg.OutputPath),
+		RawOutputPath:       strings.TrimSpace(cfg.RawOutputPath),
+		RawFormat:           rawjson.NormalizeFormat(cfg.RawFormat),
+		NotionUploadAudio:   cfg.NotionUploadAudio,
+		NotionUploadFiles:   cfg.NotionUploadFiles,
+		AnytypeUploadFiles:  cfg.AnytypeUploadFiles,
+		AnytypeFilesField:   strings.TrimSpace(cfg.AnytypeFilesField),
+		TOCMinMessages:      nonNegative(cfg.TOCMinMessages),
+		OneNoteTenant:       strings.TrimSpace(cfg.OneNoteTenant),
+		OneNoteClientID:     strings.TrimSpace(cfg.OneNoteClientID),
+		OneNoteClientSecret: strings.TrimSpace(cfg.OneNoteClientSecret),
+		OneNoteRefreshToken: strings.TrimSpace(cfg.OneNoteRefreshToken),
+		OneNoteToken:        strings.TrimSpace(cfg.OneNoteToken),
+		OneNoteNotebook:     strings.TrimSpace(cfg.OneNoteNotebook),
+		OneNoteSection:      strings.TrimSpace(cfg.OneNoteSection),
+		OneNoteBaseURL:      strings.TrimSpace(cfg.OneNoteBaseURL),
+		MathRenderURL:       strings.TrimSpace(cfg.MathRenderURL),
+		DiagramRenderURL:    strings.TrimSpace(cfg.DiagramRenderURL),
 	}
 	if payload.BaseURL == "" {
 		payload.BaseURL = defaultBaseURL
@@ -392,9 +844,105 @@ func applyConfigPayload(cfg *cliConfig, payload ConfigPayload) {
 	cfg.NotionParentType = sanitizeNotionParentType(payload.NotionParentType)
 	cfg.NotionParentID = strings.TrimSpace(payload.NotionParentID)
 	cfg.NotionTitleProperty = strings.TrimSpace(payload.NotionTitleProperty)
+	cfg.HookAPIKey = strings.TrimSpace(payload.HookAPIKey)
+	cfg.HookLimit = nonNegative(payload.HookLimit)
+	cfg.HTMLTheme = export.NormalizeHTMLTheme(payload.HTMLTheme)
+	cfg.HTMLCustomCSS = strings.TrimSpace(payload.HTMLCustomCSS)
+	cfg.IncludeContext = payload.IncludeContext
+	cfg.DownloadAudio = payload.DownloadAudio
+	cfg.DownloadAttachments = payload.DownloadAttachments
+	cfg.MathMode = export.NormalizeMathMode(payload.MathMode)
+	cfg.RenderDiagrams = payload.RenderDiagrams
+	cfg.WebhookURL = strings.TrimSpace(payload.WebhookURL)
+	cfg.WebhookHeaders = strings.TrimSpace(payload.WebhookHeaders)
+	cfg.WebhookTemplate = strings.TrimSpace(payload.WebhookTemplate)
+	cfg.WebhookIDPath = strings.TrimSpace(payload.WebhookIDPath)
+	cfg.WebhookURLPath = strings.TrimSpace(payload.WebhookURLPath)
+	cfg.AirtableBaseURL = strings.TrimSpace(payload.AirtableBaseURL)
+	cfg.AirtableToken = strings.TrimSpace(payload.AirtableToken)
+	cfg.AirtableBaseID = strings.TrimSpace(payload.AirtableBaseID)
+	cfg.AirtableTable = strings.TrimSpace(payload.AirtableTable)
+	cfg.AirtableFields = strings.TrimSpace(payload.AirtableFields)
+	cfg.AirtableBodyMode = airtable.NormalizeBodyMode(payload.AirtableBodyMode)
+	cfg.GoogleClientID = strings.TrimSpace(payload.GoogleClientID)
+	cfg.GoogleClientSecret = strings.TrimSpace(payload.GoogleClientSecret)
+	cfg.GoogleRefreshToken = strings.TrimSpace(payload.GoogleRefreshToken)
+	cfg.GoogleFolderID = strings.TrimSpace(payload.GoogleFolderID)
+	cfg.GoogleDriveMode = gdrive.NormalizeMode(payload.GoogleDriveMode)
+	cfg.TelegramBaseURL = strings.TrimSpace(payload.TelegramBaseURL)
+	cfg.TelegramBotToken = strings.TrimSpace(payload.TelegramBotToken)
+	cfg.TelegramChatID = strings.TrimSpace(payload.TelegramChatID)
+	cfg.TelegramMode = telegram.NormalizeMode(payload.TelegramMode)
+	cfg.ReadwiseBaseURL = strings.TrimSpace(payload.ReadwiseBaseURL)
+	cfg.ReadwiseToken = strings.TrimSpace(payload.ReadwiseToken)
+	cfg.ReadwiseTags = strings.TrimSpace(payload.ReadwiseTags)
+	cfg.MemosBaseURL = strings.TrimSpace(payload.MemosBaseURL)
+	cfg.MemosToken = strings.TrimSpace(payload.MemosToken)
+	cfg.MemosVisibility = memos.NormalizeVisibility(payload.MemosVisibility)
+	cfg.MemosTags = strings.TrimSpace(payload.MemosTags)
+	cfg.TriliumBaseURL = strings.TrimSpace(payload.TriliumBaseURL)
+	cfg.TriliumToken = strings.TrimSpace(payload.TriliumToken)
+	cfg.TriliumParentNoteID = strings.TrimSpace(payload.TriliumParentNoteID)
+	cfg.SpillThresholdMB = nonNegative(payload.SpillThresholdMB)
+	cfg.ArchiveMerge = normalizeArchiveMerge(payload.ArchiveMerge)
+	cfg.AnnotateChanges = payload.AnnotateChanges
+	cfg.TitleFallback = normalizeTitleFallback(payload.TitleFallback)
+	cfg.TargetTitleFallback = normalizeTargetTitleFallback(payload.TargetTitleFallback)
+	cfg.UnicodeNormalize = payload.UnicodeNormalize
+	cfg.FilenameStripEmoji = payload.FilenameStripEmoji
+	cfg.NotionChunkMode = targets.NormalizeChunkMode(payload.NotionChunkMode)
+	cfg.ServerUsers = normalizeServerUsers(payload.ServerUsers)
+	cfg.IPAllowlist = normalizeIPAllowlist(payload.IPAllowlist)
+	cfg.MessageLayout = export.NormalizeLayout(payload.MessageLayout)
+	cfg.ExportMode = export.NormalizeExportMode(payload.ExportMode)
+	cfg.ExportStats = payload.ExportStats
+	cfg.TimeFormat = export.NormalizeTimeFormat(payload.TimeFormat)
+	cfg.RelativeTimes = payload.RelativeTimes
+	cfg.SecondTimezone = strings.TrimSpace(payload.SecondTimezone)
+	cfg.FileHierarchy = export.NormalizeHierarchy(payload.FileHierarchy)
+	cfg.NotionDraftParentID = strings.TrimSpace(payload.NotionDraftParentID)
+	cfg.NotionDraftType = sanitizeNotionParentType(payload.NotionDraftType)
+	cfg.ProjectTags = payload.ProjectTags
+	cfg.NotionProjectField = strings.TrimSpace(payload.NotionProjectField)
+	cfg.TitleTemplate = normalizeTitleTemplate(payload.TitleTemplate)
+	cfg.TargetTitleTemplate = normalizeTargetTitleTemplate(payload.TargetTitleTemplate)
+	cfg.QueueWindow = normalizeQueueWindow(payload.QueueWindow)
+	cfg.UARotation = normalizeUARotation(payload.UARotation)
+	cfg.UARotationBatch = nonNegative(payload.UARotationBatch)
+	cfg.UpdateCheck = payload.UpdateCheck
+	cfg.NotionURLField = strings.TrimSpace(payload.NotionURLField)
+	cfg.HTMLQRCode = export.NormalizeQRCodeMode(payload.HTMLQRCode)
+	cfg.MaxMessageChars = nonNegative(payload.MaxMessageChars)
+	cfg.TruncateKeepFull = payload.TruncateKeepFull
+	cfg.DeleteIntervalMs = nonNegative(payload.DeleteIntervalMs)
+	cfg.DeleteGraceMinutes = nonNegative(payload.DeleteGraceMinutes)
+	cfg.ConfigLocks = normalizeConfigLocks(payload.ConfigLocks)
+	cfg.OutputPath = strings.TrimSpace(payload.OutputPath)
+	cfg.RawOutputPath = strings.TrimSpace(payload.RawOutputPath)
+	cfg.RawFormat = rawjson.NormalizeFormat(payload.RawFormat)
+	cfg.NotionUploadAudio = payload.NotionUploadAudio
+	cfg.NotionUploadFiles = payload.NotionUploadFiles
+	cfg.AnytypeUploadFiles = payload.AnytypeUploadFiles
+	cfg.AnytypeFilesField = strings.TrimSpace(payload.AnytypeFilesField)
+	cfg.TOCMinMessages = nonNegative(payload.TOCMinMessages)
+	cfg.OneNoteTenant = strings.TrimSpace(payload.OneNoteTenant)
+	cfg.OneNoteClientID = strings.TrimSpace(payload.OneNoteClientID)
+	cfg.OneNoteClientSecret = strings.TrimSpace(payload.OneNoteClientSecret)
+	cfg.OneNoteRefreshToken = strings.TrimSpace(payload.OneNoteRefreshToken)
+	cfg.OneNoteToken = strings.TrimSpace(payload.OneNoteToken)
+	cfg.OneNoteNotebook = strings.TrimSpace(payload.OneNoteNotebook)
+	cfg.OneNoteSection = strings.TrimSpace(payload.OneNoteSection)
+	cfg.OneNoteBaseURL = strings.TrimSpace(payload.OneNoteBaseURL)
+	cfg.MathRenderURL = strings.TrimSpace(payload.MathRenderURL)
+	cfg.DiagramRenderURL = strings.TrimSpace(payload.DiagramRenderURL)
 }
 
 func (s *webServer) updateConfig(input configUpdate) (ConfigPayload, error) {
+	if input.SecondTimezone != nil {
+		if _, err := export.LoadSecondLocation(*input.SecondTimezone); err != nil {
+			return ConfigPayload{}, fmt.Errorf("second_timezone 无效: %w", err)
+		}
+	}
 	s.configMu.Lock()
 	cfg := s.cfg
 
@@ -465,8 +1013,278 @@ func (s *webServer) updateConfig(input configUpdate) (ConfigPayload, error) {
 	if input.NotionTitleProperty != nil {
 		cfg.NotionTitleProperty = strings.TrimSpace(*input.NotionTitleProperty)
 	}
+	if input.HookAPIKey != nil {
+		cfg.HookAPIKey = strings.TrimSpace(*input.HookAPIKey)
+	}
+	if input.HookLimit != nil {
+		cfg.HookLimit = nonNegative(*input.HookLimit)
+	}
+	if input.HTMLTheme != nil {
+		cfg.HTMLTheme = export.NormalizeHTMLTheme(*input.HTMLTheme)
+	}
+	if input.HTMLCustomCSS != nil {
+		cfg.HTMLCustomCSS = strings.TrimSpace(*input.HTMLCustomCSS)
+	}
+	if input.IncludeContext != nil {
+		cfg.IncludeContext = *input.IncludeContext
+	}
+	if input.DownloadAudio != nil {
+		cfg.DownloadAudio = *input.DownloadAudio
+	}
+	if input.DownloadAttachments != nil {
+		cfg.DownloadAttachments = *input.DownloadAttachments
+	}
+	if input.MathMode != nil {
+		cfg.MathMode = export.NormalizeMathMode(*input.MathMode)
+	}
+	if input.RenderDiagrams != nil {
+		cfg.RenderDiagrams = *input.RenderDiagrams
+	}
+	if input.WebhookURL != nil {
+		cfg.WebhookURL = strings.TrimSpace(*input.WebhookURL)
+	}
+	if input.WebhookHeaders != nil {
+		cfg.WebhookHeaders = strings.TrimSpace(*input.WebhookHeaders)
+	}
+	if input.WebhookTemplate != nil {
+		cfg.WebhookTemplate = strings.TrimSpace(*input.WebhookTemplate)
+	}
+	if input.WebhookIDPath != nil {
+		cfg.WebhookIDPath = strings.TrimSpace(*input.WebhookIDPath)
+	}
+	if input.WebhookURLPath != nil {
+		cfg.WebhookURLPath = strings.TrimSpace(*input.WebhookURLPath)
+	}
+	if input.AirtableBaseURL != nil {
+		cfg.AirtableBaseURL = strings.TrimSpace(*input.AirtableBaseURL)
+	}
+	if input.AirtableToken != nil {
+		cfg.AirtableToken = strings.TrimSpace(*input.AirtableToken)
+	}
+	if input.AirtableBaseID != nil {
+		cfg.AirtableBaseID = strings.TrimSpace(*input.AirtableBaseID)
+	}
+	if input.AirtableTable != nil {
+		cfg.AirtableTable = strings.TrimSpace(*input.AirtableTable)
+	}
+	if input.AirtableFields != nil {
+		cfg.AirtableFields = strings.TrimSpace(*input.AirtableFields)
+	}
+	if input.AirtableBodyMode != nil {
+		cfg.AirtableBodyMode = airtable.NormalizeBodyMode(*input.AirtableBodyMode)
+	}
+	if input.GoogleClientID != nil {
+		cfg.GoogleClientID = strings.TrimSpace(*input.GoogleClientID)
+	}
+	if input.GoogleClientSecret != nil {
+		cfg.GoogleClientSecret = strings.TrimSpace(*input.GoogleClientSecret)
+	}
+	if input.GoogleRefreshToken != nil {
+		cfg.GoogleRefreshToken = strings.TrimSpace(*input.GoogleRefreshToken)
+	}
+	if input.GoogleFolderID != nil {
+		cfg.GoogleFolderID = strings.TrimSpace(*input.GoogleFolderID)
+	}
+	if input.GoogleDriveMode != nil {
+		cfg.GoogleDriveMode = gdrive.NormalizeMode(*input.GoogleDriveMode)
+	}
+	if input.TelegramBaseURL != nil {
+		cfg.TelegramBaseURL = strings.TrimSpace(*input.TelegramBaseURL)
+	}
+	if input.TelegramBotToken != nil {
+		cfg.TelegramBotToken = strings.TrimSpace(*input.TelegramBotToken)
+	}
+	if input.TelegramChatID != nil {
+		cfg.TelegramChatID = strings.TrimSpace(*input.TelegramChatID)
+	}
+	if input.TelegramMode != nil {
+		cfg.TelegramMode = telegram.NormalizeMode(*input.TelegramMode)
+	}
+	if input.ReadwiseBaseURL != nil {
+		cfg.ReadwiseBaseURL = strings.TrimSpace(*input.ReadwiseBaseURL)
+	}
+	if input.ReadwiseToken != nil {
+		cfg.ReadwiseToken = strings.TrimSpace(*input.ReadwiseToken)
+	}
+	if input.ReadwiseTags != nil {
+		cfg.ReadwiseTags = strings.TrimSpace(*input.ReadwiseTags)
+	}
+	if input.MemosBaseURL != nil {
+		cfg.MemosBaseURL = strings.TrimSpace(*input.MemosBaseURL)
+	}
+	if input.MemosToken != nil {
+		cfg.MemosToken = strings.TrimSpace(*input.MemosToken)
+	}
+	if input.MemosVisibility != nil {
+		cfg.MemosVisibility = memos.NormalizeVisibility(*input.MemosVisibility)
+	}
+	if input.MemosTags != nil {
+		cfg.MemosTags = strings.TrimSpace(*input.MemosTags)
+	}
+	if input.TriliumBaseURL != nil {
+		cfg.TriliumBaseURL = strings.TrimSpace(*input.TriliumBaseURL)
+	}
+	if input.TriliumToken != nil {
+		cfg.TriliumToken = strings.TrimSpace(*input.TriliumToken)
+	}
+	if input.TriliumParentNoteID != nil {
+		cfg.TriliumParentNoteID = strings.TrimSpace(*input.TriliumParentNoteID)
+	}
+	if input.SpillThresholdMB != nil {
+		cfg.SpillThresholdMB = nonNegative(*input.SpillThresholdMB)
+	}
+	if input.ArchiveMerge != nil {
+		cfg.ArchiveMerge = normalizeArchiveMerge(*input.ArchiveMerge)
+	}
+	if input.AnnotateChanges != nil {
+		cfg.AnnotateChanges = *input.AnnotateChanges
+	}
+	if input.TitleFallback != nil {
+		cfg.TitleFallback = normalizeTitleFallback(*input.TitleFallback)
+	}
+	if input.TargetTitleFallback != nil {
+		cfg.TargetTitleFallback = normalizeTargetTitleFallback(*input.TargetTitleFallback)
+	}
+	if input.UnicodeNormalize != nil {
+		cfg.UnicodeNormalize = *input.UnicodeNormalize
+	}
+	if input.FilenameStripEmoji != nil {
+		cfg.FilenameStripEmoji = *input.FilenameStripEmoji
+	}
+	if input.NotionChunkMode != nil {
+		cfg.NotionChunkMode = targets.NormalizeChunkMode(*input.NotionChunkMode)
+	}
+	if input.ServerUsers != nil {
+		cfg.ServerUsers = normalizeServerUsers(*input.ServerUsers)
+	}
+	if input.IPAllowlist != nil {
+		cfg.IPAllowlist = normalizeIPAllowlist(*input.IPAllowlist)
+	}
+	if input.MessageLayout != nil {
+		cfg.MessageLayout = export.NormalizeLayout(*input.MessageLayout)
+	}
+	if input.ExportMode != nil {
+		cfg.ExportMode = export.NormalizeExportMode(*input.ExportMode)
+	}
+	if input.ExportStats != nil {
+		cfg.ExportStats = *input.ExportStats
+	}
+	if input.TimeFormat != nil {
+		cfg.TimeFormat = export.NormalizeTimeFormat(*input.TimeFormat)
+	}
+	if input.RelativeTimes != nil {
+		cfg.RelativeTimes = *input.RelativeTimes
+	}
+	if input.SecondTimezone != nil {
+		cfg.SecondTimezone = strings.TrimSpace(*input.SecondTimezone)
+	}
+	if input.FileHierarchy != nil {
+		cfg.FileHierarchy = export.NormalizeHierarchy(*input.FileHierarchy)
+	}
+	if input.NotionDraftParentID != nil {
+		cfg.NotionDraftParentID = strings.TrimSpace(*input.NotionDraftParentID)
+	}
+	if input.NotionDraftType != nil {
+		cfg.NotionDraftType = sanitizeNotionParentType(*input.NotionDraftType)
+	}
+	if input.ProjectTags != nil {
+		cfg.ProjectTags = *input.ProjectTags
+	}
+	if input.NotionProjectField != nil {
+		cfg.NotionProjectField = strings.TrimSpace(*input.NotionProjectField)
+	}
+	if input.TitleTemplate != nil {
+		cfg.TitleTemplate = normalizeTitleTemplate(*input.TitleTemplate)
+	}
+	if input.TargetTitleTemplate != nil {
+		cfg.TargetTitleTemplate = normalizeTargetTitleTemplate(*input.TargetTitleTemplate)
+	}
+	if input.QueueWindow != nil {
+		cfg.QueueWindow = normalizeQueueWindow(*input.QueueWindow)
+	}
+	if input.UARotation != nil {
+		cfg.UARotation = normalizeUARotation(*input.UARotation)
+	}
+	if input.UARotationBatch != nil {
+		cfg.UARotationBatch = nonNegative(*input.UARotationBatch)
+	}
+	if input.UpdateCheck != nil {
+		cfg.UpdateCheck = *input.UpdateCheck
+	}
+	if input.NotionURLField != nil {
+		cfg.NotionURLField = strings.TrimSpace(*input.NotionURLField)
+	}
+	if input.HTMLQRCode != nil {
+		cfg.HTMLQRCode = export.NormalizeQRCodeMode(*input.HTMLQRCode)
+	}
+	if input.MaxMessageChars != nil {
+		cfg.MaxMessageChars = nonNegative(*input.MaxMessageChars)
+	}
+	if input.TruncateKeepFull != nil {
+		cfg.TruncateKeepFull = *input.TruncateKeepFull
+	}
+	if input.DeleteIntervalMs != nil {
+		cfg.DeleteIntervalMs = nonNegative(*input.DeleteIntervalMs)
+	}
+	if input.DeleteGraceMinutes != nil {
+		cfg.DeleteGraceMinutes = nonNegative(*input.DeleteGraceMinutes)
+	}
+	if input.OutputPath != nil {
+		cfg.OutputPath = strings.TrimSpace(*input.OutputPath)
+	}
+	if input.RawOutputPath != nil {
+		cfg.RawOutputPath = strings.TrimSpace(*input.RawOutputPath)
+	}
+	if input.RawFormat != nil {
+		cfg.RawFormat = rawjson.NormalizeFormat(*input.RawFormat)
+	}
+	if input.NotionUploadAudio != nil {
+		cfg.NotionUploadAudio = *input.NotionUploadAudio
+	}
+	if input.NotionUploadFiles != nil {
+		cfg.NotionUploadFiles = *input.NotionUploadFiles
+	}
+	if input.AnytypeUploadFiles != nil {
+		cfg.AnytypeUploadFiles = *input.AnytypeUploadFiles
+	}
+	if input.AnytypeFilesField != nil {
+		cfg.AnytypeFilesField = strings.TrimSpace(*input.AnytypeFilesField)
+	}
+	if input.TOCMinMessages != nil {
+		cfg.TOCMinMessages = nonNegative(*input.TOCMinMessages)
+	}
+	if input.OneNoteTenant != nil {
+		cfg.OneNoteTenant = strings.TrimSpace(*input.OneNoteTenant)
+	}
+	if input.OneNoteClientID != nil {
+		cfg.OneNoteClientID = strings.TrimSpace(*input.OneNoteClientID)
+	}
+	if input.OneNoteClientSecret != nil {
+		cfg.OneNoteClientSecret = strings.TrimSpace(*input.OneNoteClientSecret)
+	}
+	if input.OneNoteRefreshToken != nil {
+		cfg.OneNoteRefreshToken = strings.TrimSpace(*input.OneNoteRefreshToken)
+	}
+	if input.OneNoteToken != nil {
+		cfg.OneNoteToken = strings.TrimSpace(*input.OneNoteToken)
+	}
+	if input.OneNoteNotebook != nil {
+		cfg.OneNoteNotebook = strings.TrimSpace(*input.OneNoteNotebook)
+	}
+	if input.OneNoteSection != nil {
+		cfg.OneNoteSection = strings.TrimSpace(*input.OneNoteSection)
+	}
+	if input.OneNoteBaseURL != nil {
+		cfg.OneNoteBaseURL = strings.TrimSpace(*input.OneNoteBaseURL)
+	}
+	if input.MathRenderURL != nil {
+		cfg.MathRenderURL = strings.TrimSpace(*input.MathRenderURL)
+	}
+	if input.DiagramRenderURL != nil {
+		cfg.DiagramRenderURL = strings.TrimSpace(*input.DiagramRenderURL)
+	}
 
-	s.location = resolveLocation(cfg.OutputTimezone)
+	s.location = export.ResolveLocation(cfg.OutputTimezone)
 	cfgCopy := *cfg
 	payload := configToPayload(cfg)
 	s.configMu.Unlock()
@@ -482,7 +1300,7 @@ func (s *webServer) updateConfig(input configUpdate) (ConfigPayload, error) {
 func (s *webServer) replaceConfig(payload ConfigPayload) ConfigPayload {
 	s.configMu.Lock()
 	applyConfigPayload(s.cfg, payload)
-	s.location = resolveLocation(s.cfg.OutputTimezone)
+	s.location = export.ResolveLocation(s.cfg.OutputTimezone)
 	cfgCopy := *s.cfg
 	result := configToPayload(s.cfg)
 	s.configMu.Unlock()
@@ -510,6 +1328,28 @@ func normalizeExportTarget(value string) string {
 	switch strings.ToLower(strings.TrimSpace(value)) {
 	case exportTargetNotion:
 		return exportTargetNotion
+	case exportTargetExec:
+		return exportTargetExec
+	case exportTargetWebhook:
+		return exportTargetWebhook
+	case exportTargetAirtable:
+		return exportTargetAirtable
+	case exportTargetGDrive:
+		return exportTargetGDrive
+	case exportTargetTelegram:
+		return exportTargetTelegram
+	case exportTargetReadwise:
+		return exportTargetReadwise
+	case exportTargetMemos:
+		return exportTargetMemos
+	case exportTargetTrilium:
+		return exportTargetTrilium
+	case exportTargetOneNote:
+		return exportTargetOneNote
+	case exportTargetMarkdown:
+		return exportTargetMarkdown
+	case exportTargetJSON:
+		return exportTargetJSON
 	default:
 		return exportTargetAnytype
 	}
@@ -562,6 +1402,82 @@ func normalizeConfigImportPayload(payload ConfigPayload) ConfigPayload {
 	payload.NotionParentType = sanitizeNotionParentType(payload.NotionParentType)
 	payload.NotionParentID = strings.TrimSpace(payload.NotionParentID)
 	payload.NotionTitleProperty = strings.TrimSpace(payload.NotionTitleProperty)
+	payload.HookAPIKey = strings.TrimSpace(payload.HookAPIKey)
+	payload.HookLimit = nonNegative(payload.HookLimit)
+	payload.HTMLTheme = export.NormalizeHTMLTheme(payload.HTMLTheme)
+	payload.HTMLCustomCSS = strings.TrimSpace(payload.HTMLCustomCSS)
+	payload.MathMode = export.NormalizeMathMode(payload.MathMode)
+	payload.WebhookURL = strings.TrimSpace(payload.WebhookURL)
+	payload.WebhookHeaders = strings.TrimSpace(payload.WebhookHeaders)
+	payload.WebhookTemplate = strings.TrimSpace(payload.WebhookTemplate)
+	payload.WebhookIDPath = strings.TrimSpace(payload.WebhookIDPath)
+	payload.WebhookURLPath = strings.TrimSpace(payload.WebhookURLPath)
+	payload.AirtableBaseURL = strings.TrimSpace(payload.AirtableBaseURL)
+	payload.AirtableToken = strings.TrimSpace(payload.AirtableToken)
+	payload.AirtableBaseID = strings.TrimSpace(payload.AirtableBaseID)
+	payload.AirtableTable = strings.TrimSpace(payload.AirtableTable)
+	payload.AirtableFields = strings.TrimSpace(payload.AirtableFields)
+	payload.AirtableBodyMode = airtable.NormalizeBodyMode(payload.AirtableBodyMode)
+	payload.GoogleClientID = strings.TrimSpace(payload.GoogleClientID)
+	payload.GoogleClientSecret = strings.TrimSpace(payload.GoogleClientSecret)
+	payload.GoogleRefreshToken = strings.TrimSpace(payload.GoogleRefreshToken)
+	payload.GoogleFolderID = strings.TrimSpace(payload.GoogleFolderID)
+	payload.GoogleDriveMode = gdrive.NormalizeMode(payload.GoogleDriveMode)
+	payload.TelegramBaseURL = strings.TrimSpace(payload.TelegramBaseURL)
+	payload.TelegramBotToken = strings.TrimSpace(payload.TelegramBotToken)
+	payload.TelegramChatID = strings.TrimSpace(payload.TelegramChatID)
+	payload.TelegramMode = telegram.NormalizeMode(payload.TelegramMode)
+	payload.ReadwiseBaseURL = strings.TrimSpace(payload.ReadwiseBaseURL)
+	payload.ReadwiseToken = strings.TrimSpace(payload.ReadwiseToken)
+	payload.ReadwiseTags = strings.TrimSpace(payload.ReadwiseTags)
+	payload.MemosBaseURL = strings.TrimSpace(payload.MemosBaseURL)
+	payload.MemosToken = strings.TrimSpace(payload.MemosToken)
+	payload.MemosVisibility = memos.NormalizeVisibility(payload.MemosVisibility)
+	payload.MemosTags = strings.TrimSpace(payload.MemosTags)
+	payload.TriliumBaseURL = strings.TrimSpace(payload.TriliumBaseURL)
+	payload.TriliumToken = strings.TrimSpace(payload.TriliumToken)
+	payload.TriliumParentNoteID = strings.TrimSpace(payload.TriliumParentNoteID)
+	payload.SpillThresholdMB = nonNegative(payload.SpillThresholdMB)
+	payload.MaxMessageChars = nonNegative(payload.MaxMessageChars)
+	payload.ArchiveMerge = normalizeArchiveMerge(payload.ArchiveMerge)
+	payload.TitleFallback = normalizeTitleFallback(payload.TitleFallback)
+	payload.TargetTitleFallback = normalizeTargetTitleFallback(payload.TargetTitleFallback)
+	payload.NotionChunkMode = targets.NormalizeChunkMode(payload.NotionChunkMode)
+	payload.ServerUsers = normalizeServerUsers(payload.ServerUsers)
+	payload.IPAllowlist = normalizeIPAllowlist(payload.IPAllowlist)
+	payload.MessageLayout = export.NormalizeLayout(payload.MessageLayout)
+	payload.ExportMode = export.NormalizeExportMode(payload.ExportMode)
+	payload.TimeFormat = export.NormalizeTimeFormat(payload.TimeFormat)
+	payload.SecondTimezone = strings.TrimSpace(payload.SecondTimezone)
+	payload.FileHierarchy = export.NormalizeHierarchy(payload.FileHierarchy)
+	payload.NotionDraftParentID = strings.TrimSpace(payload.NotionDraftParentID)
+	payload.NotionDraftType = sanitizeNotionParentType(payload.NotionDraftType)
+	payload.NotionProjectField = strings.TrimSpace(payload.NotionProjectField)
+	payload.TitleTemplate = normalizeTitleTemplate(payload.TitleTemplate)
+	payload.TargetTitleTemplate = normalizeTargetTitleTemplate(payload.TargetTitleTemplate)
+	payload.QueueWindow = normalizeQueueWindow(payload.QueueWindow)
+	payload.UARotation = normalizeUARotation(payload.UARotation)
+	payload.UARotationBatch = nonNegative(payload.UARotationBatch)
+	payload.NotionURLField = strings.TrimSpace(payload.NotionURLField)
+	payload.HTMLQRCode = export.NormalizeQRCodeMode(payload.HTMLQRCode)
+	payload.DeleteIntervalMs = nonNegative(payload.DeleteIntervalMs)
+	payload.DeleteGraceMinutes = nonNegative(payload.DeleteGraceMinutes)
+	payload.ConfigLocks = normalizeConfigLocks(payload.ConfigLocks)
+	payload.OutputPath = strings.TrimSpace(payload.OutputPath)
+	payload.RawOutputPath = strings.TrimSpace(payload.RawOutputPath)
+	payload.RawFormat = rawjson.NormalizeFormat(payload.RawFormat)
+	payload.AnytypeFilesField = strings.TrimSpace(payload.AnytypeFilesField)
+	payload.TOCMinMessages = nonNegative(payload.TOCMinMessages)
+	payload.OneNoteTenant = strings.TrimSpace(payload.OneNoteTenant)
+	payload.OneNoteClientID = strings.TrimSpace(payload.OneNoteClientID)
+	payload.OneNoteClientSecret = strings.TrimSpace(payload.OneNoteClientSecret)
+	payload.OneNoteRefreshToken = strings.TrimSpace(payload.OneNoteRefreshToken)
+	payload.OneNoteToken = strings.TrimSpace(payload.OneNoteToken)
+	payload.OneNoteNotebook = strings.TrimSpace(payload.OneNoteNotebook)
+	payload.OneNoteSection = strings.TrimSpace(payload.OneNoteSection)
+	payload.OneNoteBaseURL = strings.TrimSpace(payload.OneNoteBaseURL)
+	payload.MathRenderURL = strings.TrimSpace(payload.MathRenderURL)
+	payload.DiagramRenderURL = strings.TrimSpace(payload.DiagramRenderURL)
 	return payload
 }
 
@@ -596,14 +1512,7 @@ func nonNegative(value int) int {
 }
 
 func sanitizeNotionParentType(value string) string {
-	switch strings.ToLower(strings.TrimSpace(value)) {
-	case "page":
-		return "page"
-	case "database":
-		return "database"
-	default:
-		return ""
-	}
+	return notion.NormalizeParentType(value)
 }
 
 func (s *webServer) serveIndex(w http.ResponseWriter, r *http.Request) {
@@ -612,7 +1521,7 @@ func (s *webServer) serveIndex(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	indexHTML, err := fs.ReadFile(distFS, "index.html")
+	indexHTML, err := fs.ReadFile(s.dist, "index.html")
 	if err != nil {
 		http.Error(w, fmt.Sprintf("加载前端页面失败: %v", err), http.StatusInternalServerError)
 		return
@@ -627,11 +1536,13 @@ func (s *webServer) serveIndex(w http.ResponseWriter, r *http.Request) {
 
 func (s *webServer) handleConversationList(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
 		return
 	}
 	query := r.URL.Query()
 	force := query.Get("refresh") == "1"
+	notExported := query.Get("not_exported") == "1"
+	withPreview := query.Get("preview") == "1"
 
 	cfg := s.configSnapshot()
 	loc := s.locationSnapshot()
@@ -648,18 +1559,40 @@ func (s *webServer) handleConversationList(w http.ResponseWriter, r *http.Reques
 
 	page, err := s.getConversationPage(r.Context(), offset, limit, force)
 	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("获取对话列表失败: %v", err))
+		writeAPIError(w, chatgptError("获取对话列表失败", err))
 		return
 	}
 
+	statuses := s.exportStatusLookup(r.Context(), page.Items)
+	var previews map[string]string
+	if withPreview {
+		previews = s.loadPreviews(r.Context(), page.Items)
+	}
+	now := time.Now()
 	items := make([]apiConversationItem, 0, len(page.Items))
 	for _, meta := range page.Items {
-		items = append(items, apiConversationItem{
-			ID:         meta.ID,
-			Title:      firstNonEmpty(meta.Title, "(未命名对话)"),
-			CreateTime: formatTimestamp(meta.CreateTime.Float64(), loc),
-			UpdateTime: formatTimestamp(meta.UpdateTime.Float64(), loc),
-		})
+		status, exported := statuses[meta.ID]
+		// 仅看未导出: 在当前页内过滤, 分页参数仍以上游为准。
+		if notExported && exported {
+			continue
+		}
+		item := apiConversationItem{
+			ID:            meta.ID,
+			Title:         firstNonEmpty(meta.Title, "(未命名对话)"),
+			CreateTime:    export.FormatTimestampAs(meta.CreateTime.Float64(), loc, cfg.TimeFormat),
+			UpdateTime:    export.FormatTimestampAs(meta.UpdateTime.Float64(), loc, cfg.TimeFormat),
+			ExportTargets: []string{},
+			Preview:       previews[meta.ID],
+		}
+		if cfg.RelativeTimes {
+			item.CreateTimeRelative = export.FormatRelative(meta.CreateTime.Float64(), now)
+			item.UpdateTimeRelative = export.FormatRelative(meta.UpdateTime.Float64(), now)
+		}
+		if exported {
+			item.LastExportedAt = export.FormatTimeAs(status.LastExportedAt, loc, cfg.TimeFormat)
+			item.ExportTargets = status.Targets
+		}
+		items = append(items, item)
 	}
 	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"items":    items,
@@ -672,12 +1605,25 @@ func (s *webServer) handleConversationList(w http.ResponseWriter, r *http.Reques
 
 func (s *webServer) handleConversationDetail(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
 		return
 	}
-	loc := s.locationSnapshot()
 	id := strings.TrimPrefix(r.URL.Path, "/api/conversations/")
 	id = strings.TrimSpace(id)
+	if convID, rest, ok := strings.Cut(id, "/versions"); ok && convID != "" && !strings.Contains(convID, "/") && (rest == "" || strings.HasPrefix(rest, "/")) {
+		s.handleConversationVersions(w, r, convID, strings.TrimPrefix(rest, "/"))
+		return
+	}
+	if convID, ok := strings.CutSuffix(id, "/search"); ok && convID != "" && !strings.Contains(convID, "/") {
+		s.handleConversationSearch(w, r, convID)
+		return
+	}
+	for _, format := range []string{"markdown", "plaintext"} {
+		if convID, ok := strings.CutSuffix(id, "/"+format); ok && convID != "" && !strings.Contains(convID, "/") {
+			s.handleConversationText(w, r, convID, format)
+			return
+		}
+	}
 	if id == "" || strings.Contains(id, "/") {
 		http.NotFound(w, r)
 		return
@@ -685,17 +1631,26 @@ func (s *webServer) handleConversationDetail(w http.ResponseWriter, r *http.Requ
 	force := r.URL.Query().Get("refresh") == "1"
 	conv, err := s.loadExportConversation(r.Context(), id, force)
 	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("获取对话详情失败: %v", err))
+		writeAPIError(w, chatgptError("获取对话详情失败", err))
 		return
 	}
+	writeJSON(w, http.StatusOK, s.conversationDetailResponse(conv))
+}
+
+// conversationDetailResponse 把导出模型转换为详情接口的响应, 时间按配置时区格式化。
+func (s *webServer) conversationDetailResponse(conv export.Conversation) apiConversationDetail {
+	loc := s.locationSnapshot()
+	format := s.configSnapshot().TimeFormat
 	resp := apiConversationDetail{
 		ID:         conv.ID,
 		Title:      firstNonEmpty(conv.Title, "(未命名对话)"),
-		CreateTime: formatTimestamp(conv.CreateTime, loc),
-		UpdateTime: formatTimestamp(conv.UpdateTime, loc),
+		CreateTime: export.FormatTimestampAs(conv.CreateTime, loc, format),
+		UpdateTime: export.FormatTimestampAs(conv.UpdateTime, loc, format),
 	}
+	var perMessage []export.Stats
+	resp.Stats, perMessage = export.ConversationStats(conv)
 	resp.Messages = make([]apiMessage, 0, len(conv.Messages))
-	for _, msg := range conv.Messages {
+	for idx, msg := range conv.Messages {
 		var refs []apiReference
 		if len(msg.References) > 0 {
 			for _, ref := range msg.References {
@@ -711,251 +1666,344 @@ func (s *webServer) handleConversationDetail(w http.ResponseWriter, r *http.Requ
 			Timestamp:  s.formatMessageTimestamp(msg),
 			Text:       msg.Text,
 			References: refs,
+			Stats:      perMessage[idx],
 		})
 	}
-	writeJSON(w, http.StatusOK, resp)
+	return resp
 }
 
 func (s *webServer) handleConversationExport(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
 		return
 	}
 	var req exportRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "请求体解析失败: "+err.Error())
+		writeErrorDetail(w, http.StatusBadRequest, errCodeInvalidRequest, "请求体解析失败", err)
 		return
 	}
 	if len(req.IDs) == 0 {
-		writeError(w, http.StatusBadRequest, "请选择至少一条对话")
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "请选择至少一条对话")
 		return
 	}
 
 	ctx := r.Context()
-	seen := make(map[string]struct{})
-	var conversations []exportConversation
+	started := time.Now().UTC()
+	cfg := s.configSnapshot()
+	format := strings.ToLower(strings.TrimSpace(req.Format))
+	switch format {
+	case "", "markdown", "md":
+		format = "markdown"
+	case "html", "epub":
+	case "pdf":
+		writeError(w, http.StatusBadRequest, errCodeUnsupportedFormat, "暂不支持直接导出 PDF, 请使用 html 格式配合 print 主题在浏览器中打印")
+		return
+	default:
+		writeError(w, http.StatusBadRequest, errCodeUnsupportedFormat, fmt.Sprintf("不支持的导出格式: %s", req.Format))
+		return
+	}
+	theme := cfg.HTMLTheme
+	if strings.TrimSpace(req.Theme) != "" {
+		theme = export.NormalizeHTMLTheme(req.Theme)
+	}
+	if format == "epub" {
+		s.exportEPUB(w, r, req, theme)
+		return
+	}
 
-	for _, rawID := range req.IDs {
-		id := strings.TrimSpace(rawID)
-		if id == "" {
-			continue
+	// 第一遍拉取全部对话, 只在内存中保留文件名与引用摘要, 对话本身存入 spillStore,
+	// 超过阈值的部分写入临时目录; 第二遍逐条读回、关联并渲染。
+	threshold := spillThresholdBytes(cfg)
+	store := newSpillStore(threshold)
+	defer store.Close()
+	items := exportItemsFromIDs(req.IDs)
+	summaries := make([]linkSummary, 0, len(items))
+	filenameTracker := make(map[string]int)
+	filenames := make(map[string]string, len(items))
+	nameOptions := filenameOptions(cfg)
+	nameOptions.Hierarchy = cfg.FileHierarchy
+	nameOptions.Location = s.locationSnapshot()
+	bundleKinds := map[string]bool{export.AssetAudio: cfg.DownloadAudio, export.AssetImage: true}
+	sizeOptions := export.SizeOptions{HTML: format == "html", Assets: bundleKinds, Attachments: cfg.DownloadAttachments}
+	var estimate int64
+	for _, item := range items {
+		conv, err := s.fetchExportConversation(ctx, item.ID)
+		if err != nil {
+			writeAPIError(w, chatgptError(fmt.Sprintf("获取对话 %s 详情失败", item.ID), err))
+			return
 		}
-		if _, ok := seen[id]; ok {
-			continue
+		s.annotateConversationChanges(ctx, &conv, started)
+		s.recordConversationVersion(ctx, conv)
+		conv = s.conversationForTarget(titleFallbackZip, conv)
+		filename := export.ConversationFilenameWith(conv, filenameTracker, nameOptions)
+		if format == "html" {
+			filename = strings.TrimSuffix(filename, ".md") + ".html"
 		}
-		seen[id] = struct{}{}
-
-		conv, err := s.loadExportConversation(ctx, id, true)
-		if err != nil {
-			writeError(w, http.StatusBadGateway, fmt.Sprintf("获取对话 %s 详情失败: %v", id, err))
+		filenames[conv.ID] = filename
+		estimate += export.EstimateSize(conv, sizeOptions)
+		summaries = append(summaries, newLinkSummary(conv))
+		if err := store.put(item.ID, conv); err != nil {
+			writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "暂存对话失败", err)
 			return
 		}
-		conversations = append(conversations, conv)
 	}
 
-	if len(conversations) == 0 {
-		writeError(w, http.StatusBadRequest, "没有有效的对话可导出")
+	if len(summaries) == 0 {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "没有有效的对话可导出")
 		return
 	}
+	// 压缩包超过阈值后写入临时目录, 先按预计大小检查磁盘空间, 避免写到一半才失败。
+	if estimate > threshold {
+		if err := diskspace.Check(os.TempDir(), estimate); err != nil {
+			logInfo("Web 导出压缩包取消: %v", err)
+			writeErrorDetail(w, http.StatusInsufficientStorage, errCodeInsufficientStorage, "磁盘空间不足, 无法生成压缩包", err)
+			return
+		}
+	}
 
-	cfg := s.configSnapshot()
-	buf := &bytes.Buffer{}
+	buf := newSpillBuffer(threshold)
+	defer buf.Close()
 	archive := zip.NewWriter(buf)
-	filenameTracker := make(map[string]int)
-
-	for _, conv := range conversations {
-		filename := buildConversationFilename(conv, filenameTracker)
-		content := renderConversationMarkdown(conv, cfg.OutputTimezone)
+	indexEntries := make([]export.IndexEntry, 0, len(items))
+	writtenAssets := make(map[string]bool)
+	for idx, item := range items {
+		var conv export.Conversation
+		if _, err := store.get(item.ID, &conv); err != nil {
+			archive.Close()
+			writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "读取暂存对话失败", err)
+			return
+		}
+		store.remove(item.ID)
+		for _, other := range summaries {
+			if summaries[idx].relates(other) {
+				conv.Related = append(conv.Related, export.RelatedConversation{ID: other.id, Title: firstNonEmpty(other.title, other.id)})
+			}
+		}
+		filename := filenames[conv.ID]
+		s.bundleConversationAssets(ctx, archive, &conv, bundleKinds, writtenAssets)
+		if cfg.DownloadAttachments {
+			s.bundleConversationAttachments(ctx, archive, &conv, writtenAssets)
+		}
+		bundleFullTexts(archive, &conv)
+		for i := range conv.Related {
+			conv.Related[i].Path = filenames[conv.Related[i].ID]
+		}
+		indexEntries = append(indexEntries, export.NewIndexEntry(conv, filename))
+		export.RebasePaths(&conv, filename)
+		var content string
+		if format == "html" {
+			content = export.RenderHTML(conv, cfg.OutputTimezone, export.HTMLOptions{Theme: theme, CustomCSS: cfg.HTMLCustomCSS, MathMode: cfg.MathMode, RenderDiagrams: cfg.RenderDiagrams, RenderSVG: s.svgRenderer(cfg), QRCode: cfg.HTMLQRCode, ArchivePath: filename})
+		} else {
+			content = export.RenderMarkdown(conv, cfg.OutputTimezone)
+		}
 		writer, err := archive.Create(filename)
 		if err != nil {
 			archive.Close()
-			writeError(w, http.StatusInternalServerError, fmt.Sprintf("创建压缩文件失败: %v", err))
+			writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "创建压缩文件失败", err)
 			return
 		}
 		if _, err := writer.Write([]byte(content)); err != nil {
 			archive.Close()
-			writeError(w, http.StatusInternalServerError, fmt.Sprintf("写入 %s 失败: %v", filename, err))
+			writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, fmt.Sprintf("写入 %s 失败", filename), err)
 			return
 		}
 	}
 
+	if err := export.WriteArchiveIndex(archive, indexEntries); err != nil {
+		archive.Close()
+		writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "写入索引失败", err)
+		return
+	}
+
 	if err := archive.Close(); err != nil {
-		writeError(w, http.StatusInternalServerError, fmt.Sprintf("生成压缩包失败: %v", err))
+		writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "生成压缩包失败", err)
 		return
 	}
 
-	logInfo("Web 导出 Markdown 压缩包: 选中=%d 有效=%d", len(req.IDs), len(conversations))
+	logInfo("Web 导出压缩包: 格式=%s 选中=%d 有效=%d 大小=%d 预计=%d", format, len(req.IDs), len(summaries), buf.Size(), estimate)
 
 	filename := fmt.Sprintf("conversations-%s.zip", time.Now().Format("20060102-150405"))
 	w.Header().Set("Content-Type", "application/zip")
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
 	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Length", strconv.FormatInt(buf.Size(), 10))
 
-	if _, err := w.Write(buf.Bytes()); err != nil {
+	if _, err := buf.WriteTo(w); err != nil {
 		logInfo("写入导出压缩包失败: %v", err)
 	}
 }
 
 func (s *webServer) handleImport(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
 		return
 	}
 	var req importRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "请求体解析失败: "+err.Error())
-		return
-	}
-	if len(req.IDs) == 0 {
-		writeError(w, http.StatusBadRequest, "请选择至少一条对话")
+		writeErrorDetail(w, http.StatusBadRequest, errCodeInvalidRequest, "请求体解析失败", err)
 		return
 	}
 
 	ctx := r.Context()
-	seen := make(map[string]struct{})
-	var exports []exportConversation
-	var skipped []string
-
-	for _, rawID := range req.IDs {
-		id := strings.TrimSpace(rawID)
-		if id == "" {
-			continue
-		}
-		if _, ok := seen[id]; ok {
-			continue
-		}
-		seen[id] = struct{}{}
+	cfg := s.configSnapshot()
+	target := strings.TrimSpace(req.Target)
+	if target == "" {
+		target = cfg.ExportTarget
+	}
+	target = normalizeExportTarget(target)
 
-		conv, err := s.loadExportConversation(ctx, id, true)
+	if len(req.IDs) == 0 && len(req.Items) == 0 && req.Filter != nil {
+		ids, err := s.resolveImportFilter(ctx, *req.Filter, req.Limit, target)
 		if err != nil {
-			writeError(w, http.StatusBadGateway, fmt.Sprintf("获取对话 %s 详情失败: %v", id, err))
+			writeErrorDetail(w, http.StatusBadRequest, errCodeInvalidRequest, "解析筛选条件失败", err)
 			return
 		}
-		if len(conv.Messages) == 0 {
-			skipped = append(skipped, id)
-			continue
+		logInfo("筛选条件匹配 %d 条对话", len(ids))
+		if len(ids) == 0 {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "没有符合筛选条件的对话")
+			return
 		}
-		exports = append(exports, conv)
+		req.IDs = ids
 	}
-
-	if len(exports) == 0 {
-		writeError(w, http.StatusBadRequest, "选中的对话没有可导出的消息")
+	order, groups := groupImportItems(req.IDs, req.Items, target)
+	if len(order) == 0 {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "请选择至少一条对话")
 		return
 	}
 
-	cfg := s.configSnapshot()
-	target := strings.TrimSpace(req.Target)
-	if target == "" {
-		target = cfg.ExportTarget
+	exporters := make(map[string]targets.Exporter, len(order))
+	labels := make(map[string]string, len(order))
+	for _, name := range order {
+		exporter, label, err := s.resolveExporter(name)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, errCodeTargetMisconfigured, err.Error())
+			return
+		}
+		exporters[name] = exporter
+		labels[name] = label
 	}
-	target = normalizeExportTarget(target)
-
-	logInfo("Web 导入触发: 选中=%d 有效=%d 目标=%s", len(req.IDs), len(exports), target)
 
+	target = strings.Join(order, ",")
+	total := 0
+	for _, name := range order {
+		total += len(groups[name])
+	}
+	logInfo("Web 导入触发: 选中=%d 目标=%s", total, target)
+	job := s.jobs.start("import", target)
 	var (
-		created     int
-		pages       []string
+		result      syncResult
 		syncErr     error
-		targetLabel = target
+		failedLabel string
 	)
-
-	switch target {
-	case exportTargetAnytype:
-		targetLabel = "Anytype"
-		client, err := s.resolveAnytypeClient()
-		if err != nil {
-			writeError(w, http.StatusBadRequest, err.Error())
-			return
+	for _, name := range order {
+		part, err := s.syncConversations(ctx, job, name, labels[name], exporters[name], s.fetchExportConversation, groups[name], cfg.OutputTimezone)
+		s.recordSyncResult(name, part)
+		job.recordSync(name, part)
+		if failedLabel == "" && len(part.Failed) > 0 {
+			failedLabel = labels[name]
 		}
-		created, syncErr = syncConversationsToAnytype(ctx, client, exports, cfg.OutputTimezone)
-	case exportTargetNotion:
-		targetLabel = "Notion"
-		client, err := s.resolveNotionClient()
+		result.Exported = append(result.Exported, part.Exported...)
+		result.Failed = append(result.Failed, part.Failed...)
+		result.Skipped = append(result.Skipped, part.Skipped...)
+		result.Unavailable = append(result.Unavailable, part.Unavailable...)
 		if err != nil {
-			writeError(w, http.StatusBadRequest, err.Error())
-			return
+			logInfo("导入 %s 失败: %v", labels[name], err)
+			syncErr = err
+			break
+		}
+	}
+	s.jobs.finish(job, syncErr, s.locationSnapshot())
+	if len(result.Exported) == 0 && len(result.Failed) > 0 {
+		if first := result.Failed[0]; first.fetch {
+			writeAPIError(w, chatgptError(fmt.Sprintf("获取对话 %s 详情失败", first.ConversationID), first.err))
+		} else {
+			writeAPIError(w, targetError(fmt.Sprintf("导入 %s 失败", failedLabel), first.err))
 		}
-		created, pages, syncErr = syncConversationsToNotion(ctx, client, exports, cfg.OutputTimezone)
-	default:
-		writeError(w, http.StatusBadRequest, fmt.Sprintf("不支持的导出目标: %s", target))
 		return
 	}
-
-	if syncErr != nil {
-		logInfo("导入 %s 失败: %v", targetLabel, syncErr)
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("导入 %s 失败: %v", targetLabel, syncErr))
+	if len(result.Exported) == 0 && len(result.Unavailable) > 0 {
+		first := result.Unavailable[0]
+		writeError(w, unavailableStatus(first), first.Code, fmt.Sprintf("对话 %s 无法导出: %s", first.ConversationID, first.Reason))
+		return
+	}
+	if len(result.Exported) == 0 {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "选中的对话没有可导出的消息")
 		return
 	}
 
+	pages := make([]string, 0, len(result.Exported))
+	for _, item := range result.Exported {
+		pages = append(pages, item.ObjectID)
+	}
 	response := map[string]interface{}{
-		"created": created,
-		"skipped": skipped,
+		"created": len(result.Exported),
+		"skipped": result.Skipped,
 		"target":  target,
+		"job_id":  job.ID,
+		"results": result.Exported,
 	}
 	if len(pages) > 0 {
 		response["pages"] = pages
 	}
-	writeJSON(w, http.StatusOK, response)
-}
-
-func (s *webServer) handleDelete(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-		return
+	if len(result.Failed) > 0 {
+		response["failed"] = result.Failed
 	}
-	cfg := s.configSnapshot()
-	token := strings.TrimSpace(cfg.Token)
-	if token == "" {
-		writeError(w, http.StatusBadRequest, "缺少 OpenAI Token, 请先在配置页填写")
-		return
+	if len(result.Unavailable) > 0 {
+		response["unavailable"] = result.Unavailable
 	}
-	var req deleteRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "请求体解析失败: "+err.Error())
-		return
+	if drifts := job.schemaDrifts(); len(drifts) > 0 {
+		response["schema_drifts"] = drifts
 	}
-	if len(req.IDs) == 0 {
-		writeError(w, http.StatusBadRequest, "请选择至少一条对话")
-		return
+	if warnings := job.lintWarnings(); len(warnings) > 0 {
+		response["lint_warnings"] = warnings
 	}
+	writeJSON(w, http.StatusOK, response)
+}
 
-	ctx := r.Context()
-	seen := make(map[string]struct{})
-	var deleted []string
-
-	for _, rawID := range req.IDs {
-		id := strings.TrimSpace(rawID)
+// groupImportItems 按目标分组待导出的对话: ids 与未指定目标的 items 使用 defaultTarget。同一目标中的同一对话
+// 只导出一次, items 中的参数优先。返回按首次出现排序的目标。
+func groupImportItems(ids []string, items []importItem, defaultTarget string) ([]string, map[string][]exportItem) {
+	var order []string
+	groups := make(map[string][]exportItem)
+	index := make(map[[2]string]int)
+	add := func(target string, item exportItem, replace bool) {
+		key := [2]string{target, item.ID}
+		if idx, ok := index[key]; ok {
+			if replace {
+				groups[target][idx] = item
+			}
+			return
+		}
+		if _, ok := groups[target]; !ok {
+			order = append(order, target)
+		}
+		index[key] = len(groups[target])
+		groups[target] = append(groups[target], item)
+	}
+	for _, item := range exportItemsFromIDs(ids) {
+		add(defaultTarget, item, false)
+	}
+	for _, raw := range items {
+		id := strings.TrimSpace(raw.ID)
 		if id == "" {
 			continue
 		}
-		if _, ok := seen[id]; ok {
-			continue
+		target := defaultTarget
+		if strings.TrimSpace(raw.Target) != "" {
+			target = normalizeExportTarget(raw.Target)
 		}
-		seen[id] = struct{}{}
-
-		if err := deleteConversation(ctx, cfg, token, id); err != nil {
-			writeError(w, http.StatusBadGateway, fmt.Sprintf("删除对话 %s 失败: %v", id, err))
-			return
+		var tags []string
+		for _, tag := range raw.Tags {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
 		}
-		s.removeDetailCache(id)
-		deleted = append(deleted, id)
-	}
-
-	if len(deleted) == 0 {
-		writeError(w, http.StatusBadRequest, "没有有效的对话可删除")
-		return
+		add(target, exportItem{ID: id, CustomTitle: strings.TrimSpace(raw.Title), Tags: tags}, true)
 	}
-
-	s.invalidateConversationCache()
-	logInfo("Web 删除触发: 删除成功=%d", len(deleted))
-
-	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"deleted": deleted,
-		"count":   len(deleted),
-	})
+	return order, groups
 }
 
-func (s *webServer) getConversationPage(ctx context.Context, offset, limit int, force bool) (*conversationListResponse, error) {
+func (s *webServer) getConversationPage(ctx context.Context, offset, limit int, force bool) (*client.ConversationPage, error) {
 	key := convPageKey{offset: offset, limit: limit}
 
 	if !force {
@@ -971,15 +2019,18 @@ func (s *webServer) getConversationPage(ctx context.Context, offset, limit int,
 	cfg := s.configSnapshot()
 	token := strings.TrimSpace(cfg.Token)
 	if token == "" {
-		return nil, errors.New("缺少 OpenAI Token, 请先在配置页填写")
+		return nil, errChatGPTTokenMissing
 	}
 
-	page, err := fetchConversationPage(ctx, cfg, token, offset, limit)
+	opts := listOptions(cfg)
+	opts.Offset, opts.Limit = offset, limit
+	page, err := newChatGPTClient(cfg, token).ListConversations(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	cloned := cloneConversationPage(page)
+	s.indexConversations(ctx, page.Items)
 
 	s.cacheMu.Lock()
 	s.pageCache[key] = conversationPageCacheEntry{
@@ -991,9 +2042,9 @@ func (s *webServer) getConversationPage(ctx context.Context, offset, limit int,
 	return cloned, nil
 }
 
-func (s *webServer) loadExportConversation(ctx context.Context, id string, force bool) (exportConversation, error) {
+func (s *webServer) loadExportConversation(ctx context.Context, id string, force bool) (export.Conversation, error) {
 	if strings.TrimSpace(id) == "" {
-		return exportConversation{}, errors.New("缺少对话 ID")
+		return export.Conversation{}, errors.New("缺少对话 ID")
 	}
 
 	if force {
@@ -1005,25 +2056,52 @@ func (s *webServer) loadExportConversation(ctx context.Context, id string, force
 	if !force {
 		s.detailMu.RLock()
 		if entry, ok := s.detailCache[id]; ok && time.Since(entry.fetched) < detailCacheTTL {
-			export := entry.export
+			conv := entry.conv
 			s.detailMu.RUnlock()
-			return export, nil
+			return conv, nil
 		}
 		s.detailMu.RUnlock()
 	}
 
-	cfg := s.configSnapshot()
-	token := strings.TrimSpace(cfg.Token)
-	if token == "" {
-		return exportConversation{}, errors.New("缺少 OpenAI Token, 请先在配置页填写")
+	conv, err := s.fetchExportConversation(ctx, id)
+	if err != nil {
+		return export.Conversation{}, err
 	}
+	// 预览等接口不需要原始 JSON, 不留在缓存中。
+	conv.Raw = nil
 
-	detail, err := fetchConversationDetail(ctx, cfg, token, id)
-	if err != nil {
-		return exportConversation{}, err
+	s.detailMu.Lock()
+	s.detailCache[id] = detailCacheEntry{
+		conv:    conv,
+		fetched: time.Now(),
 	}
+	s.detailMu.Unlock()
+
+	return conv, nil
+}
 
-	meta := conversationMeta{
+// fetchExportConversation 拉取并归一化对话详情, 不读写详情缓存。导出任务逐条调用,
+// 避免大批量任务把所有对话留在缓存中。
+func (s *webServer) fetchExportConversation(ctx context.Context, id string) (export.Conversation, error) {
+	cfg := s.configSnapshot()
+	// 导入过官方导出数据时直接使用本地副本, 对话之后又有更新时拉取接口内容并按策略合并。
+	local, stale := s.localConversation(ctx, id)
+	detail := local
+	if local == nil || stale {
+		remote, err := s.fetchRemoteConversation(ctx, cfg, id)
+		switch {
+		case err == nil && local != nil:
+			detail = s.mergeArchiveConversation(ctx, normalizeArchiveMerge(cfg.ArchiveMerge), local, remote)
+		case err == nil:
+			detail = remote
+		case local != nil:
+			logInfo("拉取对话 %s 最新内容失败, 使用本地归档: %v", id, err)
+		default:
+			return export.Conversation{}, err
+		}
+	}
+
+	meta := client.ConversationMeta{
 		ID:         firstNonEmpty(detail.ID, id),
 		Title:      detail.Title,
 		CreateTime: detail.CreateTime,
@@ -1036,21 +2114,41 @@ func (s *webServer) loadExportConversation(ctx context.Context, id string, force
 		}
 	}
 
-	export := buildExportConversation(meta, detail)
+	if len(detail.Raw) == 0 {
+		// 合并本地归档与接口内容后没有对应的原始 JSON, 按解析后的结构重新编码。
+		if raw, err := json.Marshal(detail); err == nil {
+			detail.Raw = raw
+		}
+	}
 
-	s.detailMu.Lock()
-	s.detailCache[id] = detailCacheEntry{
-		export:  export,
-		fetched: time.Now(),
+	conv := export.Build(meta, detail)
+	if !cfg.IncludeContext {
+		conv.Context = nil
 	}
-	s.detailMu.Unlock()
+	if cfg.ProjectTags {
+		conv.Project = s.projectName(ctx, cfg, detail.GizmoID)
+	}
+	return conv, nil
+}
 
-	return export, nil
+func (s *webServer) fetchRemoteConversation(ctx context.Context, cfg *cliConfig, id string) (*client.Conversation, error) {
+	token := strings.TrimSpace(cfg.Token)
+	if token == "" {
+		return nil, errChatGPTTokenMissing
+	}
+	detail, err := newChatGPTClient(cfg, token).Conversation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if detail.Drift != nil {
+		s.captureSchemaDrift(id, detail)
+	}
+	return detail, nil
 }
 
-func (s *webServer) lookupConversationMeta(id string) (conversationMeta, bool) {
+func (s *webServer) lookupConversationMeta(id string) (client.ConversationMeta, bool) {
 	if strings.TrimSpace(id) == "" {
-		return conversationMeta{}, false
+		return client.ConversationMeta{}, false
 	}
 	s.cacheMu.RLock()
 	defer s.cacheMu.RUnlock()
@@ -1067,7 +2165,7 @@ func (s *webServer) lookupConversationMeta(id string) (conversationMeta, bool) {
 			}
 		}
 	}
-	return conversationMeta{}, false
+	return client.ConversationMeta{}, false
 }
 
 func (s *webServer) invalidateConversationCache() {
@@ -1089,6 +2187,9 @@ func (s *webServer) clearDetailCache() {
 	s.detailMu.Lock()
 	s.detailCache = make(map[string]detailCacheEntry)
 	s.detailMu.Unlock()
+	s.previewMu.Lock()
+	s.previewCache = make(map[string]previewCacheEntry)
+	s.previewMu.Unlock()
 }
 
 func (s *webServer) resetExportClients() {
@@ -1099,6 +2200,14 @@ func (s *webServer) resetExportClients() {
 	s.notionClientMu.Lock()
 	s.notionClient = nil
 	s.notionClientMu.Unlock()
+
+	s.gdriveClientMu.Lock()
+	s.gdriveClient = nil
+	s.gdriveClientMu.Unlock()
+
+	s.onenoteClientMu.Lock()
+	s.onenoteClient = nil
+	s.onenoteClientMu.Unlock()
 }
 
 func (s *webServer) configSnapshot() *cliConfig {
@@ -1114,52 +2223,212 @@ func (s *webServer) locationSnapshot() *time.Location {
 	return s.location
 }
 
-func (s *webServer) formatMessageTimestamp(msg exportMessage) string {
+func (s *webServer) formatMessageTimestamp(msg export.Message) string {
 	loc := s.locationSnapshot()
+	format := s.configSnapshot().TimeFormat
 	if msg.CreateTime > 0 {
-		return formatTimestamp(msg.CreateTime, loc)
+		return export.FormatTimestampAs(msg.CreateTime, loc, format)
 	}
 	if msg.UpdateTime > 0 {
-		return formatTimestamp(msg.UpdateTime, loc)
+		return export.FormatTimestampAs(msg.UpdateTime, loc, format)
 	}
 	return "-"
 }
 
-func (s *webServer) resolveAnytypeClient() (*anytypeClient, error) {
+func (s *webServer) resolveAnytypeClient() (*anytype.Client, error) {
 	cfg := s.configSnapshot()
 	s.anyClientMu.Lock()
 	defer s.anyClientMu.Unlock()
 	if s.anyClient != nil {
 		return s.anyClient, nil
 	}
-	client, err := newAnytypeClient(cfg)
+	exporter, err := anytype.New(anytype.Config{
+		Token:   cfg.AnytypeToken,
+		SpaceID: cfg.AnytypeSpaceID,
+		BaseURL: cfg.AnytypeBaseURL,
+		TypeKey: cfg.AnytypeTypeKey,
+		Version: cfg.AnytypeVersion,
+
+		UploadFiles:   cfg.AnytypeUploadFiles,
+		FilesProperty: cfg.AnytypeFilesField,
+	})
 	if err != nil {
 		return nil, err
 	}
-	s.anyClient = client
-	return client, nil
+	exporter.FetchAsset = s.fetchAsset
+	s.anyClient = exporter
+	return exporter, nil
 }
 
-func (s *webServer) resolveNotionClient() (*notionClient, error) {
+func (s *webServer) resolveNotionClient() (*notion.Client, error) {
 	cfg := s.configSnapshot()
 	s.notionClientMu.Lock()
 	defer s.notionClientMu.Unlock()
 	if s.notionClient != nil {
 		return s.notionClient, nil
 	}
-	client, err := newNotionClient(cfg)
+	exporter, err := notion.New(notion.Config{
+		Token:         cfg.NotionToken,
+		ParentID:      cfg.NotionParentID,
+		ParentType:    cfg.NotionParentType,
+		TitleProperty: cfg.NotionTitleProperty,
+		BaseURL:       cfg.NotionBaseURL,
+		Version:       cfg.NotionVersion,
+		MathMode:      cfg.MathMode,
+		ChunkMode:     cfg.NotionChunkMode,
+
+		DraftParentID:   cfg.NotionDraftParentID,
+		DraftParentType: cfg.NotionDraftType,
+		ProjectProperty: cfg.NotionProjectField,
+		URLProperty:     cfg.NotionURLField,
+
+		UploadAudio:       cfg.NotionUploadAudio,
+		UploadAttachments: cfg.NotionUploadFiles,
+	})
+	if err != nil {
+		return nil, err
+	}
+	exporter.FetchAsset = s.fetchAsset
+	s.notionClient = exporter
+	return exporter, nil
+}
+
+// resolveExecClient 创建 exec 目标客户端。命令只能通过启动参数或环境变量指定, 不随 Web 配置保存。
+func (s *webServer) resolveExecClient() (*command.Client, error) {
+	cfg := s.configSnapshot()
+	return command.New(command.Config{
+		Command: cfg.ExecCommand,
+		Timeout: time.Duration(cfg.ExecTimeout) * time.Second,
+	})
+}
+
+func (s *webServer) resolveAirtableClient() (*airtable.Client, error) {
+	cfg := s.configSnapshot()
+	fields, err := airtable.ParseFields(cfg.AirtableFields)
 	if err != nil {
 		return nil, err
 	}
-	s.notionClient = client
-	return client, nil
+	return airtable.New(airtable.Config{
+		Token:    cfg.AirtableToken,
+		BaseID:   cfg.AirtableBaseID,
+		Table:    cfg.AirtableTable,
+		BaseURL:  cfg.AirtableBaseURL,
+		BodyMode: cfg.AirtableBodyMode,
+		Fields:   fields,
+		Filename: filenameOptions(cfg),
+	})
+}
+
+func (s *webServer) resolveTelegramClient() (*telegram.Client, error) {
+	cfg := s.configSnapshot()
+	return telegram.New(telegram.Config{
+		Token:    cfg.TelegramBotToken,
+		ChatID:   cfg.TelegramChatID,
+		Mode:     cfg.TelegramMode,
+		BaseURL:  cfg.TelegramBaseURL,
+		Filename: filenameOptions(cfg),
+	})
+}
+
+func (s *webServer) resolveReadwiseClient() (*readwise.Client, error) {
+	cfg := s.configSnapshot()
+	return readwise.New(readwise.Config{
+		Token:   cfg.ReadwiseToken,
+		Tags:    readwise.ParseTags(cfg.ReadwiseTags),
+		HTML:    export.HTMLOptions{Theme: export.HTMLThemeLight, MathMode: cfg.MathMode, RenderDiagrams: cfg.RenderDiagrams, RenderSVG: s.svgRenderer(cfg)},
+		BaseURL: cfg.ReadwiseBaseURL,
+	})
+}
+
+func (s *webServer) resolveMemosClient() (*memos.Client, error) {
+	cfg := s.configSnapshot()
+	return memos.New(memos.Config{
+		BaseURL:    cfg.MemosBaseURL,
+		Token:      cfg.MemosToken,
+		Visibility: cfg.MemosVisibility,
+		Tags:       memos.ParseTags(cfg.MemosTags),
+	})
+}
+
+func (s *webServer) resolveTriliumClient() (*trilium.Client, error) {
+	cfg := s.configSnapshot()
+	return trilium.New(trilium.Config{
+		BaseURL:      cfg.TriliumBaseURL,
+		Token:        cfg.TriliumToken,
+		ParentNoteID: cfg.TriliumParentNoteID,
+		HTML:         export.HTMLOptions{MathMode: cfg.MathMode, RenderDiagrams: cfg.RenderDiagrams, RenderSVG: s.svgRenderer(cfg)},
+	})
+}
+
+func (s *webServer) resolveOneNoteClient() (*onenote.Client, error) {
+	cfg := s.configSnapshot()
+	s.onenoteClientMu.Lock()
+	defer s.onenoteClientMu.Unlock()
+	if s.onenoteClient != nil {
+		return s.onenoteClient, nil
+	}
+	exporter, err := onenote.New(onenote.Config{
+		Tenant:       cfg.OneNoteTenant,
+		ClientID:     cfg.OneNoteClientID,
+		ClientSecret: cfg.OneNoteClientSecret,
+		RefreshToken: cfg.OneNoteRefreshToken,
+		Token:        cfg.OneNoteToken,
+		Notebook:     cfg.OneNoteNotebook,
+		Section:      cfg.OneNoteSection,
+		BaseURL:      cfg.OneNoteBaseURL,
+		HTML:         export.HTMLOptions{MathMode: cfg.MathMode},
+	})
+	if err != nil {
+		return nil, err
+	}
+	exporter.FetchAsset = s.fetchAsset
+	s.onenoteClient = exporter
+	return exporter, nil
+}
+
+// resolveMarkdownClient 创建 markdown 目标客户端, 文件名与日期目录沿用导出压缩包的设置。
+func (s *webServer) resolveMarkdownClient() (*markdown.Client, error) {
+	cfg := s.configSnapshot()
+	nameOptions := filenameOptions(cfg)
+	nameOptions.Hierarchy = cfg.FileHierarchy
+	nameOptions.Location = s.locationSnapshot()
+	return markdown.New(markdown.Config{
+		Dir:      cfg.OutputPath,
+		Filename: nameOptions,
+		Journal:  s.store,
+	})
+}
+
+func (s *webServer) resolveRawJSONClient() (*rawjson.Client, error) {
+	cfg := s.configSnapshot()
+	return rawjson.New(rawjson.Config{
+		Dir:     cfg.RawOutputPath,
+		Format:  cfg.RawFormat,
+		Journal: s.store,
+	})
+}
+
+func (s *webServer) resolveWebhookClient() (*webhook.Client, error) {
+	cfg := s.configSnapshot()
+	return webhook.New(webhook.Config{
+		URL:      cfg.WebhookURL,
+		Headers:  cfg.WebhookHeaders,
+		Template: cfg.WebhookTemplate,
+		IDPath:   cfg.WebhookIDPath,
+		URLPath:  cfg.WebhookURLPath,
+	})
 }
 
 type apiConversationItem struct {
-	ID         string `json:"id"`
-	Title      string `json:"title"`
-	CreateTime string `json:"create_time"`
-	UpdateTime string `json:"update_time"`
+	ID                 string   `json:"id"`
+	Title              string   `json:"title"`
+	CreateTime         string   `json:"create_time"`
+	UpdateTime         string   `json:"update_time"`
+	CreateTimeRelative string   `json:"create_time_relative,omitempty"`
+	UpdateTimeRelative string   `json:"update_time_relative,omitempty"`
+	LastExportedAt     string   `json:"last_exported_at,omitempty"`
+	ExportTargets      []string `json:"export_targets"`
+	Preview            string   `json:"preview,omitempty"`
 }
 
 type apiMessage struct {
@@ -1167,6 +2436,7 @@ type apiMessage struct {
 	Timestamp  string         `json:"timestamp"`
 	Text       string         `json:"text"`
 	References []apiReference `json:"references,omitempty"`
+	Stats      export.Stats   `json:"stats"`
 }
 
 type apiConversationDetail struct {
@@ -1175,6 +2445,7 @@ type apiConversationDetail struct {
 	CreateTime string       `json:"create_time"`
 	UpdateTime string       `json:"update_time"`
 	Messages   []apiMessage `json:"messages"`
+	Stats      export.Stats `json:"stats"`
 }
 
 type apiReference struct {
@@ -1184,88 +2455,35 @@ type apiReference struct {
 }
 
 type importRequest struct {
-	IDs    []string `json:"ids"`
+	IDs    []string      `json:"ids"`
+	Target string        `json:"target"`
+	Filter *importFilter `json:"filter"`
+	Limit  int           `json:"limit"`
+	// Items 是带单独参数的对话, 可与 IDs 同时使用。
+	Items []importItem `json:"items"`
+}
+
+// importItem 是导出前单独编辑过的对话: Title 覆盖导出的标题, Tags 追加到对话的标签,
+// Target 为空时使用请求的 target。
+type importItem struct {
+	ID     string   `json:"id"`
+	Title  string   `json:"title"`
+	Tags   []string `json:"tags"`
 	Target string   `json:"target"`
 }
 
 type deleteRequest struct {
 	IDs []string `json:"ids"`
+	// Wait 为 true 时等待删除任务结束再返回逐条结果, 否则立即返回任务 ID。
+	Wait bool `json:"wait"`
 }
 
 type exportRequest struct {
-	IDs []string `json:"ids"`
-}
-
-var filenameReplacer = strings.NewReplacer(
-	"/", "-",
-	"\\", "-",
-	":", "-",
-	"*", "-",
-	"?", "-",
-	"\"", "",
-	"<", "(",
-	">", ")",
-	"|", "-",
-	"\n", " ",
-	"\r", " ",
-	"\t", " ",
-)
-
-func buildConversationFilename(conv exportConversation, used map[string]int) string {
-	title := sanitizeFilenamePart(firstNonEmpty(conv.Title, "对话"))
-	idPart := sanitizeFilenamePart(conv.ID)
-	base := strings.TrimSpace(title)
-	if idPart != "" {
-		base = strings.TrimSpace(base + "-" + idPart)
-	}
-	if base == "" {
-		base = "conversation"
-	}
-	base = trimFilename(base, 120)
-	if base == "" {
-		base = "conversation"
-	}
-
-	name := base + ".md"
-	if used == nil {
-		return name
-	}
-	if _, ok := used[name]; !ok {
-		used[name] = 1
-		return name
-	}
-	index := used[name]
-	for {
-		index++
-		candidate := fmt.Sprintf("%s-%d.md", base, index)
-		if _, exists := used[candidate]; !exists {
-			used[name] = index
-			used[candidate] = 1
-			return candidate
-		}
-	}
-}
-
-func sanitizeFilenamePart(input string) string {
-	trimmed := strings.TrimSpace(input)
-	if trimmed == "" {
-		return ""
-	}
-	trimmed = filenameReplacer.Replace(trimmed)
-	trimmed = strings.Join(strings.Fields(trimmed), " ")
-	trimmed = strings.Trim(trimmed, ".-_ ")
-	return trimmed
-}
-
-func trimFilename(input string, maxRunes int) string {
-	if maxRunes <= 0 {
-		return ""
-	}
-	runes := []rune(input)
-	if len(runes) <= maxRunes {
-		return input
-	}
-	return strings.TrimSpace(string(runes[:maxRunes]))
+	IDs    []string `json:"ids"`
+	Format string   `json:"format"`
+	Theme  string   `json:"theme"`
+	// Title 为 epub 格式的书名, 为空时按选中的对话生成。
+	Title string `json:"title"`
 }
 
 func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
@@ -1277,9 +2495,11 @@ func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
 	}
 }
 
-func writeError(w http.ResponseWriter, status int, message string) {
-	if status < 400 {
-		status = http.StatusBadRequest
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
 	}
-	writeJSON(w, status, map[string]string{"error": message})
+	return ""
 }
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandleConfigSecondTimezone(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCode   string
+		want       string
+	}{
+		{name: "IANA 时区", body: `{"second_timezone":" America/New_York "}`, wantStatus: http.StatusOK, want: "America/New_York"},
+		{name: "清空第二时区", body: `{"second_timezone":""}`, wantStatus: http.StatusOK, want: ""},
+		{name: "无法识别的时区", body: `{"second_timezone":"Mars/Olympus"}`, wantStatus: http.StatusBadRequest, wantCode: errCodeInvalidRequest, want: "UTC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &webServer{cfg: &cliConfig{SecondTimezone: "UTC"}}
+			rec := httptest.NewRecorder()
+			s.handleConfig(rec, httptest.NewRequest(http.MethodPost, "/api/config", strings.NewReader(tt.body)))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("状态码 = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if s.cfg.SecondTimezone != tt.want {
+				t.Errorf("second_timezone = %q, want %q", s.cfg.SecondTimezone, tt.want)
+			}
+			if tt.wantCode == "" {
+				return
+			}
+			var body map[string]string
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body["code"] != tt.wantCode {
+				t.Errorf("响应 = %s, want code %s", rec.Body.String(), tt.wantCode)
+			}
+		})
+	}
+}
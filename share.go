@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// shareTargetManifest 是 Web App Manifest: 安装到手机桌面后出现在系统分享菜单中,
+// 分享的链接以 GET /share?title=&text=&url= 的形式送达。
+const shareTargetManifest = `{
+  "name": "ChatGPT 对话导出",
+  "short_name": "对话导出",
+  "start_url": "/",
+  "display": "standalone",
+  "share_target": {
+    "action": "/share",
+    "method": "GET",
+    "params": {"title": "title", "text": "text", "url": "url"}
+  }
+}
+`
+
+// sharedConversationID 从分享内容中找出 ChatGPT 对话链接并提取 ID。Android 通常把链接放在 text 中,
+// 并可能附带标题等文字; 公开分享链接 (/share/) 指向的是快照而不是对话本身, 单独返回 isPublicShare。
+func sharedConversationID(values ...string) (id string, isPublicShare bool) {
+	for _, value := range values {
+		for _, field := range strings.Fields(value) {
+			lower := strings.ToLower(field)
+			if !strings.Contains(lower, "chatgpt.com/") && !strings.Contains(lower, "chat.openai.com/") {
+				continue
+			}
+			if strings.Contains(lower, "/share/") {
+				isPublicShare = true
+				continue
+			}
+			if strings.Contains(lower, "/c/") {
+				if id := quickExportConversationID(field); id != "" {
+					return id, false
+				}
+			}
+		}
+	}
+	return "", isPublicShare
+}
+
+// handleShare 处理 /share: 接收系统分享菜单或书签脚本传来的完整 chatgpt.com 对话链接, 提取 ID 后返回
+// 与 /export 相同的确认页, 确认后才导出。GET 读取查询参数, POST 读取表单 (url、text、title, 可选 target)。
+func (s *webServer) handleShare(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeErrorDetail(w, http.StatusBadRequest, errCodeInvalidRequest, "解析分享内容失败", err)
+		return
+	}
+	id, isPublicShare := sharedConversationID(r.Form.Get("url"), r.Form.Get("text"), r.Form.Get("title"))
+	if id == "" {
+		if isPublicShare {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "公开分享链接 (/share/) 无法导出, 请分享对话本身的链接 (/c/...)")
+			return
+		}
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "分享内容中没有 ChatGPT 对话链接")
+		return
+	}
+	logInfo("收到分享的对话链接: conversation=%s", id)
+	s.renderQuickExportConfirm(w, id, strings.TrimSpace(r.Form.Get("target")))
+}
+
+func (s *webServer) handleManifest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+		return
+	}
+	w.Header().Set("Content-Type", "application/manifest+json")
+	w.Write([]byte(shareTargetManifest))
+}
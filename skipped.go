@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Devoty/openai-backup/export"
+)
+
+type jobSkippedMessage struct {
+	ConversationID string `json:"conversation_id"`
+	export.SkippedMessage
+}
+
+// recordSkippedMessages 汇总本次任务中对话被过滤的消息。
+func (j *exportJob) recordSkippedMessages(conv export.Conversation) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, item := range conv.Skipped {
+		j.SkippedMessages = append(j.SkippedMessages, jobSkippedMessage{ConversationID: conv.ID, SkippedMessage: item})
+	}
+}
+
+func renderSkippedMessagesMarkdown(items []jobSkippedMessage) string {
+	if len(items) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("\n## 被过滤的消息 (%d)\n\n", len(items)))
+	b.WriteString("| 对话 ID | 消息 ID | 角色 | 原因 | 内容预览 |\n")
+	b.WriteString("| --- | --- | --- | --- | --- |\n")
+	for _, item := range items {
+		role := item.Role
+		if item.Recipient != "" {
+			role += " → " + item.Recipient
+		}
+		b.WriteString(fmt.Sprintf("| `%s` | `%s` | %s | %s | %s |\n",
+			item.ConversationID,
+			item.MessageID,
+			escapeMarkdownTableCell(role),
+			item.Reason,
+			firstNonEmpty(escapeMarkdownTableCell(item.Preview), "-"),
+		))
+	}
+	return b.String()
+}
+
+// handleSkippedMessages 处理 GET /api/debug/skipped?id=xxx, 列出对话中被过滤的消息及原因。
+func (s *webServer) handleSkippedMessages(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+		return
+	}
+	id := strings.TrimSpace(r.URL.Query().Get("id"))
+	if id == "" {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "缺少对话 ID")
+		return
+	}
+	conv, err := s.loadExportConversation(r.Context(), id, r.URL.Query().Get("refresh") == "1")
+	if err != nil {
+		writeAPIError(w, chatgptError("获取对话详情失败", err))
+		return
+	}
+	skipped := conv.Skipped
+	if skipped == nil {
+		skipped = []export.SkippedMessage{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"conversation_id": conv.ID,
+		"title":           conv.Title,
+		"exported":        len(conv.Messages),
+		"skipped":         skipped,
+	})
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Devoty/openai-backup/export"
+)
+
+const (
+	// defaultSpillThresholdMB 是单个任务在内存中保留中间结果的默认上限。
+	defaultSpillThresholdMB = 64
+	spillDirName            = "openai-backup-spill"
+	// spillPIDFile 记录溢出目录所属进程的 PID。
+	spillPIDFile = "owner.pid"
+)
+
+// spillThresholdBytes 返回配置的溢出阈值, 未配置时使用默认值。
+func spillThresholdBytes(cfg *cliConfig) int64 {
+	mb := cfg.SpillThresholdMB
+	if mb <= 0 {
+		mb = defaultSpillThresholdMB
+	}
+	return int64(mb) << 20
+}
+
+// spillBase 是各进程溢出目录的父目录, 位于系统临时目录下, 同一台机器上的多个实例共用。
+func spillBase() string {
+	return filepath.Join(os.TempDir(), spillDirName)
+}
+
+var (
+	spillOnce    sync.Once
+	spillRootDir string
+	spillRootErr error
+)
+
+// spillRoot 返回本进程的溢出目录, 首次调用时在 spillBase 下创建并写入本进程的 PID,
+// 其他实例据此判断目录是否仍在使用, 见 cleanupSpillRoot。
+func spillRoot() (string, error) {
+	spillOnce.Do(func() {
+		if err := os.MkdirAll(spillBase(), 0o700); err != nil {
+			spillRootErr = fmt.Errorf("创建溢出目录失败: %w", err)
+			return
+		}
+		dir, err := os.MkdirTemp(spillBase(), "proc-*")
+		if err != nil {
+			spillRootErr = fmt.Errorf("创建溢出目录失败: %w", err)
+			return
+		}
+		if err := os.WriteFile(filepath.Join(dir, spillPIDFile), []byte(strconv.Itoa(os.Getpid())), 0o600); err != nil {
+			os.RemoveAll(dir)
+			spillRootErr = fmt.Errorf("创建溢出目录失败: %w", err)
+			return
+		}
+		spillRootDir = dir
+	})
+	return spillRootDir, spillRootErr
+}
+
+// cleanupSpillRoot 删除进程异常退出时遗留的溢出目录, 启动时调用。只删除记录的 PID 已不存在的目录,
+// 不触及仍在运行的其他实例的文件; 无法判断进程是否存在的平台上不删除。
+func cleanupSpillRoot() {
+	entries, err := os.ReadDir(spillBase())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logInfo("读取溢出目录失败: %v", err)
+		}
+		return
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(spillBase(), entry.Name())
+		data, err := os.ReadFile(filepath.Join(dir, spillPIDFile))
+		if err != nil {
+			continue
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil || pid == os.Getpid() || processAlive(pid) {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			logInfo("清理溢出目录失败: %s err=%v", dir, err)
+			continue
+		}
+		logInfo("已清理退出进程 (PID %d) 遗留的溢出目录: %s", pid, dir)
+	}
+}
+
+// removeSpillRoot 在进程退出前删除本进程的溢出目录。
+func removeSpillRoot() {
+	if spillRootDir == "" {
+		return
+	}
+	if err := os.RemoveAll(spillRootDir); err != nil {
+		logInfo("删除溢出目录失败: %v", err)
+	}
+}
+
+// spillBuffer 先在内存中累积写入的数据, 超过阈值后整体转存到临时文件, Close 时删除。
+type spillBuffer struct {
+	threshold int64
+	mem       bytes.Buffer
+	file      *os.File
+	size      int64
+}
+
+func newSpillBuffer(threshold int64) *spillBuffer {
+	return &spillBuffer{threshold: threshold}
+}
+
+func (b *spillBuffer) Write(p []byte) (int, error) {
+	if b.file == nil && int64(b.mem.Len()+len(p)) > b.threshold {
+		if err := b.spill(); err != nil {
+			return 0, err
+		}
+	}
+	var (
+		n   int
+		err error
+	)
+	if b.file != nil {
+		n, err = b.file.Write(p)
+	} else {
+		n, err = b.mem.Write(p)
+	}
+	b.size += int64(n)
+	return n, err
+}
+
+func (b *spillBuffer) spill() error {
+	root, err := spillRoot()
+	if err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(root, "buffer-*")
+	if err != nil {
+		return fmt.Errorf("创建溢出文件失败: %w", err)
+	}
+	if _, err := b.mem.WriteTo(file); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return fmt.Errorf("写入溢出文件失败: %w", err)
+	}
+	b.file = file
+	logInfo("任务数据超过 %d MB, 转存到磁盘: %s", b.threshold>>20, file.Name())
+	return nil
+}
+
+// Size 返回已写入的总字节数。
+func (b *spillBuffer) Size() int64 {
+	return b.size
+}
+
+// WriteTo 把全部内容写入 w, 调用后不应继续写入。
+func (b *spillBuffer) WriteTo(w io.Writer) (int64, error) {
+	if b.file == nil {
+		return b.mem.WriteTo(w)
+	}
+	if _, err := b.file.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.Copy(w, b.file)
+}
+
+func (b *spillBuffer) Close() error {
+	b.mem.Reset()
+	if b.file == nil {
+		return nil
+	}
+	b.file.Close()
+	err := os.Remove(b.file.Name())
+	b.file = nil
+	return err
+}
+
+// spillStore 按键保存任务的中间结果 (JSON), 内存中累计超过阈值后新写入的条目存为临时文件。
+// 同一任务内的多次读取 (如多目标导出) 不必重复拉取; Close 时删除全部临时文件。
+type spillStore struct {
+	threshold int64
+	memBytes  int64
+	mem       map[string][]byte
+	dir       string
+	files     map[string]string
+	seq       int
+}
+
+func newSpillStore(threshold int64) *spillStore {
+	return &spillStore{threshold: threshold, mem: make(map[string][]byte), files: make(map[string]string)}
+}
+
+func (s *spillStore) put(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("序列化中间结果失败: %w", err)
+	}
+	s.remove(key)
+	if s.memBytes+int64(len(data)) <= s.threshold {
+		s.mem[key] = data
+		s.memBytes += int64(len(data))
+		return nil
+	}
+	if s.dir == "" {
+		root, err := spillRoot()
+		if err != nil {
+			return err
+		}
+		dir, err := os.MkdirTemp(root, "store-*")
+		if err != nil {
+			return fmt.Errorf("创建溢出目录失败: %w", err)
+		}
+		s.dir = dir
+		logInfo("任务中间结果超过 %d MB, 后续条目写入磁盘: %s", s.threshold>>20, dir)
+	}
+	s.seq++
+	path := filepath.Join(s.dir, strconv.Itoa(s.seq)+".json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("写入溢出文件失败: %w", err)
+	}
+	s.files[key] = path
+	return nil
+}
+
+// get 读取 key 对应的条目, 不存在时返回 false。
+func (s *spillStore) get(key string, value interface{}) (bool, error) {
+	data, ok := s.mem[key]
+	if !ok {
+		path, onDisk := s.files[key]
+		if !onDisk {
+			return false, nil
+		}
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return false, fmt.Errorf("读取溢出文件失败: %w", err)
+		}
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return false, fmt.Errorf("解析中间结果失败: %w", err)
+	}
+	return true, nil
+}
+
+// remove 删除 key 对应的条目, 释放内存或临时文件。
+func (s *spillStore) remove(key string) {
+	if data, ok := s.mem[key]; ok {
+		s.memBytes -= int64(len(data))
+		delete(s.mem, key)
+	}
+	if path, ok := s.files[key]; ok {
+		os.Remove(path)
+		delete(s.files, key)
+	}
+}
+
+func (s *spillStore) Close() error {
+	s.mem, s.memBytes = make(map[string][]byte), 0
+	s.files = make(map[string]string)
+	if s.dir == "" {
+		return nil
+	}
+	err := os.RemoveAll(s.dir)
+	s.dir = ""
+	return err
+}
+
+// spilledConversation 是 sharedFetcher 暂存的对话; export.Conversation 不序列化 Raw, 需单独保存。
+type spilledConversation struct {
+	Conversation export.Conversation `json:"conversation"`
+	Raw          json.RawMessage     `json:"raw,omitempty"`
+}
+
+// sharedFetcher 在 fetchExportConversation 之外加一层 spillStore: uses 记录每个对话还会被读取的次数,
+// 仍有后续读取的对话拉取后暂存, 最后一次读取后删除。调用方需顺序调用。
+func (s *webServer) sharedFetcher(store *spillStore, uses map[string]int) conversationFetcher {
+	return func(ctx context.Context, id string) (export.Conversation, error) {
+		uses[id]--
+		var spilled spilledConversation
+		ok, err := store.get(id, &spilled)
+		if err != nil {
+			logInfo("读取暂存对话失败, 重新拉取: conversation=%s err=%v", id, err)
+		}
+		conv := spilled.Conversation
+		conv.Raw = spilled.Raw
+		if !ok || err != nil {
+			if conv, err = s.fetchExportConversation(ctx, id); err != nil {
+				return export.Conversation{}, err
+			}
+		}
+		if uses[id] > 0 {
+			if !ok {
+				if err := store.put(id, spilledConversation{Conversation: conv, Raw: conv.Raw}); err != nil {
+					logInfo("暂存对话失败: conversation=%s err=%v", id, err)
+				}
+			}
+		} else {
+			store.remove(id)
+		}
+		return conv, nil
+	}
+}
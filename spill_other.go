@@ -0,0 +1,8 @@
+//go:build !unix
+
+package main
+
+// processAlive 在其他平台上无法判断进程是否存在, 一律视为运行中, 不清理其他进程的溢出目录。
+func processAlive(pid int) bool {
+	return true
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestCleanupSpillRoot(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	// 超出 Linux pid_max 上限的 PID 不可能存在。
+	const deadPID = 1 << 23
+	if processAlive(deadPID) {
+		t.Skip("当前平台无法判断进程是否存在")
+	}
+	tests := []struct {
+		name string
+		pid  string
+		keep bool
+	}{
+		{name: "proc-dead", pid: strconv.Itoa(deadPID), keep: false},
+		{name: "proc-self", pid: strconv.Itoa(os.Getpid()), keep: true},
+		{name: "proc-parent", pid: strconv.Itoa(os.Getppid()), keep: true},
+		{name: "proc-garbled", pid: "not-a-pid", keep: true},
+		{name: "legacy", keep: true},
+	}
+	for _, tt := range tests {
+		dir := filepath.Join(spillBase(), tt.name)
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if tt.pid != "" {
+			if err := os.WriteFile(filepath.Join(dir, spillPIDFile), []byte(tt.pid), 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.WriteFile(filepath.Join(dir, "buffer-1"), []byte("data"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cleanupSpillRoot()
+
+	for _, tt := range tests {
+		_, err := os.Stat(filepath.Join(spillBase(), tt.name))
+		if kept := err == nil; kept != tt.keep {
+			t.Errorf("%s: kept=%v, want %v", tt.name, kept, tt.keep)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// chatgptProbeTTL 是 ChatGPT 鉴权检查结果的缓存时间, 监控频繁轮询 /api/status 时不会每次都请求 ChatGPT。
+const chatgptProbeTTL = 5 * time.Minute
+
+// chatgptStatus 是最近一次 ChatGPT 鉴权检查的结果, State 为 ok 或 chatgptError 给出的错误码。
+type chatgptStatus struct {
+	State     string    `json:"state"`
+	Message   string    `json:"message,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+	// token 为检查时使用的 Token, 修改 Token 后重新检查; 不输出。
+	token string
+}
+
+// targetHealth 是一个导出目标的配置与熔断状态。
+type targetHealth struct {
+	targetStatus
+	Configured bool   `json:"configured"`
+	Default    bool   `json:"default"`
+	ConfigErr  string `json:"config_error,omitempty"`
+}
+
+// lastJobStatus 是最近结束的任务, 只包含汇总信息。
+type lastJobStatus struct {
+	ID         string     `json:"id"`
+	Kind       string     `json:"kind"`
+	Target     string     `json:"target"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	Summary    jobSummary `json:"summary"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// latestFinished 返回最近结束的任务与正在执行的任务数; 任务记录只保存在内存中, 重启后为空。
+func (m *jobManager) latestFinished() (*exportJob, int) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var latest *exportJob
+	running := 0
+	for i := len(m.order) - 1; i >= 0; i-- {
+		job := m.jobs[m.order[i]].snapshot()
+		if job.FinishedAt == nil {
+			running++
+		} else if latest == nil || job.FinishedAt.After(*latest.FinishedAt) {
+			latest = job
+		}
+	}
+	return latest, running
+}
+
+// chatgptAuthStatus 请求一条对话列表检查 Token 是否有效, 结果缓存 chatgptProbeTTL; refresh 为 true 时重新检查。
+func (s *webServer) chatgptAuthStatus(ctx context.Context, refresh bool) chatgptStatus {
+	cfg := s.configSnapshot()
+	token := strings.TrimSpace(cfg.Token)
+	if token == "" {
+		return chatgptStatus{State: errCodeChatGPTTokenMissing, Message: errChatGPTTokenMissing.Error(), CheckedAt: time.Now()}
+	}
+	s.statusMu.Lock()
+	cached := s.chatgptProbe
+	s.statusMu.Unlock()
+	if !refresh && cached != nil && cached.token == token && time.Since(cached.CheckedAt) < chatgptProbeTTL {
+		return *cached
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	status := chatgptStatus{State: "ok", CheckedAt: time.Now(), token: token}
+	opts := listOptions(cfg)
+	opts.Limit = 1
+	if _, err := newChatGPTClient(cfg, token).ListConversations(ctx, opts); err != nil {
+		apiErr := chatgptError("检查 ChatGPT Token 失败", err)
+		status.State, status.Message = apiErr.Code, apiErr.Error()
+	}
+	s.statusMu.Lock()
+	s.chatgptProbe = &status
+	s.statusMu.Unlock()
+	return status
+}
+
+// handleStatus 处理 GET /api/status: 汇总 ChatGPT 鉴权、导出目标、导出队列、最近的任务、缓存、数据库与版本,
+// healthy 为 false 时 problems 列出原因。加 ?refresh=1 重新检查 ChatGPT Token; 开启 update_check 时 build.update 给出新版本。
+func (s *webServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+		return
+	}
+	ctx := r.Context()
+	cfg := s.configSnapshot()
+	var problems []string
+
+	chatgpt := s.chatgptAuthStatus(ctx, r.URL.Query().Get("refresh") == "1")
+	if chatgpt.State != "ok" {
+		problems = append(problems, "ChatGPT: "+chatgpt.Message)
+	}
+
+	defaultTarget := normalizeExportTarget(cfg.ExportTarget)
+	names := []string{exportTargetAnytype, exportTargetNotion, exportTargetAirtable, exportTargetGDrive, exportTargetTelegram, exportTargetReadwise, exportTargetMemos, exportTargetTrilium, exportTargetOneNote, exportTargetMarkdown, exportTargetJSON, exportTargetExec, exportTargetWebhook}
+	targetsHealth := make([]targetHealth, 0, len(names))
+	for _, name := range names {
+		item := targetHealth{targetStatus: s.targetBreaker(name).status(), Default: name == defaultTarget}
+		_, label, err := s.resolveExporter(name)
+		item.Configured = err == nil
+		if err != nil {
+			item.ConfigErr = err.Error()
+		}
+		if item.Default && err != nil {
+			problems = append(problems, "默认导出目标 "+label+" 未配置: "+err.Error())
+		}
+		if item.Default && !item.Healthy {
+			problems = append(problems, "默认导出目标 "+label+" 已熔断: "+item.LastError)
+		}
+		targetsHealth = append(targetsHealth, item)
+	}
+
+	queue := s.queueWindowStatus(map[string]interface{}{})
+	if counts, err := s.store.CountQueueEntries(ctx); err == nil {
+		queue["counts"] = counts
+	} else {
+		problems = append(problems, err.Error())
+	}
+
+	var lastJob *lastJobStatus
+	latest, running := s.jobs.latestFinished()
+	if latest != nil {
+		lastJob = &lastJobStatus{ID: latest.ID, Kind: latest.Kind, Target: latest.Target, Status: latest.Status, Error: latest.Error, Summary: latest.Summary, StartedAt: latest.StartedAt, FinishedAt: latest.FinishedAt}
+		if latest.Status == jobStatusFailed {
+			problems = append(problems, "最近的任务 "+latest.ID+" 失败: "+latest.Error)
+		}
+	}
+
+	s.cacheMu.RLock()
+	pages := len(s.pageCache)
+	s.cacheMu.RUnlock()
+	s.detailMu.RLock()
+	details := len(s.detailCache)
+	s.detailMu.RUnlock()
+	s.previewMu.RLock()
+	previews := len(s.previewCache)
+	s.previewMu.RUnlock()
+
+	build := buildInfo()
+	if update := s.checkForUpdate(ctx, false); update.Enabled {
+		build["update"] = update
+	}
+
+	var databases []dbFileInfo
+	for _, item := range s.store.databases() {
+		databases = append(databases, dbFileInfo{Database: item.name, Path: item.db.path, Size: sqliteFileSize(item.db.path)})
+	}
+
+	if problems == nil {
+		problems = []string{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"healthy":  len(problems) == 0,
+		"problems": problems,
+		"chatgpt":  chatgpt,
+		"targets":  targetsHealth,
+		"queue":    queue,
+		"jobs": map[string]interface{}{
+			"running": running,
+			"last":    lastJob,
+		},
+		"caches": map[string]int{
+			"conversation_pages": pages,
+			"details":            details,
+			"previews":           previews,
+		},
+		"databases": databases,
+		"build":     build,
+		"time":      time.Now().In(s.locationSnapshot()),
+	})
+}
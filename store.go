@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 	"os"
@@ -18,8 +17,11 @@ var (
 	errConfigNotFound = errors.New("config not found")
 )
 
+// ConfigStore 管理两个 SQLite 文件: config 保存配置项, archive 保存对话索引、导出状态、
+// 失败队列与抓取进度等随使用增长的数据, 二者的读写互不影响。
 type ConfigStore struct {
-	db *sql.DB
+	config  *sqliteDB
+	archive *sqliteDB
 }
 
 func Init(path string) (*ConfigStore, error) {
@@ -30,22 +32,24 @@ func Init(path string) (*ConfigStore, error) {
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return nil, fmt.Errorf("创建配置目录失败: %w", err)
 	}
-	dsn := fmt.Sprintf("file:%s?_pragma=foreign_keys(ON)&_pragma=journal_mode(WAL)&_pragma=busy_timeout=5000", path)
-	db, err := sql.Open("sqlite", dsn)
+	config, err := openSQLite(path)
 	if err != nil {
 		return nil, fmt.Errorf("打开配置数据库失败: %w", err)
 	}
-	db.SetConnMaxLifetime(0)
-	db.SetMaxOpenConns(1)
-	db.SetMaxIdleConns(1)
+	archive, err := openSQLite(archiveDBPath(path))
+	if err != nil {
+		config.Close()
+		return nil, fmt.Errorf("打开归档数据库失败: %w", err)
+	}
 
 	store := &ConfigStore{
-		db: db,
+		config:  config,
+		archive: archive,
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 	if err := store.ensureSchema(ctx); err != nil {
-		db.Close()
+		store.Close()
 		return nil, err
 	}
 	return store, nil
@@ -59,9 +63,75 @@ func (s *ConfigStore) ensureSchema(ctx context.Context) error {
 			encrypted INTEGER NOT NULL DEFAULT 0,
 			updated_at TIMESTAMP NOT NULL
 		);`
-	if _, err := s.db.ExecContext(ctx, configItemsSchema); err != nil {
+	if _, err := s.config.writer.ExecContext(ctx, configItemsSchema); err != nil {
 		return fmt.Errorf("初始化配置项表失败: %w", err)
 	}
+	if _, err := s.config.writer.ExecContext(ctx, apiTokensSchema); err != nil {
+		return fmt.Errorf("初始化 API Token 表失败: %w", err)
+	}
+	if _, err := s.config.writer.ExecContext(ctx, fileWritesSchema); err != nil {
+		return fmt.Errorf("初始化写入记录表失败: %w", err)
+	}
+	if _, err := s.config.writer.ExecContext(ctx, projectMappingsSchema); err != nil {
+		return fmt.Errorf("初始化项目映射表失败: %w", err)
+	}
+	if _, err := s.archive.writer.ExecContext(ctx, failedExportsSchema); err != nil {
+		return fmt.Errorf("初始化失败记录表失败: %w", err)
+	}
+	if _, err := s.archive.writer.ExecContext(ctx, exportStateSchema); err != nil {
+		return fmt.Errorf("初始化导出状态表失败: %w", err)
+	}
+	if _, err := s.archive.writer.ExecContext(ctx, notionDraftsSchema); err != nil {
+		return fmt.Errorf("初始化 Notion 草稿表失败: %w", err)
+	}
+	if _, err := s.archive.writer.ExecContext(ctx, conversationIndexSchema); err != nil {
+		return fmt.Errorf("初始化对话索引表失败: %w", err)
+	}
+	if _, err := s.archive.writer.ExecContext(ctx, crawlStateSchema); err != nil {
+		return fmt.Errorf("初始化抓取进度表失败: %w", err)
+	}
+	if _, err := s.archive.writer.ExecContext(ctx, crawlIDsSchema); err != nil {
+		return fmt.Errorf("初始化抓取记录表失败: %w", err)
+	}
+	if _, err := s.archive.writer.ExecContext(ctx, localConversationsSchema); err != nil {
+		return fmt.Errorf("初始化导入对话表失败: %w", err)
+	}
+	if _, err := s.archive.writer.ExecContext(ctx, localFilesSchema); err != nil {
+		return fmt.Errorf("初始化媒体文件表失败: %w", err)
+	}
+	if _, err := s.archive.writer.ExecContext(ctx, blobsSchema); err != nil {
+		return fmt.Errorf("初始化快照存储表失败: %w", err)
+	}
+	if _, err := s.archive.writer.ExecContext(ctx, localVersionsSchema); err != nil {
+		return fmt.Errorf("初始化历史版本表失败: %w", err)
+	}
+	if _, err := s.archive.writer.ExecContext(ctx, localVersionsIndex); err != nil {
+		return fmt.Errorf("初始化历史版本索引失败: %w", err)
+	}
+	if _, err := s.archive.writer.ExecContext(ctx, conversationVersionsSchema); err != nil {
+		return fmt.Errorf("初始化对话版本表失败: %w", err)
+	}
+	if _, err := s.archive.writer.ExecContext(ctx, conversationVersionsIndex); err != nil {
+		return fmt.Errorf("初始化对话版本索引失败: %w", err)
+	}
+	if _, err := s.archive.writer.ExecContext(ctx, exportQueueSchema); err != nil {
+		return fmt.Errorf("初始化导出队列表失败: %w", err)
+	}
+	if _, err := s.archive.writer.ExecContext(ctx, exportQueueIndex); err != nil {
+		return fmt.Errorf("初始化导出队列索引失败: %w", err)
+	}
+	if _, err := s.archive.writer.ExecContext(ctx, targetStatsSchema); err != nil {
+		return fmt.Errorf("初始化目标统计表失败: %w", err)
+	}
+	if _, err := s.archive.writer.ExecContext(ctx, pendingDeletesSchema); err != nil {
+		return fmt.Errorf("初始化删除等待表失败: %w", err)
+	}
+	if err := migrateArchiveTables(ctx, s.config, s.archive.path); err != nil {
+		return err
+	}
+	if err := s.ensureBlobColumns(ctx); err != nil {
+		return err
+	}
 
 	if err := s.ensureDefaultConfigItems(ctx); err != nil {
 		return err
@@ -83,7 +153,7 @@ func (s *ConfigStore) ensureDefaultConfigItems(ctx context.Context) error {
 	}
 	now := time.Now().UTC()
 	for key, value := range defaults {
-		if _, err := s.db.ExecContext(ctx, `
+		if _, err := s.config.writer.ExecContext(ctx, `
 			INSERT INTO config_items(key, value, encrypted, updated_at)
 			VALUES(?, ?, 0, ?)
 			ON CONFLICT(key) DO NOTHING
@@ -95,19 +165,23 @@ func (s *ConfigStore) ensureDefaultConfigItems(ctx context.Context) error {
 }
 
 func (s *ConfigStore) Close() error {
-	if s == nil || s.db == nil {
+	if s == nil {
 		return nil
 	}
-	return s.db.Close()
+	aerr := s.archive.Close()
+	if err := s.config.Close(); err != nil {
+		return err
+	}
+	return aerr
 }
 
 // HasConfigItems reports whether at least one config entry exists.
 func (s *ConfigStore) HasConfigItems(ctx context.Context) (bool, error) {
-	if s == nil || s.db == nil {
+	if s == nil || s.config == nil {
 		return false, errors.New("配置存储未初始化")
 	}
 	var count int
-	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM config_items`).Scan(&count); err != nil {
+	if err := s.config.reader.QueryRowContext(ctx, `SELECT COUNT(*) FROM config_items`).Scan(&count); err != nil {
 		return false, fmt.Errorf("统计配置项失败: %w", err)
 	}
 	return count > 0, nil
@@ -127,7 +201,7 @@ func (s *ConfigStore) SaveConfig(ctx context.Context, payload ConfigPayload) err
 func (s *ConfigStore) persistConfigItems(ctx context.Context, payload ConfigPayload) error {
 	items := configPayloadToItems(payload)
 	now := time.Now().UTC()
-	tx, err := s.db.BeginTx(ctx, nil)
+	tx, err := s.config.writer.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -165,7 +239,7 @@ func (s *ConfigStore) persistConfigItems(ctx context.Context, payload ConfigPayl
 
 func (s *ConfigStore) loadConfigItems(ctx context.Context) (ConfigPayload, error) {
 	var payload ConfigPayload
-	rows, err := s.db.QueryContext(ctx, `SELECT key, value FROM config_items`)
+	rows, err := s.config.reader.QueryContext(ctx, `SELECT key, value FROM config_items`)
 	if err != nil {
 		return payload, fmt.Errorf("读取配置项失败: %w", err)
 	}
@@ -209,44 +283,135 @@ type configItem struct {
 
 func configPayloadToItems(payload ConfigPayload) map[string]configItem {
 	items := map[string]configItem{
-		"listen":                {value: payload.Listen},
-		"timezone":              {value: payload.Timezone},
-		"target":                {value: payload.Target},
-		"base_url":              {value: payload.BaseURL},
-		"order":                 {value: payload.Order},
-		"page_size":             {value: strconv.Itoa(payload.PageSize)},
-		"max_conversations":     {value: strconv.Itoa(payload.MaxConversations)},
-		"initial_offset":        {value: strconv.Itoa(payload.InitialOffset)},
-		"include_archived":      {value: strconv.FormatBool(payload.IncludeArchived)},
-		"token":                 {value: payload.Token},
-		"device_id":             {value: payload.DeviceID},
-		"user_agent":            {value: payload.UserAgent},
-		"accept_language":       {value: payload.AcceptLanguage},
-		"referer":               {value: payload.Referer},
-		"cookie":                {value: payload.Cookie},
-		"origin":                {value: payload.Origin},
-		"oai_language":          {value: payload.OaiLanguage},
-		"sec_ch_ua":             {value: payload.SecChUA},
-		"sec_ch_ua_mobile":      {value: payload.SecChUAMobile},
-		"sec_ch_ua_platform":    {value: payload.SecChUAPlatform},
-		"sec_fetch_dest":        {value: payload.SecFetchDest},
-		"sec_fetch_mode":        {value: payload.SecFetchMode},
-		"sec_fetch_site":        {value: payload.SecFetchSite},
-		"chatgpt_account_id":    {value: payload.ChatGPTAccountID},
-		"oai_client_version":    {value: payload.OAIClientVersion},
-		"priority":              {value: payload.Priority},
-		"log_path":              {value: payload.LogPath},
-		"anytype_base_url":      {value: payload.AnytypeBaseURL},
-		"anytype_version":       {value: payload.AnytypeVersion},
-		"anytype_space_id":      {value: payload.AnytypeSpaceID},
-		"anytype_type_key":      {value: payload.AnytypeTypeKey},
-		"anytype_token":         {value: payload.AnytypeToken},
-		"notion_base_url":       {value: payload.NotionBaseURL},
-		"notion_version":        {value: payload.NotionVersion},
-		"notion_token":          {value: payload.NotionToken},
-		"notion_parent_type":    {value: payload.NotionParentType},
-		"notion_parent_id":      {value: payload.NotionParentID},
-		"notion_title_property": {value: payload.NotionTitleProperty},
+		"listen":                 {value: payload.Listen},
+		"timezone":               {value: payload.Timezone},
+		"target":                 {value: payload.Target},
+		"base_url":               {value: payload.BaseURL},
+		"order":                  {value: payload.Order},
+		"page_size":              {value: strconv.Itoa(payload.PageSize)},
+		"max_conversations":      {value: strconv.Itoa(payload.MaxConversations)},
+		"initial_offset":         {value: strconv.Itoa(payload.InitialOffset)},
+		"include_archived":       {value: strconv.FormatBool(payload.IncludeArchived)},
+		"token":                  {value: payload.Token},
+		"device_id":              {value: payload.DeviceID},
+		"user_agent":             {value: payload.UserAgent},
+		"accept_language":        {value: payload.AcceptLanguage},
+		"referer":                {value: payload.Referer},
+		"cookie":                 {value: payload.Cookie},
+		"origin":                 {value: payload.Origin},
+		"oai_language":           {value: payload.OaiLanguage},
+		"sec_ch_ua":              {value: payload.SecChUA},
+		"sec_ch_ua_mobile":       {value: payload.SecChUAMobile},
+		"sec_ch_ua_platform":     {value: payload.SecChUAPlatform},
+		"sec_fetch_dest":         {value: payload.SecFetchDest},
+		"sec_fetch_mode":         {value: payload.SecFetchMode},
+		"sec_fetch_site":         {value: payload.SecFetchSite},
+		"chatgpt_account_id":     {value: payload.ChatGPTAccountID},
+		"oai_client_version":     {value: payload.OAIClientVersion},
+		"priority":               {value: payload.Priority},
+		"log_path":               {value: payload.LogPath},
+		"anytype_base_url":       {value: payload.AnytypeBaseURL},
+		"anytype_version":        {value: payload.AnytypeVersion},
+		"anytype_space_id":       {value: payload.AnytypeSpaceID},
+		"anytype_type_key":       {value: payload.AnytypeTypeKey},
+		"anytype_token":          {value: payload.AnytypeToken},
+		"notion_base_url":        {value: payload.NotionBaseURL},
+		"notion_version":         {value: payload.NotionVersion},
+		"notion_token":           {value: payload.NotionToken},
+		"notion_parent_type":     {value: payload.NotionParentType},
+		"notion_parent_id":       {value: payload.NotionParentID},
+		"notion_title_property":  {value: payload.NotionTitleProperty},
+		"hook_api_key":           {value: payload.HookAPIKey},
+		"hook_limit":             {value: strconv.Itoa(payload.HookLimit)},
+		"html_theme":             {value: payload.HTMLTheme},
+		"html_custom_css":        {value: payload.HTMLCustomCSS},
+		"include_context":        {value: strconv.FormatBool(payload.IncludeContext)},
+		"download_audio":         {value: strconv.FormatBool(payload.DownloadAudio)},
+		"download_attachments":   {value: strconv.FormatBool(payload.DownloadAttachments)},
+		"math_mode":              {value: payload.MathMode},
+		"render_diagrams":        {value: strconv.FormatBool(payload.RenderDiagrams)},
+		"webhook_url":            {value: payload.WebhookURL},
+		"webhook_headers":        {value: payload.WebhookHeaders},
+		"webhook_template":       {value: payload.WebhookTemplate},
+		"webhook_id_path":        {value: payload.WebhookIDPath},
+		"webhook_url_path":       {value: payload.WebhookURLPath},
+		"airtable_base_url":      {value: payload.AirtableBaseURL},
+		"airtable_token":         {value: payload.AirtableToken},
+		"airtable_base_id":       {value: payload.AirtableBaseID},
+		"airtable_table":         {value: payload.AirtableTable},
+		"airtable_fields":        {value: payload.AirtableFields},
+		"airtable_body_mode":     {value: payload.AirtableBodyMode},
+		"google_client_id":       {value: payload.GoogleClientID},
+		"google_client_secret":   {value: payload.GoogleClientSecret},
+		"google_refresh_token":   {value: payload.GoogleRefreshToken},
+		"google_folder_id":       {value: payload.GoogleFolderID},
+		"google_drive_mode":      {value: payload.GoogleDriveMode},
+		"telegram_base_url":      {value: payload.TelegramBaseURL},
+		"telegram_bot_token":     {value: payload.TelegramBotToken},
+		"telegram_chat_id":       {value: payload.TelegramChatID},
+		"telegram_mode":          {value: payload.TelegramMode},
+		"readwise_base_url":      {value: payload.ReadwiseBaseURL},
+		"readwise_token":         {value: payload.ReadwiseToken},
+		"readwise_tags":          {value: payload.ReadwiseTags},
+		"memos_base_url":         {value: payload.MemosBaseURL},
+		"memos_token":            {value: payload.MemosToken},
+		"memos_visibility":       {value: payload.MemosVisibility},
+		"memos_tags":             {value: payload.MemosTags},
+		"trilium_base_url":       {value: payload.TriliumBaseURL},
+		"trilium_token":          {value: payload.TriliumToken},
+		"trilium_parent_note_id": {value: payload.TriliumParentNoteID},
+		"spill_threshold_mb":     {value: strconv.Itoa(payload.SpillThresholdMB)},
+		"archive_merge":          {value: payload.ArchiveMerge},
+		"annotate_changes":       {value: strconv.FormatBool(payload.AnnotateChanges)},
+		"title_fallback":         {value: payload.TitleFallback},
+		"target_title_fallback":  {value: payload.TargetTitleFallback},
+		"unicode_normalize":      {value: strconv.FormatBool(payload.UnicodeNormalize)},
+		"filename_strip_emoji":   {value: strconv.FormatBool(payload.FilenameStripEmoji)},
+		"notion_chunk_mode":      {value: payload.NotionChunkMode},
+		"server_users":           {value: payload.ServerUsers},
+		"ip_allowlist":           {value: payload.IPAllowlist},
+		"message_layout":         {value: payload.MessageLayout},
+		"export_mode":            {value: payload.ExportMode},
+		"export_stats":           {value: strconv.FormatBool(payload.ExportStats)},
+		"time_format":            {value: payload.TimeFormat},
+		"relative_times":         {value: strconv.FormatBool(payload.RelativeTimes)},
+		"second_timezone":        {value: payload.SecondTimezone},
+		"file_hierarchy":         {value: payload.FileHierarchy},
+		"notion_draft_parent_id": {value: payload.NotionDraftParentID},
+		"notion_draft_type":      {value: payload.NotionDraftType},
+		"project_tags":           {value: strconv.FormatBool(payload.ProjectTags)},
+		"notion_project_field":   {value: payload.NotionProjectField},
+		"title_template":         {value: payload.TitleTemplate},
+		"target_title_template":  {value: payload.TargetTitleTemplate},
+		"queue_window":           {value: payload.QueueWindow},
+		"ua_rotation":            {value: payload.UARotation},
+		"ua_rotation_batch":      {value: strconv.Itoa(payload.UARotationBatch)},
+		"update_check":           {value: strconv.FormatBool(payload.UpdateCheck)},
+		"notion_url_field":       {value: payload.NotionURLField},
+		"html_qr_code":           {value: payload.HTMLQRCode},
+		"max_message_chars":      {value: strconv.Itoa(payload.MaxMessageChars)},
+		"truncate_keep_full":     {value: strconv.FormatBool(payload.TruncateKeepFull)},
+		"delete_interval_ms":     {value: strconv.Itoa(payload.DeleteIntervalMs)},
+		"delete_grace_minutes":   {value: strconv.Itoa(payload.DeleteGraceMinutes)},
+		"config_locks":           {value: payload.ConfigLocks},
+		"output_path":            {value: payload.OutputPath},
+		"raw_output_path":        {value: payload.RawOutputPath},
+		"raw_format":             {value: payload.RawFormat},
+		"notion_upload_audio":    {value: strconv.FormatBool(payload.NotionUploadAudio)},
+		"notion_upload_files":    {value: strconv.FormatBool(payload.NotionUploadFiles)},
+		"anytype_upload_files":   {value: strconv.FormatBool(payload.AnytypeUploadFiles)},
+		"anytype_files_property": {value: payload.AnytypeFilesField},
+		"toc_min_messages":       {value: strconv.Itoa(payload.TOCMinMessages)},
+		"onenote_tenant":         {value: payload.OneNoteTenant},
+		"onenote_client_id":      {value: payload.OneNoteClientID},
+		"onenote_client_secret":  {value: payload.OneNoteClientSecret},
+		"onenote_refresh_token":  {value: payload.OneNoteRefreshToken},
+		"onenote_token":          {value: payload.OneNoteToken},
+		"onenote_notebook":       {value: payload.OneNoteNotebook},
+		"onenote_section":        {value: payload.OneNoteSection},
+		"onenote_base_url":       {value: payload.OneNoteBaseURL},
+		"math_render_url":        {value: payload.MathRenderURL},
+		"diagram_render_url":     {value: payload.DiagramRenderURL},
 	}
 	return items
 }
@@ -340,5 +505,231 @@ func applyConfigItem(payload *ConfigPayload, key, value string) {
 		payload.NotionParentID = strings.TrimSpace(value)
 	case "notion_title_property":
 		payload.NotionTitleProperty = strings.TrimSpace(value)
+	case "hook_api_key":
+		payload.HookAPIKey = strings.TrimSpace(value)
+	case "hook_limit":
+		if v, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+			payload.HookLimit = v
+		}
+	case "html_theme":
+		payload.HTMLTheme = strings.TrimSpace(value)
+	case "html_custom_css":
+		payload.HTMLCustomCSS = strings.TrimSpace(value)
+	case "include_context":
+		if b, err := strconv.ParseBool(strings.TrimSpace(value)); err == nil {
+			payload.IncludeContext = b
+		}
+	case "download_audio":
+		if b, err := strconv.ParseBool(strings.TrimSpace(value)); err == nil {
+			payload.DownloadAudio = b
+		}
+	case "download_attachments":
+		if b, err := strconv.ParseBool(strings.TrimSpace(value)); err == nil {
+			payload.DownloadAttachments = b
+		}
+	case "math_mode":
+		payload.MathMode = strings.TrimSpace(value)
+	case "render_diagrams":
+		if b, err := strconv.ParseBool(strings.TrimSpace(value)); err == nil {
+			payload.RenderDiagrams = b
+		}
+	case "webhook_url":
+		payload.WebhookURL = strings.TrimSpace(value)
+	case "webhook_headers":
+		payload.WebhookHeaders = strings.TrimSpace(value)
+	case "webhook_template":
+		payload.WebhookTemplate = strings.TrimSpace(value)
+	case "webhook_id_path":
+		payload.WebhookIDPath = strings.TrimSpace(value)
+	case "webhook_url_path":
+		payload.WebhookURLPath = strings.TrimSpace(value)
+	case "airtable_base_url":
+		payload.AirtableBaseURL = strings.TrimSpace(value)
+	case "airtable_token":
+		payload.AirtableToken = strings.TrimSpace(value)
+	case "airtable_base_id":
+		payload.AirtableBaseID = strings.TrimSpace(value)
+	case "airtable_table":
+		payload.AirtableTable = strings.TrimSpace(value)
+	case "airtable_fields":
+		payload.AirtableFields = strings.TrimSpace(value)
+	case "airtable_body_mode":
+		payload.AirtableBodyMode = strings.TrimSpace(value)
+	case "google_client_id":
+		payload.GoogleClientID = strings.TrimSpace(value)
+	case "google_client_secret":
+		payload.GoogleClientSecret = strings.TrimSpace(value)
+	case "google_refresh_token":
+		payload.GoogleRefreshToken = strings.TrimSpace(value)
+	case "google_folder_id":
+		payload.GoogleFolderID = strings.TrimSpace(value)
+	case "google_drive_mode":
+		payload.GoogleDriveMode = strings.TrimSpace(value)
+	case "telegram_base_url":
+		payload.TelegramBaseURL = strings.TrimSpace(value)
+	case "telegram_bot_token":
+		payload.TelegramBotToken = strings.TrimSpace(value)
+	case "telegram_chat_id":
+		payload.TelegramChatID = strings.TrimSpace(value)
+	case "telegram_mode":
+		payload.TelegramMode = strings.TrimSpace(value)
+	case "readwise_base_url":
+		payload.ReadwiseBaseURL = strings.TrimSpace(value)
+	case "readwise_token":
+		payload.ReadwiseToken = strings.TrimSpace(value)
+	case "readwise_tags":
+		payload.ReadwiseTags = strings.TrimSpace(value)
+	case "memos_base_url":
+		payload.MemosBaseURL = strings.TrimSpace(value)
+	case "memos_token":
+		payload.MemosToken = strings.TrimSpace(value)
+	case "memos_visibility":
+		payload.MemosVisibility = strings.TrimSpace(value)
+	case "memos_tags":
+		payload.MemosTags = strings.TrimSpace(value)
+	case "trilium_base_url":
+		payload.TriliumBaseURL = strings.TrimSpace(value)
+	case "trilium_token":
+		payload.TriliumToken = strings.TrimSpace(value)
+	case "trilium_parent_note_id":
+		payload.TriliumParentNoteID = strings.TrimSpace(value)
+	case "spill_threshold_mb":
+		if v, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+			payload.SpillThresholdMB = v
+		}
+	case "archive_merge":
+		payload.ArchiveMerge = strings.TrimSpace(value)
+	case "annotate_changes":
+		if b, err := strconv.ParseBool(strings.TrimSpace(value)); err == nil {
+			payload.AnnotateChanges = b
+		}
+	case "title_fallback":
+		payload.TitleFallback = strings.TrimSpace(value)
+	case "target_title_fallback":
+		payload.TargetTitleFallback = strings.TrimSpace(value)
+	case "unicode_normalize":
+		if b, err := strconv.ParseBool(strings.TrimSpace(value)); err == nil {
+			payload.UnicodeNormalize = b
+		}
+	case "filename_strip_emoji":
+		if b, err := strconv.ParseBool(strings.TrimSpace(value)); err == nil {
+			payload.FilenameStripEmoji = b
+		}
+	case "notion_chunk_mode":
+		payload.NotionChunkMode = strings.TrimSpace(value)
+	case "server_users":
+		payload.ServerUsers = strings.TrimSpace(value)
+	case "ip_allowlist":
+		payload.IPAllowlist = strings.TrimSpace(value)
+	case "message_layout":
+		payload.MessageLayout = strings.TrimSpace(value)
+	case "export_mode":
+		payload.ExportMode = strings.TrimSpace(value)
+	case "export_stats":
+		if b, err := strconv.ParseBool(strings.TrimSpace(value)); err == nil {
+			payload.ExportStats = b
+		}
+	case "time_format":
+		payload.TimeFormat = strings.TrimSpace(value)
+	case "relative_times":
+		if b, err := strconv.ParseBool(strings.TrimSpace(value)); err == nil {
+			payload.RelativeTimes = b
+		}
+	case "second_timezone":
+		payload.SecondTimezone = strings.TrimSpace(value)
+	case "file_hierarchy":
+		payload.FileHierarchy = strings.TrimSpace(value)
+	case "notion_draft_parent_id":
+		payload.NotionDraftParentID = strings.TrimSpace(value)
+	case "notion_draft_type":
+		payload.NotionDraftType = strings.TrimSpace(value)
+	case "project_tags":
+		if b, err := strconv.ParseBool(strings.TrimSpace(value)); err == nil {
+			payload.ProjectTags = b
+		}
+	case "notion_project_field":
+		payload.NotionProjectField = strings.TrimSpace(value)
+	case "title_template":
+		payload.TitleTemplate = strings.TrimSpace(value)
+	case "target_title_template":
+		payload.TargetTitleTemplate = strings.TrimSpace(value)
+	case "queue_window":
+		payload.QueueWindow = strings.TrimSpace(value)
+	case "ua_rotation":
+		payload.UARotation = strings.TrimSpace(value)
+	case "ua_rotation_batch":
+		if v, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+			payload.UARotationBatch = v
+		}
+	case "update_check":
+		if b, err := strconv.ParseBool(strings.TrimSpace(value)); err == nil {
+			payload.UpdateCheck = b
+		}
+	case "notion_url_field":
+		payload.NotionURLField = strings.TrimSpace(value)
+	case "html_qr_code":
+		payload.HTMLQRCode = strings.TrimSpace(value)
+	case "max_message_chars":
+		if v, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+			payload.MaxMessageChars = v
+		}
+	case "truncate_keep_full":
+		if b, err := strconv.ParseBool(strings.TrimSpace(value)); err == nil {
+			payload.TruncateKeepFull = b
+		}
+	case "delete_interval_ms":
+		if v, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+			payload.DeleteIntervalMs = v
+		}
+	case "delete_grace_minutes":
+		if v, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+			payload.DeleteGraceMinutes = v
+		}
+	case "config_locks":
+		payload.ConfigLocks = strings.TrimSpace(value)
+	case "output_path":
+		payload.OutputPath = strings.TrimSpace(value)
+	case "raw_output_path":
+		payload.RawOutputPath = strings.TrimSpace(value)
+	case "raw_format":
+		payload.RawFormat = strings.TrimSpace(value)
+	case "notion_upload_audio":
+		if b, err := strconv.ParseBool(strings.TrimSpace(value)); err == nil {
+			payload.NotionUploadAudio = b
+		}
+	case "notion_upload_files":
+		if b, err := strconv.ParseBool(strings.TrimSpace(value)); err == nil {
+			payload.NotionUploadFiles = b
+		}
+	case "anytype_upload_files":
+		if b, err := strconv.ParseBool(strings.TrimSpace(value)); err == nil {
+			payload.AnytypeUploadFiles = b
+		}
+	case "anytype_files_property":
+		payload.AnytypeFilesField = strings.TrimSpace(value)
+	case "toc_min_messages":
+		if v, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+			payload.TOCMinMessages = v
+		}
+	case "onenote_tenant":
+		payload.OneNoteTenant = strings.TrimSpace(value)
+	case "onenote_client_id":
+		payload.OneNoteClientID = strings.TrimSpace(value)
+	case "onenote_client_secret":
+		payload.OneNoteClientSecret = strings.TrimSpace(value)
+	case "onenote_refresh_token":
+		payload.OneNoteRefreshToken = strings.TrimSpace(value)
+	case "onenote_token":
+		payload.OneNoteToken = strings.TrimSpace(value)
+	case "onenote_notebook":
+		payload.OneNoteNotebook = strings.TrimSpace(value)
+	case "onenote_section":
+		payload.OneNoteSection = strings.TrimSpace(value)
+	case "onenote_base_url":
+		payload.OneNoteBaseURL = strings.TrimSpace(value)
+	case "math_render_url":
+		payload.MathRenderURL = strings.TrimSpace(value)
+	case "diagram_render_url":
+		payload.DiagramRenderURL = strings.TrimSpace(value)
 	}
 }
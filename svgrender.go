@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/httpc"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+const (
+	// svgRenderTimeout 是单个公式或图表的渲染时限。
+	svgRenderTimeout = 20 * time.Second
+	// svgMaxBytes 是渲染结果的大小上限。
+	svgMaxBytes = 2 << 20
+	// svgCacheEntries 是渲染结果缓存的条目上限, 超出后清空重来。
+	svgCacheEntries = 1024
+)
+
+// svgCache 按渲染服务地址与源码缓存渲染结果, 同一公式在多次导出中只请求一次。零值可直接使用。
+type svgCache struct {
+	mu      sync.Mutex
+	entries map[string][]byte
+}
+
+func (c *svgCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	svg, ok := c.entries[key]
+	return svg, ok
+}
+
+func (c *svgCache) put(key string, svg []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil || len(c.entries) >= svgCacheEntries {
+		c.entries = make(map[string][]byte)
+	}
+	c.entries[key] = svg
+}
+
+// defaultDiagramRenderURL 是未配置 diagram_render_url 时使用的 Kroki 服务。
+const defaultDiagramRenderURL = "https://kroki.io"
+
+// svgRenderer 返回导出 HTML 时使用的渲染函数: 公式交给 math_render_url 指定的服务渲染, 未配置时
+// 按原文输出; 开启 render_diagrams 时图表交给 diagram_render_url 指定的 Kroki 服务渲染。两者都未启用时
+// 返回 nil。渲染结果嵌入导出文件, 查看时不再访问渲染服务。
+func (s *webServer) svgRenderer(cfg *cliConfig) export.SVGRenderer {
+	mathURL := strings.TrimSpace(cfg.MathRenderURL)
+	diagramURL := ""
+	if cfg.RenderDiagrams {
+		diagramURL = strings.TrimRight(firstNonEmpty(strings.TrimSpace(cfg.DiagramRenderURL), defaultDiagramRenderURL), "/")
+	}
+	if mathURL == "" && diagramURL == "" {
+		return nil
+	}
+	return func(kind, source string) ([]byte, error) {
+		switch {
+		case kind == export.SVGMath && mathURL != "":
+			return s.fetchSVG(http.MethodGet, mathURL+url.QueryEscape(source), "")
+		case kind != export.SVGMath && diagramURL != "":
+			// Kroki 接受 POST {地址}/{图表类型}/svg, 请求体为图表源码。
+			return s.fetchSVG(http.MethodPost, diagramURL+"/"+url.PathEscape(kind)+"/svg", source)
+		default:
+			return nil, fmt.Errorf("未配置 %s 的渲染服务", kind)
+		}
+	}
+}
+
+// fetchSVG 请求渲染服务并校验返回的是 SVG; body 非空时以纯文本请求体 POST。
+func (s *webServer) fetchSVG(method, target, body string) ([]byte, error) {
+	key := method + " " + target + "\n" + body
+	if svg, ok := s.svgCache.get(key); ok {
+		return svg, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), svgRenderTimeout)
+	defer cancel()
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, fmt.Errorf("构造渲染请求失败: %w", err)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	resp, err := httpc.Client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("调用渲染服务失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &targets.StatusError{Action: "渲染 SVG", Status: resp.StatusCode, Message: strings.TrimSpace(targets.ReadBody(resp.Body))}
+	}
+	svg, err := io.ReadAll(io.LimitReader(resp.Body, svgMaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取渲染结果失败: %w", err)
+	}
+	if len(svg) > svgMaxBytes {
+		return nil, fmt.Errorf("渲染结果超过 %d MB", svgMaxBytes>>20)
+	}
+	if !bytes.Contains(svg[:min(len(svg), 1024)], []byte("<svg")) {
+		return nil, errors.New("渲染服务返回的不是 SVG")
+	}
+	s.svgCache.put(key, svg)
+	return svg, nil
+}
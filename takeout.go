@@ -0,0 +1,408 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Devoty/openai-backup/atomicfile"
+	"github.com/Devoty/openai-backup/client"
+	"github.com/Devoty/openai-backup/diskspace"
+	"github.com/Devoty/openai-backup/takeout"
+)
+
+const (
+	// takeoutBatchSize 是导入时每个事务写入的对话数。
+	takeoutBatchSize = 200
+	// mediaDirName 是导出数据中媒体文件的存放目录, 位于归档库同级。
+	mediaDirName = "media"
+)
+
+const localConversationsSchema = `
+	CREATE TABLE IF NOT EXISTS local_conversations (
+		id TEXT PRIMARY KEY,
+		title TEXT NOT NULL DEFAULT '',
+		create_time REAL NOT NULL DEFAULT 0,
+		update_time REAL NOT NULL DEFAULT 0,
+		data BLOB NOT NULL,
+		source TEXT NOT NULL DEFAULT '',
+		imported_at TIMESTAMP NOT NULL
+	);`
+
+const localFilesSchema = `
+	CREATE TABLE IF NOT EXISTS local_files (
+		file_id TEXT PRIMARY KEY,
+		name TEXT NOT NULL DEFAULT '',
+		path TEXT NOT NULL,
+		size INTEGER NOT NULL DEFAULT 0,
+		imported_at TIMESTAMP NOT NULL
+	);`
+
+// takeoutResult 是一次导入官方导出数据的统计。
+type takeoutResult struct {
+	Conversations int      `json:"conversations"`
+	Files         int      `json:"files"`
+	FilesSkipped  int      `json:"files_skipped"`
+	Failed        int      `json:"failed"`
+	Errors        []string `json:"errors,omitempty"`
+}
+
+// maxTakeoutErrors 限制结果中列出的错误条数。
+const maxTakeoutErrors = 20
+
+func (r *takeoutResult) addError(format string, args ...interface{}) {
+	r.Failed++
+	if len(r.Errors) < maxTakeoutErrors {
+		r.Errors = append(r.Errors, fmt.Sprintf(format, args...))
+	}
+}
+
+type localConversation struct {
+	meta client.ConversationMeta
+	data []byte
+}
+
+// mediaDir 返回导入的媒体文件目录。
+func (s *ConfigStore) mediaDir() string {
+	return filepath.Join(filepath.Dir(s.archive.path), mediaDirName)
+}
+
+// SaveLocalConversations 在同一事务中写入导入的对话与对话索引。已有记录只在导入的版本
+// 更新时覆盖, 重复导入旧的导出包不会回退内容, 也不会覆盖列表接口写入的较新索引。
+func (s *ConfigStore) SaveLocalConversations(ctx context.Context, convs []localConversation, source string) error {
+	if s == nil || s.archive == nil {
+		return errors.New("配置存储未初始化")
+	}
+	tx, err := s.archive.writer.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+	now := time.Now().UTC()
+	for _, conv := range convs {
+		meta := conv.meta
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO local_conversations(id, title, create_time, update_time, data, source, imported_at)
+			VALUES(?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(id) DO UPDATE SET
+				title=excluded.title,
+				create_time=excluded.create_time,
+				update_time=excluded.update_time,
+				data=excluded.data,
+				source=excluded.source,
+				imported_at=excluded.imported_at
+			WHERE excluded.update_time >= local_conversations.update_time
+		`, meta.ID, meta.Title, meta.CreateTime.Float64(), meta.UpdateTime.Float64(), conv.data, source, now); err != nil {
+			return fmt.Errorf("写入对话 %s 失败: %w", meta.ID, err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO conversation_index(id, title, create_time, update_time, indexed_at)
+			VALUES(?, ?, ?, ?, ?)
+			ON CONFLICT(id) DO UPDATE SET
+				title=excluded.title,
+				create_time=excluded.create_time,
+				update_time=excluded.update_time,
+				indexed_at=excluded.indexed_at
+			WHERE excluded.update_time > conversation_index.update_time
+		`, meta.ID, meta.Title, meta.CreateTime.Float64(), meta.UpdateTime.Float64(), now); err != nil {
+			return fmt.Errorf("写入对话索引失败: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交导入对话失败: %w", err)
+	}
+	return nil
+}
+
+// LocalConversation 读取导入的对话。对话索引中的更新时间比导入版本新 (导入后又继续聊过) 时
+// stale 为 true, 调用方应改用接口拉取。
+func (s *ConfigStore) LocalConversation(ctx context.Context, id string) (conv *client.Conversation, stale bool, err error) {
+	if s == nil || s.archive == nil {
+		return nil, false, errors.New("配置存储未初始化")
+	}
+	var (
+		data         []byte
+		localUpdated float64
+		indexUpdated float64
+	)
+	err = s.archive.reader.QueryRowContext(ctx, `
+		SELECT l.data, l.update_time, COALESCE(i.update_time, 0)
+		FROM local_conversations l LEFT JOIN conversation_index i ON i.id = l.id
+		WHERE l.id = ?
+	`, id).Scan(&data, &localUpdated, &indexUpdated)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("读取导入对话失败: %w", err)
+	}
+	conv = &client.Conversation{}
+	if err := json.Unmarshal(data, conv); err != nil {
+		return nil, false, fmt.Errorf("解析导入对话失败: %w", err)
+	}
+	conv.Raw = data
+	return conv, indexUpdated > localUpdated, nil
+}
+
+// CountLocalConversations 返回导入的对话数量。
+func (s *ConfigStore) CountLocalConversations(ctx context.Context) (int, error) {
+	if s == nil || s.archive == nil {
+		return 0, errors.New("配置存储未初始化")
+	}
+	var count int
+	if err := s.archive.reader.QueryRowContext(ctx, `SELECT COUNT(*) FROM local_conversations`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("统计导入对话失败: %w", err)
+	}
+	return count, nil
+}
+
+// SaveLocalFile 记录导入的媒体文件, path 为相对 mediaDir 的文件名。
+func (s *ConfigStore) SaveLocalFile(ctx context.Context, fileID, name, path string, size int64) error {
+	if s == nil || s.archive == nil {
+		return errors.New("配置存储未初始化")
+	}
+	_, err := s.archive.writer.ExecContext(ctx, `
+		INSERT INTO local_files(file_id, name, path, size, imported_at)
+		VALUES(?, ?, ?, ?, ?)
+		ON CONFLICT(file_id) DO UPDATE SET
+			name=excluded.name,
+			path=excluded.path,
+			size=excluded.size,
+			imported_at=excluded.imported_at
+	`, fileID, name, path, size, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("记录媒体文件失败: %w", err)
+	}
+	return nil
+}
+
+// LocalFilePath 返回导入的媒体文件在磁盘上的路径, 不存在时返回空字符串。
+func (s *ConfigStore) LocalFilePath(ctx context.Context, fileID string) (string, error) {
+	if s == nil || s.archive == nil {
+		return "", errors.New("配置存储未初始化")
+	}
+	var path string
+	err := s.archive.reader.QueryRowContext(ctx, `SELECT path FROM local_files WHERE file_id = ?`, fileID).Scan(&path)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("读取媒体文件记录失败: %w", err)
+	}
+	return filepath.Join(s.mediaDir(), path), nil
+}
+
+// ingestTakeout 把导出数据中的对话写入本地归档, 媒体文件解压到 mediaDir。
+// 单条对话或单个文件失败只计入结果, 不中断导入。
+func ingestTakeout(ctx context.Context, store *ConfigStore, archive *takeout.Archive, source string) (takeoutResult, error) {
+	var result takeoutResult
+	batch := make([]localConversation, 0, takeoutBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := store.SaveLocalConversations(ctx, batch, source); err != nil {
+			return err
+		}
+		result.Conversations += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+	err := archive.Conversations(func(raw json.RawMessage, conv *client.Conversation, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			result.addError("解析对话失败: %v", err)
+			return nil
+		}
+		batch = append(batch, localConversation{
+			meta: client.ConversationMeta{ID: conv.ID, Title: conv.Title, CreateTime: conv.CreateTime, UpdateTime: conv.UpdateTime},
+			data: append([]byte(nil), raw...),
+		})
+		if len(batch) >= takeoutBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		return result, err
+	}
+
+	dir := store.mediaDir()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return result, fmt.Errorf("创建媒体目录失败: %w", err)
+	}
+	// 对话已经写入数据库, 媒体文件按解压后的大小先检查磁盘空间, 不足时不开始解压。
+	var need int64
+	for _, file := range archive.Files() {
+		target := filepath.Join(dir, file.ID+strings.ToLower(filepath.Ext(file.Name)))
+		if info, err := os.Stat(target); err != nil || info.Size() != file.Size {
+			need += file.Size
+		}
+	}
+	if err := diskspace.Check(dir, need); err != nil {
+		return result, err
+	}
+	for _, file := range archive.Files() {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		name := file.ID + strings.ToLower(filepath.Ext(file.Name))
+		target := filepath.Join(dir, name)
+		if info, err := os.Stat(target); err == nil && info.Size() == file.Size {
+			result.FilesSkipped++
+		} else if err := extractTakeoutFile(store, file, target); err != nil {
+			result.addError("解压 %s 失败: %v", file.Name, err)
+			continue
+		} else {
+			result.Files++
+		}
+		if err := store.SaveLocalFile(ctx, file.ID, file.Name, name, file.Size); err != nil {
+			return result, err
+		}
+	}
+	logInfo("官方导出数据导入完成: 来源=%s 对话=%d 媒体文件=%d 已存在=%d 失败=%d", source, result.Conversations, result.Files, result.FilesSkipped, result.Failed)
+	return result, nil
+}
+
+// extractTakeoutFile 先写入临时文件再改名, 中断时不会留下不完整的媒体文件。
+func extractTakeoutFile(journal atomicfile.Journal, file takeout.File, target string) error {
+	rc, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return atomicfile.WriteFrom(journal, target, 0o600, func(w io.Writer) error {
+		_, err := io.Copy(w, rc)
+		return err
+	})
+}
+
+// localConversation 返回导入的对话详情, 没有导入或读取失败时返回 nil;
+// stale 表示对话在导入后又有更新, 需要与接口内容合并。
+func (s *webServer) localConversation(ctx context.Context, id string) (*client.Conversation, bool) {
+	conv, stale, err := s.store.LocalConversation(ctx, id)
+	if err != nil {
+		logInfo("读取导入对话失败, 改用接口拉取: conversation=%s err=%v", id, err)
+		return nil, false
+	}
+	return conv, stale
+}
+
+// downloadFile 优先读取导出数据中的媒体文件, 没有时通过 ChatGPT 文件接口下载。
+func (s *webServer) downloadFile(ctx context.Context, chatgpt *client.Client, pointer string) ([]byte, error) {
+	if id, ok := takeout.FileID(pointer); ok {
+		path, err := s.store.LocalFilePath(ctx, id)
+		if err != nil {
+			logInfo("%v", err)
+		}
+		if path != "" {
+			data, err := os.ReadFile(path)
+			if err == nil {
+				return data, nil
+			}
+			logInfo("读取导入的媒体文件失败, 改用接口下载: file=%s err=%v", id, err)
+		}
+	}
+	return chatgpt.DownloadFile(ctx, pointer)
+}
+
+// handleTakeout 接收官方导出数据压缩包 (请求体直接为 zip, 或 multipart 表单的 file 字段),
+// 写入临时文件后导入本地归档。
+func (s *webServer) handleTakeout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+		return
+	}
+	body := io.Reader(r.Body)
+	source := "upload"
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		reader, err := r.MultipartReader()
+		if err != nil {
+			writeErrorDetail(w, http.StatusBadRequest, errCodeInvalidRequest, "请求体解析失败", err)
+			return
+		}
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "缺少 file 字段")
+				return
+			}
+			if part.FormName() == "file" {
+				body = part
+				source = firstNonEmpty(part.FileName(), source)
+				break
+			}
+		}
+	}
+
+	root, err := spillRoot()
+	if err != nil {
+		writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "创建临时目录失败", err)
+		return
+	}
+	tmp, err := os.CreateTemp(root, "takeout-*.zip")
+	if err != nil {
+		writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "创建临时文件失败", err)
+		return
+	}
+	defer os.Remove(tmp.Name())
+	size, err := io.Copy(tmp, body)
+	tmp.Close()
+	if err != nil {
+		writeErrorDetail(w, http.StatusBadRequest, errCodeInvalidRequest, "接收压缩包失败", err)
+		return
+	}
+
+	archive, err := takeout.Open(tmp.Name())
+	if err != nil {
+		writeErrorDetail(w, http.StatusBadRequest, errCodeInvalidRequest, "无法识别导出数据", err)
+		return
+	}
+	defer archive.Close()
+	logInfo("开始导入官方导出数据: 来源=%s 大小=%d", source, size)
+	result, err := ingestTakeout(r.Context(), s.store, archive, source)
+	if errors.Is(err, diskspace.ErrInsufficient) {
+		writeErrorDetail(w, http.StatusInsufficientStorage, errCodeInsufficientStorage, "磁盘空间不足, 无法解压媒体文件", err)
+		return
+	}
+	if err != nil {
+		writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "导入官方导出数据失败", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// runTakeoutImport 是 --import-takeout 的命令行入口, 结果以 JSON 写入 w。
+func runTakeoutImport(ctx context.Context, cfg *cliConfig, path string, w io.Writer) error {
+	archive, err := takeout.Open(path)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+	store, err := Init(cfg.ConfigDBPath)
+	if err != nil {
+		return fmt.Errorf("初始化配置存储失败: %w", err)
+	}
+	defer store.Close()
+	result, err := ingestTakeout(ctx, store, archive, filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
@@ -0,0 +1,157 @@
+// Package takeout 读取 ChatGPT 官方"导出数据"压缩包 (conversations.json 与媒体文件),
+// 对话结构与详情接口一致, 可直接交给 export.Build 处理。
+package takeout
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/Devoty/openai-backup/client"
+)
+
+const conversationsFile = "conversations.json"
+
+// fileIDPattern 匹配媒体文件名开头的文件 ID, 如 file-AbC123-photo.png、file_00000000abcd-uuid.webp。
+var fileIDPattern = regexp.MustCompile(`^(file[-_][A-Za-z0-9]+)`)
+
+// Archive 是打开的导出压缩包。
+type Archive struct {
+	zr            *zip.Reader
+	closer        io.Closer
+	conversations *zip.File
+}
+
+// File 是压缩包中可以按文件 ID 对应到对话内图片、语音或上传文件的媒体文件。
+type File struct {
+	ID   string
+	Name string
+	Size int64
+	zf   *zip.File
+}
+
+// Open 读取 File 内容。
+func (f File) Open() (io.ReadCloser, error) {
+	return f.zf.Open()
+}
+
+// Open 打开磁盘上的导出压缩包, 使用完毕后调用 Close。
+func Open(name string) (*Archive, error) {
+	rc, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, fmt.Errorf("打开导出压缩包失败: %w", err)
+	}
+	archive, err := newArchive(&rc.Reader)
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+	archive.closer = rc
+	return archive, nil
+}
+
+// NewReader 从 r 读取导出压缩包。
+func NewReader(r io.ReaderAt, size int64) (*Archive, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("读取导出压缩包失败: %w", err)
+	}
+	return newArchive(zr)
+}
+
+func newArchive(zr *zip.Reader) (*Archive, error) {
+	archive := &Archive{zr: zr}
+	// 部分导出包把内容放在一层目录下, 取层级最浅的 conversations.json。
+	for _, f := range zr.File {
+		if path.Base(f.Name) != conversationsFile {
+			continue
+		}
+		if archive.conversations == nil || strings.Count(f.Name, "/") < strings.Count(archive.conversations.Name, "/") {
+			archive.conversations = f
+		}
+	}
+	if archive.conversations == nil {
+		return nil, errors.New("压缩包中没有 conversations.json, 不是 ChatGPT 导出数据")
+	}
+	return archive, nil
+}
+
+func (a *Archive) Close() error {
+	if a.closer == nil {
+		return nil
+	}
+	return a.closer.Close()
+}
+
+// Conversations 逐条解码 conversations.json 并调用 fn, 不把整个文件读入内存。
+// raw 为该对话的原始 JSON; 单条解析失败时 conv 为 nil, err 说明原因, 由 fn 决定是否继续。
+func (a *Archive) Conversations(fn func(raw json.RawMessage, conv *client.Conversation, err error) error) error {
+	rc, err := a.conversations.Open()
+	if err != nil {
+		return fmt.Errorf("读取 conversations.json 失败: %w", err)
+	}
+	defer rc.Close()
+
+	decoder := json.NewDecoder(rc)
+	if tok, err := decoder.Token(); err != nil || tok != json.Delim('[') {
+		return errors.New("conversations.json 格式错误: 应为对话数组")
+	}
+	for decoder.More() {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return fmt.Errorf("解析 conversations.json 失败: %w", err)
+		}
+		var conv client.Conversation
+		if err := json.Unmarshal(raw, &conv); err != nil {
+			if err := fn(raw, nil, err); err != nil {
+				return err
+			}
+			continue
+		}
+		if strings.TrimSpace(conv.ID) == "" {
+			if err := fn(raw, nil, errors.New("缺少对话 ID")); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fn(raw, &conv, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Files 返回文件名以文件 ID 开头的媒体文件, 同一 ID 只保留第一个。
+func (a *Archive) Files() []File {
+	seen := make(map[string]bool)
+	var files []File
+	for _, f := range a.zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		id, ok := FileID(path.Base(f.Name))
+		if !ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+		files = append(files, File{ID: id, Name: path.Base(f.Name), Size: int64(f.UncompressedSize64), zf: f})
+	}
+	return files
+}
+
+// FileID 从媒体文件名或资源指针 (file-service://、sediment://) 中提取文件 ID。
+func FileID(name string) (string, bool) {
+	if _, rest, ok := strings.Cut(name, "://"); ok {
+		name = rest
+	}
+	match := fileIDPattern.FindStringSubmatch(name)
+	if match == nil {
+		return "", false
+	}
+	return match[1], true
+}
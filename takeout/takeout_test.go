@@ -0,0 +1,202 @@
+package takeout
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/Devoty/openai-backup/client"
+)
+
+// newZip 按 name -> 内容生成压缩包, 以 / 结尾的名称为目录。
+func newZip(t *testing.T, files [][2]string) *bytes.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		w, err := zw.Create(f[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, f[1])
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestFileID(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   string
+		wantOK bool
+	}{
+		{"file-AbC123-photo.png", "file-AbC123", true},
+		{"file_00000000abcd-1234.webp", "file_00000000abcd", true},
+		{"file-service://file-XyZ789", "file-XyZ789", true},
+		{"sediment://file_00000000ef01", "file_00000000ef01", true},
+		{"chat.html", "", false},
+		{"myfile-abc.png", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := FileID(tt.name)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("FileID(%q) = %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   [][2]string
+		wantErr bool
+		wantIDs []string
+	}{
+		{
+			name:    "根目录下的导出",
+			files:   [][2]string{{"conversations.json", `[{"id":"c1"}]`}},
+			wantIDs: []string{"c1"},
+		},
+		{
+			name: "取层级最浅的 conversations.json",
+			files: [][2]string{
+				{"export/nested/conversations.json", `[{"id":"deep"}]`},
+				{"export/conversations.json", `[{"id":"shallow"}]`},
+			},
+			wantIDs: []string{"shallow"},
+		},
+		{
+			name:    "不是导出数据",
+			files:   [][2]string{{"chat.html", "<html></html>"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newZip(t, tt.files)
+			archive, err := NewReader(r, r.Size())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			defer archive.Close()
+			var ids []string
+			err = archive.Conversations(func(raw json.RawMessage, conv *client.Conversation, err error) error {
+				if err != nil {
+					return err
+				}
+				ids = append(ids, conv.ID)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("对话 = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestConversations(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		wantIDs  []string
+		wantBad  int
+		wantFail bool
+	}{
+		{name: "逐条解析", content: `[{"id":"a","title":"A"},{"id":"b","title":"B"}]`, wantIDs: []string{"a", "b"}},
+		{name: "空数组", content: `[]`},
+		{name: "缺少 ID 的对话交给回调处理", content: `[{"id":"a"},{"title":"无 ID"},{"id":"c"}]`, wantIDs: []string{"a", "c"}, wantBad: 1},
+		{name: "字段类型错误的对话交给回调处理", content: `[{"id":"a","mapping":[]},{"id":"b"}]`, wantIDs: []string{"b"}, wantBad: 1},
+		{name: "不是数组", content: `{"id":"a"}`, wantFail: true},
+		{name: "JSON 被截断", content: `[{"id":"a"},{"id":`, wantIDs: []string{"a"}, wantFail: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newZip(t, [][2]string{{"conversations.json", tt.content}})
+			archive, err := NewReader(r, r.Size())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			bad := 0
+			err = archive.Conversations(func(raw json.RawMessage, conv *client.Conversation, err error) error {
+				if err != nil {
+					if conv != nil || len(raw) == 0 {
+						t.Errorf("解析失败时应提供原始 JSON 且 conv 为 nil")
+					}
+					bad++
+					return nil
+				}
+				ids = append(ids, conv.ID)
+				return nil
+			})
+			if (err != nil) != tt.wantFail {
+				t.Fatalf("err = %v, wantFail %v", err, tt.wantFail)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) || bad != tt.wantBad {
+				t.Errorf("对话 = %v 失败 %d, want %v 失败 %d", ids, bad, tt.wantIDs, tt.wantBad)
+			}
+		})
+	}
+}
+
+func TestConversationsStopsOnCallbackError(t *testing.T) {
+	r := newZip(t, [][2]string{{"conversations.json", `[{"id":"a"},{"id":"b"}]`}})
+	archive, err := NewReader(r, r.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := errors.New("停止")
+	calls := 0
+	err = archive.Conversations(func(raw json.RawMessage, conv *client.Conversation, err error) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("err = %v calls = %d, want 回调错误且只调用一次", err, calls)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	r := newZip(t, [][2]string{
+		{"conversations.json", `[]`},
+		{"file-AbC123-photo.png", "png"},
+		{"dalle-generations/file-Gen456-image.webp", "webp"},
+		{"dup/file-AbC123-copy.png", "copy"},
+		{"user-1/", ""},
+		{"chat.html", "<html></html>"},
+	})
+	archive, err := NewReader(r, r.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := archive.Files()
+	var got [][2]string
+	for _, f := range files {
+		got = append(got, [2]string{f.ID, f.Name})
+	}
+	want := [][2]string{{"file-AbC123", "file-AbC123-photo.png"}, {"file-Gen456", "file-Gen456-image.webp"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Files() = %v, want %v", got, want)
+	}
+	rc, err := files[1].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	data, _ := io.ReadAll(rc)
+	if string(data) != "webp" || files[1].Size != 4 {
+		t.Errorf("内容 = %q 大小 = %d", data, files[1].Size)
+	}
+}
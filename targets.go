@@ -0,0 +1,340 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Devoty/openai-backup/diskspace"
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/httpc"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+// isTransientTargetError 判断错误是否值得重试: 限流、服务端错误与网络故障。
+func isTransientTargetError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var statusErr *targets.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Status == http.StatusTooManyRequests || statusErr.Status >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+var errTargetUnavailable = errors.New("导出目标持续不可用, 任务中止")
+
+type exportResult struct {
+	ConversationID string        `json:"conversation_id"`
+	Title          string        `json:"-"`
+	ObjectID       string        `json:"object_id"`
+	URL            string        `json:"url,omitempty"`
+	Duration       time.Duration `json:"-"`
+}
+
+type syncFailure struct {
+	ConversationID string        `json:"id"`
+	Title          string        `json:"title"`
+	Error          string        `json:"error"`
+	Duration       time.Duration `json:"-"`
+	// err 保留原始错误, 用于接口返回错误码; fetch 表示失败发生在拉取 ChatGPT 详情阶段。
+	err   error
+	fetch bool
+}
+
+// syncAbortedReason 是任务中止后未执行的对话的失败原因。
+const syncAbortedReason = "任务中止, 未执行"
+
+type syncResult struct {
+	Exported []exportResult
+	Failed   []syncFailure
+	// Skipped 为没有可导出消息的对话 ID。
+	Skipped []string
+	// Unavailable 为已删除或无权访问、跳过的对话, 见 conversationUnavailable。
+	Unavailable []unavailableConversation
+}
+
+// exportItem 是待导出的对话; Title 仅在拉取详情前失败时用于记录, 可为空。
+// CustomTitle 与 Tags 是导出前为该对话单独指定的标题与标签, 见 importItem。
+type exportItem struct {
+	ID          string
+	Title       string
+	CustomTitle string
+	Tags        []string
+	// Test 表示测试导出 (见 handleTestExport): 加上测试标记, 不记录历史版本。
+	Test bool
+}
+
+// withOverrides 返回换上单独指定的标题、追加了标签的副本; 只作用于写入目标, 版本记录仍使用原标题。
+func (item exportItem) withOverrides(conv export.Conversation) export.Conversation {
+	if item.CustomTitle != "" {
+		conv.Title = item.CustomTitle
+	}
+	if len(item.Tags) > 0 {
+		conv.Tags = append(append([]string(nil), conv.Tags...), item.Tags...)
+	}
+	if item.Test {
+		conv.Title = testExportTitlePrefix + conv.Title
+		conv.Tags = append(append([]string(nil), conv.Tags...), testExportTag)
+	}
+	return conv
+}
+
+// conversationFetcher 按 ID 拉取并归一化对话详情。
+type conversationFetcher func(ctx context.Context, id string) (export.Conversation, error)
+
+// exportItemsFromIDs 去除空白与重复的 ID。
+func exportItemsFromIDs(ids []string) []exportItem {
+	seen := make(map[string]struct{}, len(ids))
+	items := make([]exportItem, 0, len(ids))
+	for _, rawID := range ids {
+		id := strings.TrimSpace(rawID)
+		if id == "" {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		items = append(items, exportItem{ID: id})
+	}
+	return items
+}
+
+// syncConversations 逐条拉取详情、关联并写入目标, 每条处理完即释放, 内存占用与任务规模无关。
+// 单条失败记录后继续; 目标持续不可用、ChatGPT Token 无效或任务取消时中止,
+// 剩余对话同样记为失败以便后续重试。被过滤的消息与无消息的对话直接记入 job。
+// 任务中的上游请求以后台优先级发出, 上游限速时让界面上的请求先行。
+func (s *webServer) syncConversations(ctx context.Context, job *exportJob, target, label string, exporter targets.Exporter, fetch conversationFetcher, items []exportItem, timezone string) (syncResult, error) {
+	ctx = httpc.Background(ctx)
+	var result syncResult
+	breaker := s.targetBreaker(target)
+	linker := s.newConversationLinker(ctx, target)
+	abort := func(idx int, err error) (syncResult, error) {
+		for _, rest := range items[idx+1:] {
+			result.Failed = append(result.Failed, syncFailure{ConversationID: rest.ID, Title: rest.Title, Error: syncAbortedReason})
+		}
+		return result, err
+	}
+
+	job.addPending(len(items))
+	for idx, item := range items {
+		started := time.Now()
+		conv, err := fetch(ctx, item.ID)
+		if code, reason, ok := conversationUnavailable(err); ok {
+			job.advance(nil)
+			logInfo("跳过对话 %s: %s", item.ID, reason)
+			skipped := unavailableConversation{ConversationID: item.ID, Title: item.Title, Code: code, Reason: reason}
+			result.Unavailable = append(result.Unavailable, skipped)
+			job.skipUnavailable(target, skipped)
+			continue
+		}
+		if err != nil {
+			job.advance(nil)
+			logInfo("获取对话 %s 详情失败: %v", item.ID, err)
+			result.Failed = append(result.Failed, syncFailure{ConversationID: item.ID, Title: item.Title, Error: fmt.Sprintf("获取对话详情失败: %v", err), Duration: time.Since(started), err: err, fetch: true})
+			if code := chatgptError("", err).Code; ctx.Err() != nil || code == errCodeChatGPTTokenMissing || code == errCodeChatGPTUnauthorized {
+				return abort(idx, fmt.Errorf("获取对话详情中止: %w", err))
+			}
+			continue
+		}
+		if len(conv.Messages) == 0 {
+			job.advance(&conv)
+			result.Skipped = append(result.Skipped, item.ID)
+			job.record(jobOutcome{ConversationID: item.ID, Title: conv.Title, Target: target, Status: outcomeSkipped, Error: "没有可导出的消息"})
+			continue
+		}
+		job.recordSkippedMessages(conv)
+		if conflict, ok := s.takeArchiveConflict(conv.ID); ok {
+			job.recordArchiveConflict(conflict)
+		}
+		if drift, ok := s.takeSchemaDrift(conv.ID); ok {
+			job.recordSchemaDrift(drift)
+		}
+		s.annotateConversationChanges(ctx, &conv, job.StartedAt)
+		linker.link(&conv)
+
+		payload := s.conversationForTarget(target, item.withOverrides(conv))
+		job.recordLintWarnings(payload, target)
+		object, err := breaker.run(ctx, func(ctx context.Context) (targets.Object, error) {
+			return s.createWithStats(ctx, target, exporter, payload, timezone)
+		})
+		job.advance(&conv)
+		if err != nil {
+			logInfo("对话 %s 导出到 %s 失败: %v", conv.ID, label, err)
+			result.Failed = append(result.Failed, syncFailure{ConversationID: conv.ID, Title: conv.Title, Error: err.Error(), Duration: time.Since(started), err: err})
+			// 磁盘空间不足时后续对话同样无法写入, 中止任务。
+			if ctx.Err() != nil || errors.Is(err, errTargetUnavailable) || errors.Is(err, diskspace.ErrInsufficient) {
+				return abort(idx, fmt.Errorf("导出到 %s 中止: %w", label, err))
+			}
+			continue
+		}
+		job.recordSubstitutions(conv, target, object.Substitutions)
+		exported := exportResult{ConversationID: conv.ID, Title: conv.Title, ObjectID: object.ID, URL: object.URL, Duration: time.Since(started)}
+		result.Exported = append(result.Exported, exported)
+		if !item.Test {
+			s.recordConversationVersion(ctx, conv)
+			linker.exportedTo(conv, exported)
+		}
+		logInfo("%s 导出成功: conversation=%s object=%s url=%s", label, conv.ID, object.ID, object.URL)
+	}
+	return result, nil
+}
+
+// resolveExporter 根据目标名称返回对应客户端及展示名称。
+func (s *webServer) resolveExporter(target string) (targets.Exporter, string, error) {
+	switch target {
+	case exportTargetAnytype:
+		client, err := s.resolveAnytypeClient()
+		if err != nil {
+			return nil, "Anytype", err
+		}
+		return client, "Anytype", nil
+	case exportTargetNotion:
+		client, err := s.resolveNotionClient()
+		if err != nil {
+			return nil, "Notion", err
+		}
+		return client, "Notion", nil
+	case exportTargetAirtable:
+		client, err := s.resolveAirtableClient()
+		if err != nil {
+			return nil, "Airtable", err
+		}
+		return client, "Airtable", nil
+	case exportTargetGDrive:
+		client, err := s.resolveGoogleDriveClient()
+		if err != nil {
+			return nil, "Google Drive", err
+		}
+		return client, "Google Drive", nil
+	case exportTargetTelegram:
+		client, err := s.resolveTelegramClient()
+		if err != nil {
+			return nil, "Telegram", err
+		}
+		return client, "Telegram", nil
+	case exportTargetReadwise:
+		client, err := s.resolveReadwiseClient()
+		if err != nil {
+			return nil, "Readwise Reader", err
+		}
+		return client, "Readwise Reader", nil
+	case exportTargetMemos:
+		client, err := s.resolveMemosClient()
+		if err != nil {
+			return nil, "Memos", err
+		}
+		return client, "Memos", nil
+	case exportTargetTrilium:
+		client, err := s.resolveTriliumClient()
+		if err != nil {
+			return nil, "Trilium", err
+		}
+		return client, "Trilium", nil
+	case exportTargetOneNote:
+		client, err := s.resolveOneNoteClient()
+		if err != nil {
+			return nil, "OneNote", err
+		}
+		return client, "OneNote", nil
+	case exportTargetMarkdown:
+		client, err := s.resolveMarkdownClient()
+		if err != nil {
+			return nil, "Markdown 目录", err
+		}
+		return client, "Markdown 目录", nil
+	case exportTargetJSON:
+		client, err := s.resolveRawJSONClient()
+		if err != nil {
+			return nil, "JSON 目录", err
+		}
+		return client, "JSON 目录", nil
+	case exportTargetExec:
+		client, err := s.resolveExecClient()
+		if err != nil {
+			return nil, "外部命令", err
+		}
+		return client, "外部命令", nil
+	case exportTargetWebhook:
+		client, err := s.resolveWebhookClient()
+		if err != nil {
+			return nil, "Webhook", err
+		}
+		return client, "Webhook", nil
+	default:
+		return nil, target, fmt.Errorf("不支持的导出目标: %s", target)
+	}
+}
+
+func (s *webServer) targetBreaker(target string) *circuitBreaker {
+	s.breakerMu.Lock()
+	defer s.breakerMu.Unlock()
+	breaker, ok := s.breakers[target]
+	if !ok {
+		breaker = newCircuitBreaker(target)
+		s.breakers[target] = breaker
+	}
+	return breaker
+}
+
+func (s *webServer) handleTargetStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+		return
+	}
+	names := []string{exportTargetAnytype, exportTargetNotion, exportTargetAirtable, exportTargetGDrive, exportTargetTelegram, exportTargetReadwise, exportTargetMemos, exportTargetTrilium, exportTargetOneNote, exportTargetMarkdown, exportTargetJSON, exportTargetExec, exportTargetWebhook}
+	statuses := make([]targetStatus, 0, len(names))
+	for _, target := range names {
+		statuses = append(statuses, s.targetBreaker(target).status())
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"targets": statuses,
+	})
+}
+
+type jobSubstitution struct {
+	ConversationID string `json:"conversation_id"`
+	Title          string `json:"title"`
+	Target         string `json:"target"`
+	targets.Substitution
+}
+
+// recordSubstitutions 记录对话写入目标时被替换为纯文本的区块。
+func (j *exportJob) recordSubstitutions(conv export.Conversation, target string, subs []targets.Substitution) {
+	if len(subs) == 0 {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, item := range subs {
+		j.Substitutions = append(j.Substitutions, jobSubstitution{ConversationID: conv.ID, Title: conv.Title, Target: target, Substitution: item})
+	}
+}
+
+func renderSubstitutionsMarkdown(items []jobSubstitution) string {
+	if len(items) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("\n## 以纯文本代替的区块 (%d)\n\n", len(items)))
+	b.WriteString("| 对话 ID | 标题 | 目标 | 区块 | 类型 | 原因 |\n")
+	b.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+	for _, item := range items {
+		b.WriteString(fmt.Sprintf("| `%s` | %s | %s | %d | %s | %s |\n",
+			item.ConversationID,
+			firstNonEmpty(escapeMarkdownTableCell(item.Title), "-"),
+			item.Target,
+			item.Block,
+			item.Type,
+			escapeMarkdownTableCell(item.Reason),
+		))
+	}
+	return b.String()
+}
@@ -0,0 +1,321 @@
+// Package airtable 把对话写入 Airtable 表格, 标题、时间、对话 ID 与 Markdown 正文分别映射到字段。
+package airtable
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/httpc"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+const (
+	defaultBaseURL    = "https://api.airtable.com"
+	defaultContentURL = "https://content.airtable.com"
+)
+
+// capabilities 为 Airtable 的限制: 长文本字段 100000 字符, uploadAttachment 单个文件 5 MB。
+var capabilities = targets.Capabilities{
+	MaxBodyLength: 100000,
+	MaxFileSize:   5 << 20,
+}
+
+const (
+	// BodyFields 把正文按长文本上限拆分写入 "<正文字段>"、"<正文字段> 2" 等多个字段。
+	BodyFields = "fields"
+	// BodyAttachment 把正文作为 Markdown 文件上传到正文字段 (附件类型)。
+	BodyAttachment = "attachment"
+)
+
+// 字段映射中可用的键。
+const (
+	FieldTitle          = "title"
+	FieldConversationID = "conversation_id"
+	FieldCreated        = "created"
+	FieldUpdated        = "updated"
+	FieldBody           = "body"
+)
+
+// DefaultFields 是未配置时使用的字段映射。
+var DefaultFields = map[string]string{
+	FieldTitle:          "Name",
+	FieldConversationID: "Conversation ID",
+	FieldCreated:        "Created",
+	FieldUpdated:        "Updated",
+	FieldBody:           "Body",
+}
+
+// Config 是创建 Client 所需的 Airtable 参数。
+type Config struct {
+	Token   string
+	BaseID  string
+	Table   string
+	BaseURL string
+	// BodyMode 取 BodyFields (默认) 或 BodyAttachment。
+	BodyMode string
+	// Fields 覆盖 DefaultFields 中的字段名, 值为空表示不写入该项。
+	Fields map[string]string
+	// Filename 为正文附件的文件名选项。
+	Filename export.FilenameOptions
+}
+
+// Client 通过 Airtable Web API 为每个对话创建一条记录。
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	contentURL string
+	token      string
+	baseID     string
+	table      string
+	bodyMode   string
+	fields     map[string]string
+	filename   export.FilenameOptions
+}
+
+type record struct {
+	ID     string                 `json:"id,omitempty"`
+	Fields map[string]interface{} `json:"fields"`
+}
+
+type createRequest struct {
+	Records  []record `json:"records"`
+	Typecast bool     `json:"typecast"`
+}
+
+type createResponse struct {
+	Records []record `json:"records"`
+}
+
+type errorResponse struct {
+	Error json.RawMessage `json:"error"`
+}
+
+// New 校验配置并创建 Client。
+func New(cfg Config) (*Client, error) {
+	token := strings.TrimSpace(cfg.Token)
+	if token == "" {
+		return nil, fmt.Errorf("缺少 Airtable Token: 请在配置页填写 airtable_token")
+	}
+	baseID := strings.TrimSpace(cfg.BaseID)
+	if baseID == "" {
+		return nil, fmt.Errorf("缺少 Airtable Base ID: 请在配置页填写 airtable_base_id")
+	}
+	table := strings.TrimSpace(cfg.Table)
+	if table == "" {
+		return nil, fmt.Errorf("缺少 Airtable 表名: 请在配置页填写 airtable_table")
+	}
+	bodyMode := NormalizeBodyMode(cfg.BodyMode)
+
+	baseURL := strings.TrimRight(strings.TrimSpace(cfg.BaseURL), "/")
+	contentURL := baseURL
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+		contentURL = defaultContentURL
+	}
+	if parsed, err := url.Parse(baseURL); err != nil || !parsed.IsAbs() {
+		return nil, fmt.Errorf("Airtable 基础地址无效: %s", cfg.BaseURL)
+	}
+
+	fields := make(map[string]string, len(DefaultFields))
+	for key, name := range DefaultFields {
+		fields[key] = name
+	}
+	for key, name := range cfg.Fields {
+		if _, ok := DefaultFields[key]; !ok {
+			return nil, fmt.Errorf("未知的 Airtable 字段映射: %s", key)
+		}
+		fields[key] = strings.TrimSpace(name)
+	}
+	if bodyMode == BodyAttachment && fields[FieldBody] == "" {
+		return nil, fmt.Errorf("正文以附件上传时必须配置 body 字段")
+	}
+
+	return &Client{
+		httpClient: httpc.Client(),
+		baseURL:    baseURL,
+		contentURL: contentURL,
+		token:      token,
+		baseID:     baseID,
+		table:      table,
+		bodyMode:   bodyMode,
+		fields:     fields,
+		filename:   cfg.Filename,
+	}, nil
+}
+
+// NormalizeBodyMode 将正文写入方式收敛为 BodyFields 或 BodyAttachment。
+func NormalizeBodyMode(value string) string {
+	if strings.EqualFold(strings.TrimSpace(value), BodyAttachment) {
+		return BodyAttachment
+	}
+	return BodyFields
+}
+
+// ParseFields 解析 "title=Name" 形式的字段映射, 每行或每个逗号分隔一项。
+func ParseFields(raw string) (map[string]string, error) {
+	fields := make(map[string]string)
+	for _, item := range strings.FieldsFunc(raw, func(r rune) bool { return r == '\n' || r == ',' }) {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		key, name, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("Airtable 字段映射格式无效, 应为 key=字段名: %s", item)
+		}
+		fields[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(name)
+	}
+	return fields, nil
+}
+
+// CreateConversation 为对话创建一条记录, 正文按配置写入长文本字段或上传为附件。
+func (c *Client) CreateConversation(ctx context.Context, conv export.Conversation, timezone string) (targets.Object, error) {
+	body := export.RenderMarkdown(conv, timezone)
+	fields := c.recordFields(conv, timezone, body)
+
+	payload, err := json.Marshal(createRequest{Records: []record{{Fields: fields}}, Typecast: true})
+	if err != nil {
+		return targets.Object{}, fmt.Errorf("序列化 Airtable 请求失败: %w", err)
+	}
+	endpoint := fmt.Sprintf("%s/v0/%s/%s", c.baseURL, url.PathEscape(c.baseID), url.PathEscape(c.table))
+	var created createResponse
+	if err := c.do(ctx, endpoint, payload, "创建 Airtable 记录", &created); err != nil {
+		return targets.Object{}, err
+	}
+	if len(created.Records) == 0 || created.Records[0].ID == "" {
+		return targets.Object{}, fmt.Errorf("Airtable 未返回记录 ID")
+	}
+	recordID := created.Records[0].ID
+
+	if c.bodyMode == BodyAttachment {
+		if err := c.uploadBody(ctx, recordID, conv, body); err != nil {
+			return targets.Object{}, err
+		}
+	}
+	return targets.Object{ID: recordID, URL: c.recordURL(recordID)}, nil
+}
+
+func (c *Client) recordFields(conv export.Conversation, timezone, body string) map[string]interface{} {
+	loc := export.ResolveLocation(timezone)
+	fields := make(map[string]interface{})
+	set := func(key string, value interface{}) {
+		if name := c.fields[key]; name != "" {
+			fields[name] = value
+		}
+	}
+	title := strings.TrimSpace(conv.Title)
+	if title == "" {
+		title = fmt.Sprintf("对话 %s", conv.ID)
+	}
+	set(FieldTitle, title)
+	set(FieldConversationID, conv.ID)
+	if conv.CreateTime > 0 {
+		set(FieldCreated, isoTime(conv.CreateTime, loc))
+	}
+	if conv.UpdateTime > 0 {
+		set(FieldUpdated, isoTime(conv.UpdateTime, loc))
+	}
+
+	name := c.fields[FieldBody]
+	if c.bodyMode != BodyFields || name == "" {
+		return fields
+	}
+	for idx, part := range capabilities.SplitBody(body) {
+		if idx == 0 {
+			fields[name] = part
+			continue
+		}
+		fields[fmt.Sprintf("%s %d", name, idx+1)] = part
+	}
+	return fields
+}
+
+// uploadBody 把 Markdown 正文作为附件上传到记录的正文字段。
+func (c *Client) uploadBody(ctx context.Context, recordID string, conv export.Conversation, body string) error {
+	if len(body) > capabilities.MaxFileSize {
+		return fmt.Errorf("对话正文超过 Airtable 附件 %d MB 上限", capabilities.MaxFileSize>>20)
+	}
+	payload, err := json.Marshal(map[string]string{
+		"contentType": "text/markdown",
+		"filename":    export.ConversationFilenameWith(conv, nil, c.filename),
+		"file":        base64.StdEncoding.EncodeToString([]byte(body)),
+	})
+	if err != nil {
+		return fmt.Errorf("序列化 Airtable 请求失败: %w", err)
+	}
+	endpoint := fmt.Sprintf("%s/v0/%s/%s/%s/uploadAttachment", c.contentURL, url.PathEscape(c.baseID), url.PathEscape(recordID), url.PathEscape(c.fields[FieldBody]))
+	return c.do(ctx, endpoint, payload, "上传 Airtable 附件", nil)
+}
+
+func (c *Client) do(ctx context.Context, endpoint string, payload []byte, action string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("构造 Airtable 请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("调用 Airtable 接口失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &targets.StatusError{Action: action, Status: resp.StatusCode, Message: errorMessage(targets.ReadBody(resp.Body))}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("解析 Airtable 响应失败: %w", err)
+	}
+	return nil
+}
+
+// errorMessage 提取 Airtable 错误响应中的说明, error 可能是字符串或 {type, message} 对象。
+func errorMessage(body string) string {
+	var parsed errorResponse
+	if err := json.Unmarshal([]byte(body), &parsed); err != nil || len(parsed.Error) == 0 {
+		return strings.TrimSpace(body)
+	}
+	var code string
+	if err := json.Unmarshal(parsed.Error, &code); err == nil {
+		return code
+	}
+	var detail struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(parsed.Error, &detail); err == nil && detail.Message != "" {
+		return detail.Type + ": " + detail.Message
+	}
+	return strings.TrimSpace(body)
+}
+
+// Capabilities 返回 Airtable 的写入限制。
+func (c *Client) Capabilities() targets.Capabilities {
+	return capabilities
+}
+
+// recordURL 仅在表以 ID (tbl 开头) 配置时能拼出记录链接。
+func (c *Client) recordURL(recordID string) string {
+	if !strings.HasPrefix(c.table, "tbl") {
+		return ""
+	}
+	return fmt.Sprintf("https://airtable.com/%s/%s/%s", c.baseID, c.table, recordID)
+}
+
+func isoTime(ts float64, loc *time.Location) string {
+	sec := int64(ts)
+	nsec := int64((ts - float64(sec)) * 1e9)
+	return time.Unix(sec, nsec).In(loc).Format(time.RFC3339)
+}
@@ -0,0 +1,305 @@
+package airtable
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+func TestNormalizeBodyMode(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", BodyFields},
+		{"fields", BodyFields},
+		{" Attachment ", BodyAttachment},
+		{"file", BodyFields},
+	}
+	for _, tt := range tests {
+		if got := NormalizeBodyMode(tt.value); got != tt.want {
+			t.Errorf("NormalizeBodyMode(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "空配置", raw: "", want: map[string]string{}},
+		{name: "逗号与换行分隔", raw: "Title = 标题, body=正文\nupdated=", want: map[string]string{"title": "标题", "body": "正文", "updated": ""}},
+		{name: "缺少等号", raw: "title", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFields(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseFields(%q) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "字符串错误码", body: `{"error":"NOT_FOUND"}`, want: "NOT_FOUND"},
+		{name: "对象错误", body: `{"error":{"type":"INVALID_PERMISSIONS","message":"无权访问"}}`, want: "INVALID_PERMISSIONS: 无权访问"},
+		{name: "非 JSON", body: " Bad Gateway \n", want: "Bad Gateway"},
+		{name: "缺少 error", body: `{"message":"x"}`, want: `{"message":"x"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorMessage(tt.body); got != tt.want {
+				t.Errorf("errorMessage(%q) = %q, want %q", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	valid := Config{Token: "t", BaseID: "app1", Table: "对话"}
+	with := func(change func(*Config)) Config {
+		cfg := valid
+		change(&cfg)
+		return cfg
+	}
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{name: "默认配置", cfg: valid},
+		{name: "缺少 Token", cfg: with(func(c *Config) { c.Token = " " }), wantErr: true},
+		{name: "缺少 Base ID", cfg: with(func(c *Config) { c.BaseID = "" }), wantErr: true},
+		{name: "缺少表名", cfg: with(func(c *Config) { c.Table = "" }), wantErr: true},
+		{name: "相对地址", cfg: with(func(c *Config) { c.BaseURL = "api.airtable.com" }), wantErr: true},
+		{name: "未知字段映射", cfg: with(func(c *Config) { c.Fields = map[string]string{"tags": "Tags"} }), wantErr: true},
+		{name: "附件模式缺少正文字段", cfg: with(func(c *Config) { c.BodyMode = BodyAttachment; c.Fields = map[string]string{FieldBody: ""} }), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (client.baseURL != defaultBaseURL || client.contentURL != defaultContentURL) {
+				t.Errorf("baseURL = %q contentURL = %q", client.baseURL, client.contentURL)
+			}
+		})
+	}
+}
+
+func TestRecordFields(t *testing.T) {
+	long := strings.Repeat(strings.Repeat("字", 999)+"\n\n", 150)
+	tests := []struct {
+		name     string
+		mode     string
+		fields   map[string]string
+		conv     export.Conversation
+		wantKeys []string
+	}{
+		{
+			name:     "默认映射",
+			conv:     export.Conversation{ID: "c1", Title: "标题", CreateTime: 1709283600, UpdateTime: 1709287200},
+			wantKeys: []string{"Body", "Conversation ID", "Created", "Name", "Updated"},
+		},
+		{
+			name:     "缺少时间时不写入时间字段",
+			conv:     export.Conversation{ID: "c1"},
+			wantKeys: []string{"Body", "Conversation ID", "Name"},
+		},
+		{
+			name:     "正文超过长文本上限时拆分到多个字段",
+			conv:     export.Conversation{ID: "c1", Messages: []export.Message{{Role: "user", Text: long}}},
+			fields:   map[string]string{FieldConversationID: ""},
+			wantKeys: []string{"Body", "Body 2", "Name"},
+		},
+		{
+			name:     "附件模式不写正文字段",
+			mode:     BodyAttachment,
+			conv:     export.Conversation{ID: "c1"},
+			fields:   map[string]string{FieldTitle: "标题"},
+			wantKeys: []string{"Conversation ID", "标题"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := New(Config{Token: "t", BaseID: "app1", Table: "tbl1", BodyMode: tt.mode, Fields: tt.fields})
+			if err != nil {
+				t.Fatal(err)
+			}
+			body := export.RenderMarkdown(tt.conv, "UTC")
+			got := client.recordFields(tt.conv, "UTC", body)
+			var keys []string
+			for key := range got {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			if strings.Join(keys, ",") != strings.Join(tt.wantKeys, ",") {
+				t.Errorf("字段 = %v, want %v", keys, tt.wantKeys)
+			}
+			if created, ok := got["Created"]; ok && created != "2024-03-01T09:00:00Z" {
+				t.Errorf("Created = %v", created)
+			}
+			if title, ok := got["Name"]; ok && tt.conv.Title == "" && title != "对话 c1" {
+				t.Errorf("Name = %v, want 对话 c1", title)
+			}
+		})
+	}
+}
+
+// fakeAirtable 记录创建记录与上传附件的请求; fail 非零时以该状态码拒绝第 failAt 次调用。
+type fakeAirtable struct {
+	mu      sync.Mutex
+	paths   []string
+	records []record
+	upload  map[string]string
+	fail    int
+	failAt  int
+}
+
+func (f *fakeAirtable) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.paths = append(f.paths, r.URL.EscapedPath())
+	if r.Header.Get("Authorization") != "Bearer pat" {
+		w.WriteHeader(http.StatusUnauthorized)
+		io.WriteString(w, `{"error":"AUTHENTICATION_REQUIRED"}`)
+		return
+	}
+	if f.fail != 0 && len(f.paths) == f.failAt {
+		w.WriteHeader(f.fail)
+		io.WriteString(w, `{"error":{"type":"UNKNOWN_FIELD_NAME","message":"Unknown field name: \"Body\""}}`)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/uploadAttachment") {
+		json.NewDecoder(r.Body).Decode(&f.upload)
+		io.WriteString(w, `{}`)
+		return
+	}
+	var req createRequest
+	json.NewDecoder(r.Body).Decode(&req)
+	f.records = append(f.records, req.Records...)
+	fmt.Fprintf(w, `{"records":[{"id":"rec%d","fields":{}}]}`, len(f.paths))
+}
+
+func TestCreateConversation(t *testing.T) {
+	conv := export.Conversation{ID: "c1", Title: "标题", Messages: []export.Message{{Role: "user", Text: "你好"}}}
+	tests := []struct {
+		name       string
+		mode       string
+		table      string
+		fail       int
+		failAt     int
+		wantPaths  []string
+		want       targets.Object
+		wantStatus int
+		wantErr    string
+	}{
+		{
+			name:      "正文写入字段",
+			table:     "tblConv",
+			wantPaths: []string{"/v0/app1/tblConv"},
+			want:      targets.Object{ID: "rec1", URL: "https://airtable.com/app1/tblConv/rec1"},
+		},
+		{
+			name:      "表名不是 ID 时不生成链接",
+			table:     "我的 对话",
+			wantPaths: []string{"/v0/app1/%E6%88%91%E7%9A%84%20%E5%AF%B9%E8%AF%9D"},
+			want:      targets.Object{ID: "rec1"},
+		},
+		{
+			name:      "正文上传为附件",
+			mode:      BodyAttachment,
+			table:     "tblConv",
+			wantPaths: []string{"/v0/app1/tblConv", "/v0/app1/rec1/Body/uploadAttachment"},
+			want:      targets.Object{ID: "rec1", URL: "https://airtable.com/app1/tblConv/rec1"},
+		},
+		{
+			name:       "字段不存在",
+			table:      "tblConv",
+			fail:       http.StatusUnprocessableEntity,
+			failAt:     1,
+			wantPaths:  []string{"/v0/app1/tblConv"},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantErr:    "UNKNOWN_FIELD_NAME",
+		},
+		{
+			name:       "附件上传失败",
+			mode:       BodyAttachment,
+			table:      "tblConv",
+			fail:       http.StatusBadRequest,
+			failAt:     2,
+			wantPaths:  []string{"/v0/app1/tblConv", "/v0/app1/rec1/Body/uploadAttachment"},
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "上传 Airtable 附件",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeAirtable{fail: tt.fail, failAt: tt.failAt}
+			server := httptest.NewServer(api)
+			defer server.Close()
+
+			client, err := New(Config{Token: "pat", BaseID: "app1", Table: tt.table, BaseURL: server.URL, BodyMode: tt.mode})
+			if err != nil {
+				t.Fatal(err)
+			}
+			obj, err := client.CreateConversation(context.Background(), conv, "UTC")
+			if strings.Join(api.paths, ",") != strings.Join(tt.wantPaths, ",") {
+				t.Errorf("请求路径 = %v, want %v", api.paths, tt.wantPaths)
+			}
+			if tt.wantStatus != 0 {
+				var statusErr *targets.StatusError
+				if !errors.As(err, &statusErr) || statusErr.Status != tt.wantStatus || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want 状态码 %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if obj.ID != tt.want.ID || obj.URL != tt.want.URL {
+				t.Errorf("Object = %+v, want %+v", obj, tt.want)
+			}
+			if len(api.records) != 1 || api.records[0].Fields["Name"] != "标题" || api.records[0].Fields["Conversation ID"] != "c1" {
+				t.Fatalf("记录 = %+v", api.records)
+			}
+			body, hasBody := api.records[0].Fields["Body"].(string)
+			if tt.mode == BodyAttachment {
+				data, _ := base64.StdEncoding.DecodeString(api.upload["file"])
+				if hasBody || api.upload["contentType"] != "text/markdown" || !strings.HasSuffix(api.upload["filename"], ".md") || !strings.Contains(string(data), "你好") {
+					t.Errorf("附件 = %v", api.upload)
+				}
+				return
+			}
+			if !strings.Contains(body, "你好") {
+				t.Errorf("Body = %q", body)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+// Package anytype 把导出的对话以 Markdown 正文写入 Anytype 对象, 可选把对话中的文件上传为文件对象。
+package anytype
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/httpc"
+	"github.com/Devoty/openai-backup/logging"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+var debug = strings.TrimSpace(os.Getenv("ANYTYPE_DEBUG")) != ""
+
+// Config 是创建 Client 所需的 Anytype 连接参数。
+type Config struct {
+	Token   string
+	SpaceID string
+	BaseURL string
+	TypeKey string
+	Version string
+	// UploadFiles 为 true 时把图片、语音与上传文件上传为文件对象, 正文中的文件改为指向对象的链接。
+	UploadFiles bool
+	// FilesProperty 为对象类型中的对象 (objects) 属性 key, 非空时把上传的文件对象写入该属性。
+	FilesProperty string
+}
+
+// Client 通过 Anytype 本地 API 为每个对话创建一个对象。
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	version    string
+	spaceID    string
+	typeKey    string
+	token      string
+
+	// 标签属性与已知标签的缓存, 见 tags.go。
+	tagMu         sync.Mutex
+	tagPropertyID string
+	tagIDs        map[string]string
+
+	// 文件上传, 见 files.go; noFileAPI 在接口不支持上传时置为 true。
+	uploadEnabled bool
+	filesKey      string
+	fileMu        sync.Mutex
+	noFileAPI     bool
+	// pending 记录结果不确定的创建请求, 见 dedupe.go。
+	pending targets.PendingCreates
+	// FetchAsset 下载 ChatGPT 文件内容 (文件指针或上传文件 ID), 为空时不上传文件。
+	FetchAsset func(ctx context.Context, pointer string) ([]byte, error)
+}
+
+type anytypeObjectResponse struct {
+	ID     string `json:"id"`
+	Object struct {
+		ID string `json:"id"`
+	} `json:"object"`
+}
+
+type anytypeErrorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type createAnytypeObjectRequest struct {
+	Body       string                 `json:"body,omitempty"`
+	Name       string                 `json:"name,omitempty"`
+	TypeKey    string                 `json:"type_key"`
+	Properties []anytypePropertyValue `json:"properties,omitempty"`
+}
+
+// New 校验配置并创建 Client。
+func New(cfg Config) (*Client, error) {
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("缺少 Anytype API Key: 请提供 --anytype-token 或设置环境变量 ANYTYPE_TOKEN/ANYTYPE_API_KEY")
+	}
+	if cfg.SpaceID == "" {
+		return nil, fmt.Errorf("缺少 Anytype 空间 ID: 请提供 --anytype-space-id 或设置环境变量 ANYTYPE_SPACE_ID")
+	}
+	if strings.TrimSpace(cfg.BaseURL) == "" {
+		return nil, fmt.Errorf("缺少 Anytype Base URL: 请提供 --anytype-base-url 或设置环境变量 ANYTYPE_BASE_URL")
+	}
+	if strings.TrimSpace(cfg.TypeKey) == "" {
+		return nil, fmt.Errorf("缺少 Anytype Type Key: 请提供 --anytype-type-key 或设置环境变量 ANYTYPE_TYPE_KEY")
+	}
+
+	base := strings.TrimRight(cfg.BaseURL, "/")
+	if parsed, err := url.Parse(base); err != nil || !parsed.IsAbs() {
+		return nil, fmt.Errorf("Anytype Base URL 无效: %s", cfg.BaseURL)
+	}
+
+	return &Client{
+		httpClient: httpc.Client(),
+		baseURL:    base,
+		version:    cfg.Version,
+		spaceID:    cfg.SpaceID,
+		typeKey:    cfg.TypeKey,
+		token:      cfg.Token,
+
+		uploadEnabled: cfg.UploadFiles,
+		filesKey:      strings.TrimSpace(cfg.FilesProperty),
+	}, nil
+}
+
+func (c *Client) createConversationObject(ctx context.Context, conv export.Conversation, body string, files []string) (string, error) {
+	name := objectName(conv)
+
+	if c.httpClient == nil {
+		return "", fmt.Errorf("Anytype HTTP 客户端未初始化")
+	}
+
+	properties, err := c.tagProperties(ctx, conv.Tags)
+	if err != nil {
+		return "", err
+	}
+	if link := export.ConversationURL(conv.ID); link != "" {
+		properties = append(properties, anytypePropertyValue{Key: sourcePropertyKey, URL: link})
+	}
+	if c.filesKey != "" && len(files) > 0 {
+		properties = append(properties, anytypePropertyValue{Key: c.filesKey, Objects: files})
+	}
+	payload := createAnytypeObjectRequest{
+		Body:       body,
+		Name:       name,
+		TypeKey:    c.typeKey,
+		Properties: properties,
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("序列化 Anytype 请求失败: %w", err)
+	}
+
+	target := fmt.Sprintf("%s/v1/spaces/%s/objects", c.baseURL, url.PathEscape(c.spaceID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("构造 Anytype 请求失败: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if c.version != "" {
+		req.Header.Set("Anytype-Version", c.version)
+	}
+
+	if debug {
+		logging.Infof("Anytype request: url=%s name=%s type=%s payload=%s", target, payload.Name, payload.TypeKey, string(data))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("调用 Anytype 接口失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var respBytes []byte
+	if debug {
+		respBytes, _ = io.ReadAll(resp.Body)
+		logging.Infof("Anytype response: status=%d url=%s body=%s", resp.StatusCode, target, strings.TrimSpace(string(respBytes)))
+		// 重置 reader 供后续解析
+		resp.Body = io.NopCloser(bytes.NewBuffer(respBytes))
+	}
+
+	if resp.StatusCode != http.StatusCreated {
+		msg := targets.ReadBody(resp.Body)
+		var apiErr anytypeErrorResponse
+		if err := json.Unmarshal([]byte(msg), &apiErr); err == nil && apiErr.Message != "" {
+			msg = apiErr.Message
+		}
+		logging.Infof("Anytype API error: status=%d url=%s body=%s", resp.StatusCode, target, strings.TrimSpace(msg))
+		return "", &targets.StatusError{Action: "创建 Anytype 对象", Status: resp.StatusCode, Message: strings.TrimSpace(msg)}
+	}
+
+	var result anytypeObjectResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("解析 Anytype 响应失败: %w", err)
+	}
+
+	return firstNonEmpty(result.Object.ID, result.ID), nil
+}
+
+// CreateConversation 以 Markdown 正文创建 Anytype 对象, 时间按 timezone 输出。
+// 开启文件上传时先上传对话中的文件, 正文中的文件链接指向上传得到的文件对象。
+func (c *Client) CreateConversation(ctx context.Context, conv export.Conversation, timezone string) (targets.Object, error) {
+	if since, ok := c.pending.Since(conv.ID); ok {
+		objectID, found, err := c.findCreatedObject(ctx, conv.ID, objectName(conv), since)
+		if err != nil {
+			return targets.Object{}, err
+		}
+		if found {
+			logging.Infof("找到上次结果不确定时已创建的 Anytype 对象, 不再重复创建: conversation=%s object=%s", conv.ID, objectID)
+			c.pending.Clear(conv.ID)
+			return targets.Object{ID: objectID, URL: c.objectURL(objectID)}, nil
+		}
+	}
+	files := c.uploadFiles(ctx, &conv)
+	body := export.RenderMarkdown(conv, timezone)
+	started := time.Now()
+	objectID, err := c.createConversationObject(ctx, conv, body, files)
+	c.pending.Finish(conv.ID, started, err)
+	if err != nil {
+		return targets.Object{}, err
+	}
+	return targets.Object{ID: objectID, URL: c.objectURL(objectID)}, nil
+}
+
+// objectURL 生成在 Anytype 桌面端直接打开对象的深链接。
+func (c *Client) objectURL(objectID string) string {
+	if objectID == "" {
+		return ""
+	}
+	query := url.Values{}
+	query.Set("objectId", objectID)
+	query.Set("spaceId", c.spaceID)
+	return "anytype://object?" + query.Encode()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,211 @@
+package anytype
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+// fakeAnytype 模拟空间 sp1 的 Anytype 本地 API, 按 "方法 路径" (去掉空间前缀) 记录收到的请求。
+type fakeAnytype struct {
+	mu       sync.Mutex
+	requests []string
+	objects  []createAnytypeObjectRequest
+	uploads  []string
+
+	// createStatus 非零时以该状态码拒绝创建对象; fileStatus 同理用于上传文件。
+	// dropCreate 为 true 时创建对象后直接断开连接, 模拟结果不确定的请求。
+	createStatus int
+	dropCreate   bool
+	fileStatus   int
+	// properties 为属性列表, tags 为标签属性下已有的标签。
+	properties string
+	tags       []anytypeTag
+	// search 为搜索接口的响应, markdown 为按对象 ID 读取到的正文。
+	search   string
+	markdown map[string]string
+}
+
+func (f *fakeAnytype) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path := strings.TrimPrefix(r.URL.Path, "/v1/spaces/sp1")
+	f.requests = append(f.requests, r.Method+" "+path)
+	if r.Header.Get("Authorization") != "Bearer key" || r.Header.Get("Anytype-Version") != "2025-05-20" {
+		w.WriteHeader(http.StatusUnauthorized)
+		io.WriteString(w, `{"code":"unauthorized","message":"invalid api key"}`)
+		return
+	}
+	switch {
+	case r.Method == http.MethodPost && path == "/objects":
+		if f.createStatus != 0 {
+			w.WriteHeader(f.createStatus)
+			io.WriteString(w, `{"code":"bad_request","message":"unknown type key"}`)
+			return
+		}
+		var req createAnytypeObjectRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		f.objects = append(f.objects, req)
+		if f.dropCreate {
+			if conn, _, err := http.NewResponseController(w).Hijack(); err == nil {
+				conn.Close()
+			}
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"object":{"id":"obj%d"}}`, len(f.objects))
+	case r.Method == http.MethodGet && path == "/properties":
+		io.WriteString(w, f.properties)
+	case r.Method == http.MethodGet && strings.HasSuffix(path, "/tags"):
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": f.tags})
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/tags"):
+		var req struct{ Name string }
+		json.NewDecoder(r.Body).Decode(&req)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"tag":{"id":"tag-%s","name":%q}}`, req.Name, req.Name)
+	case r.Method == http.MethodPost && path == "/search":
+		io.WriteString(w, f.search)
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/objects/"):
+		json.NewEncoder(w).Encode(map[string]interface{}{"object": map[string]string{"markdown": f.markdown[strings.TrimPrefix(path, "/objects/")]}})
+	case r.Method == http.MethodPost && path == "/files":
+		if f.fileStatus != 0 {
+			w.WriteHeader(f.fileStatus)
+			io.WriteString(w, `{"code":"internal","message":"upload failed"}`)
+			return
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		data, _ := io.ReadAll(file)
+		f.uploads = append(f.uploads, header.Filename+":"+string(data))
+		fmt.Fprintf(w, `{"file":{"id":"file%d"}}`, len(f.uploads))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// newFakeClient 启动 fakeAnytype 并返回连接到它的 Client。
+func newFakeClient(t *testing.T, api *fakeAnytype, cfg Config) *Client {
+	t.Helper()
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+	cfg.Token, cfg.SpaceID, cfg.BaseURL, cfg.TypeKey, cfg.Version = "key", "sp1", server.URL+"/", "page", "2025-05-20"
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestNew(t *testing.T) {
+	valid := Config{Token: "key", SpaceID: "sp1", BaseURL: "http://localhost:31009/", TypeKey: "page"}
+	with := func(change func(*Config)) Config {
+		cfg := valid
+		change(&cfg)
+		return cfg
+	}
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{name: "完整配置", cfg: valid},
+		{name: "缺少 API Key", cfg: with(func(c *Config) { c.Token = "" }), wantErr: true},
+		{name: "缺少空间 ID", cfg: with(func(c *Config) { c.SpaceID = "" }), wantErr: true},
+		{name: "缺少 Base URL", cfg: with(func(c *Config) { c.BaseURL = " " }), wantErr: true},
+		{name: "缺少 Type Key", cfg: with(func(c *Config) { c.TypeKey = "" }), wantErr: true},
+		{name: "相对地址", cfg: with(func(c *Config) { c.BaseURL = "anytype.local/api" }), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && client.baseURL != "http://localhost:31009" {
+				t.Errorf("baseURL = %q", client.baseURL)
+			}
+		})
+	}
+}
+
+func TestObjectURL(t *testing.T) {
+	c := &Client{spaceID: "bafy.space"}
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"", ""},
+		{"bafy obj", "anytype://object?objectId=bafy+obj&spaceId=bafy.space"},
+	}
+	for _, tt := range tests {
+		if got := c.objectURL(tt.id); got != tt.want {
+			t.Errorf("objectURL(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestCreateConversation(t *testing.T) {
+	const chatID = "0f5c2a3e-1b2c-4d5e-8f90-123456789abc"
+	tests := []struct {
+		name         string
+		conv         export.Conversation
+		createStatus int
+		wantName     string
+		wantSource   string
+		wantStatus   int
+	}{
+		{name: "创建对象并写入来源网址", conv: export.Conversation{ID: chatID, Title: "标题"}, wantName: "标题", wantSource: "https://chatgpt.com/c/" + chatID},
+		{name: "缺少标题时使用对话 ID", conv: export.Conversation{ID: "c1"}, wantName: "对话 c1"},
+		{name: "类型不存在", conv: export.Conversation{ID: "c1"}, createStatus: http.StatusBadRequest, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeAnytype{createStatus: tt.createStatus}
+			client := newFakeClient(t, api, Config{})
+			tt.conv.Messages = []export.Message{{Role: "user", Text: "你好"}}
+			obj, err := client.CreateConversation(context.Background(), tt.conv, "UTC")
+			if tt.wantStatus != 0 {
+				var statusErr *targets.StatusError
+				if !errors.As(err, &statusErr) || statusErr.Status != tt.wantStatus || !strings.Contains(err.Error(), "unknown type key") {
+					t.Fatalf("err = %v, want 状态码 %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if obj.ID != "obj1" || obj.URL != "anytype://object?objectId=obj1&spaceId=sp1" {
+				t.Errorf("Object = %+v", obj)
+			}
+			if len(api.objects) != 1 {
+				t.Fatalf("创建对象 %d 个, want 1", len(api.objects))
+			}
+			created := api.objects[0]
+			if created.Name != tt.wantName || created.TypeKey != "page" || !strings.Contains(created.Body, "你好") {
+				t.Errorf("请求 = %+v", created)
+			}
+			var source string
+			for _, prop := range created.Properties {
+				if prop.Key == sourcePropertyKey {
+					source = prop.URL
+				}
+			}
+			if source != tt.wantSource {
+				t.Errorf("来源网址 = %q, want %q", source, tt.wantSource)
+			}
+		})
+	}
+}
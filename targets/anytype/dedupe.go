@@ -0,0 +1,79 @@
+package anytype
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Devoty/openai-backup/export"
+)
+
+// Anytype 的创建接口不支持幂等键。创建请求超时或返回 5xx 时对象可能已经创建, 重试前先在空间中
+// 搜索请求开始之后创建、名称相同且正文含有对话 ID 的对象, 找到时直接使用它。
+
+type anytypeSearchResponse struct {
+	Data []anytypeSearchObject `json:"data"`
+}
+
+type anytypeSearchObject struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Properties []struct {
+		Key  string `json:"key"`
+		Date string `json:"date"`
+	} `json:"properties"`
+}
+
+// objectName 返回对话对象的名称, 标题为空时使用对话 ID。
+func objectName(conv export.Conversation) string {
+	if name := strings.TrimSpace(conv.Title); name != "" {
+		return name
+	}
+	return fmt.Sprintf("对话 %s", conv.ID)
+}
+
+// createdAt 返回对象的 created_date 属性, 没有或无法解析时返回零值。
+func (o anytypeSearchObject) createdAt() time.Time {
+	for _, prop := range o.Properties {
+		if prop.Key != "created_date" {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, prop.Date); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// findCreatedObject 在空间中查找 since 之后为对话创建的对象, 允许一分钟的时钟误差。
+func (c *Client) findCreatedObject(ctx context.Context, convID, name string, since time.Time) (string, bool, error) {
+	cutoff := since.Add(-time.Minute)
+	query := map[string]interface{}{
+		"query": name,
+		"types": []string{c.typeKey},
+		"sort":  map[string]string{"property_key": "created_date", "direction": "desc"},
+	}
+	var found anytypeSearchResponse
+	if err := c.doJSON(ctx, http.MethodPost, "搜索 Anytype 对象", c.spacePath("search"), query, &found); err != nil {
+		return "", false, fmt.Errorf("查找已创建的 Anytype 对象失败: %w", err)
+	}
+	for _, object := range found.Data {
+		if object.Name != name || object.createdAt().Before(cutoff) {
+			continue
+		}
+		var detail struct {
+			Object struct {
+				Markdown string `json:"markdown"`
+			} `json:"object"`
+		}
+		if err := c.doJSON(ctx, http.MethodGet, "读取 Anytype 对象", c.spacePath("objects", object.ID), nil, &detail); err != nil {
+			return "", false, fmt.Errorf("查找已创建的 Anytype 对象失败: %w", err)
+		}
+		if strings.Contains(detail.Object.Markdown, convID) {
+			return object.ID, true, nil
+		}
+	}
+	return "", false, nil
+}
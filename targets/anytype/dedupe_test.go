@@ -0,0 +1,144 @@
+package anytype
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Devoty/openai-backup/export"
+)
+
+func TestObjectName(t *testing.T) {
+	tests := []struct {
+		conv export.Conversation
+		want string
+	}{
+		{export.Conversation{ID: "c1", Title: " 标题 "}, "标题"},
+		{export.Conversation{ID: "c1", Title: " "}, "对话 c1"},
+	}
+	for _, tt := range tests {
+		if got := objectName(tt.conv); got != tt.want {
+			t.Errorf("objectName(%+v) = %q, want %q", tt.conv, got, tt.want)
+		}
+	}
+}
+
+func TestFindCreatedObject(t *testing.T) {
+	since := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	object := func(id, name string, created time.Time) string {
+		return fmt.Sprintf(`{"id":%q,"name":%q,"properties":[{"key":"created_date","date":%q}]}`, id, name, created.Format(time.RFC3339))
+	}
+	tests := []struct {
+		name         string
+		search       string
+		markdown     map[string]string
+		wantID       string
+		wantFound    bool
+		wantRequests []string
+	}{
+		{name: "没有搜索结果", search: `{"data":[]}`, wantRequests: []string{"POST /search"}},
+		{
+			name:         "名称不同或创建时间过早的对象不读取正文",
+			search:       `{"data":[` + object("o1", "其他", since) + `,` + object("o2", "标题", since.Add(-2*time.Minute)) + `]}`,
+			wantRequests: []string{"POST /search"},
+		},
+		{
+			name:         "正文含有对话 ID 时使用该对象",
+			search:       `{"data":[` + object("o1", "标题", since.Add(-30*time.Second)) + `]}`,
+			markdown:     map[string]string{"o1": "# 标题\n\n对话 ID: c1\n"},
+			wantID:       "o1",
+			wantFound:    true,
+			wantRequests: []string{"POST /search", "GET /objects/o1"},
+		},
+		{
+			name:         "同名对象属于其他对话",
+			search:       `{"data":[` + object("o1", "标题", since.Add(time.Minute)) + `,` + object("o2", "标题", since) + `]}`,
+			markdown:     map[string]string{"o1": "对话 ID: c9", "o2": "对话 ID: c1"},
+			wantID:       "o2",
+			wantFound:    true,
+			wantRequests: []string{"POST /search", "GET /objects/o1", "GET /objects/o2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeAnytype{search: tt.search, markdown: tt.markdown}
+			client := newFakeClient(t, api, Config{})
+			id, found, err := client.findCreatedObject(context.Background(), "c1", "标题", since)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id != tt.wantID || found != tt.wantFound {
+				t.Errorf("findCreatedObject() = %q, %v, want %q, %v", id, found, tt.wantID, tt.wantFound)
+			}
+			if strings.Join(api.requests, ",") != strings.Join(tt.wantRequests, ",") {
+				t.Errorf("请求 = %v, want %v", api.requests, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestCreateConversationAfterUncertainFailure(t *testing.T) {
+	conv := export.Conversation{ID: "c1", Title: "标题", Messages: []export.Message{{Role: "user", Text: "你好"}}}
+	tests := []struct {
+		name         string
+		firstStatus  int
+		drop         bool
+		search       string
+		wantID       string
+		wantRequests []string
+	}{
+		{
+			name:         "连接中断后找到已创建的对象",
+			drop:         true,
+			search:       `{"data":[{"id":"o-existing","name":"标题","properties":[{"key":"created_date","date":"NOW"}]}]}`,
+			wantID:       "o-existing",
+			wantRequests: []string{"POST /objects", "POST /search", "GET /objects/o-existing"},
+		},
+		{
+			name:         "连接中断后没有找到时重新创建",
+			drop:         true,
+			search:       `{"data":[]}`,
+			wantID:       "obj2",
+			wantRequests: []string{"POST /objects", "POST /search", "POST /objects"},
+		},
+		{
+			name:         "请求被拒绝时直接重试",
+			firstStatus:  http.StatusBadRequest,
+			wantID:       "obj1",
+			wantRequests: []string{"POST /objects", "POST /objects"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeAnytype{
+				createStatus: tt.firstStatus,
+				dropCreate:   tt.drop,
+				search:       strings.ReplaceAll(tt.search, "NOW", time.Now().UTC().Format(time.RFC3339)),
+				markdown:     map[string]string{"o-existing": "对话 ID: c1"},
+			}
+			client := newFakeClient(t, api, Config{})
+			if _, err := client.CreateConversation(context.Background(), conv, "UTC"); err == nil {
+				t.Fatal("第一次创建应失败")
+			}
+			api.mu.Lock()
+			api.createStatus, api.dropCreate = 0, false
+			api.mu.Unlock()
+			obj, err := client.CreateConversation(context.Background(), conv, "UTC")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if obj.ID != tt.wantID {
+				t.Errorf("Object.ID = %q, want %q", obj.ID, tt.wantID)
+			}
+			if strings.Join(api.requests, ",") != strings.Join(tt.wantRequests, ",") {
+				t.Errorf("请求 = %v, want %v", api.requests, tt.wantRequests)
+			}
+			if _, pending := client.pending.Since(conv.ID); pending {
+				t.Error("成功后应清除不确定的创建记录")
+			}
+		})
+	}
+}
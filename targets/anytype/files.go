@@ -0,0 +1,155 @@
+package anytype
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"path"
+	"strings"
+
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/logging"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+// errFilesUnsupported 表示 Anytype 的 API 版本没有文件上传接口, 之后不再尝试上传。
+var errFilesUnsupported = errors.New("当前 Anytype 版本不支持通过 API 上传文件")
+
+// uploadFiles 把对话中的图片、语音与上传文件上传为 Anytype 文件对象, 并把它们的 Path 改为对象链接,
+// 返回上传得到的对象 ID。单个文件失败只记录日志, 正文中保留原始指针。
+func (c *Client) uploadFiles(ctx context.Context, conv *export.Conversation) []string {
+	if !c.uploadEnabled || c.FetchAsset == nil {
+		return nil
+	}
+	uploaded := make(map[string]string)
+	var ids []string
+	upload := func(key, label, filename string) string {
+		if id, ok := uploaded[key]; ok {
+			return id
+		}
+		if c.filesUnsupported() {
+			return ""
+		}
+		data, err := c.FetchAsset(ctx, key)
+		if err != nil {
+			logging.Infof("下载%s失败: conversation=%s file=%s err=%v", label, conv.ID, key, err)
+			return ""
+		}
+		id, err := c.uploadFile(ctx, filename, data)
+		if errors.Is(err, errFilesUnsupported) {
+			logging.Infof("%v, 文件只保留原始指针", err)
+			return ""
+		}
+		if err != nil {
+			logging.Infof("上传%s到 Anytype 失败: conversation=%s file=%s err=%v", label, conv.ID, key, err)
+			return ""
+		}
+		uploaded[key] = id
+		ids = append(ids, id)
+		return id
+	}
+
+	// 复制消息与文件列表, 避免修改调用方共享的数据。
+	conv.Messages = append([]export.Message(nil), conv.Messages...)
+	for i := range conv.Messages {
+		msg := &conv.Messages[i]
+		if len(msg.Assets) == 0 {
+			continue
+		}
+		msg.Assets = append([]export.Asset(nil), msg.Assets...)
+		for j := range msg.Assets {
+			asset := &msg.Assets[j]
+			if asset.Pointer == "" || (asset.Kind != export.AssetImage && asset.Kind != export.AssetAudio) {
+				continue
+			}
+			if id := upload(asset.Pointer, export.AssetKindLabel(asset.Kind), path.Base(export.AssetArchivePath(*asset))); id != "" {
+				asset.Path = c.objectURL(id)
+			}
+		}
+	}
+	conv.Attachments = append([]export.Attachment(nil), conv.Attachments...)
+	for i := range conv.Attachments {
+		att := &conv.Attachments[i]
+		if att.ID == "" {
+			continue
+		}
+		if id := upload(att.ID, "上传文件", firstNonEmpty(att.Name, path.Base(export.AttachmentArchivePath(*att)))); id != "" {
+			att.Path = c.objectURL(id)
+		}
+	}
+	return ids
+}
+
+// uploadFile 通过 POST /v1/spaces/{space_id}/files 上传文件, 返回文件对象 ID。
+// 接口返回 404 或 405 时记为不支持, 返回 errFilesUnsupported。
+func (c *Client) uploadFile(ctx context.Context, filename string, data []byte) (string, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, strings.ReplaceAll(filename, `"`, "")))
+	header.Set("Content-Type", http.DetectContentType(data))
+	part, err := form.CreatePart(header)
+	if err != nil {
+		return "", fmt.Errorf("构造上传内容失败: %w", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		return "", fmt.Errorf("构造上传内容失败: %w", err)
+	}
+	if err := form.Close(); err != nil {
+		return "", fmt.Errorf("构造上传内容失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.spacePath("files"), &body)
+	if err != nil {
+		return "", fmt.Errorf("构造 Anytype 请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if c.version != "" {
+		req.Header.Set("Anytype-Version", c.version)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("调用 Anytype 接口失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed {
+		c.fileMu.Lock()
+		c.noFileAPI = true
+		c.fileMu.Unlock()
+		return "", errFilesUnsupported
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		msg := targets.ReadBody(resp.Body)
+		var apiErr anytypeErrorResponse
+		if err := json.Unmarshal([]byte(msg), &apiErr); err == nil && apiErr.Message != "" {
+			msg = apiErr.Message
+		}
+		return "", &targets.StatusError{Action: "上传 Anytype 文件", Status: resp.StatusCode, Message: strings.TrimSpace(msg)}
+	}
+	var result struct {
+		anytypeObjectResponse
+		File struct {
+			ID string `json:"id"`
+		} `json:"file"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("解析 Anytype 响应失败: %w", err)
+	}
+	id := firstNonEmpty(result.File.ID, result.Object.ID, result.ID)
+	if id == "" {
+		return "", fmt.Errorf("Anytype 未返回文件对象 ID")
+	}
+	return id, nil
+}
+
+func (c *Client) filesUnsupported() bool {
+	c.fileMu.Lock()
+	defer c.fileMu.Unlock()
+	return c.noFileAPI
+}
@@ -0,0 +1,102 @@
+package anytype
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Devoty/openai-backup/export"
+)
+
+func TestUploadFiles(t *testing.T) {
+	conv := export.Conversation{
+		ID: "c1",
+		Messages: []export.Message{
+			{Role: "assistant", Assets: []export.Asset{
+				{Kind: export.AssetImage, Pointer: "file-service://file-cat", Format: "png"},
+				{Kind: export.AssetAudio, Pointer: "sediment://file-voice", Format: "wav"},
+			}},
+			{Role: "assistant", Assets: []export.Asset{{Kind: export.AssetImage, Pointer: "file-service://file-cat", Format: "png"}}},
+		},
+		Attachments: []export.Attachment{{ID: "file-doc", Name: "报告.pdf"}, {Name: "没有 ID"}},
+	}
+	fetch := func(ctx context.Context, pointer string) ([]byte, error) {
+		if pointer == "sediment://file-voice" {
+			return nil, errors.New("文件已过期")
+		}
+		return []byte("data-" + pointer), nil
+	}
+	tests := []struct {
+		name        string
+		disabled    bool
+		fetch       func(ctx context.Context, pointer string) ([]byte, error)
+		fileStatus  int
+		wantIDs     []string
+		wantUploads []string
+		wantCalls   int
+		wantPaths   []string
+	}{
+		{name: "未开启上传", disabled: true, fetch: fetch, wantPaths: []string{"", "", "", ""}},
+		{name: "未配置下载", wantPaths: []string{"", "", "", ""}},
+		{
+			name:        "上传并复用重复文件",
+			fetch:       fetch,
+			wantIDs:     []string{"file1", "file2"},
+			wantUploads: []string{"file-cat.png:data-file-service://file-cat", "报告.pdf:data-file-doc"},
+			wantCalls:   2,
+			wantPaths: []string{
+				"anytype://object?objectId=file1&spaceId=sp1", "",
+				"anytype://object?objectId=file1&spaceId=sp1",
+				"anytype://object?objectId=file2&spaceId=sp1",
+			},
+		},
+		{name: "接口不支持上传时不再尝试", fetch: fetch, fileStatus: http.StatusNotFound, wantCalls: 1, wantPaths: []string{"", "", "", ""}},
+		{name: "上传失败时保留原始指针", fetch: fetch, fileStatus: http.StatusBadRequest, wantCalls: 3, wantPaths: []string{"", "", "", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeAnytype{fileStatus: tt.fileStatus}
+			client := newFakeClient(t, api, Config{UploadFiles: !tt.disabled})
+			client.FetchAsset = tt.fetch
+			uploaded := conv
+			ids := client.uploadFiles(context.Background(), &uploaded)
+			if strings.Join(ids, ",") != strings.Join(tt.wantIDs, ",") || strings.Join(api.uploads, ",") != strings.Join(tt.wantUploads, ",") {
+				t.Errorf("uploadFiles() = %v 上传 = %q, want %v %q", ids, api.uploads, tt.wantIDs, tt.wantUploads)
+			}
+			if len(api.requests) != tt.wantCalls {
+				t.Errorf("请求 = %v, want %d 次", api.requests, tt.wantCalls)
+			}
+			paths := []string{uploaded.Messages[0].Assets[0].Path, uploaded.Messages[0].Assets[1].Path, uploaded.Messages[1].Assets[0].Path, uploaded.Attachments[0].Path}
+			if strings.Join(paths, ",") != strings.Join(tt.wantPaths, ",") {
+				t.Errorf("Path = %q, want %q", paths, tt.wantPaths)
+			}
+			if conv.Messages[0].Assets[0].Path != "" || conv.Attachments[0].Path != "" {
+				t.Fatal("uploadFiles 修改了调用方的对话")
+			}
+		})
+	}
+}
+
+func TestCreateConversationFilesProperty(t *testing.T) {
+	api := &fakeAnytype{}
+	client := newFakeClient(t, api, Config{UploadFiles: true, FilesProperty: " files "})
+	client.FetchAsset = func(ctx context.Context, pointer string) ([]byte, error) { return []byte(pointer), nil }
+	conv := export.Conversation{ID: "c1", Title: "标题", Attachments: []export.Attachment{{ID: "file-doc", Name: "报告.pdf"}}}
+	if _, err := client.CreateConversation(context.Background(), conv, "UTC"); err != nil {
+		t.Fatal(err)
+	}
+	if len(api.objects) != 1 {
+		t.Fatalf("创建对象 %d 个, want 1", len(api.objects))
+	}
+	var files []string
+	for _, prop := range api.objects[0].Properties {
+		if prop.Key == "files" {
+			files = prop.Objects
+		}
+	}
+	if strings.Join(files, ",") != "file1" || !strings.Contains(api.objects[0].Body, "anytype://object?objectId=file1") {
+		t.Errorf("files = %v 正文 = %q", files, api.objects[0].Body)
+	}
+}
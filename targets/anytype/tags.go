@@ -0,0 +1,168 @@
+package anytype
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Devoty/openai-backup/targets"
+)
+
+// tagPropertyKey 是 Anytype 内置的标签属性, 对话的 Tags 写入该属性。
+const tagPropertyKey = "tag"
+
+// sourcePropertyKey 是 Anytype 内置的来源网址属性, 写入原始对话的地址。
+const sourcePropertyKey = "source"
+
+type anytypeProperty struct {
+	ID     string `json:"id"`
+	Key    string `json:"key"`
+	Format string `json:"format"`
+}
+
+type anytypeTag struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// anytypePropertyValue 是创建对象时的属性值, 标签属性为 multi_select, 值为标签 ID; 网址属性为 url;
+// 对象属性为 objects, 值为对象 ID。
+type anytypePropertyValue struct {
+	Key         string   `json:"key"`
+	MultiSelect []string `json:"multi_select,omitempty"`
+	URL         string   `json:"url,omitempty"`
+	Objects     []string `json:"objects,omitempty"`
+}
+
+// tagProperties 把标签名称转换为创建对象时的属性值, 空间中还没有的标签会自动创建。
+func (c *Client) tagProperties(ctx context.Context, names []string) ([]anytypePropertyValue, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	ids := make([]string, 0, len(names))
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		id, err := c.tagID(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return []anytypePropertyValue{{Key: tagPropertyKey, MultiSelect: ids}}, nil
+}
+
+// tagID 返回名称对应的标签 ID (不区分大小写), 不存在时创建。结果缓存在 Client 中。
+func (c *Client) tagID(ctx context.Context, name string) (string, error) {
+	c.tagMu.Lock()
+	defer c.tagMu.Unlock()
+	key := strings.ToLower(name)
+	if id, ok := c.tagIDs[key]; ok {
+		return id, nil
+	}
+	if c.tagPropertyID == "" {
+		var props struct {
+			Data []anytypeProperty `json:"data"`
+		}
+		if err := c.doJSON(ctx, http.MethodGet, "读取 Anytype 属性", c.spacePath("properties")+"?limit=1000", nil, &props); err != nil {
+			return "", err
+		}
+		for _, prop := range props.Data {
+			if prop.Key == tagPropertyKey {
+				c.tagPropertyID = prop.ID
+				break
+			}
+		}
+		if c.tagPropertyID == "" {
+			return "", fmt.Errorf("Anytype 空间中没有 %s 属性, 无法写入标签", tagPropertyKey)
+		}
+	}
+	tagsPath := c.spacePath("properties", c.tagPropertyID, "tags")
+	if c.tagIDs == nil {
+		var tags struct {
+			Data []anytypeTag `json:"data"`
+		}
+		if err := c.doJSON(ctx, http.MethodGet, "读取 Anytype 标签", tagsPath, nil, &tags); err != nil {
+			return "", err
+		}
+		c.tagIDs = make(map[string]string, len(tags.Data))
+		for _, tag := range tags.Data {
+			c.tagIDs[strings.ToLower(strings.TrimSpace(tag.Name))] = tag.ID
+		}
+		if id, ok := c.tagIDs[key]; ok {
+			return id, nil
+		}
+	}
+	var created struct {
+		Tag anytypeTag `json:"tag"`
+	}
+	if err := c.doJSON(ctx, http.MethodPost, "创建 Anytype 标签", tagsPath, map[string]string{"name": name, "color": "grey"}, &created); err != nil {
+		return "", err
+	}
+	if created.Tag.ID == "" {
+		return "", fmt.Errorf("创建 Anytype 标签 %s 失败: 响应中没有标签 ID", name)
+	}
+	c.tagIDs[key] = created.Tag.ID
+	return created.Tag.ID, nil
+}
+
+// spacePath 拼接当前空间下的接口地址。
+func (c *Client) spacePath(parts ...string) string {
+	path := fmt.Sprintf("%s/v1/spaces/%s", c.baseURL, url.PathEscape(c.spaceID))
+	for _, part := range parts {
+		path += "/" + url.PathEscape(part)
+	}
+	return path
+}
+
+// doJSON 发送 JSON 请求并解析响应, body 为 nil 时不带请求体。
+func (c *Client) doJSON(ctx context.Context, method, action, target string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("序列化 Anytype 请求失败: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return fmt.Errorf("构造 Anytype 请求失败: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if c.version != "" {
+		req.Header.Set("Anytype-Version", c.version)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("调用 Anytype 接口失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		msg := targets.ReadBody(resp.Body)
+		var apiErr anytypeErrorResponse
+		if err := json.Unmarshal([]byte(msg), &apiErr); err == nil && apiErr.Message != "" {
+			msg = apiErr.Message
+		}
+		return &targets.StatusError{Action: action, Status: resp.StatusCode, Message: strings.TrimSpace(msg)}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("解析 Anytype 响应失败: %w", err)
+	}
+	return nil
+}
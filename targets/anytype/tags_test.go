@@ -0,0 +1,106 @@
+package anytype
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Devoty/openai-backup/export"
+)
+
+const tagPropertiesJSON = `{"data":[{"id":"p-status","key":"status","format":"select"},{"id":"p-tag","key":"tag","format":"multi_select"}]}`
+
+func TestTagProperties(t *testing.T) {
+	tests := []struct {
+		name         string
+		names        []string
+		properties   string
+		existing     []anytypeTag
+		wantIDs      []string
+		wantRequests []string
+		wantErr      string
+	}{
+		{name: "没有标签", names: []string{" ", ""}, properties: tagPropertiesJSON},
+		{
+			name:         "使用已有标签并创建缺少的标签",
+			names:        []string{"工作", " Go ", "工作", "go"},
+			properties:   tagPropertiesJSON,
+			existing:     []anytypeTag{{ID: "t-work", Name: "工作 "}},
+			wantIDs:      []string{"t-work", "tag-Go"},
+			wantRequests: []string{"GET /properties", "GET /properties/p-tag/tags", "POST /properties/p-tag/tags"},
+		},
+		{
+			name:         "空间中没有标签属性",
+			names:        []string{"工作"},
+			properties:   `{"data":[{"id":"p-status","key":"status"}]}`,
+			wantRequests: []string{"GET /properties"},
+			wantErr:      "没有 tag 属性",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeAnytype{properties: tt.properties, tags: tt.existing}
+			client := newFakeClient(t, api, Config{})
+			got, err := client.tagProperties(context.Background(), tt.names)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want 包含 %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(api.requests, ",") != strings.Join(tt.wantRequests, ",") {
+				t.Errorf("请求 = %v, want %v", api.requests, tt.wantRequests)
+			}
+			if tt.wantIDs == nil {
+				if got != nil {
+					t.Errorf("tagProperties() = %+v, want nil", got)
+				}
+				return
+			}
+			if len(got) != 1 || got[0].Key != tagPropertyKey || strings.Join(got[0].MultiSelect, ",") != strings.Join(tt.wantIDs, ",") {
+				t.Errorf("tagProperties() = %+v, want %v", got, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestTagIDCache(t *testing.T) {
+	api := &fakeAnytype{properties: tagPropertiesJSON, tags: []anytypeTag{{ID: "t-work", Name: "工作"}}}
+	client := newFakeClient(t, api, Config{})
+	convs := []export.Conversation{
+		{ID: "c1", Title: "一", Tags: []string{"工作", "新标签"}},
+		{ID: "c2", Title: "二", Tags: []string{"新标签", "工作"}},
+	}
+	for _, conv := range convs {
+		if _, err := client.CreateConversation(context.Background(), conv, "UTC"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{
+		"GET /properties", "GET /properties/p-tag/tags", "POST /properties/p-tag/tags", "POST /objects",
+		"POST /objects",
+	}
+	if strings.Join(api.requests, ",") != strings.Join(want, ",") {
+		t.Errorf("请求 = %v, want %v", api.requests, want)
+	}
+	if tags := api.objects[1].Properties[0].MultiSelect; strings.Join(tags, ",") != "tag-新标签,t-work" {
+		t.Errorf("第二个对象的标签 = %v", tags)
+	}
+}
+
+func TestSpacePath(t *testing.T) {
+	c := &Client{baseURL: "http://localhost:31009", spaceID: "bafy/space"}
+	tests := []struct {
+		parts []string
+		want  string
+	}{
+		{nil, "http://localhost:31009/v1/spaces/bafy%2Fspace"},
+		{[]string{"properties", "p 1", "tags"}, "http://localhost:31009/v1/spaces/bafy%2Fspace/properties/p%201/tags"},
+	}
+	for _, tt := range tests {
+		if got := c.spacePath(tt.parts...); got != tt.want {
+			t.Errorf("spacePath(%q) = %q, want %q", tt.parts, got, tt.want)
+		}
+	}
+}
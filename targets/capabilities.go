@@ -0,0 +1,108 @@
+package targets
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Capabilities 描述导出目标单次写入的内容限制, 客户端按此拆分或截断渲染结果。
+// 各项为 0 表示不限制; 除 MaxFileSize 外均按字符计算。
+type Capabilities struct {
+	// MaxTitleLength 是标题的字符上限, 超出时截断。
+	MaxTitleLength int
+	// MaxTextLength 是单段文本 (如 Notion 的一个 rich_text) 的字符上限, 超出时按字符切分。
+	MaxTextLength int
+	// MaxTextsPerBlock 是单个区块可包含的文本段数量上限, 超出部分丢弃。
+	MaxTextsPerBlock int
+	// MaxBodyLength 是单个正文字段或单条消息的字符上限, 超出时在段落处拆分为多份。
+	MaxBodyLength int
+	// MaxFileSize 是上传文件的字节上限。
+	MaxFileSize int
+	// MaxBlocksPerRequest 是单次请求可写入的区块数量上限, 超出部分需分批追加。
+	MaxBlocksPerRequest int
+	// BlockTypes 为支持的区块类型, 为空表示目标不区分区块 (纯文本或文件)。
+	BlockTypes []string
+}
+
+// Limited 由存在内容限制的导出目标实现。
+type Limited interface {
+	Capabilities() Capabilities
+}
+
+// CapabilitiesOf 返回导出目标声明的限制, 未声明时返回零值 (不限制)。
+func CapabilitiesOf(exporter Exporter) Capabilities {
+	if limited, ok := exporter.(Limited); ok {
+		return limited.Capabilities()
+	}
+	return Capabilities{}
+}
+
+// Supports 判断是否支持指定区块类型。未声明区块类型时始终返回 true。
+func (c Capabilities) Supports(blockType string) bool {
+	if len(c.BlockTypes) == 0 {
+		return true
+	}
+	for _, t := range c.BlockTypes {
+		if t == blockType {
+			return true
+		}
+	}
+	return false
+}
+
+// TruncateTitle 把标题截断到 MaxTitleLength 以内, 末尾以省略号标记。
+func (c Capabilities) TruncateTitle(title string) string {
+	limit := c.MaxTitleLength
+	if limit <= 0 || utf8.RuneCountInString(title) <= limit {
+		return title
+	}
+	runes := []rune(title)
+	return string(runes[:limit-1]) + "…"
+}
+
+// ChunkText 按 MaxTextLength 切分文本, 不考虑断句; 空文本返回 nil。
+func (c Capabilities) ChunkText(text string) []string {
+	runes := []rune(text)
+	if len(runes) == 0 {
+		return nil
+	}
+	limit := c.MaxTextLength
+	if limit <= 0 {
+		return []string{text}
+	}
+	parts := make([]string, 0, len(runes)/limit+1)
+	for start := 0; start < len(runes); start += limit {
+		end := start + limit
+		if end > len(runes) {
+			end = len(runes)
+		}
+		parts = append(parts, string(runes[start:end]))
+	}
+	return parts
+}
+
+// SplitBody 按 MaxBodyLength 拆分正文, 尽量在空行或换行处断开; 至少返回一份。
+func (c Capabilities) SplitBody(text string) []string {
+	limit := c.MaxBodyLength
+	if limit <= 0 || utf8.RuneCountInString(text) <= limit {
+		return []string{text}
+	}
+	var parts []string
+	for utf8.RuneCountInString(text) > limit {
+		runes := []rune(text)
+		window := string(runes[:limit])
+		cut := strings.LastIndex(window, "\n\n")
+		if cut <= len(window)/2 {
+			cut = strings.LastIndex(window, "\n")
+		}
+		if cut <= len(window)/2 {
+			cut = len(window)
+		}
+		parts = append(parts, strings.TrimRight(window[:cut], "\n"))
+		text = strings.TrimLeft(text[cut:], "\n")
+	}
+	if text != "" {
+		parts = append(parts, text)
+	}
+	return parts
+}
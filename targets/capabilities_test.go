@@ -0,0 +1,105 @@
+package targets
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCapabilitiesSupports(t *testing.T) {
+	tests := []struct {
+		name  string
+		types []string
+		block string
+		want  bool
+	}{
+		{name: "未声明区块类型", types: nil, block: "table", want: true},
+		{name: "支持的类型", types: []string{"paragraph", "code"}, block: "code", want: true},
+		{name: "不支持的类型", types: []string{"paragraph", "code"}, block: "table", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (Capabilities{BlockTypes: tt.types}).Supports(tt.block); got != tt.want {
+				t.Errorf("Supports(%q) = %v, want %v", tt.block, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCapabilitiesTruncateTitle(t *testing.T) {
+	tests := []struct {
+		limit int
+		title string
+		want  string
+	}{
+		{0, "不限制长度的标题", "不限制长度的标题"},
+		{5, "只有四字", "只有四字"},
+		{5, "五个字标题", "五个字标题"},
+		{5, "超过五个字的标题", "超过五个…"},
+		{3, "abcdef", "ab…"},
+	}
+	for _, tt := range tests {
+		if got := (Capabilities{MaxTitleLength: tt.limit}).TruncateTitle(tt.title); got != tt.want {
+			t.Errorf("TruncateTitle(%q, %d) = %q, want %q", tt.title, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestCapabilitiesChunkText(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+		text  string
+		want  []string
+	}{
+		{name: "空文本", limit: 3, text: "", want: nil},
+		{name: "不限制", limit: 0, text: "abcdef", want: []string{"abcdef"}},
+		{name: "整除", limit: 3, text: "abcdef", want: []string{"abc", "def"}},
+		{name: "按字符而不是字节", limit: 2, text: "中文字符", want: []string{"中文", "字符"}},
+		{name: "末段较短", limit: 4, text: "abcdefghij", want: []string{"abcd", "efgh", "ij"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (Capabilities{MaxTextLength: tt.limit}).ChunkText(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ChunkText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCapabilitiesSplitBody(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+		text  string
+		want  []string
+	}{
+		{name: "不超过上限", limit: 100, text: "短文本", want: []string{"短文本"}},
+		{name: "不限制", limit: 0, text: strings.Repeat("a", 50), want: []string{strings.Repeat("a", 50)}},
+		{
+			name:  "在空行处断开",
+			limit: 12,
+			text:  "first para\n\nsecond para",
+			want:  []string{"first para", "second para"},
+		},
+		{
+			name:  "没有空行时在换行处断开",
+			limit: 10,
+			text:  "line one\nline two",
+			want:  []string{"line one", "line two"},
+		},
+		{
+			name:  "断点过早时硬切",
+			limit: 6,
+			text:  "a\nbcdefghijk",
+			want:  []string{"a\nbcde", "fghijk"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (Capabilities{MaxBodyLength: tt.limit}).SplitBody(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitBody() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
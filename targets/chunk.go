@@ -0,0 +1,152 @@
+package targets
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 文本切分方式, 见 Capabilities.ChunkTextBy。
+const (
+	// ChunkRunes 按字符数硬切, 可能拆开 emoji 组合或单词。
+	ChunkRunes = "runes"
+	// ChunkGraphemes 只在字素簇之间切分, 不拆开 emoji 组合、组合音标与韩文字母。
+	ChunkGraphemes = "graphemes"
+	// ChunkWords 在字素簇的基础上优先在空白、中日韩文字之间或全角标点后切分。
+	ChunkWords = "words"
+)
+
+// NormalizeChunkMode 将切分方式收敛为 ChunkRunes、ChunkGraphemes 或 ChunkWords (默认)。
+func NormalizeChunkMode(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case ChunkRunes:
+		return ChunkRunes
+	case ChunkGraphemes:
+		return ChunkGraphemes
+	default:
+		return ChunkWords
+	}
+}
+
+// ChunkTextBy 按 MaxTextLength 与指定方式切分文本; 每段不超过上限, 拼接后与原文一致。空文本返回 nil。
+func (c Capabilities) ChunkTextBy(text, mode string) []string {
+	mode = NormalizeChunkMode(mode)
+	limit := c.MaxTextLength
+	if mode == ChunkRunes || limit <= 0 || utf8.RuneCountInString(text) <= limit {
+		return c.ChunkText(text)
+	}
+	clusters := graphemeClusters(text)
+	var parts []string
+	start, runes := 0, 0
+	for i := 0; i < len(clusters); i++ {
+		size := utf8.RuneCountInString(clusters[i])
+		if runes+size <= limit || i == start {
+			runes += size
+			continue
+		}
+		cut := i
+		if mode == ChunkWords {
+			cut = wordBreak(clusters, start, i)
+		}
+		parts = append(parts, strings.Join(clusters[start:cut], ""))
+		start, runes = cut, 0
+		i = cut - 1
+	}
+	if start < len(clusters) {
+		parts = append(parts, strings.Join(clusters[start:], ""))
+	}
+	// 单个字素簇超过上限 (极少见) 时退回按字符切分。
+	out := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if utf8.RuneCountInString(part) > limit {
+			out = append(out, c.ChunkText(part)...)
+			continue
+		}
+		out = append(out, part)
+	}
+	return out
+}
+
+// wordBreak 在 clusters[start:end] 的后半段寻找最后一个断词位置, 找不到时返回 end。
+func wordBreak(clusters []string, start, end int) int {
+	for cut := end; cut > start+(end-start)/2; cut-- {
+		if canBreakBetween(clusters[cut-1], clusters[cut]) {
+			return cut
+		}
+	}
+	return end
+}
+
+func canBreakBetween(before, after string) bool {
+	last, _ := utf8.DecodeLastRuneInString(before)
+	first, _ := utf8.DecodeRuneInString(after)
+	switch {
+	case unicode.IsSpace(last):
+		return true
+	case unicode.IsSpace(first), isClosingPunct(first):
+		return false
+	case isWideRune(last) || isWideRune(first):
+		return true
+	}
+	return false
+}
+
+// isWideRune 判断是否为可在任意字之间断行的中日文字或全角标点。
+func isWideRune(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) ||
+		r >= 0x3000 && r <= 0x303F || r >= 0xFF00 && r <= 0xFFEF
+}
+
+// isClosingPunct 中日文的句末与闭合标点不应出现在一段的开头。
+func isClosingPunct(r rune) bool {
+	return strings.ContainsRune("，。、；：？！）》」』】〉,.;:?!)", r)
+}
+
+// graphemeClusters 按简化的字素簇规则拆分文本: 组合记号、ZWJ 序列、变体选择符、肤色修饰、
+// 标签字符、国旗区域指示符对与韩文字母组合不拆开, \r\n 视为一个整体。
+func graphemeClusters(text string) []string {
+	var clusters []string
+	start := 0
+	prev := rune(-1)
+	regional := 0
+	for idx, r := range text {
+		if prev >= 0 && isGraphemeBoundary(prev, r, regional) {
+			clusters = append(clusters, text[start:idx])
+			start = idx
+		}
+		if isRegionalIndicator(r) {
+			regional++
+		} else {
+			regional = 0
+		}
+		prev = r
+	}
+	if start < len(text) {
+		clusters = append(clusters, text[start:])
+	}
+	return clusters
+}
+
+func isGraphemeBoundary(prev, r rune, regional int) bool {
+	switch {
+	case prev == '\r' && r == '\n':
+		return false
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc):
+		return false
+	case r == 0x200D || prev == 0x200D:
+		return false
+	case r >= 0xFE00 && r <= 0xFE0F, r >= 0xE0100 && r <= 0xE01EF:
+		return false
+	case r >= 0x1F3FB && r <= 0x1F3FF, r >= 0xE0020 && r <= 0xE007F:
+		return false
+	case isRegionalIndicator(prev) && isRegionalIndicator(r):
+		return regional%2 == 0
+	case prev >= 0x1100 && prev <= 0x11FF && r >= 0x1160 && r <= 0x11FF:
+		return false
+	}
+	return true
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
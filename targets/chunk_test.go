@@ -0,0 +1,99 @@
+package targets
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestNormalizeChunkMode(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ChunkWords},
+		{"runes", ChunkRunes},
+		{" Graphemes ", ChunkGraphemes},
+		{"WORDS", ChunkWords},
+		{"bytes", ChunkWords},
+	}
+	for _, tt := range tests {
+		if got := NormalizeChunkMode(tt.value); got != tt.want {
+			t.Errorf("NormalizeChunkMode(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestGraphemeClusters(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "ASCII", text: "ab", want: []string{"a", "b"}},
+		{name: "CRLF", text: "a\r\nb", want: []string{"a", "\r\n", "b"}},
+		{name: "组合音标", text: "e\u0301x", want: []string{"e\u0301", "x"}},
+		{name: "ZWJ 序列", text: "👨\u200d👩\u200d👧!", want: []string{"👨\u200d👩\u200d👧", "!"}},
+		{name: "肤色修饰", text: "👍🏽a", want: []string{"👍🏽", "a"}},
+		{name: "变体选择符", text: "❤\ufe0fa", want: []string{"❤\ufe0f", "a"}},
+		{name: "国旗成对", text: "🇨🇳🇯🇵🇺", want: []string{"🇨🇳", "🇯🇵", "🇺"}},
+		{name: "韩文字母组合", text: "\u1100\u1161\u11a8가", want: []string{"\u1100\u1161\u11a8", "가"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := graphemeClusters(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("graphemeClusters(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChunkTextBy(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+		mode  string
+		text  string
+		want  []string
+	}{
+		{name: "未超过上限", limit: 10, mode: ChunkWords, text: "hello", want: []string{"hello"}},
+		{name: "空文本", limit: 10, mode: ChunkWords, text: "", want: nil},
+		{name: "按字符硬切", limit: 4, mode: ChunkRunes, text: "hello world", want: []string{"hell", "o wo", "rld"}},
+		{name: "按字素不拆开 emoji", limit: 3, mode: ChunkGraphemes, text: "ab👍🏽cd", want: []string{"ab", "👍🏽c", "d"}},
+		{name: "按单词在空白后断开", limit: 8, mode: ChunkWords, text: "hello world again", want: []string{"hello ", "world ", "again"}},
+		{name: "中文在字之间断开", limit: 4, mode: ChunkWords, text: "今天天气很好", want: []string{"今天天气", "很好"}},
+		{name: "闭合标点不出现在段首", limit: 4, mode: ChunkWords, text: "你好世界。再见", want: []string{"你好世", "界。再见"}},
+		{name: "找不到断点时在上限处切分", limit: 4, mode: ChunkWords, text: "abcdefgh", want: []string{"abcd", "efgh"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := (Capabilities{MaxTextLength: tt.limit}).ChunkTextBy(tt.text, tt.mode)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ChunkTextBy() = %q, want %q", got, tt.want)
+			}
+			if strings.Join(got, "") != tt.text {
+				t.Errorf("拼接后与原文不一致: %q", got)
+			}
+			for _, part := range got {
+				if n := utf8.RuneCountInString(part); n > tt.limit {
+					t.Errorf("分段 %q 长度 %d 超过上限 %d", part, n, tt.limit)
+				}
+			}
+		})
+	}
+}
+
+func TestChunkTextByOversizedCluster(t *testing.T) {
+	// 单个字素簇超过上限时退回按字符切分, 仍保证每段不超过上限。
+	text := "e" + strings.Repeat("\u0301", 5)
+	got := (Capabilities{MaxTextLength: 2}).ChunkTextBy(text, ChunkGraphemes)
+	if strings.Join(got, "") != text {
+		t.Fatalf("拼接后与原文不一致: %q", got)
+	}
+	for _, part := range got {
+		if utf8.RuneCountInString(part) > 2 {
+			t.Errorf("分段 %q 超过上限", part)
+		}
+	}
+}
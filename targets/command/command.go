@@ -0,0 +1,155 @@
+// Package command 把对话交给用户指定的外部命令处理, 便于用脚本对接自定义的导出目标。
+//
+// 每个对话执行一次命令: 标准输入为 Input 的 JSON, 对话 ID、标题与时区同时以
+// OPENAI_BACKUP_* 环境变量传入。命令退出码为 0 视为成功, 标准输出可以是
+// {"id": "...", "url": "..."} 形式的 JSON, 或第一行直接输出对象 ID。
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+// DefaultTimeout 是单次命令执行的默认超时时间。
+const DefaultTimeout = time.Minute
+
+// waitDelay 是命令被结束后等待其输出管道关闭的时间。
+const waitDelay = time.Second
+
+// outputLimit 限制读取的标准输出/错误长度, 避免命令输出过多占用内存。
+const outputLimit = 64 << 10
+
+// Config 是创建 Client 所需的命令参数。
+type Config struct {
+	// Command 为可执行文件及其参数, 按空白拆分, 不经过 shell。
+	Command string
+	// Env 为额外传给命令的环境变量 (KEY=VALUE), 命令同时继承当前进程的环境变量。
+	Env     []string
+	Timeout time.Duration
+}
+
+// Client 为每个对话执行一次外部命令。
+type Client struct {
+	program string
+	args    []string
+	env     []string
+	timeout time.Duration
+}
+
+// Input 是写入命令标准输入的 JSON 内容。
+type Input struct {
+	Timezone     string              `json:"timezone"`
+	Markdown     string              `json:"markdown"`
+	Conversation export.Conversation `json:"conversation"`
+}
+
+type output struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+// New 校验配置并创建 Client。
+func New(cfg Config) (*Client, error) {
+	fields := strings.Fields(cfg.Command)
+	if len(fields) == 0 {
+		return nil, errors.New("缺少导出命令: 请提供 --exec-command 或设置环境变量 BACKUP_EXEC_COMMAND")
+	}
+	program, err := exec.LookPath(fields[0])
+	if err != nil {
+		return nil, fmt.Errorf("导出命令不可用: %w", err)
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Client{
+		program: program,
+		args:    fields[1:],
+		env:     cfg.Env,
+		timeout: timeout,
+	}, nil
+}
+
+// CreateConversation 执行命令导出对话, 命令输出的 ID 与链接作为目标对象。
+func (c *Client) CreateConversation(ctx context.Context, conv export.Conversation, timezone string) (targets.Object, error) {
+	input, err := json.Marshal(Input{
+		Timezone:     timezone,
+		Markdown:     export.RenderMarkdown(conv, timezone),
+		Conversation: conv,
+	})
+	if err != nil {
+		return targets.Object{}, fmt.Errorf("序列化对话失败: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, c.program, c.args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Env = append(os.Environ(), c.env...)
+	cmd.Env = append(cmd.Env,
+		"OPENAI_BACKUP_CONVERSATION_ID="+conv.ID,
+		"OPENAI_BACKUP_TITLE="+conv.Title,
+		"OPENAI_BACKUP_TIMEZONE="+timezone,
+	)
+	stdout := &limitedBuffer{limit: outputLimit}
+	stderr := &limitedBuffer{limit: outputLimit}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	// 超时只会结束命令本身, 它启动的子进程可能仍占用输出管道; 结束后最多再等待 waitDelay。
+	cmd.WaitDelay = waitDelay
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return targets.Object{}, fmt.Errorf("导出命令超时 (%s)", c.timeout)
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return targets.Object{}, fmt.Errorf("导出命令执行失败: %v: %s", err, message)
+		}
+		return targets.Object{}, fmt.Errorf("导出命令执行失败: %w", err)
+	}
+	return parseOutput(stdout.String()), nil
+}
+
+// parseOutput 解析命令的标准输出: JSON 对象取 id/url, 否则第一行作为 ID。
+func parseOutput(raw string) targets.Object {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return targets.Object{}
+	}
+	if strings.HasPrefix(raw, "{") {
+		var parsed output
+		if err := json.Unmarshal([]byte(raw), &parsed); err == nil {
+			return targets.Object{ID: strings.TrimSpace(parsed.ID), URL: strings.TrimSpace(parsed.URL)}
+		}
+	}
+	line, _, _ := strings.Cut(raw, "\n")
+	return targets.Object{ID: strings.TrimSpace(line)}
+}
+
+// limitedBuffer 只保留前 limit 字节, 超出部分直接丢弃。
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remain := b.limit - b.Len(); remain > 0 {
+		if len(p) > remain {
+			b.Buffer.Write(p[:remain])
+		} else {
+			b.Buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
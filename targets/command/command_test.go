@@ -0,0 +1,119 @@
+package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+func TestParseOutput(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want targets.Object
+	}{
+		{name: "空输出", raw: "  \n", want: targets.Object{}},
+		{name: "JSON", raw: `{"id":" p1 ","url":"https://x/p1"}`, want: targets.Object{ID: "p1", URL: "https://x/p1"}},
+		{name: "第一行作为 ID", raw: "page-1\n其他日志\n", want: targets.Object{ID: "page-1"}},
+		{name: "无法解析的 JSON 按文本处理", raw: "{broken\nsecond", want: targets.Object{ID: "{broken"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseOutput(tt.raw); got.ID != tt.want.ID || got.URL != tt.want.URL {
+				t.Errorf("parseOutput(%q) = %+v, want %+v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	b := &limitedBuffer{limit: 5}
+	for _, chunk := range []string{"abc", "defg", "hij"} {
+		if n, err := b.Write([]byte(chunk)); n != len(chunk) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", chunk, n, err)
+		}
+	}
+	if b.String() != "abcde" {
+		t.Errorf("内容 = %q, want abcde", b.String())
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		wantErr bool
+	}{
+		{name: "缺少命令", command: "  ", wantErr: true},
+		{name: "命令不存在", command: "openai-backup-no-such-command --flag", wantErr: true},
+		{name: "带参数的命令", command: "go version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := New(Config{Command: tt.command})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (client.timeout != DefaultTimeout || len(client.args) != 1) {
+				t.Errorf("client = %+v", client)
+			}
+		})
+	}
+}
+
+func TestCreateConversation(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("测试脚本需要 sh")
+	}
+	tests := []struct {
+		name    string
+		script  string
+		timeout time.Duration
+		want    targets.Object
+		wantErr string
+	}{
+		{
+			name:   "从环境变量与标准输入读取对话",
+			script: `read -r input; case "$input" in *'"markdown"'*) ;; *) exit 3;; esac; echo "{\"id\":\"$OPENAI_BACKUP_CONVERSATION_ID-$EXTRA\",\"url\":\"https://x/$OPENAI_BACKUP_TIMEZONE\"}"`,
+			want:   targets.Object{ID: "c1-yes", URL: "https://x/Asia/Shanghai"},
+		},
+		{name: "非零退出码带上标准错误", script: `echo "权限不足" >&2; exit 2`, wantErr: "权限不足"},
+		{name: "超时后不等待子进程", script: `sleep 5`, timeout: 100 * time.Millisecond, wantErr: "超时"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := filepath.Join(t.TempDir(), "export.sh")
+			if err := os.WriteFile(script, []byte(tt.script+"\n"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			client, err := New(Config{Command: "sh " + script, Env: []string{"EXTRA=yes"}, Timeout: tt.timeout})
+			if err != nil {
+				t.Fatal(err)
+			}
+			start := time.Now()
+			obj, err := client.CreateConversation(context.Background(), export.Conversation{ID: "c1", Title: "标题"}, "Asia/Shanghai")
+			if elapsed := time.Since(start); elapsed > 3*time.Second {
+				t.Errorf("执行耗时 %s, 超时后不应等待子进程结束", elapsed)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want 包含 %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if obj.ID != tt.want.ID || obj.URL != tt.want.URL {
+				t.Errorf("Object = %+v, want %+v", obj, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+// Package gdrive 把对话上传到 Google Drive, 可转换为 Google 文档或保存为 Markdown 文件。
+// 授权使用 OAuth 设备流程 (RequestDeviceCode/PollRefreshToken), 只申请 drive.file 权限。
+package gdrive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/httpc"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+const defaultUploadURL = "https://www.googleapis.com/upload/drive/v3/files"
+
+const (
+	// ModeDoc 上传 HTML 并由 Drive 转换为 Google 文档。
+	ModeDoc = "doc"
+	// ModeMarkdown 直接保存 .md 文件。
+	ModeMarkdown = "markdown"
+)
+
+const googleDocMimeType = "application/vnd.google-apps.document"
+
+// Config 是创建 Client 所需的参数。
+type Config struct {
+	OAuth        OAuthConfig
+	RefreshToken string
+	// FolderID 为目标文件夹 ID, 为空时上传到 "我的云端硬盘" 根目录。
+	FolderID string
+	// Mode 取 ModeDoc (默认) 或 ModeMarkdown。
+	Mode string
+	// HTML 为转换 Google 文档时使用的 HTML 渲染选项。
+	HTML export.HTMLOptions
+	// Filename 为上传文件名的兼容性选项。
+	Filename export.FilenameOptions
+	// UploadURL 为文件上传接口地址, 为空时使用 Drive v3 官方地址。
+	UploadURL string
+}
+
+// Client 通过 Drive API 为每个对话上传一个文件。
+type Client struct {
+	httpClient *http.Client
+	tokens     *tokenSource
+	uploadURL  string
+	folderID   string
+	mode       string
+	html       export.HTMLOptions
+	filename   export.FilenameOptions
+}
+
+type fileMetadata struct {
+	Name     string   `json:"name"`
+	MimeType string   `json:"mimeType,omitempty"`
+	Parents  []string `json:"parents,omitempty"`
+}
+
+type fileResponse struct {
+	ID          string `json:"id"`
+	WebViewLink string `json:"webViewLink"`
+}
+
+// New 校验配置并创建 Client。
+func New(cfg Config) (*Client, error) {
+	if strings.TrimSpace(cfg.OAuth.ClientID) == "" || strings.TrimSpace(cfg.OAuth.ClientSecret) == "" {
+		return nil, fmt.Errorf("缺少 Google OAuth 客户端: 请在配置页填写 google_client_id 与 google_client_secret")
+	}
+	refreshToken := strings.TrimSpace(cfg.RefreshToken)
+	if refreshToken == "" {
+		return nil, fmt.Errorf("尚未授权 Google Drive: 请先在配置页完成设备授权")
+	}
+	uploadURL := strings.TrimRight(strings.TrimSpace(cfg.UploadURL), "/")
+	if uploadURL == "" {
+		uploadURL = defaultUploadURL
+	}
+	return &Client{
+		httpClient: httpc.Client(),
+		tokens:     &tokenSource{cfg: cfg.OAuth, refreshToken: refreshToken},
+		uploadURL:  uploadURL,
+		folderID:   strings.TrimSpace(cfg.FolderID),
+		mode:       NormalizeMode(cfg.Mode),
+		html:       cfg.HTML,
+		filename:   cfg.Filename,
+	}, nil
+}
+
+// NormalizeMode 将上传方式收敛为 ModeDoc 或 ModeMarkdown。
+func NormalizeMode(value string) string {
+	if strings.EqualFold(strings.TrimSpace(value), ModeMarkdown) {
+		return ModeMarkdown
+	}
+	return ModeDoc
+}
+
+// CreateConversation 上传对话文件, 返回文件 ID 与 Drive 中的查看链接。
+func (c *Client) CreateConversation(ctx context.Context, conv export.Conversation, timezone string) (targets.Object, error) {
+	filename := export.ConversationFilenameWith(conv, nil, c.filename)
+	meta := fileMetadata{Name: filename}
+	if c.folderID != "" {
+		meta.Parents = []string{c.folderID}
+	}
+	var content []byte
+	var contentType string
+	if c.mode == ModeMarkdown {
+		content = []byte(export.RenderMarkdown(conv, timezone))
+		contentType = "text/markdown; charset=utf-8"
+	} else {
+		meta.Name = strings.TrimSuffix(filename, ".md")
+		meta.MimeType = googleDocMimeType
+		content = []byte(export.RenderHTML(conv, timezone, c.html))
+		contentType = "text/html; charset=utf-8"
+	}
+
+	body, formType, err := multipartBody(meta, content, contentType)
+	if err != nil {
+		return targets.Object{}, err
+	}
+	access, err := c.tokens.token(ctx)
+	if err != nil {
+		return targets.Object{}, err
+	}
+
+	endpoint := c.uploadURL + "?uploadType=multipart&fields=id,webViewLink&supportsAllDrives=true"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, body)
+	if err != nil {
+		return targets.Object{}, fmt.Errorf("构造 Google Drive 请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", formType)
+	req.Header.Set("Authorization", "Bearer "+access)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return targets.Object{}, fmt.Errorf("调用 Google Drive 接口失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return targets.Object{}, &targets.StatusError{Action: "上传 Google Drive 文件", Status: resp.StatusCode, Message: strings.TrimSpace(targets.ReadBody(resp.Body))}
+	}
+	var file fileResponse
+	if err := json.NewDecoder(resp.Body).Decode(&file); err != nil {
+		return targets.Object{}, fmt.Errorf("解析 Google Drive 响应失败: %w", err)
+	}
+	return targets.Object{ID: file.ID, URL: file.WebViewLink}, nil
+}
+
+// multipartBody 按 Drive multipart 上传格式拼装元数据与文件内容。
+func multipartBody(meta fileMetadata, content []byte, contentType string) (*bytes.Buffer, string, error) {
+	metaJSON, err := json.Marshal(meta)
+	if err != nil {
+		return nil, "", fmt.Errorf("序列化 Google Drive 元数据失败: %w", err)
+	}
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	parts := []struct {
+		contentType string
+		data        []byte
+	}{
+		{"application/json; charset=UTF-8", metaJSON},
+		{contentType, content},
+	}
+	for _, part := range parts {
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Type", part.contentType)
+		writer, err := form.CreatePart(header)
+		if err != nil {
+			return nil, "", fmt.Errorf("构造上传内容失败: %w", err)
+		}
+		if _, err := writer.Write(part.data); err != nil {
+			return nil, "", fmt.Errorf("构造上传内容失败: %w", err)
+		}
+	}
+	if err := form.Close(); err != nil {
+		return nil, "", fmt.Errorf("构造上传内容失败: %w", err)
+	}
+	return &body, "multipart/related; boundary=" + form.Boundary(), nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
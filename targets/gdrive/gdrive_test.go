@@ -0,0 +1,163 @@
+package gdrive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+func TestNormalizeMode(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ModeDoc},
+		{"doc", ModeDoc},
+		{" Markdown ", ModeMarkdown},
+		{"pdf", ModeDoc},
+	}
+	for _, tt := range tests {
+		if got := NormalizeMode(tt.value); got != tt.want {
+			t.Errorf("NormalizeMode(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	oauth := OAuthConfig{ClientID: "app", ClientSecret: "secret"}
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{name: "完整配置", cfg: Config{OAuth: oauth, RefreshToken: "r"}},
+		{name: "缺少客户端密钥", cfg: Config{OAuth: OAuthConfig{ClientID: "app"}, RefreshToken: "r"}, wantErr: true},
+		{name: "尚未授权", cfg: Config{OAuth: oauth, RefreshToken: " "}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (client.uploadURL != defaultUploadURL || client.mode != ModeDoc) {
+				t.Errorf("uploadURL = %q mode = %q", client.uploadURL, client.mode)
+			}
+		})
+	}
+}
+
+// uploadParts 解析 multipart/related 上传请求, 返回元数据与文件部分的类型和内容。
+func uploadParts(r *http.Request) (meta fileMetadata, contentType, content string, err error) {
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/related" {
+		return meta, "", "", errors.New("不是 multipart/related 请求")
+	}
+	reader := multipart.NewReader(r.Body, params["boundary"])
+	part, err := reader.NextPart()
+	if err != nil {
+		return meta, "", "", err
+	}
+	if err := json.NewDecoder(part).Decode(&meta); err != nil {
+		return meta, "", "", err
+	}
+	part, err = reader.NextPart()
+	if err != nil {
+		return meta, "", "", err
+	}
+	data, err := io.ReadAll(part)
+	return meta, part.Header.Get("Content-Type"), string(data), err
+}
+
+func TestCreateConversation(t *testing.T) {
+	conv := export.Conversation{ID: "c1", Title: "周报", Messages: []export.Message{{Role: "user", Text: "你好"}}}
+	tests := []struct {
+		name        string
+		mode        string
+		folderID    string
+		status      int
+		wantName    string
+		wantMime    string
+		wantType    string
+		wantParents []string
+		wantStatus  int
+	}{
+		{name: "转换为 Google 文档", wantName: "周报-c1", wantMime: googleDocMimeType, wantType: "text/html; charset=utf-8"},
+		{name: "Markdown 文件放入文件夹", mode: ModeMarkdown, folderID: "folder1", wantName: "周报-c1.md", wantType: "text/markdown; charset=utf-8", wantParents: []string{"folder1"}},
+		{name: "文件夹无权限", status: http.StatusNotFound, wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				meta        fileMetadata
+				contentType string
+				content     string
+				parseErr    error
+				auth, query string
+			)
+			mux := http.NewServeMux()
+			mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, `{"access_token":"ya29","expires_in":3600}`)
+			})
+			mux.HandleFunc("/upload", func(w http.ResponseWriter, r *http.Request) {
+				auth, query = r.Header.Get("Authorization"), r.URL.RawQuery
+				meta, contentType, content, parseErr = uploadParts(r)
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+					io.WriteString(w, `{"error":{"code":404,"message":"File not found: folder1."}}`)
+					return
+				}
+				io.WriteString(w, `{"id":"f1","webViewLink":"https://docs.google.com/document/d/f1/edit"}`)
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			client, err := New(Config{
+				OAuth:        OAuthConfig{ClientID: "app", ClientSecret: "secret", BaseURL: server.URL},
+				RefreshToken: "r",
+				FolderID:     tt.folderID,
+				Mode:         tt.mode,
+				UploadURL:    server.URL + "/upload",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			obj, err := client.CreateConversation(context.Background(), conv, "UTC")
+			if tt.wantStatus != 0 {
+				var statusErr *targets.StatusError
+				if !errors.As(err, &statusErr) || statusErr.Status != tt.wantStatus {
+					t.Fatalf("err = %v, want 状态码 %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if obj.ID != "f1" || obj.URL != "https://docs.google.com/document/d/f1/edit" {
+				t.Errorf("Object = %+v", obj)
+			}
+			if auth != "Bearer ya29" || !strings.Contains(query, "uploadType=multipart") || !strings.Contains(query, "supportsAllDrives=true") {
+				t.Errorf("Authorization = %q query = %q", auth, query)
+			}
+			if parseErr != nil {
+				t.Fatalf("解析上传内容失败: %v", parseErr)
+			}
+			if meta.Name != tt.wantName || meta.MimeType != tt.wantMime || strings.Join(meta.Parents, ",") != strings.Join(tt.wantParents, ",") {
+				t.Errorf("元数据 = %+v", meta)
+			}
+			if contentType != tt.wantType || !strings.Contains(content, "你好") {
+				t.Errorf("文件 %s = %q", contentType, content)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package gdrive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Devoty/openai-backup/httpc"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+const (
+	defaultOAuthURL = "https://oauth2.googleapis.com"
+
+	// Scope 只允许访问本应用创建的文件, 也是设备授权流程支持的 Drive 权限。
+	Scope = "https://www.googleapis.com/auth/drive.file"
+
+	deviceGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+)
+
+// ErrAccessDenied 表示用户在授权页拒绝了请求。
+var ErrAccessDenied = errors.New("用户拒绝了 Google 授权")
+
+// OAuthConfig 是 Google OAuth 客户端参数, 需在 Google Cloud 控制台创建 "电视和受限输入设备" 类型的客户端。
+type OAuthConfig struct {
+	ClientID     string
+	ClientSecret string
+	// BaseURL 为 OAuth 接口地址, 为空时使用 https://oauth2.googleapis.com。
+	BaseURL string
+}
+
+// DeviceCode 是设备授权流程第一步的返回, 用户需打开 VerificationURL 并输入 UserCode。
+type DeviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURL string `json:"verification_url"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (c OAuthConfig) endpoint(path string) string {
+	base := strings.TrimRight(strings.TrimSpace(c.BaseURL), "/")
+	if base == "" {
+		base = defaultOAuthURL
+	}
+	return base + path
+}
+
+// RequestDeviceCode 发起设备授权, 返回需要展示给用户的验证码与地址。
+func RequestDeviceCode(ctx context.Context, cfg OAuthConfig) (*DeviceCode, error) {
+	if strings.TrimSpace(cfg.ClientID) == "" {
+		return nil, fmt.Errorf("缺少 Google OAuth Client ID: 请在配置页填写 google_client_id")
+	}
+	form := url.Values{}
+	form.Set("client_id", cfg.ClientID)
+	form.Set("scope", Scope)
+
+	var code DeviceCode
+	if _, err := postForm(ctx, cfg.endpoint("/device/code"), form, &code); err != nil {
+		return nil, err
+	}
+	if code.DeviceCode == "" || code.UserCode == "" {
+		return nil, fmt.Errorf("Google 未返回设备验证码")
+	}
+	if code.Interval <= 0 {
+		code.Interval = 5
+	}
+	return &code, nil
+}
+
+// PollRefreshToken 按 Google 要求的间隔轮询授权结果, 用户完成授权后返回 refresh token。
+func PollRefreshToken(ctx context.Context, cfg OAuthConfig, code *DeviceCode) (string, error) {
+	interval := time.Duration(code.Interval) * time.Second
+	deadline := time.Now().Add(time.Duration(code.ExpiresIn) * time.Second)
+	form := url.Values{}
+	form.Set("client_id", cfg.ClientID)
+	form.Set("client_secret", cfg.ClientSecret)
+	form.Set("device_code", code.DeviceCode)
+	form.Set("grant_type", deviceGrantType)
+
+	for {
+		if code.ExpiresIn > 0 && time.Now().After(deadline) {
+			return "", fmt.Errorf("设备验证码已过期, 请重新发起授权")
+		}
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return "", ctx.Err()
+		case <-timer.C:
+		}
+
+		var token tokenResponse
+		status, err := postForm(ctx, cfg.endpoint("/token"), form, &token)
+		if err != nil && status == 0 {
+			return "", err
+		}
+		switch token.Error {
+		case "":
+			if err != nil {
+				return "", err
+			}
+			if token.RefreshToken == "" {
+				return "", fmt.Errorf("Google 未返回 refresh token")
+			}
+			return token.RefreshToken, nil
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * time.Second
+		case "access_denied":
+			return "", ErrAccessDenied
+		case "expired_token":
+			return "", fmt.Errorf("设备验证码已过期, 请重新发起授权")
+		default:
+			return "", fmt.Errorf("Google 授权失败: %s %s", token.Error, token.ErrorDescription)
+		}
+	}
+}
+
+// tokenSource 用 refresh token 换取并缓存 access token, 过期前一分钟自动刷新。
+type tokenSource struct {
+	cfg          OAuthConfig
+	refreshToken string
+
+	mu      sync.Mutex
+	access  string
+	expires time.Time
+}
+
+func (t *tokenSource) token(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.access != "" && time.Until(t.expires) > time.Minute {
+		return t.access, nil
+	}
+
+	form := url.Values{}
+	form.Set("client_id", t.cfg.ClientID)
+	form.Set("client_secret", t.cfg.ClientSecret)
+	form.Set("refresh_token", t.refreshToken)
+	form.Set("grant_type", "refresh_token")
+
+	var token tokenResponse
+	if _, err := postForm(ctx, t.cfg.endpoint("/token"), form, &token); err != nil {
+		return "", fmt.Errorf("刷新 Google access token 失败: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("刷新 Google access token 失败: %s", firstNonEmpty(token.ErrorDescription, token.Error, "响应缺少 access_token"))
+	}
+	t.access = token.AccessToken
+	t.expires = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	return t.access, nil
+}
+
+// postForm 提交表单并解析 JSON 响应。OAuth 接口在授权未完成等情况下也会返回 JSON 错误体,
+// 因此非 2xx 时仍尝试解析到 out, 同时返回状态码与错误。
+func postForm(ctx context.Context, endpoint string, form url.Values, out interface{}) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return 0, fmt.Errorf("构造 Google 请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpc.Client().Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("调用 Google 接口失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body := targets.ReadBody(resp.Body)
+	decodeErr := json.Unmarshal([]byte(body), out)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, &targets.StatusError{Action: "调用 Google OAuth 接口", Status: resp.StatusCode, Message: strings.TrimSpace(body)}
+	}
+	if decodeErr != nil {
+		return resp.StatusCode, fmt.Errorf("解析 Google 响应失败: %w", decodeErr)
+	}
+	return resp.StatusCode, nil
+}
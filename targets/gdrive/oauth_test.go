@@ -0,0 +1,159 @@
+package gdrive
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeOAuth 按顺序返回 responses 中的 "状态码 响应体", 记录每次请求的 grant_type。
+func fakeOAuth(t *testing.T, path string, responses ...string) (*httptest.Server, *[]string) {
+	t.Helper()
+	var grants []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path || len(grants) >= len(responses) {
+			http.NotFound(w, r)
+			return
+		}
+		r.ParseForm()
+		grants = append(grants, r.PostForm.Get("grant_type"))
+		status, body, _ := strings.Cut(responses[len(grants)-1], " ")
+		if status != "200" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &grants
+}
+
+func TestRequestDeviceCode(t *testing.T) {
+	tests := []struct {
+		name         string
+		clientID     string
+		response     string
+		wantInterval int
+		wantErr      bool
+	}{
+		{name: "缺少 Client ID", wantErr: true},
+		{name: "默认轮询间隔", clientID: "app", response: `200 {"device_code":"d","user_code":"ABCD-EFGH","verification_url":"https://www.google.com/device","expires_in":1800}`, wantInterval: 5},
+		{name: "使用返回的轮询间隔", clientID: "app", response: `200 {"device_code":"d","user_code":"ABCD-EFGH","interval":8}`, wantInterval: 8},
+		{name: "未返回验证码", clientID: "app", response: `200 {"interval":5}`, wantErr: true},
+		{name: "客户端类型不支持", clientID: "app", response: `400 {"error":"invalid_client","error_description":"Invalid client type."}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := fakeOAuth(t, "/device/code", tt.response)
+			code, err := RequestDeviceCode(context.Background(), OAuthConfig{ClientID: tt.clientID, BaseURL: server.URL + "/"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (code.DeviceCode != "d" || code.UserCode != "ABCD-EFGH" || code.Interval != tt.wantInterval) {
+				t.Errorf("DeviceCode = %+v", code)
+			}
+		})
+	}
+}
+
+func TestPollRefreshToken(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []string
+		want      string
+		wantErr   error
+		wantMsg   string
+	}{
+		{
+			name:      "等待用户授权后返回 refresh token",
+			responses: []string{`400 {"error":"authorization_pending"}`, `400 {"error":"authorization_pending"}`, `200 {"access_token":"a","refresh_token":"r1","expires_in":3600}`},
+			want:      "r1",
+		},
+		{name: "用户拒绝", responses: []string{`400 {"error":"access_denied"}`}, wantErr: ErrAccessDenied},
+		{name: "验证码过期", responses: []string{`400 {"error":"expired_token"}`}, wantMsg: "已过期"},
+		{name: "其他错误", responses: []string{`400 {"error":"invalid_grant","error_description":"Malformed auth code."}`}, wantMsg: "invalid_grant Malformed auth code."},
+		{name: "缺少 refresh token", responses: []string{`200 {"access_token":"a"}`}, wantMsg: "未返回 refresh token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, grants := fakeOAuth(t, "/token", tt.responses...)
+			cfg := OAuthConfig{ClientID: "app", ClientSecret: "secret", BaseURL: server.URL}
+			got, err := PollRefreshToken(context.Background(), cfg, &DeviceCode{DeviceCode: "d", ExpiresIn: 60})
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+			case tt.wantMsg != "":
+				if err == nil || !strings.Contains(err.Error(), tt.wantMsg) {
+					t.Fatalf("err = %v, want 包含 %q", err, tt.wantMsg)
+				}
+			case err != nil || got != tt.want:
+				t.Fatalf("PollRefreshToken() = %q, %v, want %q", got, err, tt.want)
+			}
+			if len(*grants) != len(tt.responses) || (*grants)[0] != deviceGrantType {
+				t.Errorf("轮询 = %v, want %d 次 %s", *grants, len(tt.responses), deviceGrantType)
+			}
+		})
+	}
+}
+
+func TestPollRefreshTokenCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := PollRefreshToken(ctx, OAuthConfig{ClientID: "app", BaseURL: "http://127.0.0.1:1"}, &DeviceCode{DeviceCode: "d", Interval: 5})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestTokenSource(t *testing.T) {
+	tests := []struct {
+		name         string
+		responses    []string
+		wantTokens   []string
+		wantRequests int
+		wantErr      string
+	}{
+		{
+			name:         "换取后缓存到过期前",
+			responses:    []string{`200 {"access_token":"a1","expires_in":3600}`},
+			wantTokens:   []string{"a1", "a1"},
+			wantRequests: 1,
+		},
+		{
+			name:         "不足一分钟过期时重新刷新",
+			responses:    []string{`200 {"access_token":"a1","expires_in":30}`, `200 {"access_token":"a2","expires_in":30}`},
+			wantTokens:   []string{"a1", "a2"},
+			wantRequests: 2,
+		},
+		{name: "refresh token 已撤销", responses: []string{`400 {"error":"invalid_grant","error_description":"Token has been expired or revoked."}`}, wantRequests: 1, wantErr: "revoked"},
+		{name: "响应缺少 access_token", responses: []string{`200 {"expires_in":3600}`}, wantRequests: 1, wantErr: "响应缺少 access_token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, grants := fakeOAuth(t, "/token", tt.responses...)
+			source := &tokenSource{cfg: OAuthConfig{ClientID: "app", ClientSecret: "secret", BaseURL: server.URL}, refreshToken: "r"}
+			var got []string
+			for i := 0; i < 2; i++ {
+				token, err := source.token(context.Background())
+				if err != nil {
+					if tt.wantErr == "" || !strings.Contains(err.Error(), tt.wantErr) {
+						t.Fatalf("err = %v, want 包含 %q", err, tt.wantErr)
+					}
+					break
+				}
+				got = append(got, token)
+			}
+			if tt.wantErr == "" && strings.Join(got, ",") != strings.Join(tt.wantTokens, ",") {
+				t.Errorf("token = %v, want %v", got, tt.wantTokens)
+			}
+			if len(*grants) != tt.wantRequests {
+				t.Errorf("请求次数 = %d, want %d", len(*grants), tt.wantRequests)
+			}
+		})
+	}
+}
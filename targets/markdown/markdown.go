@@ -0,0 +1,150 @@
+// Package markdown 把对话写为本地目录中的 Markdown 文件, 每个对话一个文件,
+// 目录根部维护 index.json 与 index.md 两份索引, 不依赖任何笔记应用。
+package markdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/Devoty/openai-backup/atomicfile"
+	"github.com/Devoty/openai-backup/diskspace"
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+const (
+	indexJSONName     = "index.json"
+	indexMarkdownName = "index.md"
+)
+
+// indexMu 串行化索引的读写; 每次导出任务都会新建 Client, 锁不能放在 Client 中。
+var indexMu sync.Mutex
+
+// Config 是创建 Client 所需的参数。
+type Config struct {
+	// Dir 为输出目录, 不存在时在首次写入时创建。
+	Dir string
+	// Filename 为生成文件名与日期目录的选项, 与导出压缩包一致。
+	Filename export.FilenameOptions
+	// Journal 记录写入中的临时文件, 中断的写入在下次启动时清理, 为 nil 时不记录。
+	Journal atomicfile.Journal
+}
+
+// Client 把对话写入 Dir。同一对话再次导出时覆盖原文件, 标题变化导致文件名改变时删除旧文件。
+type Client struct {
+	dir      string
+	filename export.FilenameOptions
+	journal  atomicfile.Journal
+}
+
+// New 校验配置并创建 Client。
+func New(cfg Config) (*Client, error) {
+	if cfg.Dir == "" {
+		return nil, errors.New("缺少输出目录: 请在配置中填写 output_path")
+	}
+	abs, err := filepath.Abs(cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("解析输出目录失败: %w", err)
+	}
+	if info, err := os.Stat(abs); err == nil && !info.IsDir() {
+		return nil, fmt.Errorf("输出路径 %s 不是目录", abs)
+	}
+	return &Client{dir: abs, filename: cfg.Filename, journal: cfg.Journal}, nil
+}
+
+// CreateConversation 写入对话的 Markdown 文件并更新索引, 对象 ID 为文件相对输出目录的路径。
+func (c *Client) CreateConversation(ctx context.Context, conv export.Conversation, timezone string) (targets.Object, error) {
+	if err := ctx.Err(); err != nil {
+		return targets.Object{}, err
+	}
+	indexMu.Lock()
+	defer indexMu.Unlock()
+
+	entries, err := c.readIndex()
+	if err != nil {
+		return targets.Object{}, err
+	}
+	used := make(map[string]int, len(entries))
+	var previous string
+	kept := entries[:0]
+	for _, entry := range entries {
+		if entry.ID == conv.ID {
+			previous = entry.Path
+			continue
+		}
+		used[entry.Path] = 1
+		kept = append(kept, entry)
+	}
+	name := export.ConversationFilenameWith(conv, used, c.filename)
+
+	export.RebasePaths(&conv, name)
+	path := filepath.Join(c.dir, filepath.FromSlash(name))
+	if err := c.writeFile(path, []byte(export.RenderMarkdown(conv, timezone))); err != nil {
+		return targets.Object{}, err
+	}
+	if previous != "" && previous != name {
+		if err := os.Remove(filepath.Join(c.dir, filepath.FromSlash(previous))); err != nil && !os.IsNotExist(err) {
+			return targets.Object{}, fmt.Errorf("删除旧文件 %s 失败: %w", previous, err)
+		}
+	}
+
+	entries = append(kept, export.NewIndexEntry(conv, name))
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].CreatedAt != entries[j].CreatedAt {
+			return entries[i].CreatedAt > entries[j].CreatedAt
+		}
+		return entries[i].ID < entries[j].ID
+	})
+	if err := c.writeIndex(entries, timezone); err != nil {
+		return targets.Object{}, err
+	}
+	link := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	return targets.Object{ID: name, URL: link.String()}, nil
+}
+
+// readIndex 读取已有的 index.json, 目录或索引不存在时返回空列表。
+func (c *Client) readIndex() ([]export.IndexEntry, error) {
+	data, err := os.ReadFile(filepath.Join(c.dir, indexJSONName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 失败: %w", indexJSONName, err)
+	}
+	entries, err := export.ParseIndex(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", indexJSONName, err)
+	}
+	return entries, nil
+}
+
+func (c *Client) writeIndex(entries []export.IndexEntry, timezone string) error {
+	data, err := export.MarshalIndex(entries)
+	if err != nil {
+		return err
+	}
+	if err := c.writeFile(filepath.Join(c.dir, indexJSONName), data); err != nil {
+		return err
+	}
+	return c.writeFile(filepath.Join(c.dir, indexMarkdownName), []byte(export.RenderIndexMarkdown(entries, timezone)))
+}
+
+// writeFile 原子写入 path, 父目录不存在时自动创建; 写入前检查磁盘空间。
+func (c *Client) writeFile(path string, data []byte) error {
+	if err := diskspace.Check(filepath.Dir(path), int64(len(data))); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	if err := atomicfile.WriteFile(c.journal, path, data, 0o644); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", filepath.Base(path), err)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package markdown
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Devoty/openai-backup/export"
+)
+
+// memoryJournal 记录仍未结束的写入, 用于确认每次写入都已结束。
+type memoryJournal map[string]string
+
+func (j memoryJournal) BeginFileWrite(path, temp string) error {
+	j[temp] = path
+	return nil
+}
+
+func (j memoryJournal) EndFileWrite(temp string) error {
+	delete(j, temp)
+	return nil
+}
+
+func TestNew(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file.md")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		dir     string
+		wantErr bool
+	}{
+		{name: "缺少目录", dir: "", wantErr: true},
+		{name: "输出路径是文件", dir: file, wantErr: true},
+		{name: "目录尚不存在", dir: filepath.Join(t.TempDir(), "new")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(Config{Dir: tt.dir}); (err != nil) != tt.wantErr {
+				t.Errorf("New() err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateConversation(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	journal := memoryJournal{}
+	client, err := New(Config{Dir: dir, Journal: journal})
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := float64(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC).Unix())
+	conv := func(id, title string, created float64) export.Conversation {
+		return export.Conversation{
+			ID:         id,
+			Title:      title,
+			CreateTime: created,
+			Messages:   []export.Message{{Role: "user", Text: "内容 " + title, CreateTime: created}},
+		}
+	}
+	steps := []struct {
+		name      string
+		conv      export.Conversation
+		wantID    string
+		wantFiles []string
+		wantOrder []string
+	}{
+		{
+			name:      "首次导出",
+			conv:      conv("a", "Alpha", day),
+			wantID:    "Alpha-a.md",
+			wantFiles: []string{"Alpha-a.md", "index.json", "index.md"},
+			wantOrder: []string{"a"},
+		},
+		{
+			name:      "较新的对话排在前面",
+			conv:      conv("b", "Beta", day+3600),
+			wantID:    "Beta-b.md",
+			wantFiles: []string{"Alpha-a.md", "Beta-b.md", "index.json", "index.md"},
+			wantOrder: []string{"b", "a"},
+		},
+		{
+			name:      "标题变化时删除旧文件",
+			conv:      conv("a", "Renamed", day),
+			wantID:    "Renamed-a.md",
+			wantFiles: []string{"Beta-b.md", "Renamed-a.md", "index.json", "index.md"},
+			wantOrder: []string{"b", "a"},
+		},
+		{
+			name:      "同一文件名覆盖",
+			conv:      conv("b", "Beta", day+3600),
+			wantID:    "Beta-b.md",
+			wantFiles: []string{"Beta-b.md", "Renamed-a.md", "index.json", "index.md"},
+			wantOrder: []string{"b", "a"},
+		},
+	}
+	for _, step := range steps {
+		obj, err := client.CreateConversation(context.Background(), step.conv, "UTC")
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if obj.ID != step.wantID || !strings.HasPrefix(obj.URL, "file://") || !strings.HasSuffix(obj.URL, step.wantID) {
+			t.Errorf("%s: Object = %+v, want ID %s", step.name, obj, step.wantID)
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var files []string
+		for _, entry := range entries {
+			files = append(files, entry.Name())
+		}
+		if !reflect.DeepEqual(files, step.wantFiles) {
+			t.Errorf("%s: 目录内容 = %v, want %v", step.name, files, step.wantFiles)
+		}
+		index, err := client.readIndex()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var order []string
+		for _, entry := range index {
+			order = append(order, entry.ID)
+		}
+		if !reflect.DeepEqual(order, step.wantOrder) {
+			t.Errorf("%s: 索引顺序 = %v, want %v", step.name, order, step.wantOrder)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, step.wantID))
+		if err != nil || !strings.Contains(string(data), "内容 "+step.conv.Title) {
+			t.Errorf("%s: 文件内容 = %q, err=%v", step.name, data, err)
+		}
+		if len(journal) != 0 {
+			t.Errorf("%s: 仍有 %d 个未结束的写入", step.name, len(journal))
+		}
+	}
+}
+
+func TestCreateConversationCanceled(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	client, err := New(Config{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.CreateConversation(ctx, export.Conversation{ID: "a", Title: "A"}, "UTC"); err == nil {
+		t.Fatal("已取消的导出应返回错误")
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("已取消的导出不应创建目录: err=%v", err)
+	}
+}
@@ -0,0 +1,157 @@
+// Package memos 把对话保存为自托管 Memos (usememos) 中的一条 Markdown 备忘录。
+package memos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/httpc"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+const (
+	VisibilityPrivate   = "PRIVATE"
+	VisibilityProtected = "PROTECTED"
+	VisibilityPublic    = "PUBLIC"
+)
+
+// Config 是创建 Client 所需的 Memos 参数。
+type Config struct {
+	// BaseURL 为 Memos 实例地址, 如 https://memos.example.com。
+	BaseURL string
+	// Token 为 设置 → 访问令牌 中创建的令牌。
+	Token string
+	// Visibility 取 PRIVATE (默认)、PROTECTED 或 PUBLIC。
+	Visibility string
+	// Tags 追加到正文末尾的标签, 与对话自身的标签合并。
+	Tags []string
+}
+
+// Client 通过 Memos v1 API 为每个对话创建一条备忘录。
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+	visibility string
+	tags       []string
+}
+
+type createRequest struct {
+	Content    string `json:"content"`
+	Visibility string `json:"visibility"`
+}
+
+type memoResponse struct {
+	// Name 形如 memos/{id}。
+	Name string `json:"name"`
+}
+
+// New 校验配置并创建 Client。
+func New(cfg Config) (*Client, error) {
+	baseURL := strings.TrimRight(strings.TrimSpace(cfg.BaseURL), "/")
+	if baseURL == "" {
+		return nil, fmt.Errorf("缺少 Memos 地址: 请在配置页填写 memos_base_url")
+	}
+	if parsed, err := url.Parse(baseURL); err != nil || !parsed.IsAbs() {
+		return nil, fmt.Errorf("Memos 地址无效: %s", cfg.BaseURL)
+	}
+	token := strings.TrimSpace(cfg.Token)
+	if token == "" {
+		return nil, fmt.Errorf("缺少 Memos 访问令牌: 请在配置页填写 memos_token")
+	}
+	return &Client{
+		httpClient: httpc.Client(),
+		baseURL:    baseURL,
+		token:      token,
+		visibility: NormalizeVisibility(cfg.Visibility),
+		tags:       cfg.Tags,
+	}, nil
+}
+
+// NormalizeVisibility 将可见性收敛为 Memos 支持的取值, 未知值按 PRIVATE 处理。
+func NormalizeVisibility(value string) string {
+	switch strings.ToUpper(strings.TrimSpace(value)) {
+	case VisibilityProtected:
+		return VisibilityProtected
+	case VisibilityPublic:
+		return VisibilityPublic
+	default:
+		return VisibilityPrivate
+	}
+}
+
+// CreateConversation 创建备忘录, 返回 memos/{id} 形式的资源名与网页链接。
+func (c *Client) CreateConversation(ctx context.Context, conv export.Conversation, timezone string) (targets.Object, error) {
+	content := export.RenderMarkdown(conv, timezone)
+	if tags := tagLine(c.tags, conv.Tags); tags != "" {
+		content = strings.TrimRight(content, "\n") + "\n\n" + tags + "\n"
+	}
+	data, err := json.Marshal(createRequest{Content: content, Visibility: c.visibility})
+	if err != nil {
+		return targets.Object{}, fmt.Errorf("序列化 Memos 请求失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/v1/memos", bytes.NewReader(data))
+	if err != nil {
+		return targets.Object{}, fmt.Errorf("构造 Memos 请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return targets.Object{}, fmt.Errorf("调用 Memos 接口失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return targets.Object{}, &targets.StatusError{Action: "创建 Memos 备忘录", Status: resp.StatusCode, Message: strings.TrimSpace(targets.ReadBody(resp.Body))}
+	}
+	var memo memoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&memo); err != nil {
+		return targets.Object{}, fmt.Errorf("解析 Memos 响应失败: %w", err)
+	}
+	obj := targets.Object{ID: memo.Name}
+	if strings.HasPrefix(memo.Name, "memos/") {
+		obj.URL = c.baseURL + "/" + memo.Name
+	}
+	return obj, nil
+}
+
+// ParseTags 解析逗号分隔的标签列表。
+func ParseTags(raw string) []string {
+	var tags []string
+	for _, tag := range strings.Split(raw, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// tagLine 把标签拼成 "#a #b" 形式, Memos 会自动识别为标签。标签内的空白替换为连字符。
+func tagLine(lists ...[]string) string {
+	seen := make(map[string]struct{})
+	var tags []string
+	for _, list := range lists {
+		for _, tag := range list {
+			tag = strings.Join(strings.Fields(strings.TrimPrefix(strings.TrimSpace(tag), "#")), "-")
+			key := strings.ToLower(tag)
+			if key == "" {
+				continue
+			}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			tags = append(tags, "#"+tag)
+		}
+	}
+	return strings.Join(tags, " ")
+}
@@ -0,0 +1,146 @@
+package memos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+func TestNormalizeVisibility(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", VisibilityPrivate},
+		{"private", VisibilityPrivate},
+		{" protected ", VisibilityProtected},
+		{"PUBLIC", VisibilityPublic},
+		{"workspace", VisibilityPrivate},
+	}
+	for _, tt := range tests {
+		if got := NormalizeVisibility(tt.value); got != tt.want {
+			t.Errorf("NormalizeVisibility(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want []string
+	}{
+		{"", nil},
+		{"chatgpt", []string{"chatgpt"}},
+		{" chatgpt , , 备份 ", []string{"chatgpt", "备份"}},
+	}
+	for _, tt := range tests {
+		if got := ParseTags(tt.raw); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseTags(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestTagLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		lists [][]string
+		want  string
+	}{
+		{name: "没有标签", lists: nil, want: ""},
+		{name: "合并配置与对话标签", lists: [][]string{{"chatgpt"}, {"工作", "学习"}}, want: "#chatgpt #工作 #学习"},
+		{name: "去掉 # 前缀并忽略大小写去重", lists: [][]string{{"#ChatGPT"}, {"chatgpt", " "}}, want: "#ChatGPT"},
+		{name: "空白替换为连字符", lists: [][]string{{"machine  learning"}}, want: "#machine-learning"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tagLine(tt.lists...); got != tt.want {
+				t.Errorf("tagLine() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{name: "完整配置", cfg: Config{BaseURL: "https://memos.example.com/", Token: "t"}},
+		{name: "缺少地址", cfg: Config{Token: "t"}, wantErr: true},
+		{name: "相对地址", cfg: Config{BaseURL: "memos.example.com", Token: "t"}, wantErr: true},
+		{name: "缺少令牌", cfg: Config{BaseURL: "https://memos.example.com"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("New() err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateConversation(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		response   string
+		want       targets.Object
+		wantStatus int
+	}{
+		{name: "创建成功", status: http.StatusOK, response: `{"name":"memos/42"}`, want: targets.Object{ID: "memos/42", URL: "/memos/42"}},
+		{name: "资源名格式未知时不生成链接", status: http.StatusCreated, response: `{"name":"42"}`, want: targets.Object{ID: "42"}},
+		{name: "令牌无效", status: http.StatusUnauthorized, response: "unauthenticated", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got createRequest
+			var auth, path string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				auth, path = r.Header.Get("Authorization"), r.URL.Path
+				json.NewDecoder(r.Body).Decode(&got)
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.response)
+			}))
+			defer server.Close()
+
+			client, err := New(Config{BaseURL: server.URL, Token: "tok", Visibility: "public", Tags: []string{"chatgpt"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			conv := export.Conversation{ID: "c1", Title: "标题", Tags: []string{"工作"}, Messages: []export.Message{{Role: "user", Text: "你好"}}}
+			obj, err := client.CreateConversation(context.Background(), conv, "UTC")
+			if tt.wantStatus != 0 {
+				var statusErr *targets.StatusError
+				if !errors.As(err, &statusErr) || statusErr.Status != tt.wantStatus {
+					t.Fatalf("err = %v, want 状态码 %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want.URL != "" {
+				tt.want.URL = server.URL + tt.want.URL
+			}
+			if obj.ID != tt.want.ID || obj.URL != tt.want.URL {
+				t.Errorf("Object = %+v, want %+v", obj, tt.want)
+			}
+			if auth != "Bearer tok" || path != "/api/v1/memos" {
+				t.Errorf("Authorization = %q path = %q", auth, path)
+			}
+			if got.Visibility != VisibilityPublic || !strings.Contains(got.Content, "你好") || !strings.HasSuffix(got.Content, "\n\n#chatgpt #工作\n") {
+				t.Errorf("请求 = %+v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package notion
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Devoty/openai-backup/client"
+	"github.com/Devoty/openai-backup/demo"
+	"github.com/Devoty/openai-backup/export"
+)
+
+func BenchmarkBuildPageRequest(b *testing.B) {
+	c, err := New(Config{Token: "bench", ParentID: "bench-parent"})
+	if err != nil {
+		b.Fatal(err)
+	}
+	details := demo.Conversations(demo.DefaultCount)
+	convs := make([]export.Conversation, 0, len(details))
+	for i := range details {
+		convs = append(convs, export.Build(client.ConversationMeta{}, &details[i]))
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, conv := range convs {
+			c.buildPageRequest(conv, time.UTC, nil)
+		}
+	}
+}
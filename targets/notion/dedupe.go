@@ -0,0 +1,157 @@
+package notion
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Devoty/openai-backup/targets"
+)
+
+// Notion 的创建接口不支持幂等键。创建请求超时或返回 5xx 时页面可能已经创建, 重试前先在父级中
+// 查找请求开始之后创建、元数据中对话 ID 相同的页面, 找到时直接使用它, 见 createConversationPage。
+
+// dedupeMaxListRequests 是在父页面下查找子页面时最多读取的分页数。
+const dedupeMaxListRequests = 50
+
+type notionListResponse struct {
+	Results    []notionListedObject `json:"results"`
+	HasMore    bool                 `json:"has_more"`
+	NextCursor string               `json:"next_cursor"`
+}
+
+// notionListedObject 是子区块列表或数据库查询结果中的一项, 只解析查找页面需要的字段。
+type notionListedObject struct {
+	ID               string           `json:"id"`
+	URL              string           `json:"url"`
+	Type             string           `json:"type"`
+	CreatedTime      time.Time        `json:"created_time"`
+	BulletedListItem *notionParagraph `json:"bulleted_list_item,omitempty"`
+}
+
+// findCreatedPage 在 parent 下查找 since 之后为对话 convID 创建的页面。Notion 的 created_time
+// 只精确到分钟, 查找范围向前放宽一分钟。
+func (c *Client) findCreatedPage(ctx context.Context, parent notionParent, convID string, since time.Time) (notionPageResponse, bool, error) {
+	cutoff := since.UTC().Truncate(time.Minute).Add(-time.Minute)
+	candidates, err := c.recentPages(ctx, parent, cutoff)
+	if err != nil {
+		return notionPageResponse{}, false, fmt.Errorf("查找已创建的 Notion 页面失败: %w", err)
+	}
+	for _, page := range candidates {
+		ok, err := c.pageHasConversation(ctx, page.ID, convID)
+		if err != nil {
+			return notionPageResponse{}, false, fmt.Errorf("查找已创建的 Notion 页面失败: %w", err)
+		}
+		if ok {
+			return page, true, nil
+		}
+	}
+	return notionPageResponse{}, false, nil
+}
+
+// recentPages 列出 parent 下 cutoff 之后创建的页面: 父级为页面时遍历其子页面区块,
+// 为数据库或数据源时按创建时间查询。
+func (c *Client) recentPages(ctx context.Context, parent notionParent, cutoff time.Time) ([]notionPageResponse, error) {
+	var pages []notionPageResponse
+	if parent.Type == "page_id" {
+		cursor := ""
+		for i := 0; i < dedupeMaxListRequests; i++ {
+			target := fmt.Sprintf("%s/v1/blocks/%s/children?page_size=100", c.baseURL, url.PathEscape(parent.PageID))
+			if cursor != "" {
+				target += "&start_cursor=" + url.QueryEscape(cursor)
+			}
+			var list notionListResponse
+			if err := c.getJSON(ctx, "读取 Notion 子页面", target, &list); err != nil {
+				return nil, err
+			}
+			for _, block := range list.Results {
+				if block.Type == "child_page" && !block.CreatedTime.Before(cutoff) {
+					pages = append(pages, notionPageResponse{ID: block.ID, URL: notionPageURL(block.ID)})
+				}
+			}
+			if !list.HasMore || list.NextCursor == "" {
+				break
+			}
+			cursor = list.NextCursor
+		}
+		return pages, nil
+	}
+
+	target := fmt.Sprintf("%s/v1/databases/%s/query", c.baseURL, url.PathEscape(parent.DatabaseID))
+	if parent.Type == "data_source_id" {
+		target = fmt.Sprintf("%s/v1/data_sources/%s/query", c.baseURL, url.PathEscape(parent.DataSourceID))
+	}
+	query := map[string]interface{}{
+		"filter": map[string]interface{}{
+			"timestamp":    "created_time",
+			"created_time": map[string]string{"on_or_after": cutoff.Format(time.RFC3339)},
+		},
+		"sorts":     []map[string]string{{"timestamp": "created_time", "direction": "descending"}},
+		"page_size": 20,
+	}
+	var list notionListResponse
+	if err := c.postJSON(ctx, "查询 Notion 数据库", target, query, &list); err != nil {
+		return nil, err
+	}
+	for _, page := range list.Results {
+		pages = append(pages, notionPageResponse{ID: page.ID, URL: firstNonEmpty(page.URL, notionPageURL(page.ID))})
+	}
+	return pages, nil
+}
+
+// pageHasConversation 检查页面开头的元数据中是否有 "对话 ID: convID"。
+func (c *Client) pageHasConversation(ctx context.Context, pageID, convID string) (bool, error) {
+	var list notionListResponse
+	target := fmt.Sprintf("%s/v1/blocks/%s/children?page_size=5", c.baseURL, url.PathEscape(pageID))
+	if err := c.getJSON(ctx, "读取 Notion 页面内容", target, &list); err != nil {
+		return false, err
+	}
+	want := fmt.Sprintf("对话 ID: %s", convID)
+	for _, block := range list.Results {
+		if block.BulletedListItem == nil {
+			continue
+		}
+		var text strings.Builder
+		for _, rt := range block.BulletedListItem.RichText {
+			text.WriteString(rt.PlainText)
+		}
+		if strings.TrimSpace(text.String()) == want {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// postJSON 发送 POST 请求并解析 JSON 响应。
+func (c *Client) postJSON(ctx context.Context, action, target string, body, out interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("序列化 Notion 请求失败: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("构造 Notion 请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if c.version != "" {
+		req.Header.Set("Notion-Version", c.version)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("调用 Notion 接口失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return newAPIError(action, resp.StatusCode, targets.ReadBody(resp.Body))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("解析 Notion 响应失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package notion
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Devoty/openai-backup/export"
+)
+
+// childPages 返回父页面下子区块列表的响应, ages 为各子页面创建至今的时长。
+func childPages(ages map[string]time.Duration) string {
+	var results []string
+	for id, age := range ages {
+		results = append(results, fmt.Sprintf(`{"id":%q,"type":"child_page","created_time":%q}`, id, time.Now().Add(-age).UTC().Format(time.RFC3339)))
+	}
+	results = append(results, `{"id":"para","type":"paragraph","created_time":"2024-01-01T00:00:00Z"}`)
+	return `{"results":[` + strings.Join(results, ",") + `],"has_more":false}`
+}
+
+// metadataBlocks 返回页面开头元数据区块的响应。
+func metadataBlocks(convID string) string {
+	return fmt.Sprintf(`{"results":[{"id":"b1","type":"bulleted_list_item","bulleted_list_item":{"rich_text":[{"plain_text":"对话 ID: %s"}]}}]}`, convID)
+}
+
+func TestFindCreatedPage(t *testing.T) {
+	tests := []struct {
+		name         string
+		parent       notionParent
+		children     map[string]string
+		query        string
+		wantFound    bool
+		wantPage     notionPageResponse
+		wantRequests []string
+	}{
+		{
+			name:   "页面父级下的子页面",
+			parent: notionParent{Type: "page_id", PageID: "parent-1"},
+			children: map[string]string{
+				"parent-1": childPages(map[string]time.Duration{"new-page": 0, "old-page": time.Hour}),
+				"new-page": metadataBlocks("c1"),
+				"old-page": metadataBlocks("c1"),
+			},
+			wantFound:    true,
+			wantPage:     notionPageResponse{ID: "new-page", URL: "https://www.notion.so/newpage"},
+			wantRequests: []string{"GET /v1/blocks/parent-1/children", "GET /v1/blocks/new-page/children"},
+		},
+		{
+			name:         "数据源中按创建时间查询",
+			parent:       notionParent{Type: "data_source_id", DataSourceID: "ds-1"},
+			query:        `{"results":[{"id":"other","url":""},{"id":"p2","url":"https://www.notion.so/Deploy-p2"}]}`,
+			children:     map[string]string{"other": metadataBlocks("c9"), "p2": metadataBlocks("c1")},
+			wantFound:    true,
+			wantPage:     notionPageResponse{ID: "p2", URL: "https://www.notion.so/Deploy-p2"},
+			wantRequests: []string{"POST /v1/data_sources/ds-1/query", "GET /v1/blocks/other/children", "GET /v1/blocks/p2/children"},
+		},
+		{
+			name:         "数据库中没有对应页面",
+			parent:       notionParent{Type: "database_id", DatabaseID: "db-1"},
+			query:        `{"results":[{"id":"other"}]}`,
+			children:     map[string]string{"other": metadataBlocks("c9")},
+			wantRequests: []string{"POST /v1/databases/db-1/query", "GET /v1/blocks/other/children"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeNotion{children: tt.children, query: tt.query}
+			c := newFakeClient(t, api, Config{})
+			page, found, err := c.findCreatedPage(context.Background(), tt.parent, "c1", time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if found != tt.wantFound || page != tt.wantPage {
+				t.Errorf("findCreatedPage() = %+v, %v, want %+v, %v", page, found, tt.wantPage, tt.wantFound)
+			}
+			if strings.Join(api.requests, ",") != strings.Join(tt.wantRequests, ",") {
+				t.Errorf("requests = %v, want %v", api.requests, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestCreateConversationAfterUncertainFailure(t *testing.T) {
+	conv := export.Conversation{ID: "c1", Title: "部署", Messages: []export.Message{{Role: "user", Text: "你好"}}}
+	tests := []struct {
+		name         string
+		dropCreate   bool
+		createErrors []string
+		children     map[string]string
+		wantRequests []string
+		wantID       string
+	}{
+		{
+			name:         "找到已创建的页面",
+			dropCreate:   true,
+			children:     map[string]string{"parent-1": childPages(map[string]time.Duration{"page-1": 0}), "page-1": metadataBlocks("c1")},
+			wantRequests: []string{"GET /v1/blocks/parent-1/children", "GET /v1/blocks/page-1/children"},
+			wantID:       "page-1",
+		},
+		{
+			name:         "没有找到时重新创建",
+			dropCreate:   true,
+			children:     map[string]string{"parent-1": childPages(nil)},
+			wantRequests: []string{"GET /v1/blocks/parent-1/children", "POST /v1/pages"},
+			wantID:       "page-2",
+		},
+		{
+			name:         "确定失败的请求不查找",
+			createErrors: []string{`{"code":"restricted_resource","message":"Insufficient permissions"}`},
+			wantRequests: []string{"POST /v1/pages"},
+			wantID:       "page-1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeNotion{dropCreate: tt.dropCreate, createErrors: tt.createErrors}
+			c := newFakeClient(t, api, Config{})
+			if _, err := c.CreateConversation(context.Background(), conv, "UTC"); err == nil {
+				t.Fatal("第一次创建应失败")
+			}
+			api.mu.Lock()
+			api.dropCreate, api.children, api.requests = false, tt.children, nil
+			api.mu.Unlock()
+			obj, err := c.CreateConversation(context.Background(), conv, "UTC")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if obj.ID != tt.wantID {
+				t.Errorf("CreateConversation() = %+v, want ID %s", obj, tt.wantID)
+			}
+			if strings.Join(api.requests, ",") != strings.Join(tt.wantRequests, ",") {
+				t.Errorf("requests = %v, want %v", api.requests, tt.wantRequests)
+			}
+			if _, pending := c.pending.Since(conv.ID); pending {
+				t.Error("成功后仍记录为结果不确定")
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package notion
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/Devoty/openai-backup/targets"
+)
+
+// 草稿模式: 配置了草稿父级 (如名为 Inbox 的页面) 时, 导出的页面先创建在草稿父级下,
+// 审阅后由 Promote 移动到最终父级。
+
+// Drafts 判断是否启用了草稿父级。
+func (c *Client) Drafts() bool {
+	return c.draftParentID != ""
+}
+
+// pageTitleKey 返回创建页面时使用的标题属性名, 草稿父级为页面时固定为 title。
+func (c *Client) pageTitleKey() string {
+	if c.draftParentID != "" {
+		return c.draftTitleKey
+	}
+	return c.titlePropertyKey
+}
+
+// Promote 把草稿页面移动到最终父级, 返回移动后的页面。
+func (c *Client) Promote(ctx context.Context, pageID string) (targets.Object, error) {
+	if pageID == "" {
+		return targets.Object{}, fmt.Errorf("缺少 Notion 页面 ID")
+	}
+	parent, err := c.finalParent(ctx)
+	if err != nil {
+		return targets.Object{}, err
+	}
+	data, err := json.Marshal(map[string]notionParent{"parent": parent})
+	if err != nil {
+		return targets.Object{}, fmt.Errorf("序列化 Notion 请求失败: %w", err)
+	}
+	target := fmt.Sprintf("%s/v1/pages/%s/move", c.baseURL, url.PathEscape(pageID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(data))
+	if err != nil {
+		return targets.Object{}, fmt.Errorf("构造 Notion 请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if c.version != "" {
+		req.Header.Set("Notion-Version", c.version)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return targets.Object{}, fmt.Errorf("调用 Notion 接口失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return targets.Object{}, c.parentHint(newAPIError("移动 Notion 页面", resp.StatusCode, targets.ReadBody(resp.Body)))
+	}
+	var result notionPageResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return targets.Object{}, fmt.Errorf("解析 Notion 响应失败: %w", err)
+	}
+	result.ID = firstNonEmpty(result.ID, pageID)
+	if result.URL == "" {
+		result.URL = notionPageURL(result.ID)
+	}
+	return targets.Object{ID: result.ID, URL: result.URL}, nil
+}
@@ -0,0 +1,121 @@
+package notion
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+func TestDraftParent(t *testing.T) {
+	conv := export.Conversation{ID: "c1", Title: "部署", Messages: []export.Message{{Role: "user", Text: "你好"}}}
+	tests := []struct {
+		name         string
+		cfg          Config
+		wantDrafts   bool
+		wantParent   notionParent
+		wantTitleKey string
+	}{
+		{
+			name:         "未设置草稿父级",
+			cfg:          Config{ParentID: "db-1", ParentType: "database", TitleProperty: "Name"},
+			wantParent:   notionParent{Type: "database_id", DatabaseID: "db-1"},
+			wantTitleKey: "Name",
+		},
+		{
+			name:         "草稿父级为页面时使用 title",
+			cfg:          Config{ParentID: "db-1", ParentType: "database", TitleProperty: "Name", DraftParentID: "inbox"},
+			wantDrafts:   true,
+			wantParent:   notionParent{Type: "page_id", PageID: "inbox"},
+			wantTitleKey: "title",
+		},
+		{
+			name:         "草稿父级为数据源",
+			cfg:          Config{TitleProperty: "Name", DraftParentID: "ds-inbox", DraftParentType: "data_source"},
+			wantDrafts:   true,
+			wantParent:   notionParent{Type: "data_source_id", DataSourceID: "ds-inbox"},
+			wantTitleKey: "Name",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeNotion{}
+			c := newFakeClient(t, api, tt.cfg)
+			if c.Drafts() != tt.wantDrafts {
+				t.Errorf("Drafts() = %v, want %v", c.Drafts(), tt.wantDrafts)
+			}
+			if _, err := c.CreateConversation(context.Background(), conv, "UTC"); err != nil {
+				t.Fatal(err)
+			}
+			page := api.pages[0]
+			if page.Parent != tt.wantParent {
+				t.Errorf("parent = %+v, want %+v", page.Parent, tt.wantParent)
+			}
+			if _, ok := page.Properties[tt.wantTitleKey]; !ok || len(page.Properties) != 1 {
+				t.Errorf("properties = %+v, want 标题属性 %s", page.Properties, tt.wantTitleKey)
+			}
+		})
+	}
+}
+
+func TestPromote(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        Config
+		pageID     string
+		moveStatus int
+		wantParent notionParent
+		wantErr    string
+	}{
+		{
+			name:       "移动到页面父级",
+			cfg:        Config{DraftParentID: "inbox"},
+			pageID:     "page-9",
+			wantParent: notionParent{Type: "page_id", PageID: "parent-1"},
+		},
+		{
+			name:       "移动到旧版本的数据库",
+			cfg:        Config{ParentID: "db-1", ParentType: "database", TitleProperty: "Name", Version: "2022-06-28", DraftParentID: "inbox"},
+			pageID:     "page-9",
+			wantParent: notionParent{Type: "database_id", DatabaseID: "db-1"},
+		},
+		{name: "缺少页面 ID", cfg: Config{DraftParentID: "inbox"}, wantErr: "缺少 Notion 页面 ID"},
+		{
+			name:       "父级校验错误附带说明",
+			cfg:        Config{ParentID: "db-1", ParentType: "database", TitleProperty: "Name", Version: "2022-06-28", DraftParentID: "inbox"},
+			pageID:     "page-9",
+			moveStatus: http.StatusBadRequest,
+			wantErr:    "父级类型=page (草稿)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeNotion{moveStatus: tt.moveStatus}
+			c := newFakeClient(t, api, tt.cfg)
+			obj, err := c.Promote(context.Background(), tt.pageID)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				var statusErr *targets.StatusError
+				if tt.moveStatus != 0 && (!errors.As(err, &statusErr) || statusErr.Status != tt.moveStatus) {
+					t.Errorf("err = %v, want 状态码 %d", err, tt.moveStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if obj.ID != tt.pageID || obj.URL != "https://www.notion.so/page9" {
+				t.Errorf("Promote() = %+v", obj)
+			}
+			if len(api.moves) != 1 || api.moves[0] != tt.wantParent || api.requests[0] != "POST /v1/pages/"+tt.pageID+"/move" {
+				t.Errorf("requests = %v moves = %+v, want %+v", api.requests, api.moves, tt.wantParent)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package notion
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Devoty/openai-backup/logging"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+// Notion 错误码: validation_error 常指向某个区块 (如 body.children[3].code...), conflict_error 为并发写入冲突。
+const (
+	errCodeValidation = "validation_error"
+	errCodeConflict   = "conflict_error"
+)
+
+// conflictRetryWait 是 conflict_error 后的基础等待时间, 按重试次数递增。
+const conflictRetryWait = time.Second
+
+// fallbackPlaceholder 是被拒绝的区块没有可用文字时的替代内容。
+const fallbackPlaceholder = "[此处内容无法写入 Notion, 已省略]"
+
+var rejectedChildPattern = regexp.MustCompile(`children\[(\d+)\]`)
+
+// apiError 是 Notion 返回的错误响应, 保留 code 用于区分可以就地处理的区块错误。
+type apiError struct {
+	targets.StatusError
+	Code string
+}
+
+func (e *apiError) Unwrap() error {
+	return &e.StatusError
+}
+
+// newAPIError 解析 Notion 的错误响应, 无法解析时以原始内容作为错误信息。
+func newAPIError(action string, status int, body string) *apiError {
+	e := &apiError{StatusError: targets.StatusError{Action: action, Status: status, Message: strings.TrimSpace(body)}}
+	var resp notionErrorResponse
+	if err := json.Unmarshal([]byte(body), &resp); err == nil && resp.Message != "" {
+		e.Message = strings.TrimSpace(resp.Message)
+		e.Code = resp.Code
+	}
+	return e
+}
+
+// isConflict 判断是否为可以稍后重试的写入冲突。
+func isConflict(err error) bool {
+	var e *apiError
+	return errors.As(err, &e) && (e.Code == errCodeConflict || e.Status == http.StatusConflict)
+}
+
+// rejectedBlock 从 validation_error 中找出被拒绝区块在本次请求中的下标。
+func rejectedBlock(err error, count int) (int, string, bool) {
+	var e *apiError
+	if !errors.As(err, &e) || e.Code != errCodeValidation {
+		return 0, "", false
+	}
+	match := rejectedChildPattern.FindStringSubmatch(e.Message)
+	if match == nil {
+		return 0, "", false
+	}
+	idx, convErr := strconv.Atoi(match[1])
+	if convErr != nil || idx < 0 || idx >= count {
+		return 0, "", false
+	}
+	return idx, e.Message, true
+}
+
+// sendBlocks 调用 send 写入 blocks, offset 为 blocks[0] 之前已写入的区块数。Notion 以 validation_error
+// 拒绝某个区块时把它替换为纯文本段落后重试 (每个区块只替换一次), 遇到 conflict_error 时等待后重试,
+// 其余错误直接返回。返回替换记录。
+func (c *Client) sendBlocks(ctx context.Context, blocks []notionBlock, offset int, send func([]notionBlock) error) ([]targets.Substitution, error) {
+	blocks = append([]notionBlock(nil), blocks...)
+	replaced := make(map[int]bool)
+	var subs []targets.Substitution
+	conflicts := 0
+	for {
+		err := send(blocks)
+		if err == nil {
+			return subs, nil
+		}
+		if idx, reason, ok := rejectedBlock(err, len(blocks)); ok && !replaced[idx] {
+			replaced[idx] = true
+			subs = append(subs, targets.Substitution{Block: offset + idx + 1, Type: blocks[idx].Type, Reason: reason})
+			logging.Infof("Notion 拒绝第 %d 个区块 (%s), 改为纯文本后重试: %s", offset+idx+1, blocks[idx].Type, reason)
+			blocks[idx] = c.fallbackBlock(blocks[idx])
+			continue
+		}
+		if isConflict(err) && conflicts < appendMaxAttempts {
+			conflicts++
+			timer := time.NewTimer(conflictRetryWait * time.Duration(conflicts))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return subs, ctx.Err()
+			case <-timer.C:
+			}
+			continue
+		}
+		return subs, err
+	}
+}
+
+// fallbackBlock 把区块替换为只含纯文本的段落, 文字取自原区块的内容、公式或文件说明。
+func (c *Client) fallbackBlock(block notionBlock) notionBlock {
+	text := strings.TrimSpace(blockPlainText(block))
+	if text == "" {
+		text = fallbackPlaceholder
+	}
+	parts := c.chunkText(text)
+	if len(parts) > capabilities.MaxTextsPerBlock {
+		parts = parts[:capabilities.MaxTextsPerBlock]
+	}
+	richTexts := make([]notionRichText, 0, len(parts))
+	for _, part := range parts {
+		richTexts = append(richTexts, newNotionPlainText(part, nil))
+	}
+	return notionBlock{Object: "block", Type: "paragraph", Paragraph: &notionParagraph{RichText: richTexts}}
+}
+
+func blockPlainText(block notionBlock) string {
+	var richTexts []notionRichText
+	switch {
+	case block.Paragraph != nil:
+		richTexts = block.Paragraph.RichText
+	case block.Heading2 != nil:
+		richTexts = block.Heading2.RichText
+	case block.Heading3 != nil:
+		richTexts = block.Heading3.RichText
+	case block.BulletedListItem != nil:
+		richTexts = block.BulletedListItem.RichText
+	case block.Code != nil:
+		richTexts = block.Code.RichText
+	case block.Image != nil:
+		richTexts = block.Image.Caption
+	case block.Audio != nil:
+		richTexts = block.Audio.Caption
+	case block.File != nil:
+		richTexts = block.File.Caption
+	case block.Equation != nil:
+		return block.Equation.Expression
+	}
+	var b strings.Builder
+	for _, rt := range richTexts {
+		b.WriteString(rt.PlainText)
+	}
+	return b.String()
+}
@@ -0,0 +1,177 @@
+package notion
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+func TestNewAPIError(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantCode    string
+		wantMessage string
+	}{
+		{name: "Notion 错误响应", body: `{"object":"error","code":"validation_error","message":" body.children[2] is invalid "}`, wantCode: errCodeValidation, wantMessage: "body.children[2] is invalid"},
+		{name: "不是 JSON", body: " bad gateway ", wantMessage: "bad gateway"},
+		{name: "缺少 message", body: `{"code":"conflict_error"}`, wantMessage: `{"code":"conflict_error"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newAPIError("创建 Notion 页面", http.StatusBadRequest, tt.body)
+			if err.Code != tt.wantCode || err.Message != tt.wantMessage {
+				t.Errorf("newAPIError() = code %q message %q, want %q %q", err.Code, err.Message, tt.wantCode, tt.wantMessage)
+			}
+			var statusErr *targets.StatusError
+			if !errors.As(error(err), &statusErr) || statusErr.Status != http.StatusBadRequest {
+				t.Errorf("errors.As(StatusError) 失败: %v", err)
+			}
+		})
+	}
+}
+
+func TestRejectedBlock(t *testing.T) {
+	validation := func(message string) error {
+		return &apiError{StatusError: targets.StatusError{Status: 400, Message: message}, Code: errCodeValidation}
+	}
+	tests := []struct {
+		name    string
+		err     error
+		wantIdx int
+		wantOK  bool
+	}{
+		{name: "指向区块", err: validation("body.children[3].code.language should be one of ..."), wantIdx: 3, wantOK: true},
+		{name: "超出本次请求", err: validation("body.children[5].paragraph is invalid"), wantOK: false},
+		{name: "没有区块下标", err: validation("body.properties.Name should be defined"), wantOK: false},
+		{name: "非校验错误", err: &apiError{StatusError: targets.StatusError{Status: 409, Message: "children[1]"}, Code: errCodeConflict}, wantOK: false},
+		{name: "其他错误", err: errors.New("children[1]"), wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx, _, ok := rejectedBlock(tt.err, 5)
+			if ok != tt.wantOK || idx != tt.wantIdx {
+				t.Errorf("rejectedBlock() = %d, %v, want %d, %v", idx, ok, tt.wantIdx, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestIsConflict(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{newAPIError("a", http.StatusConflict, `{"code":"conflict_error","message":"Conflict occurred while saving."}`), true},
+		{newAPIError("a", http.StatusBadRequest, `{"code":"conflict_error","message":"Conflict"}`), true},
+		{newAPIError("a", http.StatusConflict, "conflict"), true},
+		{newAPIError("a", http.StatusBadRequest, `{"code":"validation_error","message":"bad"}`), false},
+		{errors.New("conflict"), false},
+	}
+	for _, tt := range tests {
+		if got := isConflict(tt.err); got != tt.want {
+			t.Errorf("isConflict(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestFallbackBlock(t *testing.T) {
+	c := &Client{}
+	tests := []struct {
+		name  string
+		block notionBlock
+		want  string
+	}{
+		{name: "代码块", block: c.newCode("fmt.Println(1)", "go"), want: "fmt.Println(1)"},
+		{name: "公式", block: notionBlock{Type: "equation", Equation: &notionEquation{Expression: `E = mc^2`}}, want: "E = mc^2"},
+		{name: "图片说明", block: notionBlock{Type: "image", Image: &notionFile{Caption: []notionRichText{newNotionPlainText("架构图", nil)}}}, want: "架构图"},
+		{name: "没有文字", block: newNotionDivider(), want: fallbackPlaceholder},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := c.fallbackBlock(tt.block)
+			if got.Type != "paragraph" || got.Paragraph == nil || blockPlainText(got) != tt.want {
+				t.Errorf("fallbackBlock() = %+v, want 段落 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendBlocks(t *testing.T) {
+	c := &Client{}
+	blocks := []notionBlock{newNotionBulletedParagraph("a"), c.newCode("x := 1", "golang"), newNotionBulletedParagraph("b")}
+	rejectCode := newAPIError("追加 Notion 区块", 400, `{"code":"validation_error","message":"body.children[1].code.language should be \"go\""}`)
+	tests := []struct {
+		name      string
+		responses []error
+		ctx       func() context.Context
+		wantSubs  int
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "一次成功", responses: []error{nil}, wantCalls: 1},
+		{name: "被拒绝的区块改为纯文本", responses: []error{rejectCode, nil}, wantSubs: 1, wantCalls: 2},
+		{name: "同一区块只替换一次", responses: []error{rejectCode, rejectCode}, wantSubs: 1, wantCalls: 2, wantErr: true},
+		{
+			name:      "写入冲突时等待被取消",
+			responses: []error{newAPIError("追加 Notion 区块", http.StatusConflict, `{"code":"conflict_error","message":"Conflict"}`)},
+			ctx: func() context.Context {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx
+			},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{name: "其他错误直接返回", responses: []error{errors.New("connection reset")}, wantCalls: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.ctx != nil {
+				ctx = tt.ctx()
+			}
+			var sent [][]notionBlock
+			subs, err := c.sendBlocks(ctx, blocks, 10, func(batch []notionBlock) error {
+				sent = append(sent, batch)
+				return tt.responses[len(sent)-1]
+			})
+			if (err != nil) != tt.wantErr || len(subs) != tt.wantSubs || len(sent) != tt.wantCalls {
+				t.Fatalf("sendBlocks() = %+v, %v; 调用 %d 次", subs, err, len(sent))
+			}
+			if tt.wantSubs == 0 {
+				return
+			}
+			if sub := subs[0]; sub.Block != 12 || sub.Type != "code" || !strings.Contains(sub.Reason, "code.language") {
+				t.Errorf("Substitution = %+v", sub)
+			}
+			if last := sent[len(sent)-1]; last[1].Type != "paragraph" || blockPlainText(last[1]) != "x := 1" {
+				t.Errorf("重试时第 2 个区块 = %+v", last[1])
+			}
+			if blocks[1].Type != "code" {
+				t.Error("传入的区块被修改")
+			}
+		})
+	}
+}
+
+func TestCreateConversationSubstitutions(t *testing.T) {
+	api := &fakeNotion{createErrors: []string{`{"object":"error","code":"validation_error","message":"body.children[5].code.language should be \"go\""}`}}
+	c := newFakeClient(t, api, Config{})
+	conv := export.Conversation{ID: "c1", Title: "代码", Messages: []export.Message{{Role: "assistant", Text: "```golang\nx := 1\n```"}}}
+	obj, err := c.CreateConversation(context.Background(), conv, "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(obj.Substitutions) != 1 || obj.Substitutions[0].Block != 6 || obj.Substitutions[0].Type != "code" {
+		t.Fatalf("Substitutions = %+v", obj.Substitutions)
+	}
+	if len(api.pages) != 1 || api.pages[0].Children[5].Type != "paragraph" {
+		t.Errorf("重试的页面区块 = %+v", api.pages)
+	}
+}
@@ -0,0 +1,66 @@
+package notion
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Devoty/openai-backup/export"
+)
+
+// layoutConversation 包含两个相隔一天的话题, 按 topics 与 by_day 都应分为两组。
+func layoutConversation(layout string) export.Conversation {
+	day := 24 * time.Hour.Seconds()
+	start := float64(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC).Unix())
+	return export.Conversation{
+		ID:     "conv-layout",
+		Title:  "排列方式",
+		Layout: layout,
+		Messages: []export.Message{
+			{Role: "user", Text: "如何配置 Nginx?", CreateTime: start},
+			{Role: "assistant", Text: "编辑 nginx.conf。", CreateTime: start + 60},
+			{Role: "user", Text: "换个问题: Go 的切片如何扩容?", CreateTime: start + day},
+			{Role: "assistant", Text: "按容量翻倍。", CreateTime: start + day + 60},
+		},
+	}
+}
+
+// blockOutline 把页面中消息部分的结构压缩为字符串: H2 为组标题, H3 为消息标题, --- 为分隔线。
+func blockOutline(blocks []notionBlock) string {
+	var parts []string
+	for _, block := range blocks {
+		switch {
+		case block.Heading2 != nil:
+			parts = append(parts, "H2:"+block.Heading2.RichText[0].Text.Content)
+		case block.Heading3 != nil:
+			parts = append(parts, "H3:"+strings.SplitN(block.Heading3.RichText[0].Text.Content, " ", 2)[0])
+		case block.Divider != nil:
+			parts = append(parts, "---")
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestBuildPageRequestLayout(t *testing.T) {
+	c, err := New(Config{Token: "test", ParentID: "test-parent"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		layout string
+		want   string
+	}{
+		{export.LayoutChronological, "--- H3:1. H3:2. H3:3. H3:4."},
+		{export.LayoutByDay, "--- H2:2024-03-01 H3:1. H3:2. H2:2024-03-02 H3:3. H3:4."},
+		{export.LayoutQA, "--- H2:问答 1 · 2024-03-01 09:00:00 H3:1. H3:2. H2:问答 2 · 2024-03-02 09:00:00 H3:3. H3:4."},
+		{export.LayoutTopics, "--- H2:话题 1 · 如何配置 Nginx? H3:1. H3:2. --- H2:话题 2 · 换个问题: Go 的切片如何扩容? H3:3. H3:4."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.layout, func(t *testing.T) {
+			page := c.buildPageRequest(layoutConversation(tt.layout), time.UTC, nil)
+			if got := blockOutline(page.Children); got != tt.want {
+				t.Errorf("layout %s:\n got  %s\n want %s", tt.layout, got, tt.want)
+			}
+		})
+	}
+}
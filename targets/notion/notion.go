@@ -0,0 +1,914 @@
+// Package notion 把导出的对话写入 Notion 页面, 支持代码块、公式与图片、语音等文件的上传。
+package notion
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/httpc"
+	"github.com/Devoty/openai-backup/logging"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+const defaultBaseURL = "https://api.notion.com"
+
+// capabilities 为 Notion API 的请求限制: rich_text 单段 2000 字符 (留出余量)、
+// 单个区块最多 100 段 rich_text、创建页面或追加子区块时每次最多 100 个区块。
+var capabilities = targets.Capabilities{
+	MaxTitleLength:      2000,
+	MaxTextLength:       1800,
+	MaxTextsPerBlock:    100,
+	MaxBlocksPerRequest: 100,
+	BlockTypes:          []string{"paragraph", "heading_2", "heading_3", "bulleted_list_item", "divider", "image", "audio", "file", "equation", "code", "table_of_contents"},
+}
+
+// Config 是创建 Client 所需的 Notion 连接参数。
+type Config struct {
+	Token string
+	// ParentID 为页面、数据库或数据源 ID, ParentType 取 page (默认)、database 或 data_source。
+	ParentID   string
+	ParentType string
+	// TitleProperty 为标题属性名, 父级为页面时默认 title。
+	TitleProperty string
+	BaseURL       string
+	Version       string
+	MathMode      string
+	// ChunkMode 为长文本拆分为多个 rich_text 的方式, 取 targets.ChunkWords (默认)、ChunkGraphemes 或 ChunkRunes。
+	ChunkMode string
+	// DraftParentID 非空时页面先创建在草稿父级下, 审阅后用 Promote 移动到 ParentID;
+	// DraftParentType 的取值与 ParentType 相同。
+	DraftParentID   string
+	DraftParentType string
+	// ProjectProperty 为数据库中的选择 (select) 属性名, 非空时把对话的 Project 写入该属性。
+	ProjectProperty string
+	// URLProperty 为数据库中的网址 (url) 属性名, 非空时写入原始 ChatGPT 对话的地址。
+	URLProperty string
+	// UploadAudio 为 true 时语音也上传为音频区块, 否则只上传图片。
+	UploadAudio bool
+	// UploadAttachments 为 true 时用户上传的文件以文件区块写入页面开头的信息列表。
+	UploadAttachments bool
+}
+
+// Client 通过 Notion API 为每个对话创建一个页面。
+type Client struct {
+	httpClient       *http.Client
+	baseURL          string
+	version          string
+	token            string
+	parentType       string
+	parentID         string
+	titlePropertyKey string
+	mathMode         string
+	chunkMode        string
+	// dataSourceID 缓存新版本下从数据库解析出的数据源, 见 pageParent。
+	parentMu     sync.Mutex
+	dataSourceID string
+	// 草稿父级, 见 draft.go。
+	draftParentType   string
+	draftParentID     string
+	draftTitleKey     string
+	draftDataSourceID string
+	// projectKey 为写入项目分类的选择属性, 见 Config.ProjectProperty。
+	projectKey string
+	// urlKey 为写入原始对话地址的网址属性, 见 Config.URLProperty。
+	urlKey string
+	// uploadAudio 与 uploadAttachments 见 Config 中的同名字段。
+	uploadAudio       bool
+	uploadAttachments bool
+	// pending 记录结果不确定的页面创建请求, 见 dedupe.go。
+	pending targets.PendingCreates
+	// FetchAsset 下载 ChatGPT 文件内容 (文件指针或上传文件 ID), 用于把图片等文件上传到 Notion;
+	// 为空时只保留文件指针。
+	FetchAsset func(ctx context.Context, pointer string) ([]byte, error)
+}
+
+type notionPageRequest struct {
+	Parent     notionParent              `json:"parent"`
+	Properties map[string]notionProperty `json:"properties"`
+	Children   []notionBlock             `json:"children,omitempty"`
+}
+
+type notionParent struct {
+	Type         string `json:"type"`
+	DatabaseID   string `json:"database_id,omitempty"`
+	DataSourceID string `json:"data_source_id,omitempty"`
+	PageID       string `json:"page_id,omitempty"`
+}
+
+type notionProperty struct {
+	Title  []notionRichText `json:"title,omitempty"`
+	Select *notionSelect    `json:"select,omitempty"`
+	URL    *string          `json:"url,omitempty"`
+}
+
+type notionSelect struct {
+	Name string `json:"name"`
+}
+
+type notionRichText struct {
+	Type        string             `json:"type"`
+	PlainText   string             `json:"plain_text"`
+	Text        *notionText        `json:"text,omitempty"`
+	Equation    *notionEquation    `json:"equation,omitempty"`
+	Annotations *notionAnnotations `json:"annotations,omitempty"`
+}
+
+type notionText struct {
+	Content string      `json:"content"`
+	Link    *notionLink `json:"link,omitempty"`
+}
+
+type notionLink struct {
+	URL string `json:"url"`
+}
+
+type notionEquation struct {
+	Expression string `json:"expression"`
+}
+
+type notionAnnotations struct {
+	Bold   bool `json:"bold,omitempty"`
+	Italic bool `json:"italic,omitempty"`
+}
+
+type notionBlock struct {
+	Object           string           `json:"object"`
+	Type             string           `json:"type"`
+	Paragraph        *notionParagraph `json:"paragraph,omitempty"`
+	Heading2         *notionHeading   `json:"heading_2,omitempty"`
+	Heading3         *notionHeading   `json:"heading_3,omitempty"`
+	BulletedListItem *notionParagraph `json:"bulleted_list_item,omitempty"`
+	Divider          *struct{}        `json:"divider,omitempty"`
+	Image            *notionFile      `json:"image,omitempty"`
+	Audio            *notionFile      `json:"audio,omitempty"`
+	File             *notionFile      `json:"file,omitempty"`
+	Equation         *notionEquation  `json:"equation,omitempty"`
+	Code             *notionCode      `json:"code,omitempty"`
+	// TableOfContents 是 Notion 自动生成的目录区块, 列出页面中的标题并可点击跳转。
+	TableOfContents *struct{} `json:"table_of_contents,omitempty"`
+}
+
+type notionCode struct {
+	RichText []notionRichText `json:"rich_text"`
+	Language string           `json:"language"`
+}
+
+// notionFile 是 image、audio 与 file 区块引用的文件; Name 只用于 file 区块。
+type notionFile struct {
+	Type       string           `json:"type"`
+	FileUpload *notionFileRef   `json:"file_upload,omitempty"`
+	Caption    []notionRichText `json:"caption,omitempty"`
+	Name       string           `json:"name,omitempty"`
+}
+
+type notionFileRef struct {
+	ID string `json:"id"`
+}
+
+type notionParagraph struct {
+	RichText []notionRichText `json:"rich_text"`
+}
+
+type notionHeading struct {
+	RichText []notionRichText `json:"rich_text"`
+}
+
+type notionAppendRequest struct {
+	Children []notionBlock `json:"children"`
+}
+
+type notionPageResponse struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+type notionErrorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// New 校验配置并创建 Client。
+func New(cfg Config) (*Client, error) {
+	token := strings.TrimSpace(cfg.Token)
+	if token == "" {
+		return nil, fmt.Errorf("缺少 Notion API Key: 请提供 --notion-token")
+	}
+	parentID := strings.TrimSpace(cfg.ParentID)
+	if parentID == "" {
+		return nil, fmt.Errorf("缺少 Notion 父级 ID: 请提供 --notion-parent-id")
+	}
+	parentType := NormalizeParentType(cfg.ParentType)
+	if strings.TrimSpace(cfg.ParentType) == "" {
+		parentType = parentPage
+	}
+	if parentType == "" {
+		return nil, fmt.Errorf("不支持的 Notion 父级类型: %s", cfg.ParentType)
+	}
+	titleProperty := strings.TrimSpace(cfg.TitleProperty)
+
+	baseURL := strings.TrimSpace(cfg.BaseURL)
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	baseURL = strings.TrimRight(baseURL, "/")
+	if parsed, err := url.Parse(baseURL); err != nil || !parsed.IsAbs() {
+		return nil, fmt.Errorf("Notion 基础地址无效: %s", cfg.BaseURL)
+	}
+	draftParentID := strings.TrimSpace(cfg.DraftParentID)
+	draftParentType := NormalizeParentType(cfg.DraftParentType)
+	if strings.TrimSpace(cfg.DraftParentType) == "" {
+		draftParentType = parentPage
+	}
+	if draftParentID != "" && draftParentType == "" {
+		return nil, fmt.Errorf("不支持的 Notion 草稿父级类型: %s", cfg.DraftParentType)
+	}
+	if draftParentID == parentID {
+		draftParentID = ""
+	}
+	version := strings.TrimSpace(cfg.Version)
+	if err := checkVersion(version); err != nil {
+		return nil, err
+	}
+	if (parentType == parentDataSource || (draftParentID != "" && draftParentType == parentDataSource)) && !usesDataSources(version) {
+		// 旧版本没有数据源, 以数据源为父级时自动改用支持它的版本。
+		logging.Infof("Notion 父级为数据源, Notion-Version 由 %q 改为 %s", version, dataSourceVersion)
+		version = dataSourceVersion
+	}
+
+	draftTitleKey := titleProperty
+	if draftParentType == parentPage {
+		draftTitleKey = "title"
+	}
+	if titleProperty == "" {
+		if parentType == parentPage {
+			titleProperty = "title"
+		} else {
+			return nil, fmt.Errorf("缺少 Notion 标题属性: 请提供 --notion-title-property")
+		}
+	}
+	if draftParentID != "" && draftTitleKey == "" {
+		return nil, fmt.Errorf("草稿父级为数据库时需要标题属性: 请提供 --notion-title-property")
+	}
+
+	return &Client{
+		httpClient:       httpc.Client(),
+		baseURL:          baseURL,
+		version:          version,
+		token:            token,
+		parentType:       parentType,
+		parentID:         parentID,
+		titlePropertyKey: titleProperty,
+		mathMode:         export.NormalizeMathMode(cfg.MathMode),
+		chunkMode:        targets.NormalizeChunkMode(cfg.ChunkMode),
+		draftParentType:  draftParentType,
+		draftParentID:    draftParentID,
+		draftTitleKey:    draftTitleKey,
+		projectKey:       strings.TrimSpace(cfg.ProjectProperty),
+		urlKey:           strings.TrimSpace(cfg.URLProperty),
+
+		uploadAudio:       cfg.UploadAudio,
+		uploadAttachments: cfg.UploadAttachments,
+	}, nil
+}
+
+// createConversationPage 创建页面并追加其余区块, 返回页面与被替换为纯文本的区块。
+func (c *Client) createConversationPage(ctx context.Context, conv export.Conversation, loc *time.Location) (notionPageResponse, []targets.Substitution, error) {
+	payload := c.buildPageRequest(conv, loc, c.uploadFiles(ctx, conv))
+	var rest []notionBlock
+	if limit := capabilities.MaxBlocksPerRequest; len(payload.Children) > limit {
+		payload.Children, rest = payload.Children[:limit], payload.Children[limit:]
+	}
+	parent, err := c.pageParent(ctx)
+	if err != nil {
+		return notionPageResponse{}, nil, err
+	}
+	payload.Parent = parent
+	var (
+		result notionPageResponse
+		subs   []targets.Substitution
+		found  bool
+	)
+	if since, ok := c.pending.Since(conv.ID); ok {
+		result, found, err = c.findCreatedPage(ctx, parent, conv.ID, since)
+		if err != nil {
+			return notionPageResponse{}, nil, err
+		}
+		if found {
+			logging.Infof("找到上次结果不确定时已创建的 Notion 页面, 不再重复创建: conversation=%s page=%s", conv.ID, result.ID)
+			c.pending.Clear(conv.ID)
+		}
+	}
+	if !found {
+		started := time.Now()
+		subs, err = c.sendBlocks(ctx, payload.Children, 0, func(blocks []notionBlock) error {
+			payload.Children = blocks
+			var err error
+			result, err = c.postPage(ctx, payload)
+			return err
+		})
+		c.pending.Finish(conv.ID, started, err)
+		if err != nil {
+			return notionPageResponse{}, subs, c.parentHint(err)
+		}
+	}
+	if result.URL == "" {
+		result.URL = notionPageURL(result.ID)
+	}
+	more, err := c.appendChildren(ctx, result.ID, rest, len(payload.Children))
+	subs = append(subs, more...)
+	if err != nil {
+		// 页面已经创建, 不再交给熔断器整体重试, 以免产生重复页面。
+		return result, subs, fmt.Errorf("Notion 页面已创建但内容不完整 (%s): %v", result.URL, err)
+	}
+	return result, subs, nil
+}
+
+func (c *Client) postPage(ctx context.Context, payload notionPageRequest) (notionPageResponse, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return notionPageResponse{}, fmt.Errorf("序列化 Notion 请求失败: %w", err)
+	}
+
+	target := c.baseURL + "/v1/pages"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(data))
+	if err != nil {
+		return notionPageResponse{}, fmt.Errorf("构造 Notion 请求失败: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if c.version != "" {
+		req.Header.Set("Notion-Version", c.version)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return notionPageResponse{}, fmt.Errorf("调用 Notion 接口失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return notionPageResponse{}, newAPIError("创建 Notion 页面", resp.StatusCode, targets.ReadBody(resp.Body))
+	}
+
+	var result notionPageResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return notionPageResponse{}, fmt.Errorf("解析 Notion 响应失败: %w", err)
+	}
+	return result, nil
+}
+
+// appendChildren 把超出单次请求上限的区块分批追加到页面末尾, 遇到限流或 5xx 时等待后重试当前批次,
+// 被拒绝的区块按 sendBlocks 替换为纯文本。offset 为页面中已有的区块数。
+func (c *Client) appendChildren(ctx context.Context, pageID string, blocks []notionBlock, offset int) ([]targets.Substitution, error) {
+	limit := capabilities.MaxBlocksPerRequest
+	target := fmt.Sprintf("%s/v1/blocks/%s/children", c.baseURL, url.PathEscape(pageID))
+	var subs []targets.Substitution
+	for len(blocks) > 0 {
+		batch := blocks
+		if len(batch) > limit {
+			batch = batch[:limit]
+		}
+		more, err := c.sendBlocks(ctx, batch, offset, func(batch []notionBlock) error {
+			return c.appendBatch(ctx, target, batch)
+		})
+		subs = append(subs, more...)
+		if err != nil {
+			return subs, err
+		}
+		offset += len(batch)
+		blocks = blocks[len(batch):]
+	}
+	return subs, nil
+}
+
+func (c *Client) appendBatch(ctx context.Context, target string, batch []notionBlock) error {
+	data, err := json.Marshal(notionAppendRequest{Children: batch})
+	if err != nil {
+		return fmt.Errorf("序列化 Notion 请求失败: %w", err)
+	}
+	for attempt := 1; ; attempt++ {
+		wait, err := c.patch(ctx, target, data)
+		if err == nil {
+			return nil
+		}
+		if wait <= 0 || attempt >= appendMaxAttempts {
+			return err
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+const appendMaxAttempts = 3
+
+// patch 发送追加请求。返回可重试错误时附带建议的等待时间, 其余错误等待时间为 0。
+func (c *Client) patch(ctx context.Context, target string, data []byte) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, target, bytes.NewReader(data))
+	if err != nil {
+		return 0, fmt.Errorf("构造 Notion 请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if c.version != "" {
+		req.Header.Set("Notion-Version", c.version)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return time.Second, fmt.Errorf("调用 Notion 接口失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return 0, nil
+	}
+	statusErr := newAPIError("追加 Notion 区块", resp.StatusCode, targets.ReadBody(resp.Body))
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+		return 0, statusErr
+	}
+	wait := 2 * time.Second
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		wait = time.Duration(seconds) * time.Second
+	}
+	return wait, statusErr
+}
+
+func (c *Client) buildPageRequest(conv export.Conversation, loc *time.Location, uploads map[string]notionUpload) notionPageRequest {
+	title := strings.TrimSpace(conv.Title)
+	if title == "" {
+		title = fmt.Sprintf("对话 %s", conv.ID)
+	}
+
+	properties := map[string]notionProperty{
+		c.pageTitleKey(): {Title: []notionRichText{newNotionPlainText(capabilities.TruncateTitle(title), nil)}},
+	}
+	if key := c.projectProperty(); key != "" && strings.TrimSpace(conv.Project) != "" {
+		properties[key] = notionProperty{Select: &notionSelect{Name: selectOptionName(conv.Project)}}
+	}
+	link := export.ConversationURL(conv.ID)
+	if key := c.urlProperty(); key != "" && link != "" {
+		properties[key] = notionProperty{URL: &link}
+	}
+
+	tf := export.NewTimeFormatter(conv, loc)
+	children := make([]notionBlock, 0, len(conv.Messages)*2+4)
+	metadata := []string{
+		fmt.Sprintf("对话 ID: %s", conv.ID),
+		fmt.Sprintf("创建时间: %s", tf.Format(conv.CreateTime)),
+		fmt.Sprintf("最近更新: %s", tf.Format(conv.UpdateTime)),
+	}
+	for _, line := range metadata {
+		children = append(children, newNotionBulletedParagraph(line))
+	}
+	for _, att := range conv.Attachments {
+		line := "上传文件: " + att.Name
+		if detail := export.AttachmentDetail(att); detail != "" {
+			line += " (" + detail + ")"
+		}
+		if upload, ok := uploads[att.ID]; ok {
+			children = append(children, c.newFileBlock(upload, line))
+			continue
+		}
+		children = append(children, newNotionBulletedParagraph(line))
+	}
+	if link != "" {
+		children = slices.Insert(children, 1, newNotionLinkItem("原始对话: ", link))
+	}
+	children = append(children, newNotionDivider())
+	// 消息较多时在正文前插入目录区块, 链接到各条消息的标题。
+	if conv.WantsTOC() {
+		children = append(children, newNotionTableOfContents(), newNotionDivider())
+	}
+
+	if len(conv.Context) > 0 {
+		children = append(children, newNotionHeading3("上下文"))
+		for _, entry := range conv.Context {
+			children = append(children, c.paragraphBlocksFromText(entry.Label, &notionAnnotations{Bold: true})...)
+			children = append(children, c.textBlocks(entry.Text, nil)...)
+		}
+		children = append(children, newNotionDivider())
+	}
+
+	// 按 message_layout 分组: 组标题为二级标题, 话题转换处插入分隔线, 与 Markdown/HTML 导出一致。
+	for _, group := range conv.GroupMessages(tf) {
+		if group.Divider {
+			children = append(children, newNotionDivider())
+		}
+		if group.Heading != "" {
+			children = append(children, newNotionHeading2(group.Heading))
+		}
+		for offset, msg := range group.Messages {
+			children = append(children, c.messageBlocks(group.Start+offset+1, msg, tf, uploads)...)
+		}
+	}
+	if len(conv.Related) > 0 {
+		children = append(children, newNotionDivider(), newNotionHeading3("相关对话"))
+		for _, item := range conv.Related {
+			children = append(children, newNotionRelatedItem(item))
+		}
+	}
+
+	return notionPageRequest{
+		Properties: properties,
+		Children:   children,
+	}
+}
+
+// messageBlocks 生成一条消息的区块: 标题 (num 为全文中的序号)、正文与附带的文件。
+func (c *Client) messageBlocks(num int, msg export.Message, tf export.TimeFormatter, uploads map[string]notionUpload) []notionBlock {
+	role := strings.ToUpper(firstNonEmpty(msg.Role, "UNKNOWN"))
+	heading := fmt.Sprintf("%d. %s · %s", num, role, tf.Format(msg.CreateTime))
+	children := []notionBlock{newNotionHeading3(heading)}
+
+	annotations := determineAnnotations(msg.Role)
+	text := strings.TrimSpace(msg.Text)
+	if text == "" && len(msg.Assets) == 0 {
+		text = "(空内容)"
+	}
+	if text != "" {
+		children = append(children, c.textBlocks(text, annotations)...)
+	}
+	for _, asset := range msg.Assets {
+		if upload, ok := uploads[asset.Pointer]; ok {
+			children = append(children, c.newFileBlock(upload, asset.Prompt))
+			continue
+		}
+		children = append(children, newNotionBulletedParagraph(fmt.Sprintf("%s: %s", export.AssetKindLabel(asset.Kind), asset.Pointer)))
+		if asset.Prompt != "" {
+			children = append(children, newNotionBulletedParagraph("提示词: "+asset.Prompt))
+		}
+	}
+	return children
+}
+
+// projectProperty 返回写入项目分类的属性名; 页面创建在页面父级下时只能设置标题, 返回空字符串。
+func (c *Client) projectProperty() string {
+	if c.databaseParent() {
+		return c.projectKey
+	}
+	return ""
+}
+
+// databaseParent 报告页面是否创建在数据库下, 只有这时才能设置标题以外的属性。
+func (c *Client) databaseParent() bool {
+	parentType := c.parentType
+	if c.draftParentID != "" {
+		parentType = c.draftParentType
+	}
+	return parentType != parentPage
+}
+
+// urlProperty 返回写入原始对话地址的属性名, 与 projectProperty 一样只在数据库父级下生效。
+func (c *Client) urlProperty() string {
+	if c.databaseParent() {
+		return c.urlKey
+	}
+	return ""
+}
+
+// selectOptionName 处理选项名: Notion 的选项名不能包含逗号, 且最长 100 个字符。
+func selectOptionName(name string) string {
+	name = strings.Join(strings.Fields(strings.ReplaceAll(name, ",", " ")), " ")
+	if runes := []rune(name); len(runes) > 100 {
+		name = strings.TrimSpace(string(runes[:100]))
+	}
+	return name
+}
+
+func determineAnnotations(role string) *notionAnnotations {
+	if strings.EqualFold(role, "user") {
+		return &notionAnnotations{Bold: true}
+	}
+	if strings.EqualFold(role, "system") {
+		return &notionAnnotations{Italic: true}
+	}
+	return nil
+}
+
+func (c *Client) paragraphBlocksFromText(text string, annotations *notionAnnotations) []notionBlock {
+	normalized := strings.ReplaceAll(text, "\r\n", "\n")
+	segments := strings.Split(normalized, "\n\n")
+	if len(segments) == 0 {
+		segments = []string{""}
+	}
+	blocks := make([]notionBlock, 0, len(segments))
+	for _, segment := range segments {
+		parts := c.chunkText(segment)
+		richTexts := make([]notionRichText, 0, len(parts))
+		for idx, part := range parts {
+			var ann *notionAnnotations
+			if idx == 0 {
+				ann = annotations
+			}
+			richTexts = append(richTexts, newNotionPlainText(part, ann))
+		}
+		if len(richTexts) == 0 {
+			richTexts = append(richTexts, newNotionPlainText("", annotations))
+		}
+		blocks = append(blocks, newNotionParagraphs(richTexts)...)
+	}
+	return blocks
+}
+
+// newNotionParagraphs 生成段落区块, 文本段超过单个区块上限时拆成多个相邻段落。
+func newNotionParagraphs(richTexts []notionRichText) []notionBlock {
+	limit := capabilities.MaxTextsPerBlock
+	blocks := make([]notionBlock, 0, len(richTexts)/limit+1)
+	for len(richTexts) > 0 {
+		batch := richTexts
+		if len(batch) > limit {
+			batch = batch[:limit]
+		}
+		blocks = append(blocks, notionBlock{
+			Object:    "block",
+			Type:      "paragraph",
+			Paragraph: &notionParagraph{RichText: batch},
+		})
+		richTexts = richTexts[len(batch):]
+	}
+	return blocks
+}
+
+// textBlocks 把消息文本转为 Notion 区块: 围栏代码块 (含 mermaid 等图表源码) 生成带语言的代码块,
+// 其余内容按段落输出。
+func (c *Client) textBlocks(text string, annotations *notionAnnotations) []notionBlock {
+	var blocks []notionBlock
+	for _, block := range export.SplitFencedBlocks(text) {
+		if block.Code {
+			blocks = append(blocks, c.newCode(block.Text, block.Lang))
+			continue
+		}
+		blocks = append(blocks, c.proseBlocks(block.Text, annotations)...)
+	}
+	if len(blocks) == 0 {
+		return c.paragraphBlocksFromText(text, annotations)
+	}
+	return blocks
+}
+
+// proseBlocks 输出普通段落。开启公式转换时, 独立成段的公式生成公式块, 行内公式生成行内公式。
+func (c *Client) proseBlocks(text string, annotations *notionAnnotations) []notionBlock {
+	if c.mathMode == export.MathRaw {
+		return c.paragraphBlocksFromText(text, annotations)
+	}
+	segments := strings.Split(text, "\n\n")
+	blocks := make([]notionBlock, 0, len(segments))
+	for _, segment := range segments {
+		if strings.TrimSpace(segment) == "" {
+			continue
+		}
+		if expr, ok := export.BlockMathExpression(segment); ok && len(expr) <= notionEquationLimit {
+			blocks = append(blocks, notionBlock{Object: "block", Type: "equation", Equation: &notionEquation{Expression: expr}})
+			continue
+		}
+		blocks = append(blocks, newNotionParagraphs(c.richTextsWithMath(segment, annotations))...)
+	}
+	return blocks
+}
+
+func (c *Client) richTextsWithMath(text string, annotations *notionAnnotations) []notionRichText {
+	var richTexts []notionRichText
+	for _, span := range export.SplitInlineMath(text) {
+		if span.Math && span.Text != "" && len(span.Text) <= notionEquationLimit {
+			richTexts = append(richTexts, notionRichText{Type: "equation", PlainText: span.Text, Equation: &notionEquation{Expression: span.Text}})
+			continue
+		}
+		content := span.Text
+		if span.Math {
+			content = "$" + span.Text + "$"
+		}
+		for _, part := range c.chunkText(content) {
+			var ann *notionAnnotations
+			if len(richTexts) == 0 {
+				ann = annotations
+			}
+			richTexts = append(richTexts, newNotionPlainText(part, ann))
+		}
+	}
+	if len(richTexts) == 0 {
+		richTexts = append(richTexts, newNotionPlainText("", annotations))
+	}
+	return richTexts
+}
+
+func newNotionPlainText(content string, annotations *notionAnnotations) notionRichText {
+	if content == "" {
+		content = " "
+	}
+	return notionRichText{
+		Type:        "text",
+		PlainText:   content,
+		Text:        &notionText{Content: content},
+		Annotations: annotations,
+	}
+}
+
+func newNotionBulletedParagraph(content string) notionBlock {
+	return notionBlock{
+		Object: "block",
+		Type:   "bulleted_list_item",
+		BulletedListItem: &notionParagraph{
+			RichText: []notionRichText{newNotionPlainText(content, nil)},
+		},
+	}
+}
+
+// newNotionLinkItem 生成 "标签: 地址" 形式的列表项, 地址为超链接。
+func newNotionLinkItem(label, link string) notionBlock {
+	text := newNotionPlainText(link, nil)
+	text.Text.Link = &notionLink{URL: link}
+	return notionBlock{
+		Object: "block",
+		Type:   "bulleted_list_item",
+		BulletedListItem: &notionParagraph{
+			RichText: []notionRichText{newNotionPlainText(label, nil), text},
+		},
+	}
+}
+
+// newNotionRelatedItem 生成关联对话条目, 仅 http(s) 链接可作为 Notion 超链接。
+func newNotionRelatedItem(item export.RelatedConversation) notionBlock {
+	title := firstNonEmpty(item.Title, item.ID)
+	text := newNotionPlainText(title, nil)
+	if strings.HasPrefix(item.URL, "http://") || strings.HasPrefix(item.URL, "https://") {
+		text.Text.Link = &notionLink{URL: item.URL}
+	} else {
+		text = newNotionPlainText(fmt.Sprintf("%s (%s)", title, item.ID), nil)
+	}
+	return notionBlock{
+		Object: "block",
+		Type:   "bulleted_list_item",
+		BulletedListItem: &notionParagraph{
+			RichText: []notionRichText{text},
+		},
+	}
+}
+
+func (c *Client) newCode(code, lang string) notionBlock {
+	// 超出单个区块文本段上限的代码截断。
+	parts := c.chunkText(code)
+	if len(parts) > capabilities.MaxTextsPerBlock {
+		parts = parts[:capabilities.MaxTextsPerBlock]
+	}
+	richTexts := make([]notionRichText, 0, len(parts)+1)
+	for _, part := range parts {
+		richTexts = append(richTexts, newNotionPlainText(part, nil))
+	}
+	if len(richTexts) == 0 {
+		richTexts = append(richTexts, newNotionPlainText("", nil))
+	}
+	return notionBlock{
+		Object: "block",
+		Type:   "code",
+		Code:   &notionCode{RichText: richTexts, Language: notionCodeLanguage(lang)},
+	}
+}
+
+func newNotionHeading2(content string) notionBlock {
+	return notionBlock{
+		Object: "block",
+		Type:   "heading_2",
+		Heading2: &notionHeading{
+			RichText: []notionRichText{newNotionPlainText(content, nil)},
+		},
+	}
+}
+
+func newNotionHeading3(content string) notionBlock {
+	return notionBlock{
+		Object: "block",
+		Type:   "heading_3",
+		Heading3: &notionHeading{
+			RichText: []notionRichText{newNotionPlainText(content, nil)},
+		},
+	}
+}
+
+func newNotionTableOfContents() notionBlock {
+	return notionBlock{
+		Object:          "block",
+		Type:            "table_of_contents",
+		TableOfContents: &struct{}{},
+	}
+}
+
+func newNotionDivider() notionBlock {
+	return notionBlock{
+		Object:  "block",
+		Type:    "divider",
+		Divider: &struct{}{},
+	}
+}
+
+// CreateConversation 为对话创建 Notion 页面, 时间按 timezone 输出。
+func (c *Client) CreateConversation(ctx context.Context, conv export.Conversation, timezone string) (targets.Object, error) {
+	page, subs, err := c.createConversationPage(ctx, conv, export.ResolveLocation(timezone))
+	if err != nil {
+		return targets.Object{}, err
+	}
+	return targets.Object{ID: page.ID, URL: page.URL, Substitutions: subs}, nil
+}
+
+// chunkText 按 rich_text 长度上限与配置的方式切分文本。
+func (c *Client) chunkText(text string) []string {
+	return capabilities.ChunkTextBy(text, c.chunkMode)
+}
+
+// Capabilities 返回 Notion 的写入限制。
+func (c *Client) Capabilities() targets.Capabilities {
+	return capabilities
+}
+
+// notionPageURL 在响应缺少 url 字段时根据页面 ID 拼出可访问链接。
+func notionPageURL(pageID string) string {
+	compact := strings.ReplaceAll(strings.TrimSpace(pageID), "-", "")
+	if compact == "" {
+		return ""
+	}
+	return "https://www.notion.so/" + compact
+}
+
+var notionCodeLanguages = map[string]string{
+	"js":         "javascript",
+	"jsx":        "javascript",
+	"ts":         "typescript",
+	"tsx":        "typescript",
+	"py":         "python",
+	"python3":    "python",
+	"golang":     "go",
+	"sh":         "shell",
+	"zsh":        "shell",
+	"console":    "shell",
+	"cpp":        "c++",
+	"cc":         "c++",
+	"csharp":     "c#",
+	"cs":         "c#",
+	"fsharp":     "f#",
+	"objc":       "objective-c",
+	"yml":        "yaml",
+	"dockerfile": "docker",
+	"md":         "markdown",
+	"tex":        "latex",
+	"rb":         "ruby",
+	"rs":         "rust",
+	"kt":         "kotlin",
+	"ps1":        "powershell",
+	"proto":      "protobuf",
+	"mmd":        "mermaid",
+	"text":       "plain text",
+	"txt":        "plain text",
+	"plaintext":  "plain text",
+}
+
+var notionSupportedLanguages = map[string]bool{
+	"abap": true, "arduino": true, "bash": true, "basic": true, "c": true, "clojure": true,
+	"coffeescript": true, "c++": true, "c#": true, "css": true, "dart": true, "diff": true,
+	"docker": true, "elixir": true, "elm": true, "erlang": true, "flow": true, "fortran": true,
+	"f#": true, "gherkin": true, "glsl": true, "go": true, "graphql": true, "groovy": true,
+	"haskell": true, "html": true, "java": true, "javascript": true, "json": true, "julia": true,
+	"kotlin": true, "latex": true, "less": true, "lisp": true, "livescript": true, "lua": true,
+	"makefile": true, "markdown": true, "markup": true, "matlab": true, "mermaid": true, "nix": true,
+	"objective-c": true, "ocaml": true, "pascal": true, "perl": true, "php": true, "plain text": true,
+	"powershell": true, "prolog": true, "protobuf": true, "python": true, "r": true, "reason": true,
+	"ruby": true, "rust": true, "sass": true, "scala": true, "scheme": true, "scss": true,
+	"shell": true, "sql": true, "swift": true, "typescript": true, "vb.net": true, "verilog": true,
+	"vhdl": true, "visual basic": true, "webassembly": true, "xml": true, "yaml": true,
+}
+
+// notionCodeLanguage 把代码块语言映射为 Notion 支持的语言名, 未知语言 (含 plantuml) 使用 plain text。
+func notionCodeLanguage(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if mapped, ok := notionCodeLanguages[lang]; ok {
+		lang = mapped
+	}
+	if notionSupportedLanguages[lang] {
+		return lang
+	}
+	return "plain text"
+}
+
+// notionEquationLimit 是 Notion 公式表达式的长度上限, 超出时保留原文。
+const notionEquationLimit = 1000
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,311 @@
+package notion
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+// fakeNotion 模拟 Notion API, 按 "方法 路径" 记录收到的请求。
+type fakeNotion struct {
+	mu       sync.Mutex
+	requests []string
+	version  string
+	pages    []notionPageRequest
+	appended [][]notionBlock
+	moves    []notionParent
+	uploads  []string
+
+	// createErrors 中的错误响应依次用于拒绝创建页面 (400), 用完后正常创建;
+	// dropCreate 为 true 时创建页面后直接断开连接, 模拟结果不确定的请求。
+	createErrors []string
+	dropCreate   bool
+	// databases 与 children 为按 ID 读取数据库与子区块的响应, query 为查询数据库或数据源的响应。
+	databases map[string]string
+	children  map[string]string
+	query     string
+	// moveStatus 非零时以该状态码拒绝移动页面; uploadStatus 同理用于创建文件上传。
+	moveStatus   int
+	uploadStatus int
+}
+
+func (f *fakeNotion) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path := r.URL.Path
+	f.requests = append(f.requests, r.Method+" "+path)
+	f.version = r.Header.Get("Notion-Version")
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		io.WriteString(w, `{"code":"unauthorized","message":"API token is invalid."}`)
+		return
+	}
+	id := strings.Split(strings.TrimPrefix(path, "/v1/"), "/")
+	switch {
+	case r.Method == http.MethodPost && path == "/v1/pages":
+		if len(f.createErrors) > 0 {
+			body := f.createErrors[0]
+			f.createErrors = f.createErrors[1:]
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, body)
+			return
+		}
+		var req notionPageRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		f.pages = append(f.pages, req)
+		if f.dropCreate {
+			if conn, _, err := http.NewResponseController(w).Hijack(); err == nil {
+				conn.Close()
+			}
+			return
+		}
+		fmt.Fprintf(w, `{"id":"page-%d"}`, len(f.pages))
+	case r.Method == http.MethodPatch && strings.HasSuffix(path, "/children"):
+		var req notionAppendRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		f.appended = append(f.appended, req.Children)
+		io.WriteString(w, `{"results":[]}`)
+	case r.Method == http.MethodGet && strings.HasSuffix(path, "/children"):
+		io.WriteString(w, firstNonEmpty(f.children[id[1]], `{"results":[]}`))
+	case r.Method == http.MethodGet && id[0] == "databases":
+		body, ok := f.databases[id[1]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"code":"object_not_found","message":"Could not find database."}`)
+			return
+		}
+		io.WriteString(w, body)
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/query"):
+		io.WriteString(w, firstNonEmpty(f.query, `{"results":[]}`))
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/move"):
+		if f.moveStatus != 0 {
+			w.WriteHeader(f.moveStatus)
+			io.WriteString(w, `{"code":"validation_error","message":"body.parent.database_id should be not present"}`)
+			return
+		}
+		var req struct{ Parent notionParent }
+		json.NewDecoder(r.Body).Decode(&req)
+		f.moves = append(f.moves, req.Parent)
+		fmt.Fprintf(w, `{"id":%q}`, id[1])
+	case r.Method == http.MethodPost && path == "/v1/file_uploads":
+		if f.uploadStatus != 0 {
+			w.WriteHeader(f.uploadStatus)
+			io.WriteString(w, `{"code":"validation_error","message":"file too large"}`)
+			return
+		}
+		var req struct {
+			Filename string `json:"filename"`
+			Mode     string `json:"mode"`
+			Parts    int    `json:"number_of_parts"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		f.uploads = append(f.uploads, fmt.Sprintf("create %s %s %d", req.Filename, req.Mode, req.Parts))
+		fmt.Fprintf(w, `{"id":"upload-%d"}`, len(f.uploads))
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/send"):
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		data, _ := io.ReadAll(file)
+		f.uploads = append(f.uploads, fmt.Sprintf("send %s %s %d", header.Filename, r.FormValue("part_number"), len(data)))
+		io.WriteString(w, `{}`)
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/complete"):
+		f.uploads = append(f.uploads, "complete "+id[1])
+		io.WriteString(w, `{}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// newFakeClient 启动 fakeNotion 并返回连接到它的 Client, 未指定父级时以页面 parent-1 为父级。
+func newFakeClient(t *testing.T, api *fakeNotion, cfg Config) *Client {
+	t.Helper()
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+	cfg.Token, cfg.BaseURL = "secret", server.URL+"/"
+	if cfg.ParentID == "" {
+		cfg.ParentID = "parent-1"
+	}
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestNew(t *testing.T) {
+	valid := Config{Token: "secret", ParentID: "parent-1"}
+	with := func(change func(*Config)) Config {
+		cfg := valid
+		change(&cfg)
+		return cfg
+	}
+	tests := []struct {
+		name        string
+		cfg         Config
+		wantErr     bool
+		wantVersion string
+		wantTitle   string
+		wantDraft   string
+	}{
+		{name: "页面父级", cfg: valid, wantTitle: "title"},
+		{name: "缺少 API Key", cfg: with(func(c *Config) { c.Token = " " }), wantErr: true},
+		{name: "缺少父级", cfg: with(func(c *Config) { c.ParentID = "" }), wantErr: true},
+		{name: "未知父级类型", cfg: with(func(c *Config) { c.ParentType = "workspace" }), wantErr: true},
+		{name: "相对地址", cfg: with(func(c *Config) { c.BaseURL = "api.notion.local/v1" }), wantErr: true},
+		{name: "版本格式无效", cfg: with(func(c *Config) { c.Version = "v1" }), wantErr: true},
+		{name: "数据库父级缺少标题属性", cfg: with(func(c *Config) { c.ParentType = "database" }), wantErr: true},
+		{
+			name:      "数据库父级",
+			cfg:       with(func(c *Config) { c.ParentType, c.TitleProperty, c.Version = "Database", " Name ", "2022-06-28" }),
+			wantTitle: "Name", wantVersion: "2022-06-28",
+		},
+		{
+			name:      "数据源父级自动使用新版本",
+			cfg:       with(func(c *Config) { c.ParentType, c.TitleProperty, c.Version = "data-source", "Name", "2022-06-28" }),
+			wantTitle: "Name", wantVersion: dataSourceVersion,
+		},
+		{
+			name:      "草稿父级",
+			cfg:       with(func(c *Config) { c.DraftParentID = "inbox" }),
+			wantTitle: "title", wantDraft: "inbox",
+		},
+		{
+			name:      "草稿父级与最终父级相同时忽略",
+			cfg:       with(func(c *Config) { c.DraftParentID = "parent-1" }),
+			wantTitle: "title",
+		},
+		{name: "草稿父级类型无效", cfg: with(func(c *Config) { c.DraftParentID, c.DraftParentType = "inbox", "folder" }), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if client.baseURL != defaultBaseURL || client.version != tt.wantVersion || client.titlePropertyKey != tt.wantTitle || client.draftParentID != tt.wantDraft {
+				t.Errorf("New() = baseURL %q version %q title %q draft %q", client.baseURL, client.version, client.titlePropertyKey, client.draftParentID)
+			}
+		})
+	}
+}
+
+func TestNotionPageURL(t *testing.T) {
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"", ""},
+		{"1a2b3c4d-0000-1111-2222-333344445555", "https://www.notion.so/1a2b3c4d000011112222333344445555"},
+	}
+	for _, tt := range tests {
+		if got := notionPageURL(tt.id); got != tt.want {
+			t.Errorf("notionPageURL(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestCreateConversation(t *testing.T) {
+	short := export.Conversation{ID: "c1", Title: "部署", Messages: []export.Message{
+		{Role: "user", Text: "如何配置 nginx?"},
+		{Role: "assistant", Text: "编辑 nginx.conf。"},
+	}}
+	long := export.Conversation{ID: "c2", Title: "长对话"}
+	for i := 0; i < 120; i++ {
+		long.Messages = append(long.Messages, export.Message{Role: "user", Text: fmt.Sprintf("第 %d 条", i+1)})
+	}
+	tests := []struct {
+		name         string
+		cfg          Config
+		conv         export.Conversation
+		createErrors []string
+		wantRequests []string
+		wantParent   notionParent
+		wantTitleKey string
+		wantStatus   int
+	}{
+		{
+			name:         "页面父级",
+			conv:         short,
+			wantRequests: []string{"POST /v1/pages"},
+			wantParent:   notionParent{Type: "page_id", PageID: "parent-1"},
+			wantTitleKey: "title",
+		},
+		{
+			name:         "超过单次上限的区块分批追加",
+			conv:         long,
+			wantRequests: []string{"POST /v1/pages", "PATCH /v1/blocks/page-1/children", "PATCH /v1/blocks/page-1/children"},
+			wantParent:   notionParent{Type: "page_id", PageID: "parent-1"},
+			wantTitleKey: "title",
+		},
+		{
+			name:         "旧版本的数据库父级",
+			cfg:          Config{ParentID: "db-1", ParentType: "database", TitleProperty: "Name", Version: "2022-06-28"},
+			conv:         short,
+			wantRequests: []string{"POST /v1/pages"},
+			wantParent:   notionParent{Type: "database_id", DatabaseID: "db-1"},
+			wantTitleKey: "Name",
+		},
+		{
+			name:         "创建被拒绝",
+			conv:         short,
+			createErrors: []string{`{"code":"restricted_resource","message":"Insufficient permissions for this endpoint."}`},
+			wantRequests: []string{"POST /v1/pages"},
+			wantStatus:   http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeNotion{createErrors: tt.createErrors}
+			c := newFakeClient(t, api, tt.cfg)
+			obj, err := c.CreateConversation(context.Background(), tt.conv, "UTC")
+			if strings.Join(api.requests, ",") != strings.Join(tt.wantRequests, ",") {
+				t.Errorf("requests = %v, want %v", api.requests, tt.wantRequests)
+			}
+			if tt.wantStatus != 0 {
+				var statusErr *targets.StatusError
+				if !errors.As(err, &statusErr) || statusErr.Status != tt.wantStatus || !strings.Contains(err.Error(), "Insufficient permissions") {
+					t.Fatalf("err = %v, want 状态码 %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if obj.ID != "page-1" || obj.URL != "https://www.notion.so/page1" {
+				t.Errorf("CreateConversation() = %+v", obj)
+			}
+			page := api.pages[0]
+			if page.Parent != tt.wantParent {
+				t.Errorf("parent = %+v, want %+v", page.Parent, tt.wantParent)
+			}
+			if title := page.Properties[tt.wantTitleKey].Title; len(title) != 1 || title[0].Text.Content != tt.conv.Title {
+				t.Errorf("properties = %+v", page.Properties)
+			}
+			total := len(page.Children)
+			for _, batch := range api.appended {
+				if len(batch) > capabilities.MaxBlocksPerRequest {
+					t.Errorf("追加 %d 个区块, 超过单次上限", len(batch))
+				}
+				total += len(batch)
+			}
+			if want := len(c.buildPageRequest(tt.conv, nil, nil).Children); total != want {
+				t.Errorf("共写入 %d 个区块, want %d", total, want)
+			}
+		})
+	}
+}
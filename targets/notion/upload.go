@@ -0,0 +1,208 @@
+package notion
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/logging"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+const (
+	// singlePartLimit 是 single_part 上传的文件大小上限, 更大的文件按 multi_part 分片上传。
+	singlePartLimit = 20 << 20
+	// uploadPartSize 是 multi_part 上传的分片大小, Notion 要求除最后一片外每片 5–20 MB。
+	uploadPartSize = 10 << 20
+)
+
+// notionUpload 是上传完成的文件, Block 为引用它的区块类型 (image、audio 或 file)。
+type notionUpload struct {
+	ID    string
+	Block string
+	Name  string
+}
+
+// uploadFiles 把对话中的图片 (以及按配置的语音与上传文件) 上传到 Notion, 返回文件指针或上传文件 ID
+// 到上传结果的映射。单个文件失败只记录日志, 页面中退化为文本说明。
+func (c *Client) uploadFiles(ctx context.Context, conv export.Conversation) map[string]notionUpload {
+	if c.FetchAsset == nil {
+		return nil
+	}
+	uploads := make(map[string]notionUpload)
+	upload := func(key, label, filename, block string) {
+		if _, ok := uploads[key]; ok {
+			return
+		}
+		data, err := c.FetchAsset(ctx, key)
+		if err != nil {
+			logging.Infof("下载%s失败: conversation=%s file=%s err=%v", label, conv.ID, key, err)
+			return
+		}
+		contentType := http.DetectContentType(data)
+		if block == "" {
+			// 上传的图片以图片区块显示, 其他类型写为文件区块。
+			block = "file"
+			if strings.HasPrefix(contentType, "image/") {
+				block = "image"
+			}
+		}
+		uploadID, err := c.uploadFile(ctx, filename, contentType, data)
+		if err != nil {
+			logging.Infof("上传%s到 Notion 失败: conversation=%s file=%s err=%v", label, conv.ID, key, err)
+			return
+		}
+		uploads[key] = notionUpload{ID: uploadID, Block: block, Name: filename}
+	}
+	for _, msg := range conv.Messages {
+		for _, asset := range msg.Assets {
+			switch {
+			case asset.Kind == export.AssetImage:
+				upload(asset.Pointer, "图片", path.Base(export.AssetArchivePath(asset)), "image")
+			case asset.Kind == export.AssetAudio && c.uploadAudio:
+				upload(asset.Pointer, "语音", path.Base(export.AssetArchivePath(asset)), "audio")
+			}
+		}
+	}
+	if c.uploadAttachments {
+		for _, att := range conv.Attachments {
+			if att.ID != "" {
+				upload(att.ID, "上传文件", path.Base(export.AttachmentArchivePath(att)), "")
+			}
+		}
+	}
+	return uploads
+}
+
+// uploadFile 通过 Notion 文件上传接口上传文件, 返回可在区块中引用的上传 ID。不超过 20 MB 时
+// 使用 single_part 一次发送, 否则按 multi_part 分片发送后调用 complete。
+func (c *Client) uploadFile(ctx context.Context, filename, contentType string, data []byte) (string, error) {
+	request := map[string]interface{}{"filename": filename, "content_type": contentType}
+	parts := 1
+	if len(data) > singlePartLimit {
+		parts = (len(data) + uploadPartSize - 1) / uploadPartSize
+		request["mode"] = "multi_part"
+		request["number_of_parts"] = parts
+	}
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("序列化 Notion 请求失败: %w", err)
+	}
+	var created notionFileRef
+	if err := c.doUploadRequest(ctx, c.baseURL+"/v1/file_uploads", "application/json", bytes.NewReader(payload), &created); err != nil {
+		return "", err
+	}
+	if created.ID == "" {
+		return "", fmt.Errorf("Notion 未返回文件上传 ID")
+	}
+
+	sendURL := fmt.Sprintf("%s/v1/file_uploads/%s/send", c.baseURL, url.PathEscape(created.ID))
+	for part := 1; part <= parts; part++ {
+		chunk := data
+		if parts > 1 {
+			start := (part - 1) * uploadPartSize
+			chunk = data[start:min(start+uploadPartSize, len(data))]
+		}
+		body, formType, err := uploadForm(filename, contentType, chunk, part, parts > 1)
+		if err != nil {
+			return "", err
+		}
+		if err := c.doUploadRequest(ctx, sendURL, formType, body, nil); err != nil {
+			return "", err
+		}
+	}
+	if parts > 1 {
+		completeURL := fmt.Sprintf("%s/v1/file_uploads/%s/complete", c.baseURL, url.PathEscape(created.ID))
+		if err := c.doUploadRequest(ctx, completeURL, "application/json", strings.NewReader("{}"), nil); err != nil {
+			return "", err
+		}
+	}
+	return created.ID, nil
+}
+
+// uploadForm 构造 send 请求的 multipart 表单; 分片上传时带上从 1 开始的 part_number。
+func uploadForm(filename, contentType string, data []byte, part int, multiPart bool) (io.Reader, string, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if multiPart {
+		if err := form.WriteField("part_number", strconv.Itoa(part)); err != nil {
+			return nil, "", fmt.Errorf("构造上传内容失败: %w", err)
+		}
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, strings.ReplaceAll(filename, `"`, "")))
+	header.Set("Content-Type", contentType)
+	writer, err := form.CreatePart(header)
+	if err != nil {
+		return nil, "", fmt.Errorf("构造上传内容失败: %w", err)
+	}
+	if _, err := writer.Write(data); err != nil {
+		return nil, "", fmt.Errorf("构造上传内容失败: %w", err)
+	}
+	if err := form.Close(); err != nil {
+		return nil, "", fmt.Errorf("构造上传内容失败: %w", err)
+	}
+	return &body, form.FormDataContentType(), nil
+}
+
+func (c *Client) doUploadRequest(ctx context.Context, target, contentType string, body io.Reader, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, body)
+	if err != nil {
+		return fmt.Errorf("构造 Notion 请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if c.version != "" {
+		req.Header.Set("Notion-Version", c.version)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("调用 Notion 接口失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		message := targets.ReadBody(resp.Body)
+		var apiErr notionErrorResponse
+		if err := json.Unmarshal([]byte(message), &apiErr); err == nil && apiErr.Message != "" {
+			message = apiErr.Message
+		}
+		return &targets.StatusError{Action: "上传 Notion 文件", Status: resp.StatusCode, Message: strings.TrimSpace(message)}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("解析 Notion 响应失败: %w", err)
+	}
+	return nil
+}
+
+// newFileBlock 创建引用上传文件的区块, caption 为说明文字。
+func (c *Client) newFileBlock(upload notionUpload, caption string) notionBlock {
+	file := &notionFile{Type: "file_upload", FileUpload: &notionFileRef{ID: upload.ID}}
+	for _, part := range c.chunkText(caption) {
+		file.Caption = append(file.Caption, newNotionPlainText(part, nil))
+	}
+	block := notionBlock{Object: "block", Type: upload.Block}
+	switch upload.Block {
+	case "audio":
+		block.Audio = file
+	case "file":
+		file.Name = upload.Name
+		block.File = file
+	default:
+		block.Type = "image"
+		block.Image = file
+	}
+	return block
+}
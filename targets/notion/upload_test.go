@@ -0,0 +1,175 @@
+package notion
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+func TestUploadFile(t *testing.T) {
+	tests := []struct {
+		name         string
+		size         int
+		uploadStatus int
+		wantUploads  []string
+	}{
+		{
+			name:        "single_part",
+			size:        1024,
+			wantUploads: []string{"create a.png  0", "send a.png  1024"},
+		},
+		{
+			name: "超过 20 MB 时分片上传",
+			size: singlePartLimit + uploadPartSize/2 + 1,
+			wantUploads: []string{
+				"create a.png multi_part 3",
+				fmt.Sprintf("send a.png 1 %d", uploadPartSize),
+				fmt.Sprintf("send a.png 2 %d", uploadPartSize),
+				fmt.Sprintf("send a.png 3 %d", singlePartLimit+uploadPartSize/2+1-2*uploadPartSize),
+				"complete upload-1",
+			},
+		},
+		{name: "创建上传被拒绝", size: 1024, uploadStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeNotion{uploadStatus: tt.uploadStatus}
+			c := newFakeClient(t, api, Config{})
+			data := append(append([]byte(nil), pngHeader...), bytes.Repeat([]byte{0}, tt.size-len(pngHeader))...)
+			id, err := c.uploadFile(context.Background(), "a.png", "image/png", data)
+			if tt.uploadStatus != 0 {
+				var statusErr *targets.StatusError
+				if !errors.As(err, &statusErr) || statusErr.Status != tt.uploadStatus || statusErr.Message != "file too large" {
+					t.Fatalf("err = %v, want 状态码 %d", err, tt.uploadStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id != "upload-1" || strings.Join(api.uploads, ",") != strings.Join(tt.wantUploads, ",") {
+				t.Errorf("uploadFile() = %q, uploads = %q, want %q", id, api.uploads, tt.wantUploads)
+			}
+		})
+	}
+}
+
+func TestUploadFiles(t *testing.T) {
+	conv := export.Conversation{
+		ID: "c1",
+		Messages: []export.Message{
+			{Role: "assistant", Assets: []export.Asset{
+				{Kind: export.AssetImage, Pointer: "file-service://file-img", Format: "png"},
+				{Kind: export.AssetAudio, Pointer: "file-service://file-voice", Format: "wav"},
+				{Kind: export.AssetImage, Pointer: "file-service://file-missing"},
+			}},
+			{Role: "user", Assets: []export.Asset{{Kind: export.AssetImage, Pointer: "file-service://file-img", Format: "png"}}},
+		},
+		Attachments: []export.Attachment{{ID: "file-doc", Name: "report.pdf"}, {ID: "file-pic", Name: "photo.png"}, {Name: "no-id.txt"}},
+	}
+	files := map[string][]byte{
+		"file-service://file-img":   pngHeader,
+		"file-service://file-voice": []byte("RIFF\x00\x00\x00\x00WAVEfmt "),
+		"file-doc":                  []byte("%PDF-1.7"),
+		"file-pic":                  pngHeader,
+	}
+	tests := []struct {
+		name        string
+		cfg         Config
+		fetch       bool
+		want        map[string]string
+		wantUploads int
+	}{
+		{name: "没有下载函数", cfg: Config{UploadAudio: true, UploadAttachments: true}},
+		{
+			name:        "默认只上传图片",
+			fetch:       true,
+			want:        map[string]string{"file-service://file-img": "image"},
+			wantUploads: 1,
+		},
+		{
+			name:  "上传语音与上传文件",
+			cfg:   Config{UploadAudio: true, UploadAttachments: true},
+			fetch: true,
+			want: map[string]string{
+				"file-service://file-img":   "image",
+				"file-service://file-voice": "audio",
+				"file-doc":                  "file",
+				"file-pic":                  "image",
+			},
+			wantUploads: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeNotion{}
+			c := newFakeClient(t, api, tt.cfg)
+			var fetched []string
+			if tt.fetch {
+				c.FetchAsset = func(ctx context.Context, pointer string) ([]byte, error) {
+					fetched = append(fetched, pointer)
+					if data, ok := files[pointer]; ok {
+						return data, nil
+					}
+					return nil, errors.New("404 not found")
+				}
+			}
+			uploads := c.uploadFiles(context.Background(), conv)
+			got := make(map[string]string)
+			for key, upload := range uploads {
+				got[key] = upload.Block
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("uploadFiles() = %v, want %v", got, tt.want)
+			}
+			if sends := strings.Count(strings.Join(api.uploads, "\n"), "send "); sends != tt.wantUploads {
+				t.Errorf("上传 %d 个文件, want %d: %q", sends, tt.wantUploads, api.uploads)
+			}
+			seen := make(map[string]bool)
+			for _, pointer := range fetched {
+				if seen[pointer] {
+					t.Errorf("重复下载同一文件: %v", fetched)
+				}
+				seen[pointer] = true
+			}
+			if upload, ok := uploads["file-doc"]; ok && upload.Name != "file-doc-report.pdf" {
+				t.Errorf("上传文件名 = %q", upload.Name)
+			}
+		})
+	}
+}
+
+func TestNewFileBlock(t *testing.T) {
+	c := &Client{}
+	tests := []struct {
+		block    string
+		wantType string
+		wantName string
+	}{
+		{block: "image", wantType: "image"},
+		{block: "audio", wantType: "audio"},
+		{block: "file", wantType: "file", wantName: "report.pdf"},
+		{block: "", wantType: "image"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.wantType+"/"+tt.block, func(t *testing.T) {
+			block := c.newFileBlock(notionUpload{ID: "upload-1", Block: tt.block, Name: "report.pdf"}, "说明")
+			file := map[string]*notionFile{"image": block.Image, "audio": block.Audio, "file": block.File}[block.Type]
+			if block.Type != tt.wantType || file == nil {
+				t.Fatalf("newFileBlock() = %+v", block)
+			}
+			if file.Type != "file_upload" || file.FileUpload.ID != "upload-1" || file.Name != tt.wantName || blockPlainText(block) != "说明" {
+				t.Errorf("文件 = %+v", file)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package notion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Devoty/openai-backup/logging"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+// dataSourceVersion 是引入数据源 (data source) 的 Notion-Version。此后一个数据库可以包含多个数据源,
+// 在数据库中创建页面需要以 data_source_id 作为父级, database_id 父级只适用于旧版本。
+const dataSourceVersion = "2025-09-03"
+
+// 父级类型: page 为页面, database 为数据库 (新版本下自动解析出数据源), data_source 为数据源。
+const (
+	parentPage       = "page"
+	parentDatabase   = "database"
+	parentDataSource = "data_source"
+)
+
+// NormalizeParentType 规范化父级类型, 无法识别时返回空字符串。
+func NormalizeParentType(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case parentPage:
+		return parentPage
+	case parentDatabase:
+		return parentDatabase
+	case parentDataSource, "datasource", "data-source":
+		return parentDataSource
+	default:
+		return ""
+	}
+}
+
+// checkVersion 校验 Notion-Version 的格式 (YYYY-MM-DD), 空值表示不发送该请求头。
+func checkVersion(version string) error {
+	if version == "" {
+		return nil
+	}
+	if _, err := time.Parse("2006-01-02", version); err != nil {
+		return fmt.Errorf("Notion-Version 格式无效: %s (应为日期, 如 %s)", version, dataSourceVersion)
+	}
+	return nil
+}
+
+// usesDataSources 判断版本是否采用数据源模型; 版本为日期字符串, 可以直接按字典序比较。
+func usesDataSources(version string) bool {
+	return version != "" && version >= dataSourceVersion
+}
+
+type notionDatabaseResponse struct {
+	ID          string `json:"id"`
+	DataSources []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"data_sources"`
+}
+
+// pageParent 返回创建页面时的父级: 设置了草稿父级时为草稿父级, 否则为最终父级。
+func (c *Client) pageParent(ctx context.Context) (notionParent, error) {
+	if c.draftParentID != "" {
+		return c.resolveParent(ctx, c.draftParentType, c.draftParentID, &c.draftDataSourceID)
+	}
+	return c.resolveParent(ctx, c.parentType, c.parentID, &c.dataSourceID)
+}
+
+// finalParent 返回最终父级, 草稿页面审阅后移动到这里。
+func (c *Client) finalParent(ctx context.Context) (notionParent, error) {
+	return c.resolveParent(ctx, c.parentType, c.parentID, &c.dataSourceID)
+}
+
+// resolveParent 把父级类型与 ID 转换为请求中的父级。新版本下父级为数据库时读取数据库的数据源: 只有一个
+// 数据源时自动使用它, 有多个时提示改用 data_source 父级并列出可选的数据源。解析结果缓存在 cached 中。
+func (c *Client) resolveParent(ctx context.Context, parentType, parentID string, cached *string) (notionParent, error) {
+	switch parentType {
+	case parentDataSource:
+		return notionParent{Type: "data_source_id", DataSourceID: parentID}, nil
+	case parentDatabase:
+		if !usesDataSources(c.version) {
+			return notionParent{Type: "database_id", DatabaseID: parentID}, nil
+		}
+	default:
+		return notionParent{Type: "page_id", PageID: parentID}, nil
+	}
+
+	c.parentMu.Lock()
+	defer c.parentMu.Unlock()
+	if *cached != "" {
+		return notionParent{Type: "data_source_id", DataSourceID: *cached}, nil
+	}
+	var db notionDatabaseResponse
+	if err := c.getJSON(ctx, "读取 Notion 数据库", fmt.Sprintf("%s/v1/databases/%s", c.baseURL, url.PathEscape(parentID)), &db); err != nil {
+		return notionParent{}, err
+	}
+	switch len(db.DataSources) {
+	case 0:
+		return notionParent{}, fmt.Errorf("Notion 数据库 %s 没有数据源, 无法在其中创建页面", parentID)
+	case 1:
+		*cached = db.DataSources[0].ID
+		logging.Infof("Notion-Version %s 使用数据源模型, 数据库 %s 的页面将写入数据源 %s", c.version, parentID, *cached)
+		return notionParent{Type: "data_source_id", DataSourceID: *cached}, nil
+	default:
+		names := make([]string, 0, len(db.DataSources))
+		for _, source := range db.DataSources {
+			names = append(names, fmt.Sprintf("%s (%s)", firstNonEmpty(source.Name, "未命名"), source.ID))
+		}
+		return notionParent{}, fmt.Errorf("Notion 数据库 %s 包含多个数据源, 请把父级类型设为 data_source 并填写其中一个 ID: %s", parentID, strings.Join(names, ", "))
+	}
+}
+
+// getJSON 发送 GET 请求并解析 JSON 响应。
+func (c *Client) getJSON(ctx context.Context, action, target string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return fmt.Errorf("构造 Notion 请求失败: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if c.version != "" {
+		req.Header.Set("Notion-Version", c.version)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("调用 Notion 接口失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return newAPIError(action, resp.StatusCode, targets.ReadBody(resp.Body))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("解析 Notion 响应失败: %w", err)
+	}
+	return nil
+}
+
+// parentHint 为父级相关的校验错误补充说明, 常见于 Notion-Version 与父级类型不匹配。
+func (c *Client) parentHint(err error) error {
+	e, ok := err.(*apiError)
+	if !ok || e.Code != errCodeValidation {
+		return err
+	}
+	lower := strings.ToLower(e.Message)
+	if !strings.Contains(lower, "parent") && !strings.Contains(lower, "data_source") && !strings.Contains(lower, "database") {
+		return err
+	}
+	version := firstNonEmpty(c.version, "(未设置)")
+	parentType := c.parentType
+	if c.draftParentID != "" {
+		parentType = c.draftParentType + " (草稿)"
+	}
+	return fmt.Errorf("%w (当前 Notion-Version=%s, 父级类型=%s; %s 及之后的版本在数据库中创建页面需要数据源, 可把父级类型设为 database 自动解析或设为 data_source)", err, version, parentType, dataSourceVersion)
+}
@@ -0,0 +1,139 @@
+package notion
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Devoty/openai-backup/targets"
+)
+
+func TestNormalizeParentType(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{" Page ", parentPage},
+		{"database", parentDatabase},
+		{"datasource", parentDataSource},
+		{"data-source", parentDataSource},
+		{"", ""},
+		{"workspace", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeParentType(tt.value); got != tt.want {
+			t.Errorf("NormalizeParentType(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	tests := []struct {
+		version         string
+		wantErr         bool
+		wantDataSources bool
+	}{
+		{version: ""},
+		{version: "2022-06-28"},
+		{version: dataSourceVersion, wantDataSources: true},
+		{version: "2026-01-15", wantDataSources: true},
+		{version: "latest", wantErr: true},
+		{version: "2025-13-01", wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := checkVersion(tt.version); (err != nil) != tt.wantErr {
+			t.Errorf("checkVersion(%q) err = %v, wantErr %v", tt.version, err, tt.wantErr)
+		}
+		if tt.wantErr {
+			continue
+		}
+		if got := usesDataSources(tt.version); got != tt.wantDataSources {
+			t.Errorf("usesDataSources(%q) = %v, want %v", tt.version, got, tt.wantDataSources)
+		}
+	}
+}
+
+func TestResolveParent(t *testing.T) {
+	api := &fakeNotion{databases: map[string]string{
+		"db-one":   `{"id":"db-one","data_sources":[{"id":"ds-1","name":"对话"}]}`,
+		"db-many":  `{"id":"db-many","data_sources":[{"id":"ds-1","name":"对话"},{"id":"ds-2","name":""}]}`,
+		"db-empty": `{"id":"db-empty","data_sources":[]}`,
+	}}
+	c := newFakeClient(t, api, Config{Version: dataSourceVersion})
+	tests := []struct {
+		name         string
+		parentType   string
+		parentID     string
+		version      string
+		want         notionParent
+		wantErr      string
+		wantRequests int
+	}{
+		{name: "页面", parentType: parentPage, parentID: "p1", version: dataSourceVersion, want: notionParent{Type: "page_id", PageID: "p1"}},
+		{name: "数据源", parentType: parentDataSource, parentID: "ds-9", version: dataSourceVersion, want: notionParent{Type: "data_source_id", DataSourceID: "ds-9"}},
+		{name: "旧版本的数据库", parentType: parentDatabase, parentID: "db-one", version: "2022-06-28", want: notionParent{Type: "database_id", DatabaseID: "db-one"}},
+		{name: "只有一个数据源", parentType: parentDatabase, parentID: "db-one", version: dataSourceVersion, want: notionParent{Type: "data_source_id", DataSourceID: "ds-1"}, wantRequests: 1},
+		{name: "多个数据源", parentType: parentDatabase, parentID: "db-many", version: dataSourceVersion, wantErr: "对话 (ds-1), 未命名 (ds-2)", wantRequests: 1},
+		{name: "没有数据源", parentType: parentDatabase, parentID: "db-empty", version: dataSourceVersion, wantErr: "没有数据源", wantRequests: 1},
+		{name: "数据库不存在", parentType: parentDatabase, parentID: "db-missing", version: dataSourceVersion, wantErr: "Could not find database", wantRequests: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api.mu.Lock()
+			api.requests = nil
+			api.mu.Unlock()
+			c.version = tt.version
+			var cached string
+			for i := 0; i < 2; i++ {
+				got, err := c.resolveParent(context.Background(), tt.parentType, tt.parentID, &cached)
+				if tt.wantErr != "" {
+					if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+						t.Fatalf("err = %v, want %q", err, tt.wantErr)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != tt.want {
+					t.Errorf("resolveParent() = %+v, want %+v", got, tt.want)
+				}
+			}
+			// 解析出的数据源会缓存, 出错时下次重新读取。
+			want := tt.wantRequests
+			if tt.wantErr != "" {
+				want *= 2
+			}
+			if len(api.requests) != want {
+				t.Errorf("requests = %v, want %d 次", api.requests, want)
+			}
+		})
+	}
+}
+
+func TestParentHint(t *testing.T) {
+	c := &Client{version: "2022-06-28", parentType: parentDatabase}
+	tests := []struct {
+		name     string
+		err      error
+		wantHint bool
+	}{
+		{name: "父级校验错误", err: newAPIError("创建 Notion 页面", 400, `{"code":"validation_error","message":"body.parent.data_source_id should be defined"}`), wantHint: true},
+		{name: "其他校验错误", err: newAPIError("创建 Notion 页面", 400, `{"code":"validation_error","message":"body.children[3].code.language is invalid"}`)},
+		{name: "非校验错误", err: newAPIError("创建 Notion 页面", 404, `{"code":"object_not_found","message":"Could not find database"}`)},
+		{name: "网络错误", err: errors.New("connection reset")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := c.parentHint(tt.err)
+			if hinted := strings.Contains(got.Error(), "Notion-Version=2022-06-28, 父级类型=database"); hinted != tt.wantHint {
+				t.Errorf("parentHint() = %v, wantHint %v", got, tt.wantHint)
+			}
+			var statusErr *targets.StatusError
+			if errors.As(tt.err, &statusErr) && !errors.As(got, &statusErr) {
+				t.Errorf("parentHint() 丢失了状态码: %v", got)
+			}
+		})
+	}
+}
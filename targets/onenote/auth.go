@@ -0,0 +1,95 @@
+package onenote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Devoty/openai-backup/targets"
+)
+
+const (
+	defaultAuthURL = "https://login.microsoftonline.com"
+	defaultTenant  = "common"
+
+	// Scope 为刷新 access token 时申请的权限: 读写用户的 OneNote 笔记本。
+	Scope = "https://graph.microsoft.com/Notes.ReadWrite offline_access"
+)
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// tokenSource 提供调用 Graph 的 access token: 配置了 refresh token 时向 Microsoft 身份平台换取并缓存,
+// 过期前一分钟自动刷新; 否则直接使用配置的 access token。
+type tokenSource struct {
+	httpClient   *http.Client
+	authURL      string
+	tenant       string
+	clientID     string
+	clientSecret string
+	static       string
+
+	mu           sync.Mutex
+	refreshToken string
+	access       string
+	expires      time.Time
+}
+
+func (t *tokenSource) token(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.refreshToken == "" {
+		return t.static, nil
+	}
+	if t.access != "" && time.Until(t.expires) > time.Minute {
+		return t.access, nil
+	}
+
+	form := url.Values{}
+	form.Set("client_id", t.clientID)
+	if t.clientSecret != "" {
+		form.Set("client_secret", t.clientSecret)
+	}
+	form.Set("refresh_token", t.refreshToken)
+	form.Set("grant_type", "refresh_token")
+	form.Set("scope", Scope)
+
+	endpoint := fmt.Sprintf("%s/%s/oauth2/v2.0/token", t.authURL, url.PathEscape(t.tenant))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("构造 Microsoft 授权请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("刷新 OneNote access token 失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body := targets.ReadBody(resp.Body)
+	var token tokenResponse
+	_ = json.Unmarshal([]byte(body), &token)
+	if resp.StatusCode != http.StatusOK {
+		return "", &targets.StatusError{Action: "刷新 OneNote access token", Status: resp.StatusCode, Message: firstNonEmpty(token.ErrorDescription, token.Error, strings.TrimSpace(body))}
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("刷新 OneNote access token 失败: 响应缺少 access_token")
+	}
+	t.access = token.AccessToken
+	t.expires = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	// Microsoft 会轮换 refresh token, 新值只保存在内存中, 配置中的旧值在过期前仍可使用。
+	if token.RefreshToken != "" {
+		t.refreshToken = token.RefreshToken
+	}
+	return t.access, nil
+}
@@ -0,0 +1,111 @@
+package onenote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Devoty/openai-backup/targets"
+)
+
+func TestTokenSource(t *testing.T) {
+	tests := []struct {
+		name         string
+		refreshToken string
+		responses    []string
+		status       int
+		wantTokens   []string
+		wantRequests int
+		wantRefresh  string
+		wantStatus   int
+		wantErr      string
+	}{
+		{name: "未配置 refresh token 时使用固定 token", wantTokens: []string{"static", "static"}},
+		{
+			name:         "换取后缓存到过期前",
+			refreshToken: "r1",
+			responses:    []string{`{"access_token":"a1","refresh_token":"r2","expires_in":3600}`},
+			wantTokens:   []string{"a1", "a1"},
+			wantRequests: 1,
+			wantRefresh:  "r2",
+		},
+		{
+			name:         "不足一分钟过期时重新刷新",
+			refreshToken: "r1",
+			responses:    []string{`{"access_token":"a1","expires_in":30}`, `{"access_token":"a2","expires_in":30}`},
+			wantTokens:   []string{"a1", "a2"},
+			wantRequests: 2,
+			wantRefresh:  "r1",
+		},
+		{
+			name:         "refresh token 失效",
+			refreshToken: "r1",
+			responses:    []string{`{"error":"invalid_grant","error_description":"AADSTS70000: refresh token 已过期"}`},
+			status:       http.StatusBadRequest,
+			wantRequests: 1,
+			wantStatus:   http.StatusBadRequest,
+			wantErr:      "AADSTS70000",
+		},
+		{
+			name:         "响应缺少 access_token",
+			refreshToken: "r1",
+			responses:    []string{`{"expires_in":3600}`},
+			wantRequests: 1,
+			wantErr:      "缺少 access_token",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int
+			var form []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if r.URL.Path != "/contoso/oauth2/v2.0/token" {
+					http.NotFound(w, r)
+					return
+				}
+				r.ParseForm()
+				form = append(form, fmt.Sprintf("%s|%s|%s|%s", r.PostForm.Get("grant_type"), r.PostForm.Get("client_id"), r.PostForm.Get("refresh_token"), r.PostForm.Get("scope")))
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				io.WriteString(w, tt.responses[min(requests, len(tt.responses))-1])
+			}))
+			defer server.Close()
+
+			client, err := New(Config{Tenant: "contoso", ClientID: "app", RefreshToken: tt.refreshToken, Token: "static", AuthURL: server.URL})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for i := 0; i < 2; i++ {
+				token, err := client.tokens.token(context.Background())
+				if err != nil {
+					var statusErr *targets.StatusError
+					if tt.wantErr == "" || !strings.Contains(err.Error(), tt.wantErr) || (tt.wantStatus != 0 && (!errors.As(err, &statusErr) || statusErr.Status != tt.wantStatus)) {
+						t.Fatalf("err = %v, want 包含 %q", err, tt.wantErr)
+					}
+					break
+				}
+				got = append(got, token)
+			}
+			if tt.wantErr == "" && strings.Join(got, ",") != strings.Join(tt.wantTokens, ",") {
+				t.Errorf("token = %v, want %v", got, tt.wantTokens)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("请求次数 = %d, want %d", requests, tt.wantRequests)
+			}
+			if len(form) > 0 && form[0] != "refresh_token|app|r1|"+Scope {
+				t.Errorf("表单 = %q", form[0])
+			}
+			if tt.wantRefresh != "" && client.tokens.refreshToken != tt.wantRefresh {
+				t.Errorf("refreshToken = %q, want %q", client.tokens.refreshToken, tt.wantRefresh)
+			}
+		})
+	}
+}
@@ -0,0 +1,414 @@
+// Package onenote 通过 Microsoft Graph 的 OneNote 页面接口为每个对话创建一个页面。
+// 授权使用 refresh token (由 Microsoft 身份平台换取 access token) 或直接填写的 access token。
+package onenote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/httpc"
+	"github.com/Devoty/openai-backup/logging"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+const defaultBaseURL = "https://graph.microsoft.com/v1.0"
+
+// defaultSection 是未配置分区时使用的分区名。
+const defaultSection = "ChatGPT"
+
+// maxImageBytes 是单个页面随请求上传的图片总大小, 超出后其余图片只保留文件指针。
+// Graph 限制整个请求约 70 MB, 这里留出正文与编码的余量。
+const maxImageBytes = 25 << 20
+
+// Config 是创建 Client 所需的 OneNote 参数。
+type Config struct {
+	// Tenant 为 Microsoft Entra 租户 (ID 或域名), 为空时使用 common。
+	Tenant string
+	// ClientID 与 ClientSecret 为注册应用的凭据, 用于刷新 access token; 公共客户端不需要 ClientSecret。
+	ClientID     string
+	ClientSecret string
+	// RefreshToken 非空时用于换取 access token, 否则直接使用 Token。
+	RefreshToken string
+	Token        string
+	// Notebook 为笔记本名称; Section 为分区名称或 ID, 为空时使用 "ChatGPT"。
+	// 指定了笔记本时分区不存在会自动创建。
+	Notebook string
+	Section  string
+	// BaseURL 为 Graph 接口地址, 为空时使用 https://graph.microsoft.com/v1.0。
+	BaseURL string
+	// AuthURL 为 Microsoft 身份平台地址, 为空时使用 https://login.microsoftonline.com。
+	AuthURL string
+	HTML    export.HTMLOptions
+}
+
+// Client 通过 Graph 接口在指定分区中为每个对话创建页面。
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	tokens     *tokenSource
+	// staticToken 为 true 时使用配置的 access token, 过期后无法自动刷新。
+	staticToken bool
+	notebook    string
+	section     string
+	html        export.HTMLOptions
+	// sectionID 缓存解析出的分区 ID, 见 sectionTarget。
+	sectionMu sync.Mutex
+	sectionID string
+	// FetchAsset 下载 ChatGPT 文件内容 (文件指针或上传文件 ID), 用于把图片随页面一起上传;
+	// 为空时只保留文件指针。
+	FetchAsset func(ctx context.Context, pointer string) ([]byte, error)
+}
+
+type graphErrorResponse struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type graphObject struct {
+	ID             string `json:"id"`
+	DisplayName    string `json:"displayName"`
+	ParentNotebook *struct {
+		DisplayName string `json:"displayName"`
+	} `json:"parentNotebook,omitempty"`
+}
+
+type graphList struct {
+	Value []graphObject `json:"value"`
+}
+
+type pageResponse struct {
+	ID    string `json:"id"`
+	Links struct {
+		OneNoteWebURL struct {
+			Href string `json:"href"`
+		} `json:"oneNoteWebUrl"`
+	} `json:"links"`
+}
+
+// pageImage 是随页面以 multipart 上传的图片, 页面中以 name:<Name> 引用。
+type pageImage struct {
+	Name string
+	Data []byte
+}
+
+// New 校验配置并创建 Client。
+func New(cfg Config) (*Client, error) {
+	refreshToken := strings.TrimSpace(cfg.RefreshToken)
+	token := strings.TrimSpace(cfg.Token)
+	clientID := strings.TrimSpace(cfg.ClientID)
+	switch {
+	case refreshToken == "" && token == "":
+		return nil, fmt.Errorf("缺少 OneNote 授权: 请在配置页填写 onenote_refresh_token 与 onenote_client_id, 或填写 onenote_token")
+	case refreshToken != "" && clientID == "":
+		return nil, fmt.Errorf("缺少 OneNote 应用 ID: 使用 refresh token 时请填写 onenote_client_id")
+	}
+	baseURL := strings.TrimRight(strings.TrimSpace(cfg.BaseURL), "/")
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	if parsed, err := url.Parse(baseURL); err != nil || !parsed.IsAbs() {
+		return nil, fmt.Errorf("OneNote 接口地址无效: %s", cfg.BaseURL)
+	}
+	authURL := strings.TrimRight(strings.TrimSpace(cfg.AuthURL), "/")
+	if authURL == "" {
+		authURL = defaultAuthURL
+	}
+	tenant := strings.TrimSpace(cfg.Tenant)
+	if tenant == "" {
+		tenant = defaultTenant
+	}
+	section := strings.TrimSpace(cfg.Section)
+	if section == "" {
+		section = defaultSection
+	}
+	// OneNote 页面不执行脚本, 图表保留源码。
+	opts := cfg.HTML
+	opts.RenderDiagrams = false
+	httpClient := httpc.Client()
+	return &Client{
+		httpClient: httpClient,
+		baseURL:    baseURL,
+		tokens: &tokenSource{
+			httpClient:   httpClient,
+			authURL:      authURL,
+			tenant:       tenant,
+			clientID:     clientID,
+			clientSecret: strings.TrimSpace(cfg.ClientSecret),
+			static:       token,
+			refreshToken: refreshToken,
+		},
+		staticToken: refreshToken == "",
+		notebook:    strings.TrimSpace(cfg.Notebook),
+		section:     section,
+		html:        opts,
+	}, nil
+}
+
+// CreateConversation 在分区中创建页面, 时间按 timezone 输出, 返回页面 ID 与网页版链接。
+func (c *Client) CreateConversation(ctx context.Context, conv export.Conversation, timezone string) (targets.Object, error) {
+	sectionID, err := c.sectionTarget(ctx)
+	if err != nil {
+		return targets.Object{}, err
+	}
+	images := c.bundleImages(ctx, &conv)
+	page := renderPage(conv, timezone, c.html)
+
+	var (
+		body        io.Reader
+		contentType string
+	)
+	if len(images) == 0 {
+		body, contentType = strings.NewReader(page), "text/html; charset=utf-8"
+	} else {
+		body, contentType, err = multipartPage(page, images)
+		if err != nil {
+			return targets.Object{}, err
+		}
+	}
+
+	target := fmt.Sprintf("%s/me/onenote/sections/%s/pages", c.baseURL, url.PathEscape(sectionID))
+	var result pageResponse
+	if err := c.do(ctx, http.MethodPost, "创建 OneNote 页面", target, contentType, body, &result); err != nil {
+		return targets.Object{}, err
+	}
+	if result.ID == "" {
+		return targets.Object{}, fmt.Errorf("OneNote 未返回页面 ID")
+	}
+	return targets.Object{ID: result.ID, URL: result.Links.OneNoteWebURL.Href}, nil
+}
+
+// renderPage 输出 OneNote 页面的 XHTML: title 为页面标题, created 为对话的创建时间。
+func renderPage(conv export.Conversation, timezone string, opts export.HTMLOptions) string {
+	title := strings.TrimSpace(conv.Title)
+	if title == "" {
+		title = fmt.Sprintf("对话 %s", conv.ID)
+	}
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n")
+	b.WriteString(fmt.Sprintf("<title>%s</title>\n", html.EscapeString(title)))
+	if conv.CreateTime > 0 {
+		created := time.Unix(int64(conv.CreateTime), 0).UTC().Format(time.RFC3339)
+		b.WriteString(fmt.Sprintf("<meta name=\"created\" content=\"%s\"/>\n", created))
+	}
+	b.WriteString("</head>\n<body>\n")
+	b.WriteString(export.RenderXHTMLFragment(conv, timezone, opts))
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
+
+// bundleImages 下载对话中的图片, 改为引用随请求上传的数据块。单个图片下载失败或超出 maxImageBytes
+// 时页面中保留原始指针。
+func (c *Client) bundleImages(ctx context.Context, conv *export.Conversation) []pageImage {
+	if c.FetchAsset == nil {
+		return nil
+	}
+	var (
+		images []pageImage
+		total  int
+		names  = make(map[string]string)
+	)
+	// 复制消息列表, 避免修改调用方的数据。
+	conv.Messages = append([]export.Message(nil), conv.Messages...)
+	for i := range conv.Messages {
+		msg := &conv.Messages[i]
+		if len(msg.Assets) == 0 {
+			continue
+		}
+		msg.Assets = append([]export.Asset(nil), msg.Assets...)
+		for j := range msg.Assets {
+			asset := &msg.Assets[j]
+			if asset.Kind != export.AssetImage || asset.Pointer == "" {
+				continue
+			}
+			if name, ok := names[asset.Pointer]; ok {
+				asset.Path = "name:" + name
+				continue
+			}
+			data, err := c.FetchAsset(ctx, asset.Pointer)
+			if err != nil {
+				logging.Infof("下载图片失败: conversation=%s pointer=%s err=%v", conv.ID, asset.Pointer, err)
+				continue
+			}
+			if total+len(data) > maxImageBytes {
+				logging.Infof("OneNote 页面图片超过 %d MB, 其余图片只保留指针: conversation=%s", maxImageBytes>>20, conv.ID)
+				return images
+			}
+			total += len(data)
+			name := fmt.Sprintf("image%d", len(images)+1)
+			images = append(images, pageImage{Name: name, Data: data})
+			names[asset.Pointer] = name
+			asset.Path = "name:" + name
+		}
+	}
+	return images
+}
+
+// multipartPage 按 Graph 的要求组装 multipart 请求: Presentation 部分为页面 HTML, 其余部分为图片。
+func multipartPage(page string, images []pageImage) (io.Reader, string, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="Presentation"`)
+	header.Set("Content-Type", "text/html; charset=utf-8")
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return nil, "", fmt.Errorf("构造 OneNote 请求失败: %w", err)
+	}
+	if _, err := io.WriteString(part, page); err != nil {
+		return nil, "", fmt.Errorf("构造 OneNote 请求失败: %w", err)
+	}
+	for _, image := range images {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q`, image.Name))
+		header.Set("Content-Type", http.DetectContentType(image.Data))
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", fmt.Errorf("构造 OneNote 请求失败: %w", err)
+		}
+		if _, err := part.Write(image.Data); err != nil {
+			return nil, "", fmt.Errorf("构造 OneNote 请求失败: %w", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", fmt.Errorf("构造 OneNote 请求失败: %w", err)
+	}
+	return &buf, writer.FormDataContentType(), nil
+}
+
+// sectionTarget 返回页面所在的分区 ID。分区配置为 ID (含 "!") 时直接使用; 否则按名称查找:
+// 指定了笔记本时在其中查找, 不存在则创建; 未指定时在全部笔记本中查找, 同名分区有多个时提示指定笔记本。
+// 解析结果缓存到修改配置为止。
+func (c *Client) sectionTarget(ctx context.Context) (string, error) {
+	if strings.Contains(c.section, "!") {
+		return c.section, nil
+	}
+	c.sectionMu.Lock()
+	defer c.sectionMu.Unlock()
+	if c.sectionID != "" {
+		return c.sectionID, nil
+	}
+
+	if c.notebook == "" {
+		var sections graphList
+		target := fmt.Sprintf("%s/me/onenote/sections?%s", c.baseURL, nameQuery(c.section, "parentNotebook($select=displayName)"))
+		if err := c.do(ctx, http.MethodGet, "查找 OneNote 分区", target, "", nil, &sections); err != nil {
+			return "", err
+		}
+		switch len(sections.Value) {
+		case 0:
+			return "", fmt.Errorf("OneNote 中没有名为 %s 的分区: 请先创建分区, 或填写 onenote_notebook 由程序在该笔记本中创建", c.section)
+		case 1:
+			c.sectionID = sections.Value[0].ID
+			return c.sectionID, nil
+		default:
+			names := make([]string, 0, len(sections.Value))
+			for _, section := range sections.Value {
+				if section.ParentNotebook != nil {
+					names = append(names, section.ParentNotebook.DisplayName)
+				}
+			}
+			return "", fmt.Errorf("多个笔记本中都有名为 %s 的分区, 请填写 onenote_notebook 指定其一: %s", c.section, strings.Join(names, ", "))
+		}
+	}
+
+	var notebooks graphList
+	target := fmt.Sprintf("%s/me/onenote/notebooks?%s", c.baseURL, nameQuery(c.notebook, ""))
+	if err := c.do(ctx, http.MethodGet, "查找 OneNote 笔记本", target, "", nil, &notebooks); err != nil {
+		return "", err
+	}
+	if len(notebooks.Value) == 0 {
+		return "", fmt.Errorf("OneNote 中没有名为 %s 的笔记本", c.notebook)
+	}
+	sectionsURL := fmt.Sprintf("%s/me/onenote/notebooks/%s/sections", c.baseURL, url.PathEscape(notebooks.Value[0].ID))
+	var sections graphList
+	if err := c.do(ctx, http.MethodGet, "查找 OneNote 分区", sectionsURL+"?"+nameQuery(c.section, ""), "", nil, &sections); err != nil {
+		return "", err
+	}
+	if len(sections.Value) > 0 {
+		c.sectionID = sections.Value[0].ID
+		return c.sectionID, nil
+	}
+	data, err := json.Marshal(map[string]string{"displayName": c.section})
+	if err != nil {
+		return "", fmt.Errorf("序列化 OneNote 请求失败: %w", err)
+	}
+	var created graphObject
+	if err := c.do(ctx, http.MethodPost, "创建 OneNote 分区", sectionsURL, "application/json", bytes.NewReader(data), &created); err != nil {
+		return "", err
+	}
+	if created.ID == "" {
+		return "", fmt.Errorf("创建 OneNote 分区 %s 失败: 响应中没有分区 ID", c.section)
+	}
+	logging.Infof("已在 OneNote 笔记本 %s 中创建分区 %s", c.notebook, c.section)
+	c.sectionID = created.ID
+	return c.sectionID, nil
+}
+
+// nameQuery 生成按 displayName 精确匹配的查询参数, expand 非空时附带 $expand。
+func nameQuery(name, expand string) string {
+	query := url.Values{}
+	query.Set("$filter", fmt.Sprintf("displayName eq '%s'", strings.ReplaceAll(name, "'", "''")))
+	if expand != "" {
+		query.Set("$expand", expand)
+	}
+	return query.Encode()
+}
+
+// do 发送带授权的 Graph 请求并解析 JSON 响应, contentType 为空时不带请求体。
+func (c *Client) do(ctx context.Context, method, action, target, contentType string, body io.Reader, out interface{}) error {
+	token, err := c.tokens.token(ctx)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return fmt.Errorf("构造 OneNote 请求失败: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("调用 OneNote 接口失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		msg := targets.ReadBody(resp.Body)
+		var apiErr graphErrorResponse
+		if err := json.Unmarshal([]byte(msg), &apiErr); err == nil && apiErr.Error.Message != "" {
+			msg = apiErr.Error.Message
+		}
+		if resp.StatusCode == http.StatusUnauthorized && c.staticToken {
+			msg += " (onenote_token 可能已过期, 建议改用 refresh token)"
+		}
+		return &targets.StatusError{Action: action, Status: resp.StatusCode, Message: strings.TrimSpace(msg)}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("解析 OneNote 响应失败: %w", err)
+	}
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,385 @@
+package onenote
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         Config
+		wantSection string
+		wantTenant  string
+		wantStatic  bool
+		wantErr     bool
+	}{
+		{name: "固定 token 与默认分区", cfg: Config{Token: "t"}, wantSection: defaultSection, wantTenant: defaultTenant, wantStatic: true},
+		{name: "refresh token", cfg: Config{RefreshToken: "r", ClientID: "app", Tenant: "contoso", Section: " 备份 "}, wantSection: "备份", wantTenant: "contoso"},
+		{name: "缺少授权", cfg: Config{ClientID: "app"}, wantErr: true},
+		{name: "refresh token 缺少应用 ID", cfg: Config{RefreshToken: "r"}, wantErr: true},
+		{name: "相对地址", cfg: Config{Token: "t", BaseURL: "graph.microsoft.com/v1.0"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if client.section != tt.wantSection || client.tokens.tenant != tt.wantTenant || client.staticToken != tt.wantStatic {
+				t.Errorf("section = %q tenant = %q staticToken = %v", client.section, client.tokens.tenant, client.staticToken)
+			}
+			if client.baseURL != defaultBaseURL || client.tokens.authURL != defaultAuthURL {
+				t.Errorf("baseURL = %q authURL = %q", client.baseURL, client.tokens.authURL)
+			}
+		})
+	}
+}
+
+func TestNameQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		expand string
+		want   string
+	}{
+		{name: "ChatGPT", want: "%24filter=displayName+eq+%27ChatGPT%27"},
+		{name: "Tom's", want: "%24filter=displayName+eq+%27Tom%27%27s%27"},
+		{name: "备份", expand: "parentNotebook", want: "%24expand=parentNotebook&%24filter=displayName+eq+%27%E5%A4%87%E4%BB%BD%27"},
+	}
+	for _, tt := range tests {
+		if got := nameQuery(tt.name, tt.expand); got != tt.want {
+			t.Errorf("nameQuery(%q, %q) = %q, want %q", tt.name, tt.expand, got, tt.want)
+		}
+	}
+}
+
+func TestRenderPage(t *testing.T) {
+	tests := []struct {
+		name  string
+		conv  export.Conversation
+		check func(page string) bool
+	}{
+		{
+			name: "标题与创建时间",
+			conv: export.Conversation{ID: "c1", Title: "A & B", CreateTime: 1709283600, Messages: []export.Message{{Role: "user", Text: "你好"}}},
+			check: func(page string) bool {
+				return strings.Contains(page, "<title>A &amp; B</title>") &&
+					strings.Contains(page, `<meta name="created" content="2024-03-01T09:00:00Z"/>`) && strings.Contains(page, "你好")
+			},
+		},
+		{
+			name: "缺少标题与时间",
+			conv: export.Conversation{ID: "c1"},
+			check: func(page string) bool {
+				return strings.Contains(page, "<title>对话 c1</title>") && !strings.Contains(page, `name="created"`)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderPage(tt.conv, "UTC", export.HTMLOptions{}); !tt.check(got) {
+				t.Errorf("renderPage() = %q", got)
+			}
+		})
+	}
+}
+
+func TestBundleImages(t *testing.T) {
+	image := func(pointer string) export.Asset { return export.Asset{Kind: export.AssetImage, Pointer: pointer} }
+	conv := export.Conversation{ID: "c1", Messages: []export.Message{
+		{Role: "assistant", Assets: []export.Asset{image("file-a"), image("file-missing"), {Kind: export.AssetAudio, Pointer: "file-audio"}}},
+		{Role: "assistant", Assets: []export.Asset{image("file-a"), image("file-b")}},
+	}}
+	tests := []struct {
+		name      string
+		fetch     func(ctx context.Context, pointer string) ([]byte, error)
+		wantNames []string
+		wantPaths []string
+	}{
+		{name: "未配置下载", wantPaths: []string{"", "", "", "", ""}},
+		{
+			name: "下载图片并复用重复指针",
+			fetch: func(ctx context.Context, pointer string) ([]byte, error) {
+				if pointer == "file-missing" {
+					return nil, errors.New("404")
+				}
+				return []byte(pointer), nil
+			},
+			wantNames: []string{"image1", "image2"},
+			wantPaths: []string{"name:image1", "", "", "name:image1", "name:image2"},
+		},
+		{
+			name: "超过总大小后只保留指针",
+			fetch: func(ctx context.Context, pointer string) ([]byte, error) {
+				if pointer == "file-b" {
+					return make([]byte, maxImageBytes), nil
+				}
+				return []byte(pointer), nil
+			},
+			wantNames: []string{"image1", "image2"},
+			wantPaths: []string{"name:image1", "name:image2", "", "name:image1", ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{FetchAsset: tt.fetch}
+			bundled := conv
+			images := c.bundleImages(context.Background(), &bundled)
+			var names, paths []string
+			for _, img := range images {
+				names = append(names, img.Name)
+			}
+			for _, msg := range bundled.Messages {
+				for _, asset := range msg.Assets {
+					paths = append(paths, asset.Path)
+				}
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantNames, ",") || strings.Join(paths, ",") != strings.Join(tt.wantPaths, ",") {
+				t.Errorf("图片 = %v 路径 = %q, want %v %q", names, paths, tt.wantNames, tt.wantPaths)
+			}
+			if conv.Messages[0].Assets[0].Path != "" {
+				t.Fatal("bundleImages 修改了调用方的对话")
+			}
+		})
+	}
+}
+
+// fakeGraph 模拟 Graph 的 OneNote 接口: notebooks 与 sections 为按名称查找的结果, 记录收到的请求。
+type fakeGraph struct {
+	mu        sync.Mutex
+	requests  []string
+	notebooks string
+	sections  string
+	pageParts []string
+	status    int
+}
+
+func (f *fakeGraph) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r.Method+" "+strings.TrimPrefix(r.URL.Path, "/v1.0"))
+	if r.Header.Get("Authorization") != "Bearer tok" {
+		w.WriteHeader(http.StatusUnauthorized)
+		io.WriteString(w, `{"error":{"code":"InvalidAuthenticationToken","message":"Access token has expired."}}`)
+		return
+	}
+	switch {
+	case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/notebooks"):
+		io.WriteString(w, f.notebooks)
+	case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/sections"):
+		io.WriteString(w, f.sections)
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/sections"):
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"id":"0-new!1"}`)
+	case strings.HasSuffix(r.URL.Path, "/pages"):
+		if f.status != 0 {
+			w.WriteHeader(f.status)
+			io.WriteString(w, `{"error":{"code":"20102","message":"The specified resource ID does not exist."}}`)
+			return
+		}
+		f.pageParts = readPageParts(r)
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"id":"page1","links":{"oneNoteWebUrl":{"href":"https://onedrive.live.com/page1"}}}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// readPageParts 返回页面请求各部分的 "名称:内容"; 非 multipart 请求只有一个 Presentation 部分。
+func readPageParts(r *http.Request) []string {
+	mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		data, _ := io.ReadAll(r.Body)
+		return []string{"Presentation:" + string(data)}
+	}
+	var parts []string
+	reader := multipart.NewReader(r.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			return parts
+		}
+		data, _ := io.ReadAll(part)
+		parts = append(parts, part.FormName()+":"+string(data))
+	}
+}
+
+func TestSectionTarget(t *testing.T) {
+	tests := []struct {
+		name         string
+		notebook     string
+		section      string
+		notebooks    string
+		sections     string
+		wantID       string
+		wantRequests []string
+		wantErr      string
+	}{
+		{name: "分区 ID 不需要查找", section: "0-abc!12", wantID: "0-abc!12"},
+		{
+			name:         "在全部笔记本中按名称查找",
+			sections:     `{"value":[{"id":"0-s!1","displayName":"ChatGPT"}]}`,
+			wantID:       "0-s!1",
+			wantRequests: []string{"GET /me/onenote/sections"},
+		},
+		{
+			name:         "分区不存在",
+			sections:     `{"value":[]}`,
+			wantRequests: []string{"GET /me/onenote/sections"},
+			wantErr:      "没有名为 ChatGPT 的分区",
+		},
+		{
+			name:         "多个笔记本有同名分区",
+			sections:     `{"value":[{"id":"1","parentNotebook":{"displayName":"工作"}},{"id":"2","parentNotebook":{"displayName":"个人"}}]}`,
+			wantRequests: []string{"GET /me/onenote/sections"},
+			wantErr:      "工作, 个人",
+		},
+		{
+			name:         "在指定笔记本中查找",
+			notebook:     "工作",
+			notebooks:    `{"value":[{"id":"0-nb!1"}]}`,
+			sections:     `{"value":[{"id":"0-s!2"}]}`,
+			wantID:       "0-s!2",
+			wantRequests: []string{"GET /me/onenote/notebooks", "GET /me/onenote/notebooks/0-nb!1/sections"},
+		},
+		{
+			name:         "指定笔记本中没有分区时创建",
+			notebook:     "工作",
+			notebooks:    `{"value":[{"id":"0-nb!1"}]}`,
+			sections:     `{"value":[]}`,
+			wantID:       "0-new!1",
+			wantRequests: []string{"GET /me/onenote/notebooks", "GET /me/onenote/notebooks/0-nb!1/sections", "POST /me/onenote/notebooks/0-nb!1/sections"},
+		},
+		{
+			name:         "笔记本不存在",
+			notebook:     "工作",
+			notebooks:    `{"value":[]}`,
+			wantRequests: []string{"GET /me/onenote/notebooks"},
+			wantErr:      "没有名为 工作 的笔记本",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph := &fakeGraph{notebooks: tt.notebooks, sections: tt.sections}
+			server := httptest.NewServer(graph)
+			defer server.Close()
+			client, err := New(Config{Token: "tok", Notebook: tt.notebook, Section: tt.section, BaseURL: server.URL + "/v1.0"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 2; i++ {
+				id, err := client.sectionTarget(context.Background())
+				if tt.wantErr != "" {
+					if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+						t.Fatalf("err = %v, want 包含 %q", err, tt.wantErr)
+					}
+					break
+				}
+				if err != nil || id != tt.wantID {
+					t.Fatalf("sectionTarget() = %q, %v, want %q", id, err, tt.wantID)
+				}
+			}
+			// 第二次调用使用缓存, 不再请求 Graph。
+			if strings.Join(graph.requests, ",") != strings.Join(tt.wantRequests, ",") {
+				t.Errorf("请求 = %v, want %v", graph.requests, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestCreateConversation(t *testing.T) {
+	conv := export.Conversation{ID: "c1", Title: "标题", Messages: []export.Message{
+		{Role: "user", Text: "画一只猫"},
+		{Role: "assistant", Assets: []export.Asset{{Kind: export.AssetImage, Pointer: "file-cat"}}},
+	}}
+	tests := []struct {
+		name       string
+		token      string
+		fetch      bool
+		status     int
+		wantParts  []string
+		wantStatus int
+		wantErr    string
+	}{
+		{name: "纯 HTML 页面", token: "tok", wantParts: []string{"Presentation"}},
+		{name: "图片随页面上传", token: "tok", fetch: true, wantParts: []string{"Presentation", "image1"}},
+		{name: "分区已被删除", token: "tok", status: http.StatusNotFound, wantStatus: http.StatusNotFound, wantErr: "does not exist"},
+		{name: "固定 token 过期时提示改用 refresh token", token: "expired", wantStatus: http.StatusUnauthorized, wantErr: "建议改用 refresh token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph := &fakeGraph{status: tt.status}
+			server := httptest.NewServer(graph)
+			defer server.Close()
+			client, err := New(Config{Token: tt.token, Section: "0-s!1", BaseURL: server.URL + "/v1.0"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.fetch {
+				client.FetchAsset = func(ctx context.Context, pointer string) ([]byte, error) {
+					return []byte("\x89PNG\r\n\x1a\n" + pointer), nil
+				}
+			}
+			obj, err := client.CreateConversation(context.Background(), conv, "UTC")
+			if tt.wantStatus != 0 {
+				var statusErr *targets.StatusError
+				if !errors.As(err, &statusErr) || statusErr.Status != tt.wantStatus || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want 状态码 %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if obj.ID != "page1" || obj.URL != "https://onedrive.live.com/page1" {
+				t.Errorf("Object = %+v", obj)
+			}
+			if got := graph.requests; len(got) != 1 || got[0] != "POST /me/onenote/sections/0-s!1/pages" {
+				t.Errorf("请求 = %v", got)
+			}
+			var names []string
+			for _, part := range graph.pageParts {
+				names = append(names, part[:strings.Index(part, ":")])
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantParts, ",") {
+				t.Fatalf("请求部分 = %v, want %v", names, tt.wantParts)
+			}
+			page := graph.pageParts[0]
+			if !strings.Contains(page, "<title>标题</title>") || !strings.Contains(page, "画一只猫") {
+				t.Errorf("页面 = %q", page)
+			}
+			if tt.fetch != strings.Contains(page, "name:image1") {
+				t.Errorf("页面中的图片引用 = %q", page)
+			}
+		})
+	}
+}
+
+func TestFirstNonEmpty(t *testing.T) {
+	tests := []struct {
+		values []string
+		want   string
+	}{
+		{nil, ""},
+		{[]string{" ", "", "invalid_grant", "x"}, "invalid_grant"},
+	}
+	for _, tt := range tests {
+		if got := firstNonEmpty(tt.values...); got != tt.want {
+			t.Errorf("firstNonEmpty(%q) = %q, want %q", tt.values, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package targets
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// MaybeApplied 判断失败的创建请求是否可能已被目标执行: 网络错误 (包括超时) 与 503 以外的 5xx
+// 都无法确定对象是否已经创建; 429 与 503 表示请求未被处理, 其余 4xx 表示请求被拒绝。
+func MaybeApplied(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Status >= 500 && statusErr.Status != http.StatusServiceUnavailable
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// PendingCreates 记录结果不确定的创建请求, 按对话 ID 保存最早一次请求的开始时间。
+// 目标接口不支持幂等键时, 同一对话重试前据此在目标中查找已经创建的对象, 避免重复创建。
+// 零值可直接使用。
+type PendingCreates struct {
+	mu      sync.Mutex
+	started map[string]time.Time
+}
+
+// Since 返回对话上一次结果不确定的创建请求的开始时间。
+func (p *PendingCreates) Since(id string) (time.Time, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	started, ok := p.started[id]
+	return started, ok
+}
+
+// Finish 按创建请求的结果更新记录: err 满足 MaybeApplied 时记下 started (已有更早的记录时保留),
+// 否则 (成功或确定失败) 清除记录。
+func (p *PendingCreates) Finish(id string, started time.Time, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !MaybeApplied(err) {
+		delete(p.started, id)
+		return
+	}
+	if previous, ok := p.started[id]; ok && previous.Before(started) {
+		return
+	}
+	if p.started == nil {
+		p.started = make(map[string]time.Time)
+	}
+	p.started[id] = started
+}
+
+// Clear 清除对话的记录, 用于在目标中找到了已经创建的对象之后。
+func (p *PendingCreates) Clear(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.started, id)
+}
@@ -0,0 +1,74 @@
+package targets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMaybeApplied(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "成功", err: nil, want: false},
+		{name: "主动取消", err: fmt.Errorf("创建页面失败: %w", context.Canceled), want: false},
+		{name: "请求超时", err: fmt.Errorf("创建页面失败: %w", &net.DNSError{IsTimeout: true}), want: true},
+		{name: "500", err: &StatusError{Action: "创建页面", Status: http.StatusInternalServerError}, want: true},
+		{name: "502", err: fmt.Errorf("wrap: %w", &StatusError{Status: http.StatusBadGateway}), want: true},
+		{name: "503 未处理", err: &StatusError{Status: http.StatusServiceUnavailable}, want: false},
+		{name: "429 未处理", err: &StatusError{Status: http.StatusTooManyRequests}, want: false},
+		{name: "400 被拒绝", err: &StatusError{Status: http.StatusBadRequest}, want: false},
+		{name: "其他错误", err: errors.New("渲染失败"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MaybeApplied(tt.err); got != tt.want {
+				t.Errorf("MaybeApplied(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPendingCreates(t *testing.T) {
+	uncertain := &StatusError{Status: http.StatusBadGateway}
+	rejected := &StatusError{Status: http.StatusBadRequest}
+	t0 := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Minute)
+
+	var p PendingCreates
+	if _, ok := p.Since("a"); ok {
+		t.Fatal("零值不应有记录")
+	}
+	steps := []struct {
+		name    string
+		started time.Time
+		err     error
+		want    time.Time
+		wantOK  bool
+	}{
+		{name: "结果不确定时记录", started: t0, err: uncertain, want: t0, wantOK: true},
+		{name: "再次不确定时保留更早的时间", started: t1, err: uncertain, want: t0, wantOK: true},
+		{name: "确定失败时清除", started: t1, err: rejected, wantOK: false},
+		{name: "重新记录", started: t1, err: uncertain, want: t1, wantOK: true},
+		{name: "成功时清除", started: t1, err: nil, wantOK: false},
+	}
+	for _, step := range steps {
+		p.Finish("a", step.started, step.err)
+		got, ok := p.Since("a")
+		if ok != step.wantOK || !got.Equal(step.want) {
+			t.Fatalf("%s: Since() = %v, %v, want %v, %v", step.name, got, ok, step.want, step.wantOK)
+		}
+	}
+
+	p.Finish("b", t0, uncertain)
+	p.Clear("b")
+	if _, ok := p.Since("b"); ok {
+		t.Error("Clear 后仍有记录")
+	}
+}
@@ -0,0 +1,180 @@
+// Package rawjson 把对话详情的原始 JSON 写入本地目录, 每个对话一个文件, 不经过任何渲染,
+// 作为与导出格式无关的无损归档。
+package rawjson
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Devoty/openai-backup/atomicfile"
+	"github.com/Devoty/openai-backup/diskspace"
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+const (
+	// FormatJSON 把对话详情原样写为 <id>.json (仅调整缩进)。
+	FormatJSON = "json"
+	// FormatJSONL 把消息树展开为 <id>.jsonl, 每行一个节点的消息。
+	FormatJSONL = "jsonl"
+)
+
+// NormalizeFormat 归一化文件格式, 未知取值按 json 处理。
+func NormalizeFormat(value string) string {
+	if strings.EqualFold(strings.TrimSpace(value), FormatJSONL) {
+		return FormatJSONL
+	}
+	return FormatJSON
+}
+
+// Config 是创建 Client 所需的参数。
+type Config struct {
+	// Dir 为输出目录, 不存在时在首次写入时创建。
+	Dir string
+	// Format 为 json 或 jsonl, 见 NormalizeFormat。
+	Format string
+	// Journal 记录写入中的临时文件, 中断的写入在下次启动时清理, 为 nil 时不记录。
+	Journal atomicfile.Journal
+}
+
+// Client 把对话写入 Dir, 文件以对话 ID 命名, 再次导出时覆盖。
+type Client struct {
+	dir     string
+	format  string
+	journal atomicfile.Journal
+}
+
+// New 校验配置并创建 Client。
+func New(cfg Config) (*Client, error) {
+	if strings.TrimSpace(cfg.Dir) == "" {
+		return nil, errors.New("缺少输出目录: 请在配置中填写 raw_output_path")
+	}
+	abs, err := filepath.Abs(cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("解析输出目录失败: %w", err)
+	}
+	if info, err := os.Stat(abs); err == nil && !info.IsDir() {
+		return nil, fmt.Errorf("输出路径 %s 不是目录", abs)
+	}
+	return &Client{dir: abs, format: NormalizeFormat(cfg.Format), journal: cfg.Journal}, nil
+}
+
+// CreateConversation 写入对话的原始 JSON, 对象 ID 为文件名。
+func (c *Client) CreateConversation(ctx context.Context, conv export.Conversation, timezone string) (targets.Object, error) {
+	if err := ctx.Err(); err != nil {
+		return targets.Object{}, err
+	}
+	if len(conv.Raw) == 0 {
+		return targets.Object{}, fmt.Errorf("对话 %s 缺少原始数据", conv.ID)
+	}
+	if conv.ID == "" || conv.ID != filepath.Base(conv.ID) || strings.HasPrefix(conv.ID, ".") {
+		return targets.Object{}, fmt.Errorf("对话 ID 无法用作文件名: %q", conv.ID)
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+	if c.format == FormatJSONL {
+		data, err = flatten(conv.ID, conv.Raw)
+	} else {
+		var buf bytes.Buffer
+		if err = json.Indent(&buf, conv.Raw, "", "  "); err == nil {
+			buf.WriteByte('\n')
+			data = buf.Bytes()
+		}
+	}
+	if err != nil {
+		return targets.Object{}, fmt.Errorf("解析对话 %s 的原始数据失败: %w", conv.ID, err)
+	}
+
+	name := conv.ID + "." + c.format
+	path := filepath.Join(c.dir, name)
+	if err := diskspace.Check(c.dir, int64(len(data))); err != nil {
+		return targets.Object{}, err
+	}
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return targets.Object{}, fmt.Errorf("创建目录失败: %w", err)
+	}
+	if err := atomicfile.WriteFile(c.journal, path, data, 0o644); err != nil {
+		return targets.Object{}, fmt.Errorf("写入 %s 失败: %w", name, err)
+	}
+	link := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	return targets.Object{ID: name, URL: link.String()}, nil
+}
+
+// line 是 jsonl 文件中的一行: 节点在消息树中的位置与原样保留的消息。
+type line struct {
+	ConversationID string          `json:"conversation_id"`
+	NodeID         string          `json:"node_id"`
+	Parent         string          `json:"parent,omitempty"`
+	Children       []string        `json:"children,omitempty"`
+	Message        json.RawMessage `json:"message"`
+}
+
+// flatten 按消息树的深度优先顺序展开全部节点 (包括未选中的分支), 跳过没有消息的节点。
+// 消息对象原样写出, 不经过 client 包的结构解析。
+func flatten(conversationID string, raw json.RawMessage) ([]byte, error) {
+	var detail struct {
+		Mapping map[string]struct {
+			Parent   *string         `json:"parent"`
+			Children []string        `json:"children"`
+			Message  json.RawMessage `json:"message"`
+		} `json:"mapping"`
+	}
+	if err := json.Unmarshal(raw, &detail); err != nil {
+		return nil, err
+	}
+	var roots []string
+	for id, node := range detail.Mapping {
+		if node.Parent == nil || *node.Parent == "" {
+			roots = append(roots, id)
+			continue
+		}
+		if _, ok := detail.Mapping[*node.Parent]; !ok {
+			roots = append(roots, id)
+		}
+	}
+	sort.Strings(roots)
+
+	var buf bytes.Buffer
+	visited := make(map[string]bool, len(detail.Mapping))
+	stack := make([]string, 0, len(roots))
+	for i := len(roots) - 1; i >= 0; i-- {
+		stack = append(stack, roots[i])
+	}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		node, ok := detail.Mapping[id]
+		if !ok || visited[id] {
+			continue
+		}
+		visited[id] = true
+		for i := len(node.Children) - 1; i >= 0; i-- {
+			stack = append(stack, node.Children[i])
+		}
+		if len(node.Message) == 0 || bytes.Equal(bytes.TrimSpace(node.Message), []byte("null")) {
+			continue
+		}
+		entry := line{ConversationID: conversationID, NodeID: id, Children: node.Children, Message: node.Message}
+		if node.Parent != nil {
+			entry.Parent = *node.Parent
+		}
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
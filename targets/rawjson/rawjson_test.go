@@ -0,0 +1,139 @@
+package rawjson
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Devoty/openai-backup/export"
+)
+
+const detail = `{"id":"c1","mapping":{
+	"root":{"id":"root","parent":null,"children":["u1"],"message":null},
+	"u1":{"id":"u1","parent":"root","children":["a1","a2"],"message":{"id":"u1","content":{"parts":["你好"]},"x_unknown":1}},
+	"a1":{"id":"a1","parent":"u1","children":[],"message":{"id":"a1"}},
+	"a2":{"id":"a2","parent":"u1","children":[],"message":{"id":"a2"}},
+	"orphan":{"id":"orphan","parent":"gone","children":[],"message":{"id":"orphan"}}
+}}`
+
+func TestNormalizeFormat(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", FormatJSON},
+		{"json", FormatJSON},
+		{" JSONL ", FormatJSONL},
+		{"ndjson", FormatJSON},
+	}
+	for _, tt := range tests {
+		if got := NormalizeFormat(tt.value); got != tt.want {
+			t.Errorf("NormalizeFormat(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	data, err := flatten("c1", json.RawMessage(detail))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	want := []struct {
+		node   string
+		parent string
+	}{
+		{"orphan", "gone"},
+		{"u1", "root"},
+		{"a1", "u1"},
+		{"a2", "u1"},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("行数 = %d, want %d:\n%s", len(lines), len(want), data)
+	}
+	for i, w := range want {
+		var got line
+		if err := json.Unmarshal([]byte(lines[i]), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.ConversationID != "c1" || got.NodeID != w.node || got.Parent != w.parent {
+			t.Errorf("第 %d 行 = %+v, want node=%s parent=%s", i+1, got, w.node, w.parent)
+		}
+	}
+	if !strings.Contains(lines[1], `"x_unknown":1`) || !strings.Contains(lines[1], `"children":["a1","a2"]`) {
+		t.Errorf("消息应原样保留: %s", lines[1])
+	}
+}
+
+func TestCreateConversation(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		conv     export.Conversation
+		wantFile string
+		wantErr  bool
+		check    func(data string) bool
+	}{
+		{
+			name:     "json 仅调整缩进",
+			format:   FormatJSON,
+			conv:     export.Conversation{ID: "c1", Raw: json.RawMessage(`{"id":"c1","x":[1,2]}`)},
+			wantFile: "c1.json",
+			check: func(data string) bool {
+				return data == "{\n  \"id\": \"c1\",\n  \"x\": [\n    1,\n    2\n  ]\n}\n"
+			},
+		},
+		{
+			name:     "jsonl 展开消息树",
+			format:   FormatJSONL,
+			conv:     export.Conversation{ID: "c1", Raw: json.RawMessage(detail)},
+			wantFile: "c1.jsonl",
+			check:    func(data string) bool { return strings.Count(data, "\n") == 4 },
+		},
+		{name: "缺少原始数据", format: FormatJSON, conv: export.Conversation{ID: "c1"}, wantErr: true},
+		{name: "ID 包含路径", format: FormatJSON, conv: export.Conversation{ID: "../c1", Raw: json.RawMessage(`{}`)}, wantErr: true},
+		{name: "隐藏文件名", format: FormatJSON, conv: export.Conversation{ID: ".c1", Raw: json.RawMessage(`{}`)}, wantErr: true},
+		{name: "原始数据无法解析", format: FormatJSON, conv: export.Conversation{ID: "c1", Raw: json.RawMessage(`{`)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "raw")
+			client, err := New(Config{Dir: dir, Format: tt.format})
+			if err != nil {
+				t.Fatal(err)
+			}
+			obj, err := client.CreateConversation(context.Background(), tt.conv, "UTC")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			entries, _ := os.ReadDir(dir)
+			if tt.wantErr {
+				if len(entries) != 0 {
+					t.Errorf("失败时不应写入文件, 目录中有 %d 项", len(entries))
+				}
+				return
+			}
+			if obj.ID != tt.wantFile || !strings.HasSuffix(obj.URL, "/"+tt.wantFile) {
+				t.Errorf("Object = %+v, want %s", obj, tt.wantFile)
+			}
+			var names []string
+			for _, entry := range entries {
+				names = append(names, entry.Name())
+			}
+			if !reflect.DeepEqual(names, []string{tt.wantFile}) {
+				t.Errorf("目录内容 = %v, want [%s]", names, tt.wantFile)
+			}
+			data, err := os.ReadFile(filepath.Join(dir, tt.wantFile))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.check(string(data)) {
+				t.Errorf("文件内容不符合预期:\n%s", data)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+// Package readwise 把对话以文章形式保存到 Readwise Reader, 便于划线与回顾。
+package readwise
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/httpc"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+const (
+	defaultBaseURL = "https://readwise.io"
+	// conversationURL 是 Reader 要求的文档唯一地址, 使用 ChatGPT 网页端的对话链接。
+	conversationURL = "https://chatgpt.com/c/"
+)
+
+// Config 是创建 Client 所需的 Readwise 参数。
+type Config struct {
+	// Token 为 https://readwise.io/access_token 获取的访问令牌。
+	Token string
+	// Tags 为每篇文章附加的标签, 与对话自身的标签合并。
+	Tags    []string
+	HTML    export.HTMLOptions
+	BaseURL string
+}
+
+// Client 通过 Reader API 为每个对话保存一篇文章。
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+	tags       []string
+	html       export.HTMLOptions
+}
+
+type saveRequest struct {
+	URL             string   `json:"url"`
+	HTML            string   `json:"html"`
+	Title           string   `json:"title"`
+	Author          string   `json:"author,omitempty"`
+	Summary         string   `json:"summary,omitempty"`
+	PublishedDate   string   `json:"published_date,omitempty"`
+	Tags            []string `json:"tags,omitempty"`
+	Category        string   `json:"category"`
+	SavedUsing      string   `json:"saved_using"`
+	ShouldCleanHTML bool     `json:"should_clean_html"`
+}
+
+type saveResponse struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+// New 校验配置并创建 Client。
+func New(cfg Config) (*Client, error) {
+	token := strings.TrimSpace(cfg.Token)
+	if token == "" {
+		return nil, fmt.Errorf("缺少 Readwise Token: 请在配置页填写 readwise_token")
+	}
+	baseURL := strings.TrimRight(strings.TrimSpace(cfg.BaseURL), "/")
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	if parsed, err := url.Parse(baseURL); err != nil || !parsed.IsAbs() {
+		return nil, fmt.Errorf("Readwise 基础地址无效: %s", cfg.BaseURL)
+	}
+	return &Client{
+		httpClient: httpc.Client(),
+		baseURL:    baseURL,
+		token:      token,
+		tags:       cfg.Tags,
+		html:       cfg.HTML,
+	}, nil
+}
+
+// CreateConversation 保存对话为 Reader 文章。同一对话重复保存时 Reader 返回已有文章, 不会产生副本。
+func (c *Client) CreateConversation(ctx context.Context, conv export.Conversation, timezone string) (targets.Object, error) {
+	title := strings.TrimSpace(conv.Title)
+	if title == "" {
+		title = fmt.Sprintf("对话 %s", conv.ID)
+	}
+	payload := saveRequest{
+		URL:        conversationURL + url.PathEscape(conv.ID),
+		HTML:       export.RenderHTML(conv, timezone, c.html),
+		Title:      title,
+		Author:     "ChatGPT",
+		Summary:    firstUserMessage(conv),
+		Tags:       mergeTags(c.tags, conv.Tags),
+		Category:   "article",
+		SavedUsing: "openai-backup",
+	}
+	if conv.CreateTime > 0 {
+		payload.PublishedDate = time.Unix(int64(conv.CreateTime), 0).UTC().Format(time.RFC3339)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return targets.Object{}, fmt.Errorf("序列化 Readwise 请求失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/v3/save/", bytes.NewReader(data))
+	if err != nil {
+		return targets.Object{}, fmt.Errorf("构造 Readwise 请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Token "+c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return targets.Object{}, fmt.Errorf("调用 Readwise 接口失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return targets.Object{}, &targets.StatusError{Action: "保存 Readwise 文章", Status: resp.StatusCode, Message: strings.TrimSpace(targets.ReadBody(resp.Body))}
+	}
+	var result saveResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return targets.Object{}, fmt.Errorf("解析 Readwise 响应失败: %w", err)
+	}
+	return targets.Object{ID: result.ID, URL: result.URL}, nil
+}
+
+// ParseTags 解析逗号分隔的标签列表。
+func ParseTags(raw string) []string {
+	var tags []string
+	for _, tag := range strings.Split(raw, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func mergeTags(lists ...[]string) []string {
+	seen := make(map[string]struct{})
+	var tags []string
+	for _, list := range lists {
+		for _, tag := range list {
+			key := strings.ToLower(strings.TrimSpace(tag))
+			if key == "" {
+				continue
+			}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			tags = append(tags, strings.TrimSpace(tag))
+		}
+	}
+	return tags
+}
+
+// firstUserMessage 取首条用户消息作为文章摘要。
+func firstUserMessage(conv export.Conversation) string {
+	for _, msg := range conv.Messages {
+		if msg.Role != "user" {
+			continue
+		}
+		text := strings.Join(strings.Fields(msg.Text), " ")
+		if runes := []rune(text); len(runes) > 200 {
+			text = string(runes[:200]) + "…"
+		}
+		if text != "" {
+			return text
+		}
+	}
+	return ""
+}
@@ -0,0 +1,173 @@
+package readwise
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want []string
+	}{
+		{"", nil},
+		{"chatgpt", []string{"chatgpt"}},
+		{" chatgpt , , 备份 ", []string{"chatgpt", "备份"}},
+	}
+	for _, tt := range tests {
+		if got := ParseTags(tt.raw); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseTags(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	tests := []struct {
+		name  string
+		lists [][]string
+		want  []string
+	}{
+		{name: "没有标签", lists: nil, want: nil},
+		{name: "保留首次出现的写法", lists: [][]string{{"ChatGPT", " 工作 "}, {"chatgpt", "学习", ""}}, want: []string{"ChatGPT", "工作", "学习"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeTags(tt.lists...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeTags() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFirstUserMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []export.Message
+		want     string
+	}{
+		{name: "没有用户消息", messages: []export.Message{{Role: "assistant", Text: "你好"}}, want: ""},
+		{name: "跳过空白消息并合并空白", messages: []export.Message{{Role: "user", Text: " \n "}, {Role: "user", Text: "如何\n  学习 Go?"}}, want: "如何 学习 Go?"},
+		{name: "超过 200 字截断", messages: []export.Message{{Role: "user", Text: strings.Repeat("问", 300)}}, want: strings.Repeat("问", 200) + "…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := firstUserMessage(export.Conversation{Messages: tt.messages}); got != tt.want {
+				t.Errorf("firstUserMessage() = %q (%d 字), want %q", got, utf8.RuneCountInString(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantURL string
+		wantErr bool
+	}{
+		{name: "默认地址", cfg: Config{Token: "t"}, wantURL: defaultBaseURL},
+		{name: "自定义地址", cfg: Config{Token: "t", BaseURL: "http://127.0.0.1:9000/"}, wantURL: "http://127.0.0.1:9000"},
+		{name: "缺少 Token", cfg: Config{}, wantErr: true},
+		{name: "相对地址", cfg: Config{Token: "t", BaseURL: "readwise.example.com"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && client.baseURL != tt.wantURL {
+				t.Errorf("baseURL = %q, want %q", client.baseURL, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestCreateConversation(t *testing.T) {
+	tests := []struct {
+		name       string
+		conv       export.Conversation
+		status     int
+		response   string
+		check      func(req saveRequest) bool
+		want       targets.Object
+		wantStatus int
+	}{
+		{
+			name:     "保存文章",
+			conv:     export.Conversation{ID: "c/1", Title: "标题", CreateTime: 1709283600, Tags: []string{"工作"}, Messages: []export.Message{{Role: "user", Text: "你好"}}},
+			status:   http.StatusCreated,
+			response: `{"id":"doc1","url":"https://read.readwise.io/read/doc1"}`,
+			check: func(req saveRequest) bool {
+				return req.URL == "https://chatgpt.com/c/c%2F1" && req.Title == "标题" && req.Summary == "你好" &&
+					req.PublishedDate == "2024-03-01T09:00:00Z" && reflect.DeepEqual(req.Tags, []string{"chatgpt", "工作"}) &&
+					req.Category == "article" && strings.Contains(req.HTML, "你好")
+			},
+			want: targets.Object{ID: "doc1", URL: "https://read.readwise.io/read/doc1"},
+		},
+		{
+			name:     "已保存过的文章",
+			conv:     export.Conversation{ID: "c2"},
+			status:   http.StatusOK,
+			response: `{"id":"doc2","url":"https://read.readwise.io/read/doc2"}`,
+			check: func(req saveRequest) bool {
+				return req.Title == "对话 c2" && req.PublishedDate == "" && req.Summary == ""
+			},
+			want: targets.Object{ID: "doc2", URL: "https://read.readwise.io/read/doc2"},
+		},
+		{name: "Token 无效", conv: export.Conversation{ID: "c3"}, status: http.StatusUnauthorized, response: `{"detail":"Invalid token."}`, wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got saveRequest
+			var auth string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/v3/save/" {
+					http.NotFound(w, r)
+					return
+				}
+				auth = r.Header.Get("Authorization")
+				json.NewDecoder(r.Body).Decode(&got)
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.response)
+			}))
+			defer server.Close()
+
+			client, err := New(Config{Token: "rw", BaseURL: server.URL, Tags: []string{"chatgpt"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			obj, err := client.CreateConversation(context.Background(), tt.conv, "UTC")
+			if tt.wantStatus != 0 {
+				var statusErr *targets.StatusError
+				if !errors.As(err, &statusErr) || statusErr.Status != tt.wantStatus {
+					t.Fatalf("err = %v, want 状态码 %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if auth != "Token rw" {
+				t.Errorf("Authorization = %q", auth)
+			}
+			if !tt.check(got) {
+				t.Errorf("请求 = %+v", got)
+			}
+			if obj.ID != tt.want.ID || obj.URL != tt.want.URL {
+				t.Errorf("Object = %+v, want %+v", obj, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+// Package targets 定义导出目标 (Notion、Anytype 等) 的公共接口与错误类型。
+package targets
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/Devoty/openai-backup/export"
+)
+
+// Exporter 是各导出目标客户端的统一写入入口, 返回目标侧对象。
+type Exporter interface {
+	CreateConversation(ctx context.Context, conv export.Conversation, timezone string) (Object, error)
+}
+
+// Object 描述目标侧创建的对象: ID 以及可直接打开的链接。
+type Object struct {
+	ID  string
+	URL string
+	// Substitutions 为写入时被目标拒绝、改用纯文本代替的区块, 写入任务报告。
+	Substitutions []Substitution
+}
+
+// Substitution 记录一个被目标拒绝后以纯文本代替的区块。
+type Substitution struct {
+	// Block 为区块在页面中的序号, 从 1 开始。
+	Block  int    `json:"block"`
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+// StatusError 表示导出目标接口返回了非成功状态码。
+type StatusError struct {
+	Action  string
+	Status  int
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s失败: status=%d message=%s", e.Action, e.Status, e.Message)
+}
+
+// ReadBody 读取最多 4KB 的响应内容, 用于日志与错误信息。
+func ReadBody(r io.Reader) string {
+	const limit = 4 << 10
+	buf, err := io.ReadAll(io.LimitReader(r, limit))
+	if err != nil {
+		return fmt.Sprintf("读取响应失败: %v", err)
+	}
+	return string(buf)
+}
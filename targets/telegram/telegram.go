@@ -0,0 +1,286 @@
+// Package telegram 通过 Bot API 把对话发送到 Telegram 频道、群组或私聊。
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/httpc"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+const defaultBaseURL = "https://api.telegram.org"
+
+const (
+	// ModeFull 把完整 Markdown 正文拆分为多条消息发送。
+	ModeFull = "full"
+	// ModeSummary 只发送摘要, 完整 Markdown 作为文件附在同一条消息中。
+	ModeSummary = "summary"
+)
+
+// capabilities 为 Bot API 的限制: 单条消息 4096 字符 (为分段序号留出空间), 上传文件 50 MB。
+var capabilities = targets.Capabilities{
+	MaxBodyLength: 4000,
+	MaxFileSize:   50 << 20,
+}
+
+const (
+	// captionLimit 是文件说明文字的字符上限。
+	captionLimit = 1024
+	// maxRetryAfter 限制遇到限流时的单次等待时间, 更久的限流交给调用方重试。
+	maxRetryAfter = time.Minute
+	maxAttempts   = 3
+)
+
+// Config 是创建 Client 所需的 Bot 参数。
+type Config struct {
+	Token string
+	// ChatID 为数字 ID 或公开频道的 @username。
+	ChatID string
+	// Mode 取 ModeFull (默认) 或 ModeSummary。
+	Mode    string
+	BaseURL string
+	// Filename 为摘要模式下发送文件的文件名选项。
+	Filename export.FilenameOptions
+}
+
+// Client 为每个对话发送一条或多条 Telegram 消息。
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+	chatID     string
+	mode       string
+	filename   export.FilenameOptions
+}
+
+type apiResponse struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	Description string          `json:"description"`
+	Parameters  struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+type sentMessage struct {
+	MessageID int64 `json:"message_id"`
+}
+
+// New 校验配置并创建 Client。
+func New(cfg Config) (*Client, error) {
+	token := strings.TrimSpace(cfg.Token)
+	if token == "" {
+		return nil, fmt.Errorf("缺少 Telegram Bot Token: 请在配置页填写 telegram_bot_token")
+	}
+	chatID := strings.TrimSpace(cfg.ChatID)
+	if chatID == "" {
+		return nil, fmt.Errorf("缺少 Telegram Chat ID: 请在配置页填写 telegram_chat_id")
+	}
+	baseURL := strings.TrimRight(strings.TrimSpace(cfg.BaseURL), "/")
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	return &Client{
+		httpClient: httpc.Client(),
+		baseURL:    baseURL,
+		token:      token,
+		chatID:     chatID,
+		mode:       NormalizeMode(cfg.Mode),
+		filename:   cfg.Filename,
+	}, nil
+}
+
+// NormalizeMode 将发送方式收敛为 ModeFull 或 ModeSummary。
+func NormalizeMode(value string) string {
+	if strings.EqualFold(strings.TrimSpace(value), ModeSummary) {
+		return ModeSummary
+	}
+	return ModeFull
+}
+
+// CreateConversation 发送对话, 返回第一条消息的 ID 与链接 (仅频道/超级群组可生成链接)。
+func (c *Client) CreateConversation(ctx context.Context, conv export.Conversation, timezone string) (targets.Object, error) {
+	markdown := export.RenderMarkdown(conv, timezone)
+	var firstID int64
+	if c.mode == ModeSummary {
+		if len(markdown) > capabilities.MaxFileSize {
+			return targets.Object{}, fmt.Errorf("对话正文超过 Telegram 文件 %d MB 上限", capabilities.MaxFileSize>>20)
+		}
+		id, err := c.sendDocument(ctx, export.ConversationFilenameWith(conv, nil, c.filename), []byte(markdown), summary(conv, timezone))
+		if err != nil {
+			return targets.Object{}, err
+		}
+		firstID = id
+	} else {
+		parts := splitMessage(markdown)
+		for idx, part := range parts {
+			if len(parts) > 1 {
+				part = fmt.Sprintf("(%d/%d)\n%s", idx+1, len(parts), part)
+			}
+			id, err := c.sendMessage(ctx, part)
+			if err != nil {
+				if idx > 0 {
+					return targets.Object{}, fmt.Errorf("已发送 %d/%d 段后失败: %w", idx, len(parts), err)
+				}
+				return targets.Object{}, err
+			}
+			if idx == 0 {
+				firstID = id
+			}
+		}
+	}
+	messageID := strconv.FormatInt(firstID, 10)
+	return targets.Object{ID: messageID, URL: c.messageURL(messageID)}, nil
+}
+
+// summary 生成标题、时间、消息数与首条提问预览, 长度不超过文件说明上限。
+func summary(conv export.Conversation, timezone string) string {
+	tf := export.NewTimeFormatter(conv, export.ResolveLocation(timezone))
+	title := strings.TrimSpace(conv.Title)
+	if title == "" {
+		title = fmt.Sprintf("对话 %s", conv.ID)
+	}
+	var b strings.Builder
+	b.WriteString(title + "\n\n")
+	b.WriteString(fmt.Sprintf("创建时间: %s\n", tf.Format(conv.CreateTime)))
+	b.WriteString(fmt.Sprintf("最近更新: %s\n", tf.Format(conv.UpdateTime)))
+	b.WriteString(fmt.Sprintf("消息数: %d\n", len(conv.Messages)))
+	for _, msg := range conv.Messages {
+		if msg.Role == "user" && strings.TrimSpace(msg.Text) != "" {
+			b.WriteString("\n" + strings.TrimSpace(msg.Text))
+			break
+		}
+	}
+	return truncate(b.String(), captionLimit)
+}
+
+// splitMessage 按单条消息上限拆分文本, 去掉各段首尾空白。
+func splitMessage(text string) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return []string{"(空内容)"}
+	}
+	parts := capabilities.SplitBody(text)
+	for idx := range parts {
+		parts[idx] = strings.TrimSpace(parts[idx])
+	}
+	return parts
+}
+
+func (c *Client) sendMessage(ctx context.Context, text string) (int64, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"chat_id":                  c.chatID,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("序列化 Telegram 请求失败: %w", err)
+	}
+	return c.call(ctx, "sendMessage", "application/json", func() io.Reader { return bytes.NewReader(payload) })
+}
+
+func (c *Client) sendDocument(ctx context.Context, filename string, data []byte, caption string) (int64, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	_ = form.WriteField("chat_id", c.chatID)
+	_ = form.WriteField("caption", caption)
+	part, err := form.CreateFormFile("document", filename)
+	if err != nil {
+		return 0, fmt.Errorf("构造上传内容失败: %w", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		return 0, fmt.Errorf("构造上传内容失败: %w", err)
+	}
+	if err := form.Close(); err != nil {
+		return 0, fmt.Errorf("构造上传内容失败: %w", err)
+	}
+	payload := body.Bytes()
+	return c.call(ctx, "sendDocument", form.FormDataContentType(), func() io.Reader { return bytes.NewReader(payload) })
+}
+
+// call 调用 Bot API 方法并返回消息 ID。遇到限流时按 retry_after 等待后重试, 避免分段消息重复发送。
+func (c *Client) call(ctx context.Context, method, contentType string, body func() io.Reader) (int64, error) {
+	endpoint := fmt.Sprintf("%s/bot%s/%s", c.baseURL, c.token, method)
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, body())
+		if err != nil {
+			return 0, fmt.Errorf("构造 Telegram 请求失败: %w", err)
+		}
+		req.Header.Set("Content-Type", contentType)
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			// 错误信息中的地址包含 Bot Token, 不直接透出。
+			return 0, fmt.Errorf("调用 Telegram 接口失败: %s", strings.ReplaceAll(err.Error(), c.token, "***"))
+		}
+		raw := targets.ReadBody(resp.Body)
+		resp.Body.Close()
+
+		var parsed apiResponse
+		_ = json.Unmarshal([]byte(raw), &parsed)
+		if resp.StatusCode == http.StatusOK && parsed.OK {
+			var sent sentMessage
+			if err := json.Unmarshal(parsed.Result, &sent); err != nil {
+				return 0, fmt.Errorf("解析 Telegram 响应失败: %w", err)
+			}
+			return sent.MessageID, nil
+		}
+
+		wait := time.Duration(parsed.Parameters.RetryAfter) * time.Second
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < maxAttempts && wait <= maxRetryAfter {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return 0, ctx.Err()
+			case <-timer.C:
+			}
+			continue
+		}
+		message := strings.TrimSpace(firstNonEmpty(parsed.Description, raw))
+		return 0, &targets.StatusError{Action: "发送 Telegram 消息", Status: resp.StatusCode, Message: message}
+	}
+}
+
+// Capabilities 返回 Telegram 的发送限制。
+func (c *Client) Capabilities() targets.Capabilities {
+	return capabilities
+}
+
+// messageURL 为频道与超级群组生成消息链接: 公开频道使用 @username, 私有频道使用 -100 开头的数字 ID。
+func (c *Client) messageURL(messageID string) string {
+	if username, ok := strings.CutPrefix(c.chatID, "@"); ok {
+		return fmt.Sprintf("https://t.me/%s/%s", username, messageID)
+	}
+	if internal, ok := strings.CutPrefix(c.chatID, "-100"); ok {
+		return fmt.Sprintf("https://t.me/c/%s/%s", internal, messageID)
+	}
+	return ""
+}
+
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
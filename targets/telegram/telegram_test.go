@@ -0,0 +1,257 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+func TestNormalizeMode(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ModeFull},
+		{"full", ModeFull},
+		{" Summary ", ModeSummary},
+		{"brief", ModeFull},
+	}
+	for _, tt := range tests {
+		if got := NormalizeMode(tt.value); got != tt.want {
+			t.Errorf("NormalizeMode(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestMessageURL(t *testing.T) {
+	tests := []struct {
+		chatID string
+		want   string
+	}{
+		{"@my_channel", "https://t.me/my_channel/42"},
+		{"-1001234567890", "https://t.me/c/1234567890/42"},
+		{"-4567", ""},
+		{"123456", ""},
+	}
+	for _, tt := range tests {
+		c := &Client{chatID: tt.chatID}
+		if got := c.messageURL("42"); got != tt.want {
+			t.Errorf("messageURL(%s) = %q, want %q", tt.chatID, got, tt.want)
+		}
+	}
+}
+
+func TestSplitMessage(t *testing.T) {
+	long := strings.Repeat(strings.Repeat("字", 99)+"\n\n", 60)
+	tests := []struct {
+		name      string
+		text      string
+		wantParts int
+	}{
+		{name: "空内容", text: " \n ", wantParts: 1},
+		{name: "短消息", text: "你好", wantParts: 1},
+		{name: "超过单条上限", text: long, wantParts: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := splitMessage(tt.text)
+			if len(parts) != tt.wantParts {
+				t.Fatalf("分段数 = %d, want %d", len(parts), tt.wantParts)
+			}
+			for _, part := range parts {
+				if part == "" || part != strings.TrimSpace(part) || utf8.RuneCountInString(part) > capabilities.MaxBodyLength {
+					t.Errorf("分段不符合要求: 长度 %d", utf8.RuneCountInString(part))
+				}
+			}
+		})
+	}
+}
+
+func TestSummary(t *testing.T) {
+	tests := []struct {
+		name  string
+		conv  export.Conversation
+		check func(s string) bool
+	}{
+		{
+			name: "标题、消息数与首条提问",
+			conv: export.Conversation{ID: "c1", Title: "旅行计划", Messages: []export.Message{
+				{Role: "assistant", Text: "欢迎"},
+				{Role: "user", Text: "  去哪里玩?  "},
+				{Role: "user", Text: "第二个问题"},
+			}},
+			check: func(s string) bool {
+				return strings.HasPrefix(s, "旅行计划\n\n") && strings.Contains(s, "消息数: 3\n") &&
+					strings.HasSuffix(s, "\n去哪里玩?") && !strings.Contains(s, "第二个问题")
+			},
+		},
+		{
+			name:  "缺少标题",
+			conv:  export.Conversation{ID: "c1"},
+			check: func(s string) bool { return strings.HasPrefix(s, "对话 c1\n") },
+		},
+		{
+			name:  "不超过说明文字上限",
+			conv:  export.Conversation{ID: "c1", Title: "长", Messages: []export.Message{{Role: "user", Text: strings.Repeat("问", 2000)}}},
+			check: func(s string) bool { return utf8.RuneCountInString(s) == captionLimit && strings.HasSuffix(s, "…") },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := summary(tt.conv, "UTC"); !tt.check(got) {
+				t.Errorf("summary() = %q", got)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{name: "完整配置", cfg: Config{Token: "123:abc", ChatID: "@c"}},
+		{name: "缺少 Token", cfg: Config{ChatID: "@c"}, wantErr: true},
+		{name: "缺少 Chat ID", cfg: Config{Token: "123:abc", ChatID: " "}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && client.baseURL != defaultBaseURL {
+				t.Errorf("baseURL = %q", client.baseURL)
+			}
+		})
+	}
+}
+
+// fakeBotAPI 记录收到的 Bot API 调用, 按顺序返回递增的消息 ID; fail 非零时以该状态码拒绝第 failAt 次调用。
+type fakeBotAPI struct {
+	mu     sync.Mutex
+	calls  []string
+	texts  []string
+	files  []string
+	fail   int
+	failAt int
+}
+
+func (f *fakeBotAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	f.calls = append(f.calls, method)
+	if f.fail != 0 && len(f.calls) == f.failAt {
+		w.WriteHeader(f.fail)
+		io.WriteString(w, `{"ok":false,"description":"Bad Request: chat not found"}`)
+		return
+	}
+	switch method {
+	case "sendMessage":
+		var payload struct {
+			ChatID string `json:"chat_id"`
+			Text   string `json:"text"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		f.texts = append(f.texts, payload.Text)
+	case "sendDocument":
+		file, header, err := r.FormFile("document")
+		if err == nil {
+			data, _ := io.ReadAll(file)
+			f.files = append(f.files, header.Filename+":"+r.FormValue("caption")+":"+string(data))
+		}
+	}
+	fmt.Fprintf(w, `{"ok":true,"result":{"message_id":%d}}`, 100+len(f.calls))
+}
+
+func TestCreateConversation(t *testing.T) {
+	short := export.Conversation{ID: "c1", Title: "短对话", Messages: []export.Message{{Role: "user", Text: "你好"}}}
+	long := export.Conversation{ID: "c2", Title: "长对话", Messages: []export.Message{{Role: "assistant", Text: strings.Repeat(strings.Repeat("字", 99)+"\n\n", 60)}}}
+	tests := []struct {
+		name       string
+		mode       string
+		conv       export.Conversation
+		fail       int
+		failAt     int
+		wantCalls  []string
+		wantID     string
+		wantStatus int
+		wantErr    string
+	}{
+		{name: "完整正文单条消息", mode: ModeFull, conv: short, wantCalls: []string{"sendMessage"}, wantID: "101"},
+		{name: "长正文拆分并标注序号", mode: ModeFull, conv: long, wantCalls: []string{"sendMessage", "sendMessage"}, wantID: "101"},
+		{name: "摘要附带文件", mode: ModeSummary, conv: short, wantCalls: []string{"sendDocument"}, wantID: "101"},
+		{name: "首条消息被拒绝", mode: ModeFull, conv: long, fail: http.StatusBadRequest, failAt: 1, wantCalls: []string{"sendMessage"}, wantStatus: http.StatusBadRequest},
+		{name: "分段中途失败", mode: ModeFull, conv: long, fail: http.StatusBadRequest, failAt: 2, wantCalls: []string{"sendMessage", "sendMessage"}, wantStatus: http.StatusBadRequest, wantErr: "已发送 1/2 段后失败"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeBotAPI{fail: tt.fail, failAt: tt.failAt}
+			server := httptest.NewServer(api)
+			defer server.Close()
+			client, err := New(Config{Token: "123:secret", ChatID: "@backup", Mode: tt.mode, BaseURL: server.URL + "/"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			obj, err := client.CreateConversation(context.Background(), tt.conv, "UTC")
+			if strings.Join(api.calls, ",") != strings.Join(tt.wantCalls, ",") {
+				t.Errorf("调用 = %v, want %v", api.calls, tt.wantCalls)
+			}
+			if tt.wantStatus != 0 {
+				var statusErr *targets.StatusError
+				if !errors.As(err, &statusErr) || statusErr.Status != tt.wantStatus || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want 状态码 %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if obj.ID != tt.wantID || obj.URL != "https://t.me/backup/"+tt.wantID {
+				t.Errorf("Object = %+v", obj)
+			}
+			if len(api.texts) > 1 {
+				for i, text := range api.texts {
+					if prefix := fmt.Sprintf("(%d/%d)\n", i+1, len(api.texts)); !strings.HasPrefix(text, prefix) {
+						t.Errorf("第 %d 段缺少序号 %q", i+1, prefix)
+					}
+				}
+			}
+			if tt.mode == ModeSummary && len(api.files) != 1 {
+				t.Fatalf("上传文件数 = %d, want 1", len(api.files))
+			}
+			for _, file := range api.files {
+				if !strings.HasPrefix(file, "短对话-c1.md:短对话\n") || !strings.Contains(file, "你好") {
+					t.Errorf("上传的文件 = %q", file)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateConversationHidesToken(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	baseURL := server.URL
+	server.Close()
+	client, err := New(Config{Token: "123:secret-token", ChatID: "@c", BaseURL: baseURL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.CreateConversation(context.Background(), export.Conversation{ID: "c1"}, "UTC")
+	if err == nil || strings.Contains(err.Error(), "secret-token") {
+		t.Fatalf("err = %v, 应失败且不包含 Bot Token", err)
+	}
+}
@@ -0,0 +1,121 @@
+// Package trilium 通过 ETAPI 在自托管 Trilium Notes 中为每个对话创建一篇文本笔记。
+package trilium
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/httpc"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+const defaultParentNoteID = "root"
+
+// Config 是创建 Client 所需的 Trilium 参数。
+type Config struct {
+	// BaseURL 为 Trilium 实例地址, 如 http://localhost:8080。
+	BaseURL string
+	// Token 为 选项 → ETAPI 中创建的令牌。
+	Token string
+	// ParentNoteID 为新笔记所在的父笔记, 为空时放在根目录下。
+	ParentNoteID string
+	HTML         export.HTMLOptions
+}
+
+// Client 通过 ETAPI 为每个对话创建一篇笔记。
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+	parentID   string
+	html       export.HTMLOptions
+}
+
+type createNoteRequest struct {
+	ParentNoteID string `json:"parentNoteId"`
+	Title        string `json:"title"`
+	Type         string `json:"type"`
+	Content      string `json:"content"`
+}
+
+type createNoteResponse struct {
+	Note struct {
+		NoteID string `json:"noteId"`
+	} `json:"note"`
+}
+
+// New 校验配置并创建 Client。
+func New(cfg Config) (*Client, error) {
+	baseURL := strings.TrimRight(strings.TrimSpace(cfg.BaseURL), "/")
+	if baseURL == "" {
+		return nil, fmt.Errorf("缺少 Trilium 地址: 请在配置页填写 trilium_base_url")
+	}
+	if parsed, err := url.Parse(baseURL); err != nil || !parsed.IsAbs() {
+		return nil, fmt.Errorf("Trilium 地址无效: %s", cfg.BaseURL)
+	}
+	token := strings.TrimSpace(cfg.Token)
+	if token == "" {
+		return nil, fmt.Errorf("缺少 Trilium ETAPI 令牌: 请在配置页填写 trilium_token")
+	}
+	parentID := strings.TrimSpace(cfg.ParentNoteID)
+	if parentID == "" {
+		parentID = defaultParentNoteID
+	}
+	return &Client{
+		httpClient: httpc.Client(),
+		baseURL:    baseURL,
+		token:      token,
+		parentID:   parentID,
+		html:       cfg.HTML,
+	}, nil
+}
+
+// CreateConversation 创建文本笔记, 返回笔记 ID 与网页端链接。
+func (c *Client) CreateConversation(ctx context.Context, conv export.Conversation, timezone string) (targets.Object, error) {
+	title := strings.TrimSpace(conv.Title)
+	if title == "" {
+		title = fmt.Sprintf("对话 %s", conv.ID)
+	}
+	data, err := json.Marshal(createNoteRequest{
+		ParentNoteID: c.parentID,
+		Title:        title,
+		Type:         "text",
+		Content:      export.RenderHTMLFragment(conv, timezone, c.html),
+	})
+	if err != nil {
+		return targets.Object{}, fmt.Errorf("序列化 Trilium 请求失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/etapi/create-note", bytes.NewReader(data))
+	if err != nil {
+		return targets.Object{}, fmt.Errorf("构造 Trilium 请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// ETAPI 直接使用令牌作为 Authorization 头, 不带 Bearer 前缀。
+	req.Header.Set("Authorization", c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return targets.Object{}, fmt.Errorf("调用 Trilium 接口失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return targets.Object{}, &targets.StatusError{Action: "创建 Trilium 笔记", Status: resp.StatusCode, Message: strings.TrimSpace(targets.ReadBody(resp.Body))}
+	}
+	var result createNoteResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return targets.Object{}, fmt.Errorf("解析 Trilium 响应失败: %w", err)
+	}
+	if result.Note.NoteID == "" {
+		return targets.Object{}, fmt.Errorf("Trilium 未返回笔记 ID")
+	}
+	noteID := result.Note.NoteID
+	return targets.Object{ID: noteID, URL: fmt.Sprintf("%s/#root/%s", c.baseURL, noteID)}, nil
+}
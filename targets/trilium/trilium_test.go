@@ -0,0 +1,105 @@
+package trilium
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        Config
+		wantParent string
+		wantErr    bool
+	}{
+		{name: "默认放在根笔记下", cfg: Config{BaseURL: "http://localhost:8080/", Token: "t"}, wantParent: "root"},
+		{name: "指定父笔记", cfg: Config{BaseURL: "http://localhost:8080", Token: "t", ParentNoteID: " abc123 "}, wantParent: "abc123"},
+		{name: "缺少地址", cfg: Config{Token: "t"}, wantErr: true},
+		{name: "相对地址", cfg: Config{BaseURL: "trilium.example.com/etapi", Token: "t"}, wantErr: true},
+		{name: "缺少令牌", cfg: Config{BaseURL: "http://localhost:8080"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (client.parentID != tt.wantParent || strings.HasSuffix(client.baseURL, "/")) {
+				t.Errorf("parentID = %q baseURL = %q", client.parentID, client.baseURL)
+			}
+		})
+	}
+}
+
+func TestCreateConversation(t *testing.T) {
+	tests := []struct {
+		name       string
+		title      string
+		status     int
+		response   string
+		wantTitle  string
+		wantID     string
+		wantStatus int
+		wantErr    bool
+	}{
+		{name: "创建成功", title: "标题", status: http.StatusCreated, response: `{"note":{"noteId":"n1"}}`, wantTitle: "标题", wantID: "n1"},
+		{name: "缺少标题时使用对话 ID", title: " ", status: http.StatusOK, response: `{"note":{"noteId":"n2"}}`, wantTitle: "对话 c1", wantID: "n2"},
+		{name: "未返回笔记 ID", title: "标题", status: http.StatusCreated, response: `{"note":{}}`, wantTitle: "标题", wantErr: true},
+		{name: "父笔记不存在", title: "标题", status: http.StatusNotFound, response: `{"code":"NOTE_NOT_FOUND"}`, wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got createNoteRequest
+			var auth string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/etapi/create-note" {
+					http.NotFound(w, r)
+					return
+				}
+				auth = r.Header.Get("Authorization")
+				json.NewDecoder(r.Body).Decode(&got)
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.response)
+			}))
+			defer server.Close()
+
+			client, err := New(Config{BaseURL: server.URL, Token: "etapi-token", ParentNoteID: "p1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			conv := export.Conversation{ID: "c1", Title: tt.title, Messages: []export.Message{{Role: "user", Text: "你好"}}}
+			obj, err := client.CreateConversation(context.Background(), conv, "UTC")
+			if tt.wantStatus != 0 {
+				var statusErr *targets.StatusError
+				if !errors.As(err, &statusErr) || statusErr.Status != tt.wantStatus {
+					t.Fatalf("err = %v, want 状态码 %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if auth != "etapi-token" {
+				t.Errorf("Authorization = %q, want 不带 Bearer 的令牌", auth)
+			}
+			if got.ParentNoteID != "p1" || got.Type != "text" || got.Title != tt.wantTitle || !strings.Contains(got.Content, "你好") {
+				t.Errorf("请求 = %+v", got)
+			}
+			if tt.wantErr {
+				return
+			}
+			if obj.ID != tt.wantID || obj.URL != server.URL+"/#root/"+tt.wantID {
+				t.Errorf("Object = %+v", obj)
+			}
+		})
+	}
+}
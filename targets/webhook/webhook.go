@@ -0,0 +1,205 @@
+// Package webhook 把对话以模板生成的 JSON 请求体 POST 到任意地址, 用于对接没有专用客户端的服务。
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/httpc"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+// DefaultTemplate 是未配置模板时使用的请求体。
+const DefaultTemplate = `{"id": {{json .ID}}, "title": {{json .Title}}, "create_time": {{json .CreateTime}}, "update_time": {{json .UpdateTime}}, "markdown": {{json .Markdown}}, "conversation": {{json .Conversation}}}`
+
+// Config 是创建 Client 所需的 webhook 参数。
+type Config struct {
+	URL string
+	// Headers 为附加请求头, 每行一个 "Name: Value"。
+	Headers string
+	// Template 为 text/template 语法的请求体模板, 为空时使用 DefaultTemplate。
+	Template string
+	// IDPath 与 URLPath 为响应 JSON 中对象 ID 与链接的字段路径, 以 "." 分隔, 数字表示数组下标,
+	// 例如 "records.0.id"; 为空时分别读取 id 与 url。
+	IDPath  string
+	URLPath string
+}
+
+// Client 为每个对话发送一次 webhook 请求。
+type Client struct {
+	httpClient *http.Client
+	url        string
+	headers    http.Header
+	template   *template.Template
+	idPath     string
+	urlPath    string
+}
+
+// TemplateData 是请求体模板可用的数据, 时间已按导出时区格式化。
+type TemplateData struct {
+	ID           string
+	Title        string
+	CreateTime   string
+	UpdateTime   string
+	Timezone     string
+	Markdown     string
+	Conversation export.Conversation
+}
+
+var templateFuncs = template.FuncMap{
+	// json 把任意值编码为 JSON, 字符串会带上引号并正确转义。
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	},
+}
+
+// New 校验配置并创建 Client。
+func New(cfg Config) (*Client, error) {
+	target := strings.TrimSpace(cfg.URL)
+	if target == "" {
+		return nil, fmt.Errorf("缺少 Webhook 地址: 请在配置页填写 webhook_url")
+	}
+	if parsed, err := url.Parse(target); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("Webhook 地址无效: %s", target)
+	}
+	headers, err := parseHeaders(cfg.Headers)
+	if err != nil {
+		return nil, err
+	}
+	text := cfg.Template
+	if strings.TrimSpace(text) == "" {
+		text = DefaultTemplate
+	}
+	tmpl, err := template.New("webhook").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("解析 Webhook 模板失败: %w", err)
+	}
+	return &Client{
+		httpClient: httpc.Client(),
+		url:        target,
+		headers:    headers,
+		template:   tmpl,
+		idPath:     firstNonEmpty(strings.TrimSpace(cfg.IDPath), "id"),
+		urlPath:    firstNonEmpty(strings.TrimSpace(cfg.URLPath), "url"),
+	}, nil
+}
+
+// CreateConversation 渲染请求体并发送, 非 2xx 响应返回 targets.StatusError 以便按限流/服务端错误重试。
+func (c *Client) CreateConversation(ctx context.Context, conv export.Conversation, timezone string) (targets.Object, error) {
+	body, err := c.Render(conv, timezone)
+	if err != nil {
+		return targets.Object{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return targets.Object{}, fmt.Errorf("构造 Webhook 请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, values := range c.headers {
+		req.Header[name] = values
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return targets.Object{}, fmt.Errorf("调用 Webhook 失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	message := targets.ReadBody(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return targets.Object{}, &targets.StatusError{Action: "调用 Webhook", Status: resp.StatusCode, Message: strings.TrimSpace(message)}
+	}
+
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(message), &parsed); err != nil {
+		return targets.Object{}, nil
+	}
+	return targets.Object{ID: lookupPath(parsed, c.idPath), URL: lookupPath(parsed, c.urlPath)}, nil
+}
+
+// Render 生成对话的请求体, 结果必须是合法 JSON。
+func (c *Client) Render(conv export.Conversation, timezone string) ([]byte, error) {
+	tf := export.NewTimeFormatter(conv, export.ResolveLocation(timezone))
+	data := TemplateData{
+		ID:           conv.ID,
+		Title:        conv.Title,
+		CreateTime:   tf.Format(conv.CreateTime),
+		UpdateTime:   tf.Format(conv.UpdateTime),
+		Timezone:     timezone,
+		Markdown:     export.RenderMarkdown(conv, timezone),
+		Conversation: conv,
+	}
+	var buf bytes.Buffer
+	if err := c.template.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("渲染 Webhook 模板失败: %w", err)
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("Webhook 模板生成的请求体不是合法 JSON")
+	}
+	return buf.Bytes(), nil
+}
+
+func parseHeaders(raw string) (http.Header, error) {
+	headers := make(http.Header)
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("Webhook 请求头格式无效, 应为 \"Name: Value\": %s", line)
+		}
+		headers.Add(name, strings.TrimSpace(value))
+	}
+	return headers, nil
+}
+
+// lookupPath 按 "a.0.b" 形式的路径读取 JSON 值, 找不到或不是标量时返回空字符串。
+func lookupPath(value interface{}, path string) string {
+	for _, key := range strings.Split(path, ".") {
+		switch node := value.(type) {
+		case map[string]interface{}:
+			value = node[key]
+		case []interface{}:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(node) {
+				return ""
+			}
+			value = node[idx]
+		default:
+			return ""
+		}
+	}
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
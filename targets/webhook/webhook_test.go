@@ -0,0 +1,194 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+func TestParseHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    http.Header
+		wantErr bool
+	}{
+		{name: "空配置", raw: "", want: http.Header{}},
+		{
+			name: "多行与重复请求头",
+			raw:  "Authorization: Bearer abc\n\n  X-Tag: a \nx-tag: b",
+			want: http.Header{"Authorization": {"Bearer abc"}, "X-Tag": {"a", "b"}},
+		},
+		{name: "值中包含冒号", raw: "X-Time: 12:00", want: http.Header{"X-Time": {"12:00"}}},
+		{name: "缺少冒号", raw: "Authorization Bearer", wantErr: true},
+		{name: "缺少名称", raw: ": value", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseHeaders(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseHeaders() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookupPath(t *testing.T) {
+	var value interface{}
+	if err := json.Unmarshal([]byte(`{"id":"p1","n":42,"records":[{"id":"rec1","fields":{"url":"https://x"}}],"ok":true}`), &value); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"id", "p1"},
+		{"n", "42"},
+		{"records.0.id", "rec1"},
+		{"records.0.fields.url", "https://x"},
+		{"records.1.id", ""},
+		{"records.x.id", ""},
+		{"records", ""},
+		{"ok", ""},
+		{"missing.id", ""},
+	}
+	for _, tt := range tests {
+		if got := lookupPath(value, tt.path); got != tt.want {
+			t.Errorf("lookupPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{name: "默认模板", cfg: Config{URL: "https://example.com/hook"}},
+		{name: "缺少地址", cfg: Config{}, wantErr: true},
+		{name: "不支持的协议", cfg: Config{URL: "ftp://example.com"}, wantErr: true},
+		{name: "缺少主机", cfg: Config{URL: "http:///hook"}, wantErr: true},
+		{name: "请求头无效", cfg: Config{URL: "https://example.com", Headers: "bad"}, wantErr: true},
+		{name: "模板语法错误", cfg: Config{URL: "https://example.com", Template: `{"id": {{.ID}`}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("New() err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	conv := export.Conversation{ID: "c1", Title: `引号 "与" 换行` + "\n", CreateTime: 1709283600}
+	tests := []struct {
+		name     string
+		template string
+		want     map[string]interface{}
+		wantErr  bool
+	}{
+		{
+			name:     "json 函数转义字符串",
+			template: `{"id": {{json .ID}}, "title": {{json .Title}}, "tz": {{json .Timezone}}}`,
+			want:     map[string]interface{}{"id": "c1", "title": `引号 "与" 换行` + "\n", "tz": "UTC"},
+		},
+		{
+			name:     "按时区格式化时间",
+			template: `{"created": {{json .CreateTime}}}`,
+			want:     map[string]interface{}{"created": "2024-03-01 09:00:00"},
+		},
+		{name: "结果不是合法 JSON", template: `{"id": {{.Title}}}`, wantErr: true},
+		{name: "引用不存在的字段", template: `{"id": {{json .Missing}}}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := New(Config{URL: "https://example.com/hook", Template: tt.template})
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := client.Render(conv, "UTC")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var got map[string]interface{}
+			if err := json.Unmarshal(body, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Render() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateConversation(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		response   string
+		idPath     string
+		urlPath    string
+		want       targets.Object
+		wantStatus int
+	}{
+		{name: "默认字段", status: http.StatusOK, response: `{"id":"p1","url":"https://x/p1"}`, want: targets.Object{ID: "p1", URL: "https://x/p1"}},
+		{name: "自定义字段路径", status: http.StatusCreated, response: `{"records":[{"id":"rec1"}]}`, idPath: "records.0.id", want: targets.Object{ID: "rec1"}},
+		{name: "响应不是 JSON", status: http.StatusNoContent, response: ""},
+		{name: "服务端错误", status: http.StatusBadGateway, response: "upstream down", wantStatus: http.StatusBadGateway},
+		{name: "请求被拒绝", status: http.StatusBadRequest, response: "bad template", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotHeader http.Header
+			var gotBody []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotHeader = r.Header.Clone()
+				gotBody, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.response)
+			}))
+			defer server.Close()
+
+			client, err := New(Config{URL: server.URL, Headers: "X-Api-Key: k1", IDPath: tt.idPath, URLPath: tt.urlPath})
+			if err != nil {
+				t.Fatal(err)
+			}
+			obj, err := client.CreateConversation(context.Background(), export.Conversation{ID: "c1", Title: "T"}, "UTC")
+			var statusErr *targets.StatusError
+			if tt.wantStatus != 0 {
+				if !errors.As(err, &statusErr) || statusErr.Status != tt.wantStatus {
+					t.Fatalf("err = %v, want 状态码 %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if obj.ID != tt.want.ID || obj.URL != tt.want.URL {
+				t.Errorf("Object = %+v, want %+v", obj, tt.want)
+			}
+			if gotHeader.Get("X-Api-Key") != "k1" || gotHeader.Get("Content-Type") != "application/json" {
+				t.Errorf("请求头 = %v", gotHeader)
+			}
+			if !json.Valid(gotBody) {
+				t.Errorf("请求体不是合法 JSON: %s", gotBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Devoty/openai-backup/export"
+	"github.com/Devoty/openai-backup/httpc"
+	"github.com/Devoty/openai-backup/targets"
+)
+
+const targetStatsSchema = `
+	CREATE TABLE IF NOT EXISTS target_stats (
+		target TEXT PRIMARY KEY,
+		succeeded INTEGER NOT NULL DEFAULT 0,
+		failed INTEGER NOT NULL DEFAULT 0,
+		bytes_uploaded INTEGER NOT NULL DEFAULT 0,
+		latency_ms INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT '',
+		last_success_at TIMESTAMP,
+		last_failure_at TIMESTAMP,
+		since TIMESTAMP NOT NULL
+	);`
+
+// targetWrite 是对导出目标的一次写入尝试, 熔断器重试时每次尝试分别记录。
+type targetWrite struct {
+	Target  string
+	Err     error
+	Bytes   int64
+	Latency time.Duration
+}
+
+// targetStats 是一个导出目标自 Since 起累计的写入统计。
+type targetStats struct {
+	Target        string     `json:"target"`
+	Attempts      int64      `json:"attempts"`
+	Succeeded     int64      `json:"succeeded"`
+	Failed        int64      `json:"failed"`
+	ErrorRate     float64    `json:"error_rate"`
+	BytesUploaded int64      `json:"bytes_uploaded"`
+	AvgLatencyMS  int64      `json:"avg_latency_ms"`
+	LastError     string     `json:"last_error,omitempty"`
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
+	LastFailureAt *time.Time `json:"last_failure_at,omitempty"`
+	Since         time.Time  `json:"since"`
+}
+
+// RecordTargetWrite 把一次写入尝试累加到目标的统计中。
+func (s *ConfigStore) RecordTargetWrite(ctx context.Context, write targetWrite) error {
+	if s == nil || s.archive == nil {
+		return errors.New("配置存储未初始化")
+	}
+	now := time.Now().UTC()
+	succeeded, failed, lastError := 1, 0, ""
+	var lastSuccess, lastFailure interface{} = now, nil
+	if write.Err != nil {
+		succeeded, failed, lastError = 0, 1, write.Err.Error()
+		lastSuccess, lastFailure = nil, now
+	}
+	_, err := s.archive.writer.ExecContext(ctx, `
+		INSERT INTO target_stats(target, succeeded, failed, bytes_uploaded, latency_ms, last_error, last_success_at, last_failure_at, since)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(target) DO UPDATE SET
+			succeeded=target_stats.succeeded+excluded.succeeded,
+			failed=target_stats.failed+excluded.failed,
+			bytes_uploaded=target_stats.bytes_uploaded+excluded.bytes_uploaded,
+			latency_ms=target_stats.latency_ms+excluded.latency_ms,
+			last_error=CASE WHEN excluded.failed > 0 THEN excluded.last_error ELSE target_stats.last_error END,
+			last_success_at=COALESCE(excluded.last_success_at, target_stats.last_success_at),
+			last_failure_at=COALESCE(excluded.last_failure_at, target_stats.last_failure_at)
+	`, write.Target, succeeded, failed, write.Bytes, write.Latency.Milliseconds(), lastError, lastSuccess, lastFailure, now)
+	if err != nil {
+		return fmt.Errorf("写入目标统计失败: %w", err)
+	}
+	return nil
+}
+
+// TargetStats 返回各目标的累计统计, 按目标名排序; 没有写入记录的目标不出现在结果中。
+func (s *ConfigStore) TargetStats(ctx context.Context) ([]targetStats, error) {
+	if s == nil || s.archive == nil {
+		return nil, errors.New("配置存储未初始化")
+	}
+	rows, err := s.archive.reader.QueryContext(ctx, `
+		SELECT target, succeeded, failed, bytes_uploaded, latency_ms, last_error, last_success_at, last_failure_at, since
+		FROM target_stats ORDER BY target`)
+	if err != nil {
+		return nil, fmt.Errorf("读取目标统计失败: %w", err)
+	}
+	defer rows.Close()
+	var result []targetStats
+	for rows.Next() {
+		var (
+			item                     targetStats
+			latency                  int64
+			lastSuccess, lastFailure sql.NullTime
+		)
+		if err := rows.Scan(&item.Target, &item.Succeeded, &item.Failed, &item.BytesUploaded, &latency, &item.LastError, &lastSuccess, &lastFailure, &item.Since); err != nil {
+			return nil, fmt.Errorf("解析目标统计失败: %w", err)
+		}
+		item.Attempts = item.Succeeded + item.Failed
+		if item.Attempts > 0 {
+			item.ErrorRate = float64(item.Failed) / float64(item.Attempts)
+			item.AvgLatencyMS = latency / item.Attempts
+		}
+		if lastSuccess.Valid {
+			item.LastSuccessAt = &lastSuccess.Time
+		}
+		if lastFailure.Valid {
+			item.LastFailureAt = &lastFailure.Time
+		}
+		result = append(result, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取目标统计失败: %w", err)
+	}
+	return result, nil
+}
+
+// ResetTargetStats 清空目标的统计, target 为空时清空全部, 返回删除的记录数。
+func (s *ConfigStore) ResetTargetStats(ctx context.Context, target string) (int64, error) {
+	if s == nil || s.archive == nil {
+		return 0, errors.New("配置存储未初始化")
+	}
+	query, args := `DELETE FROM target_stats`, []interface{}{}
+	if target != "" {
+		query, args = query+` WHERE target = ?`, append(args, target)
+	}
+	res, err := s.archive.writer.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("清空目标统计失败: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// createWithStats 调用 exporter 写入一条对话, 并把耗时、请求体字节数与结果计入目标统计。
+// 任务取消导致的失败不计入。
+func (s *webServer) createWithStats(ctx context.Context, target string, exporter targets.Exporter, conv export.Conversation, timezone string) (targets.Object, error) {
+	counted, counter := httpc.CountUploads(ctx)
+	started := time.Now()
+	object, err := exporter.CreateConversation(counted, conv, timezone)
+	if s.store != nil && ctx.Err() == nil {
+		write := targetWrite{Target: target, Err: err, Bytes: counter.Bytes(), Latency: time.Since(started)}
+		if err := s.store.RecordTargetWrite(ctx, write); err != nil {
+			logInfo("记录目标统计失败: %v", err)
+		}
+	}
+	return object, err
+}
+
+// handleTargetStats 处理 /api/stats/targets: GET 返回各目标的累计写入统计;
+// DELETE 清空统计, ?target= 只清空指定目标。
+func (s *webServer) handleTargetStats(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		stats, err := s.store.TargetStats(r.Context())
+		if err != nil {
+			writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "读取目标统计失败", err)
+			return
+		}
+		if stats == nil {
+			stats = []targetStats{}
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"targets": stats})
+	case http.MethodDelete:
+		target := strings.TrimSpace(r.URL.Query().Get("target"))
+		if target != "" {
+			target = normalizeExportTarget(target)
+		}
+		removed, err := s.store.ResetTargetStats(r.Context(), target)
+		if err != nil {
+			writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "清空目标统计失败", err)
+			return
+		}
+		logInfo("目标统计已清空: target=%s", firstNonEmpty(target, "(全部)"))
+		writeJSON(w, http.StatusOK, map[string]interface{}{"removed": removed})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+)
+
+const (
+	// testExportTitlePrefix 与 testExportTag 标记测试导出的对象, 便于在目标中找到并删除。
+	testExportTitlePrefix = "[测试导出] "
+	testExportTag         = "openai-backup-test"
+
+	testSampleRecent   = "recent"
+	testSampleRandom   = "random"
+	defaultTestSamples = 3
+	maxTestSamples     = 20
+)
+
+// testExportRequest 是 POST /api/import/test 的请求体。Mode 为 recent (最近更新) 或 random, 默认 recent;
+// Refresh 为 true 时抽取前完整抓取一遍对话列表。
+type testExportRequest struct {
+	Count   int    `json:"count"`
+	Mode    string `json:"mode"`
+	Target  string `json:"target"`
+	Refresh bool   `json:"refresh"`
+}
+
+// sampleConversations 从对话索引中选出 count 条对话: recent 取最近更新的, random 随机抽取。
+func (s *webServer) sampleConversations(ctx context.Context, mode string, count int, refresh bool) ([]exportItem, error) {
+	if _, err := s.refreshConversationIndex(ctx, refresh); err != nil {
+		local, countErr := s.store.CountLocalConversations(ctx)
+		if !errors.Is(err, errChatGPTTokenMissing) || countErr != nil || local == 0 {
+			return nil, err
+		}
+	}
+	metas, err := s.store.QueryConversationIndex(ctx, 0, 0, "")
+	if err != nil {
+		return nil, err
+	}
+	if mode == testSampleRandom {
+		rand.Shuffle(len(metas), func(i, j int) { metas[i], metas[j] = metas[j], metas[i] })
+	}
+	items := make([]exportItem, 0, count)
+	for _, meta := range metas[:min(count, len(metas))] {
+		items = append(items, exportItem{ID: meta.ID, Title: meta.Title, Test: true})
+	}
+	return items, nil
+}
+
+// handleTestExport 处理 POST /api/import/test: 抽取几条对话完整导出到目标, 用于在全量导出前检查格式与凭据。
+// 标题加 testExportTitlePrefix 前缀并追加 testExportTag 标签; 不记录导出状态、失败记录与历史版本, 不影响后续导出。
+func (s *webServer) handleTestExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+		return
+	}
+	var req testExportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorDetail(w, http.StatusBadRequest, errCodeInvalidRequest, "请求体解析失败", err)
+		return
+	}
+	mode := strings.ToLower(strings.TrimSpace(req.Mode))
+	switch mode {
+	case "":
+		mode = testSampleRecent
+	case testSampleRecent, testSampleRandom:
+	default:
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "mode 只支持 recent 或 random")
+		return
+	}
+	count := req.Count
+	if count <= 0 {
+		count = defaultTestSamples
+	}
+	if count > maxTestSamples {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, fmt.Sprintf("测试导出最多 %d 条对话", maxTestSamples))
+		return
+	}
+
+	cfg := s.configSnapshot()
+	target := strings.TrimSpace(req.Target)
+	if target == "" {
+		target = cfg.ExportTarget
+	}
+	target = normalizeExportTarget(target)
+	exporter, label, err := s.resolveExporter(target)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errCodeTargetMisconfigured, err.Error())
+		return
+	}
+
+	items, err := s.sampleConversations(r.Context(), mode, count, req.Refresh)
+	if err != nil {
+		writeAPIError(w, chatgptError("读取对话列表失败", err))
+		return
+	}
+	if len(items) == 0 {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "没有可用于测试的对话")
+		return
+	}
+
+	logInfo("测试导出触发: 抽取=%d 方式=%s 目标=%s", len(items), mode, target)
+	job := s.jobs.start("test", target)
+	result, syncErr := s.syncConversations(r.Context(), job, target, label, exporter, s.fetchExportConversation, items, cfg.OutputTimezone)
+	job.recordSync(target, result)
+	s.jobs.finish(job, syncErr, s.locationSnapshot())
+
+	if len(result.Exported) == 0 && len(result.Failed) > 0 {
+		if first := result.Failed[0]; first.fetch {
+			writeAPIError(w, chatgptError(fmt.Sprintf("获取对话 %s 详情失败", first.ConversationID), first.err))
+		} else {
+			writeAPIError(w, targetError(fmt.Sprintf("测试导出到 %s 失败", label), first.err))
+		}
+		return
+	}
+
+	response := map[string]interface{}{
+		"target":  target,
+		"mode":    mode,
+		"job_id":  job.ID,
+		"created": len(result.Exported),
+		"results": result.Exported,
+		"skipped": result.Skipped,
+	}
+	if len(result.Failed) > 0 {
+		response["failed"] = result.Failed
+	}
+	if len(result.Unavailable) > 0 {
+		response["unavailable"] = result.Unavailable
+	}
+	if warnings := job.lintWarnings(); len(warnings) > 0 {
+		response["lint_warnings"] = warnings
+	}
+	writeJSON(w, http.StatusOK, response)
+}
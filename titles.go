@@ -0,0 +1,121 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/Devoty/openai-backup/export"
+)
+
+// titleFallbackZip 是 target_title_fallback 中表示 Web 下载压缩包的键。
+const titleFallbackZip = "zip"
+
+func normalizeTitleFallback(value string) string {
+	return export.ParseTitleFallback(value).String()
+}
+
+type targetTitleFallback struct {
+	target string
+	rule   export.TitleFallback
+}
+
+// parseTargetTitleFallback 解析 "目标=方式" 列表 (逗号或换行分隔), 忽略无法识别的条目, 同一目标以最后一项为准。
+func parseTargetTitleFallback(value string) []targetTitleFallback {
+	var rules []targetTitleFallback
+	seen := make(map[string]int)
+	for _, entry := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '\n' }) {
+		target, mode, ok := strings.Cut(entry, "=")
+		target = strings.ToLower(strings.TrimSpace(target))
+		if !ok || target == "" {
+			continue
+		}
+		rule := targetTitleFallback{target: target, rule: export.ParseTitleFallback(mode)}
+		if idx, ok := seen[target]; ok {
+			rules[idx] = rule
+			continue
+		}
+		seen[target] = len(rules)
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func normalizeTargetTitleFallback(value string) string {
+	rules := parseTargetTitleFallback(value)
+	entries := make([]string, 0, len(rules))
+	for _, item := range rules {
+		entries = append(entries, item.target+"="+item.rule.String())
+	}
+	return strings.Join(entries, ",")
+}
+
+// titleFallbackFor 返回目标使用的标题生成方式, 未单独配置时使用 title_fallback。
+func titleFallbackFor(cfg *cliConfig, target string) export.TitleFallback {
+	for _, item := range parseTargetTitleFallback(cfg.TargetTitleFallback) {
+		if item.target == target {
+			return item.rule
+		}
+	}
+	return export.ParseTitleFallback(cfg.TitleFallback)
+}
+
+// withFallbackTitle 为未命名对话按目标配置生成标题, 再按目标的标题模板修饰; 只作用于写入目标的副本,
+// 版本记录与关联仍使用原标题。
+func (s *webServer) withFallbackTitle(target string, conv export.Conversation) export.Conversation {
+	cfg := s.configSnapshot()
+	loc := s.locationSnapshot()
+	if title := export.FallbackTitle(conv, titleFallbackFor(cfg, target), loc); title != "" {
+		conv.Title = title
+	}
+	conv.Title = export.DecorateTitle(conv, titleTemplateFor(cfg, target), loc)
+	return conv
+}
+
+func normalizeTitleTemplate(value string) string {
+	return export.NormalizeTitleTemplate(value)
+}
+
+type targetTitleTemplate struct {
+	target   string
+	template string
+}
+
+// parseTargetTitleTemplate 解析按行分隔的 "目标=模板" 列表; 模板中可能出现逗号, 因此只按换行分隔。
+// 同一目标以最后一项为准, 模板为空表示该目标不修饰标题。
+func parseTargetTitleTemplate(value string) []targetTitleTemplate {
+	var rules []targetTitleTemplate
+	seen := make(map[string]int)
+	for _, line := range strings.Split(value, "\n") {
+		target, template, ok := strings.Cut(line, "=")
+		target = strings.ToLower(strings.TrimSpace(target))
+		if !ok || target == "" {
+			continue
+		}
+		rule := targetTitleTemplate{target: target, template: export.NormalizeTitleTemplate(template)}
+		if idx, ok := seen[target]; ok {
+			rules[idx] = rule
+			continue
+		}
+		seen[target] = len(rules)
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func normalizeTargetTitleTemplate(value string) string {
+	rules := parseTargetTitleTemplate(value)
+	entries := make([]string, 0, len(rules))
+	for _, item := range rules {
+		entries = append(entries, item.target+"="+item.template)
+	}
+	return strings.Join(entries, "\n")
+}
+
+// titleTemplateFor 返回目标使用的标题模板, 未单独配置时使用 title_template。
+func titleTemplateFor(cfg *cliConfig, target string) string {
+	for _, item := range parseTargetTitleTemplate(cfg.TargetTitleTemplate) {
+		if item.target == target {
+			return item.template
+		}
+	}
+	return cfg.TitleTemplate
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// API Token 的权限范围, 每项对应一个用户角色: 创建者的角色不低于该角色时才能授予。
+const (
+	tokenScopeRead   = "read"
+	tokenScopeExport = "export"
+	tokenScopeDelete = "delete"
+)
+
+var tokenScopeRoles = map[string]string{
+	tokenScopeRead:   roleViewer,
+	tokenScopeExport: roleOperator,
+	tokenScopeDelete: roleAdmin,
+}
+
+const (
+	// apiTokenPrefix 便于在日志与配置中识别 Token, 也用来与 server_users 中的 Token 区分。
+	apiTokenPrefix = "obk_"
+	// apiTokenTouchInterval 内重复使用同一 Token 不再更新 last_used_at, 避免每个请求都写库。
+	apiTokenTouchInterval = time.Minute
+)
+
+const apiTokensSchema = `
+	CREATE TABLE IF NOT EXISTS api_tokens (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		owner TEXT NOT NULL,
+		scopes TEXT NOT NULL,
+		token_hash TEXT NOT NULL UNIQUE,
+		hint TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		last_used_at TIMESTAMP
+	);`
+
+var errTokenNotFound = errors.New("API Token 不存在")
+
+// apiToken 是供脚本与定时任务使用的个人 Token, 库中只保存摘要, 明文只在创建时返回一次。
+type apiToken struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Owner      string     `json:"owner"`
+	Scopes     []string   `json:"scopes"`
+	Hint       string     `json:"hint"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newAPITokenSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成 API Token 失败: %w", err)
+	}
+	return apiTokenPrefix + hex.EncodeToString(buf), nil
+}
+
+// normalizeTokenScopes 去重并排序, 遇到无法识别的范围时返回错误; 为空时默认只读。
+func normalizeTokenScopes(scopes []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var out []string
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if scope == "" || seen[scope] {
+			continue
+		}
+		if _, ok := tokenScopeRoles[scope]; !ok {
+			return nil, fmt.Errorf("不支持的权限范围: %s (可选 read、export、delete)", scope)
+		}
+		seen[scope] = true
+		out = append(out, scope)
+	}
+	if len(out) == 0 {
+		out = []string{tokenScopeRead}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// hasTokenScope 判断 scopes 是否授予 scope。export 与 delete 都包含 read: 发起导出或删除后
+// 需要通过 GET /api/jobs/{id} 查询任务进度。
+func hasTokenScope(scopes []string, scope string) bool {
+	for _, item := range scopes {
+		if item == scope || (scope == tokenScopeRead && tokenScopeRoles[item] != "") {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateAPIToken 保存 Token 摘要, hint 为明文的末尾几位, 供列表中辨认。
+func (s *ConfigStore) CreateAPIToken(ctx context.Context, owner, name string, scopes []string, secret string) (apiToken, error) {
+	if s == nil || s.config == nil {
+		return apiToken{}, errors.New("配置存储未初始化")
+	}
+	token := apiToken{
+		Name:      name,
+		Owner:     owner,
+		Scopes:    scopes,
+		Hint:      "…" + secret[len(secret)-4:],
+		CreatedAt: time.Now().UTC(),
+	}
+	res, err := s.config.writer.ExecContext(ctx, `
+		INSERT INTO api_tokens(name, owner, scopes, token_hash, hint, created_at)
+		VALUES(?, ?, ?, ?, ?, ?)
+	`, token.Name, token.Owner, strings.Join(token.Scopes, ","), hashAPIToken(secret), token.Hint, token.CreatedAt)
+	if err != nil {
+		return apiToken{}, fmt.Errorf("保存 API Token 失败: %w", err)
+	}
+	if token.ID, err = res.LastInsertId(); err != nil {
+		return apiToken{}, fmt.Errorf("保存 API Token 失败: %w", err)
+	}
+	return token, nil
+}
+
+// ListAPITokens 按创建顺序列出 Token, owner 为空时列出全部。
+func (s *ConfigStore) ListAPITokens(ctx context.Context, owner string) ([]apiToken, error) {
+	if s == nil || s.config == nil {
+		return nil, errors.New("配置存储未初始化")
+	}
+	rows, err := s.config.reader.QueryContext(ctx, `
+		SELECT id, name, owner, scopes, hint, created_at, last_used_at
+		FROM api_tokens WHERE ? = '' OR owner = ? ORDER BY id
+	`, owner, owner)
+	if err != nil {
+		return nil, fmt.Errorf("读取 API Token 失败: %w", err)
+	}
+	defer rows.Close()
+	tokens := []apiToken{}
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取 API Token 失败: %w", err)
+	}
+	return tokens, nil
+}
+
+// LookupAPIToken 按明文查找 Token, 不存在时返回 errTokenNotFound; 顺带更新最近使用时间。
+func (s *ConfigStore) LookupAPIToken(ctx context.Context, secret string) (apiToken, error) {
+	if s == nil || s.config == nil {
+		return apiToken{}, errors.New("配置存储未初始化")
+	}
+	token, err := scanAPIToken(s.config.reader.QueryRowContext(ctx, `
+		SELECT id, name, owner, scopes, hint, created_at, last_used_at
+		FROM api_tokens WHERE token_hash = ?
+	`, hashAPIToken(secret)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return apiToken{}, errTokenNotFound
+	}
+	if err != nil {
+		return apiToken{}, err
+	}
+	now := time.Now().UTC()
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= apiTokenTouchInterval {
+		if _, err := s.config.writer.ExecContext(ctx, `UPDATE api_tokens SET last_used_at = ? WHERE id = ?`, now, token.ID); err != nil {
+			logInfo("更新 API Token 使用时间失败: id=%d err=%v", token.ID, err)
+		}
+	}
+	return token, nil
+}
+
+// RevokeAPIToken 删除 Token, owner 非空时只能删除该用户自己的 Token。
+func (s *ConfigStore) RevokeAPIToken(ctx context.Context, id int64, owner string) error {
+	if s == nil || s.config == nil {
+		return errors.New("配置存储未初始化")
+	}
+	res, err := s.config.writer.ExecContext(ctx, `DELETE FROM api_tokens WHERE id = ? AND (? = '' OR owner = ?)`, id, owner, owner)
+	if err != nil {
+		return fmt.Errorf("撤销 API Token 失败: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return errTokenNotFound
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAPIToken(row rowScanner) (apiToken, error) {
+	var (
+		token    apiToken
+		scopes   string
+		lastUsed sql.NullTime
+	)
+	if err := row.Scan(&token.ID, &token.Name, &token.Owner, &scopes, &token.Hint, &token.CreatedAt, &lastUsed); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return apiToken{}, err
+		}
+		return apiToken{}, fmt.Errorf("读取 API Token 失败: %w", err)
+	}
+	token.Scopes = strings.Split(scopes, ",")
+	if lastUsed.Valid {
+		token.LastUsedAt = &lastUsed.Time
+	}
+	return token, nil
+}
+
+// authenticateAPIToken 校验 Bearer 形式的 API Token。Token 的权限不超过创建者当前的角色,
+// 创建者已从 server_users 中移除时 Token 失效。
+func (s *webServer) authenticateAPIToken(users []serverUser, r *http.Request) (serverUser, bool) {
+	auth := strings.TrimSpace(r.Header.Get("Authorization"))
+	if len(auth) <= 7 || !strings.EqualFold(auth[:7], "Bearer ") {
+		return serverUser{}, false
+	}
+	secret := strings.TrimSpace(auth[7:])
+	if !strings.HasPrefix(secret, apiTokenPrefix) {
+		return serverUser{}, false
+	}
+	token, err := s.store.LookupAPIToken(r.Context(), secret)
+	if err != nil {
+		if !errors.Is(err, errTokenNotFound) {
+			logInfo("校验 API Token 失败: %v", err)
+		}
+		return serverUser{}, false
+	}
+	for _, user := range users {
+		if user.name == token.Owner {
+			user.scopes = token.Scopes
+			return user, true
+		}
+	}
+	return serverUser{}, false
+}
+
+type tokenCreateRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+type tokenCreateResponse struct {
+	apiToken
+	Token string `json:"token"`
+}
+
+type tokenRevokeRequest struct {
+	ID int64 `json:"id"`
+}
+
+// handleTokens 处理 /api/tokens: GET 列出当前用户的 Token (admin 列出全部), POST 创建 Token。
+// 只能用登录用户管理, API Token 本身不能创建或撤销 Token。
+func (s *webServer) handleTokens(w http.ResponseWriter, r *http.Request) {
+	user, ok := authUserFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "未配置 server_users, 无需 API Token")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		owner := user.name
+		if user.role == roleAdmin {
+			owner = ""
+		}
+		tokens, err := s.store.ListAPITokens(r.Context(), owner)
+		if err != nil {
+			writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "读取 API Token 失败", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"tokens": tokens})
+	case http.MethodPost:
+		defer r.Body.Close()
+		var input tokenCreateRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			writeErrorDetail(w, http.StatusBadRequest, errCodeInvalidRequest, "解析请求失败", err)
+			return
+		}
+		name := strings.TrimSpace(input.Name)
+		if name == "" {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "缺少 Token 名称")
+			return
+		}
+		scopes, err := normalizeTokenScopes(input.Scopes)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+			return
+		}
+		for _, scope := range scopes {
+			if !user.allows(tokenScopeRoles[scope]) {
+				writeError(w, http.StatusForbidden, errCodeForbidden, fmt.Sprintf("当前用户 (%s, %s) 不能授予 %s 权限", user.name, user.role, scope))
+				return
+			}
+		}
+		secret, err := newAPITokenSecret()
+		if err != nil {
+			writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "生成 API Token 失败", err)
+			return
+		}
+		token, err := s.store.CreateAPIToken(r.Context(), user.name, name, scopes, secret)
+		if err != nil {
+			writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "保存 API Token 失败", err)
+			return
+		}
+		logInfo("创建 API Token: id=%d owner=%s name=%s scopes=%s", token.ID, token.Owner, token.Name, strings.Join(token.Scopes, ","))
+		writeJSON(w, http.StatusOK, tokenCreateResponse{apiToken: token, Token: secret})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+	}
+}
+
+// handleTokenRevoke 处理 POST /api/tokens/revoke, 普通用户只能撤销自己的 Token。
+func (s *webServer) handleTokenRevoke(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+		return
+	}
+	user, ok := authUserFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "未配置 server_users, 无需 API Token")
+		return
+	}
+	defer r.Body.Close()
+	var input tokenRevokeRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeErrorDetail(w, http.StatusBadRequest, errCodeInvalidRequest, "解析请求失败", err)
+		return
+	}
+	owner := user.name
+	if user.role == roleAdmin {
+		owner = ""
+	}
+	if err := s.store.RevokeAPIToken(r.Context(), input.ID, owner); err != nil {
+		if errors.Is(err, errTokenNotFound) {
+			writeError(w, http.StatusNotFound, errCodeNotFound, err.Error())
+			return
+		}
+		writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "撤销 API Token 失败", err)
+		return
+	}
+	logInfo("撤销 API Token: id=%d by=%s", input.ID, user.name)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"revoked": input.ID})
+}
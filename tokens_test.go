@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTokenScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		scopes  []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "为空时默认只读", scopes: nil, want: []string{tokenScopeRead}},
+		{name: "去重排序并忽略空白", scopes: []string{" Export", "read", "", "export"}, want: []string{tokenScopeExport, tokenScopeRead}},
+		{name: "全部权限", scopes: []string{"delete", "export", "read"}, want: []string{tokenScopeDelete, tokenScopeExport, tokenScopeRead}},
+		{name: "无法识别的权限", scopes: []string{"read", "admin"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeTokenScopes(tt.scopes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalizeTokenScopes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerUserPermitsScopes(t *testing.T) {
+	tests := []struct {
+		name   string
+		user   serverUser
+		method string
+		path   string
+		want   bool
+	}{
+		{name: "登录用户不受权限范围限制", user: serverUser{role: roleAdmin}, method: http.MethodPost, path: "/api/config", want: true},
+		{name: "read 浏览", user: serverUser{role: roleViewer, scopes: []string{"read"}}, method: http.MethodGet, path: "/api/conversations", want: true},
+		{name: "read 导出", user: serverUser{role: roleOperator, scopes: []string{"read"}}, method: http.MethodPost, path: "/api/conversations/export", want: false},
+		{name: "export 导出", user: serverUser{role: roleOperator, scopes: []string{"export"}}, method: http.MethodPost, path: "/api/conversations/export", want: true},
+		{name: "export 包含 read", user: serverUser{role: roleOperator, scopes: []string{"export"}}, method: http.MethodGet, path: "/api/conversations", want: true},
+		{name: "delete 包含 read", user: serverUser{role: roleAdmin, scopes: []string{"delete"}}, method: http.MethodGet, path: "/api/jobs/20240301-120000-abcd1234", want: true},
+		{name: "delete 不包含 export", user: serverUser{role: roleAdmin, scopes: []string{"delete"}}, method: http.MethodPost, path: "/api/conversations/export", want: false},
+		{name: "delete 删除", user: serverUser{role: roleAdmin, scopes: []string{"delete"}}, method: http.MethodPost, path: "/api/conversations/delete", want: true},
+		{name: "权限不超过创建者的角色", user: serverUser{role: roleOperator, scopes: []string{"delete"}}, method: http.MethodPost, path: "/api/conversations/delete", want: false},
+		{name: "Token 不能修改配置", user: serverUser{role: roleAdmin, scopes: []string{"delete", "export", "read"}}, method: http.MethodPost, path: "/api/config", want: false},
+		{name: "Token 不能管理 Token", user: serverUser{role: roleAdmin, scopes: []string{"read"}}, method: http.MethodGet, path: "/api/tokens", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			if got := tt.user.permits(r, requiredRole(r)); got != tt.want {
+				t.Errorf("permits(%s %s) = %v, want %v", tt.method, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPITokenStore(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	secret, err := newAPITokenSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(secret, apiTokenPrefix) {
+		t.Fatalf("Token 缺少前缀: %s", secret)
+	}
+	created, err := store.CreateAPIToken(ctx, "alice", "cron", []string{tokenScopeExport, tokenScopeRead}, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Hint != "…"+secret[len(secret)-4:] {
+		t.Errorf("Hint = %q", created.Hint)
+	}
+	if _, err := store.CreateAPIToken(ctx, "bob", "other", []string{tokenScopeRead}, apiTokenPrefix+"bob"); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := store.LookupAPIToken(ctx, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.ID != created.ID || found.Owner != "alice" || !reflect.DeepEqual(found.Scopes, created.Scopes) {
+		t.Errorf("LookupAPIToken() = %+v, want %+v", found, created)
+	}
+	if _, err := store.LookupAPIToken(ctx, secret+"x"); !errors.Is(err, errTokenNotFound) {
+		t.Errorf("查找不存在的 Token: err = %v", err)
+	}
+	again, err := store.LookupAPIToken(ctx, secret)
+	if err != nil || again.LastUsedAt == nil {
+		t.Errorf("使用后应记录 last_used_at: %+v err=%v", again, err)
+	}
+
+	tests := []struct {
+		owner string
+		want  int
+	}{
+		{"alice", 1},
+		{"bob", 1},
+		{"carol", 0},
+		{"", 2},
+	}
+	for _, tt := range tests {
+		tokens, err := store.ListAPITokens(ctx, tt.owner)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tokens) != tt.want {
+			t.Errorf("ListAPITokens(%q) = %d 个, want %d", tt.owner, len(tokens), tt.want)
+		}
+	}
+
+	if err := store.RevokeAPIToken(ctx, created.ID, "bob"); !errors.Is(err, errTokenNotFound) {
+		t.Errorf("撤销他人的 Token: err = %v, want errTokenNotFound", err)
+	}
+	if err := store.RevokeAPIToken(ctx, created.ID, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.LookupAPIToken(ctx, secret); !errors.Is(err, errTokenNotFound) {
+		t.Errorf("撤销后仍可查到 Token: err = %v", err)
+	}
+}
+
+func TestWithAuthAPIToken(t *testing.T) {
+	store := newTestStore(t)
+	s := &webServer{cfg: &cliConfig{ServerUsers: "alice:operator:login-a"}, store: store}
+	create := func(scopes ...string) string {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/api/tokens", strings.NewReader(`{"name":"cron","scopes":["`+strings.Join(scopes, `","`)+`"]}`))
+		r.SetBasicAuth("alice", "login-a")
+		s.withAuth(http.HandlerFunc(s.handleTokens)).ServeHTTP(rec, r)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("创建 Token: status = %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp tokenCreateResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Token
+	}
+	readToken := create("read")
+	exportToken := create("export")
+
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/tokens", strings.NewReader(`{"name":"x","scopes":["delete"]}`))
+	r.SetBasicAuth("alice", "login-a")
+	s.withAuth(http.HandlerFunc(s.handleTokens)).ServeHTTP(rec, r)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("operator 授予 delete: status = %d, want 403", rec.Code)
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		users  string
+		method string
+		path   string
+		want   int
+	}{
+		{name: "read 浏览", token: readToken, method: http.MethodGet, path: "/api/conversations", want: http.StatusNoContent},
+		{name: "read 导出", token: readToken, method: http.MethodPost, path: "/api/conversations/export", want: http.StatusForbidden},
+		{name: "export 导出", token: exportToken, method: http.MethodPost, path: "/api/conversations/export", want: http.StatusNoContent},
+		{name: "export 查询导出任务", token: exportToken, method: http.MethodGet, path: "/api/jobs/20240301-120000-abcd1234", want: http.StatusNoContent},
+		{name: "Token 不能管理 Token", token: readToken, method: http.MethodGet, path: "/api/tokens", want: http.StatusForbidden},
+		{name: "未知 Token", token: apiTokenPrefix + "unknown", method: http.MethodGet, path: "/api/conversations", want: http.StatusUnauthorized},
+		{name: "创建者被移除后失效", token: readToken, users: "bob:admin:login-b", method: http.MethodGet, path: "/api/conversations", want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s.cfg = &cliConfig{ServerUsers: firstNonEmpty(tt.users, "alice:operator:login-a")}
+			handler := s.withAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			r.Header.Set("Authorization", "Bearer "+tt.token)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, r)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Devoty/openai-backup/client"
+)
+
+// unavailableConversation 是批量任务中因已删除或无权访问而跳过的对话。
+type unavailableConversation struct {
+	ConversationID string `json:"id"`
+	Title          string `json:"title"`
+	// Code 为 chatgpt_not_found 或 chatgpt_forbidden。
+	Code   string `json:"code"`
+	Reason string `json:"reason"`
+}
+
+// conversationUnavailable 判断拉取对话详情的失败是否只与这个对话有关: 对话已删除或不存在 (404),
+// 或无权访问 (403, 如他人分享到工作区的对话、已退出的团队空间)。Token 失效 (401) 与 Cloudflare
+// 拦截 (响应为 HTML 页面的 403) 会影响所有请求, 不属于此类, 仍按原有逻辑中止任务。
+func conversationUnavailable(err error) (code, reason string, ok bool) {
+	var statusErr *client.StatusError
+	if !errors.As(err, &statusErr) {
+		return "", "", false
+	}
+	switch {
+	case statusErr.Status == http.StatusNotFound:
+		return errCodeChatGPTNotFound, "对话不存在或已被删除 (404)", true
+	case isConversationForbidden(statusErr):
+		return errCodeChatGPTForbidden, "无权访问该对话 (403), 可能是他人分享的对话或工作区权限受限", true
+	}
+	return "", "", false
+}
+
+// isConversationForbidden 判断对话详情的 403 是否只针对该对话: 接口返回 JSON 时为对话级的拒绝,
+// 返回 HTML 页面时为整体被拦截。
+func isConversationForbidden(statusErr *client.StatusError) bool {
+	if statusErr.Status != http.StatusForbidden || statusErr.Action != client.ActionConversationDetail {
+		return false
+	}
+	body := strings.TrimSpace(statusErr.Body)
+	return strings.HasPrefix(body, "{")
+}
+
+// skipUnavailable 把无法访问的对话记为跳过, 写入任务记录。
+func (j *exportJob) skipUnavailable(target string, item unavailableConversation) {
+	j.record(jobOutcome{ConversationID: item.ConversationID, Title: item.Title, Target: target, Status: outcomeSkipped, Error: item.Reason})
+}
+
+// unavailableStatus 返回单个对话无法访问时接口使用的状态码。
+func unavailableStatus(item unavailableConversation) int {
+	if item.Code == errCodeChatGPTForbidden {
+		return http.StatusForbidden
+	}
+	return http.StatusNotFound
+}
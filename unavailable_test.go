@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Devoty/openai-backup/client"
+)
+
+func TestConversationUnavailable(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantCode   string
+		wantOK     bool
+		wantStatus int
+	}{
+		{name: "对话已删除", err: fmt.Errorf("c1: %w", &client.StatusError{Action: client.ActionConversationDetail, Status: http.StatusNotFound}), wantCode: errCodeChatGPTNotFound, wantOK: true, wantStatus: http.StatusNotFound},
+		{name: "对话级的 403", err: &client.StatusError{Action: client.ActionConversationDetail, Status: http.StatusForbidden, Body: ` {"detail":"forbidden"}`}, wantCode: errCodeChatGPTForbidden, wantOK: true, wantStatus: http.StatusForbidden},
+		{name: "Cloudflare 拦截", err: &client.StatusError{Action: client.ActionConversationDetail, Status: http.StatusForbidden, Body: "<!DOCTYPE html>"}},
+		{name: "列表接口的 403", err: &client.StatusError{Status: http.StatusForbidden, Body: `{"detail":"forbidden"}`}},
+		{name: "Token 失效", err: &client.StatusError{Action: client.ActionConversationDetail, Status: http.StatusUnauthorized}},
+		{name: "其他错误", err: errors.New("EOF")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, reason, ok := conversationUnavailable(tt.err)
+			if code != tt.wantCode || ok != tt.wantOK || (reason != "") != tt.wantOK {
+				t.Fatalf("conversationUnavailable() = %q, %q, %v, want %q, %v", code, reason, ok, tt.wantCode, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if got := unavailableStatus(unavailableConversation{Code: code}); got != tt.wantStatus {
+				t.Errorf("unavailableStatus() = %d, want %d", got, tt.wantStatus)
+			}
+		})
+	}
+}
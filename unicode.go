@@ -0,0 +1,29 @@
+package main
+
+import "github.com/Devoty/openai-backup/export"
+
+// unicodeOptions 返回写入目标前的文本规范化选项, unicode_normalize 同时开启 NFC 与控制字符清理。
+func unicodeOptions(cfg *cliConfig) export.UnicodeOptions {
+	return export.UnicodeOptions{NFC: cfg.UnicodeNormalize, StripControl: cfg.UnicodeNormalize}
+}
+
+func filenameOptions(cfg *cliConfig) export.FilenameOptions {
+	return export.FilenameOptions{StripEmoji: cfg.FilenameStripEmoji}
+}
+
+// conversationForTarget 返回写入目标前的副本: 按目标配置生成未命名对话的标题, 按 export_mode 筛选消息,
+// 按项目映射设置目标中的分类, 按配置规范化文本, 并带上渲染 Markdown/HTML 时的消息排列方式、统计与目录开关。
+func (s *webServer) conversationForTarget(target string, conv export.Conversation) export.Conversation {
+	cfg := s.configSnapshot()
+	conv = s.withFallbackTitle(target, conv)
+	conv = export.ApplyExportMode(conv, cfg.ExportMode)
+	// 只有导出压缩包能把全文写为附件, 其他目标不保留原文。
+	conv = export.TruncateMessages(conv, cfg.MaxMessageChars, cfg.TruncateKeepFull && target == titleFallbackZip)
+	conv = s.applyProjectMapping(target, conv)
+	conv.Layout = cfg.MessageLayout
+	conv.ShowStats = cfg.ExportStats
+	conv.TOCMinMessages = cfg.TOCMinMessages
+	conv.TimeFormat = cfg.TimeFormat
+	conv.SecondTimezone = cfg.SecondTimezone
+	return export.NormalizeConversation(conv, unicodeOptions(cfg))
+}
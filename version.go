@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Devoty/openai-backup/httpc"
+)
+
+const (
+	// updateCheckTTL 是检查新版本结果的缓存时间; 检查失败时 updateRetryDelay 后再试。
+	updateCheckTTL   = 6 * time.Hour
+	updateRetryDelay = 30 * time.Minute
+)
+
+// version 是发布版本号, 构建时通过 -ldflags "-X main.version=v1.2.3" 写入。
+var version = "dev"
+
+// updateReleasesURL 是检查新版本时请求的 GitHub 最新发布接口。
+var updateReleasesURL = "https://api.github.com/repos/Devoty/openai-backup/releases/latest"
+
+// updateStatus 是检查新版本的结果。Available 只在当前版本号可以比较时判断, dev 构建始终为 false。
+type updateStatus struct {
+	Enabled     bool       `json:"enabled"`
+	Available   bool       `json:"available"`
+	Latest      string     `json:"latest,omitempty"`
+	URL         string     `json:"url,omitempty"`
+	PublishedAt string     `json:"published_at,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	CheckedAt   *time.Time `json:"checked_at,omitempty"`
+}
+
+// buildInfo 返回版本号、Go 版本与构建时记录的提交信息。
+func buildInfo() map[string]interface{} {
+	info := map[string]interface{}{
+		"version": version,
+		"go":      runtime.Version(),
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				info["revision"] = setting.Value
+			case "vcs.time":
+				info["revision_time"] = setting.Value
+			case "vcs.modified":
+				info["modified"] = setting.Value == "true"
+			}
+		}
+	}
+	return info
+}
+
+// checkForUpdate 返回新版本检查结果, 未开启 update_check 时不发出请求。结果缓存 updateCheckTTL,
+// refresh 为 true 时重新检查; 同一时间只有一个请求访问 GitHub。
+func (s *webServer) checkForUpdate(ctx context.Context, refresh bool) updateStatus {
+	if !s.configSnapshot().UpdateCheck {
+		return updateStatus{}
+	}
+	s.updateMu.Lock()
+	defer s.updateMu.Unlock()
+	if cached := s.updateCheck; cached != nil && !refresh {
+		ttl := updateCheckTTL
+		if cached.Error != "" {
+			ttl = updateRetryDelay
+		}
+		if time.Since(*cached.CheckedAt) < ttl {
+			return *cached
+		}
+	}
+
+	now := time.Now()
+	status := updateStatus{Enabled: true, CheckedAt: &now}
+	release, err := fetchLatestRelease(ctx)
+	if err != nil {
+		logInfo("检查新版本失败: %v", err)
+		status.Error = err.Error()
+	} else if release != nil {
+		status.Latest, status.URL, status.PublishedAt = release.TagName, release.HTMLURL, release.PublishedAt
+		status.Available = compareVersions(release.TagName, version) > 0
+	}
+	s.updateCheck = &status
+	return status
+}
+
+type githubRelease struct {
+	TagName     string `json:"tag_name"`
+	HTMLURL     string `json:"html_url"`
+	PublishedAt string `json:"published_at"`
+}
+
+// fetchLatestRelease 读取最新的正式发布; 仓库还没有发布时返回 nil。
+func fetchLatestRelease(ctx context.Context) (*githubRelease, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, updateReleasesURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "openai-backup/"+version)
+	resp, err := httpc.Client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("GitHub 返回 %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var release githubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("解析发布信息失败: %w", err)
+	}
+	return &release, nil
+}
+
+// compareVersions 比较 v1.2.3 形式的版本号, a 较新时返回 1、较旧时返回 -1; 无法解析 (如 dev) 时返回 0。
+// 预发布版本 (v1.2.3-rc1) 比同号的正式版本旧。
+func compareVersions(a, b string) int {
+	pa, preA, okA := parseVersion(a)
+	pb, preB, okB := parseVersion(b)
+	if !okA || !okB {
+		return 0
+	}
+	for i := range pa {
+		switch {
+		case pa[i] > pb[i]:
+			return 1
+		case pa[i] < pb[i]:
+			return -1
+		}
+	}
+	switch {
+	case preA == preB:
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	case preA > preB:
+		return 1
+	default:
+		return -1
+	}
+}
+
+func parseVersion(value string) ([3]int, string, bool) {
+	var parts [3]int
+	value = strings.TrimPrefix(strings.TrimSpace(value), "v")
+	value, _, _ = strings.Cut(value, "+")
+	value, pre, _ := strings.Cut(value, "-")
+	fields := strings.Split(value, ".")
+	if len(fields) == 0 || len(fields) > 3 {
+		return parts, "", false
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return parts, "", false
+		}
+		parts[i] = n
+	}
+	return parts, pre, true
+}
+
+// handleVersion 处理 GET /api/version: 返回版本与构建信息; 开启 update_check 时附带 GitHub 上的最新发布,
+// 加 ?refresh=1 重新检查。
+func (s *webServer) handleVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "不支持的请求方法")
+		return
+	}
+	info := buildInfo()
+	info["update"] = s.checkForUpdate(r.Context(), r.URL.Query().Get("refresh") == "1")
+	writeJSON(w, http.StatusOK, info)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.2.3", "v1.2.3", 0},
+		{"v1.10.0", "v1.9.9", 1},
+		{"1.2", "v1.2.1", -1},
+		{"v2.0.0+build.5", "v2.0.0", 0},
+		{"v1.2.3", "v1.2.3-rc1", 1},
+		{"v1.2.3-rc1", "v1.2.3-rc2", -1},
+		{"v1.2.3", "dev", 0},
+		{"v1.2.3.4", "v1.2.3", 0},
+		{"v1.-1.0", "v1.0.0", 0},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestCheckForUpdate(t *testing.T) {
+	tests := []struct {
+		name          string
+		enabled       bool
+		current       string
+		status        int
+		body          string
+		wantAvailable bool
+		wantLatest    string
+		wantErr       bool
+		wantRequests  int32
+	}{
+		{name: "未开启时不发请求", enabled: false, current: "v1.0.0", status: http.StatusOK, body: `{"tag_name":"v2.0.0"}`},
+		{name: "有新版本", enabled: true, current: "v1.0.0", status: http.StatusOK, body: `{"tag_name":"v1.1.0","html_url":"https://example.com/v1.1.0"}`, wantAvailable: true, wantLatest: "v1.1.0", wantRequests: 1},
+		{name: "已是最新", enabled: true, current: "v1.1.0", status: http.StatusOK, body: `{"tag_name":"v1.1.0"}`, wantLatest: "v1.1.0", wantRequests: 1},
+		{name: "dev 构建不提示", enabled: true, current: "dev", status: http.StatusOK, body: `{"tag_name":"v1.1.0"}`, wantLatest: "v1.1.0", wantRequests: 1},
+		{name: "仓库还没有发布", enabled: true, current: "v1.0.0", status: http.StatusNotFound, wantRequests: 1},
+		{name: "请求失败", enabled: true, current: "v1.0.0", status: http.StatusForbidden, body: "rate limited", wantErr: true, wantRequests: 1},
+		{name: "响应无效", enabled: true, current: "v1.0.0", status: http.StatusOK, body: "{", wantErr: true, wantRequests: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer srv.Close()
+			oldURL, oldVersion := updateReleasesURL, version
+			updateReleasesURL, version = srv.URL, tt.current
+			t.Cleanup(func() { updateReleasesURL, version = oldURL, oldVersion })
+
+			s := &webServer{cfg: &cliConfig{UpdateCheck: tt.enabled}}
+			got := s.checkForUpdate(context.Background(), false)
+			if got.Enabled != tt.enabled || got.Available != tt.wantAvailable || got.Latest != tt.wantLatest || (got.Error != "") != tt.wantErr {
+				t.Errorf("checkForUpdate() = %+v", got)
+			}
+			// 缓存有效期内不再请求 GitHub。
+			if again := s.checkForUpdate(context.Background(), false); again.Latest != got.Latest {
+				t.Errorf("缓存结果 = %+v, want %+v", again, got)
+			}
+			if n := atomic.LoadInt32(&requests); n != tt.wantRequests {
+				t.Errorf("请求次数 = %d, want %d", n, tt.wantRequests)
+			}
+			if !tt.enabled {
+				return
+			}
+			s.checkForUpdate(context.Background(), true)
+			if n := atomic.LoadInt32(&requests); n != tt.wantRequests+1 {
+				t.Errorf("refresh 后请求次数 = %d, want %d", n, tt.wantRequests+1)
+			}
+		})
+	}
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Devoty/openai-backup/export"
+)
+
+const conversationVersionsSchema = `
+	CREATE TABLE IF NOT EXISTS conversation_versions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		conversation_id TEXT NOT NULL,
+		content_hash TEXT NOT NULL,
+		title TEXT NOT NULL DEFAULT '',
+		update_time REAL NOT NULL DEFAULT 0,
+		message_count INTEGER NOT NULL DEFAULT 0,
+		data BLOB NOT NULL,
+		blob_hash TEXT NOT NULL DEFAULT '',
+		first_seen_at TIMESTAMP NOT NULL,
+		last_seen_at TIMESTAMP NOT NULL
+	);`
+
+const conversationVersionsIndex = `CREATE INDEX IF NOT EXISTS idx_conversation_versions ON conversation_versions(conversation_id, id)`
+
+var errVersionNotFound = errors.New("版本不存在")
+
+// conversationVersion 是对话在某次导出时的内容快照。同一内容重复导出只更新 LastSeenAt。
+type conversationVersion struct {
+	ID           int64     `json:"id"`
+	ContentHash  string    `json:"content_hash"`
+	Title        string    `json:"title"`
+	UpdateTime   float64   `json:"update_time"`
+	MessageCount int       `json:"message_count"`
+	FirstSeenAt  time.Time `json:"first_seen_at"`
+	LastSeenAt   time.Time `json:"last_seen_at"`
+}
+
+// conversationContentHash 计算对话内容摘要, 只包含标题、消息与上传文件;
+// 相关对话链接等随导出批次变化的信息不参与计算。
+func conversationContentHash(conv export.Conversation) string {
+	data, _ := json.Marshal(struct {
+		Title       string              `json:"title"`
+		Messages    []export.Message    `json:"messages"`
+		Attachments []export.Attachment `json:"attachments"`
+	}{conv.Title, conv.Messages, conv.Attachments})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// RecordConversationVersion 在内容与最近一个版本不同时保存新快照, 返回是否新增了版本。
+func (s *ConfigStore) RecordConversationVersion(ctx context.Context, conv export.Conversation) (bool, error) {
+	if s == nil || s.archive == nil {
+		return false, errors.New("配置存储未初始化")
+	}
+	conv = export.ClearChanges(conv)
+	conv.Related = nil
+	hash := conversationContentHash(conv)
+	tx, err := s.archive.writer.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	var (
+		latestID   int64
+		latestHash string
+	)
+	err = tx.QueryRowContext(ctx, `SELECT id, content_hash FROM conversation_versions WHERE conversation_id = ? ORDER BY id DESC LIMIT 1`, conv.ID).Scan(&latestID, &latestHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, fmt.Errorf("读取对话版本失败: %w", err)
+	}
+	created := false
+	if latestHash == hash {
+		if _, err := tx.ExecContext(ctx, `UPDATE conversation_versions SET last_seen_at = ? WHERE id = ?`, now, latestID); err != nil {
+			return false, fmt.Errorf("更新对话版本失败: %w", err)
+		}
+	} else {
+		data, err := json.Marshal(conv)
+		if err != nil {
+			return false, fmt.Errorf("序列化对话失败: %w", err)
+		}
+		blob, err := putBlob(ctx, tx, data)
+		if err != nil {
+			return false, err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO conversation_versions(conversation_id, content_hash, title, update_time, message_count, data, blob_hash, first_seen_at, last_seen_at)
+			VALUES(?, ?, ?, ?, ?, x'', ?, ?, ?)
+		`, conv.ID, hash, conv.Title, conv.UpdateTime, len(conv.Messages), blob, now, now); err != nil {
+			return false, fmt.Errorf("保存对话版本失败: %w", err)
+		}
+		created = true
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("提交对话版本失败: %w", err)
+	}
+	return created, nil
+}
+
+// ListConversationVersions 按时间倒序返回对话的全部版本, 不含内容。
+func (s *ConfigStore) ListConversationVersions(ctx context.Context, conversationID string) ([]conversationVersion, error) {
+	if s == nil || s.archive == nil {
+		return nil, errors.New("配置存储未初始化")
+	}
+	rows, err := s.archive.reader.QueryContext(ctx, `
+		SELECT id, content_hash, title, update_time, message_count, first_seen_at, last_seen_at
+		FROM conversation_versions WHERE conversation_id = ? ORDER BY id DESC
+	`, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("读取对话版本失败: %w", err)
+	}
+	defer rows.Close()
+	versions := []conversationVersion{}
+	for rows.Next() {
+		var v conversationVersion
+		if err := rows.Scan(&v.ID, &v.ContentHash, &v.Title, &v.UpdateTime, &v.MessageCount, &v.FirstSeenAt, &v.LastSeenAt); err != nil {
+			return nil, fmt.Errorf("解析对话版本失败: %w", err)
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// ConversationVersion 读取指定版本的内容。
+func (s *ConfigStore) ConversationVersion(ctx context.Context, conversationID string, versionID int64) (conversationVersion, export.Conversation, error) {
+	var (
+		v    conversationVersion
+		conv export.Conversation
+		data []byte
+	)
+	if s == nil || s.archive == nil {
+		return v, conv, errors.New("配置存储未初始化")
+	}
+	err := s.archive.reader.QueryRowContext(ctx, `
+		SELECT v.id, v.content_hash, v.title, v.update_time, v.message_count, v.first_seen_at, v.last_seen_at, COALESCE(b.data, v.data)
+		FROM conversation_versions v LEFT JOIN blobs b ON b.hash = v.blob_hash
+		WHERE v.conversation_id = ? AND v.id = ?
+	`, conversationID, versionID).Scan(&v.ID, &v.ContentHash, &v.Title, &v.UpdateTime, &v.MessageCount, &v.FirstSeenAt, &v.LastSeenAt, &data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return v, conv, errVersionNotFound
+	}
+	if err != nil {
+		return v, conv, fmt.Errorf("读取对话版本失败: %w", err)
+	}
+	if err := json.Unmarshal(data, &conv); err != nil {
+		return v, conv, fmt.Errorf("解析对话版本失败: %w", err)
+	}
+	return v, conv, nil
+}
+
+// ConversationVersionBefore 返回 before 之前首次出现的最近一个版本, 即本次任务开始前最后一次备份的内容。
+func (s *ConfigStore) ConversationVersionBefore(ctx context.Context, conversationID string, before time.Time) (export.Conversation, bool, error) {
+	versions, err := s.ListConversationVersions(ctx, conversationID)
+	if err != nil {
+		return export.Conversation{}, false, err
+	}
+	for _, v := range versions {
+		if !v.FirstSeenAt.Before(before) {
+			continue
+		}
+		_, conv, err := s.ConversationVersion(ctx, conversationID, v.ID)
+		if err != nil {
+			return export.Conversation{}, false, err
+		}
+		return conv, true, nil
+	}
+	return export.Conversation{}, false, nil
+}
+
+// annotateConversationChanges 在开启 annotate_changes 时对比上一次备份, 标记新增、修改与删除的消息。
+// since 为任务开始时间, 同一任务内导出到多个目标时都与任务开始前的版本比较。
+func (s *webServer) annotateConversationChanges(ctx context.Context, conv *export.Conversation, since time.Time) {
+	if !s.configSnapshot().AnnotateChanges {
+		return
+	}
+	previous, ok, err := s.store.ConversationVersionBefore(ctx, conv.ID, since)
+	if err != nil {
+		logInfo("读取上一次备份失败, 不标记变化: conversation=%s err=%v", conv.ID, err)
+		return
+	}
+	if !ok {
+		return
+	}
+	export.AnnotateChanges(conv, previous)
+}
+
+// recordConversationVersion 记录导出时的对话快照, 失败只记录日志, 不影响导出。
+func (s *webServer) recordConversationVersion(ctx context.Context, conv export.Conversation) {
+	created, err := s.store.RecordConversationVersion(ctx, conv)
+	if err != nil {
+		logInfo("记录对话版本失败: conversation=%s err=%v", conv.ID, err)
+		return
+	}
+	if created {
+		logInfo("对话内容有变化, 已保存新版本: conversation=%s", conv.ID)
+	}
+}
+
+type apiConversationVersion struct {
+	ID           int64  `json:"id"`
+	ContentHash  string `json:"content_hash"`
+	Title        string `json:"title"`
+	UpdateTime   string `json:"update_time"`
+	MessageCount int    `json:"message_count"`
+	FirstSeenAt  string `json:"first_seen_at"`
+	LastSeenAt   string `json:"last_seen_at"`
+}
+
+func newAPIConversationVersion(v conversationVersion, loc *time.Location, format string) apiConversationVersion {
+	return apiConversationVersion{
+		ID:           v.ID,
+		ContentHash:  v.ContentHash,
+		Title:        v.Title,
+		UpdateTime:   export.FormatTimestampAs(v.UpdateTime, loc, format),
+		MessageCount: v.MessageCount,
+		FirstSeenAt:  export.FormatTimeAs(v.FirstSeenAt, loc, format),
+		LastSeenAt:   export.FormatTimeAs(v.LastSeenAt, loc, format),
+	}
+}
+
+// handleConversationVersions 处理 GET /api/conversations/{id}/versions (版本列表) 与
+// GET /api/conversations/{id}/versions/{version}?format=json|markdown|html (旧版本内容)。
+func (s *webServer) handleConversationVersions(w http.ResponseWriter, r *http.Request, conversationID, version string) {
+	loc := s.locationSnapshot()
+	cfg := s.configSnapshot()
+	if version == "" {
+		versions, err := s.store.ListConversationVersions(r.Context(), conversationID)
+		if err != nil {
+			writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "读取对话版本失败", err)
+			return
+		}
+		items := make([]apiConversationVersion, 0, len(versions))
+		for _, v := range versions {
+			items = append(items, newAPIConversationVersion(v, loc, cfg.TimeFormat))
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"conversation_id": conversationID, "versions": items})
+		return
+	}
+
+	versionID, err := strconv.ParseInt(version, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "版本号无效")
+		return
+	}
+	v, conv, err := s.store.ConversationVersion(r.Context(), conversationID, versionID)
+	if errors.Is(err, errVersionNotFound) {
+		writeError(w, http.StatusNotFound, errCodeNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeErrorDetail(w, http.StatusInternalServerError, errCodeInternal, "读取对话版本失败", err)
+		return
+	}
+
+	conv.Layout = cfg.MessageLayout
+	conv.TOCMinMessages = cfg.TOCMinMessages
+	conv.TimeFormat = cfg.TimeFormat
+	conv.SecondTimezone = cfg.SecondTimezone
+	switch format := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format"))); format {
+	case "", "json":
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"version":      newAPIConversationVersion(v, loc, cfg.TimeFormat),
+			"conversation": s.conversationDetailResponse(conv),
+		})
+	case "markdown", "md":
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Write([]byte(export.RenderMarkdown(conv, cfg.OutputTimezone)))
+	case "html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(export.RenderHTML(conv, cfg.OutputTimezone, export.HTMLOptions{Theme: cfg.HTMLTheme, CustomCSS: cfg.HTMLCustomCSS, MathMode: cfg.MathMode, RenderDiagrams: cfg.RenderDiagrams, RenderSVG: s.svgRenderer(cfg), QRCode: cfg.HTMLQRCode})))
+	default:
+		writeError(w, http.StatusBadRequest, errCodeUnsupportedFormat, fmt.Sprintf("不支持的格式: %s", format))
+	}
+}
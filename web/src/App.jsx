@@ -1,6 +1,7 @@
 import React, { useState, useEffect, useMemo, useCallback, useRef } from "react";
 import { configSections, initialConfig, initialPreview } from "./config/constants";
 import { clampPageSizeValue, createConfigDraft, normalizeConfigResponse, normalizeTarget, prepareConfigPayload } from "./utils/config";
+import { apiErrorMessage } from "./utils/errors";
 
 import Header from "./components/Header";
 import MessageBar from "./components/MessageBar";
@@ -12,6 +13,7 @@ function App() {
 	const [config, setConfig] = useState(initialConfig);
 	const [activeTab, setActiveTab] = useState("conversations");
 	const [configTab, setConfigTab] = useState("core");
+	const [lockedConfigKeys, setLockedConfigKeys] = useState([]);
 	const [configDraft, setConfigDraft] = useState(() => createConfigDraft(initialConfig));
 	const [configSaving, setConfigSaving] = useState(false);
 	const [total, setTotal] = useState(0);
@@ -38,13 +40,13 @@ function App() {
 	const selectedIds = useMemo(() => Array.from(selected), [selected]);
 	const selectedCount = selectedIds.length;
 
-	const showMessage = useCallback((text, isError) => {
+	const showMessage = useCallback((text, isError, action) => {
 		if (messageTimerRef.current) {
 			clearTimeout(messageTimerRef.current);
 			messageTimerRef.current = null;
 		}
-		setMessage({ text: text || "", error: !!isError });
-		if (text && !isError) {
+		setMessage({ text: text || "", error: !!isError, action: action || null });
+		if (text && !isError && !action) {
 			messageTimerRef.current = setTimeout(() => {
 				setMessage({ text: "", error: false });
 				messageTimerRef.current = null;
@@ -78,11 +80,19 @@ function App() {
 				});
 				const data = await response.json().catch(() => ({}));
 				if (!response.ok) {
-					throw new Error(data.error || response.statusText || "加载配置失败");
+					throw new Error(apiErrorMessage(data) || response.statusText || "加载配置失败");
 				}
 				if (!cancelled) {
 					applyConfigPayloadToState(data);
 				}
+				// 锁定的配置项在设置页中只读; 读取失败 (如非管理员) 时不影响其他字段。
+				const locksResponse = await fetch("/api/admin/config-locks", {
+					headers: { Accept: "application/json" }
+				});
+				const locksData = await locksResponse.json().catch(() => ({}));
+				if (!cancelled && locksResponse.ok && Array.isArray(locksData.locks)) {
+					setLockedConfigKeys(locksData.locks.map((item) => item.key));
+				}
 			} catch (error) {
 				if (!cancelled) {
 					showMessage((error && error.message) || "加载配置失败", true);
@@ -113,7 +123,7 @@ function App() {
 				});
 				const data = await response.json().catch(() => ({}));
 				if (!response.ok) {
-					throw new Error(data.error || response.statusText);
+					throw new Error(apiErrorMessage(data) || response.statusText);
 				}
 				if (cancelled) {
 					return;
@@ -206,9 +216,7 @@ function App() {
 				let messageText = response.statusText || "导出配置失败";
 				try {
 					const data = await response.json();
-					if (data && data.error) {
-						messageText = data.error;
-					}
+					messageText = apiErrorMessage(data) || messageText;
 				} catch {
 					try {
 						const text = await response.text();
@@ -300,7 +308,7 @@ function App() {
 					});
 					const data = await response.json().catch(() => ({}));
 					if (!response.ok) {
-						throw new Error(data.error || response.statusText || "导入配置失败");
+						throw new Error(apiErrorMessage(data) || response.statusText || "导入配置失败");
 					}
 					applyConfigPayloadToState(data);
 					showMessage("配置已导入", false);
@@ -340,7 +348,7 @@ function App() {
 				});
 				const data = await response.json().catch(() => ({}));
 				if (!response.ok) {
-					throw new Error(data.error || response.statusText || "保存配置失败");
+					throw new Error(apiErrorMessage(data) || response.statusText || "保存配置失败");
 				}
 				const normalized = normalizeConfigResponse(data);
 				setConfig(normalized);
@@ -380,7 +388,7 @@ function App() {
 				});
 				const data = await response.json().catch(() => ({}));
 				if (!response.ok) {
-					throw new Error(data.error || response.statusText);
+					throw new Error(apiErrorMessage(data) || response.statusText);
 				}
 				setPreview({
 					id: data.id || id,
@@ -420,7 +428,7 @@ function App() {
 			});
 			const data = await response.json().catch(() => ({}));
 			if (!response.ok) {
-				throw new Error(data.error || response.statusText);
+				throw new Error(apiErrorMessage(data) || response.statusText);
 			}
 			const created = typeof data.created === "number" ? data.created : 0;
 			const skipped = Array.isArray(data.skipped) ? data.skipped.length : 0;
@@ -464,9 +472,7 @@ function App() {
 				let messageText = response.statusText || "导出失败";
 				try {
 					const data = await response.json();
-					if (data && data.error) {
-						messageText = data.error;
-					}
+					messageText = apiErrorMessage(data) || messageText;
 				} catch {
 					try {
 						const text = await response.text();
@@ -559,12 +565,60 @@ function App() {
 				});
 				const data = await response.json().catch(() => ({}));
 				if (!response.ok) {
-					throw new Error(data.error || response.statusText);
+					throw new Error(apiErrorMessage(data) || response.statusText);
+				}
+				// 删除在后台任务中逐条执行, 轮询任务进度直至结束; 配置了等待期时任务先处于 pending, 期间可以撤销。
+				const jobId = data.job_id;
+				const undo = {
+					label: "撤销",
+					onClick: () => {
+						fetch("/api/jobs/" + encodeURIComponent(jobId) + "/cancel", { method: "POST" }).catch(() => {});
+						showMessage("正在撤销删除…", false);
+					}
+				};
+				let job = data;
+				let pendingShown = false;
+				for (;;) {
+					if (job.status === "pending" && !pendingShown) {
+						pendingShown = true;
+						const executeAt = job.execute_at ? new Date(job.execute_at).toLocaleTimeString() : "";
+						showMessage("已加入删除队列, 将于 " + executeAt + " 删除 " + ids.length + " 条对话", false, undo);
+						if (type === "bulk") {
+							setBulkDeleteLoading(false);
+						} else {
+							setSingleDeleteLoading(false);
+						}
+					}
+					await new Promise((resolve) => setTimeout(resolve, 1000));
+					const jobResponse = await fetch("/api/jobs/" + encodeURIComponent(jobId), {
+						headers: { Accept: "application/json" }
+					});
+					job = await jobResponse.json().catch(() => ({}));
+					if (!jobResponse.ok) {
+						throw new Error(apiErrorMessage(job) || jobResponse.statusText);
+					}
+					if (job.status === "pending") {
+						continue;
+					}
+					if (job.status !== "running") {
+						break;
+					}
+					const progress = job.progress || {};
+					showMessage("正在删除对话… " + (progress.done || 0) + " / " + (progress.total || ids.length), false);
+				}
+				const outcomes = Array.isArray(job.outcomes) ? job.outcomes : [];
+				const deletedIds = outcomes.filter((item) => item.status === "deleted").map((item) => item.conversation_id);
+				const failedCount = outcomes.filter((item) => item.status === "failed").length;
+				if (deletedIds.length > 0) {
+					adjustAfterDelete(deletedIds, deletedIds.length, type === "single");
+				}
+				if (job.status === "canceled" && outcomes.length === 0) {
+					showMessage("已撤销删除", false);
+				} else if (job.status === "completed" && failedCount === 0) {
+					showMessage("删除成功 " + deletedIds.length + " 条对话", false);
+				} else {
+					showMessage("已删除 " + deletedIds.length + " 条对话" + (failedCount > 0 ? ", 失败 " + failedCount + " 条" : "") + (job.error ? ": " + job.error : ""), true);
 				}
-				const deletedIds = Array.isArray(data.deleted) ? data.deleted : ids;
-				const count = typeof data.count === "number" ? data.count : deletedIds.length;
-				adjustAfterDelete(deletedIds, count, type === "single");
-				showMessage("删除成功 " + count + " 条对话", false);
 			} catch (error) {
 				showMessage(error.message || "删除失败", true);
 			} finally {
@@ -676,6 +730,18 @@ function App() {
 		});
 	}, [conversations, searchTerm]);
 
+	const lockedConfigSections = useMemo(() => {
+		if (lockedConfigKeys.length === 0) {
+			return configSections;
+		}
+		return configSections.map((section) => ({
+			...section,
+			fields: section.fields.map((field) =>
+				lockedConfigKeys.indexOf(field.key) === -1 ? field : { ...field, disabled: true, description: "该配置项已被管理员锁定" }
+			)
+		}));
+	}, [lockedConfigKeys]);
+
 	const handleBackToList = useCallback(() => {
 		setPreview(initialPreview);
 	}, []);
@@ -687,7 +753,7 @@ function App() {
 			{activeTab === "settings" ? (
 				<SettingsPage
 					configDraft={configDraft}
-					configSections={configSections}
+					configSections={lockedConfigSections}
 					handleConfigFieldChange={handleConfigFieldChange}
 					handleConfigSubmit={handleConfigSubmit}
 					handleConfigReset={handleConfigReset}
@@ -1,7 +1,7 @@
 import React, { useMemo, useState } from "react";
 
 function ConfigField({ field, value, onChange }) {
-	const { key, label, type = "text", placeholder, options = [], description, rows = 3, min, max, fullWidth, secureToggle } = field;
+	const { key, label, type = "text", placeholder, options = [], description, rows = 3, min, max, fullWidth, secureToggle, disabled } = field;
 	const fieldClassName = fullWidth ? "form-field full-width" : "form-field";
 	const fieldId = "config-" + key;
 	const [visible, setVisible] = useState(false);
@@ -15,6 +15,7 @@ function ConfigField({ field, value, onChange }) {
 						type="checkbox"
 						checked={!!value}
 						onChange={(event) => onChange(key, event.target.checked)}
+						disabled={disabled}
 					/>
 					<span>{label}</span>
 				</label>
@@ -27,7 +28,7 @@ function ConfigField({ field, value, onChange }) {
 		return (
 			<div className={fieldClassName}>
 				<label htmlFor={fieldId}>{label}</label>
-				<select id={fieldId} value={value == null ? "" : value} onChange={(event) => onChange(key, event.target.value)}>
+				<select id={fieldId} value={value == null ? "" : value} onChange={(event) => onChange(key, event.target.value)} disabled={disabled}>
 					{options.map((option) => (
 						<option key={option.value == null ? "" : option.value} value={option.value == null ? "" : option.value}>
 							{option.label}
@@ -51,6 +52,7 @@ function ConfigField({ field, value, onChange }) {
 						onChange={(event) => onChange(key, event.target.value)}
 						placeholder={placeholder}
 						autoComplete="off"
+						disabled={disabled}
 					/>
 					{secureToggle ? (
 						<button type="button" className="ghost-link" onClick={() => setVisible((prev) => !prev)}>
@@ -73,6 +75,7 @@ function ConfigField({ field, value, onChange }) {
 					value={value == null ? "" : value}
 					onChange={(event) => onChange(key, event.target.value)}
 					placeholder={placeholder}
+					disabled={disabled}
 				/>
 				{description ? <div className="field-hint">{description}</div> : null}
 			</div>
@@ -91,6 +94,7 @@ function ConfigField({ field, value, onChange }) {
 				min={min}
 				max={max}
 				inputMode={type === "number" ? "numeric" : undefined}
+				disabled={disabled}
 			/>
 			{description ? <div className="field-hint">{description}</div> : null}
 		</div>
@@ -1,4 +1,4 @@
-import React from "react";
+import React, { useState } from "react";
 import PreviewMessages from "./PreviewMessages";
 
 const ConversationPreview = ({
@@ -13,6 +13,25 @@ const ConversationPreview = ({
 	handleSingleDelete,
 	singleDeleteLoading,
 }) => {
+	const [copyLabel, setCopyLabel] = useState("");
+
+	const handleCopy = async (format) => {
+		if (!preview.id) {
+			return;
+		}
+		try {
+			const response = await fetch("/api/conversations/" + encodeURIComponent(preview.id) + "/" + format);
+			if (!response.ok) {
+				throw new Error(response.statusText);
+			}
+			await navigator.clipboard.writeText(await response.text());
+			setCopyLabel("已复制");
+		} catch (err) {
+			setCopyLabel("复制失败");
+		}
+		setTimeout(() => setCopyLabel(""), 2000);
+	};
+
 	return (
 		<section className="panel preview-panel">
 			<div className="preview-header">
@@ -27,6 +46,12 @@ const ConversationPreview = ({
 				</div>
 				<div className="preview-actions">
 					<div className="button-group">
+						<button type="button" className="ghost" onClick={() => handleCopy("markdown")} disabled={!preview.id}>
+							{copyLabel || "复制 Markdown"}
+						</button>
+						<button type="button" className="ghost" onClick={() => handleCopy("plaintext")} disabled={!preview.id}>
+							复制纯文本
+						</button>
 						<button type="button" className="secondary" onClick={handleExportZip} disabled={selectedCount === 0 || exportZipLoading}>
 							导出 Markdown
 						</button>
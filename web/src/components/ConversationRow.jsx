@@ -14,8 +14,8 @@ function ConversationRow({ item, checked, active, onToggle, onPreview, previewLo
 	);
 
 	const timeLabel = useMemo(() => {
-		return item.update_time || item.create_time || "-";
-	}, [item.create_time, item.update_time]);
+		return item.update_time_relative || item.update_time || item.create_time || "-";
+	}, [item.create_time, item.update_time, item.update_time_relative]);
 
 	const subtitle = useMemo(() => {
 		if (item.create_time) {
@@ -38,7 +38,7 @@ function ConversationRow({ item, checked, active, onToggle, onPreview, previewLo
 						<div className="conversation-title" title={item.title || "(未命名对话)"}>
 							{item.title || "(未命名对话)"}
 						</div>
-						<div className="conversation-time">{previewLoading ? "加载中…" : timeLabel}</div>
+						<div className="conversation-time" title={item.update_time || undefined}>{previewLoading ? "加载中…" : timeLabel}</div>
 					</div>
 					<div className="conversation-subtitle" title={subtitle}>
 						{subtitle}
@@ -5,6 +5,11 @@ function MessageBar({ message }) {
 	return (
 		<div id="message" className={className}>
 			{message.text}
+			{message.action ? (
+				<button type="button" className="secondary message-action" onClick={message.action.onClick}>
+					{message.action.label}
+				</button>
+			) : null}
 		</div>
 	);
 }
@@ -26,17 +26,38 @@ export const initialConfig = {
 	oai_client_version: "",
 	priority: "",
 	log_path: "",
+	output_path: "",
+	raw_output_path: "",
+	raw_format: "json",
 	anytype_base_url: "",
 	anytype_version: "",
 	anytype_space_id: "",
 	anytype_type_key: "",
+	anytype_upload_files: false,
+	anytype_files_property: "",
 	anytype_token: "",
 	notion_base_url: "",
 	notion_version: "",
 	notion_token: "",
 	notion_parent_type: "",
 	notion_parent_id: "",
-	notion_title_property: ""
+	notion_title_property: "",
+	notion_draft_parent_id: "",
+	notion_draft_type: "",
+	notion_project_field: "",
+	notion_url_field: "",
+	notion_upload_audio: false,
+	notion_upload_files: false,
+	project_tags: false,
+	queue_window: "",
+	ua_rotation: "",
+	ua_rotation_batch: 0,
+	update_check: false,
+	max_message_chars: 0,
+	truncate_keep_full: false,
+	toc_min_messages: 0,
+	delete_interval_ms: 0,
+	delete_grace_minutes: 0
 };
 
 export const initialPreview = {
@@ -83,6 +104,23 @@ export const configSections = [
 			},
 			{ key: "initial_offset", label: "起始 Offset", type: "number", min: 0 },
 			{ key: "include_archived", label: "包含归档对话", type: "checkbox", description: "启用后会请求已归档的对话。" },
+			{ key: "project_tags", label: "按 ChatGPT 项目归类", type: "checkbox", description: "项目中的对话写入 Notion 选择属性、Anytype 标签或压缩包子目录。" },
+			{ key: "queue_window", label: "导出队列时间段 (如 02:00-06:00, 留空不限制)" },
+			{
+				key: "max_message_chars",
+				label: "单条消息最大字符数",
+				type: "number",
+				min: 0,
+				description: "超出时保留开头与结尾，中间注明省略的字符数；0 表示不截断。"
+			},
+			{ key: "truncate_keep_full", label: "截断消息的全文另存为附件", type: "checkbox", description: "仅导出压缩包：全文写入 attachments/ 目录，消息末尾附上链接。" },
+			{
+				key: "toc_min_messages",
+				label: "生成目录的最少消息数",
+				type: "number",
+				min: 0,
+				description: "消息数达到该值时，Markdown/HTML 开头生成目录，Notion 页面插入目录区块；0 表示不生成。"
+			},
 			{
 				key: "target",
 				label: "默认导出目标",
@@ -92,7 +130,18 @@ export const configSections = [
 					{ value: "notion", label: "Notion" }
 				]
 			},
-			{ key: "log_path", label: "导出路径 / 日志文件", placeholder: "chatgpt_export.log", fullWidth: true }
+			{ key: "log_path", label: "导出路径 / 日志文件", placeholder: "chatgpt_export.log", fullWidth: true },
+			{ key: "output_path", label: "Markdown 目录导出的输出目录 (markdown 目标)", placeholder: "backup/markdown", fullWidth: true },
+			{ key: "raw_output_path", label: "原始 JSON 导出的输出目录 (json 目标)", placeholder: "backup/raw", fullWidth: true },
+			{
+				key: "raw_format",
+				label: "原始 JSON 文件格式",
+				type: "select",
+				options: [
+					{ value: "json", label: "完整对话 (.json)" },
+					{ value: "jsonl", label: "逐条消息 (.jsonl)" }
+				]
+			}
 		]
 	},
 	{
@@ -104,6 +153,8 @@ export const configSections = [
 			{ key: "anytype_version", label: "Anytype API 版本" },
 			{ key: "anytype_space_id", label: "Anytype Space ID" },
 			{ key: "anytype_type_key", label: "Anytype 类型 Key" },
+			{ key: "anytype_upload_files", label: "上传文件到 Anytype", type: "checkbox", description: "图片、语音与上传文件保存为文件对象，正文中的文件链接指向这些对象。" },
+			{ key: "anytype_files_property", label: "Anytype 文件属性 (对象类型, 留空不写入)" },
 			{
 				key: "anytype_token",
 				label: "Anytype Token",
@@ -127,11 +178,28 @@ export const configSections = [
 				options: [
 					{ value: "", label: "自动" },
 					{ value: "page", label: "页面 (page)" },
-					{ value: "database", label: "数据库 (database)" }
+					{ value: "database", label: "数据库 (database)" },
+					{ value: "data_source", label: "数据源 (data_source)" }
 				]
 			},
 			{ key: "notion_parent_id", label: "Notion 父级 ID" },
-			{ key: "notion_title_property", label: "Notion 标题属性" }
+			{ key: "notion_title_property", label: "Notion 标题属性" },
+			{ key: "notion_draft_parent_id", label: "Notion 草稿父级 ID (留空直接写入父级)" },
+			{
+				key: "notion_draft_type",
+				label: "Notion 草稿父级类型",
+				type: "select",
+				options: [
+					{ value: "", label: "默认 (页面)" },
+					{ value: "page", label: "页面 (page)" },
+					{ value: "database", label: "数据库 (database)" },
+					{ value: "data_source", label: "数据源 (data_source)" }
+				]
+			},
+			{ key: "notion_project_field", label: "Notion 项目属性 (选择类型, 留空不写入)" },
+			{ key: "notion_url_field", label: "Notion 原始对话属性 (网址类型, 留空不写入)" },
+			{ key: "notion_upload_audio", label: "上传语音到 Notion", type: "checkbox", description: "语音消息以音频区块写入页面；图片始终上传。" },
+			{ key: "notion_upload_files", label: "上传对话中的上传文件到 Notion", type: "checkbox", description: "页面开头的上传文件列表改为可下载的文件区块，文件已过期时保留文件名。" }
 		]
 	},
 	{
@@ -149,6 +217,10 @@ export const configSections = [
 			},
 			{ key: "device_id", label: "Device ID" },
 			{ key: "user_agent", label: "User Agent", fullWidth: true },
+			{ key: "ua_rotation", label: "轮换浏览器请求头 (all 或 chrome-windows、chrome-mac、edge-windows、firefox-mac、safari-mac, 留空不轮换)", fullWidth: true },
+			{ key: "ua_rotation_batch", label: "每组请求头连续使用的请求数 (0 为 50)", type: "number", min: 0 },
+			{ key: "delete_interval_ms", label: "批量删除的请求间隔 (毫秒, 0 为 1000)", type: "number", min: 0 },
+			{ key: "delete_grace_minutes", label: "删除前的等待时间 (分钟, 期间可撤销, 0 为立即删除)", type: "number", min: 0 },
 			{ key: "accept_language", label: "Accept-Language" },
 			{ key: "referer", label: "Referer" },
 			{ key: "cookie", label: "Cookie", type: "textarea", rows: 2, fullWidth: true },
@@ -162,7 +234,8 @@ export const configSections = [
 			{ key: "sec_fetch_site", label: "sec-fetch-site" },
 			{ key: "chatgpt_account_id", label: "ChatGPT Account ID" },
 			{ key: "oai_client_version", label: "OAI Client Version" },
-			{ key: "priority", label: "Priority" }
+			{ key: "priority", label: "Priority" },
+			{ key: "update_check", label: "检查新版本", type: "checkbox", description: "启用后定期从 GitHub 读取最新发布，在 /api/version 与 /api/status 中提示新版本。" }
 		]
 	}
 ];
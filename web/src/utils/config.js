@@ -12,7 +12,7 @@ export function sanitizeOrder(value) {
 
 export function sanitizeParentType(value) {
 	const lower = typeof value === "string" ? value.trim().toLowerCase() : "";
-	if (lower === "page" || lower === "database") {
+	if (lower === "page" || lower === "database" || lower === "data_source") {
 		return lower;
 	}
 	return "";
@@ -78,16 +78,25 @@ export function normalizeConfigResponse(data) {
 		"oai_client_version",
 		"priority",
 		"log_path",
+		"output_path",
+		"raw_output_path",
+		"raw_format",
 		"anytype_base_url",
 		"anytype_version",
 		"anytype_space_id",
 		"anytype_type_key",
+		"anytype_files_property",
 		"anytype_token",
 		"notion_base_url",
 		"notion_version",
 		"notion_token",
 		"notion_parent_id",
-		"notion_title_property"
+		"notion_title_property",
+		"notion_draft_parent_id",
+		"notion_project_field",
+		"notion_url_field",
+		"queue_window",
+		"ua_rotation"
 	];
 	keysToAssign.forEach(assignString);
 
@@ -101,8 +110,30 @@ export function normalizeConfigResponse(data) {
 	const offsetValue = toNumber(data.initial_offset);
 	normalized.initial_offset = typeof offsetValue === "number" && offsetValue >= 0 ? offsetValue : 0;
 
+	const rotationBatch = toNumber(data.ua_rotation_batch);
+	normalized.ua_rotation_batch = typeof rotationBatch === "number" && rotationBatch >= 0 ? rotationBatch : 0;
+
+	const maxMessageChars = toNumber(data.max_message_chars);
+	normalized.max_message_chars = typeof maxMessageChars === "number" && maxMessageChars >= 0 ? maxMessageChars : 0;
+
+	const tocMinMessages = toNumber(data.toc_min_messages);
+	normalized.toc_min_messages = typeof tocMinMessages === "number" && tocMinMessages >= 0 ? tocMinMessages : 0;
+
+	const deleteInterval = toNumber(data.delete_interval_ms);
+	normalized.delete_interval_ms = typeof deleteInterval === "number" && deleteInterval >= 0 ? deleteInterval : 0;
+
+	const deleteGrace = toNumber(data.delete_grace_minutes);
+	normalized.delete_grace_minutes = typeof deleteGrace === "number" && deleteGrace >= 0 ? deleteGrace : 0;
+
 	normalized.include_archived = Boolean(data.include_archived);
+	normalized.project_tags = Boolean(data.project_tags);
+	normalized.update_check = Boolean(data.update_check);
+	normalized.truncate_keep_full = Boolean(data.truncate_keep_full);
+	normalized.notion_upload_audio = Boolean(data.notion_upload_audio);
+	normalized.notion_upload_files = Boolean(data.notion_upload_files);
+	normalized.anytype_upload_files = Boolean(data.anytype_upload_files);
 	normalized.notion_parent_type = sanitizeParentType(data.notion_parent_type);
+	normalized.notion_draft_type = sanitizeParentType(data.notion_draft_type);
 
 	return normalized;
 }
@@ -121,6 +152,9 @@ export function createConfigDraft(config) {
 		max_conversations: String(Math.max(0, typeof maxValue === "number" ? maxValue : 0)),
 		initial_offset: String(Math.max(0, typeof offsetValue === "number" ? offsetValue : 0)),
 		include_archived: !!source.include_archived,
+		project_tags: !!source.project_tags,
+		notion_upload_audio: !!source.notion_upload_audio,
+		notion_upload_files: !!source.notion_upload_files,
 		token: source.token || "",
 		device_id: source.device_id || "",
 		user_agent: source.user_agent || "",
@@ -139,17 +173,35 @@ export function createConfigDraft(config) {
 		oai_client_version: source.oai_client_version || "",
 		priority: source.priority || "",
 		log_path: source.log_path || "",
+		output_path: source.output_path || "",
+		raw_output_path: source.raw_output_path || "",
+		raw_format: source.raw_format || "json",
 		anytype_base_url: source.anytype_base_url || "",
 		anytype_version: source.anytype_version || "",
 		anytype_space_id: source.anytype_space_id || "",
 		anytype_type_key: source.anytype_type_key || "",
+		anytype_upload_files: !!source.anytype_upload_files,
+		anytype_files_property: source.anytype_files_property || "",
 		anytype_token: source.anytype_token || "",
 		notion_base_url: source.notion_base_url || "",
 		notion_version: source.notion_version || "",
 		notion_token: source.notion_token || "",
 		notion_parent_type: sanitizeParentType(source.notion_parent_type),
 		notion_parent_id: source.notion_parent_id || "",
-		notion_title_property: source.notion_title_property || ""
+		notion_title_property: source.notion_title_property || "",
+		notion_draft_parent_id: source.notion_draft_parent_id || "",
+		notion_draft_type: sanitizeParentType(source.notion_draft_type),
+		notion_project_field: source.notion_project_field || "",
+		notion_url_field: source.notion_url_field || "",
+		queue_window: source.queue_window || "",
+		ua_rotation: source.ua_rotation || "",
+		ua_rotation_batch: String(Math.max(0, toNumber(source.ua_rotation_batch) || 0)),
+		update_check: !!source.update_check,
+		max_message_chars: String(Math.max(0, toNumber(source.max_message_chars) || 0)),
+		truncate_keep_full: !!source.truncate_keep_full,
+		toc_min_messages: String(Math.max(0, toNumber(source.toc_min_messages) || 0)),
+		delete_interval_ms: String(Math.max(0, toNumber(source.delete_interval_ms) || 0)),
+		delete_grace_minutes: String(Math.max(0, toNumber(source.delete_grace_minutes) || 0))
 	};
 }
 
@@ -166,6 +218,9 @@ export function prepareConfigPayload(draft) {
 		max_conversations: Math.max(0, typeof maxValue === "number" ? maxValue : 0),
 		initial_offset: Math.max(0, typeof offsetValue === "number" ? offsetValue : 0),
 		include_archived: !!draft.include_archived,
+		project_tags: !!draft.project_tags,
+		notion_upload_audio: !!draft.notion_upload_audio,
+		notion_upload_files: !!draft.notion_upload_files,
 		token: (draft.token || "").trim(),
 		device_id: (draft.device_id || "").trim(),
 		user_agent: (draft.user_agent || "").trim(),
@@ -184,16 +239,34 @@ export function prepareConfigPayload(draft) {
 		oai_client_version: (draft.oai_client_version || "").trim(),
 		priority: (draft.priority || "").trim(),
 		log_path: (draft.log_path || "").trim(),
+		output_path: (draft.output_path || "").trim(),
+		raw_output_path: (draft.raw_output_path || "").trim(),
+		raw_format: draft.raw_format || "json",
 		anytype_base_url: (draft.anytype_base_url || "").trim(),
 		anytype_version: (draft.anytype_version || "").trim(),
 		anytype_space_id: (draft.anytype_space_id || "").trim(),
 		anytype_type_key: (draft.anytype_type_key || "").trim(),
+		anytype_upload_files: !!draft.anytype_upload_files,
+		anytype_files_property: (draft.anytype_files_property || "").trim(),
 		anytype_token: (draft.anytype_token || "").trim(),
 		notion_base_url: (draft.notion_base_url || "").trim(),
 		notion_version: (draft.notion_version || "").trim(),
 		notion_token: (draft.notion_token || "").trim(),
 		notion_parent_type: sanitizeParentType(draft.notion_parent_type),
 		notion_parent_id: (draft.notion_parent_id || "").trim(),
-		notion_title_property: (draft.notion_title_property || "").trim()
+		notion_title_property: (draft.notion_title_property || "").trim(),
+		notion_draft_parent_id: (draft.notion_draft_parent_id || "").trim(),
+		notion_draft_type: sanitizeParentType(draft.notion_draft_type),
+		notion_project_field: (draft.notion_project_field || "").trim(),
+		notion_url_field: (draft.notion_url_field || "").trim(),
+		queue_window: (draft.queue_window || "").trim(),
+		ua_rotation: (draft.ua_rotation || "").trim(),
+		ua_rotation_batch: Math.max(0, toNumber(draft.ua_rotation_batch) || 0),
+		update_check: !!draft.update_check,
+		max_message_chars: Math.max(0, toNumber(draft.max_message_chars) || 0),
+		truncate_keep_full: !!draft.truncate_keep_full,
+		toc_min_messages: Math.max(0, toNumber(draft.toc_min_messages) || 0),
+		delete_interval_ms: Math.max(0, toNumber(draft.delete_interval_ms) || 0),
+		delete_grace_minutes: Math.max(0, toNumber(draft.delete_grace_minutes) || 0)
 	};
 }
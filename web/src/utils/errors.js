@@ -0,0 +1,7 @@
+// apiErrorMessage 把接口返回的 {code, message, detail} 拼成提示文本。
+export function apiErrorMessage(data) {
+	if (!data || typeof data.message !== "string" || data.message === "") {
+		return "";
+	}
+	return data.detail ? `${data.message}: ${data.detail}` : data.message;
+}